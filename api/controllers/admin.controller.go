@@ -0,0 +1,467 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/jobs"
+	"hermit/internal/repositories"
+	"hermit/internal/schema"
+	"hermit/internal/storage"
+	"hermit/internal/vectorizer"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+)
+
+// AdminController handles system administration endpoints.
+type AdminController struct {
+	jobClient     *jobs.Client
+	websiteRepo   *repositories.WebsiteRepository
+	userRepo      *repositories.UserRepository
+	pageRepo      *repositories.PageRepository
+	credsRepo     *repositories.WebsiteCredentialsRepository
+	connectorRepo *repositories.WebsiteConnectorRepository
+	auditRepo     *repositories.AuditLogRepository
+	vectorSvc     *vectorizer.Service
+	storage       *storage.GarageStorage
+	logger        *zap.Logger
+}
+
+// NewAdminController creates a new AdminController.
+func NewAdminController(
+	jobClient *jobs.Client,
+	websiteRepo *repositories.WebsiteRepository,
+	userRepo *repositories.UserRepository,
+	pageRepo *repositories.PageRepository,
+	credsRepo *repositories.WebsiteCredentialsRepository,
+	connectorRepo *repositories.WebsiteConnectorRepository,
+	auditRepo *repositories.AuditLogRepository,
+	vectorSvc *vectorizer.Service,
+	storage *storage.GarageStorage,
+	logger *zap.Logger,
+) *AdminController {
+	return &AdminController{
+		jobClient:     jobClient,
+		websiteRepo:   websiteRepo,
+		userRepo:      userRepo,
+		pageRepo:      pageRepo,
+		credsRepo:     credsRepo,
+		connectorRepo: connectorRepo,
+		auditRepo:     auditRepo,
+		vectorSvc:     vectorSvc,
+		storage:       storage,
+		logger:        logger,
+	}
+}
+
+// statsWindow is the period covered by the page statistics of GetStats.
+const statsWindow = 24 * time.Hour
+
+// SystemStats aggregates system-wide statistics for the ops dashboard.
+type SystemStats struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Users       int       `json:"users"`
+	Websites    struct {
+		Total         int            `json:"total"`
+		ByCrawlStatus map[string]int `json:"by_crawl_status"`
+	} `json:"websites"`
+	// Pages counts pages processed in the last 24 hours
+	Pages struct {
+		Total     int            `json:"total"`
+		Crawled   int            `json:"crawled"`
+		ByStatus  map[string]int `json:"by_status"`
+		ErrorRate float64        `json:"error_rate"`
+	} `json:"pages_last_24h"`
+	Queues map[string]jobs.QueueDepth `json:"queues,omitempty"`
+	// JobFailureRate is the share of tasks processed today that failed
+	JobFailureRate float64        `json:"job_failure_rate"`
+	Vectors        *int           `json:"vectors,omitempty"`
+	Storage        *storage.Usage `json:"storage,omitempty"`
+	// Unavailable lists the sources that could not be reached, their fields
+	// are left empty
+	Unavailable []string `json:"unavailable,omitempty"`
+}
+
+// GetStats godoc
+// @Summary      Get system-wide statistics
+// @Description  Aggregates total users, websites by crawl status, pages processed in the last 24 hours, queue depths, vector counts, storage used and error rates. Sources that cannot be reached are listed in unavailable instead of failing the request. Storage usage lists every object, so the call can be slow on large buckets.
+// @ID           getStats
+// @Tags         Admin
+// @Produce      json
+// @Success      200  {object}  SystemStats
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /admin/stats [get]
+func (ac *AdminController) GetStats(c echo.Context) error {
+	ctx := c.Request().Context()
+	now := time.Now()
+	stats := SystemStats{GeneratedAt: now}
+
+	users, err := ac.userRepo.Count(ctx)
+	if err != nil {
+		return apperrors.Internal("Failed to count users", err)
+	}
+	stats.Users = users
+
+	websites, err := ac.websiteRepo.CountByCrawlStatus(ctx)
+	if err != nil {
+		return apperrors.Internal("Failed to count websites", err)
+	}
+	stats.Websites.ByCrawlStatus = websites
+	for _, count := range websites {
+		stats.Websites.Total += count
+	}
+
+	pages, err := ac.pageRepo.CountAllByStatusSince(ctx, now.Add(-statsWindow))
+	if err != nil {
+		return apperrors.Internal("Failed to count pages", err)
+	}
+	stats.Pages.ByStatus = pages
+	for _, count := range pages {
+		stats.Pages.Total += count
+	}
+	stats.Pages.Crawled = pages["success"]
+	if stats.Pages.Total > 0 {
+		stats.Pages.ErrorRate = float64(pages["error"]) / float64(stats.Pages.Total)
+	}
+
+	queues, err := ac.jobClient.GetQueueDepths()
+	if err != nil {
+		ac.logger.Warn("Failed to get queue depths", zap.Error(err))
+		stats.Unavailable = append(stats.Unavailable, "queues")
+	} else {
+		stats.Queues = queues
+		processed, failed := 0, 0
+		for _, queue := range queues {
+			processed += queue.ProcessedToday
+			failed += queue.FailedToday
+		}
+		if processed > 0 {
+			stats.JobFailureRate = float64(failed) / float64(processed)
+		}
+	}
+
+	vectors, err := ac.vectorSvc.GetTotalVectorCount(ctx)
+	if err != nil {
+		ac.logger.Warn("Failed to count vectors", zap.Error(err))
+		stats.Unavailable = append(stats.Unavailable, "vectors")
+	} else {
+		stats.Vectors = &vectors
+	}
+
+	usage, err := ac.storage.Usage(ctx, "")
+	if err != nil {
+		ac.logger.Warn("Failed to compute storage usage", zap.Error(err))
+		stats.Unavailable = append(stats.Unavailable, "storage")
+	} else {
+		stats.Storage = &usage
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// TriggerGarbageCollection godoc
+// @Summary      Trigger garbage collection
+// @Description  Enqueues a maintenance:gc job that reconciles Postgres with Garage and ChromaDB. Orphans are only reported unless delete=true. The report is stored as the job result.
+// @ID           triggerGarbageCollection
+// @Tags         Admin
+// @Produce      json
+// @Param        delete  query     bool  false  "Delete orphaned objects and chunks"  default(false)
+// @Success      202     {object}  GarbageCollectionResponse
+// @Failure      400     {object}  apperrors.Response
+// @Failure      500     {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /admin/maintenance/gc [post]
+func (ac *AdminController) TriggerGarbageCollection(c echo.Context) error {
+	del := false
+	if param := c.QueryParam("delete"); param != "" {
+		value, err := strconv.ParseBool(param)
+		if err != nil {
+			return apperrors.Validation("Invalid delete parameter")
+		}
+		del = value
+	}
+
+	taskID, err := ac.jobClient.EnqueueGarbageCollect(c.Request().Context(), del)
+	if err != nil {
+		ac.logger.Error("Failed to enqueue gc job", zap.Error(err))
+		return apperrors.Internal("Failed to enqueue garbage collection", err)
+	}
+
+	return c.JSON(http.StatusAccepted, GarbageCollectionResponse{
+		EnqueuedResponse: EnqueuedResponse{
+			Message: "Garbage collection queued",
+			TaskID:  taskID,
+		},
+		Delete: del,
+	})
+}
+
+// ReprocessWebsite godoc
+// @Summary      Reprocess a website from archived HTML
+// @Description  Enqueues a reprocess:website job that runs content extraction and cleaning again with the current code and settings over the raw HTML archived for the website's pages, storing and re-vectorizing only the pages whose text changed, so improvements to the content processor reach already crawled sites without a recrawl. HTML is archived when the server sets ARCHIVE_RAW_HTML. The report is stored as the job result.
+// @ID           reprocessWebsite
+// @Tags         Admin
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      202  {object}  EnqueuedResponse
+// @Failure      400  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      409  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /admin/websites/{id}/reprocess [post]
+func (ac *AdminController) ReprocessWebsite(c echo.Context) error {
+	websiteID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	ctx := c.Request().Context()
+
+	website, err := ac.websiteRepo.GetByID(ctx, uint(websiteID))
+	if err != nil {
+		return apperrors.Internal("Failed to get website", err)
+	}
+	if website == nil {
+		return apperrors.NotFound("Website not found")
+	}
+
+	taskID, err := ac.jobClient.EnqueueReprocessWebsite(ctx, website.ID)
+	if errors.Is(err, jobs.ErrAlreadyQueued) {
+		return apperrors.Conflict("A reprocess of this website is already queued")
+	}
+	if err != nil {
+		ac.logger.Error("Failed to enqueue reprocess job", zap.Uint("websiteID", website.ID), zap.Error(err))
+		return apperrors.Internal("Failed to enqueue reprocess job", err)
+	}
+
+	return c.JSON(http.StatusAccepted, EnqueuedResponse{
+		Message: "Reprocess job enqueued",
+		TaskID:  taskID,
+	})
+}
+
+// RotateEncryptionKeys godoc
+// @Summary      Rotate credential encryption keys
+// @Description  Re-encrypts stored website credentials and connectors with the current CREDENTIALS_ENCRYPTION_KEY. Once done, the keys in CREDENTIALS_ENCRYPTION_PREVIOUS_KEYS can be removed.
+// @ID           rotateEncryptionKeys
+// @Tags         Admin
+// @Produce      json
+// @Success      200  {object}  RotateKeysResponse
+// @Failure      400  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /admin/maintenance/rotate-keys [post]
+func (ac *AdminController) RotateEncryptionKeys(c echo.Context) error {
+	if !ac.credsRepo.Enabled() {
+		return apperrors.Validation("Credentials encryption is not configured")
+	}
+
+	rotated, err := ac.credsRepo.RotateKeys(c.Request().Context())
+	if err == nil {
+		var connectors int
+		connectors, err = ac.connectorRepo.RotateKeys(c.Request().Context())
+		rotated += connectors
+	}
+	if err != nil {
+		ac.logger.Error("Failed to rotate encryption keys", zap.Int("rotated", rotated), zap.Error(err))
+		return apperrors.Internal("Failed to rotate encryption keys", err)
+	}
+
+	ac.logger.Info("Rotated credential encryption keys", zap.Int("rotated", rotated))
+	return c.JSON(http.StatusOK, RotateKeysResponse{
+		Message: "Credentials re-encrypted with the current key",
+		Rotated: rotated,
+	})
+}
+
+// ListAuditLogs godoc
+// @Summary      List the audit log
+// @Description  Lists the mutating API requests recorded in the audit log, most recent first: the user and API key that made them, the action, the resource with its state before and after, the status, IP and user agent. Requests that failed or were refused are recorded too. Secrets such as credentials and request header values are never recorded.
+// @ID           listAuditLogs
+// @Tags         Admin
+// @Produce      json
+// @Param        page           query     int     false  "Page number"     default(1)
+// @Param        limit          query     int     false  "Items per page (max 200)"  default(50)
+// @Param        actor_user_id  query     string  false  "Filter by the user who made the request"
+// @Param        action         query     string  false  "Filter by action, e.g. website.recrawl"
+// @Param        resource_type  query     string  false  "Filter by resource type (user, website, api_key, session, job, queue, system)"
+// @Param        resource_id    query     string  false  "Filter by resource ID"
+// @Param        since          query     string  false  "Only entries at or after this RFC 3339 time"
+// @Param        until          query     string  false  "Only entries before this RFC 3339 time"
+// @Success      200            {object}  PaginatedResponse{data=[]schema.AuditLog}
+// @Failure      400            {object}  apperrors.Response
+// @Failure      500            {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /admin/audit-logs [get]
+func (ac *AdminController) ListAuditLogs(c echo.Context) error {
+	page := 1
+	if pageParam := c.QueryParam("page"); pageParam != "" {
+		if p, err := strconv.Atoi(pageParam); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	limit := 50
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+
+	filter := schema.AuditLogFilter{
+		Action:       c.QueryParam("action"),
+		ResourceType: c.QueryParam("resource_type"),
+		ResourceID:   c.QueryParam("resource_id"),
+	}
+	if param := c.QueryParam("actor_user_id"); param != "" {
+		userID, err := ulid.Parse(param)
+		if err != nil {
+			return apperrors.Validation("Invalid actor_user_id parameter")
+		}
+		filter.ActorUserID = &userID
+	}
+	for name, target := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if param := c.QueryParam(name); param != "" {
+			t, err := time.Parse(time.RFC3339, param)
+			if err != nil {
+				return apperrors.Validation("Invalid " + name + " parameter, expected an RFC 3339 time")
+			}
+			*target = &t
+		}
+	}
+
+	entries, total, err := ac.auditRepo.List(c.Request().Context(), filter, limit, (page-1)*limit)
+	if err != nil {
+		return apperrors.Internal("Failed to list audit log", err)
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       entries,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	})
+}
+
+// AssignOwnerRequest defines the request body for assigning orphaned websites to a user.
+type AssignOwnerRequest struct {
+	UserID     string `json:"user_id" example:"01HQZX3Y4K5M6N7P8Q9R0S1T2V"`
+	WebsiteIDs []uint `json:"website_ids"`                      // Empty assigns all orphaned websites
+	DryRun     *bool  `json:"dry_run,omitempty" example:"true"` // Defaults to true
+}
+
+// OrphanedWebsite is a website without an owner listed in an AssignOwnerReport.
+type OrphanedWebsite struct {
+	ID  uint   `json:"id"`
+	URL string `json:"url"`
+}
+
+// AssignOwnerReport describes the websites affected by an owner assignment.
+type AssignOwnerReport struct {
+	DryRun        bool              `json:"dry_run"`
+	UserID        string            `json:"user_id"`
+	Websites      []OrphanedWebsite `json:"websites"`
+	Matched       int               `json:"matched"`
+	Assigned      int64             `json:"assigned"`
+	Skipped       []uint            `json:"skipped"` // Requested IDs that don't exist or already have an owner
+	WebsiteCount  int               `json:"website_count"`
+	LimitExceeded bool              `json:"limit_exceeded"`
+}
+
+// AssignWebsiteOwner godoc
+// @Summary      Assign orphaned websites to a user
+// @Description  Backfills the owner of websites created before ownership was tracked (user_id NULL). Runs as a dry run unless dry_run=false and reports the affected websites. Websites that already have an owner are never changed. The user's website limit is reported but not enforced.
+// @ID           assignWebsiteOwner
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      AssignOwnerRequest  true  "Owner assignment"
+// @Success      200      {object}  AssignOwnerReport
+// @Failure      400      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /admin/websites/assign-owner [post]
+func (ac *AdminController) AssignWebsiteOwner(c echo.Context) error {
+	var req AssignOwnerRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	userID, err := ulid.Parse(req.UserID)
+	if err != nil {
+		return apperrors.Validation("Invalid user ID")
+	}
+
+	ctx := c.Request().Context()
+
+	user, err := ac.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		ac.logger.Warn("Failed to get user for owner assignment", zap.String("userID", req.UserID), zap.Error(err))
+		return apperrors.NotFound("User not found")
+	}
+
+	ids := make([]int64, 0, len(req.WebsiteIDs))
+	for _, id := range req.WebsiteIDs {
+		ids = append(ids, int64(id))
+	}
+
+	websites, err := ac.websiteRepo.ListOrphaned(ctx, ids)
+	if err != nil {
+		ac.logger.Error("Failed to list orphaned websites", zap.Error(err))
+		return apperrors.Internal("Failed to list orphaned websites", err)
+	}
+
+	websiteCount, err := ac.userRepo.GetWebsiteCount(ctx, userID)
+	if err != nil {
+		return apperrors.Internal("Failed to get website count", err)
+	}
+
+	report := AssignOwnerReport{
+		DryRun:   req.DryRun == nil || *req.DryRun,
+		UserID:   userID.String(),
+		Websites: make([]OrphanedWebsite, 0, len(websites)),
+		Matched:  len(websites),
+		Skipped:  []uint{},
+	}
+
+	found := make(map[uint]bool, len(websites))
+	matchedIDs := make([]int64, 0, len(websites))
+	for _, website := range websites {
+		report.Websites = append(report.Websites, OrphanedWebsite{ID: website.ID, URL: website.URL})
+		found[website.ID] = true
+		matchedIDs = append(matchedIDs, int64(website.ID))
+	}
+	for _, id := range req.WebsiteIDs {
+		if !found[id] {
+			report.Skipped = append(report.Skipped, id)
+		}
+	}
+
+	report.WebsiteCount = websiteCount + len(websites)
+	if !report.DryRun && len(matchedIDs) > 0 {
+		// Websites claimed concurrently are skipped by AssignOwner
+		report.Assigned, err = ac.websiteRepo.AssignOwner(ctx, userID, matchedIDs)
+		if err != nil {
+			ac.logger.Error("Failed to assign website owner", zap.String("userID", req.UserID), zap.Error(err))
+			return apperrors.Internal("Failed to assign websites", err)
+		}
+		report.WebsiteCount = websiteCount + int(report.Assigned)
+
+		ac.logger.Info("Assigned orphaned websites",
+			zap.String("userID", req.UserID),
+			zap.Int64("assigned", report.Assigned),
+		)
+	}
+	report.LimitExceeded = report.WebsiteCount > user.WebsiteLimit
+
+	return c.JSON(http.StatusOK, report)
+}
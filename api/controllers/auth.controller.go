@@ -2,8 +2,10 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
 
 	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
 	"hermit/internal/auth"
 	"hermit/internal/schema"
 
@@ -23,47 +25,49 @@ func NewAuthController(authService *auth.Service) *AuthController {
 	}
 }
 
-// Register handles user registration
-// POST /api/v1/auth/register
+// Register godoc
+// @Summary      Register a user
+// @Description  Creates an account and a default API key. The key is only returned once.
+// @ID           register
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        user  body      schema.CreateUserRequest  true  "Email and password"
+// @Success      201   {object}  schema.LoginResponse
+// @Failure      400   {object}  apperrors.Response
+// @Failure      409   {object}  apperrors.Response
+// @Failure      500   {object}  apperrors.Response
+// @Router       /auth/register [post]
 func (ctrl *AuthController) Register(c echo.Context) error {
 	var req schema.CreateUserRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
-		})
+		return apperrors.Validation("invalid request body")
 	}
 
-	// Validate request
-	if req.Email == "" || req.Password == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "email and password are required",
-		})
+	if err := c.Validate(&req); err != nil {
+		return err
 	}
 
 	// Register user
-	user, err := ctrl.authService.Register(req.Email, req.Password)
+	user, err := ctrl.authService.Register(c.Request().Context(), req.Email, req.Password)
 	if err != nil {
-		if err.Error() == "email already registered" {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": err.Error(),
-			})
+		if apperrors.Is(err, apperrors.CodeConflict) {
+			return err
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "failed to register user",
-		})
+		return apperrors.Internal("failed to register user", err)
 	}
+	middlewares.SetAuditResource(c, user.ID.String())
 
 	// Create default API key for the user
 	_, plainKey, err := ctrl.authService.CreateAPIKey(
+		c.Request().Context(),
 		user.ID,
 		"Default API Key",
 		[]string{},
 		nil,
 	)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "user created but failed to generate API key",
-		})
+		return apperrors.Internal("user created but failed to generate API key", err)
 	}
 
 	return c.JSON(http.StatusCreated, schema.LoginResponse{
@@ -73,42 +77,56 @@ func (ctrl *AuthController) Register(c echo.Context) error {
 	})
 }
 
-// Login handles user login
-// POST /api/v1/auth/login
+// Login godoc
+// @Summary      Log in
+// @Description  Checks the credentials and creates a new API key for the session. Repeated failures lock the account and the IP out for a while; the Retry-After header then tells when to try again.
+// @ID           login
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        credentials  body      schema.LoginRequest  true  "Email and password"
+// @Success      200          {object}  schema.LoginResponse
+// @Failure      400          {object}  apperrors.Response
+// @Failure      401          {object}  apperrors.Response
+// @Failure      429          {object}  apperrors.Response
+// @Failure      500          {object}  apperrors.Response
+// @Router       /auth/login [post]
 func (ctrl *AuthController) Login(c echo.Context) error {
 	var req schema.LoginRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
-		})
+		return apperrors.Validation("invalid request body")
 	}
 
-	// Validate request
-	if req.Email == "" || req.Password == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "email and password are required",
-		})
+	if err := c.Validate(&req); err != nil {
+		return err
 	}
 
 	// Login user
-	user, err := ctrl.authService.Login(req.Email, req.Password)
+	user, err := ctrl.authService.Login(c.Request().Context(), req.Email, req.Password, c.RealIP(), c.Request().UserAgent())
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "invalid credentials",
-		})
+		if appErr, ok := apperrors.As(err); ok && appErr.Code == apperrors.CodeQuotaExceeded {
+			if retryAfter, ok := appErr.Details["retry_after_seconds"].(int); ok {
+				c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			}
+			return err
+		}
+		// Don't reveal whether the account exists or is inactive
+		if apperrors.Is(err, apperrors.CodeUnauthorized) || apperrors.Is(err, apperrors.CodeForbidden) {
+			return apperrors.Unauthorized("invalid credentials")
+		}
+		return apperrors.Internal("failed to log in", err)
 	}
 
 	// Create a new session API key
 	_, plainKey, err := ctrl.authService.CreateAPIKey(
+		c.Request().Context(),
 		user.ID,
 		"Session Key",
 		[]string{},
 		nil,
 	)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "login successful but failed to generate API key",
-		})
+		return apperrors.Internal("login successful but failed to generate API key", err)
 	}
 
 	return c.JSON(http.StatusOK, schema.LoginResponse{
@@ -118,55 +136,118 @@ func (ctrl *AuthController) Login(c echo.Context) error {
 	})
 }
 
-// GetMe returns the authenticated user's information
-// GET /api/v1/auth/me
+// GetMe godoc
+// @Summary      Get the current user
+// @Description  Returns the user the API key belongs to.
+// @ID           getMe
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  schema.UserResponse
+// @Failure      401  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /auth/me [get]
 func (ctrl *AuthController) GetMe(c echo.Context) error {
 	user := middlewares.GetUser(c)
 	if user == nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "authentication required",
-		})
+		return apperrors.Unauthorized("authentication required")
 	}
 
 	return c.JSON(http.StatusOK, user.ToResponse())
 }
 
-// CreateAPIKey creates a new API key for the authenticated user
-// POST /api/v1/auth/api-keys
+// GetLoginActivity godoc
+// @Summary      Get login activity
+// @Description  Returns the most recent login attempts on the account, failed ones included.
+// @ID           getLoginActivity
+// @Tags         Auth
+// @Produce      json
+// @Param        limit  query     int  false  "Number of attempts (max 100)"  default(20)
+// @Success      200    {object}  LoginActivityResponse
+// @Failure      401    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /auth/login-activity [get]
+func (ctrl *AuthController) GetLoginActivity(c echo.Context) error {
+	userID, err := middlewares.GetUserID(c)
+	if err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	limit := 20
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	attempts, err := ctrl.authService.GetLoginActivity(c.Request().Context(), userID, limit)
+	if err != nil {
+		return apperrors.Internal("failed to retrieve login activity", err)
+	}
+
+	return c.JSON(http.StatusOK, LoginActivityResponse{
+		Attempts: attempts,
+		Count:    len(attempts),
+	})
+}
+
+// ListScopes godoc
+// @Summary      List API key scopes
+// @Description  Documents the scope grammar accepted for API keys.
+// @ID           listScopes
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  schema.ScopeGrammar
+// @Router       /auth/scopes [get]
+func (ctrl *AuthController) ListScopes(c echo.Context) error {
+	return c.JSON(http.StatusOK, schema.ScopeDocumentation)
+}
+
+// CreateAPIKey godoc
+// @Summary      Create an API key
+// @Description  Creates an API key for the current user. The key is only returned once.
+// @ID           createAPIKey
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        key  body      schema.CreateAPIKeyRequest  true  "Name, scopes and expiry"
+// @Success      201  {object}  schema.CreateAPIKeyResponse
+// @Failure      400  {object}  apperrors.Response
+// @Failure      401  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /auth/api-keys [post]
 func (ctrl *AuthController) CreateAPIKey(c echo.Context) error {
 	userID, err := middlewares.GetUserID(c)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "authentication required",
-		})
+		return apperrors.Unauthorized("authentication required")
 	}
 
 	var req schema.CreateAPIKeyRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
-		})
+		return apperrors.Validation("invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
 	}
 
-	// Validate request
-	if req.Name == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "name is required",
-		})
+	if err := schema.ValidateScopes(req.Scopes); err != nil {
+		return apperrors.Validation(err.Error())
 	}
 
 	// Create API key
 	apiKey, plainKey, err := ctrl.authService.CreateAPIKey(
+		c.Request().Context(),
 		userID,
 		req.Name,
 		req.Scopes,
 		req.ExpiresAt,
 	)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "failed to create API key",
-		})
+		return apperrors.Internal("failed to create API key", err)
 	}
+	middlewares.SetAuditResource(c, apiKey.ID.String())
 
 	return c.JSON(http.StatusCreated, schema.CreateAPIKeyResponse{
 		APIKey:   apiKey,
@@ -175,21 +256,26 @@ func (ctrl *AuthController) CreateAPIKey(c echo.Context) error {
 	})
 }
 
-// ListAPIKeys returns all API keys for the authenticated user
-// GET /api/v1/auth/api-keys
+// ListAPIKeys godoc
+// @Summary      List API keys
+// @Description  Returns the API keys of the current user without their secret.
+// @ID           listAPIKeys
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  APIKeyListResponse
+// @Failure      401  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /auth/api-keys [get]
 func (ctrl *AuthController) ListAPIKeys(c echo.Context) error {
 	userID, err := middlewares.GetUserID(c)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "authentication required",
-		})
+		return apperrors.Unauthorized("authentication required")
 	}
 
-	apiKeys, err := ctrl.authService.GetUserAPIKeys(userID)
+	apiKeys, err := ctrl.authService.GetUserAPIKeys(c.Request().Context(), userID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "failed to retrieve API keys",
-		})
+		return apperrors.Internal("failed to retrieve API keys", err)
 	}
 
 	// Convert to response format
@@ -198,35 +284,41 @@ func (ctrl *AuthController) ListAPIKeys(c echo.Context) error {
 		responses = append(responses, key.ToResponse())
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"api_keys": responses,
-		"count":    len(responses),
+	return c.JSON(http.StatusOK, APIKeyListResponse{
+		APIKeys: responses,
+		Count:   len(responses),
 	})
 }
 
-// GetAPIKey returns a specific API key by ID
-// GET /api/v1/auth/api-keys/:id
+// GetAPIKey godoc
+// @Summary      Get an API key
+// @Description  Returns an API key of the current user without its secret.
+// @ID           getAPIKey
+// @Tags         Auth
+// @Produce      json
+// @Param        id   path      string  true  "API key ID"
+// @Success      200  {object}  schema.APIKeyResponse
+// @Failure      400  {object}  apperrors.Response
+// @Failure      401  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /auth/api-keys/{id} [get]
 func (ctrl *AuthController) GetAPIKey(c echo.Context) error {
 	userID, err := middlewares.GetUserID(c)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "authentication required",
-		})
+		return apperrors.Unauthorized("authentication required")
 	}
 
 	keyID, err := ulid.Parse(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid API key ID",
-		})
+		return apperrors.Validation("invalid API key ID")
 	}
 
 	// Get all user's API keys and find the matching one
-	apiKeys, err := ctrl.authService.GetUserAPIKeys(userID)
+	apiKeys, err := ctrl.authService.GetUserAPIKeys(c.Request().Context(), userID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "failed to retrieve API key",
-		})
+		return apperrors.Internal("failed to retrieve API key", err)
 	}
 
 	for _, key := range apiKeys {
@@ -235,37 +327,52 @@ func (ctrl *AuthController) GetAPIKey(c echo.Context) error {
 		}
 	}
 
-	return c.JSON(http.StatusNotFound, map[string]string{
-		"error": "API key not found",
-	})
+	return apperrors.NotFound("API key not found")
 }
 
-// UpdateAPIKey updates an API key
-// PUT /api/v1/auth/api-keys/:id
+// UpdateAPIKey godoc
+// @Summary      Update an API key
+// @Description  Renames, rescopes, deactivates or changes the expiry of an API key of the current user.
+// @ID           updateAPIKey
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string                      true  "API key ID"
+// @Param        key  body      schema.UpdateAPIKeyRequest  true  "Fields to update"
+// @Success      200  {object}  schema.APIKeyResponse
+// @Failure      400  {object}  apperrors.Response
+// @Failure      401  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /auth/api-keys/{id} [put]
 func (ctrl *AuthController) UpdateAPIKey(c echo.Context) error {
 	userID, err := middlewares.GetUserID(c)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "authentication required",
-		})
+		return apperrors.Unauthorized("authentication required")
 	}
 
 	keyID, err := ulid.Parse(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid API key ID",
-		})
+		return apperrors.Validation("invalid API key ID")
 	}
 
 	var req schema.UpdateAPIKeyRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
-		})
+		return apperrors.Validation("invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	if err := schema.ValidateScopes(req.Scopes); err != nil {
+		return apperrors.Validation(err.Error())
 	}
 
 	// Update API key
 	apiKey, err := ctrl.authService.UpdateAPIKey(
+		c.Request().Context(),
 		keyID,
 		userID,
 		req.Name,
@@ -274,60 +381,154 @@ func (ctrl *AuthController) UpdateAPIKey(c echo.Context) error {
 		req.ExpiresAt,
 	)
 	if err != nil {
-		if err.Error() == "unauthorized" {
-			return c.JSON(http.StatusForbidden, map[string]string{
-				"error": "you don't have permission to update this API key",
-			})
+		if _, ok := apperrors.As(err); ok {
+			return err
 		}
-		if err.Error() == "API key not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "API key not found",
-			})
-		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "failed to update API key",
-		})
+		return apperrors.Internal("failed to update API key", err)
 	}
 
 	return c.JSON(http.StatusOK, apiKey.ToResponse())
 }
 
-// RevokeAPIKey revokes (deletes) an API key
-// DELETE /api/v1/auth/api-keys/:id
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  Deletes an API key of the current user.
+// @ID           revokeAPIKey
+// @Tags         Auth
+// @Produce      json
+// @Param        id   path      string  true  "API key ID"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  apperrors.Response
+// @Failure      401  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /auth/api-keys/{id} [delete]
 func (ctrl *AuthController) RevokeAPIKey(c echo.Context) error {
 	userID, err := middlewares.GetUserID(c)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "authentication required",
-		})
+		return apperrors.Unauthorized("authentication required")
 	}
 
 	keyID, err := ulid.Parse(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid API key ID",
-		})
+		return apperrors.Validation("invalid API key ID")
 	}
 
 	// Revoke API key
-	err = ctrl.authService.RevokeAPIKey(keyID, userID)
+	err = ctrl.authService.RevokeAPIKey(c.Request().Context(), keyID, userID)
 	if err != nil {
-		if err.Error() == "unauthorized" {
-			return c.JSON(http.StatusForbidden, map[string]string{
-				"error": "you don't have permission to revoke this API key",
-			})
+		if _, ok := apperrors.As(err); ok {
+			return err
 		}
-		if err.Error() == "API key not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "API key not found",
-			})
+		return apperrors.Internal("failed to revoke API key", err)
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{
+		Message: "API key revoked successfully",
+	})
+}
+
+// ListSessions godoc
+// @Summary      List web sessions
+// @Description  Returns the active web sessions of the current user with the device they were created on.
+// @ID           listSessions
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  SessionListResponse
+// @Failure      401  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /auth/sessions [get]
+func (ctrl *AuthController) ListSessions(c echo.Context) error {
+	userID, err := middlewares.GetUserID(c)
+	if err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	sessions, err := ctrl.authService.GetUserSessions(c.Request().Context(), userID)
+	if err != nil {
+		return apperrors.Internal("failed to retrieve sessions", err)
+	}
+
+	current := middlewares.GetAPIKey(c)
+	responses := make([]*schema.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, session.ToSessionResponse(current != nil && current.ID == session.ID))
+	}
+
+	return c.JSON(http.StatusOK, SessionListResponse{
+		Sessions: responses,
+		Count:    len(responses),
+	})
+}
+
+// RevokeSession godoc
+// @Summary      Revoke a web session
+// @Description  Signs a web session of the current user out.
+// @ID           revokeSession
+// @Tags         Auth
+// @Produce      json
+// @Param        id   path      string  true  "Session ID"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  apperrors.Response
+// @Failure      401  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /auth/sessions/{id} [delete]
+func (ctrl *AuthController) RevokeSession(c echo.Context) error {
+	userID, err := middlewares.GetUserID(c)
+	if err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	sessionID, err := ulid.Parse(c.Param("id"))
+	if err != nil {
+		return apperrors.Validation("invalid session ID")
+	}
+
+	if err := ctrl.authService.RevokeSession(c.Request().Context(), sessionID, userID); err != nil {
+		if _, ok := apperrors.As(err); ok {
+			return err
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "failed to revoke API key",
-		})
+		return apperrors.Internal("failed to revoke session", err)
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{
+		Message: "Session revoked successfully",
+	})
+}
+
+// RevokeSessions godoc
+// @Summary      Revoke other web sessions
+// @Description  Signs out all web sessions of the current user but the one the request was made with.
+// @ID           revokeSessions
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  RevokeSessionsResponse
+// @Failure      401  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /auth/sessions [delete]
+func (ctrl *AuthController) RevokeSessions(c echo.Context) error {
+	userID, err := middlewares.GetUserID(c)
+	if err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	var current *ulid.ULID
+	if key := middlewares.GetAPIKey(c); key != nil {
+		current = &key.ID
+	}
+
+	revoked, err := ctrl.authService.RevokeSessions(c.Request().Context(), userID, current)
+	if err != nil {
+		return apperrors.Internal("failed to revoke sessions", err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "API key revoked successfully",
+	return c.JSON(http.StatusOK, RevokeSessionsResponse{
+		Message: "Sessions revoked successfully",
+		Revoked: revoked,
 	})
 }
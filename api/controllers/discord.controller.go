@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/discord"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// maxDiscordBody limits the size of requests from Discord.
+const maxDiscordBody = 1 << 20
+
+// DiscordController handles the interactions of the Discord app.
+type DiscordController struct {
+	bot    *discord.Bot
+	logger *zap.Logger
+}
+
+// NewDiscordController creates a new DiscordController. A nil bot disables
+// the Discord endpoint.
+func NewDiscordController(bot *discord.Bot, logger *zap.Logger) *DiscordController {
+	return &DiscordController{bot: bot, logger: logger}
+}
+
+// HandleInteraction godoc
+// @Summary      Discord interactions
+// @Description  Receives the interactions of the Discord app. The /ask command is answered from the website linked to the channel, or to the parent channel of a thread; the answer is edited in as it streams. Follow-up questions in a thread are answered in the context of the thread. Requests must be signed with the key in DISCORD_PUBLIC_KEY.
+// @ID           discordInteraction
+// @Tags         Discord
+// @Accept       json
+// @Produce      json
+// @Param        interaction  body      discord.Interaction  true  "Interaction"
+// @Success      200          {object}  discord.InteractionResponse
+// @Failure      400          {object}  apperrors.Response
+// @Failure      401          {object}  apperrors.Response
+// @Failure      501          {object}  apperrors.Response
+// @Router       /discord/interactions [post]
+func (dc *DiscordController) HandleInteraction(c echo.Context) error {
+	if dc.bot == nil {
+		return apperrors.New(apperrors.CodeNotImplemented, "The Discord app is not enabled on this server")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxDiscordBody))
+	if err != nil {
+		return apperrors.Validation("Failed to read request body")
+	}
+	if err := dc.bot.Verify(c.Request().Header, body); err != nil {
+		return apperrors.Unauthorized("invalid Discord signature")
+	}
+
+	var interaction discord.Interaction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	switch interaction.Type {
+	case discord.InteractionPing:
+		return c.JSON(http.StatusOK, discord.InteractionResponse{Type: discord.ResponsePong})
+	case discord.InteractionApplicationCommand:
+	default:
+		return apperrors.Validation("Unsupported interaction type")
+	}
+
+	if strings.TrimSpace(interaction.Option("question")) == "" {
+		return c.JSON(http.StatusOK, discord.EphemeralMessage("Ask a question about the website linked to this channel, e.g. `/ask How do I get started?`"))
+	}
+
+	// Discord expects a response within 3 seconds, defer it and answer in
+	// the background
+	if _, _, err := dc.bot.Resolve(c.Request().Context(), &interaction); err != nil {
+		appErr, _ := apperrors.As(err)
+		if appErr.Code == apperrors.CodeInternal {
+			dc.logger.Error("Failed to resolve Discord channel", zap.String("channel", interaction.ChannelID), zap.Error(err))
+		}
+		return c.JSON(http.StatusOK, discord.EphemeralMessage(appErr.Message))
+	}
+	go dc.bot.Answer(context.WithoutCancel(c.Request().Context()), &interaction)
+
+	return c.JSON(http.StatusOK, discord.InteractionResponse{Type: discord.ResponseDeferredChannelMessage})
+}
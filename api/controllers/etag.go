@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// notModified sets a weak ETag derived from parts on the response and
+// reports whether the request's If-None-Match header already has it, in which
+// case the handler replies 304 Not Modified instead of building the body.
+// Parts must identify everything the response depends on, including the user
+// when it is filtered by user.
+func notModified(c echo.Context, parts ...any) bool {
+	data, err := json.Marshal(parts)
+	if err != nil {
+		return false
+	}
+	sum := sha256.Sum256(data)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderCacheControl, "private, no-cache")
+	header.Set("ETag", etag)
+	header.Add(echo.HeaderVary, echo.HeaderAuthorization)
+
+	return etagMatches(c.Request().Header.Get("If-None-Match"), etag)
+}
+
+// etagMatches reports whether an If-None-Match header matches etag using the
+// weak comparison.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 
 	"hermit/internal/config"
 	"hermit/internal/database"
+	"hermit/internal/resilience"
 	"hermit/internal/storage"
 
 	"github.com/jmoiron/sqlx"
@@ -20,6 +21,7 @@ type HealthController struct {
 	db       *sqlx.DB
 	storage  *storage.GarageStorage
 	chromaDB *database.ChromaDBClient
+	breakers *resilience.Registry
 	config   *config.Config
 }
 
@@ -29,6 +31,7 @@ func NewHealthController(
 	db *sqlx.DB,
 	storage *storage.GarageStorage,
 	chromaDB *database.ChromaDBClient,
+	breakers *resilience.Registry,
 	cfg *config.Config,
 ) *HealthController {
 	return &HealthController{
@@ -36,6 +39,7 @@ func NewHealthController(
 		db:       db,
 		storage:  storage,
 		chromaDB: chromaDB,
+		breakers: breakers,
 		config:   cfg,
 	}
 }
@@ -45,6 +49,8 @@ type HealthResponse struct {
 	Status    string                   `json:"status"`
 	Timestamp string                   `json:"timestamp"`
 	Services  map[string]ServiceHealth `json:"services"`
+	// Breakers are the circuit breakers guarding calls to dependencies
+	Breakers []resilience.BreakerStatus `json:"breakers"`
 }
 
 // ServiceHealth represents the health of a service.
@@ -56,7 +62,8 @@ type ServiceHealth struct {
 
 // GetHealth handles GET /health
 // @Summary Health check
-// @Description Check health of all services
+// @Description Check health of all services and report the state of the circuit breakers guarding them
+// @ID           getHealth
 // @Tags health
 // @Accept json
 // @Produce json
@@ -101,6 +108,14 @@ func (h *HealthController) GetHealth(c echo.Context) error {
 		response.Status = "degraded"
 	}
 
+	// An open breaker means callers are currently turned away
+	response.Breakers = h.breakers.Status()
+	for _, breaker := range response.Breakers {
+		if breaker.State == resilience.StateOpen && response.Status == "healthy" {
+			response.Status = "degraded"
+		}
+	}
+
 	statusCode := http.StatusOK
 	if response.Status == "unhealthy" {
 		statusCode = http.StatusServiceUnavailable
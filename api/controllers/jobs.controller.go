@@ -1,8 +1,17 @@
 package controllers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/jobs"
+	"hermit/internal/repositories"
 
 	"github.com/hibiken/asynq"
 	"github.com/labstack/echo/v4"
@@ -11,12 +20,16 @@ import (
 
 // JobsController handles job management endpoints.
 type JobsController struct {
-	logger    *zap.Logger
-	inspector *asynq.Inspector
+	logger     *zap.Logger
+	inspector  *asynq.Inspector
+	policy     jobs.ScalePolicy
+	queueStats *repositories.QueueStatsRepository
+	jobClient  *jobs.Client
 }
 
-// NewJobsController creates a new JobsController.
-func NewJobsController(logger *zap.Logger, redisURL string) (*JobsController, error) {
+// NewJobsController creates a new JobsController. policy holds the workers'
+// concurrency bounds used to derive the target concurrency.
+func NewJobsController(logger *zap.Logger, redisURL string, policy jobs.ScalePolicy, queueStats *repositories.QueueStatsRepository, jobClient *jobs.Client) (*JobsController, error) {
 	opt, err := asynq.ParseRedisURI(redisURL)
 	if err != nil {
 		return nil, err
@@ -25,8 +38,11 @@ func NewJobsController(logger *zap.Logger, redisURL string) (*JobsController, er
 	inspector := asynq.NewInspector(opt)
 
 	return &JobsController{
-		logger:    logger,
-		inspector: inspector,
+		logger:     logger,
+		inspector:  inspector,
+		policy:     policy,
+		queueStats: queueStats,
+		jobClient:  jobClient,
 	}, nil
 }
 
@@ -42,6 +58,8 @@ type JobInfo struct {
 	Payload       map[string]interface{} `json:"payload,omitempty"`
 	CompletedAt   string                 `json:"completed_at,omitempty"`
 	NextProcessAt string                 `json:"next_process_at,omitempty"`
+	// Progress is the last progress reported by an active job
+	Progress *jobs.Progress `json:"progress,omitempty"`
 }
 
 // QueueStats represents statistics for a queue.
@@ -60,16 +78,18 @@ type QueueStats struct {
 // ListQueues godoc
 // @Summary      List all job queues
 // @Description  Get statistics for all job queues
+// @ID           listQueues
 // @Tags         Jobs
 // @Produce      json
 // @Success      200  {array}   QueueStats
-// @Failure      500  {object}  map[string]string
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /jobs/queues [get]
 func (jc *JobsController) ListQueues(c echo.Context) error {
 	queues, err := jc.inspector.Queues()
 	if err != nil {
 		jc.logger.Error("Failed to list queues", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list queues"})
+		return apperrors.Internal("Failed to list queues", err)
 	}
 
 	var stats []QueueStats
@@ -96,15 +116,92 @@ func (jc *JobsController) ListQueues(c echo.Context) error {
 	return c.JSON(http.StatusOK, stats)
 }
 
+// GetPressure godoc
+// @Summary      Get queue pressure
+// @Description  Get the depth and latency of every queue along with the concurrency a worker needs to absorb the load within its scaling bounds. Workers expose the same signals as Prometheus gauges on /metrics of their health port.
+// @ID           getPressure
+// @Tags         Jobs
+// @Produce      json
+// @Success      200  {object}  jobs.Pressure
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /jobs/pressure [get]
+func (jc *JobsController) GetPressure(c echo.Context) error {
+	pressure, err := jobs.CollectPressure(jc.inspector, jc.policy)
+	if err != nil {
+		jc.logger.Error("Failed to collect queue pressure", zap.Error(err))
+		return apperrors.Internal("Failed to collect queue pressure", err)
+	}
+
+	return c.JSON(http.StatusOK, pressure)
+}
+
+// Windows of the queue history
+const (
+	defaultHistoryWindow = 24 * time.Hour
+	maxHistoryWindow     = 90 * 24 * time.Hour
+)
+
+// GetQueueHistory godoc
+// @Summary      Get queue history
+// @Description  Get the backlog and throughput of a queue over a window as a time series for charts, from the samples workers store every QUEUE_STATS_INTERVAL seconds and keep for QUEUE_STATS_RETENTION hours. The window is split in at most 288 steps of at least a minute; each point has the queue depths of the last sample of its step and the tasks processed during it. Steps without samples are left out.
+// @ID           getQueueHistory
+// @Tags         Jobs
+// @Produce      json
+// @Param        queue   path      string  true   "Queue name"
+// @Param        window  query     string  false  "Window until now, e.g. 30m, 24h or 7d, at most 90d"  default(24h)
+// @Success      200     {object}  jobs.QueueHistory
+// @Failure      400     {object}  apperrors.Response
+// @Failure      500     {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /jobs/queues/{queue}/history [get]
+func (jc *JobsController) GetQueueHistory(c echo.Context) error {
+	queue := c.Param("queue")
+
+	window := defaultHistoryWindow
+	if param := c.QueryParam("window"); param != "" {
+		parsed, err := parseWindow(param)
+		if err != nil || parsed < time.Minute || parsed > maxHistoryWindow {
+			return apperrors.Validation("window must be a duration between 1m and 90d, e.g. 24h or 7d")
+		}
+		window = parsed
+	}
+
+	// Load one step before the window as the baseline of the counters
+	now := time.Now()
+	since := jobs.HistoryStart(window, now).Add(-jobs.HistoryStep(window))
+	samples, err := jc.queueStats.ListSince(c.Request().Context(), queue, since)
+	if err != nil {
+		jc.logger.Error("Failed to get queue history", zap.String("queue", queue), zap.Error(err))
+		return apperrors.Internal("Failed to get queue history", err)
+	}
+
+	return c.JSON(http.StatusOK, jobs.NewQueueHistory(queue, window, samples, now))
+}
+
+// parseWindow parses a duration, also accepting whole days such as 7d.
+func parseWindow(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
 // ListPendingJobs godoc
 // @Summary      List pending jobs
 // @Description  Get all pending jobs in a queue
+// @ID           listPendingJobs
 // @Tags         Jobs
 // @Produce      json
 // @Param        queue  query     string  false  "Queue name"  default(default)
 // @Param        limit  query     int     false  "Limit"       default(50)
 // @Success      200    {array}   JobInfo
-// @Failure      500    {object}  map[string]string
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /jobs/pending [get]
 func (jc *JobsController) ListPendingJobs(c echo.Context) error {
 	queue := c.QueryParam("queue")
@@ -122,7 +219,7 @@ func (jc *JobsController) ListPendingJobs(c echo.Context) error {
 	tasks, err := jc.inspector.ListPendingTasks(queue, asynq.PageSize(limit))
 	if err != nil {
 		jc.logger.Error("Failed to list pending tasks", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list pending tasks"})
+		return apperrors.Internal("Failed to list pending tasks", err)
 	}
 
 	var jobs []JobInfo
@@ -143,12 +240,14 @@ func (jc *JobsController) ListPendingJobs(c echo.Context) error {
 // ListActiveJobs godoc
 // @Summary      List active jobs
 // @Description  Get all active (running) jobs in a queue
+// @ID           listActiveJobs
 // @Tags         Jobs
 // @Produce      json
 // @Param        queue  query     string  false  "Queue name"  default(default)
 // @Param        limit  query     int     false  "Limit"       default(50)
 // @Success      200    {array}   JobInfo
-// @Failure      500    {object}  map[string]string
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /jobs/active [get]
 func (jc *JobsController) ListActiveJobs(c echo.Context) error {
 	queue := c.QueryParam("queue")
@@ -166,7 +265,7 @@ func (jc *JobsController) ListActiveJobs(c echo.Context) error {
 	tasks, err := jc.inspector.ListActiveTasks(queue, asynq.PageSize(limit))
 	if err != nil {
 		jc.logger.Error("Failed to list active tasks", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list active tasks"})
+		return apperrors.Internal("Failed to list active tasks", err)
 	}
 
 	var jobs []JobInfo
@@ -178,21 +277,35 @@ func (jc *JobsController) ListActiveJobs(c echo.Context) error {
 			State:    "active",
 			MaxRetry: task.MaxRetry,
 			Retried:  task.Retried,
+			Progress: jc.progress(c.Request().Context(), task.ID),
 		})
 	}
 
 	return c.JSON(http.StatusOK, jobs)
 }
 
+// progress returns the last progress reported by an active job, nil when it
+// reported none or Redis is unreachable as progress is informational.
+func (jc *JobsController) progress(ctx context.Context, taskID string) *jobs.Progress {
+	progress, err := jc.jobClient.GetProgress(ctx, taskID)
+	if err != nil {
+		jc.logger.Warn("Failed to get job progress", zap.String("jobID", taskID), zap.Error(err))
+		return nil
+	}
+	return progress
+}
+
 // ListScheduledJobs godoc
 // @Summary      List scheduled jobs
 // @Description  Get all scheduled (future) jobs in a queue
+// @ID           listScheduledJobs
 // @Tags         Jobs
 // @Produce      json
 // @Param        queue  query     string  false  "Queue name"  default(default)
 // @Param        limit  query     int     false  "Limit"       default(50)
 // @Success      200    {array}   JobInfo
-// @Failure      500    {object}  map[string]string
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /jobs/scheduled [get]
 func (jc *JobsController) ListScheduledJobs(c echo.Context) error {
 	queue := c.QueryParam("queue")
@@ -210,7 +323,7 @@ func (jc *JobsController) ListScheduledJobs(c echo.Context) error {
 	tasks, err := jc.inspector.ListScheduledTasks(queue, asynq.PageSize(limit))
 	if err != nil {
 		jc.logger.Error("Failed to list scheduled tasks", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list scheduled tasks"})
+		return apperrors.Internal("Failed to list scheduled tasks", err)
 	}
 
 	var jobs []JobInfo
@@ -232,12 +345,14 @@ func (jc *JobsController) ListScheduledJobs(c echo.Context) error {
 // ListRetryJobs godoc
 // @Summary      List retry jobs
 // @Description  Get all jobs pending retry in a queue
+// @ID           listRetryJobs
 // @Tags         Jobs
 // @Produce      json
 // @Param        queue  query     string  false  "Queue name"  default(default)
 // @Param        limit  query     int     false  "Limit"       default(50)
 // @Success      200    {array}   JobInfo
-// @Failure      500    {object}  map[string]string
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /jobs/retry [get]
 func (jc *JobsController) ListRetryJobs(c echo.Context) error {
 	queue := c.QueryParam("queue")
@@ -255,7 +370,7 @@ func (jc *JobsController) ListRetryJobs(c echo.Context) error {
 	tasks, err := jc.inspector.ListRetryTasks(queue, asynq.PageSize(limit))
 	if err != nil {
 		jc.logger.Error("Failed to list retry tasks", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list retry tasks"})
+		return apperrors.Internal("Failed to list retry tasks", err)
 	}
 
 	var jobs []JobInfo
@@ -278,12 +393,14 @@ func (jc *JobsController) ListRetryJobs(c echo.Context) error {
 // ListArchivedJobs godoc
 // @Summary      List archived (failed) jobs
 // @Description  Get all archived jobs in a queue
+// @ID           listArchivedJobs
 // @Tags         Jobs
 // @Produce      json
 // @Param        queue  query     string  false  "Queue name"  default(default)
 // @Param        limit  query     int     false  "Limit"       default(50)
 // @Success      200    {array}   JobInfo
-// @Failure      500    {object}  map[string]string
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /jobs/archived [get]
 func (jc *JobsController) ListArchivedJobs(c echo.Context) error {
 	queue := c.QueryParam("queue")
@@ -301,7 +418,7 @@ func (jc *JobsController) ListArchivedJobs(c echo.Context) error {
 	tasks, err := jc.inspector.ListArchivedTasks(queue, asynq.PageSize(limit))
 	if err != nil {
 		jc.logger.Error("Failed to list archived tasks", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list archived tasks"})
+		return apperrors.Internal("Failed to list archived tasks", err)
 	}
 
 	var jobs []JobInfo
@@ -320,16 +437,242 @@ func (jc *JobsController) ListArchivedJobs(c echo.Context) error {
 	return c.JSON(http.StatusOK, jobs)
 }
 
+// JobRetryInfo describes the retries of a job. Asynq only keeps the most
+// recent failure, earlier errors are not available.
+type JobRetryInfo struct {
+	Retried      int        `json:"retried"`
+	MaxRetry     int        `json:"max_retry"`
+	Remaining    int        `json:"remaining"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastFailedAt *time.Time `json:"last_failed_at,omitempty"`
+}
+
+// JobDetail represents the full details of a single job.
+type JobDetail struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Queue string `json:"queue"`
+	State string `json:"state"`
+	// Payload is the decoded payload struct of known task types and the raw
+	// JSON payload otherwise
+	Payload       interface{}     `json:"payload,omitempty"`
+	PayloadError  string          `json:"payload_error,omitempty"`
+	Retry         JobRetryInfo    `json:"retry"`
+	NextProcessAt *time.Time      `json:"next_process_at,omitempty"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+	Deadline      *time.Time      `json:"deadline,omitempty"`
+	Timeout       string          `json:"timeout,omitempty"`
+	Retention     string          `json:"retention,omitempty"`
+	IsOrphaned    bool            `json:"is_orphaned,omitempty"`
+	Result        json.RawMessage `json:"result,omitempty" swaggertype:"object"`
+	// Progress is the last progress reported by the job while it is active
+	Progress *jobs.Progress `json:"progress,omitempty"`
+}
+
+// optionalTime returns a pointer to t, or nil when t is zero.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// optionalDuration formats d, or returns "" when d is zero.
+func optionalDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// newJobDetail builds the JobDetail of a task.
+func newJobDetail(info *asynq.TaskInfo) JobDetail {
+	detail := JobDetail{
+		ID:    info.ID,
+		Type:  info.Type,
+		Queue: info.Queue,
+		State: info.State.String(),
+		Retry: JobRetryInfo{
+			Retried:      info.Retried,
+			MaxRetry:     info.MaxRetry,
+			Remaining:    max(info.MaxRetry-info.Retried, 0),
+			LastError:    info.LastErr,
+			LastFailedAt: optionalTime(info.LastFailedAt),
+		},
+		NextProcessAt: optionalTime(info.NextProcessAt),
+		CompletedAt:   optionalTime(info.CompletedAt),
+		Deadline:      optionalTime(info.Deadline),
+		Timeout:       optionalDuration(info.Timeout),
+		Retention:     optionalDuration(info.Retention),
+		IsOrphaned:    info.IsOrphaned,
+	}
+
+	payload, ok, err := jobs.ParsePayload(info.Type, info.Payload)
+	switch {
+	case err != nil:
+		detail.PayloadError = err.Error()
+	case ok:
+		detail.Payload = payload
+	}
+	if detail.Payload == nil && json.Valid(info.Payload) {
+		detail.Payload = json.RawMessage(info.Payload)
+	}
+
+	if len(info.Result) > 0 && json.Valid(info.Result) {
+		detail.Result = info.Result
+	}
+
+	return detail
+}
+
+// taskError returns the error to answer a failed task lookup or operation with.
+func taskError(err error, message string) error {
+	if errors.Is(err, asynq.ErrTaskNotFound) {
+		return apperrors.NotFound("Job not found")
+	}
+	if errors.Is(err, asynq.ErrQueueNotFound) {
+		return apperrors.NotFound("Queue not found")
+	}
+	return apperrors.Internal(message, err)
+}
+
+// GetJob godoc
+// @Summary      Get job details
+// @Description  Get the full details of a job: its decoded payload, retry information, next run time and result.
+// @ID           getJob
+// @Tags         Jobs
+// @Produce      json
+// @Param        id     path      string  true  "Job ID"
+// @Param        queue  query     string  false "Queue name"  default(default)
+// @Success      200    {object}  JobDetail
+// @Failure      404    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /jobs/{id} [get]
+func (jc *JobsController) GetJob(c echo.Context) error {
+	jobID := c.Param("id")
+	queue := c.QueryParam("queue")
+	if queue == "" {
+		queue = "default"
+	}
+
+	info, err := jc.inspector.GetTaskInfo(queue, jobID)
+	if err != nil {
+		if !errors.Is(err, asynq.ErrTaskNotFound) && !errors.Is(err, asynq.ErrQueueNotFound) {
+			jc.logger.Error("Failed to get job", zap.String("jobID", jobID), zap.String("queue", queue), zap.Error(err))
+		}
+		return taskError(err, "Failed to get job")
+	}
+
+	detail := newJobDetail(info)
+	if info.State == asynq.TaskStateActive {
+		detail.Progress = jc.progress(c.Request().Context(), info.ID)
+	}
+
+	return c.JSON(http.StatusOK, detail)
+}
+
+// BatchJobsRequest defines the request body for cancelling or retrying many
+// jobs, at most 100 per request.
+type BatchJobsRequest struct {
+	Queue string   `json:"queue,omitempty" example:"crawl"` // Defaults to "default"
+	IDs   []string `json:"ids" validate:"min=1,max=100,dive,required"`
+}
+
+// BatchJobResult reports the outcome for a single job of a batch request.
+type BatchJobResult struct {
+	ID    string `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// runBatch binds a BatchJobsRequest and applies op to each job, reporting
+// the outcome per job.
+func (jc *JobsController) runBatch(c echo.Context, action string, op func(queue, id string) error) error {
+	var req BatchJobsRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	if req.Queue == "" {
+		req.Queue = "default"
+	}
+
+	results := make([]BatchJobResult, len(req.IDs))
+	failed := 0
+	for i, id := range req.IDs {
+		results[i].ID = id
+		if err := op(req.Queue, id); err != nil {
+			failed++
+			switch {
+			case errors.Is(err, asynq.ErrTaskNotFound):
+				results[i].Error = "Job not found"
+			case errors.Is(err, asynq.ErrQueueNotFound):
+				results[i].Error = "Queue not found"
+			default:
+				results[i].Error = err.Error()
+			}
+			continue
+		}
+		results[i].OK = true
+	}
+
+	jc.logger.Info("Batch job operation completed",
+		zap.String("action", action),
+		zap.String("queue", req.Queue),
+		zap.Int("jobs", len(req.IDs)),
+		zap.Int("failed", failed),
+	)
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// CancelJobs godoc
+// @Summary      Cancel many jobs
+// @Description  Cancel several pending or scheduled jobs of a queue at once. The outcome is reported per job.
+// @ID           cancelJobs
+// @Tags         Jobs
+// @Accept       json
+// @Produce      json
+// @Param        request  body      BatchJobsRequest  true  "Jobs to cancel"
+// @Success      200      {array}   BatchJobResult
+// @Failure      400      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /jobs/batch/cancel [post]
+func (jc *JobsController) CancelJobs(c echo.Context) error {
+	return jc.runBatch(c, "cancel", jc.inspector.DeleteTask)
+}
+
+// RetryJobs godoc
+// @Summary      Retry many jobs
+// @Description  Run several archived, retry or scheduled jobs of a queue immediately. The outcome is reported per job.
+// @ID           retryJobs
+// @Tags         Jobs
+// @Accept       json
+// @Produce      json
+// @Param        request  body      BatchJobsRequest  true  "Jobs to retry"
+// @Success      200      {array}   BatchJobResult
+// @Failure      400      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /jobs/batch/retry [post]
+func (jc *JobsController) RetryJobs(c echo.Context) error {
+	return jc.runBatch(c, "retry", jc.inspector.RunTask)
+}
+
 // CancelJob godoc
 // @Summary      Cancel a job
 // @Description  Cancel a pending or scheduled job
+// @ID           cancelJob
 // @Tags         Jobs
 // @Produce      json
 // @Param        id     path      string  true  "Job ID"
 // @Param        queue  query     string  false "Queue name"  default(default)
-// @Success      200    {object}  map[string]string
-// @Failure      400    {object}  map[string]string
-// @Failure      500    {object}  map[string]string
+// @Success      200    {object}  JobActionResponse
+// @Failure      400    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /jobs/{id}/cancel [post]
 func (jc *JobsController) CancelJob(c echo.Context) error {
 	jobID := c.Param("id")
@@ -345,7 +688,7 @@ func (jc *JobsController) CancelJob(c echo.Context) error {
 			zap.String("queue", queue),
 			zap.Error(err),
 		)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to cancel job"})
+		return apperrors.Internal("Failed to cancel job", err)
 	}
 
 	jc.logger.Info("Job cancelled",
@@ -353,22 +696,24 @@ func (jc *JobsController) CancelJob(c echo.Context) error {
 		zap.String("queue", queue),
 	)
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "Job cancelled successfully",
-		"job_id":  jobID,
+	return c.JSON(http.StatusOK, JobActionResponse{
+		Message: "Job cancelled successfully",
+		JobID:   jobID,
 	})
 }
 
 // RetryJob godoc
 // @Summary      Retry an archived job
 // @Description  Retry an archived (failed) job immediately
+// @ID           retryJob
 // @Tags         Jobs
 // @Produce      json
 // @Param        id     path      string  true  "Job ID"
 // @Param        queue  query     string  false "Queue name"  default(default)
-// @Success      200    {object}  map[string]string
-// @Failure      400    {object}  map[string]string
-// @Failure      500    {object}  map[string]string
+// @Success      200    {object}  JobActionResponse
+// @Failure      400    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /jobs/{id}/retry [post]
 func (jc *JobsController) RetryJob(c echo.Context) error {
 	jobID := c.Param("id")
@@ -384,7 +729,7 @@ func (jc *JobsController) RetryJob(c echo.Context) error {
 			zap.String("queue", queue),
 			zap.Error(err),
 		)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retry job"})
+		return apperrors.Internal("Failed to retry job", err)
 	}
 
 	jc.logger.Info("Job retried",
@@ -392,20 +737,22 @@ func (jc *JobsController) RetryJob(c echo.Context) error {
 		zap.String("queue", queue),
 	)
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "Job queued for retry",
-		"job_id":  jobID,
+	return c.JSON(http.StatusOK, JobActionResponse{
+		Message: "Job queued for retry",
+		JobID:   jobID,
 	})
 }
 
 // PauseQueue godoc
 // @Summary      Pause a queue
 // @Description  Pause processing of jobs in a queue
+// @ID           pauseQueue
 // @Tags         Jobs
 // @Produce      json
 // @Param        queue  path      string  true  "Queue name"
-// @Success      200    {object}  map[string]string
-// @Failure      500    {object}  map[string]string
+// @Success      200    {object}  QueueActionResponse
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /jobs/queues/{queue}/pause [post]
 func (jc *JobsController) PauseQueue(c echo.Context) error {
 	queue := c.Param("queue")
@@ -413,25 +760,27 @@ func (jc *JobsController) PauseQueue(c echo.Context) error {
 	err := jc.inspector.PauseQueue(queue)
 	if err != nil {
 		jc.logger.Error("Failed to pause queue", zap.String("queue", queue), zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to pause queue"})
+		return apperrors.Internal("Failed to pause queue", err)
 	}
 
 	jc.logger.Info("Queue paused", zap.String("queue", queue))
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "Queue paused successfully",
-		"queue":   queue,
+	return c.JSON(http.StatusOK, QueueActionResponse{
+		Message: "Queue paused successfully",
+		Queue:   queue,
 	})
 }
 
 // ResumeQueue godoc
 // @Summary      Resume a queue
 // @Description  Resume processing of jobs in a paused queue
+// @ID           resumeQueue
 // @Tags         Jobs
 // @Produce      json
 // @Param        queue  path      string  true  "Queue name"
-// @Success      200    {object}  map[string]string
-// @Failure      500    {object}  map[string]string
+// @Success      200    {object}  QueueActionResponse
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /jobs/queues/{queue}/resume [post]
 func (jc *JobsController) ResumeQueue(c echo.Context) error {
 	queue := c.Param("queue")
@@ -439,13 +788,131 @@ func (jc *JobsController) ResumeQueue(c echo.Context) error {
 	err := jc.inspector.UnpauseQueue(queue)
 	if err != nil {
 		jc.logger.Error("Failed to resume queue", zap.String("queue", queue), zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to resume queue"})
+		return apperrors.Internal("Failed to resume queue", err)
 	}
 
 	jc.logger.Info("Queue resumed", zap.String("queue", queue))
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "Queue resumed successfully",
-		"queue":   queue,
+	return c.JSON(http.StatusOK, QueueActionResponse{
+		Message: "Queue resumed successfully",
+		Queue:   queue,
 	})
 }
+
+// QueueBulkResult reports the outcome of a bulk queue operation. Without
+// confirmation it is a dry run and Affected is the number of tasks that
+// would be affected.
+type QueueBulkResult struct {
+	Queue    string `json:"queue"`
+	Action   string `json:"action"`
+	DryRun   bool   `json:"dry_run"`
+	Affected int    `json:"affected"`
+	Message  string `json:"message"`
+}
+
+// runQueueBulk applies a bulk operation to a queue once the caller confirmed
+// it by passing the queue name in the confirm query parameter. Otherwise it
+// only reports how many tasks would be affected, counted by count.
+func (jc *JobsController) runQueueBulk(
+	c echo.Context,
+	action string,
+	count func(*asynq.QueueInfo) int,
+	op func(queue string) (int, error),
+) error {
+	queue := c.Param("queue")
+
+	if c.QueryParam("confirm") != queue {
+		info, err := jc.inspector.GetQueueInfo(queue)
+		if err != nil {
+			return taskError(err, "Failed to get queue info")
+		}
+		return c.JSON(http.StatusOK, QueueBulkResult{
+			Queue:    queue,
+			Action:   action,
+			DryRun:   true,
+			Affected: count(info),
+			Message:  "Dry run, pass confirm=" + queue + " to apply",
+		})
+	}
+
+	affected, err := op(queue)
+	if err != nil {
+		if !errors.Is(err, asynq.ErrQueueNotFound) {
+			jc.logger.Error("Failed to run bulk queue operation", zap.String("action", action), zap.String("queue", queue), zap.Error(err))
+		}
+		return taskError(err, "Failed to "+action)
+	}
+
+	jc.logger.Info("Bulk queue operation completed",
+		zap.String("action", action),
+		zap.String("queue", queue),
+		zap.Int("affected", affected),
+	)
+
+	return c.JSON(http.StatusOK, QueueBulkResult{
+		Queue:    queue,
+		Action:   action,
+		Affected: affected,
+		Message:  "Operation applied",
+	})
+}
+
+// PurgeArchivedJobs godoc
+// @Summary      Purge archived jobs
+// @Description  Delete all archived (failed) jobs of a queue. Runs as a dry run reporting the number of jobs unless confirm equals the queue name.
+// @ID           purgeArchivedJobs
+// @Tags         Jobs
+// @Produce      json
+// @Param        queue    path      string  true   "Queue name"
+// @Param        confirm  query     string  false  "Queue name to confirm the operation"
+// @Success      200      {object}  QueueBulkResult
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /jobs/queues/{queue}/archived [delete]
+func (jc *JobsController) PurgeArchivedJobs(c echo.Context) error {
+	return jc.runQueueBulk(c, "purge archived jobs",
+		func(info *asynq.QueueInfo) int { return info.Archived },
+		jc.inspector.DeleteAllArchivedTasks,
+	)
+}
+
+// DeletePendingJobs godoc
+// @Summary      Delete pending jobs
+// @Description  Delete all pending jobs of a queue. Runs as a dry run reporting the number of jobs unless confirm equals the queue name.
+// @ID           deletePendingJobs
+// @Tags         Jobs
+// @Produce      json
+// @Param        queue    path      string  true   "Queue name"
+// @Param        confirm  query     string  false  "Queue name to confirm the operation"
+// @Success      200      {object}  QueueBulkResult
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /jobs/queues/{queue}/pending [delete]
+func (jc *JobsController) DeletePendingJobs(c echo.Context) error {
+	return jc.runQueueBulk(c, "delete pending jobs",
+		func(info *asynq.QueueInfo) int { return info.Pending },
+		jc.inspector.DeleteAllPendingTasks,
+	)
+}
+
+// RequeueRetryJobs godoc
+// @Summary      Requeue retry jobs
+// @Description  Run all jobs of a queue that are waiting for a retry immediately. Runs as a dry run reporting the number of jobs unless confirm equals the queue name.
+// @ID           requeueRetryJobs
+// @Tags         Jobs
+// @Produce      json
+// @Param        queue    path      string  true   "Queue name"
+// @Param        confirm  query     string  false  "Queue name to confirm the operation"
+// @Success      200      {object}  QueueBulkResult
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /jobs/queues/{queue}/retry/requeue [post]
+func (jc *JobsController) RequeueRetryJobs(c echo.Context) error {
+	return jc.runQueueBulk(c, "requeue retry jobs",
+		func(info *asynq.QueueInfo) int { return info.Retry },
+		jc.inspector.RunAllRetryTasks,
+	)
+}
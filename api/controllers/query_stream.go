@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/llm"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// mimeNDJSON is the media type of newline-delimited JSON streams.
+const mimeNDJSON = "application/x-ndjson"
+
+// acceptsNDJSON reports whether the client asked for an NDJSON stream.
+func acceptsNDJSON(c echo.Context) bool {
+	return strings.Contains(c.Request().Header.Get(echo.HeaderAccept), mimeNDJSON)
+}
+
+// queryStreamWriter writes the events of a streamed query in one of the
+// stream formats.
+type queryStreamWriter interface {
+	start(query string) error
+	sources(meta *llm.QueryStreamMeta) error
+	chunk(text string) error
+	done(meta *llm.QueryStreamMeta, queryID uint) error
+	fail(err apperrors.Response) error
+}
+
+// newQueryStreamWriter returns the writer of the format the client accepts,
+// Server-Sent Events by default.
+func newQueryStreamWriter(c echo.Context) queryStreamWriter {
+	if acceptsNDJSON(c) {
+		return &ndjsonStreamWriter{c: c}
+	}
+	return &sseStreamWriter{c: c}
+}
+
+// streamQuery answers a query, writing the answer as it is generated.
+func (wc *WebsiteController) streamQuery(c echo.Context, website *schema.Website, req QueryRequest, w queryStreamWriter) error {
+	if err := w.start(req.Query); err != nil {
+		return nil
+	}
+
+	ctx := c.Request().Context()
+	meta, queryID, err := wc.queries.QueryStream(ctx, requestActor(c), website, req.question(), w.sources, w.chunk)
+	if err != nil {
+		if ctx.Err() != nil {
+			wc.logger.Info("Client disconnected from query stream", zap.Uint("websiteID", website.ID))
+			return nil
+		}
+		wc.logger.Error("Failed to process streaming query", zap.Error(err))
+		appErr, _ := apperrors.As(err)
+		w.fail(streamError(c, appErr))
+		return nil
+	}
+
+	w.done(meta, queryID)
+	return nil
+}
+
+// sseStreamWriter streams a query as Server-Sent Events: start, sources,
+// chunk, metadata and done, or error.
+type sseStreamWriter struct {
+	c echo.Context
+}
+
+func (w *sseStreamWriter) start(query string) error {
+	w.c.Response().Header().Set("Content-Type", "text/event-stream")
+	w.c.Response().Header().Set("Cache-Control", "no-cache")
+	w.c.Response().Header().Set("Connection", "keep-alive")
+	w.c.Response().Header().Set("X-Accel-Buffering", "no")
+	w.c.Response().WriteHeader(http.StatusOK)
+
+	return writeSSE(w.c, "start", map[string]string{"query": query})
+}
+
+func (w *sseStreamWriter) sources(meta *llm.QueryStreamMeta) error {
+	return writeSSE(w.c, "sources", meta)
+}
+
+func (w *sseStreamWriter) chunk(text string) error {
+	return writeSSE(w.c, "chunk", map[string]string{"text": text})
+}
+
+func (w *sseStreamWriter) done(meta *llm.QueryStreamMeta, queryID uint) error {
+	if err := writeSSE(w.c, "metadata", map[string]any{
+		"query_id":         queryID,
+		"retrieved_chunks": meta.RetrievedChunks,
+		"sources_count":    len(meta.Sources),
+		"cached":           meta.Cached,
+		"degraded":         meta.Degraded,
+		"guardrail":        meta.Guardrail,
+		"usage":            meta.Usage,
+	}); err != nil {
+		return err
+	}
+	return writeSSE(w.c, "done", map[string]string{"status": "complete"})
+}
+
+func (w *sseStreamWriter) fail(err apperrors.Response) error {
+	return writeSSE(w.c, "error", err)
+}
+
+// QueryStreamRecord is a line of an NDJSON query stream. Type is sources,
+// chunk, done or error and decides which other fields are set.
+type QueryStreamRecord struct {
+	Type string `json:"type" example:"chunk"`
+	// Text is the next part of the answer of chunk records
+	Text string `json:"text,omitempty"`
+	// Sources, Query and RetrievedChunks are set on the sources record
+	Sources         []llm.QuerySource `json:"sources,omitempty"`
+	Query           string            `json:"query,omitempty"`
+	RetrievedChunks int               `json:"retrieved_chunks,omitempty"`
+	Cached          bool              `json:"cached,omitempty"`
+	Degraded        bool              `json:"degraded,omitempty"`
+	// Guardrail names the guardrail that refused the question
+	Guardrail string `json:"guardrail,omitempty"`
+	// QueryID identifies the answered query for feedback and Usage counts
+	// its LLM tokens on the done record
+	QueryID uint                `json:"query_id,omitempty"`
+	Usage   *llm.TokenUsage     `json:"usage,omitempty"`
+	Error   *apperrors.Response `json:"error,omitempty"`
+}
+
+// ndjsonStreamWriter streams a query as newline-delimited JSON records, see
+// QueryStreamRecord.
+type ndjsonStreamWriter struct {
+	c echo.Context
+}
+
+func (w *ndjsonStreamWriter) write(record QueryStreamRecord) error {
+	if err := json.NewEncoder(w.c.Response()).Encode(record); err != nil {
+		return err
+	}
+	w.c.Response().Flush()
+	return nil
+}
+
+func (w *ndjsonStreamWriter) start(string) error {
+	w.c.Response().Header().Set("Content-Type", mimeNDJSON)
+	w.c.Response().Header().Set("Cache-Control", "no-cache")
+	w.c.Response().Header().Set("X-Accel-Buffering", "no")
+	w.c.Response().WriteHeader(http.StatusOK)
+	w.c.Response().Flush()
+	return nil
+}
+
+func (w *ndjsonStreamWriter) sources(meta *llm.QueryStreamMeta) error {
+	return w.write(QueryStreamRecord{
+		Type:            "sources",
+		Sources:         meta.Sources,
+		Query:           meta.Query,
+		RetrievedChunks: meta.RetrievedChunks,
+		Cached:          meta.Cached,
+		Degraded:        meta.Degraded,
+		Guardrail:       meta.Guardrail,
+	})
+}
+
+func (w *ndjsonStreamWriter) chunk(text string) error {
+	return w.write(QueryStreamRecord{Type: "chunk", Text: text})
+}
+
+func (w *ndjsonStreamWriter) done(meta *llm.QueryStreamMeta, queryID uint) error {
+	return w.write(QueryStreamRecord{
+		Type:            "done",
+		QueryID:         queryID,
+		Usage:           meta.Usage,
+		RetrievedChunks: meta.RetrievedChunks,
+		Cached:          meta.Cached,
+		Degraded:        meta.Degraded,
+		Guardrail:       meta.Guardrail,
+	})
+}
+
+func (w *ndjsonStreamWriter) fail(err apperrors.Response) error {
+	return w.write(QueryStreamRecord{Type: "error", Error: &err})
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"time"
+
+	"hermit/internal/schema"
+)
+
+// MessageResponse confirms an action that returns nothing else.
+type MessageResponse struct {
+	Message string `json:"message" example:"Credentials deleted"`
+}
+
+// EnqueuedResponse confirms a background job was enqueued.
+type EnqueuedResponse struct {
+	Message string `json:"message" example:"Reindex job enqueued"`
+	TaskID  string `json:"task_id,omitempty" example:"3f1c2b7e-9a4d-4f0e-8b6a-5c7d9e1f2a3b"`
+	Status  string `json:"status,omitempty" example:"pending"`
+	// ScheduledAt is when a deferred job runs, e.g. when a crawl window opens.
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty" example:"2024-01-01T01:00:00Z"`
+}
+
+// IndexPagesResponse confirms the pages were enqueued for indexing.
+type IndexPagesResponse struct {
+	EnqueuedResponse
+	URLs []string `json:"urls"`
+}
+
+// GarbageCollectionResponse confirms a garbage collection was enqueued.
+type GarbageCollectionResponse struct {
+	EnqueuedResponse
+	Delete bool `json:"delete"`
+}
+
+// RotateKeysResponse reports the credentials and connectors re-encrypted by a
+// key rotation.
+type RotateKeysResponse struct {
+	Message string `json:"message" example:"Credentials re-encrypted with the current key"`
+	Rotated int    `json:"rotated" example:"3"`
+}
+
+// JobActionResponse confirms an action on a job.
+type JobActionResponse struct {
+	Message string `json:"message" example:"Job cancelled successfully"`
+	JobID   string `json:"job_id" example:"3f1c2b7e-9a4d-4f0e-8b6a-5c7d9e1f2a3b"`
+}
+
+// QueueActionResponse confirms an action on a queue.
+type QueueActionResponse struct {
+	Message string `json:"message" example:"Queue paused successfully"`
+	Queue   string `json:"queue" example:"crawl"`
+}
+
+// APIKeyListResponse lists the API keys of a user.
+type APIKeyListResponse struct {
+	APIKeys []*schema.APIKeyResponse `json:"api_keys"`
+	Count   int                      `json:"count"`
+}
+
+// SessionListResponse lists the web sessions of a user.
+type SessionListResponse struct {
+	Sessions []*schema.SessionResponse `json:"sessions"`
+	Count    int                       `json:"count"`
+}
+
+// RevokeSessionsResponse reports the number of web sessions revoked.
+type RevokeSessionsResponse struct {
+	Message string `json:"message" example:"Sessions revoked successfully"`
+	Revoked int64  `json:"revoked" example:"2"`
+}
+
+// LoginActivityResponse lists the recent login attempts on an account.
+type LoginActivityResponse struct {
+	Attempts []schema.LoginAttempt `json:"attempts"`
+	Count    int                   `json:"count"`
+}
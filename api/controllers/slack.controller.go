@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/slack"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// maxSlackBody limits the size of requests from Slack.
+const maxSlackBody = 1 << 20
+
+// slackMention matches the mentions of users and apps in a message.
+var slackMention = regexp.MustCompile(`<@[A-Z0-9]+(\|[^>]*)?>`)
+
+// SlackController handles the slash command and events of the Slack app.
+type SlackController struct {
+	bot    *slack.Bot
+	logger *zap.Logger
+}
+
+// NewSlackController creates a new SlackController. A nil bot disables the
+// Slack endpoints.
+func NewSlackController(bot *slack.Bot, logger *zap.Logger) *SlackController {
+	return &SlackController{bot: bot, logger: logger}
+}
+
+// SlackMessage is a message returned to Slack in response to a command.
+type SlackMessage struct {
+	ResponseType string `json:"response_type,omitempty" example:"ephemeral"`
+	Text         string `json:"text" example:"This channel isn't linked to a website"`
+}
+
+// SlackEvent is the envelope of the events Slack sends to the app.
+type SlackEvent struct {
+	Type      string `json:"type" example:"event_callback"`
+	Challenge string `json:"challenge,omitempty"`
+	Event     struct {
+		Type     string `json:"type" example:"app_mention"`
+		User     string `json:"user"`
+		BotID    string `json:"bot_id,omitempty"`
+		Text     string `json:"text"`
+		Channel  string `json:"channel"`
+		TS       string `json:"ts"`
+		ThreadTS string `json:"thread_ts,omitempty"`
+	} `json:"event"`
+}
+
+// readSlackRequest reads the body of a request from Slack and checks its
+// signature.
+func (sc *SlackController) readSlackRequest(c echo.Context) ([]byte, error) {
+	if sc.bot == nil {
+		return nil, apperrors.New(apperrors.CodeNotImplemented, "The Slack app is not enabled on this server")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxSlackBody))
+	if err != nil {
+		return nil, apperrors.Validation("Failed to read request body")
+	}
+	if err := sc.bot.Verify(c.Request().Header, body); err != nil {
+		return nil, apperrors.Unauthorized("invalid Slack signature")
+	}
+	return body, nil
+}
+
+// HandleCommand godoc
+// @Summary      Slack slash command
+// @Description  Answers the question passed to the app's slash command from the website linked to the channel. The answer is posted in the channel and edited as it streams in. Requests must be signed with SLACK_SIGNING_SECRET.
+// @ID           slackCommand
+// @Tags         Slack
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Success      200  {object}  SlackMessage
+// @Failure      401  {object}  apperrors.Response
+// @Failure      501  {object}  apperrors.Response
+// @Router       /slack/commands [post]
+func (sc *SlackController) HandleCommand(c echo.Context) error {
+	body, err := sc.readSlackRequest(c)
+	if err != nil {
+		return err
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	question := slack.Question{
+		ChannelID: form.Get("channel_id"),
+		UserID:    form.Get("user_id"),
+		Text:      strings.TrimSpace(form.Get("text")),
+	}
+	if question.Text == "" {
+		return c.JSON(http.StatusOK, SlackMessage{
+			ResponseType: "ephemeral",
+			Text:         "Ask a question about the website linked to this channel, e.g. `" + form.Get("command") + " How do I get started?`",
+		})
+	}
+
+	// Slack expects a response within 3 seconds, answer in the background
+	if _, _, err := sc.bot.Resolve(c.Request().Context(), question.ChannelID); err != nil {
+		appErr, _ := apperrors.As(err)
+		if appErr.Code == apperrors.CodeInternal {
+			sc.logger.Error("Failed to resolve Slack channel", zap.String("channel", question.ChannelID), zap.Error(err))
+		}
+		return c.JSON(http.StatusOK, SlackMessage{ResponseType: "ephemeral", Text: appErr.Message})
+	}
+	go sc.bot.Answer(context.WithoutCancel(c.Request().Context()), question)
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleEvent godoc
+// @Summary      Slack events
+// @Description  Receives the events of the Slack app. Mentions of the app are answered in a thread from the website linked to the channel. Requests must be signed with SLACK_SIGNING_SECRET.
+// @ID           slackEvent
+// @Tags         Slack
+// @Accept       json
+// @Produce      json
+// @Param        event  body      SlackEvent  true  "Event"
+// @Success      200    {object}  map[string]string
+// @Failure      400    {object}  apperrors.Response
+// @Failure      401    {object}  apperrors.Response
+// @Failure      501    {object}  apperrors.Response
+// @Router       /slack/events [post]
+func (sc *SlackController) HandleEvent(c echo.Context) error {
+	body, err := sc.readSlackRequest(c)
+	if err != nil {
+		return err
+	}
+
+	var event SlackEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	switch event.Type {
+	case "url_verification":
+		return c.JSON(http.StatusOK, map[string]string{"challenge": event.Challenge})
+	case "event_callback":
+	default:
+		return c.NoContent(http.StatusOK)
+	}
+
+	// Slack retries events not acknowledged in time, which were answered
+	// already
+	if c.Request().Header.Get("X-Slack-Retry-Num") != "" {
+		return c.NoContent(http.StatusOK)
+	}
+
+	if event.Event.Type != "app_mention" || event.Event.BotID != "" {
+		return c.NoContent(http.StatusOK)
+	}
+
+	question := slack.Question{
+		ChannelID: event.Event.Channel,
+		ThreadTS:  event.Event.ThreadTS,
+		UserID:    event.Event.User,
+		Text:      strings.TrimSpace(slackMention.ReplaceAllString(event.Event.Text, "")),
+	}
+	if question.ThreadTS == "" {
+		question.ThreadTS = event.Event.TS
+	}
+	if question.Text != "" {
+		go sc.bot.Answer(context.WithoutCancel(c.Request().Context()), question)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"hermit/internal/jobs"
+	"hermit/internal/repositories"
+	"hermit/internal/schema"
+	"hermit/internal/vectorizer"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// The website controller depends on the stores and queues below rather than
+// on the repositories and the job client, so its handlers can be exercised
+// with fakes, and with the services built on the servicefake package. Each
+// interface lists only the methods the controller calls.
+
+// PageStore reads the crawled pages of websites.
+type PageStore interface {
+	GetByWebsiteID(ctx context.Context, websiteID uint) ([]schema.Page, error)
+	// GetTags returns the tags of the pages by ID.
+	GetTags(ctx context.Context, ids []int64) (map[uint][]string, error)
+	ListIDsByTag(ctx context.Context, websiteID uint, tag string) ([]uint, error)
+	ListTags(ctx context.Context, websiteID uint, limit int) ([]schema.TagCount, error)
+	// CountByStatus counts the pages of a website crawled since a time by
+	// status.
+	CountByStatus(ctx context.Context, websiteID uint, since time.Time) (map[string]int, error)
+	CountErrorsByHTTPStatus(ctx context.Context, websiteID uint) (map[int]int, error)
+	// Version identifies the state of the pages of a website.
+	Version(ctx context.Context, websiteID uint) (schema.Version, error)
+}
+
+// UserStore looks up users.
+type UserStore interface {
+	// GetByEmail returns nil when no user has the email.
+	GetByEmail(ctx context.Context, email string) (*schema.User, error)
+}
+
+// QueryLogStore reads the queries answered and records their feedback.
+type QueryLogStore interface {
+	GetByID(ctx context.Context, id uint) (*schema.QueryLog, error)
+	UpsertFeedback(ctx context.Context, feedback *schema.QueryFeedback) error
+	GetFeedbackStats(ctx context.Context, websiteID uint) ([]schema.FeedbackStats, error)
+	GetUsage(ctx context.Context, websiteID uint, since time.Time, byUser bool) ([]schema.UsageStats, error)
+}
+
+// SnapshotStore reads the visual snapshots of pages.
+type SnapshotStore interface {
+	ListChangesByWebsiteID(ctx context.Context, websiteID uint, minScore float64, limit int) ([]schema.VisualSnapshot, error)
+}
+
+// CrawlEventStore reads the events recorded by crawls.
+type CrawlEventStore interface {
+	LatestID(ctx context.Context, websiteID uint) (uint, error)
+	ListByWebsiteID(ctx context.Context, websiteID uint, level, event string, limit, offset int) ([]schema.CrawlEvent, int, error)
+}
+
+// CredentialsStore persists the encrypted fetch credentials of websites.
+type CredentialsStore interface {
+	// Enabled reports whether an encryption key is configured.
+	Enabled() bool
+	Save(ctx context.Context, websiteID uint, creds *schema.FetchCredentials) error
+	Get(ctx context.Context, websiteID uint) (*schema.FetchCredentials, error)
+	Delete(ctx context.Context, websiteID uint) error
+}
+
+// ConnectorStore persists the encrypted connector settings of websites.
+type ConnectorStore interface {
+	// Enabled reports whether an encryption key is configured.
+	Enabled() bool
+	Save(ctx context.Context, websiteID uint, connector *schema.ConnectorConfig) error
+	Get(ctx context.Context, websiteID uint) (*schema.ConnectorConfig, error)
+	Delete(ctx context.Context, websiteID uint) error
+}
+
+// SlackChannelStore persists the Slack channels linked to websites.
+type SlackChannelStore interface {
+	Link(ctx context.Context, channel *schema.SlackChannel) error
+	ListByWebsite(ctx context.Context, websiteID uint) ([]schema.SlackChannel, error)
+	Unlink(ctx context.Context, websiteID uint, channelID string) (bool, error)
+}
+
+// DiscordChannelStore persists the Discord channels linked to websites.
+type DiscordChannelStore interface {
+	Link(ctx context.Context, channel *schema.DiscordChannel) error
+	ListByWebsite(ctx context.Context, websiteID uint) ([]schema.DiscordChannel, error)
+	Unlink(ctx context.Context, websiteID uint, channelID string) (bool, error)
+}
+
+// EvaluationStore persists the evaluation cases and runs of websites.
+type EvaluationStore interface {
+	ReplaceCases(ctx context.Context, websiteID uint, cases []schema.EvalCase) ([]schema.EvalCase, error)
+	ListCases(ctx context.Context, websiteID uint) ([]schema.EvalCase, error)
+	CountCases(ctx context.Context, websiteID uint) (int, error)
+	CreateRun(ctx context.Context, websiteID uint, scorer string, cases int) (*schema.EvalRun, error)
+	GetRun(ctx context.Context, id uint) (*schema.EvalRun, error)
+	ListRuns(ctx context.Context, websiteID uint, limit, offset int) ([]schema.EvalRun, int, error)
+	FailRun(ctx context.Context, id uint, reason string) error
+	ListResults(ctx context.Context, runID uint) ([]schema.EvalResult, error)
+}
+
+// PermissionStore persists the website permissions granted to users and
+// API keys.
+type PermissionStore interface {
+	Grant(ctx context.Context, permission *schema.WebsitePermission) (*schema.WebsitePermission, error)
+	ListByWebsiteID(ctx context.Context, websiteID uint) ([]schema.WebsitePermission, error)
+	Revoke(ctx context.Context, websiteID, id uint) (bool, error)
+}
+
+// APIKeyStore looks up API keys.
+type APIKeyStore interface {
+	GetByID(ctx context.Context, id ulid.ULID) (*schema.APIKey, error)
+}
+
+// JobQueue queues the jobs the controller starts itself and reports on the
+// crawl jobs of websites.
+type JobQueue interface {
+	EnqueueEvaluateRAG(ctx context.Context, websiteID, runID uint) error
+	GetCrawlJobStatus(ctx context.Context, websiteID uint) (*jobs.CrawlJobStatus, error)
+}
+
+// VectorCounter counts the vectors stored for websites.
+type VectorCounter interface {
+	GetWebsiteVectorCount(ctx context.Context, websiteID uint) (int, error)
+}
+
+var (
+	_ PageStore           = (*repositories.PageRepository)(nil)
+	_ UserStore           = (*repositories.UserRepository)(nil)
+	_ QueryLogStore       = (*repositories.QueryLogRepository)(nil)
+	_ SnapshotStore       = (*repositories.VisualSnapshotRepository)(nil)
+	_ CrawlEventStore     = (*repositories.CrawlEventRepository)(nil)
+	_ CredentialsStore    = (*repositories.WebsiteCredentialsRepository)(nil)
+	_ ConnectorStore      = (*repositories.WebsiteConnectorRepository)(nil)
+	_ SlackChannelStore   = (*repositories.SlackChannelRepository)(nil)
+	_ DiscordChannelStore = (*repositories.DiscordChannelRepository)(nil)
+	_ EvaluationStore     = (*repositories.EvaluationRepository)(nil)
+	_ PermissionStore     = (*repositories.WebsitePermissionRepository)(nil)
+	_ APIKeyStore         = (*repositories.APIKeyRepository)(nil)
+	_ JobQueue            = (*jobs.Client)(nil)
+	_ VectorCounter       = (*vectorizer.Service)(nil)
+)
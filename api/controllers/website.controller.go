@@ -1,129 +1,253 @@
 package controllers
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/config"
+	"hermit/internal/contentprocessor"
 	"hermit/internal/jobs"
-	"hermit/internal/llm"
-	"hermit/internal/repositories"
 	"hermit/internal/schema"
 	_ "hermit/internal/schema" // Used by swaggo
+	"hermit/internal/service"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
 // WebsiteController handles API requests for websites.
 type WebsiteController struct {
-	websiteRepo *repositories.WebsiteRepository
-	pageRepo    *repositories.PageRepository
-	userRepo    *repositories.UserRepository
-	jobClient   *jobs.Client
-	ragService  *llm.RAGService
-	logger      *zap.Logger
+	pageRepo      PageStore
+	userRepo      UserStore
+	queryLogRepo  QueryLogStore
+	snapshotRepo  SnapshotStore
+	eventRepo     CrawlEventStore
+	credsRepo     CredentialsStore
+	connectorRepo ConnectorStore
+	slackRepo     SlackChannelStore
+	discordRepo   DiscordChannelStore
+	evalRepo      EvaluationStore
+	permRepo      PermissionStore
+	apiKeyRepo    APIKeyStore
+	jobClient     JobQueue
+	vectorSvc     VectorCounter
+	websites      *service.WebsiteService
+	queries       *service.QueryService
+	search        *service.SearchService
+	cfg           *config.Config
+	logger        *zap.Logger
+}
+
+// WebsiteControllerParams are the dependencies of a WebsiteController.
+type WebsiteControllerParams struct {
+	fx.In
+
+	PageRepo      PageStore
+	UserRepo      UserStore
+	QueryLogRepo  QueryLogStore
+	SnapshotRepo  SnapshotStore
+	EventRepo     CrawlEventStore
+	CredsRepo     CredentialsStore
+	ConnectorRepo ConnectorStore
+	SlackRepo     SlackChannelStore
+	DiscordRepo   DiscordChannelStore
+	EvalRepo      EvaluationStore
+	PermRepo      PermissionStore
+	APIKeyRepo    APIKeyStore
+	JobClient     JobQueue
+	VectorSvc     VectorCounter
+	Websites      *service.WebsiteService
+	Queries       *service.QueryService
+	Search        *service.SearchService
+	Config        *config.Config
+	Logger        *zap.Logger
 }
 
 // NewWebsiteController creates a new WebsiteController.
-func NewWebsiteController(
-	websiteRepo *repositories.WebsiteRepository,
-	pageRepo *repositories.PageRepository,
-	userRepo *repositories.UserRepository,
-	jobClient *jobs.Client,
-	ragService *llm.RAGService,
-	logger *zap.Logger,
-) *WebsiteController {
+func NewWebsiteController(p WebsiteControllerParams) *WebsiteController {
 	return &WebsiteController{
-		websiteRepo: websiteRepo,
-		pageRepo:    pageRepo,
-		userRepo:    userRepo,
-		jobClient:   jobClient,
-		ragService:  ragService,
-		logger:      logger,
+		pageRepo:      p.PageRepo,
+		userRepo:      p.UserRepo,
+		queryLogRepo:  p.QueryLogRepo,
+		snapshotRepo:  p.SnapshotRepo,
+		eventRepo:     p.EventRepo,
+		credsRepo:     p.CredsRepo,
+		connectorRepo: p.ConnectorRepo,
+		slackRepo:     p.SlackRepo,
+		discordRepo:   p.DiscordRepo,
+		evalRepo:      p.EvalRepo,
+		permRepo:      p.PermRepo,
+		apiKeyRepo:    p.APIKeyRepo,
+		jobClient:     p.JobClient,
+		vectorSvc:     p.VectorSvc,
+		websites:      p.Websites,
+		queries:       p.Queries,
+		search:        p.Search,
+		cfg:           p.Config,
+		logger:        p.Logger,
 	}
 }
 
 // WebsiteCreateRequest defines the request body for creating a website.
 type WebsiteCreateRequest struct {
-	URL string `json:"url" example:"https://example.com"`
+	URL              string `json:"url" validate:"required,weburl" example:"https://example.com"`
+	TLSSkipVerify    bool   `json:"tls_skip_verify" example:"false"`
+	VisualMonitoring bool   `json:"visual_monitoring" example:"false"`
+	// Crawl budgets, 0 uses the server defaults
+	MaxCrawlBytes           int64 `json:"max_crawl_bytes,omitempty" validate:"gte=0" example:"104857600"`
+	MaxCrawlDurationSeconds int   `json:"max_crawl_duration_seconds,omitempty" validate:"gte=0" example:"1800"`
+	MaxPagesPerPrefix       int   `json:"max_pages_per_prefix,omitempty" validate:"gte=0" example:"200"`
+	// Minimum content quality of indexed pages between 0 and 1, 0 uses the
+	// server default
+	MinContentQuality float64 `json:"min_content_quality,omitempty" validate:"gte=0,lte=1" example:"0.5"`
+	// PIIRedaction redacts emails, phone numbers, SSNs and PII_PATTERNS from
+	// pages before they are stored and vectorized
+	PIIRedaction bool `json:"pii_redaction,omitempty" example:"false"`
+	// User agent and extra headers for sites blocking the default user
+	// agent, see RequestSettingsRequest
+	UserAgent         string            `json:"user_agent,omitempty" example:"Mozilla/5.0 (compatible; AcmeDocsBot/1.0)"`
+	UserAgentRotation []string          `json:"user_agent_rotation,omitempty"`
+	RequestHeaders    map[string]string `json:"request_headers,omitempty"`
+	// Start URLs crawled besides url and the URL prefixes the crawl is
+	// restricted to, all on the host of url
+	SeedURLs []string `json:"seed_urls,omitempty" validate:"max=20,dive,weburl" example:"https://example.com/docs/"`
+	Scope    []string `json:"scope,omitempty" validate:"max=20,dive,weburl" example:"https://example.com/docs/"`
 }
 
 // CreateWebsite godoc
 // @Summary      Create a new website
-// @Description  Adds a new website to the monitoring list and starts the crawling process.
+// @Description  Adds a new website to the monitoring list and starts the crawling process. Additional start URLs and scope prefixes restrict the crawl to parts of the host, e.g. only pages under https://example.com/docs/.
+// @ID           createWebsite
 // @Tags         Websites
 // @Accept       json
 // @Produce      json
 // @Param        website  body      WebsiteCreateRequest  true  "Website URL"
 // @Success      201      {object}  schema.Website
-// @Failure      400      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Failure      400      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /websites [post]
 func (wc *WebsiteController) CreateWebsite(c echo.Context) error {
-	userID, err := middlewares.GetUserID(c)
-	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
 	}
 
 	var req WebsiteCreateRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return apperrors.Validation("Invalid request payload")
 	}
 
-	// Check if user can create more websites
-	websiteCount, err := wc.userRepo.GetWebsiteCount(c.Request().Context(), userID)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check website limit"})
+	if err := c.Validate(&req); err != nil {
+		return err
 	}
 
-	user, err := wc.userRepo.GetByID(c.Request().Context(), userID)
+	website, err := wc.websites.Create(c.Request().Context(), requestActor(c), service.NewWebsite{
+		URL:                     req.URL,
+		TLSSkipVerify:           req.TLSSkipVerify,
+		VisualMonitoring:        req.VisualMonitoring,
+		MaxCrawlBytes:           req.MaxCrawlBytes,
+		MaxCrawlDurationSeconds: req.MaxCrawlDurationSeconds,
+		MaxPagesPerPrefix:       req.MaxPagesPerPrefix,
+		MinContentQuality:       req.MinContentQuality,
+		PIIRedaction:            req.PIIRedaction,
+		UserAgent:               req.UserAgent,
+		UserAgentRotation:       req.UserAgentRotation,
+		RequestHeaders:          req.RequestHeaders,
+		SeedURLs:                req.SeedURLs,
+		ScopePrefixes:           req.Scope,
+	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get user"})
+		return err
 	}
+	middlewares.SetAuditResource(c, strconv.FormatUint(uint64(website.ID), 10))
 
-	if !user.CanCreateWebsite(websiteCount) {
-		return c.JSON(http.StatusForbidden, map[string]string{
-			"error": fmt.Sprintf("Website limit reached (%d/%d)", websiteCount, user.WebsiteLimit),
-		})
+	return c.JSON(http.StatusCreated, website)
+}
+
+// WebsiteBulkCreateRequest defines the request body for creating many websites at once.
+type WebsiteBulkCreateRequest struct {
+	URLs []string `json:"urls" validate:"min=1,dive,weburl" example:"https://example.com,https://example.org"`
+}
+
+// WebsiteBulkCreateResult reports the outcome for a single URL in a bulk create.
+type WebsiteBulkCreateResult struct {
+	URL     string          `json:"url"`
+	Website *schema.Website `json:"website,omitempty"`
+	Queued  bool            `json:"queued"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// CreateWebsitesBulk godoc
+// @Summary      Create websites in bulk
+// @Description  Adds several websites at once and enqueues their crawls in a single batch.
+// @ID           createWebsitesBulk
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        websites  body      WebsiteBulkCreateRequest  true  "Website URLs"
+// @Success      201       {array}   WebsiteBulkCreateResult
+// @Failure      400       {object}  apperrors.Response
+// @Failure      403       {object}  apperrors.Response
+// @Failure      500       {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/bulk [post]
+func (wc *WebsiteController) CreateWebsitesBulk(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
 	}
 
-	website, err := wc.websiteRepo.Create(c.Request().Context(), req.URL)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create website"})
+	var req WebsiteBulkCreateRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
 	}
 
-	// Associate website with user
-	website.UserID = &userID
-	err = wc.websiteRepo.Update(c.Request().Context(), website)
-	if err != nil {
-		wc.logger.Error("Failed to associate website with user", zap.Error(err))
+	if err := c.Validate(&req); err != nil {
+		return err
 	}
 
-	// Enqueue crawl job
-	err = wc.jobClient.EnqueueCrawlWebsite(c.Request().Context(), uint(website.ID), website.URL)
+	created, err := wc.websites.CreateBulk(c.Request().Context(), requestActor(c), req.URLs)
 	if err != nil {
-		wc.logger.Error("Failed to enqueue crawl job", zap.Error(err))
-		// Don't fail the request, website is created
+		return err
 	}
 
-	return c.JSON(http.StatusCreated, website)
+	results := make([]WebsiteBulkCreateResult, len(created))
+	for i, res := range created {
+		results[i] = WebsiteBulkCreateResult{
+			URL:     res.URL,
+			Website: res.Website,
+			Queued:  res.Queued,
+			Error:   res.Error,
+		}
+	}
+
+	return c.JSON(http.StatusCreated, results)
 }
 
 // ListWebsites godoc
 // @Summary      List all websites
-// @Description  Retrieves a list of all monitored websites with pagination.
+// @Description  Retrieves the websites the user owns or that were shared with the user or API key, all websites for admins, with pagination, optionally only those with a label. Each website carries its vector count and storage usage as of IndexStatsAt, refreshed shortly after crawls and vectorization. Responses carry an ETag; If-None-Match is answered with 304 when no website changed.
+// @ID           listWebsites
 // @Tags         Websites
 // @Produce      json
-// @Param        page   query     int     false  "Page number"     default(1)
-// @Param        limit  query     int     false  "Items per page"  default(20)
-// @Success      200    {object}  PaginatedResponse
-// @Failure      500    {object}  map[string]string
+// @Param        page           query     int     false  "Page number"     default(1)
+// @Param        limit          query     int     false  "Items per page"  default(20)
+// @Param        tag            query     string  false  "Only websites with this label"
+// @Param        If-None-Match  header    string  false  "ETag of a previous response"
+// @Success      200            {object}  PaginatedResponse{data=[]schema.Website}
+// @Success      304            "Not modified"
+// @Failure      500            {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /websites [get]
 func (wc *WebsiteController) ListWebsites(c echo.Context) error {
-	userID, err := middlewares.GetUserID(c)
-	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
 	}
 
 	// Parse pagination params
@@ -141,48 +265,33 @@ func (wc *WebsiteController) ListWebsites(c echo.Context) error {
 		}
 	}
 
-	// Get all websites
-	allWebsites, err := wc.websiteRepo.List(c.Request().Context())
+	label := schema.NormalizeLabel(c.QueryParam("tag"))
+
+	// Reply 304 while the user's websites are unchanged, e.g. for polling
+	// dashboards
+	actor := requestActor(c)
+	version, err := wc.websites.ListVersion(c.Request().Context(), actor)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list websites"})
+		return err
+	}
+	if notModified(c, append(append([]any{"websites"}, version...), page, limit, label)...) {
+		return c.NoContent(http.StatusNotModified)
 	}
 
-	// Filter by user ownership (admins can see all)
-	user := middlewares.GetUser(c)
-	var websites []schema.Website
-	for _, w := range allWebsites {
-		if user.IsAdmin() || (w.UserID != nil && *w.UserID == userID) {
-			websites = append(websites, w)
-		}
+	// Websites the user owns or that were shared with the user or the API
+	// key, all websites for admins
+	websites, total, err := wc.websites.ListAccessible(c.Request().Context(), actor, label, limit, (page-1)*limit)
+	if err != nil {
+		return err
 	}
 
-	// Calculate pagination
-	total := len(websites)
 	totalPages := (total + limit - 1) / limit
 	if totalPages == 0 {
 		totalPages = 1
 	}
 
-	// Get page slice
-	start := (page - 1) * limit
-	end := start + limit
-	if start >= total {
-		start = 0
-		end = 0
-	}
-	if end > total {
-		end = total
-	}
-
-	var pageData interface{}
-	if start < end {
-		pageData = websites[start:end]
-	} else {
-		pageData = []interface{}{}
-	}
-
 	return c.JSON(http.StatusOK, PaginatedResponse{
-		Data:       pageData,
+		Data:       websites,
 		Page:       page,
 		Limit:      limit,
 		Total:      total,
@@ -201,41 +310,38 @@ type PaginatedResponse struct {
 
 // GetPages godoc
 // @Summary      Get pages for a website
-// @Description  Retrieves all crawled pages for a specific website with pagination.
+// @Description  Retrieves all crawled pages for a specific website with pagination. Pages discovered but not indexed have status skipped and a machine-readable skip reason. Each page lists the topics it was tagged with and whether its content was vectorized, with the chunk count or the error of the last failed attempt. Responses carry an ETag; If-None-Match is answered with 304 when no page changed.
+// @ID           getPages
 // @Tags         Websites
 // @Produce      json
-// @Param        id      path      int     true   "Website ID"
-// @Param        page    query     int     false  "Page number"     default(1)
-// @Param        limit   query     int     false  "Items per page"  default(50)
-// @Param        status  query     string  false  "Filter by status (success, error, pending)"
-// @Success      200     {object}  PaginatedResponse
-// @Failure      400     {object}  map[string]string
-// @Failure      500     {object}  map[string]string
+// @Param        id             path      int     true   "Website ID"
+// @Param        page           query     int     false  "Page number"     default(1)
+// @Param        limit          query     int     false  "Items per page"  default(50)
+// @Param        status         query     string  false  "Filter by status (success, error, pending, skipped)"
+// @Param        reason         query     string  false  "Filter skipped pages by reason (robots, pattern, depth, budget, duplicate, quality, size, mime, scope)"
+// @Param        tag            query     string  false  "Filter by tag"
+// @Param        vector_status  query     string  false  "Filter by vectorization status (pending, vectorized, failed)"
+// @Param        If-None-Match  header    string  false  "ETag of a previous response"
+// @Success      200            {object}  PaginatedResponse{data=[]schema.Page}
+// @Success      304            "Not modified"
+// @Failure      400            {object}  apperrors.Response
+// @Failure      500            {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /websites/{id}/pages [get]
 func (wc *WebsiteController) GetPages(c echo.Context) error {
-	userID, err := middlewares.GetUserID(c)
-	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
 	}
 
 	idParam := c.Param("id")
 	websiteID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid website ID"})
+		return apperrors.Validation("Invalid website ID")
 	}
 
-	// Verify ownership
-	website, err := wc.websiteRepo.GetByID(c.Request().Context(), uint(websiteID))
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionRead)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve website"})
-	}
-	if website == nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Website not found"})
-	}
-
-	user := middlewares.GetUser(c)
-	if !user.IsAdmin() && (website.UserID == nil || *website.UserID != userID) {
-		return c.JSON(http.StatusForbidden, map[string]string{"error": "Access denied"})
+		return err
 	}
 
 	// Parse pagination params
@@ -254,20 +360,54 @@ func (wc *WebsiteController) GetPages(c echo.Context) error {
 	}
 
 	status := c.QueryParam("status")
+	reason := c.QueryParam("reason")
+	tag := contentprocessor.NormalizeTag(c.QueryParam("tag"))
+	vectorStatus := c.QueryParam("vector_status")
+
+	// Reply 304 while the website's pages are unchanged
+	version, err := wc.pageRepo.Version(c.Request().Context(), website.ID)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve pages", err)
+	}
+	if notModified(c, "pages", website.ID, version, page, limit, status, reason, tag, vectorStatus) {
+		return c.NoContent(http.StatusNotModified)
+	}
 
 	// Get all pages (for now - TODO: add DB-level pagination)
 	allPages, err := wc.pageRepo.GetByWebsiteID(c.Request().Context(), uint(websiteID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve pages"})
+		return apperrors.Internal("Failed to retrieve pages", err)
+	}
+
+	var tagged map[uint]bool
+	if tag != "" {
+		ids, err := wc.pageRepo.ListIDsByTag(c.Request().Context(), uint(websiteID), tag)
+		if err != nil {
+			return apperrors.Internal("Failed to retrieve pages", err)
+		}
+		tagged = make(map[uint]bool, len(ids))
+		for _, id := range ids {
+			tagged[id] = true
+		}
 	}
 
-	// Filter by status if provided
+	// Filter by status, skip reason, vectorization status and tag if provided
 	var filteredPages []schema.Page
-	if status != "" {
+	if status != "" || reason != "" || vectorStatus != "" || tag != "" {
 		for _, p := range allPages {
-			if p.Status == status {
-				filteredPages = append(filteredPages, p)
+			if status != "" && p.Status != status {
+				continue
+			}
+			if reason != "" && p.SkipReason.String != reason {
+				continue
 			}
+			if vectorStatus != "" && p.VectorStatus.String != vectorStatus {
+				continue
+			}
+			if tag != "" && !tagged[p.ID] {
+				continue
+			}
+			filteredPages = append(filteredPages, p)
 		}
 	} else {
 		filteredPages = allPages
@@ -298,6 +438,20 @@ func (wc *WebsiteController) GetPages(c echo.Context) error {
 		pageData = []schema.Page{}
 	}
 
+	if len(pageData) > 0 {
+		ids := make([]int64, len(pageData))
+		for i, p := range pageData {
+			ids[i] = int64(p.ID)
+		}
+		tags, err := wc.pageRepo.GetTags(c.Request().Context(), ids)
+		if err != nil {
+			return apperrors.Internal("Failed to retrieve page tags", err)
+		}
+		for i := range pageData {
+			pageData[i].Tags = tags[pageData[i].ID]
+		}
+	}
+
 	return c.JSON(http.StatusOK, PaginatedResponse{
 		Data:       pageData,
 		Page:       page,
@@ -307,244 +461,1093 @@ func (wc *WebsiteController) GetPages(c echo.Context) error {
 	})
 }
 
+// GetVisualChanges godoc
+// @Summary      Get visual changes for a website
+// @Description  Lists screenshot snapshots recorded for pages of a visually monitored website, most recent first.
+// @ID           getVisualChanges
+// @Tags         Websites
+// @Produce      json
+// @Param        id         path      int     true   "Website ID"
+// @Param        min_score  query     number  false  "Only return snapshots with at least this change score (0-1)"
+// @Param        limit      query     int     false  "Maximum number of snapshots"  default(50)
+// @Success      200        {array}   schema.VisualSnapshot
+// @Failure      400        {object}  apperrors.Response
+// @Failure      403        {object}  apperrors.Response
+// @Failure      404        {object}  apperrors.Response
+// @Failure      500        {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/visual-changes [get]
+func (wc *WebsiteController) GetVisualChanges(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	if _, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionRead); err != nil {
+		return err
+	}
+
+	minScore := 0.0
+	if scoreParam := c.QueryParam("min_score"); scoreParam != "" {
+		score, err := strconv.ParseFloat(scoreParam, 64)
+		if err != nil || score < 0 || score > 1 {
+			return apperrors.Validation("min_score must be between 0 and 1")
+		}
+		minScore = score
+	}
+
+	limit := 50
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	snapshots, err := wc.snapshotRepo.ListChangesByWebsiteID(c.Request().Context(), uint(websiteID), minScore, limit)
+	if err != nil {
+		wc.logger.Error("Failed to list visual snapshots", zap.Error(err))
+		return apperrors.Internal("Failed to retrieve visual changes", err)
+	}
+
+	if snapshots == nil {
+		snapshots = []schema.VisualSnapshot{}
+	}
+
+	return c.JSON(http.StatusOK, snapshots)
+}
+
+// GetTags godoc
+// @Summary      Get tags for a website
+// @Description  Lists the topics the website's pages were tagged with and the number of pages tagged with each, most used first. Use a tag to filter pages or query retrieval.
+// @ID           getTags
+// @Tags         Websites
+// @Produce      json
+// @Param        id     path      int  true   "Website ID"
+// @Param        limit  query     int  false  "Maximum number of tags"  default(100)
+// @Success      200    {array}   schema.TagCount
+// @Failure      400    {object}  apperrors.Response
+// @Failure      403    {object}  apperrors.Response
+// @Failure      404    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/tags [get]
+func (wc *WebsiteController) GetTags(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	if _, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionRead); err != nil {
+		return err
+	}
+
+	limit := 100
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 500 {
+			limit = l
+		}
+	}
+
+	tags, err := wc.pageRepo.ListTags(c.Request().Context(), uint(websiteID), limit)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve tags", err)
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
+
+// GetCrawlEvents godoc
+// @Summary      Get crawl events for a website
+// @Description  Lists notable crawl events (robots blocks, low quality pages, redirects, errors and traps) for a website, most recent first.
+// @ID           getCrawlEvents
+// @Tags         Websites
+// @Produce      json
+// @Param        id     path      int     true   "Website ID"
+// @Param        page   query     int     false  "Page number"     default(1)
+// @Param        limit  query     int     false  "Items per page"  default(50)
+// @Param        level  query     string  false  "Filter by level (info, warn, error)"
+// @Param        event  query     string  false  "Filter by event (robots_blocked, robots_ignored, low_quality, redirected, error, trapped, budget_exhausted, crawl_stalled)"
+// @Success      200    {object}  PaginatedResponse{data=[]schema.CrawlEvent}
+// @Failure      400    {object}  apperrors.Response
+// @Failure      403    {object}  apperrors.Response
+// @Failure      404    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/crawl-events [get]
+func (wc *WebsiteController) GetCrawlEvents(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	if _, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionRead); err != nil {
+		return err
+	}
+
+	// Parse pagination params
+	page := 1
+	if pageParam := c.QueryParam("page"); pageParam != "" {
+		if p, err := strconv.Atoi(pageParam); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	limit := 50
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	level := c.QueryParam("level")
+	switch level {
+	case "", schema.CrawlEventLevelInfo, schema.CrawlEventLevelWarn, schema.CrawlEventLevelError:
+	default:
+		return apperrors.Validation("level must be one of info, warn, error")
+	}
+
+	events, total, err := wc.eventRepo.ListByWebsiteID(c.Request().Context(), uint(websiteID), level, c.QueryParam("event"), limit, (page-1)*limit)
+	if err != nil {
+		wc.logger.Error("Failed to list crawl events", zap.Error(err))
+		return apperrors.Internal("Failed to retrieve crawl events", err)
+	}
+
+	if events == nil {
+		events = []schema.CrawlEvent{}
+	}
+
+	totalPages := (total + limit - 1) / limit
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       events,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	})
+}
+
 // QueryRequest defines the request body for querying a website.
 type QueryRequest struct {
-	Query string `json:"query" example:"What is this website about?"`
+	Query string `json:"query" validate:"required_without=PreviewID,max=4000" example:"What is this website about?"`
+	// PreviewID reuses the sources returned by the sources preview endpoint
+	// instead of searching again. Query may be omitted when it is set.
+	PreviewID string `json:"preview_id,omitempty" validate:"omitempty,ulid" example:"01HZY8K3J5Q7X9V2B4N6M8P0R1"`
+	// Tags limits retrieval to pages tagged with any of them. They are
+	// ignored with a preview ID, whose sources were already retrieved.
+	Tags []string `json:"tags,omitempty" validate:"max=10,dive,required,max=64" example:"pricing"`
+}
+
+// question returns the service question of the request.
+func (r *QueryRequest) question() service.Question {
+	return service.Question{Query: r.Query, Tags: r.Tags, PreviewID: r.PreviewID}
+}
+
+// streamError returns the body of a stream error event, which can't use the
+// error handler once the stream has started.
+func streamError(c echo.Context, err *apperrors.Error) apperrors.Response {
+	return apperrors.Response{
+		Error:     err.Message,
+		Code:      err.Code,
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+	}
 }
 
 // QueryWebsite godoc
 // @Summary      Query website content using AI
-// @Description  Performs a RAG-based query against the website's indexed content.
+// @Description  Performs a RAG-based query against the website's indexed content. With `Accept: application/x-ndjson` the answer is streamed as newline-delimited JSON records instead: `sources`, `chunk` records with the next `text` of the answer, then `done` with the `query_id`, or `error`.
+// @ID           queryWebsite
 // @Tags         Websites
 // @Accept       json
-// @Produce      json
+// @Produce      json,application/x-ndjson
 // @Param        id     path      int           true  "Website ID"
 // @Param        query  body      QueryRequest  true  "Query"
 // @Success      200    {object}  llm.QueryResponse
-// @Failure      400    {object}  map[string]string
-// @Failure      500    {object}  map[string]string
+// @Failure      400    {object}  apperrors.Response
+// @Failure      409    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /websites/{id}/query [post]
 func (wc *WebsiteController) QueryWebsite(c echo.Context) error {
-	userID, err := middlewares.GetUserID(c)
-	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
 	}
 
 	idParam := c.Param("id")
 	websiteID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid website ID"})
+		return apperrors.Validation("Invalid website ID")
 	}
 
-	// Verify ownership
-	website, err := wc.websiteRepo.GetByID(c.Request().Context(), uint(websiteID))
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionQuery)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve website"})
+		return err
 	}
-	if website == nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Website not found"})
+
+	var req QueryRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
 	}
 
-	user := middlewares.GetUser(c)
-	if !user.IsAdmin() && (website.UserID == nil || *website.UserID != userID) {
-		return c.JSON(http.StatusForbidden, map[string]string{"error": "Access denied"})
+	if acceptsNDJSON(c) {
+		return wc.streamQuery(c, website, req, &ndjsonStreamWriter{c: c})
+	}
+
+	response, err := wc.queries.Query(c.Request().Context(), requestActor(c), website, req.question())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// PreviewQuerySources godoc
+// @Summary      Preview sources for a query
+// @Description  Runs the vector search for a query and returns the matching sources without generating an answer. Pass the returned preview_id to the query or streaming query endpoint to generate the answer from these sources.
+// @ID           previewQuerySources
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int           true  "Website ID"
+// @Param        query  body      QueryRequest  true  "Query"
+// @Success      200    {object}  llm.SourcesPreview
+// @Failure      400    {object}  apperrors.Response
+// @Failure      403    {object}  apperrors.Response
+// @Failure      404    {object}  apperrors.Response
+// @Failure      409    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/query/sources [post]
+func (wc *WebsiteController) PreviewQuerySources(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionQuery)
+	if err != nil {
+		return err
 	}
 
 	var req QueryRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return apperrors.Validation("Invalid request payload")
 	}
 
-	if req.Query == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Query cannot be empty"})
+	if err := c.Validate(&req); err != nil {
+		return err
 	}
 
-	response, err := wc.ragService.Query(c.Request().Context(), uint(websiteID), req.Query)
+	preview, err := wc.queries.PreviewSources(c.Request().Context(), website, req.question())
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to process query"})
+		return err
 	}
 
-	return c.JSON(http.StatusOK, response)
+	return c.JSON(http.StatusOK, preview)
+}
+
+// GetSuggestedQuestions godoc
+// @Summary      Get suggested questions for a website
+// @Description  Returns questions users could ask about the website, generated by the LLM from a sample of its indexed content. Questions are generated again after each completed crawl. Websites without indexed content return no questions.
+// @ID           getSuggestedQuestions
+// @Tags         Websites
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      200  {object}  schema.SuggestedQuestions
+// @Failure      400  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Failure      503  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/suggested-questions [get]
+func (wc *WebsiteController) GetSuggestedQuestions(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionQuery)
+	if err != nil {
+		return err
+	}
+
+	suggested, err := wc.queries.Suggest(c.Request().Context(), website)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, suggested)
 }
 
 // QueryWebsiteStream godoc
 // @Summary      Query website content (streaming)
-// @Description  Ask questions about website content using AI with Server-Sent Events streaming
+// @Description  Ask questions about website content using AI with Server-Sent Events streaming. With `Accept: application/x-ndjson` the answer is streamed as newline-delimited JSON records instead: `sources`, `chunk` records with the next `text` of the answer, then `done` with the `query_id`, or `error`.
+// @ID           queryWebsiteStream
 // @Tags         Websites
 // @Accept       json
-// @Produce      text/event-stream
+// @Produce      text/event-stream,application/x-ndjson
 // @Param        id     path      int                   true  "Website ID"
 // @Param        query  body      QueryRequest   true  "Query"
-// @Success      200    {string}  string                "SSE stream of answer chunks"
-// @Failure      400    {object}  map[string]string
-// @Failure      500    {object}  map[string]string
+// @Success      200    {string}  string                "SSE stream of JSON events: start, sources, chunk, metadata, done, error"
+// @Failure      400    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /websites/{id}/query/stream [post]
 func (wc *WebsiteController) QueryWebsiteStream(c echo.Context) error {
-	userID, err := middlewares.GetUserID(c)
-	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
 	}
 
 	idParam := c.Param("id")
 	websiteID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid website ID"})
+		return apperrors.Validation("Invalid website ID")
 	}
 
-	// Verify ownership
-	website, err := wc.websiteRepo.GetByID(c.Request().Context(), uint(websiteID))
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionQuery)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve website"})
+		return err
 	}
-	if website == nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Website not found"})
+
+	var req QueryRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
 	}
 
-	user := middlewares.GetUser(c)
-	if !user.IsAdmin() && (website.UserID == nil || *website.UserID != userID) {
-		return c.JSON(http.StatusForbidden, map[string]string{"error": "Access denied"})
+	if err := c.Validate(&req); err != nil {
+		return err
 	}
 
-	var req QueryRequest
+	return wc.streamQuery(c, website, req, newQueryStreamWriter(c))
+}
+
+// writeSSE writes a single Server-Sent Event with a JSON-encoded payload and
+// flushes it to the client.
+func writeSSE(c echo.Context, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode SSE payload: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(c.Response(), "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	c.Response().Flush()
+
+	return nil
+}
+
+// SubmitQueryFeedback godoc
+// @Summary      Rate a query answer
+// @Description  Records thumbs up/down feedback and an optional comment for a previous query.
+// @ID           submitQueryFeedback
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                          true  "Website ID"
+// @Param        queryID   path      int                          true  "Query ID"
+// @Param        feedback  body      schema.QueryFeedbackRequest  true  "Feedback"
+// @Success      200       {object}  schema.QueryFeedback
+// @Failure      400       {object}  apperrors.Response
+// @Failure      403       {object}  apperrors.Response
+// @Failure      404       {object}  apperrors.Response
+// @Failure      500       {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/queries/{queryID}/feedback [post]
+func (wc *WebsiteController) SubmitQueryFeedback(c echo.Context) error {
+	userID, err := middlewares.GetUserID(c)
+	if err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	websiteID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	queryID, err := strconv.ParseUint(c.Param("queryID"), 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid query ID")
+	}
+
+	if _, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionQuery); err != nil {
+		return err
+	}
+
+	var req schema.QueryFeedbackRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return apperrors.Validation("Invalid request payload")
 	}
 
-	if req.Query == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Query cannot be empty"})
+	if err := c.Validate(&req); err != nil {
+		return err
 	}
 
-	// Set headers for SSE
-	c.Response().Header().Set("Content-Type", "text/event-stream")
-	c.Response().Header().Set("Cache-Control", "no-cache")
-	c.Response().Header().Set("Connection", "keep-alive")
-	c.Response().Header().Set("X-Accel-Buffering", "no")
+	var rating int
+	switch req.Rating {
+	case "up":
+		rating = schema.FeedbackThumbsUp
+	case "down":
+		rating = schema.FeedbackThumbsDown
+	default:
+		return apperrors.Validation("Rating must be 'up' or 'down'")
+	}
+
+	queryLog, err := wc.queryLogRepo.GetByID(c.Request().Context(), uint(queryID))
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve query", err)
+	}
+	if queryLog == nil || queryLog.WebsiteID != uint(websiteID) {
+		return apperrors.NotFound("Query not found")
+	}
 
-	c.Response().WriteHeader(http.StatusOK)
+	feedback := &schema.QueryFeedback{
+		QueryLogID: queryLog.ID,
+		UserID:     &userID,
+		Rating:     rating,
+		Comment:    sql.NullString{String: req.Comment, Valid: req.Comment != ""},
+	}
 
-	// Send initial event
-	fmt.Fprintf(c.Response(), "event: start\ndata: {\"query\":\"%s\"}\n\n", req.Query)
-	c.Response().Flush()
+	if err := wc.queryLogRepo.UpsertFeedback(c.Request().Context(), feedback); err != nil {
+		wc.logger.Error("Failed to save query feedback", zap.Error(err))
+		return apperrors.Internal("Failed to save feedback", err)
+	}
 
-	// Stream the response
-	meta, err := wc.ragService.QueryStream(c.Request().Context(), uint(websiteID), req.Query, func(chunk string) error {
-		// Send each chunk as SSE
-		fmt.Fprintf(c.Response(), "event: chunk\ndata: %s\n\n", chunk)
-		c.Response().Flush()
-		return nil
-	})
+	return c.JSON(http.StatusOK, feedback)
+}
 
+// GetFeedbackStats godoc
+// @Summary      Get query feedback statistics
+// @Description  Aggregates query volume, latency and feedback ratings per website (admin only).
+// @ID           getFeedbackStats
+// @Tags         Admin
+// @Produce      json
+// @Param        website_id  query     int  false  "Restrict to a single website"
+// @Success      200         {array}   schema.FeedbackStats
+// @Failure      400         {object}  apperrors.Response
+// @Failure      500         {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /admin/feedback/stats [get]
+func (wc *WebsiteController) GetFeedbackStats(c echo.Context) error {
+	var websiteID uint64
+	if param := c.QueryParam("website_id"); param != "" {
+		id, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			return apperrors.Validation("Invalid website ID")
+		}
+		websiteID = id
+	}
+
+	stats, err := wc.queryLogRepo.GetFeedbackStats(c.Request().Context(), uint(websiteID))
 	if err != nil {
-		wc.logger.Error("Failed to process streaming query", zap.Error(err))
-		fmt.Fprintf(c.Response(), "event: error\ndata: {\"error\":\"Failed to process query\"}\n\n")
-		c.Response().Flush()
-		return nil
+		wc.logger.Error("Failed to get feedback stats", zap.Error(err))
+		return apperrors.Internal("Failed to get feedback stats", err)
 	}
 
-	// Send metadata with sources
-	fmt.Fprintf(c.Response(), "event: metadata\ndata: {\"retrieved_chunks\":%d,\"sources_count\":%d}\n\n",
-		meta.RetrievedChunks, len(meta.Sources))
-	c.Response().Flush()
+	if stats == nil {
+		stats = []schema.FeedbackStats{}
+	}
 
-	// Send done event
-	fmt.Fprintf(c.Response(), "event: done\ndata: {\"status\":\"complete\"}\n\n")
-	c.Response().Flush()
+	return c.JSON(http.StatusOK, stats)
+}
 
-	return nil
+// WebsiteStatusResponse is a website with the breakdown of its failed pages
+// and the progress of its current or last crawl.
+type WebsiteStatusResponse struct {
+	*schema.Website
+	// ErrorStatusCodes counts failed pages per HTTP status, "0" being network errors
+	ErrorStatusCodes map[string]int `json:"error_status_codes"`
+	Progress         CrawlProgress  `json:"progress"`
+}
+
+// CrawlProgress aggregates live crawl data for progress dashboards.
+type CrawlProgress struct {
+	// Job is the crawl task in the queue, nil when none is queued or running
+	Job *jobs.CrawlJobStatus `json:"job,omitempty"`
+	// PagesByStatus counts pages processed since the crawl started
+	PagesByStatus  map[string]int `json:"pages_by_status"`
+	PagesProcessed int            `json:"pages_processed"`
+	PagesLimit     int            `json:"pages_limit,omitempty"`
+	VectorCount    *int           `json:"vector_count,omitempty"`
+	ElapsedSeconds int            `json:"elapsed_seconds,omitempty"`
+	// ETASeconds is only set while crawling and assumes the page budget is
+	// used up, so it is an upper bound for sites with fewer pages
+	ETASeconds            *int                `json:"eta_seconds,omitempty"`
+	EstimatedCompletionAt *time.Time          `json:"estimated_completion_at,omitempty"`
+	RecentErrors          []schema.CrawlEvent `json:"recent_errors"`
+	// Unavailable lists the sources that could not be reached, their fields
+	// are left empty
+	Unavailable []string `json:"unavailable,omitempty"`
 }
 
+// statusRecentErrors is the number of crawl errors returned by the status endpoint.
+const statusRecentErrors = 5
+
 // GetWebsiteStatus godoc
 // @Summary      Get website crawl status
-// @Description  Retrieves the current crawl status and statistics for a website.
+// @Description  Retrieves the current crawl status and statistics for a website, including the number of failed pages per HTTP status, the crawl job's queue position, pages processed so far, the vector count, an ETA and the last crawl errors. While no crawl is running, responses carry an ETag and If-None-Match is answered with 304 when nothing changed.
+// @ID           getWebsiteStatus
 // @Tags         Websites
 // @Produce      json
-// @Param        id   path      int  true  "Website ID"
-// @Success      200  {object}  schema.Website
-// @Failure      400  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Param        id             path      int     true   "Website ID"
+// @Param        If-None-Match  header    string  false  "ETag of a previous response"
+// @Success      200            {object}  WebsiteStatusResponse
+// @Success      304            "Not modified"
+// @Failure      400  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /websites/{id}/status [get]
 func (wc *WebsiteController) GetWebsiteStatus(c echo.Context) error {
-	userID, err := middlewares.GetUserID(c)
-	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
 	}
 
 	idParam := c.Param("id")
 	websiteID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid website ID"})
+		return apperrors.Validation("Invalid website ID")
 	}
 
-	website, err := wc.websiteRepo.GetByID(c.Request().Context(), uint(websiteID))
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionRead)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve website"})
+		return err
 	}
 
-	if website == nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Website not found"})
+	// The progress of a running crawl changes every second, so only the
+	// status of idle websites is revalidated
+	if website.CrawlStatus != "crawling" {
+		etag, err := wc.statusVersion(c.Request().Context(), website)
+		if err != nil {
+			return err
+		}
+		if notModified(c, "status", etag) {
+			return c.NoContent(http.StatusNotModified)
+		}
 	}
 
-	user := middlewares.GetUser(c)
-	if !user.IsAdmin() && (website.UserID == nil || *website.UserID != userID) {
-		return c.JSON(http.StatusForbidden, map[string]string{"error": "Access denied"})
+	counts, err := wc.pageRepo.CountErrorsByHTTPStatus(c.Request().Context(), website.ID)
+	if err != nil {
+		wc.logger.Error("Failed to count page errors", zap.Uint("websiteID", website.ID), zap.Error(err))
+		return apperrors.Internal("Failed to retrieve crawl statistics", err)
 	}
 
-	return c.JSON(http.StatusOK, website)
+	errorStatusCodes := make(map[string]int, len(counts))
+	for status, count := range counts {
+		errorStatusCodes[strconv.Itoa(status)] = count
+	}
+
+	progress, err := wc.crawlProgress(c.Request().Context(), website)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, WebsiteStatusResponse{
+		Website:          website,
+		ErrorStatusCodes: errorStatusCodes,
+		Progress:         progress,
+	})
+}
+
+// statusVersion gathers what the status of a website that isn't crawling
+// depends on, without the aggregate queries of the status itself. The crawl
+// job and vector count live outside the database and change on their own.
+func (wc *WebsiteController) statusVersion(ctx context.Context, website *schema.Website) ([]any, error) {
+	pages, err := wc.pageRepo.Version(ctx, website.ID)
+	if err != nil {
+		return nil, apperrors.Internal("Failed to retrieve crawl statistics", err)
+	}
+
+	lastEvent, err := wc.eventRepo.LatestID(ctx, website.ID)
+	if err != nil {
+		return nil, apperrors.Internal("Failed to retrieve crawl errors", err)
+	}
+
+	// Errors are part of the version as the status reports them as unavailable
+	job, jobErr := wc.jobClient.GetCrawlJobStatus(ctx, website.ID)
+	vectorCount, vectorErr := wc.vectorSvc.GetWebsiteVectorCount(ctx, website.ID)
+
+	return []any{website.ID, website.UpdatedAt, pages, lastEvent, job, jobErr != nil, vectorCount, vectorErr != nil}, nil
+}
+
+// crawlProgress gathers the progress of a website's current or last crawl.
+// Redis and ChromaDB being unreachable is reported in Unavailable instead of
+// failing the request.
+func (wc *WebsiteController) crawlProgress(ctx context.Context, website *schema.Website) (CrawlProgress, error) {
+	progress := CrawlProgress{PagesLimit: wc.cfg.CrawlerMaxPages}
+
+	var since time.Time
+	if website.CrawlStartedAt.Valid {
+		since = website.CrawlStartedAt.Time
+	}
+
+	counts, err := wc.pageRepo.CountByStatus(ctx, website.ID, since)
+	if err != nil {
+		return progress, apperrors.Internal("Failed to retrieve crawl statistics", err)
+	}
+	progress.PagesByStatus = counts
+	for _, count := range counts {
+		progress.PagesProcessed += count
+	}
+
+	events, _, err := wc.eventRepo.ListByWebsiteID(ctx, website.ID, schema.CrawlEventLevelError, "", statusRecentErrors, 0)
+	if err != nil {
+		return progress, apperrors.Internal("Failed to retrieve crawl errors", err)
+	}
+	progress.RecentErrors = events
+	if progress.RecentErrors == nil {
+		progress.RecentErrors = []schema.CrawlEvent{}
+	}
+
+	job, err := wc.jobClient.GetCrawlJobStatus(ctx, website.ID)
+	if err != nil {
+		wc.logger.Warn("Failed to get crawl job status", zap.Uint("websiteID", website.ID), zap.Error(err))
+		progress.Unavailable = append(progress.Unavailable, "job")
+	}
+	progress.Job = job
+
+	vectorCount, err := wc.vectorSvc.GetWebsiteVectorCount(ctx, website.ID)
+	if err != nil {
+		wc.logger.Warn("Failed to count website vectors", zap.Uint("websiteID", website.ID), zap.Error(err))
+		progress.Unavailable = append(progress.Unavailable, "vector_count")
+	} else {
+		progress.VectorCount = &vectorCount
+	}
+
+	if website.CrawlStatus != "crawling" || since.IsZero() {
+		return progress, nil
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(since)
+	progress.ElapsedSeconds = int(elapsed.Seconds())
+
+	maxDuration := time.Duration(wc.cfg.CrawlerMaxDuration) * time.Second
+	if website.MaxCrawlDurationSeconds > 0 {
+		maxDuration = time.Duration(website.MaxCrawlDurationSeconds) * time.Second
+	}
+
+	if eta, ok := estimateCrawlETA(elapsed, progress.PagesProcessed, progress.PagesLimit, maxDuration); ok {
+		seconds := int(eta.Seconds())
+		completion := now.Add(eta)
+		progress.ETASeconds = &seconds
+		progress.EstimatedCompletionAt = &completion
+	}
+
+	return progress, nil
+}
+
+// estimateCrawlETA estimates the remaining time of a crawl from its page rate
+// so far, capped by the duration budget. It reports false when there is not
+// enough data or no limit to estimate against.
+func estimateCrawlETA(elapsed time.Duration, processed, pageLimit int, maxDuration time.Duration) (time.Duration, bool) {
+	var eta time.Duration
+	var ok bool
+
+	if pageLimit > 0 && processed > 0 && elapsed > 0 {
+		remaining := pageLimit - processed
+		if remaining < 0 {
+			remaining = 0
+		}
+		eta = time.Duration(float64(elapsed) / float64(processed) * float64(remaining))
+		ok = true
+	}
+
+	if maxDuration > 0 {
+		left := maxDuration - elapsed
+		if left < 0 {
+			left = 0
+		}
+		if !ok || left < eta {
+			eta = left
+			ok = true
+		}
+	}
+
+	return eta.Round(time.Second), ok
 }
 
 // RecrawlWebsite godoc
 // @Summary      Trigger website re-crawl
-// @Description  Manually triggers a re-crawl of a website.
+// @Description  Manually triggers a re-crawl of a website. A website still marked as crawling is only recrawled when no crawl job of it is queued or running, e.g. after its worker crashed. Outside the website's crawl window the crawl is scheduled for when the window opens, with status "scheduled" and scheduled_at.
+// @ID           recrawlWebsite
 // @Tags         Websites
 // @Produce      json
 // @Param        id   path      int  true  "Website ID"
-// @Success      200  {object}  map[string]string
-// @Failure      400  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      409  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Success      200  {object}  EnqueuedResponse
+// @Failure      400  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      409  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
 // @Router       /websites/{id}/recrawl [post]
 func (wc *WebsiteController) RecrawlWebsite(c echo.Context) error {
-	userID, err := middlewares.GetUserID(c)
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	scheduledAt, err := wc.websites.Recrawl(c.Request().Context(), website)
+	if err != nil {
+		return err
+	}
+
+	if !scheduledAt.IsZero() {
+		return c.JSON(http.StatusOK, EnqueuedResponse{
+			Message:     "Re-crawl job scheduled for the website's crawl window",
+			Status:      "scheduled",
+			ScheduledAt: &scheduledAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, EnqueuedResponse{
+		Message: "Re-crawl job enqueued",
+		Status:  "pending",
+	})
+}
+
+// ReindexWebsite godoc
+// @Summary      Re-embed website content
+// @Description  Drops the vectors of a website and embeds its stored page content again with the configured embedding model, without crawling. Use it after changing the embedding model. The report is stored as the job result.
+// @ID           reindexWebsite
+// @Tags         Websites
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      202  {object}  EnqueuedResponse
+// @Failure      400  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      409  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/reindex [post]
+func (wc *WebsiteController) ReindexWebsite(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	taskID, err := wc.websites.Reindex(c.Request().Context(), website)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, EnqueuedResponse{
+		Message: "Reindex job enqueued",
+		TaskID:  taskID,
+	})
+}
+
+// RevectorizeFailedPages godoc
+// @Summary      Re-vectorize failed pages
+// @Description  Embeds the stored content of the website's pages whose vectorization failed again, e.g. after the embedding service was unavailable. The pages are listed with GET /websites/{id}/pages?vector_status=failed.
+// @ID           revectorizeFailedPages
+// @Tags         Websites
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      202  {object}  EnqueuedResponse
+// @Failure      400  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      409  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/pages/revectorize [post]
+func (wc *WebsiteController) RevectorizeFailedPages(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+		return err
+	}
+
+	taskID, err := wc.websites.RevectorizeFailedPages(c.Request().Context(), website)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, EnqueuedResponse{
+		Message: "Revectorize job enqueued",
+		TaskID:  taskID,
+	})
+}
+
+// ReprocessPage godoc
+// @Summary      Reprocess a page
+// @Description  Runs content extraction, chunking and vectorization of a page again from the raw HTML archived during its last fetch, without fetching it again, e.g. after the extraction was improved. HTML is archived when the server sets ARCHIVE_RAW_HTML; pages fetched before have none until they are recrawled.
+// @ID           reprocessPage
+// @Tags         Websites
+// @Produce      json
+// @Param        id      path      int  true  "Website ID"
+// @Param        pageID  path      int  true  "Page ID"
+// @Success      202     {object}  EnqueuedResponse
+// @Failure      400     {object}  apperrors.Response
+// @Failure      403     {object}  apperrors.Response
+// @Failure      404     {object}  apperrors.Response
+// @Failure      409     {object}  apperrors.Response
+// @Failure      500     {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/pages/{pageID}/reprocess [post]
+func (wc *WebsiteController) ReprocessPage(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
 	}
 
 	idParam := c.Param("id")
 	websiteID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid website ID"})
+		return apperrors.Validation("Invalid website ID")
 	}
 
-	website, err := wc.websiteRepo.GetByID(c.Request().Context(), uint(websiteID))
+	pageID, err := strconv.ParseUint(c.Param("pageID"), 10, 32)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve website"})
+		return apperrors.Validation("Invalid page ID")
 	}
 
-	if website == nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Website not found"})
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
 	}
 
-	user := middlewares.GetUser(c)
-	if !user.IsAdmin() && (website.UserID == nil || *website.UserID != userID) {
-		return c.JSON(http.StatusForbidden, map[string]string{"error": "Access denied"})
+	taskID, err := wc.websites.ReprocessPage(c.Request().Context(), website, uint(pageID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, EnqueuedResponse{
+		Message: "Reprocess job enqueued",
+		TaskID:  taskID,
+	})
+}
+
+// CrawlScopeRequest defines the request body for changing the start URLs and
+// crawl scope of a website.
+type CrawlScopeRequest struct {
+	SeedURLs []string `json:"seed_urls" validate:"max=20,dive,weburl" example:"https://example.com/docs/"`
+	Scope    []string `json:"scope" validate:"max=20,dive,weburl" example:"https://example.com/docs/"`
+}
+
+// SetCrawlScope godoc
+// @Summary      Set the crawl scope
+// @Description  Replaces the additional start URLs of a website and the URL prefixes its crawls are restricted to. An empty scope crawls the whole host. Applies from the next crawl; pages crawled before are kept.
+// @ID           setCrawlScope
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                true  "Website ID"
+// @Param        request  body      CrawlScopeRequest  true  "Start URLs and scope"
+// @Success      200      {object}  schema.Website
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/crawl-scope [put]
+func (wc *WebsiteController) SetCrawlScope(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
 	}
 
-	// Check if already crawling
-	if website.CrawlStatus == "crawling" {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "Website is already being crawled"})
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
 	}
 
-	// Enqueue recrawl job
-	err = wc.jobClient.EnqueueRecrawlWebsite(c.Request().Context(), uint(websiteID))
+	var req CrawlScopeRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if err := wc.websites.SetCrawlScope(c.Request().Context(), website, req.SeedURLs, req.Scope); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, website)
+}
+
+// ContentQualityRequest defines the request body for changing the minimum
+// content quality of a website's pages.
+type ContentQualityRequest struct {
+	// Minimum quality between 0 and 1, 0 uses the server default
+	MinContentQuality float64 `json:"min_content_quality" validate:"gte=0,lte=1" example:"0.5"`
+}
+
+// SetContentQuality godoc
+// @Summary      Set the minimum content quality
+// @Description  Sets the quality score pages of the website need to be indexed, replacing the server default. Pages scoring lower are skipped with reason quality. Applies from the next crawl; pages indexed before are kept.
+// @ID           setContentQuality
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                    true  "Website ID"
+// @Param        request  body      ContentQualityRequest  true  "Minimum quality"
+// @Success      200      {object}  schema.Website
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/content-quality [put]
+func (wc *WebsiteController) SetContentQuality(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req ContentQualityRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if err := wc.websites.SetContentQuality(c.Request().Context(), website, req.MinContentQuality); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, website)
+}
+
+// IndexPagesRequest defines the request body for indexing specific pages.
+type IndexPagesRequest struct {
+	URL  string   `json:"url,omitempty" example:"https://example.com/changelog"`
+	URLs []string `json:"urls,omitempty"`
+}
+
+// IndexPages godoc
+// @Summary      Index specific pages now
+// @Description  Fetches and indexes the given pages of a website right away on the critical queue without a full crawl. Links on the pages are not followed. The per-page results are stored as the job result.
+// @ID           indexPages
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                true  "Website ID"
+// @Param        request  body      IndexPagesRequest  true  "Pages to index"
+// @Success      202      {object}  IndexPagesResponse
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/pages [post]
+func (wc *WebsiteController) IndexPages(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req IndexPagesRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	rawURLs := req.URLs
+	if req.URL != "" {
+		rawURLs = append([]string{req.URL}, rawURLs...)
+	}
+	urls, taskID, err := wc.websites.IndexPages(c.Request().Context(), website, rawURLs)
 	if err != nil {
-		wc.logger.Error("Failed to enqueue recrawl job", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to enqueue recrawl job"})
+		return err
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "Re-crawl job enqueued",
-		"status":  "pending",
+	return c.JSON(http.StatusAccepted, IndexPagesResponse{
+		EnqueuedResponse: EnqueuedResponse{
+			Message: "Index job enqueued",
+			TaskID:  taskID,
+		},
+		URLs: urls,
 	})
 }
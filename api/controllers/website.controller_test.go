@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/config"
+	"hermit/internal/jobs"
+	"hermit/internal/schema"
+	"hermit/internal/service"
+	"hermit/internal/service/servicefake"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+)
+
+// websiteFixture is a WebsiteController on the fakes of the servicefake
+// package, with websites of two users.
+type websiteFixture struct {
+	controller *WebsiteController
+	websites   *servicefake.Websites
+	jobs       *servicefake.Jobs
+	alice      *schema.User
+	bob        *schema.User
+}
+
+// statusPages answers the page queries of the website status, other page
+// queries are not expected.
+type statusPages struct {
+	PageStore
+}
+
+func (statusPages) CountByStatus(ctx context.Context, websiteID uint, since time.Time) (map[string]int, error) {
+	return map[string]int{"success": 3, "error": 1}, nil
+}
+
+func (statusPages) CountErrorsByHTTPStatus(ctx context.Context, websiteID uint) (map[int]int, error) {
+	return map[int]int{404: 1}, nil
+}
+
+func (statusPages) Version(ctx context.Context, websiteID uint) (schema.Version, error) {
+	return schema.Version{}, nil
+}
+
+// noEvents is a CrawlEventStore without events.
+type noEvents struct{}
+
+func (noEvents) LatestID(ctx context.Context, websiteID uint) (uint, error) {
+	return 0, nil
+}
+
+func (noEvents) ListByWebsiteID(ctx context.Context, websiteID uint, level, event string, limit, offset int) ([]schema.CrawlEvent, int, error) {
+	return nil, 0, nil
+}
+
+// unreachableQueue is a JobQueue whose Redis is down.
+type unreachableQueue struct{}
+
+func (unreachableQueue) EnqueueEvaluateRAG(ctx context.Context, websiteID, runID uint) error {
+	return errors.New("redis unreachable")
+}
+
+func (unreachableQueue) GetCrawlJobStatus(ctx context.Context, websiteID uint) (*jobs.CrawlJobStatus, error) {
+	return nil, errors.New("redis unreachable")
+}
+
+// fixedVectors is a VectorCounter counting the same vectors for every website.
+type fixedVectors int
+
+func (v fixedVectors) GetWebsiteVectorCount(ctx context.Context, websiteID uint) (int, error) {
+	return int(v), nil
+}
+
+func newWebsiteFixture(t *testing.T) *websiteFixture {
+	t.Helper()
+	alice := &schema.User{ID: ulid.Make(), Email: "alice@example.com", Role: "user"}
+	bob := &schema.User{ID: ulid.Make(), Email: "bob@example.com", Role: "user"}
+
+	websites := servicefake.NewWebsites(
+		schema.Website{ID: 1, URL: "https://docs.example.com", UserID: &alice.ID, CrawlStatus: "completed", Labels: []string{"docs"}},
+		schema.Website{ID: 2, URL: "https://blog.example.com", UserID: &alice.ID, CrawlStatus: "completed", Labels: []string{"blog"}},
+		schema.Website{ID: 3, URL: "https://bob.example.com", UserID: &bob.ID, CrawlStatus: "completed", Labels: []string{"docs"}},
+	)
+	queue := servicefake.NewJobs()
+	websiteService := service.NewWebsiteService(websites, servicefake.NewPages(), servicefake.NewUsers(),
+		servicefake.NewPermissions(), queue, servicefake.NewContent(), &servicefake.Answers{}, zap.NewNop())
+
+	controller := NewWebsiteController(WebsiteControllerParams{
+		PageRepo:  statusPages{},
+		EventRepo: noEvents{},
+		JobClient: unreachableQueue{},
+		VectorSvc: fixedVectors(42),
+		Websites:  websiteService,
+		Config:    &config.Config{CrawlerMaxPages: 100},
+		Logger:    zap.NewNop(),
+	})
+
+	return &websiteFixture{controller: controller, websites: websites, jobs: queue, alice: alice, bob: bob}
+}
+
+// request builds the context of a request by user to the website ID in the
+// path, if any.
+func (f *websiteFixture) request(user *schema.User, method, target, id, body string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserContextKey, user))
+
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	e.Validator = middlewares.NewRequestValidator()
+	c := e.NewContext(req, rec)
+	if id != "" {
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+	}
+	return c, rec
+}
+
+func TestListWebsitesFiltersByLabel(t *testing.T) {
+	f := newWebsiteFixture(t)
+
+	c, rec := f.request(f.alice, http.MethodGet, "/api/websites?tag=Docs", "", "")
+	if err := f.controller.ListWebsites(c); err != nil {
+		t.Fatalf("ListWebsites() error = %v", err)
+	}
+
+	var resp struct {
+		Data  []schema.Website `json:"data"`
+		Total int              `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	// Bob's website with the label is not listed
+	if resp.Total != 1 || len(resp.Data) != 1 || resp.Data[0].ID != 1 {
+		t.Errorf("ListWebsites(tag=Docs) = %+v, want only website 1", resp)
+	}
+}
+
+func TestListWebsitesPaginates(t *testing.T) {
+	f := newWebsiteFixture(t)
+
+	c, rec := f.request(f.alice, http.MethodGet, "/api/websites?page=2&limit=1", "", "")
+	if err := f.controller.ListWebsites(c); err != nil {
+		t.Fatalf("ListWebsites() error = %v", err)
+	}
+
+	var resp struct {
+		Data       []schema.Website `json:"data"`
+		Total      int              `json:"total"`
+		TotalPages int              `json:"total_pages"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Total != 2 || resp.TotalPages != 2 || len(resp.Data) != 1 || resp.Data[0].ID != 2 {
+		t.Errorf("ListWebsites(page=2, limit=1) = %+v, want website 2 of 2", resp)
+	}
+}
+
+func TestSetLabels(t *testing.T) {
+	f := newWebsiteFixture(t)
+
+	c, rec := f.request(f.alice, http.MethodPut, "/api/websites/2/labels", "2", `{"labels":["Internal Docs","internal-docs"," Blog "]}`)
+	if err := f.controller.SetLabels(c); err != nil {
+		t.Fatalf("SetLabels() error = %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("SetLabels() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	website, _ := f.websites.GetByID(context.Background(), 2)
+	if want := []string{"internal-docs", "blog"}; !slices.Equal(website.Labels, want) {
+		t.Errorf("Labels = %v, want %v", website.Labels, want)
+	}
+
+	tooLong := `{"labels":["` + strings.Repeat("a", schema.MaxWebsiteLabelLength+1) + `"]}`
+	c, _ = f.request(f.alice, http.MethodPut, "/api/websites/2/labels", "2", tooLong)
+	if err := f.controller.SetLabels(c); !apperrors.Is(err, apperrors.CodeValidation) {
+		t.Errorf("SetLabels() of a too long label error = %v, want a validation error", err)
+	}
+
+	// Bob can't label Alice's website
+	c, _ = f.request(f.bob, http.MethodPut, "/api/websites/1/labels", "1", `{"labels":["mine"]}`)
+	if err := f.controller.SetLabels(c); !apperrors.Is(err, apperrors.CodeForbidden) {
+		t.Errorf("SetLabels() of another user's website error = %v, want forbidden", err)
+	}
+	website, _ = f.websites.GetByID(context.Background(), 1)
+	if want := []string{"docs"}; !slices.Equal(website.Labels, want) {
+		t.Errorf("Labels of website 1 = %v, want %v unchanged", website.Labels, want)
+	}
+}
+
+func TestRecrawlWebsite(t *testing.T) {
+	f := newWebsiteFixture(t)
+
+	// Bob can't recrawl Alice's website
+	c, _ := f.request(f.bob, http.MethodPost, "/api/websites/1/recrawl", "1", "")
+	if err := f.controller.RecrawlWebsite(c); !apperrors.Is(err, apperrors.CodeForbidden) {
+		t.Errorf("RecrawlWebsite() of another user's website error = %v, want forbidden", err)
+	}
+	if queued := f.jobs.Queued(); len(queued) != 0 {
+		t.Fatalf("queued %+v for another user's website, want nothing", queued)
+	}
+
+	c, rec := f.request(f.alice, http.MethodPost, "/api/websites/1/recrawl", "1", "")
+	if err := f.controller.RecrawlWebsite(c); err != nil {
+		t.Fatalf("RecrawlWebsite() error = %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("RecrawlWebsite() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	queued := f.jobs.Queued()
+	if len(queued) != 1 || queued[0].Type != jobs.TypeRecrawlWebsite || queued[0].WebsiteID != 1 {
+		t.Fatalf("queued %+v, want a recrawl of website 1", queued)
+	}
+
+	// A second recrawl is refused while the first is queued
+	c, _ = f.request(f.alice, http.MethodPost, "/api/websites/1/recrawl", "1", "")
+	if err := f.controller.RecrawlWebsite(c); !apperrors.Is(err, apperrors.CodeConflict) {
+		t.Errorf("RecrawlWebsite() while queued error = %v, want conflict", err)
+	}
+}
+
+func TestGetWebsiteStatusReportsUnavailableQueue(t *testing.T) {
+	f := newWebsiteFixture(t)
+
+	c, rec := f.request(f.alice, http.MethodGet, "/api/websites/1/status", "1", "")
+	if err := f.controller.GetWebsiteStatus(c); err != nil {
+		t.Fatalf("GetWebsiteStatus() error = %v", err)
+	}
+
+	var resp WebsiteStatusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !slices.Equal(resp.Progress.Unavailable, []string{"job"}) {
+		t.Errorf("Unavailable = %v, want [job]", resp.Progress.Unavailable)
+	}
+	if resp.Progress.VectorCount == nil || *resp.Progress.VectorCount != 42 {
+		t.Errorf("VectorCount = %v, want 42", resp.Progress.VectorCount)
+	}
+	if resp.Progress.PagesProcessed != 4 || resp.ErrorStatusCodes["404"] != 1 {
+		t.Errorf("status = %+v, want 4 pages processed and one 404", resp)
+	}
+
+	// Bob can't see the status of Alice's website
+	c, _ = f.request(f.bob, http.MethodGet, "/api/websites/1/status", "1", "")
+	if err := f.controller.GetWebsiteStatus(c); !apperrors.Is(err, apperrors.CodeForbidden) {
+		t.Errorf("GetWebsiteStatus() of another user's website error = %v, want forbidden", err)
+	}
+}
+
+func TestQueryLabelScopesWebsites(t *testing.T) {
+	f := newWebsiteFixture(t)
+	body := `{"query":"How do I get started?"}`
+
+	c, _ := f.request(f.alice, http.MethodPost, "/api/websites/query", "", body)
+	if err := f.controller.QueryLabel(c); !apperrors.Is(err, apperrors.CodeValidation) {
+		t.Errorf("QueryLabel() without a tag error = %v, want a validation error", err)
+	}
+
+	// Alice's blog label isn't shared with Bob
+	c, _ = f.request(f.bob, http.MethodPost, "/api/websites/query?tag=blog", "", body)
+	if err := f.controller.QueryLabel(c); !apperrors.Is(err, apperrors.CodeNotFound) {
+		t.Errorf("QueryLabel() of another user's label error = %v, want not found", err)
+	}
+}
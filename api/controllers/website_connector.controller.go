@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// GetConnector godoc
+// @Summary      Get website connector
+// @Description  Describes the Notion or Confluence connector a website's pages are ingested with instead of being crawled. Tokens are never returned.
+// @ID           getConnector
+// @Tags         Websites
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      200  {object}  schema.ConnectorSummary
+// @Failure      400  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Failure      501  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/connector [get]
+func (wc *WebsiteController) GetConnector(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	if !wc.connectorRepo.Enabled() {
+		return apperrors.New(apperrors.CodeNotImplemented, "Connectors are not enabled on this server")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	if _, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage); err != nil {
+		return err
+	}
+
+	connector, err := wc.connectorRepo.Get(c.Request().Context(), uint(websiteID))
+	if err != nil {
+		wc.logger.Error("Failed to get website connector", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to retrieve connector", err)
+	}
+
+	if connector == nil {
+		return apperrors.NotFound("No connector configured")
+	}
+
+	return c.JSON(http.StatusOK, connector.Summary())
+}
+
+// SetConnector godoc
+// @Summary      Set website connector
+// @Description  Ingests the pages of a website from a Notion workspace (`{"kind": "notion", "token": "..."}`, the pages shared with the integration) or a Confluence space (`{"kind": "confluence", "base_url": "...", "space_key": "...", "email": "...", "api_token": "..."}`, or `token` for a personal access token) instead of crawling it. The config is stored encrypted and a sync is queued; later recrawls sync again.
+// @ID           setConnector
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id         path      int                     true  "Website ID"
+// @Param        connector  body      schema.ConnectorConfig  true  "Connector config"
+// @Success      200        {object}  schema.ConnectorSummary
+// @Failure      400        {object}  apperrors.Response
+// @Failure      403        {object}  apperrors.Response
+// @Failure      404        {object}  apperrors.Response
+// @Failure      409        {object}  apperrors.Response
+// @Failure      500        {object}  apperrors.Response
+// @Failure      501        {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/connector [put]
+func (wc *WebsiteController) SetConnector(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	if !wc.connectorRepo.Enabled() {
+		return apperrors.New(apperrors.CodeNotImplemented, "Connectors are not enabled on this server")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var connector schema.ConnectorConfig
+	if err := c.Bind(&connector); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	if err := connector.Validate(); err != nil {
+		return apperrors.Validation(err.Error())
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if err := wc.connectorRepo.Save(c.Request().Context(), uint(websiteID), &connector); err != nil {
+		wc.logger.Error("Failed to save website connector", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to save connector", err)
+	}
+
+	// A crawl already running or queued picks up the connector when it
+	// starts, or syncs at the next recrawl
+	if _, err := wc.websites.Recrawl(c.Request().Context(), website); err != nil && !apperrors.Is(err, apperrors.CodeConflict) {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, connector.Summary())
+}
+
+// DeleteConnector godoc
+// @Summary      Delete website connector
+// @Description  Removes the connector of a website. Later crawls fetch the website's URL again.
+// @ID           deleteConnector
+// @Tags         Websites
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/connector [delete]
+func (wc *WebsiteController) DeleteConnector(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	if _, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage); err != nil {
+		return err
+	}
+
+	if err := wc.connectorRepo.Delete(c.Request().Context(), uint(websiteID)); err != nil {
+		wc.logger.Error("Failed to delete website connector", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to delete connector", err)
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Message: "Connector deleted"})
+}
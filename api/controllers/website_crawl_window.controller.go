@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CrawlWindowRequest defines the request body for restricting the crawls of
+// a website to a daily window in UTC. Empty times remove the window.
+type CrawlWindowRequest struct {
+	Start string `json:"start" example:"01:00"`
+	End   string `json:"end" example:"05:00"`
+}
+
+// SetCrawlWindow godoc
+// @Summary      Set the crawl window
+// @Description  Restricts the crawls of the website to a daily window in UTC, given as HH:MM start and end times; a window ending before it starts wraps midnight, e.g. 22:00-02:00. Recrawls, scheduled recrawls and crawl jobs outside the window are deferred until it opens. Empty times remove the window.
+// @ID           setCrawlWindow
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                 true  "Website ID"
+// @Param        request  body      CrawlWindowRequest  true  "Crawl window"
+// @Success      200      {object}  schema.Website
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/crawl-window [put]
+func (wc *WebsiteController) SetCrawlWindow(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req CrawlWindowRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if err := wc.websites.SetCrawlWindow(c.Request().Context(), website, req.Start, req.End); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, website)
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// GetCredentials godoc
+// @Summary      Get website credentials
+// @Description  Describes the credentials used to crawl a protected website. Passwords, header values and cookies are never returned and proxy passwords are redacted.
+// @ID           getCredentials
+// @Tags         Websites
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      200  {object}  schema.FetchCredentialsSummary
+// @Failure      400  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Failure      501  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/credentials [get]
+func (wc *WebsiteController) GetCredentials(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	if !wc.credsRepo.Enabled() {
+		return apperrors.New(apperrors.CodeNotImplemented, "Website credentials are not enabled on this server")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	if _, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage); err != nil {
+		return err
+	}
+
+	creds, err := wc.credsRepo.Get(c.Request().Context(), uint(websiteID))
+	if err != nil {
+		wc.logger.Error("Failed to get website credentials", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to retrieve credentials", err)
+	}
+
+	if creds == nil {
+		return apperrors.NotFound("No credentials configured")
+	}
+
+	return c.JSON(http.StatusOK, creds.Summary())
+}
+
+// SetCredentials godoc
+// @Summary      Set website credentials
+// @Description  Sets the basic auth credentials, custom headers, cookies or proxies used to crawl a protected website. They are stored encrypted and replace any existing credentials.
+// @ID           setCredentials
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id           path      int                      true  "Website ID"
+// @Param        credentials  body      schema.FetchCredentials  true  "Fetch credentials"
+// @Success      200          {object}  schema.FetchCredentialsSummary
+// @Failure      400          {object}  apperrors.Response
+// @Failure      403          {object}  apperrors.Response
+// @Failure      404          {object}  apperrors.Response
+// @Failure      500          {object}  apperrors.Response
+// @Failure      501          {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/credentials [put]
+func (wc *WebsiteController) SetCredentials(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	if !wc.credsRepo.Enabled() {
+		return apperrors.New(apperrors.CodeNotImplemented, "Website credentials are not enabled on this server")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var creds schema.FetchCredentials
+	if err := c.Bind(&creds); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	if err := creds.Validate(); err != nil {
+		return apperrors.Validation(err.Error())
+	}
+
+	if _, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage); err != nil {
+		return err
+	}
+
+	if err := wc.credsRepo.Save(c.Request().Context(), uint(websiteID), &creds); err != nil {
+		wc.logger.Error("Failed to save website credentials", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to save credentials", err)
+	}
+
+	return c.JSON(http.StatusOK, creds.Summary())
+}
+
+// DeleteCredentials godoc
+// @Summary      Delete website credentials
+// @Description  Removes the credentials of a website. Later crawls fetch pages without authentication.
+// @ID           deleteCredentials
+// @Tags         Websites
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/credentials [delete]
+func (wc *WebsiteController) DeleteCredentials(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	if _, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage); err != nil {
+		return err
+	}
+
+	if err := wc.credsRepo.Delete(c.Request().Context(), uint(websiteID)); err != nil {
+		wc.logger.Error("Failed to delete website credentials", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to delete credentials", err)
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Message: "Credentials deleted"})
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// LinkDiscordChannelRequest defines the request body for linking a Discord
+// channel to a website.
+type LinkDiscordChannelRequest struct {
+	// ID of the channel, copied from its menu in Discord's developer mode
+	ChannelID string `json:"channel_id" validate:"required,numeric,max=20" example:"1234567890123456789"`
+}
+
+// ListDiscordChannels godoc
+// @Summary      List linked Discord channels
+// @Description  Lists the Discord channels whose questions are answered from the website.
+// @ID           listDiscordChannels
+// @Tags         Websites
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      200  {array}   schema.DiscordChannel
+// @Failure      400  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/discord-channels [get]
+func (wc *WebsiteController) ListDiscordChannels(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	if _, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage); err != nil {
+		return err
+	}
+
+	channels, err := wc.discordRepo.ListByWebsite(c.Request().Context(), uint(websiteID))
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve Discord channels", err)
+	}
+
+	return c.JSON(http.StatusOK, channels)
+}
+
+// LinkDiscordChannel godoc
+// @Summary      Link a Discord channel
+// @Description  Answers questions asked in a Discord channel and its threads with the app's /ask command from the website; follow-up questions in a thread are answered in the context of the thread. Anyone in the channel can then query the website, so only the owner and admins can link channels. A channel is linked to one website at a time; linking it again moves it.
+// @ID           linkDiscordChannel
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                      true  "Website ID"
+// @Param        request  body      LinkDiscordChannelRequest  true  "Channel"
+// @Success      201      {object}  schema.DiscordChannel
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/discord-channels [post]
+func (wc *WebsiteController) LinkDiscordChannel(c echo.Context) error {
+	userID, err := middlewares.GetUserID(c)
+	if err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req LinkDiscordChannelRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	if _, err := wc.websites.GetOwned(c.Request().Context(), requestActor(c), uint(websiteID)); err != nil {
+		return err
+	}
+
+	channel := &schema.DiscordChannel{
+		ChannelID: strings.TrimSpace(req.ChannelID),
+		WebsiteID: uint(websiteID),
+		CreatedBy: &userID,
+	}
+	if err := wc.discordRepo.Link(c.Request().Context(), channel); err != nil {
+		wc.logger.Error("Failed to link Discord channel", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to link Discord channel", err)
+	}
+
+	return c.JSON(http.StatusCreated, channel)
+}
+
+// UnlinkDiscordChannel godoc
+// @Summary      Unlink a Discord channel
+// @Description  Stops answering questions asked in a Discord channel from the website.
+// @ID           unlinkDiscordChannel
+// @Tags         Websites
+// @Produce      json
+// @Param        id         path      int     true  "Website ID"
+// @Param        channelID  path      string  true  "Discord channel ID"
+// @Success      200        {object}  MessageResponse
+// @Failure      400        {object}  apperrors.Response
+// @Failure      403        {object}  apperrors.Response
+// @Failure      404        {object}  apperrors.Response
+// @Failure      500        {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/discord-channels/{channelID} [delete]
+func (wc *WebsiteController) UnlinkDiscordChannel(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	if _, err := wc.websites.GetOwned(c.Request().Context(), requestActor(c), uint(websiteID)); err != nil {
+		return err
+	}
+
+	unlinked, err := wc.discordRepo.Unlink(c.Request().Context(), uint(websiteID), c.Param("channelID"))
+	if err != nil {
+		wc.logger.Error("Failed to unlink Discord channel", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to unlink Discord channel", err)
+	}
+	if !unlinked {
+		return apperrors.NotFound("Discord channel not linked to this website")
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Message: "Discord channel unlinked"})
+}
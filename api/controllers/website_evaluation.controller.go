@@ -0,0 +1,329 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// EvaluationCaseInput is a question and the answer it is expected to get.
+type EvaluationCaseInput struct {
+	Question       string `json:"question" validate:"required,max=2000" example:"How do I reset my password?"`
+	ExpectedAnswer string `json:"expected_answer" validate:"required,max=8000" example:"Use the Forgot password link on the login page."`
+}
+
+// SetEvaluationCasesRequest defines the request body for uploading the
+// evaluation cases of a website.
+type SetEvaluationCasesRequest struct {
+	Cases []EvaluationCaseInput `json:"cases" validate:"required,min=1,max=200,dive"`
+}
+
+// CreateEvaluationRunRequest defines the request body for starting an
+// evaluation run.
+type CreateEvaluationRunRequest struct {
+	Scorer string `json:"scorer,omitempty" validate:"omitempty,oneof=overlap llm" example:"overlap"`
+}
+
+// EvaluationRunResponse is an evaluation run with the results of its cases.
+type EvaluationRunResponse struct {
+	Run     *schema.EvalRun     `json:"run"`
+	Results []schema.EvalResult `json:"results"`
+}
+
+// GetEvaluationCases godoc
+// @Summary      Get evaluation cases
+// @Description  Lists the question and expected answer pairs the RAG answers of a website are evaluated against.
+// @ID           getEvaluationCases
+// @Tags         Websites
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      200  {array}   schema.EvalCase
+// @Failure      400  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/evaluation/cases [get]
+func (wc *WebsiteController) GetEvaluationCases(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionRead)
+	if err != nil {
+		return err
+	}
+
+	cases, err := wc.evalRepo.ListCases(c.Request().Context(), website.ID)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve evaluation cases", err)
+	}
+
+	return c.JSON(http.StatusOK, cases)
+}
+
+// SetEvaluationCases godoc
+// @Summary      Upload evaluation cases
+// @Description  Replaces the question and expected answer pairs the RAG answers of a website are evaluated against. Results of earlier runs keep a copy of their cases.
+// @ID           setEvaluationCases
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                        true  "Website ID"
+// @Param        request  body      SetEvaluationCasesRequest  true  "Evaluation cases"
+// @Success      200      {array}   schema.EvalCase
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/evaluation/cases [put]
+func (wc *WebsiteController) SetEvaluationCases(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req SetEvaluationCasesRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	cases := make([]schema.EvalCase, 0, len(req.Cases))
+	for _, input := range req.Cases {
+		question := strings.TrimSpace(input.Question)
+		expected := strings.TrimSpace(input.ExpectedAnswer)
+		if question == "" || expected == "" {
+			return apperrors.Validation("Questions and expected answers must not be blank")
+		}
+		cases = append(cases, schema.EvalCase{Question: question, ExpectedAnswer: expected})
+	}
+
+	stored, err := wc.evalRepo.ReplaceCases(c.Request().Context(), website.ID, cases)
+	if err != nil {
+		return apperrors.Internal("Failed to store evaluation cases", err)
+	}
+
+	return c.JSON(http.StatusOK, stored)
+}
+
+// CreateEvaluationRun godoc
+// @Summary      Start an evaluation run
+// @Description  Queues a run answering every evaluation case of a website with the current retrieval settings and scoring the answers against the expected answers. The overlap scorer compares words; the llm scorer asks the LLM to grade each answer. Scores range from 0 to 1.
+// @ID           createEvaluationRun
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                         true   "Website ID"
+// @Param        request  body      CreateEvaluationRunRequest  false  "Run options"
+// @Success      202      {object}  schema.EvalRun
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/evaluation/runs [post]
+func (wc *WebsiteController) CreateEvaluationRun(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req CreateEvaluationRunRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return apperrors.Validation("Invalid request payload")
+		}
+		if err := c.Validate(&req); err != nil {
+			return err
+		}
+	}
+	if req.Scorer == "" {
+		req.Scorer = schema.EvalScorerOverlap
+	}
+
+	ctx := c.Request().Context()
+
+	website, err := wc.websites.Get(ctx, requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	cases, err := wc.evalRepo.CountCases(ctx, website.ID)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve evaluation cases", err)
+	}
+	if cases == 0 {
+		return apperrors.Validation("Upload evaluation cases before starting a run")
+	}
+
+	run, err := wc.evalRepo.CreateRun(ctx, website.ID, req.Scorer, cases)
+	if err != nil {
+		return apperrors.Internal("Failed to create evaluation run", err)
+	}
+
+	if err := wc.jobClient.EnqueueEvaluateRAG(ctx, website.ID, run.ID); err != nil {
+		wc.logger.Error("Failed to enqueue evaluation run", zap.Uint("runID", run.ID), zap.Error(err))
+		if failErr := wc.evalRepo.FailRun(ctx, run.ID, "failed to enqueue run"); failErr != nil {
+			wc.logger.Warn("Failed to mark evaluation run as failed", zap.Uint("runID", run.ID), zap.Error(failErr))
+		}
+		return apperrors.Internal("Failed to enqueue evaluation run", err)
+	}
+
+	return c.JSON(http.StatusAccepted, run)
+}
+
+// ListEvaluationRuns godoc
+// @Summary      List evaluation runs
+// @Description  Lists the evaluation runs of a website, most recent first, with the retrieval settings each run used and its metrics, so runs can be compared over time.
+// @ID           listEvaluationRuns
+// @Tags         Websites
+// @Produce      json
+// @Param        id     path      int  true   "Website ID"
+// @Param        page   query     int  false  "Page number"     default(1)
+// @Param        limit  query     int  false  "Items per page"  default(20)
+// @Success      200    {object}  PaginatedResponse{data=[]schema.EvalRun}
+// @Failure      400    {object}  apperrors.Response
+// @Failure      403    {object}  apperrors.Response
+// @Failure      404    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/evaluation/runs [get]
+func (wc *WebsiteController) ListEvaluationRuns(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	page := 1
+	if pageParam := c.QueryParam("page"); pageParam != "" {
+		if p, err := strconv.Atoi(pageParam); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	limit := 20
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionRead)
+	if err != nil {
+		return err
+	}
+
+	runs, total, err := wc.evalRepo.ListRuns(c.Request().Context(), website.ID, limit, (page-1)*limit)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve evaluation runs", err)
+	}
+
+	totalPages := (total + limit - 1) / limit
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       runs,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	})
+}
+
+// GetEvaluationRun godoc
+// @Summary      Get an evaluation run
+// @Description  Retrieves an evaluation run of a website with the answer and score of every case.
+// @ID           getEvaluationRun
+// @Tags         Websites
+// @Produce      json
+// @Param        id     path      int  true  "Website ID"
+// @Param        runID  path      int  true  "Evaluation run ID"
+// @Success      200    {object}  EvaluationRunResponse
+// @Failure      400    {object}  apperrors.Response
+// @Failure      403    {object}  apperrors.Response
+// @Failure      404    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/evaluation/runs/{runID} [get]
+func (wc *WebsiteController) GetEvaluationRun(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	runID, err := strconv.ParseUint(c.Param("runID"), 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid evaluation run ID")
+	}
+
+	ctx := c.Request().Context()
+
+	website, err := wc.websites.Get(ctx, requestActor(c), uint(websiteID), schema.WebsitePermissionRead)
+	if err != nil {
+		return err
+	}
+
+	run, err := wc.evalRepo.GetRun(ctx, uint(runID))
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve evaluation run", err)
+	}
+
+	if run == nil || run.WebsiteID != website.ID {
+		return apperrors.NotFound("Evaluation run not found")
+	}
+
+	results, err := wc.evalRepo.ListResults(ctx, run.ID)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve evaluation results", err)
+	}
+
+	return c.JSON(http.StatusOK, EvaluationRunResponse{
+		Run:     run,
+		Results: results,
+	})
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LabelsRequest defines the request body for changing the labels of a
+// website.
+type LabelsRequest struct {
+	// Labels replace those of the website, an empty list removes them
+	Labels []string `json:"labels" example:"docs"`
+}
+
+// SetLabels godoc
+// @Summary      Set the labels
+// @Description  Replaces the labels grouping the website, such as docs, competitor or internal. Labels are stored lower case with words joined by hyphens, so "Internal Docs" becomes internal-docs; duplicates are dropped. List the websites with a label with GET /websites?tag=.
+// @ID           setLabels
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int            true  "Website ID"
+// @Param        request  body      LabelsRequest  true  "Labels"
+// @Success      200      {object}  schema.Website
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/labels [put]
+func (wc *WebsiteController) SetLabels(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req LabelsRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if err := wc.websites.SetLabels(c.Request().Context(), website, req.Labels); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, website)
+}
+
+// ListLabels godoc
+// @Summary      List website labels
+// @Description  Lists the labels of the websites the user can access and the number of websites labelled with each, most used first.
+// @ID           listLabels
+// @Tags         Websites
+// @Produce      json
+// @Success      200  {array}   schema.LabelCount
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/labels [get]
+func (wc *WebsiteController) ListLabels(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	labels, err := wc.websites.ListLabels(c.Request().Context(), requestActor(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, labels)
+}
+
+// QueryLabel godoc
+// @Summary      Query the websites with a label
+// @Description  Performs a RAG-based query against the indexed content of the websites with a label that the user can query, at most 20. The chunks most similar to the query across the websites are retrieved and each source names its `website_id`. Websites not indexed yet are left out. These answers are not cached nor recorded in the query log, and `preview_id` is not supported.
+// @ID           queryLabel
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        tag    query     string        true  "Label of the websites to query"
+// @Param        query  body      QueryRequest  true  "Query"
+// @Success      200    {object}  llm.QueryResponse
+// @Failure      400    {object}  apperrors.Response
+// @Failure      404    {object}  apperrors.Response
+// @Failure      409    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/query [post]
+func (wc *WebsiteController) QueryLabel(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	var req QueryRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	websites, err := wc.websites.ListQueryable(c.Request().Context(), requestActor(c), schema.NormalizeLabel(c.QueryParam("tag")))
+	if err != nil {
+		return err
+	}
+
+	response, err := wc.queries.QueryWebsites(c.Request().Context(), websites, req.question())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+)
+
+// NoisePatternsRequest defines the request body for changing the noise
+// patterns of a website.
+type NoisePatternsRequest struct {
+	// How the patterns combine with the server's CONTENT_NOISE_PATTERNS:
+	// extend adds them, replace uses only them, off turns noise removal off
+	NoiseRemoval string `json:"noise_removal" example:"extend" enums:"extend,replace,off"`
+	// Phrases matched case insensitively, or regular expressions wrapped in
+	// slashes
+	Patterns []string `json:"patterns" example:"Share this article"`
+}
+
+// SetNoisePatterns godoc
+// @Summary      Set the noise patterns
+// @Description  Replaces the noise patterns of the website. Text blocks of a page made up only of noise phrases, such as a "Read more" link or a "Privacy policy | Terms of service" footer, are removed before chunking; blocks with other text are kept whole, so body text mentioning a phrase is not changed. Patterns are phrases matched case insensitively on word boundaries, or regular expressions wrapped in slashes such as /^share on \w+$/. noise_removal extends the server's CONTENT_NOISE_PATTERNS with them, replaces them or turns noise removal off. Applies from the next crawl.
+// @ID           setNoisePatterns
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                   true  "Website ID"
+// @Param        request  body      NoisePatternsRequest  true  "Noise patterns"
+// @Success      200      {object}  schema.Website
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/noise-patterns [put]
+func (wc *WebsiteController) SetNoisePatterns(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req NoisePatternsRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+	if req.NoiseRemoval == "" {
+		req.NoiseRemoval = schema.NoiseRemovalExtend
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if err := wc.websites.SetNoisePatterns(c.Request().Context(), website, req.NoiseRemoval, req.Patterns); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, website)
+}
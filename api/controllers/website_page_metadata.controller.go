@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetPageContentMetadata godoc
+// @Summary      Get page content metadata
+// @Description  Returns the metadata stored with the extracted content of a page without downloading it: its title, language, SHA-256 content hash, extraction time, the content type the page was served with and the size of the stored object. The ETag follows the content hash, so external consumers can check a page for changes by sending If-None-Match and getting 304 Not Modified while it is unchanged. Content stored before the metadata was recorded only has the object fields and the page URL until it is recrawled.
+// @ID           getPageContentMetadata
+// @Tags         Websites
+// @Produce      json
+// @Param        id      path      int  true  "Website ID"
+// @Param        pageID  path      int  true  "Page ID"
+// @Success      200     {object}  storage.PageInfo
+// @Success      304     "Not modified"
+// @Failure      400     {object}  apperrors.Response
+// @Failure      403     {object}  apperrors.Response
+// @Failure      404     {object}  apperrors.Response
+// @Failure      500     {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/pages/{pageID}/metadata [get]
+func (wc *WebsiteController) GetPageContentMetadata(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	pageID, err := strconv.ParseUint(c.Param("pageID"), 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid page ID")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionRead)
+	if err != nil {
+		return err
+	}
+
+	info, err := wc.websites.PageContentMetadata(c.Request().Context(), website, uint(pageID))
+	if err != nil {
+		return err
+	}
+
+	// Content stored without a hash changes along with its object
+	if notModified(c, "page-metadata", info.ObjectKey, info.ContentHash, info.LastModified) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSON(http.StatusOK, info)
+}
@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+	"hermit/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+)
+
+// requestActor returns the service actor of the authenticated request.
+func requestActor(c echo.Context) service.Actor {
+	actor := service.Actor{User: middlewares.GetUser(c)}
+	if key := middlewares.GetAPIKey(c); key != nil {
+		actor.APIKeyID = &key.ID
+	}
+	return actor
+}
+
+// GrantPermissionRequest defines the request body for granting a website
+// permission. Exactly one of email and api_key_id is set.
+type GrantPermissionRequest struct {
+	// Email of the user the permission is granted to
+	Email string `json:"email,omitempty" validate:"omitempty,email" example:"teammate@example.com"`
+	// ID of the API key the permission is granted to, it applies to requests
+	// authenticated with that key only
+	APIKeyID   string `json:"api_key_id,omitempty" example:"01HZX3QK8V6Y4N2M7R5T9W1C0D"`
+	Permission string `json:"permission" validate:"required,oneof=read query manage" example:"query"`
+}
+
+// ListPermissions godoc
+// @Summary      List website permissions
+// @Description  Lists the permissions the website's owner granted to other users and API keys. Only the owner and admins can list them.
+// @ID           listPermissions
+// @Tags         Websites
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      200  {array}   schema.WebsitePermission
+// @Failure      400  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/permissions [get]
+func (wc *WebsiteController) ListPermissions(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	// Granted permissions don't allow sharing the website further
+	if _, err := wc.websites.GetOwned(c.Request().Context(), requestActor(c), uint(websiteID)); err != nil {
+		return err
+	}
+
+	permissions, err := wc.permRepo.ListByWebsiteID(c.Request().Context(), uint(websiteID))
+	if err != nil {
+		wc.logger.Error("Failed to list website permissions", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to list permissions", err)
+	}
+
+	return c.JSON(http.StatusOK, permissions)
+}
+
+// GrantPermission godoc
+// @Summary      Grant a website permission
+// @Description  Grants another user, or a single API key, a permission on the website. read allows reading the website's status, pages, tags, crawl events and evaluations, query allows querying it and manage allows everything else but sharing it, including read and query. Only the owner and admins can grant permissions; granting a permission twice returns the existing grant.
+// @ID           grantPermission
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                     true  "Website ID"
+// @Param        request  body      GrantPermissionRequest  true  "User or API key and permission"
+// @Success      201      {object}  schema.WebsitePermission
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/permissions [post]
+func (wc *WebsiteController) GrantPermission(c echo.Context) error {
+	userID, err := middlewares.GetUserID(c)
+	if err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req GrantPermissionRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	req.APIKeyID = strings.TrimSpace(req.APIKeyID)
+	if (req.Email == "") == (req.APIKeyID == "") {
+		return apperrors.Validation("Exactly one of email and api_key_id is required")
+	}
+
+	website, err := wc.websites.GetOwned(c.Request().Context(), requestActor(c), uint(websiteID))
+	if err != nil {
+		return err
+	}
+
+	permission := &schema.WebsitePermission{
+		WebsiteID:  uint(websiteID),
+		Permission: req.Permission,
+		CreatedBy:  &userID,
+	}
+
+	if req.Email != "" {
+		grantee, err := wc.userRepo.GetByEmail(c.Request().Context(), req.Email)
+		if err != nil {
+			if apperrors.Is(err, apperrors.CodeNotFound) {
+				return apperrors.NotFound("User not found")
+			}
+			return apperrors.Internal("Failed to retrieve user", err)
+		}
+		if website.UserID != nil && *website.UserID == grantee.ID {
+			return apperrors.Validation("The website's owner already has all permissions")
+		}
+		permission.UserID = &grantee.ID
+	} else {
+		keyID, err := ulid.Parse(req.APIKeyID)
+		if err != nil {
+			return apperrors.Validation("Invalid API key ID")
+		}
+		key, err := wc.apiKeyRepo.GetByID(c.Request().Context(), keyID)
+		if err != nil {
+			if apperrors.Is(err, apperrors.CodeNotFound) {
+				return apperrors.NotFound("API key not found")
+			}
+			return apperrors.Internal("Failed to retrieve API key", err)
+		}
+		permission.APIKeyID = &key.ID
+	}
+
+	granted, err := wc.permRepo.Grant(c.Request().Context(), permission)
+	if err != nil {
+		wc.logger.Error("Failed to grant website permission", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to grant permission", err)
+	}
+
+	return c.JSON(http.StatusCreated, granted)
+}
+
+// RevokePermission godoc
+// @Summary      Revoke a website permission
+// @Description  Revokes a permission granted on the website. Only the owner and admins can revoke permissions.
+// @ID           revokePermission
+// @Tags         Websites
+// @Produce      json
+// @Param        id            path      int  true  "Website ID"
+// @Param        permissionID  path      int  true  "Permission ID"
+// @Success      200           {object}  MessageResponse
+// @Failure      400           {object}  apperrors.Response
+// @Failure      403           {object}  apperrors.Response
+// @Failure      404           {object}  apperrors.Response
+// @Failure      500           {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/permissions/{permissionID} [delete]
+func (wc *WebsiteController) RevokePermission(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	permissionID, err := strconv.ParseUint(c.Param("permissionID"), 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid permission ID")
+	}
+
+	if _, err := wc.websites.GetOwned(c.Request().Context(), requestActor(c), uint(websiteID)); err != nil {
+		return err
+	}
+
+	revoked, err := wc.permRepo.Revoke(c.Request().Context(), uint(websiteID), uint(permissionID))
+	if err != nil {
+		wc.logger.Error("Failed to revoke website permission", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to revoke permission", err)
+	}
+	if !revoked {
+		return apperrors.NotFound("Permission not found")
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Message: "Permission revoked"})
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PIIRedactionRequest defines the request body for turning PII redaction of a
+// website on or off.
+type PIIRedactionRequest struct {
+	Enabled bool `json:"enabled" example:"true"`
+}
+
+// SetPIIRedaction godoc
+// @Summary      Turn PII redaction on or off
+// @Description  Redacts emails, phone numbers, SSNs and the server's PII_PATTERNS from the website's pages before they are stored and vectorized, replacing them with placeholders such as [REDACTED:email]. Applies from the next crawl; recrawl to redact pages indexed before. Turning it off resets the redaction report.
+// @ID           setPIIRedaction
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                  true  "Website ID"
+// @Param        request  body      PIIRedactionRequest  true  "PII redaction"
+// @Success      200      {object}  schema.Website
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/pii-redaction [put]
+func (wc *WebsiteController) SetPIIRedaction(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req PIIRedactionRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if err := wc.websites.SetPIIRedaction(c.Request().Context(), website, req.Enabled); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, website)
+}
+
+// GetPIIReport godoc
+// @Summary      Get the PII redaction report
+// @Description  Counts the personal data redacted from the website's pages by the last crawl of each page, per kind.
+// @ID           getPIIReport
+// @Tags         Websites
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      200  {object}  schema.PIIReport
+// @Failure      400  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/pii-report [get]
+func (wc *WebsiteController) GetPIIReport(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionRead)
+	if err != nil {
+		return err
+	}
+
+	report, err := wc.websites.PIIReport(c.Request().Context(), website)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequestSettingsRequest defines the request body for changing the user
+// agent and headers a website is crawled with.
+type RequestSettingsRequest struct {
+	// User agent sent instead of CRAWLER_USER_AGENT and matched against the
+	// robots.txt rules, empty for the server default
+	UserAgent string `json:"user_agent" example:"Mozilla/5.0 (compatible; AcmeDocsBot/1.0)"`
+	// User agents used in turn for page requests instead of user_agent
+	UserAgentRotation []string `json:"user_agent_rotation" example:"Mozilla/5.0 (X11; Linux x86_64) AcmeDocsBot/1.0"`
+	// Extra headers sent with every request, including for robots.txt and
+	// sitemaps. Use credentials for secrets
+	RequestHeaders map[string]string `json:"request_headers"`
+}
+
+// SetRequestSettings godoc
+// @Summary      Set the user agent and request headers
+// @Description  Replaces the user agent the website is crawled with, a list of user agents rotated between page requests and extra headers sent with every request. robots.txt and sitemaps are fetched with the same user agent and headers, and robots.txt rules are matched for user_agent. Headers are shown with the website; use credentials for secrets. Applies from the next crawl.
+// @ID           setRequestSettings
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                     true  "Website ID"
+// @Param        request  body      RequestSettingsRequest  true  "Request settings"
+// @Success      200      {object}  schema.Website
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/request-settings [put]
+func (wc *WebsiteController) SetRequestSettings(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req RequestSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if err := wc.websites.SetRequestSettings(c.Request().Context(), website, req.UserAgent, req.UserAgentRotation, req.RequestHeaders); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, website)
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RobotsRequest defines the request body for overriding whether robots.txt
+// is respected when crawling a website.
+type RobotsRequest struct {
+	// RespectRobots overrides CRAWLER_RESPECT_ROBOTS_TXT, null uses it
+	RespectRobots *bool `json:"respect_robots" example:"false"`
+}
+
+// SetRespectRobots godoc
+// @Summary      Override robots.txt compliance
+// @Description  Overrides whether robots.txt is respected when crawling the website, null for the server's CRAWLER_RESPECT_ROBOTS_TXT. While it is bypassed, every URL disallowed by robots.txt that is fetched anyway is recorded as a robots_ignored crawl event, and changes of the setting are logged with the user who made them. Only use it for sites you are allowed to crawl. Applies from the next crawl.
+// @ID           setRespectRobots
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int            true  "Website ID"
+// @Param        request  body      RobotsRequest  true  "robots.txt compliance"
+// @Success      200      {object}  schema.Website
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/robots [put]
+func (wc *WebsiteController) SetRespectRobots(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req RobotsRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	actor := requestActor(c)
+	website, err := wc.websites.Get(c.Request().Context(), actor, uint(websiteID), schema.WebsitePermissionManage)
+	if err != nil {
+		return err
+	}
+
+	if err := wc.websites.SetRespectRobots(c.Request().Context(), actor, website, req.RespectRobots); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, website)
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetPageScreenshot godoc
+// @Summary      Get a page screenshot
+// @Description  Returns the PNG screenshot of a page taken by the headless browser service during its last crawl, to verify what was indexed. Screenshots are captured when the server sets SCREENSHOT_PAGES and SCREENSHOT_SERVICE_URL; pages crawled before have none until they are recrawled.
+// @ID           getPageScreenshot
+// @Tags         Websites
+// @Produce      png
+// @Param        id      path      int  true  "Website ID"
+// @Param        pageID  path      int  true  "Page ID"
+// @Success      200     {file}    binary
+// @Failure      400     {object}  apperrors.Response
+// @Failure      403     {object}  apperrors.Response
+// @Failure      404     {object}  apperrors.Response
+// @Failure      500     {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/pages/{pageID}/screenshot [get]
+func (wc *WebsiteController) GetPageScreenshot(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	pageID, err := strconv.ParseUint(c.Param("pageID"), 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid page ID")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionRead)
+	if err != nil {
+		return err
+	}
+
+	image, err := wc.websites.PageScreenshot(c.Request().Context(), website, uint(pageID))
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("Cache-Control", "private, max-age=300")
+	return c.Blob(http.StatusOK, "image/png", image)
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SearchPages godoc
+// @Summary      Search pages by keyword
+// @Description  Full-text search over the stored text of the website's pages, without the LLM. q accepts web search syntax: "quoted phrases", or and -excluded words. Results are ranked with matches in titles first and carry up to three passages with the matches wrapped in <mark> tags. Pages crawled before search was available are found once the website is recrawled or reindexed.
+// @ID           searchPages
+// @Tags         Websites
+// @Produce      json
+// @Param        id     path      int     true   "Website ID"
+// @Param        q      query     string  true   "Search query"
+// @Param        page   query     int     false  "Page number"     default(1)
+// @Param        limit  query     int     false  "Items per page"  default(10)
+// @Success      200    {object}  PaginatedResponse{data=[]schema.SearchHit}
+// @Failure      400    {object}  apperrors.Response
+// @Failure      403    {object}  apperrors.Response
+// @Failure      404    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/search [get]
+func (wc *WebsiteController) SearchPages(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	website, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionRead)
+	if err != nil {
+		return err
+	}
+
+	// Parse pagination params
+	page := 1
+	if pageParam := c.QueryParam("page"); pageParam != "" {
+		if p, err := strconv.Atoi(pageParam); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	limit := 10
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 50 {
+			limit = l
+		}
+	}
+
+	hits, total, err := wc.search.Search(c.Request().Context(), website, c.QueryParam("q"), limit, (page-1)*limit)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       hits,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	})
+}
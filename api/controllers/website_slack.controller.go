@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// LinkSlackChannelRequest defines the request body for linking a Slack
+// channel to a website.
+type LinkSlackChannelRequest struct {
+	// ID of the channel, shown at the bottom of its details in Slack
+	ChannelID string `json:"channel_id" validate:"required,max=32" example:"C0123456789"`
+}
+
+// ListSlackChannels godoc
+// @Summary      List linked Slack channels
+// @Description  Lists the Slack channels whose questions are answered from the website.
+// @ID           listSlackChannels
+// @Tags         Websites
+// @Produce      json
+// @Param        id   path      int  true  "Website ID"
+// @Success      200  {array}   schema.SlackChannel
+// @Failure      400  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      404  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/slack-channels [get]
+func (wc *WebsiteController) ListSlackChannels(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	if _, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage); err != nil {
+		return err
+	}
+
+	channels, err := wc.slackRepo.ListByWebsite(c.Request().Context(), uint(websiteID))
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve Slack channels", err)
+	}
+
+	return c.JSON(http.StatusOK, channels)
+}
+
+// LinkSlackChannel godoc
+// @Summary      Link a Slack channel
+// @Description  Answers questions asked in a Slack channel, with the app's slash command or by mentioning it, from the website. Anyone in the channel can then query the website, so only the owner and admins can link channels. A channel is linked to one website at a time; linking it again moves it.
+// @ID           linkSlackChannel
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                      true  "Website ID"
+// @Param        request  body      LinkSlackChannelRequest  true  "Channel"
+// @Success      201      {object}  schema.SlackChannel
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/slack-channels [post]
+func (wc *WebsiteController) LinkSlackChannel(c echo.Context) error {
+	userID, err := middlewares.GetUserID(c)
+	if err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req LinkSlackChannelRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	if _, err := wc.websites.GetOwned(c.Request().Context(), requestActor(c), uint(websiteID)); err != nil {
+		return err
+	}
+
+	channel := &schema.SlackChannel{
+		ChannelID: strings.TrimSpace(req.ChannelID),
+		WebsiteID: uint(websiteID),
+		CreatedBy: &userID,
+	}
+	if err := wc.slackRepo.Link(c.Request().Context(), channel); err != nil {
+		wc.logger.Error("Failed to link Slack channel", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to link Slack channel", err)
+	}
+
+	return c.JSON(http.StatusCreated, channel)
+}
+
+// UnlinkSlackChannel godoc
+// @Summary      Unlink a Slack channel
+// @Description  Stops answering questions asked in a Slack channel from the website.
+// @ID           unlinkSlackChannel
+// @Tags         Websites
+// @Produce      json
+// @Param        id         path      int     true  "Website ID"
+// @Param        channelID  path      string  true  "Slack channel ID"
+// @Success      200        {object}  MessageResponse
+// @Failure      400        {object}  apperrors.Response
+// @Failure      403        {object}  apperrors.Response
+// @Failure      404        {object}  apperrors.Response
+// @Failure      500        {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/slack-channels/{channelID} [delete]
+func (wc *WebsiteController) UnlinkSlackChannel(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	if _, err := wc.websites.GetOwned(c.Request().Context(), requestActor(c), uint(websiteID)); err != nil {
+		return err
+	}
+
+	unlinked, err := wc.slackRepo.Unlink(c.Request().Context(), uint(websiteID), c.Param("channelID"))
+	if err != nil {
+		wc.logger.Error("Failed to unlink Slack channel", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to unlink Slack channel", err)
+	}
+	if !unlinked {
+		return apperrors.NotFound("Slack channel not linked to this website")
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Message: "Slack channel unlinked"})
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// Bounds of the period covered by usage reports, in days.
+const (
+	defaultUsageDays = 30
+	maxUsageDays     = 365
+)
+
+// UsageReport aggregates the LLM token usage of queries over a period, with
+// the costs estimated from LLM_COST_PER_1K_PROMPT_TOKENS and
+// LLM_COST_PER_1K_COMPLETION_TOKENS.
+type UsageReport struct {
+	Since                     time.Time `json:"since"`
+	Days                      int       `json:"days" example:"30"`
+	CostPer1KPromptTokens     float64   `json:"cost_per_1k_prompt_tokens"`
+	CostPer1KCompletionTokens float64   `json:"cost_per_1k_completion_tokens"`
+	// Total sums the usage of all websites in the report
+	Total    schema.UsageStats   `json:"total"`
+	Websites []schema.UsageStats `json:"websites"`
+	Users    []schema.UsageStats `json:"users"`
+}
+
+// usageReport builds the usage report of a website, of all websites when
+// websiteID is zero, over the period of the days query parameter.
+func (wc *WebsiteController) usageReport(c echo.Context, websiteID uint) (*UsageReport, error) {
+	days := defaultUsageDays
+	if param := c.QueryParam("days"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed < 1 || parsed > maxUsageDays {
+			return nil, apperrors.Validation("days must be between 1 and 365")
+		}
+		days = parsed
+	}
+
+	report := &UsageReport{
+		Since:                     time.Now().AddDate(0, 0, -days).UTC().Truncate(time.Second),
+		Days:                      days,
+		CostPer1KPromptTokens:     wc.cfg.LLMCostPer1KPromptTokens,
+		CostPer1KCompletionTokens: wc.cfg.LLMCostPer1KCompletionTokens,
+	}
+
+	websites, err := wc.queryLogRepo.GetUsage(c.Request().Context(), websiteID, report.Since, false)
+	if err != nil {
+		wc.logger.Error("Failed to get token usage", zap.Uint("websiteID", websiteID), zap.Error(err))
+		return nil, apperrors.Internal("Failed to get token usage", err)
+	}
+	users, err := wc.queryLogRepo.GetUsage(c.Request().Context(), websiteID, report.Since, true)
+	if err != nil {
+		wc.logger.Error("Failed to get token usage", zap.Uint("websiteID", websiteID), zap.Error(err))
+		return nil, apperrors.Internal("Failed to get token usage", err)
+	}
+
+	report.Websites = make([]schema.UsageStats, 0, len(websites))
+	for _, stats := range websites {
+		stats.Price(report.CostPer1KPromptTokens, report.CostPer1KCompletionTokens)
+		report.Websites = append(report.Websites, stats)
+
+		report.Total.Queries += stats.Queries
+		report.Total.CachedQueries += stats.CachedQueries
+		report.Total.PromptTokens += stats.PromptTokens
+		report.Total.CompletionTokens += stats.CompletionTokens
+	}
+	report.Total.Price(report.CostPer1KPromptTokens, report.CostPer1KCompletionTokens)
+
+	report.Users = make([]schema.UsageStats, 0, len(users))
+	for _, stats := range users {
+		stats.Price(report.CostPer1KPromptTokens, report.CostPer1KCompletionTokens)
+		report.Users = append(report.Users, stats)
+	}
+
+	return report, nil
+}
+
+// GetWebsiteUsage godoc
+// @Summary      Get website token usage
+// @Description  Aggregates the LLM tokens the website's queries used over the last days, in total and per user, with their estimated cost. Requires the manage permission.
+// @ID           getWebsiteUsage
+// @Tags         Websites
+// @Produce      json
+// @Param        id    path      int  true   "Website ID"
+// @Param        days  query     int  false  "Number of days covered, 30 by default, at most 365"
+// @Success      200   {object}  UsageReport
+// @Failure      400   {object}  apperrors.Response
+// @Failure      403   {object}  apperrors.Response
+// @Failure      404   {object}  apperrors.Response
+// @Failure      500   {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/usage [get]
+func (wc *WebsiteController) GetWebsiteUsage(c echo.Context) error {
+	if _, err := middlewares.GetUserID(c); err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	if _, err := wc.websites.Get(c.Request().Context(), requestActor(c), uint(websiteID), schema.WebsitePermissionManage); err != nil {
+		return err
+	}
+
+	report, err := wc.usageReport(c, uint(websiteID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetUsage godoc
+// @Summary      Get token usage
+// @Description  Aggregates the LLM tokens queries used over the last days per website and per user, with their estimated cost (admin only).
+// @ID           getUsage
+// @Tags         Admin
+// @Produce      json
+// @Param        days  query     int  false  "Number of days covered, 30 by default, at most 365"
+// @Success      200   {object}  UsageReport
+// @Failure      400   {object}  apperrors.Response
+// @Failure      500   {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /admin/usage [get]
+func (wc *WebsiteController) GetUsage(c echo.Context) error {
+	report, err := wc.usageReport(c, 0)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"hermit/api/middlewares"
+	"hermit/internal/apperrors"
+	"hermit/internal/auth"
+	"hermit/internal/schema"
+	"hermit/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// WidgetController handles the embeddable chat widget and its public keys.
+type WidgetController struct {
+	authService *auth.Service
+	websites    *service.WebsiteService
+	queries     *service.QueryService
+	logger      *zap.Logger
+}
+
+// NewWidgetController creates a new WidgetController.
+func NewWidgetController(authService *auth.Service, websites *service.WebsiteService, queries *service.QueryService, logger *zap.Logger) *WidgetController {
+	return &WidgetController{authService: authService, websites: websites, queries: queries, logger: logger}
+}
+
+// CreateWidgetKeyRequest defines the request body for creating a public key
+// of the chat widget.
+type CreateWidgetKeyRequest struct {
+	Name string `json:"name" validate:"required,min=3,max=255" example:"Docs site widget"`
+	// AllowedOrigins are the hosts of the sites the widget is embedded on,
+	// *.example.com allows the subdomains of example.com
+	AllowedOrigins []string `json:"allowed_origins" validate:"required,min=1,max=20,dive,required,max=255" example:"docs.example.com"`
+	// RateLimitPerMin is the number of questions per minute per visitor,
+	// WIDGET_RATE_LIMIT_PER_MIN when omitted
+	RateLimitPerMin int `json:"rate_limit_per_min,omitempty" validate:"omitempty,min=1,max=600" example:"10"`
+}
+
+// CreateWidgetKeyResponse is returned when a public key is created.
+type CreateWidgetKeyResponse struct {
+	APIKey   *schema.APIKey `json:"api_key"`
+	PlainKey string         `json:"plain_key"`
+	// Snippet adds the widget to a page
+	Snippet string `json:"snippet" example:"<script src=\"https://hermit.example.com/widget.js\" data-key=\"hmt_pub_...\" async></script>"`
+}
+
+// WidgetQueryRequest defines the request body for asking a question from the
+// chat widget.
+type WidgetQueryRequest struct {
+	Query string `json:"query" validate:"required,max=1000" example:"How do I get started?"`
+}
+
+// CreateWidgetKey godoc
+// @Summary      Create a chat widget key
+// @Description  Creates a public key for the chat widget served at /widget.js. Public keys are embedded in pages, so they can only query the website, from pages of the allowed origins, at a limited rate per visitor, and are rejected by the rest of the API. Only the owner and admins can create them; they are listed and revoked with the other API keys. The key is only returned once.
+// @ID           createWidgetKey
+// @Tags         Websites
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                     true  "Website ID"
+// @Param        request  body      CreateWidgetKeyRequest  true  "Name, origins and rate limit"
+// @Success      201      {object}  CreateWidgetKeyResponse
+// @Failure      400      {object}  apperrors.Response
+// @Failure      403      {object}  apperrors.Response
+// @Failure      404      {object}  apperrors.Response
+// @Failure      500      {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /websites/{id}/widget-keys [post]
+func (wc *WidgetController) CreateWidgetKey(c echo.Context) error {
+	userID, err := middlewares.GetUserID(c)
+	if err != nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	idParam := c.Param("id")
+	websiteID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return apperrors.Validation("Invalid website ID")
+	}
+
+	var req CreateWidgetKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	origins := make([]string, 0, len(req.AllowedOrigins))
+	for _, origin := range req.AllowedOrigins {
+		normalized, ok := schema.NormalizeOrigin(origin)
+		if !ok {
+			return apperrors.Validation(fmt.Sprintf("Invalid origin %q, expected a host such as docs.example.com or *.example.com", origin))
+		}
+		origins = append(origins, normalized)
+	}
+
+	if _, err := wc.websites.GetOwned(c.Request().Context(), requestActor(c), uint(websiteID)); err != nil {
+		return err
+	}
+
+	apiKey, plainKey, err := wc.authService.CreatePublicKey(c.Request().Context(), userID, uint(websiteID), req.Name, origins, req.RateLimitPerMin)
+	if err != nil {
+		wc.logger.Error("Failed to create widget key", zap.Uint64("websiteID", websiteID), zap.Error(err))
+		return apperrors.Internal("Failed to create widget key", err)
+	}
+
+	return c.JSON(http.StatusCreated, CreateWidgetKeyResponse{
+		APIKey:   apiKey,
+		PlainKey: plainKey,
+		Snippet:  fmt.Sprintf(`<script src="%s://%s/widget.js" data-key="%s" async></script>`, c.Scheme(), c.Request().Host, plainKey),
+	})
+}
+
+// widgetWebsite returns the website of the public key of a widget request,
+// which its owner must still be allowed to query.
+func (wc *WidgetController) widgetWebsite(c echo.Context) (*schema.Website, error) {
+	key := middlewares.GetAPIKey(c)
+	if key == nil || key.WebsiteID == nil {
+		return nil, apperrors.Unauthorized("missing public key")
+	}
+	return wc.websites.Get(c.Request().Context(), requestActor(c), *key.WebsiteID, schema.WebsitePermissionQuery)
+}
+
+// WidgetQuery godoc
+// @Summary      Ask a question from the chat widget
+// @Description  Answers a question about the website of the widget's public key. Requests must come from a page of one of the key's allowed origins and are rate limited per visitor.
+// @ID           widgetQuery
+// @Tags         Widget
+// @Accept       json
+// @Produce      json
+// @Param        query  body      WidgetQueryRequest  true  "Question"
+// @Success      200    {object}  llm.QueryResponse
+// @Failure      400    {object}  apperrors.Response
+// @Failure      401    {object}  apperrors.Response
+// @Failure      403    {object}  apperrors.Response
+// @Failure      409    {object}  apperrors.Response
+// @Failure      429    {object}  apperrors.Response
+// @Failure      500    {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /widget/query [post]
+func (wc *WidgetController) WidgetQuery(c echo.Context) error {
+	website, err := wc.widgetWebsite(c)
+	if err != nil {
+		return err
+	}
+
+	var req WidgetQueryRequest
+	if err := c.Bind(&req); err != nil {
+		return apperrors.Validation("Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	response, err := wc.queries.Query(c.Request().Context(), requestActor(c), website, service.Question{Query: req.Query})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// WidgetSuggestedQuestions godoc
+// @Summary      Suggested questions of the chat widget
+// @Description  Returns questions visitors could ask about the website of the widget's public key, shown when the widget opens.
+// @ID           widgetSuggestedQuestions
+// @Tags         Widget
+// @Produce      json
+// @Success      200  {object}  schema.SuggestedQuestions
+// @Failure      401  {object}  apperrors.Response
+// @Failure      403  {object}  apperrors.Response
+// @Failure      429  {object}  apperrors.Response
+// @Failure      500  {object}  apperrors.Response
+// @Security     BearerAuth
+// @Router       /widget/suggested-questions [get]
+func (wc *WidgetController) WidgetSuggestedQuestions(c echo.Context) error {
+	website, err := wc.widgetWebsite(c)
+	if err != nil {
+		return err
+	}
+
+	suggested, err := wc.queries.Suggest(c.Request().Context(), website)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, suggested)
+}
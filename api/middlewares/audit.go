@@ -0,0 +1,228 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/repositories"
+	"hermit/internal/requestid"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+)
+
+// auditResourceKey is the echo context key a handler sets the ID of the
+// resource it created under, see SetAuditResource.
+const auditResourceKey = "audit_resource_id"
+
+// redacted replaces secrets in audit log snapshots.
+const redacted = "[redacted]"
+
+// SetAuditResource sets the ID of the resource a request created, so its
+// audit log entry refers to it and snapshots it after the request.
+func SetAuditResource(c echo.Context, id string) {
+	c.Set(auditResourceKey, id)
+}
+
+// AuditSnapshot returns the state of a resource recorded in the audit log,
+// nil when it doesn't exist.
+type AuditSnapshot func(ctx context.Context, id string) (interface{}, error)
+
+// Auditor records mutating API requests in the audit log.
+type Auditor struct {
+	auditRepo   *repositories.AuditLogRepository
+	websiteRepo *repositories.WebsiteRepository
+	apiKeyRepo  *repositories.APIKeyRepository
+	logger      *zap.Logger
+}
+
+// NewAuditor creates a new Auditor.
+func NewAuditor(
+	auditRepo *repositories.AuditLogRepository,
+	websiteRepo *repositories.WebsiteRepository,
+	apiKeyRepo *repositories.APIKeyRepository,
+	logger *zap.Logger,
+) *Auditor {
+	return &Auditor{
+		auditRepo:   auditRepo,
+		websiteRepo: websiteRepo,
+		apiKeyRepo:  apiKeyRepo,
+		logger:      logger,
+	}
+}
+
+// Website audits a request changing the website of the id parameter,
+// snapshotting it before and after.
+func (a *Auditor) Website(action string) echo.MiddlewareFunc {
+	return a.Record(action, schema.AuditResourceWebsite, "id", a.snapshotWebsite)
+}
+
+// APIKey audits a request changing the API key of the id parameter,
+// snapshotting it before and after.
+func (a *Auditor) APIKey(action string) echo.MiddlewareFunc {
+	return a.Record(action, schema.AuditResourceAPIKey, "id", a.snapshotAPIKey)
+}
+
+// Action audits a request changing a resource identified by a parameter,
+// which may be empty, without snapshots.
+func (a *Auditor) Action(action, resourceType, param string) echo.MiddlewareFunc {
+	return a.Record(action, resourceType, param, nil)
+}
+
+// Record audits a request changing the resource of the param parameter, or
+// the one set with SetAuditResource. The snapshot, when set, records the
+// resource before and after the request. Failed and refused requests are
+// recorded too, with their status.
+func (a *Auditor) Record(action, resourceType, param string, snapshot AuditSnapshot) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			entry := &schema.AuditLog{
+				Action:       action,
+				ResourceType: resourceType,
+				IP:           c.RealIP(),
+				UserAgent:    c.Request().UserAgent(),
+				RequestID:    requestid.FromContext(ctx),
+				Details:      requestDetails(c),
+			}
+			if param != "" {
+				entry.ResourceID = c.Param(param)
+			}
+			if user := GetUser(c); user != nil {
+				entry.ActorUserID = &user.ID
+				entry.ActorEmail = user.Email
+			}
+			if key := GetAPIKey(c); key != nil {
+				entry.APIKeyID = &key.ID
+			}
+
+			if snapshot != nil && entry.ResourceID != "" {
+				entry.Before = a.snapshot(ctx, snapshot, entry)
+			}
+
+			err := next(c)
+
+			if id, ok := c.Get(auditResourceKey).(string); ok && id != "" {
+				entry.ResourceID = id
+			}
+			entry.Status = responseStatus(c, err)
+			if snapshot != nil && entry.ResourceID != "" && entry.Status < http.StatusBadRequest {
+				entry.After = a.snapshot(ctx, snapshot, entry)
+			} else {
+				entry.After = entry.Before
+			}
+
+			// Record even when the client went away after the change was made
+			if recordErr := a.auditRepo.Create(context.WithoutCancel(ctx), entry); recordErr != nil {
+				a.logger.Error("Failed to record audit log entry",
+					zap.String("action", action),
+					zap.String("resourceID", entry.ResourceID),
+					zap.Error(recordErr),
+				)
+			}
+
+			return err
+		}
+	}
+}
+
+// snapshot returns the JSON state of an entry's resource, or nil when it
+// doesn't exist or can't be loaded.
+func (a *Auditor) snapshot(ctx context.Context, snapshot AuditSnapshot, entry *schema.AuditLog) schema.JSONRaw {
+	state, err := snapshot(ctx, entry.ResourceID)
+	if err != nil {
+		if !apperrors.Is(err, apperrors.CodeNotFound) {
+			a.logger.Warn("Failed to snapshot audited resource",
+				zap.String("resourceType", entry.ResourceType),
+				zap.String("resourceID", entry.ResourceID),
+				zap.Error(err),
+			)
+		}
+		return nil
+	}
+	if state == nil {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		a.logger.Warn("Failed to encode audited resource", zap.String("resourceType", entry.ResourceType), zap.Error(err))
+		return nil
+	}
+	return data
+}
+
+// snapshotWebsite returns a website with the values of its request headers
+// redacted, since they may hold credentials of the crawled site.
+func (a *Auditor) snapshotWebsite(ctx context.Context, id string) (interface{}, error) {
+	websiteID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+	website, err := a.websiteRepo.GetByID(ctx, uint(websiteID))
+	if err != nil {
+		return nil, err
+	}
+	if len(website.RequestHeaders) > 0 {
+		headers := make(schema.RequestHeaders, len(website.RequestHeaders))
+		for name := range website.RequestHeaders {
+			headers[name] = redacted
+		}
+		website.RequestHeaders = headers
+	}
+	return website, nil
+}
+
+// snapshotAPIKey returns an API key, whose hash is never encoded.
+func (a *Auditor) snapshotAPIKey(ctx context.Context, id string) (interface{}, error) {
+	keyID, err := ulid.Parse(id)
+	if err != nil {
+		return nil, nil
+	}
+	return a.apiKeyRepo.GetByID(ctx, keyID)
+}
+
+// requestDetails returns the path parameters and query of a request. The
+// body isn't recorded since it may hold credentials.
+func requestDetails(c echo.Context) schema.JSONRaw {
+	details := map[string]interface{}{
+		"method": c.Request().Method,
+		"path":   c.Path(),
+	}
+	if names := c.ParamNames(); len(names) > 0 {
+		params := make(map[string]string, len(names))
+		for _, name := range names {
+			params[name] = c.Param(name)
+		}
+		details["params"] = params
+	}
+	if query := c.QueryParams(); len(query) > 0 {
+		details["query"] = query
+	}
+	data, err := json.Marshal(details)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// responseStatus returns the status a request was or will be answered with,
+// the error handler writing the response of failed requests.
+func responseStatus(c echo.Context, err error) int {
+	if err == nil {
+		return c.Response().Status
+	}
+	if appErr, ok := apperrors.As(err); ok {
+		return appErr.Code.Status()
+	}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return http.StatusInternalServerError
+}
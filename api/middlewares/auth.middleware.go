@@ -2,11 +2,12 @@ package middlewares
 
 import (
 	"context"
-	"net/http"
 	"strings"
 
+	"hermit/internal/apperrors"
 	"hermit/internal/auth"
 	"hermit/internal/schema"
+	"hermit/internal/tenant"
 
 	"github.com/labstack/echo/v4"
 	"github.com/oklog/ulid/v2"
@@ -29,32 +30,27 @@ func AuthMiddleware(authService *auth.Service) echo.MiddlewareFunc {
 			// Get API key from Authorization header
 			authHeader := c.Request().Header.Get("Authorization")
 			if authHeader == "" {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "missing authorization header",
-				})
+				return apperrors.Unauthorized("missing authorization header")
 			}
 
 			// Expected format: "Bearer hmt_xxxxx"
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "invalid authorization header format",
-				})
+				return apperrors.Unauthorized("invalid authorization header format")
 			}
 
 			apiKey := parts[1]
 
 			// Validate API key
-			user, key, err := authService.ValidateAPIKey(apiKey)
+			user, key, err := authService.ValidateAPIKey(c.Request().Context(), apiKey)
 			if err != nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "invalid or expired API key",
-				})
+				return apperrors.Unauthorized("invalid or expired API key")
 			}
 
 			// Store user and API key in context
 			ctx := context.WithValue(c.Request().Context(), UserContextKey, user)
 			ctx = context.WithValue(ctx, APIKeyContextKey, key)
+			ctx = tenant.WithTenant(ctx, tenant.FromUser(user, key))
 			c.SetRequest(c.Request().WithContext(ctx))
 
 			return next(c)
@@ -83,7 +79,7 @@ func OptionalAuthMiddleware(authService *auth.Service) echo.MiddlewareFunc {
 			apiKey := parts[1]
 
 			// Validate API key
-			user, key, err := authService.ValidateAPIKey(apiKey)
+			user, key, err := authService.ValidateAPIKey(c.Request().Context(), apiKey)
 			if err != nil {
 				// Invalid key, continue without user context
 				return next(c)
@@ -92,6 +88,7 @@ func OptionalAuthMiddleware(authService *auth.Service) echo.MiddlewareFunc {
 			// Store user and API key in context
 			ctx := context.WithValue(c.Request().Context(), UserContextKey, user)
 			ctx = context.WithValue(ctx, APIKeyContextKey, key)
+			ctx = tenant.WithTenant(ctx, tenant.FromUser(user, key))
 			c.SetRequest(c.Request().WithContext(ctx))
 
 			return next(c)
@@ -105,9 +102,7 @@ func RequireRole(roles ...string) echo.MiddlewareFunc {
 		return func(c echo.Context) error {
 			user := GetUser(c)
 			if user == nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "authentication required",
-				})
+				return apperrors.Unauthorized("authentication required")
 			}
 
 			// Check if user has any of the required roles
@@ -117,9 +112,7 @@ func RequireRole(roles ...string) echo.MiddlewareFunc {
 				}
 			}
 
-			return c.JSON(http.StatusForbidden, map[string]string{
-				"error": "insufficient permissions",
-			})
+			return apperrors.Forbidden("insufficient permissions")
 		}
 	}
 }
@@ -128,24 +121,45 @@ func RequireRole(roles ...string) echo.MiddlewareFunc {
 func RequireScope(scope string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			apiKey := GetAPIKey(c)
-			if apiKey == nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "authentication required",
-				})
+			if err := checkScope(c, scope); err != nil {
+				return err
 			}
+			return next(c)
+		}
+	}
+}
 
-			if !apiKey.HasScope(scope) {
-				return c.JSON(http.StatusForbidden, map[string]string{
-					"error": "insufficient scope",
-				})
+// RequireWebsiteScope creates a middleware that checks if the API key has the
+// scope for an action on the website of the id path parameter, e.g.
+// websites:42:read, or on every website for routes without one.
+func RequireWebsiteScope(action string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			scope := "websites:" + action
+			if id := c.Param("id"); id != "" {
+				scope = "websites:" + id + ":" + action
+			}
+			if err := checkScope(c, scope); err != nil {
+				return err
 			}
-
 			return next(c)
 		}
 	}
 }
 
+// checkScope returns an error unless the API key of the request has scope.
+func checkScope(c echo.Context, scope string) error {
+	apiKey := GetAPIKey(c)
+	if apiKey == nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+
+	if !apiKey.HasScope(scope) {
+		return apperrors.Forbidden("insufficient scope, " + scope + " is required")
+	}
+	return nil
+}
+
 // GetUser retrieves the authenticated user from context
 func GetUser(c echo.Context) *schema.User {
 	user := c.Request().Context().Value(UserContextKey)
@@ -168,7 +182,7 @@ func GetAPIKey(c echo.Context) *schema.APIKey {
 func GetUserID(c echo.Context) (ulid.ULID, error) {
 	user := GetUser(c)
 	if user == nil {
-		return ulid.ULID{}, echo.NewHTTPError(http.StatusUnauthorized, "authentication required")
+		return ulid.ULID{}, apperrors.Unauthorized("authentication required")
 	}
 	return user.ID, nil
 }
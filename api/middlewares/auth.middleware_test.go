@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRequireWebsiteScope(t *testing.T) {
+	key := &schema.APIKey{Scopes: []string{"websites:42:read"}}
+
+	tests := []struct {
+		name    string
+		action  string
+		id      string
+		apiKey  *schema.APIKey
+		wantErr apperrors.Code
+	}{
+		{name: "read of the scoped website", action: "read", id: "42", apiKey: key},
+		{name: "read of another website", action: "read", id: "7", apiKey: key, wantErr: apperrors.CodeForbidden},
+		{name: "write to the scoped website", action: "write", id: "42", apiKey: key, wantErr: apperrors.CodeForbidden},
+		{name: "query of the scoped website", action: "query", id: "42", apiKey: key, wantErr: apperrors.CodeForbidden},
+		{name: "list of all websites", action: "read", apiKey: key, wantErr: apperrors.CodeForbidden},
+		{name: "key without scopes", action: "delete", id: "7", apiKey: &schema.APIKey{}},
+		{name: "no key", action: "read", id: "42", wantErr: apperrors.CodeUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/websites", nil)
+			if tt.apiKey != nil {
+				req = req.WithContext(context.WithValue(req.Context(), APIKeyContextKey, tt.apiKey))
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+			if tt.id != "" {
+				c.SetParamNames("id")
+				c.SetParamValues(tt.id)
+			}
+
+			called := false
+			handler := RequireWebsiteScope(tt.action)(func(c echo.Context) error {
+				called = true
+				return nil
+			})
+			err := handler(c)
+
+			if tt.wantErr == "" {
+				if err != nil || !called {
+					t.Fatalf("error = %v, called = %v, want the handler to run", err, called)
+				}
+				return
+			}
+			if !apperrors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %s", err, tt.wantErr)
+			}
+			if called {
+				t.Error("handler ran for a rejected request")
+			}
+		})
+	}
+}
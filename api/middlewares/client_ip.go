@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"net"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// NewIPExtractor returns how the client IP of requests is found. Behind
+// trusted proxies it is the last address of X-Forwarded-For that isn't one of
+// them, otherwise the address of the connection, so that clients can't evade
+// per-IP limits by sending the header themselves.
+func NewIPExtractor(trustedProxies []string) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	// Only the configured proxies are trusted, not every private address
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range trustedProxies {
+		if ipRange := parseIPRange(proxy); ipRange != nil {
+			options = append(options, echo.TrustIPRange(ipRange))
+		}
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// parseIPRange parses a CIDR range or a single IP, nil when it is neither.
+func parseIPRange(value string) *net.IPNet {
+	if _, ipRange, err := net.ParseCIDR(value); err == nil {
+		return ipRange
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil && !strings.Contains(value, ":") {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+}
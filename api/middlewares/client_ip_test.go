@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewIPExtractor(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		want           string
+	}{
+		{
+			name:         "header ignored without trusted proxies",
+			remoteAddr:   "203.0.113.7:51234",
+			forwardedFor: "198.51.100.1",
+			want:         "203.0.113.7",
+		},
+		{
+			name:         "private peers aren't trusted by default",
+			remoteAddr:   "10.0.0.5:51234",
+			forwardedFor: "198.51.100.1",
+			want:         "10.0.0.5",
+		},
+		{
+			name:           "client behind a trusted proxy",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.5:51234",
+			forwardedFor:   "198.51.100.1",
+			want:           "198.51.100.1",
+		},
+		{
+			name:           "spoofed entries before the proxy are skipped",
+			trustedProxies: []string{"10.0.0.5"},
+			remoteAddr:     "10.0.0.5:51234",
+			forwardedFor:   "192.0.2.99, 198.51.100.1",
+			want:           "198.51.100.1",
+		},
+		{
+			name:           "header ignored from untrusted peers",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "203.0.113.7:51234",
+			forwardedFor:   "198.51.100.1",
+			want:           "203.0.113.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+
+			if got := NewIPExtractor(tt.trustedProxies)(req); got != tt.want {
+				t.Errorf("client IP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/deadline"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// DeadlineConfig holds configuration for request deadlines.
+type DeadlineConfig struct {
+	Default time.Duration
+	// Routes overrides the deadline per route, keyed by method and route
+	// path, e.g. "POST /api/v1/websites/:id/query". 0 disables the deadline.
+	Routes map[string]time.Duration
+}
+
+// NewDeadline creates a middleware that cancels the request context once the
+// deadline of its route passes. Requests that fail because they ran out of
+// time get a 504 listing the stages that completed instead of a 500.
+// WebSocket upgrades and NDJSON streams, which end when the answer is
+// complete, are never given a deadline.
+func NewDeadline(cfg DeadlineConfig, logger *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			timeout := cfg.Default
+			if override, ok := cfg.Routes[c.Request().Method+" "+c.Path()]; ok {
+				timeout = override
+			}
+			streaming := strings.Contains(c.Request().Header.Get(echo.HeaderAccept), "application/x-ndjson")
+			if timeout <= 0 || c.IsWebSocket() || streaming {
+				return next(c)
+			}
+
+			start := time.Now()
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			ctx, trace := deadline.WithTrace(ctx)
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			res := c.Response()
+			writer := &deadlineWriter{ResponseWriter: res.Writer, ctx: ctx}
+			res.Writer = writer
+			defer func() { res.Writer = writer.ResponseWriter }()
+
+			err := next(c)
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) || (res.Committed && !writer.timedOut) {
+				return err
+			}
+
+			logger.Warn("Request deadline exceeded",
+				zap.String("method", c.Request().Method),
+				zap.String("path", c.Path()),
+				zap.Duration("timeout", timeout),
+				zap.Strings("pendingStages", trace.Pending()),
+			)
+
+			// Replace the error response the handler produced with a timeout
+			res.Writer = writer.ResponseWriter
+			if writer.timedOut {
+				res.Committed = false
+			}
+			return apperrors.New(apperrors.CodeTimeout, "Request timed out").WithDetails(map[string]interface{}{
+				"timeout":          timeout.String(),
+				"elapsed":          time.Since(start).Round(time.Millisecond).String(),
+				"completed_stages": trace.Completed(),
+				"pending_stages":   trace.Pending(),
+			})
+		}
+	}
+}
+
+// deadlineWriter discards error responses written after the deadline passed
+// so they can be replaced by a timeout response.
+type deadlineWriter struct {
+	http.ResponseWriter
+	ctx      context.Context
+	timedOut bool
+}
+
+func (w *deadlineWriter) WriteHeader(code int) {
+	if code >= http.StatusInternalServerError && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		w.timedOut = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *deadlineWriter) Write(b []byte) (int, error) {
+	if w.timedOut {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *deadlineWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/tenant"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// NewErrorHandler returns an echo.HTTPErrorHandler that answers every error
+// with an apperrors.Response. Typed errors keep their code and message, echo
+// errors are mapped by status and anything else is reported as an internal
+// error without leaking its message.
+func NewErrorHandler(logger *zap.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+		response := apperrors.Response{RequestID: requestID}
+
+		var httpErr *echo.HTTPError
+		appErr, ok := apperrors.As(err)
+		switch {
+		case ok:
+			response.Error = appErr.Message
+			response.Code = appErr.Code
+			response.Details = appErr.Details
+		case errors.As(err, &httpErr):
+			response.Code = apperrors.CodeForStatus(httpErr.Code)
+			response.Error = fmt.Sprint(httpErr.Message)
+			if httpErr.Code >= http.StatusInternalServerError {
+				response.Error = http.StatusText(httpErr.Code)
+			}
+		default:
+			response.Code = apperrors.CodeInternal
+			response.Error = "Internal server error"
+		}
+
+		status := response.Code.Status()
+		if httpErr != nil {
+			status = httpErr.Code
+		}
+
+		if status >= http.StatusInternalServerError {
+			fields := []zap.Field{
+				zap.String("method", c.Request().Method),
+				zap.String("path", c.Path()),
+				zap.String("requestID", requestID),
+				zap.String("code", string(response.Code)),
+				zap.Error(err),
+			}
+			logger.Error("Request failed", append(fields, tenant.Fields(c.Request().Context())...)...)
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(status)
+		} else {
+			err = c.JSON(status, response)
+		}
+		if err != nil {
+			logger.Error("Failed to write error response", zap.Error(err))
+		}
+	}
+}
@@ -2,24 +2,43 @@ package middlewares
 
 import (
 	"hermit/internal/config"
+	"hermit/internal/tenant"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
 )
 
+// WidgetPathPrefix is the prefix of the API routes of the chat widget.
+const WidgetPathPrefix = "/api/v1/widget/"
+
 func SetupMiddlewares(e *echo.Echo, logger *zap.Logger, cfg *config.Config) {
+	// Rate limits and the login lockout count requests per client IP
+	e.IPExtractor = NewIPExtractor(cfg.TrustedProxies)
+
+	// Answer every error with a consistent JSON body carrying the request ID
+	e.HTTPErrorHandler = NewErrorHandler(logger)
+	e.Validator = NewRequestValidator()
+	e.Use(NewRequestID())
+
 	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
-		LogURI:    true,
-		LogStatus: true,
+		LogURI:       true,
+		LogStatus:    true,
+		LogRequestID: true,
 		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
-			logger.Info("request",
+			fields := []zap.Field{
 				zap.String("URI", v.URI),
 				zap.Int("status", v.Status),
 				zap.String("method", v.Method),
 				zap.Duration("latency", v.Latency),
-			)
+				zap.String("requestID", v.RequestID),
+			}
+			// Attribute authenticated requests to their tenant
+			fields = append(fields, tenant.Fields(c.Request().Context())...)
+			logger.Info("request", fields...)
 			return nil
 		},
 	}))
@@ -45,16 +64,48 @@ func SetupMiddlewares(e *echo.Echo, logger *zap.Logger, cfg *config.Config) {
 	}
 	e.Use(NewRateLimiter(rateLimiterCfg, logger))
 
+	// Per-route request deadlines
+	queryTimeout := time.Duration(cfg.RequestTimeoutQuery) * time.Second
+	statusTimeout := time.Duration(cfg.RequestTimeoutStatus) * time.Second
+	e.Use(NewDeadline(DeadlineConfig{
+		Default: time.Duration(cfg.RequestTimeout) * time.Second,
+		Routes: map[string]time.Duration{
+			"POST /api/v1/websites/:id/query":         queryTimeout,
+			"POST /api/v1/websites/:id/query/sources": queryTimeout,
+			"POST /api/v1/widget/query":               queryTimeout,
+			"GET /api/v1/websites/:id/status":         statusTimeout,
+			"GET /api/v1/health":                      statusTimeout,
+			"GET /api/health":                         statusTimeout,
+			// Streams end when the answer is complete
+			"POST /api/v1/websites/:id/query/stream": 0,
+		},
+	}, logger))
+
 	// CORS configuration
 	corsOrigins := []string{"*"}
 	if cfg.Port == "8080" { // Production check - adjust as needed
 		corsOrigins = []string{"https://yourdomain.com"} // Configure via env in production
 	}
 
+	// The chat widget is embedded on any site, its public keys restrict the
+	// origins instead
+	isWidget := func(c echo.Context) bool {
+		return strings.HasPrefix(c.Request().URL.Path, WidgetPathPrefix)
+	}
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		Skipper:      func(c echo.Context) bool { return !isWidget(c) },
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodOptions},
+		AllowHeaders: []string{echo.HeaderContentType, echo.HeaderAuthorization},
+		MaxAge:       3600,
+	}))
+
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		Skipper:          isWidget,
 		AllowOrigins:     corsOrigins,
 		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodPut, http.MethodDelete, http.MethodOptions},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "If-None-Match"},
+		ExposeHeaders:    []string{echo.HeaderXRequestID, "ETag"},
 		AllowCredentials: true,
 		MaxAge:           3600,
 	}))
@@ -1,10 +1,11 @@
 package middlewares
 
 import (
-	"net/http"
 	"sync"
 	"time"
 
+	"hermit/internal/apperrors"
+
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
@@ -39,20 +40,7 @@ func NewRateLimiter(cfg RateLimiterConfig, logger *zap.Logger) echo.MiddlewareFu
 		}
 	}
 
-	limiter := &ipRateLimiter{
-		visitors: make(map[string]*visitor),
-		limit:    cfg.RequestsPerMinute,
-		window:   time.Minute,
-	}
-
-	// Cleanup old visitors every 5 minutes
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-		for range ticker.C {
-			limiter.cleanup()
-		}
-	}()
+	limiter := newIPRateLimiter(cfg.RequestsPerMinute)
 
 	logger.Info("Rate limiting enabled",
 		zap.Int64("requests_per_minute", cfg.RequestsPerMinute),
@@ -67,10 +55,7 @@ func NewRateLimiter(cfg RateLimiterConfig, logger *zap.Logger) echo.MiddlewareFu
 					zap.String("ip", ip),
 					zap.String("path", c.Request().URL.Path),
 				)
-				return c.JSON(http.StatusTooManyRequests, map[string]string{
-					"error":   "Rate limit exceeded",
-					"message": "Too many requests. Please try again later.",
-				})
+				return apperrors.QuotaExceeded("Rate limit exceeded, please try again later")
 			}
 
 			return next(c)
@@ -78,23 +63,49 @@ func NewRateLimiter(cfg RateLimiterConfig, logger *zap.Logger) echo.MiddlewareFu
 	}
 }
 
+// newIPRateLimiter creates a limiter allowing limit requests per minute and
+// cleaning up old visitors every 5 minutes.
+func newIPRateLimiter(limit int64) *ipRateLimiter {
+	limiter := &ipRateLimiter{
+		visitors: make(map[string]*visitor),
+		limit:    limit,
+		window:   time.Minute,
+	}
+
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			limiter.cleanup()
+		}
+	}()
+
+	return limiter
+}
+
 // allow checks if the IP is allowed to make a request.
 func (l *ipRateLimiter) allow(ip string) bool {
+	return l.allowLimit(ip, l.limit)
+}
+
+// allowLimit checks if the visitor is allowed to make a request, limit
+// overriding the limiter's limit.
+func (l *ipRateLimiter) allowLimit(key string, limit int64) bool {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	now := time.Now()
-	v, exists := l.visitors[ip]
+	v, exists := l.visitors[key]
 
 	if !exists || now.Sub(v.firstSeen) > l.window {
-		l.visitors[ip] = &visitor{
+		l.visitors[key] = &visitor{
 			requests:  1,
 			firstSeen: now,
 		}
 		return true
 	}
 
-	if v.requests >= l.limit {
+	if v.requests >= limit {
 		return false
 	}
 
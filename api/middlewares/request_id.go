@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"hermit/internal/requestid"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oklog/ulid/v2"
+)
+
+// NewRequestID returns a middleware that identifies every request. A valid
+// X-Request-ID sent by the client is reused, otherwise one is generated. The
+// ID is returned in the response and stored in the request context, from where
+// it is added to logs and to the payload of jobs enqueued by the request.
+func NewRequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if !requestid.Valid(id) {
+				id = ulid.Make().String()
+				req.Header.Set(echo.HeaderXRequestID, id)
+			}
+
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+			c.SetRequest(req.WithContext(requestid.WithRequestID(req.Context(), id)))
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"hermit/internal/apperrors"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// RequestValidator validates request bodies using their `validate` struct
+// tags. It is installed as the echo.Validator so handlers can call
+// c.Validate after c.Bind.
+type RequestValidator struct {
+	validate *validator.Validate
+}
+
+// NewRequestValidator creates a RequestValidator with the custom tags used by
+// the API registered.
+func NewRequestValidator() *RequestValidator {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON name so messages match the request body
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	// weburl accepts absolute http and https URLs only
+	_ = v.RegisterValidation("weburl", func(fl validator.FieldLevel) bool {
+		u, err := url.Parse(strings.TrimSpace(fl.Field().String()))
+		if err != nil {
+			return false
+		}
+		return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+	})
+
+	return &RequestValidator{validate: v}
+}
+
+// Validate implements echo.Validator. Invalid input is reported as an
+// apperrors validation error listing every failing field in its details.
+func (rv *RequestValidator) Validate(i interface{}) error {
+	err := rv.validate.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return apperrors.Internal("Failed to validate request", err)
+	}
+
+	parent := reflect.Indirect(reflect.ValueOf(i)).Type()
+	fields := make(map[string]interface{}, len(validationErrs))
+	messages := make([]string, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		name := fieldName(fe)
+		message := validationMessage(fe, parent)
+		fields[name] = message
+		messages = append(messages, name+" "+message)
+	}
+
+	return apperrors.Validation(strings.Join(messages, "; ")).
+		WithDetails(map[string]interface{}{"fields": fields})
+}
+
+// fieldName returns the JSON path of a failing field without the top-level
+// struct name, e.g. "urls[2]".
+func fieldName(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// validationMessage describes why a field failed validation. Fields named in
+// cross-field rules are looked up on parent to report their JSON name.
+func validationMessage(fe validator.FieldError, parent reflect.Type) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return fmt.Sprintf("is required when %s is not set", jsonName(parent, fe.Param()))
+	case "email":
+		return "must be a valid email address"
+	case "weburl":
+		return "must be an absolute http or https URL"
+	case "url":
+		return "must be a valid URL"
+	case "ulid":
+		return "must be a valid ULID"
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "min":
+		if isCollection(fe.Kind()) {
+			return fmt.Sprintf("must contain at least %s item(s)", fe.Param())
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if isCollection(fe.Kind()) {
+			return fmt.Sprintf("must contain at most %s item(s)", fe.Param())
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", fe.Param())
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s", fe.Param())
+	default:
+		return fmt.Sprintf("failed the %q rule", fe.Tag())
+	}
+}
+
+// jsonName returns the JSON name of the named field of t, or name itself when
+// t has no such field.
+func jsonName(t reflect.Type, name string) string {
+	if t.Kind() != reflect.Struct {
+		return name
+	}
+	field, ok := t.FieldByName(name)
+	if !ok {
+		return name
+	}
+	if tag := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]; tag != "" && tag != "-" {
+		return tag
+	}
+	return name
+}
+
+func isCollection(kind reflect.Kind) bool {
+	return kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+}
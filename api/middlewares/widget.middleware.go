@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"context"
+	"strings"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/auth"
+	"hermit/internal/tenant"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// WidgetAuthMiddleware creates a middleware that authenticates the chat
+// widget with a public key. Requests must come from a page of one of the
+// key's allowed origins, and each visitor may make the key's number of
+// requests per minute, defaultLimit when it doesn't set one. All visitors of
+// a key together may make keyLimit requests per minute, or the visitor limit
+// when it is higher, so rotating client IPs doesn't lift the limit of a
+// copied key. A keyLimit of 0 disables the limit per key.
+func WidgetAuthMiddleware(authService *auth.Service, defaultLimit, keyLimit int64, logger *zap.Logger) echo.MiddlewareFunc {
+	limiter := newIPRateLimiter(defaultLimit)
+	keyLimiter := newIPRateLimiter(keyLimit)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Expected format: "Bearer hmt_pub_xxxxx"
+			parts := strings.SplitN(c.Request().Header.Get("Authorization"), " ", 2)
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				return apperrors.Unauthorized("missing public key")
+			}
+
+			user, key, err := authService.ValidatePublicKey(c.Request().Context(), parts[1])
+			if err != nil {
+				return apperrors.Unauthorized("invalid or expired public key")
+			}
+
+			// Browsers always send the origin of cross-origin requests, the
+			// rate limit bounds what other clients can do with a copied key
+			origin := c.Request().Header.Get(echo.HeaderOrigin)
+			if !key.AllowsOrigin(origin) {
+				return apperrors.Forbidden("this key can't be used from " + origin)
+			}
+
+			limit := defaultLimit
+			if key.RateLimitPerMin > 0 {
+				limit = int64(key.RateLimitPerMin)
+			}
+			if !limiter.allowLimit(key.ID.String()+"|"+c.RealIP(), limit) {
+				logger.Warn("Widget rate limit exceeded",
+					zap.String("keyID", key.ID.String()),
+					zap.String("ip", c.RealIP()),
+				)
+				return apperrors.QuotaExceeded("Rate limit exceeded, please try again later")
+			}
+			if keyLimit > 0 && !keyLimiter.allowLimit(key.ID.String(), max(keyLimit, limit)) {
+				logger.Warn("Widget key rate limit exceeded",
+					zap.String("keyID", key.ID.String()),
+					zap.String("ip", c.RealIP()),
+				)
+				return apperrors.QuotaExceeded("Rate limit exceeded, please try again later")
+			}
+
+			ctx := context.WithValue(c.Request().Context(), UserContextKey, user)
+			ctx = context.WithValue(ctx, APIKeyContextKey, key)
+			ctx = tenant.WithTenant(ctx, tenant.FromUser(user, key))
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
@@ -6,11 +6,15 @@ import (
 	"hermit/api/controllers"
 	"hermit/api/middlewares"
 	"hermit/internal/auth"
+	"hermit/internal/config"
 	"hermit/internal/repositories"
+	"hermit/internal/schema"
+	"hermit/internal/service"
 	"hermit/web"
 
 	"github.com/labstack/echo/v4"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"go.uber.org/zap"
 )
 
 // AppForRoutes defines the interface required by the route setup functions.
@@ -26,10 +30,17 @@ func SetupRoutes(
 	hc *controllers.HealthController,
 	jc *controllers.JobsController,
 	ac *controllers.AuthController,
+	adc *controllers.AdminController,
+	sc *controllers.SlackController,
+	dc *controllers.DiscordController,
+	wgc *controllers.WidgetController,
+	audit *middlewares.Auditor,
+	cfg *config.Config,
 	authService *auth.Service,
-	websiteRepo *repositories.WebsiteRepository,
 	apiKeyRepo *repositories.APIKeyRepository,
-	userRepo *repositories.UserRepository,
+	websites *service.WebsiteService,
+	queries *service.QueryService,
+	logger *zap.Logger,
 ) {
 	// Root Route
 	e.GET("/", func(c echo.Context) error {
@@ -54,47 +65,146 @@ func SetupRoutes(
 
 	// Auth Routes (public, no auth required)
 	authRoutes := v1.Group("/auth")
-	authRoutes.POST("/register", ac.Register)
+	authRoutes.POST("/register", ac.Register, audit.Action("user.register", schema.AuditResourceUser, ""))
 	authRoutes.POST("/login", ac.Login)
+	authRoutes.GET("/scopes", ac.ListScopes)
+
+	// Scopes of API keys, applied to the routes they cover
+	read := middlewares.RequireWebsiteScope("read")
+	write := middlewares.RequireWebsiteScope("write")
+	del := middlewares.RequireWebsiteScope("delete")
+	crawl := middlewares.RequireWebsiteScope("crawl")
+	query := middlewares.RequireWebsiteScope("query")
+	keysRead := middlewares.RequireScope("api_keys:read")
+	keysWrite := middlewares.RequireScope("api_keys:write")
+	keysDelete := middlewares.RequireScope("api_keys:delete")
+	jobsRead := middlewares.RequireScope("jobs:read")
+	jobsWrite := middlewares.RequireScope("jobs:write")
 
 	// Auth Routes (protected, auth required)
 	authProtectedRoutes := v1.Group("/auth")
 	authProtectedRoutes.Use(middlewares.AuthMiddleware(authService))
 	authProtectedRoutes.GET("/me", ac.GetMe)
-	authProtectedRoutes.POST("/api-keys", ac.CreateAPIKey)
-	authProtectedRoutes.GET("/api-keys", ac.ListAPIKeys)
-	authProtectedRoutes.GET("/api-keys/:id", ac.GetAPIKey)
-	authProtectedRoutes.PUT("/api-keys/:id", ac.UpdateAPIKey)
-	authProtectedRoutes.DELETE("/api-keys/:id", ac.RevokeAPIKey)
+	authProtectedRoutes.GET("/login-activity", ac.GetLoginActivity)
+	authProtectedRoutes.POST("/api-keys", ac.CreateAPIKey, keysWrite, audit.APIKey("api_key.create"))
+	authProtectedRoutes.GET("/api-keys", ac.ListAPIKeys, keysRead)
+	authProtectedRoutes.GET("/api-keys/:id", ac.GetAPIKey, keysRead)
+	authProtectedRoutes.PUT("/api-keys/:id", ac.UpdateAPIKey, keysWrite, audit.APIKey("api_key.update"))
+	authProtectedRoutes.DELETE("/api-keys/:id", ac.RevokeAPIKey, keysDelete, audit.APIKey("api_key.revoke"))
+	authProtectedRoutes.GET("/sessions", ac.ListSessions, keysRead)
+	authProtectedRoutes.DELETE("/sessions", ac.RevokeSessions, keysDelete, audit.Action("session.revoke_all", schema.AuditResourceSession, ""))
+	authProtectedRoutes.DELETE("/sessions/:id", ac.RevokeSession, keysDelete, audit.APIKey("session.revoke"))
 
 	// Website Routes (protected)
 	websiteRoutes := v1.Group("/websites")
 	websiteRoutes.Use(middlewares.AuthMiddleware(authService))
-	websiteRoutes.POST("", wc.CreateWebsite)
-	websiteRoutes.GET("", wc.ListWebsites)
-	websiteRoutes.GET("/:id/pages", wc.GetPages)
-	websiteRoutes.POST("/:id/query", wc.QueryWebsite)
-	websiteRoutes.POST("/:id/query/stream", wc.QueryWebsiteStream)
-	websiteRoutes.GET("/:id/status", wc.GetWebsiteStatus)
-	websiteRoutes.POST("/:id/recrawl", wc.RecrawlWebsite)
+	websiteRoutes.POST("", wc.CreateWebsite, write, audit.Website("website.create"))
+	websiteRoutes.POST("/bulk", wc.CreateWebsitesBulk, write, audit.Action("website.bulk_create", schema.AuditResourceWebsite, ""))
+	websiteRoutes.GET("", wc.ListWebsites, read)
+	websiteRoutes.GET("/labels", wc.ListLabels, read)
+	websiteRoutes.POST("/query", wc.QueryLabel, query)
+	websiteRoutes.GET("/:id/pages", wc.GetPages, read)
+	websiteRoutes.POST("/:id/pages", wc.IndexPages, crawl, audit.Website("website.pages.index"))
+	websiteRoutes.POST("/:id/pages/revectorize", wc.RevectorizeFailedPages, crawl, audit.Website("website.pages.revectorize"))
+	websiteRoutes.GET("/:id/pages/:pageID/screenshot", wc.GetPageScreenshot, read)
+	websiteRoutes.GET("/:id/pages/:pageID/metadata", wc.GetPageContentMetadata, read)
+	websiteRoutes.POST("/:id/pages/:pageID/reprocess", wc.ReprocessPage, crawl, audit.Website("website.pages.reprocess"))
+	websiteRoutes.GET("/:id/tags", wc.GetTags, read)
+	websiteRoutes.GET("/:id/visual-changes", wc.GetVisualChanges, read)
+	websiteRoutes.GET("/:id/crawl-events", wc.GetCrawlEvents, read)
+	websiteRoutes.POST("/:id/query", wc.QueryWebsite, query)
+	websiteRoutes.POST("/:id/query/sources", wc.PreviewQuerySources, query)
+	websiteRoutes.POST("/:id/query/stream", wc.QueryWebsiteStream, query)
+	websiteRoutes.GET("/:id/suggested-questions", wc.GetSuggestedQuestions, query)
+	websiteRoutes.GET("/:id/search", wc.SearchPages, read)
+	websiteRoutes.GET("/:id/usage", wc.GetWebsiteUsage, read)
+	websiteRoutes.POST("/:id/queries/:queryID/feedback", wc.SubmitQueryFeedback, query)
+	websiteRoutes.GET("/:id/status", wc.GetWebsiteStatus, read)
+	websiteRoutes.POST("/:id/recrawl", wc.RecrawlWebsite, crawl, audit.Website("website.recrawl"))
+	websiteRoutes.POST("/:id/reindex", wc.ReindexWebsite, crawl, audit.Website("website.reindex"))
+	websiteRoutes.PUT("/:id/crawl-scope", wc.SetCrawlScope, write, audit.Website("website.crawl_scope.update"))
+	websiteRoutes.PUT("/:id/content-quality", wc.SetContentQuality, write, audit.Website("website.content_quality.update"))
+	websiteRoutes.PUT("/:id/pii-redaction", wc.SetPIIRedaction, write, audit.Website("website.pii_redaction.update"))
+	websiteRoutes.PUT("/:id/noise-patterns", wc.SetNoisePatterns, write, audit.Website("website.noise_patterns.update"))
+	websiteRoutes.PUT("/:id/labels", wc.SetLabels, write, audit.Website("website.labels.update"))
+	websiteRoutes.PUT("/:id/request-settings", wc.SetRequestSettings, write, audit.Website("website.request_settings.update"))
+	websiteRoutes.PUT("/:id/robots", wc.SetRespectRobots, write, audit.Website("website.robots.update"))
+	websiteRoutes.PUT("/:id/crawl-window", wc.SetCrawlWindow, write, audit.Website("website.crawl_window.update"))
+	websiteRoutes.GET("/:id/pii-report", wc.GetPIIReport, read)
+	websiteRoutes.GET("/:id/credentials", wc.GetCredentials, read)
+	websiteRoutes.PUT("/:id/credentials", wc.SetCredentials, write, audit.Website("website.credentials.set"))
+	websiteRoutes.DELETE("/:id/credentials", wc.DeleteCredentials, del, audit.Website("website.credentials.delete"))
+	websiteRoutes.GET("/:id/connector", wc.GetConnector, read)
+	websiteRoutes.PUT("/:id/connector", wc.SetConnector, write, audit.Website("website.connector.set"))
+	websiteRoutes.DELETE("/:id/connector", wc.DeleteConnector, del, audit.Website("website.connector.delete"))
+	websiteRoutes.GET("/:id/evaluation/cases", wc.GetEvaluationCases, read)
+	websiteRoutes.PUT("/:id/evaluation/cases", wc.SetEvaluationCases, write, audit.Website("website.evaluation_cases.update"))
+	websiteRoutes.POST("/:id/evaluation/runs", wc.CreateEvaluationRun, query, audit.Website("website.evaluation_run.create"))
+	websiteRoutes.GET("/:id/evaluation/runs", wc.ListEvaluationRuns, read)
+	websiteRoutes.GET("/:id/evaluation/runs/:runID", wc.GetEvaluationRun, read)
+	websiteRoutes.GET("/:id/permissions", wc.ListPermissions, read)
+	websiteRoutes.POST("/:id/permissions", wc.GrantPermission, write, audit.Website("website.permission.grant"))
+	websiteRoutes.DELETE("/:id/permissions/:permissionID", wc.RevokePermission, del, audit.Website("website.permission.revoke"))
+	websiteRoutes.GET("/:id/slack-channels", wc.ListSlackChannels, read)
+	websiteRoutes.POST("/:id/slack-channels", wc.LinkSlackChannel, write, audit.Website("website.slack_channel.link"))
+	websiteRoutes.DELETE("/:id/slack-channels/:channelID", wc.UnlinkSlackChannel, del, audit.Website("website.slack_channel.unlink"))
+	websiteRoutes.GET("/:id/discord-channels", wc.ListDiscordChannels, read)
+	websiteRoutes.POST("/:id/discord-channels", wc.LinkDiscordChannel, write, audit.Website("website.discord_channel.link"))
+	websiteRoutes.DELETE("/:id/discord-channels/:channelID", wc.UnlinkDiscordChannel, del, audit.Website("website.discord_channel.unlink"))
+	websiteRoutes.POST("/:id/widget-keys", wgc.CreateWidgetKey, write, audit.Website("website.widget_key.create"))
+
+	// Slack Routes (public, requests are signed by Slack)
+	slackRoutes := v1.Group("/slack")
+	slackRoutes.POST("/commands", sc.HandleCommand)
+	slackRoutes.POST("/events", sc.HandleEvent)
+
+	// Discord Routes (public, requests are signed by Discord)
+	v1.POST("/discord/interactions", dc.HandleInteraction)
+
+	// Chat Widget Routes (public keys, restricted to their origins)
+	widgetRoutes := v1.Group("/widget")
+	widgetRoutes.Use(middlewares.WidgetAuthMiddleware(authService, cfg.WidgetRateLimitPerMin, cfg.WidgetKeyRateLimitPerMin, logger))
+	widgetRoutes.POST("/query", wgc.WidgetQuery)
+	widgetRoutes.GET("/suggested-questions", wgc.WidgetSuggestedQuestions)
 
 	// Job Management Routes (protected, admin only)
 	jobRoutes := v1.Group("/jobs")
 	jobRoutes.Use(middlewares.AuthMiddleware(authService))
 	jobRoutes.Use(middlewares.RequireRole("admin"))
-	jobRoutes.GET("/queues", jc.ListQueues)
-	jobRoutes.GET("/pending", jc.ListPendingJobs)
-	jobRoutes.GET("/active", jc.ListActiveJobs)
-	jobRoutes.GET("/scheduled", jc.ListScheduledJobs)
-	jobRoutes.GET("/retry", jc.ListRetryJobs)
-	jobRoutes.GET("/archived", jc.ListArchivedJobs)
-	jobRoutes.POST("/:id/cancel", jc.CancelJob)
-	jobRoutes.POST("/:id/retry", jc.RetryJob)
-	jobRoutes.POST("/queues/:queue/pause", jc.PauseQueue)
-	jobRoutes.POST("/queues/:queue/resume", jc.ResumeQueue)
+	jobRoutes.GET("/queues", jc.ListQueues, jobsRead)
+	jobRoutes.GET("/queues/:queue/history", jc.GetQueueHistory, jobsRead)
+	jobRoutes.GET("/pressure", jc.GetPressure, jobsRead)
+	jobRoutes.GET("/pending", jc.ListPendingJobs, jobsRead)
+	jobRoutes.GET("/active", jc.ListActiveJobs, jobsRead)
+	jobRoutes.GET("/scheduled", jc.ListScheduledJobs, jobsRead)
+	jobRoutes.GET("/retry", jc.ListRetryJobs, jobsRead)
+	jobRoutes.GET("/archived", jc.ListArchivedJobs, jobsRead)
+	jobRoutes.GET("/:id", jc.GetJob, jobsRead)
+	jobRoutes.POST("/:id/cancel", jc.CancelJob, jobsWrite, audit.Action("job.cancel", schema.AuditResourceJob, "id"))
+	jobRoutes.POST("/batch/cancel", jc.CancelJobs, jobsWrite, audit.Action("job.batch_cancel", schema.AuditResourceJob, ""))
+	jobRoutes.POST("/batch/retry", jc.RetryJobs, jobsWrite, audit.Action("job.batch_retry", schema.AuditResourceJob, ""))
+	jobRoutes.POST("/:id/retry", jc.RetryJob, jobsWrite, audit.Action("job.retry", schema.AuditResourceJob, "id"))
+	jobRoutes.POST("/queues/:queue/pause", jc.PauseQueue, jobsWrite, audit.Action("queue.pause", schema.AuditResourceQueue, "queue"))
+	jobRoutes.POST("/queues/:queue/resume", jc.ResumeQueue, jobsWrite, audit.Action("queue.resume", schema.AuditResourceQueue, "queue"))
+	jobRoutes.DELETE("/queues/:queue/archived", jc.PurgeArchivedJobs, jobsWrite, audit.Action("queue.archived.purge", schema.AuditResourceQueue, "queue"))
+	jobRoutes.DELETE("/queues/:queue/pending", jc.DeletePendingJobs, jobsWrite, audit.Action("queue.pending.delete", schema.AuditResourceQueue, "queue"))
+	jobRoutes.POST("/queues/:queue/retry/requeue", jc.RequeueRetryJobs, jobsWrite, audit.Action("queue.retry.requeue", schema.AuditResourceQueue, "queue"))
+
+	// Admin Routes (protected, admin only)
+	adminRoutes := v1.Group("/admin")
+	adminRoutes.Use(middlewares.AuthMiddleware(authService))
+	adminRoutes.Use(middlewares.RequireRole("admin"))
+	adminRoutes.GET("/stats", adc.GetStats)
+	adminRoutes.GET("/feedback/stats", wc.GetFeedbackStats)
+	adminRoutes.GET("/usage", wc.GetUsage)
+	adminRoutes.POST("/maintenance/gc", adc.TriggerGarbageCollection, audit.Action("admin.gc", schema.AuditResourceSystem, ""))
+	adminRoutes.POST("/maintenance/rotate-keys", adc.RotateEncryptionKeys, audit.Action("admin.rotate_keys", schema.AuditResourceSystem, ""))
+	adminRoutes.GET("/audit-logs", adc.ListAuditLogs)
+	adminRoutes.POST("/websites/assign-owner", adc.AssignWebsiteOwner, audit.Action("admin.assign_owner", schema.AuditResourceWebsite, ""))
+	adminRoutes.POST("/websites/:id/reprocess", adc.ReprocessWebsite, audit.Action("admin.reprocess_website", schema.AuditResourceWebsite, "id"))
 
 	// Web Routes (handles frontend pages with session auth)
-	web.SetupRoutes(e, authService, websiteRepo, apiKeyRepo, userRepo)
+	web.SetupRoutes(e, authService, apiKeyRepo, websites, queries, logger)
 
 	// Websocket Route (public for now, can add auth later)
 	e.GET("/websocket", app.WebsocketHandler)
@@ -6,6 +6,14 @@ import (
 	_ "hermit/internal/docs" // docs is generated by Swag CLI
 )
 
+// @title                       Hermit API
+// @version                     1.0
+// @description                 Crawls websites, indexes their pages and answers questions about them.
+// @BasePath                    /api/v1
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 API key sent as "Bearer hmt_..."
 func main() {
 	app.NewFxApp().Run()
 }
@@ -7,9 +7,9 @@ import (
 	"log"
 	"os"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"hermit/internal/database"
+
 	"github.com/joho/godotenv"
-	"github.com/pressly/goose/v3"
 )
 
 var (
@@ -23,6 +23,7 @@ func init() {
 	}
 }
 
+// Migrations are also available as "hermit migrate".
 func main() {
 	if err := flags.Parse(os.Args[1:]); err != nil {
 		log.Fatalf("Error parsing flags: %v", err)
@@ -34,31 +35,7 @@ func main() {
 		return
 	}
 
-	command := args[0]
-	dbString := os.Getenv("DATABASE_URL")
-
-	if dbString == "" {
-		fmt.Println("goose: missing DATABASE_URL environment variable")
-		return
-	}
-
-	db, err := goose.OpenDBWithDriver("postgres", dbString)
-	if err != nil {
-		fmt.Printf("goose: failed to open DB: %v\n", err)
-	}
-
-	defer func() {
-		if err := db.Close(); err != nil {
-			fmt.Printf("goose: failed to close DB: %v\n", err)
-		}
-	}()
-
-	arguments := []string{}
-	if len(args) > 1 {
-		arguments = append(arguments, args[1:]...)
-	}
-
-	if err := goose.RunContext(context.Background(), command, db, *dir, arguments...); err != nil {
-		fmt.Printf("goose %v: %v\n", command, err)
+	if err := database.Migrate(context.Background(), os.Getenv("DATABASE_URL"), *dir, args[0], args[1:]...); err != nil {
+		fmt.Printf("goose: %v\n", err)
 	}
 }
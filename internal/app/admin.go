@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+
+	"hermit/internal/auth"
+	"hermit/internal/config"
+	"hermit/internal/schema"
+	"hermit/internal/seed"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
+
+// CreateAdmin creates an admin account, or makes the existing user with the
+// email an admin, using the dependencies of Core. It reports whether the
+// account was created.
+func CreateAdmin(ctx context.Context, email, password string) (*schema.User, bool, error) {
+	var (
+		authService *auth.Service
+		db          *sqlx.DB
+	)
+	app := fx.New(Core, fx.NopLogger, fx.Populate(&authService, &db))
+	if err := app.Err(); err != nil {
+		return nil, false, err
+	}
+	defer db.Close()
+
+	return authService.CreateAdmin(ctx, email, password)
+}
+
+// Seed creates the demo data, using the dependencies of Core. The canned
+// embeddings have the configured embedding dimensions.
+func Seed(ctx context.Context, opts seed.Options) (*seed.Report, error) {
+	var (
+		seeder *seed.Seeder
+		cfg    *config.Config
+		db     *sqlx.DB
+	)
+	app := fx.New(Core, fx.NopLogger, fx.Provide(seed.NewSeeder), fx.Populate(&seeder, &cfg, &db))
+	if err := app.Err(); err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	if opts.Dimensions == 0 {
+		opts.Dimensions = cfg.OllamaEmbeddingDimensions
+	}
+	return seeder.Seed(ctx, opts)
+}
@@ -15,11 +15,19 @@ import (
 	"hermit/internal/contentprocessor"
 	"hermit/internal/crawler"
 	"hermit/internal/database"
+	"hermit/internal/discord"
 	"hermit/internal/jobs"
 	"hermit/internal/llm"
+	"hermit/internal/notifications"
+	"hermit/internal/ollama"
 	"hermit/internal/repositories"
+	"hermit/internal/resilience"
+	"hermit/internal/secrets"
+	"hermit/internal/service"
+	"hermit/internal/slack"
 	"hermit/internal/storage"
 	"hermit/internal/vectorizer"
+	"hermit/internal/visual"
 
 	"github.com/coder/websocket"
 	"github.com/jmoiron/sqlx"
@@ -70,97 +78,275 @@ func NewLogger() (*zap.Logger, error) {
 	return zap.NewDevelopment()
 }
 
-func NewFxApp() *fx.App {
-	return fx.New(
-		fx.Provide(
-			config.NewConfig,
-			NewLogger,
-
-			database.NewPostgresDB,
-			database.NewGarageClient,
-			database.NewChromaDBClient,
+// Core provides the dependencies shared by the API server, the worker and the
+// admin commands. fx only constructs the ones a command uses.
+var Core = fx.Options(
+	fx.Provide(
+		// Fail before connecting to anything when the config is invalid
+		func() (*config.Config, error) {
+			cfg := config.NewConfig()
+			if err := cfg.Validate(); err != nil {
+				return nil, err
+			}
+			return cfg, nil
+		},
+		NewLogger,
 
-			storage.NewGarageStorage,
+		database.NewPostgresDB,
+		database.NewChromaDBClient,
 
-			repositories.NewWebsiteRepository,
-			repositories.NewPageRepository,
-			repositories.NewUserRepository,
-			repositories.NewAPIKeyRepository,
+		resilience.NewRegistry,
 
-			auth.NewService,
+		storage.NewBackend,
+		storage.NewGarageStorage,
 
-			func(cfg *config.Config, logger *zap.Logger) *vectorizer.Embedder {
-				return vectorizer.NewEmbedder(cfg.OllamaURL, cfg.OllamaModel, logger)
-			},
-			func(cfg *config.Config, logger *zap.Logger) (*vectorizer.ChromaRepository, error) {
-				return vectorizer.NewChromaRepository(cfg.ChromaDBURL, logger)
-			},
-			vectorizer.NewService,
+		repositories.NewWebsiteRepository,
+		repositories.NewPageRepository,
+		repositories.NewUserRepository,
+		repositories.NewAPIKeyRepository,
+		repositories.NewQueryCacheRepository,
+		repositories.NewQueryLogRepository,
+		repositories.NewVisualSnapshotRepository,
+		repositories.NewCrawlEventRepository,
+		repositories.NewEvaluationRepository,
+		repositories.NewWebsitePermissionRepository,
+		repositories.NewLoginAuditRepository,
+		repositories.NewAuditLogRepository,
+		repositories.NewQueueStatsRepository,
+		repositories.NewSlackChannelRepository,
+		repositories.NewDiscordChannelRepository,
+		func(cfg *config.Config) (*secrets.Cipher, error) {
+			return secrets.NewCipher(cfg.CredentialsEncryptionKey, cfg.CredentialsEncryptionPreviousKeys...)
+		},
+		repositories.NewWebsiteCredentialsRepository,
+		repositories.NewWebsiteConnectorRepository,
 
-			func(cfg *config.Config, logger *zap.Logger) *llm.OllamaLLM {
-				return llm.NewOllamaLLM(cfg.OllamaURL, cfg.OllamaLLMModel, logger)
-			},
-			func(vectorizerSvc *vectorizer.Service, ollamaLLM *llm.OllamaLLM, logger *zap.Logger, cfg *config.Config) *llm.RAGService {
-				return llm.NewRAGService(vectorizerSvc, ollamaLLM, logger, cfg.RAGTopK, cfg.RAGContextChunks)
-			},
+		auth.NewLoginGuard,
+		auth.NewService,
 
-			func(logger *zap.Logger) *contentprocessor.ContentProcessor {
-				return contentprocessor.NewContentProcessor(logger)
-			},
-			func(cfg *config.Config, logger *zap.Logger) *contentprocessor.RobotsEnforcer {
-				return contentprocessor.NewRobotsEnforcer(cfg.CrawlerUserAgent, logger)
-			},
+		func(cfg *config.Config, breakers *resilience.Registry, logger *zap.Logger) (*ollama.Client, error) {
+			return ollama.NewClient(ollama.OptionsFromConfig(cfg), breakers, logger)
+		},
+		func(cfg *config.Config, client *ollama.Client, logger *zap.Logger) *vectorizer.Embedder {
+			return vectorizer.NewEmbedder(client, cfg.OllamaModel, logger)
+		},
+		func(cfg *config.Config, websiteRepo *repositories.WebsiteRepository, breakers *resilience.Registry, logger *zap.Logger) (*vectorizer.ChromaRepository, error) {
+			return vectorizer.NewChromaRepository(cfg.ChromaDBURL, websiteRepo, breakers, resilience.OptionsFromConfig(cfg), logger)
+		},
+		vectorizer.NewService,
 
-			crawler.NewCrawler,
+		func(cfg *config.Config, client *ollama.Client, logger *zap.Logger) *llm.OllamaLLM {
+			return llm.NewOllamaLLM(client, cfg.OllamaLLMModel, logger)
+		},
+		func(cfg *config.Config) *llm.AnswerPostProcessor {
+			return llm.NewAnswerPostProcessor(cfg.AnswerSanitize, cfg.AnswerRewriteLinks, cfg.AnswerStripPreamble, cfg.AnswerMaxLength)
+		},
+		func(cfg *config.Config, ollamaLLM *llm.OllamaLLM, logger *zap.Logger) *llm.Guardrails {
+			return llm.NewGuardrails(cfg.GuardrailsEnabled, cfg.GuardrailsMinSimilarity, cfg.GuardrailsModeration, ollamaLLM, logger)
+		},
 
-			func(cfg *config.Config, logger *zap.Logger) (*jobs.Client, error) {
-				return jobs.NewClient(cfg.RedisURL, logger)
-			},
+		func(cfg *config.Config, logger *zap.Logger) *contentprocessor.ContentProcessor {
+			return contentprocessor.NewContentProcessor(logger, contentprocessor.QualityWeightsFromConfig(cfg))
+		},
+		func(cfg *config.Config, ollamaLLM *llm.OllamaLLM, logger *zap.Logger) contentprocessor.PageClassifier {
+			if !cfg.ContentQualityClassifier {
+				return nil
+			}
+			return llm.NewPageClassifier(ollamaLLM, logger)
+		},
+		func(cfg *config.Config, logger *zap.Logger) *contentprocessor.RobotsEnforcer {
+			return contentprocessor.NewRobotsEnforcer(cfg.CrawlerUserAgent, logger)
+		},
 
-			controllers.NewWebsiteController,
-			controllers.NewHealthController,
-			func(logger *zap.Logger, cfg *config.Config) (*controllers.JobsController, error) {
-				return controllers.NewJobsController(logger, cfg.RedisURL)
-			},
-			controllers.NewAuthController,
+		notifications.NewNotifier,
+		visual.NewDetector,
+		func(
+			logger *zap.Logger,
+			storage *storage.GarageStorage,
+			pageRepo *repositories.PageRepository,
+			websiteRepo *repositories.WebsiteRepository,
+			vectorizerSvc *vectorizer.Service,
+			contentProcessor *contentprocessor.ContentProcessor,
+			robotsEnforcer *contentprocessor.RobotsEnforcer,
+			classifier contentprocessor.PageClassifier,
+			jobClient *jobs.Client,
+			visualDetector *visual.Detector,
+			crawlEventRepo *repositories.CrawlEventRepository,
+			credentialsRepo *repositories.WebsiteCredentialsRepository,
+			connectorRepo *repositories.WebsiteConnectorRepository,
+			cfg *config.Config,
+		) *crawler.Crawler {
+			return crawler.NewCrawler(logger, storage, pageRepo, websiteRepo, vectorizerSvc, contentProcessor, robotsEnforcer,
+				classifier, jobClient, visualDetector, crawlEventRepo, credentialsRepo, connectorRepo, cfg)
+		},
 
-			func() *echo.Echo {
-				return echo.New()
-			},
+		// The cache is invalidated and pruned even when disabled, so answers
+		// cached before are current once it is enabled again
+		func(repo *repositories.QueryCacheRepository, logger *zap.Logger, cfg *config.Config) *llm.AnswerCache {
+			ttl := time.Duration(cfg.RAGCacheTTLMinutes) * time.Minute
+			return llm.NewAnswerCache(repo, logger, cfg.RAGCacheSimilarity, ttl, cfg.RAGCacheMaxCandidates)
+		},
 
-			func(e *echo.Echo, db *sqlx.DB, log *zap.Logger) *App {
-				return &App{Echo: e, DB: db, Logger: log}
-			},
-		),
-		fx.WithLogger(func(log *zap.Logger) fxevent.Logger {
-			return &fxevent.ZapLogger{Logger: log}
-		}),
-		fx.Invoke(func(e *echo.Echo, logger *zap.Logger, cfg *config.Config) {
-			middlewares.SetupMiddlewares(e, logger, cfg)
-		}),
-		fx.Invoke(RegisterHooks),
-		fx.Invoke(func(
-			e *echo.Echo,
-			app *App,
-			wc *controllers.WebsiteController,
-			hc *controllers.HealthController,
-			jc *controllers.JobsController,
-			ac *controllers.AuthController,
-			authService *auth.Service,
-			websiteRepo *repositories.WebsiteRepository,
-			apiKeyRepo *repositories.APIKeyRepository,
-			userRepo *repositories.UserRepository,
-		) {
-			routes.SetupRoutes(e, app, wc, hc, jc, ac, authService, websiteRepo, apiKeyRepo, userRepo)
-		}),
-		fx.Invoke(func(lc fx.Lifecycle, jobClient *jobs.Client) {
+		func(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (*jobs.Client, error) {
+			jobClient, err := jobs.NewClient(jobs.ClientConfigFromConfig(cfg), logger)
+			if err != nil {
+				return nil, err
+			}
 			lc.Append(fx.Hook{
 				OnStop: func(ctx context.Context) error {
 					return jobClient.Close()
 				},
 			})
-		}),
-	)
+			return jobClient, nil
+		},
+	),
+)
+
+// API provides the HTTP server with its services and controllers.
+var API = fx.Options(
+	fx.Provide(
+		func(vectorizerSvc *vectorizer.Service, ollamaLLM *llm.OllamaLLM, cache *llm.AnswerCache, postProcessor *llm.AnswerPostProcessor, guardrails *llm.Guardrails, websiteRepo *repositories.WebsiteRepository, pageRepo *repositories.PageRepository, logger *zap.Logger, cfg *config.Config) *llm.RAGService {
+			if !cfg.RAGCacheEnabled {
+				cache = nil
+			}
+			return llm.NewRAGService(vectorizerSvc, ollamaLLM, logger, cfg.RAGTopK, cfg.RAGContextChunks, cache, postProcessor, guardrails, websiteRepo, pageRepo)
+		},
+		llm.NewQuestionSuggester,
+
+		func(websiteRepo *repositories.WebsiteRepository, pageRepo *repositories.PageRepository, userRepo *repositories.UserRepository, permRepo *repositories.WebsitePermissionRepository, jobClient *jobs.Client, storage *storage.GarageStorage, answerCache *llm.AnswerCache, logger *zap.Logger) *service.WebsiteService {
+			return service.NewWebsiteService(websiteRepo, pageRepo, userRepo, permRepo, jobClient, storage, answerCache, logger)
+		},
+		func(ragService *llm.RAGService, suggester *llm.QuestionSuggester, queryLogRepo *repositories.QueryLogRepository, pageRepo *repositories.PageRepository, logger *zap.Logger) *service.QueryService {
+			return service.NewQueryService(ragService, suggester, queryLogRepo, pageRepo, logger)
+		},
+		func(pageRepo *repositories.PageRepository, storage *storage.GarageStorage, logger *zap.Logger) *service.SearchService {
+			return service.NewSearchService(pageRepo, storage, logger)
+		},
+		slack.NewBot,
+		discord.NewBot,
+
+		middlewares.NewAuditor,
+
+		// Stores of the website controller
+		func(r *repositories.PageRepository) controllers.PageStore { return r },
+		func(r *repositories.UserRepository) controllers.UserStore { return r },
+		func(r *repositories.QueryLogRepository) controllers.QueryLogStore { return r },
+		func(r *repositories.VisualSnapshotRepository) controllers.SnapshotStore { return r },
+		func(r *repositories.CrawlEventRepository) controllers.CrawlEventStore { return r },
+		func(r *repositories.WebsiteCredentialsRepository) controllers.CredentialsStore { return r },
+		func(r *repositories.WebsiteConnectorRepository) controllers.ConnectorStore { return r },
+		func(r *repositories.SlackChannelRepository) controllers.SlackChannelStore { return r },
+		func(r *repositories.DiscordChannelRepository) controllers.DiscordChannelStore { return r },
+		func(r *repositories.EvaluationRepository) controllers.EvaluationStore { return r },
+		func(r *repositories.WebsitePermissionRepository) controllers.PermissionStore { return r },
+		func(r *repositories.APIKeyRepository) controllers.APIKeyStore { return r },
+		func(c *jobs.Client) controllers.JobQueue { return c },
+		func(s *vectorizer.Service) controllers.VectorCounter { return s },
+		controllers.NewWebsiteController,
+		controllers.NewHealthController,
+		func(logger *zap.Logger, cfg *config.Config, queueStats *repositories.QueueStatsRepository, jobClient *jobs.Client) (*controllers.JobsController, error) {
+			policy := jobs.ScalePolicy{Min: cfg.WorkerConcurrency, Max: cfg.WorkerConcurrency}
+			if cfg.WorkerAutoscale {
+				policy = jobs.ScalePolicy{Min: cfg.WorkerMinConcurrency, Max: cfg.WorkerMaxConcurrency}
+			}
+			return controllers.NewJobsController(logger, cfg.RedisURL, policy, queueStats, jobClient)
+		},
+		controllers.NewAuthController,
+		controllers.NewAdminController,
+		controllers.NewSlackController,
+		controllers.NewDiscordController,
+		controllers.NewWidgetController,
+
+		func() *echo.Echo {
+			return echo.New()
+		},
+
+		func(e *echo.Echo, db *sqlx.DB, log *zap.Logger) *App {
+			return &App{Echo: e, DB: db, Logger: log}
+		},
+	),
+	fx.Invoke(func(e *echo.Echo, logger *zap.Logger, cfg *config.Config) {
+		middlewares.SetupMiddlewares(e, logger, cfg)
+	}),
+	fx.Invoke(RegisterBootstrapAdmin),
+	fx.Invoke(RegisterHooks),
+	fx.Invoke(func(
+		e *echo.Echo,
+		app *App,
+		wc *controllers.WebsiteController,
+		hc *controllers.HealthController,
+		jc *controllers.JobsController,
+		ac *controllers.AuthController,
+		adc *controllers.AdminController,
+		sc *controllers.SlackController,
+		dc *controllers.DiscordController,
+		wgc *controllers.WidgetController,
+		audit *middlewares.Auditor,
+		cfg *config.Config,
+		authService *auth.Service,
+		apiKeyRepo *repositories.APIKeyRepository,
+		websites *service.WebsiteService,
+		queries *service.QueryService,
+		logger *zap.Logger,
+	) {
+		routes.SetupRoutes(e, app, wc, hc, jc, ac, adc, sc, dc, wgc, audit, cfg, authService, apiKeyRepo, websites, queries, logger)
+	}),
+	fx.Invoke(func(lc fx.Lifecycle, bot *discord.Bot, logger *zap.Logger) {
+		if bot == nil || !bot.CanRegisterCommands() {
+			return
+		}
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+					defer cancel()
+					if err := bot.RegisterCommands(ctx); err != nil {
+						logger.Warn("Failed to register Discord commands", zap.Error(err))
+					}
+				}()
+				return nil
+			},
+		})
+	}),
+)
+
+// zapEvents logs the events of fx with the application logger.
+var zapEvents = fx.WithLogger(func(log *zap.Logger) fxevent.Logger {
+	return &fxevent.ZapLogger{Logger: log}
+})
+
+// logConfig logs the effective configuration with its secrets redacted when
+// the API server or the worker starts.
+var logConfig = fx.Invoke(func(cfg *config.Config, logger *zap.Logger) {
+	logger.Info("Effective configuration", zap.Any("config", cfg.Summary()))
+})
+
+// NewFxApp creates the API server.
+func NewFxApp() *fx.App {
+	return fx.New(Core, API, logConfig, zapEvents)
+}
+
+// RegisterBootstrapAdmin creates the admin set by ADMIN_EMAIL and
+// ADMIN_PASSWORD on start, before the server accepts requests, while there
+// is no admin.
+func RegisterBootstrapAdmin(lc fx.Lifecycle, cfg *config.Config, authService *auth.Service, logger *zap.Logger) {
+	if cfg.AdminEmail == "" {
+		return
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			user, err := authService.BootstrapAdmin(ctx, cfg.AdminEmail, cfg.AdminPassword)
+			if err != nil {
+				return fmt.Errorf("failed to bootstrap admin %s: %w", cfg.AdminEmail, err)
+			}
+			if user == nil {
+				logger.Debug("Admin exists, ignoring ADMIN_EMAIL")
+				return nil
+			}
+			logger.Info("Bootstrapped admin", zap.String("email", user.Email), zap.String("userID", user.ID.String()))
+			return nil
+		},
+	})
 }
 
 func RegisterHooks(lc fx.Lifecycle, app *App, cfg *config.Config) {
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hermit/internal/config"
+
+	"github.com/alicebob/miniredis/v2"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// embeddedRedisTick is how often the clock of the embedded Redis advances,
+// expiring keys such as unique task locks.
+const embeddedRedisTick = time.Second
+
+// EmbeddedRedis runs an in-memory Redis in the process and points the config
+// at it, when always is true or the config enables it. Jobs are lost when the
+// process stops, so it only suits a single process running the API and the
+// worker.
+func EmbeddedRedis(always bool) fx.Option {
+	return fx.Decorate(func(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (*config.Config, error) {
+		if !always && !cfg.RedisEmbedded {
+			return cfg, nil
+		}
+
+		server := miniredis.NewMiniRedis()
+		if err := server.Start(); err != nil {
+			return nil, fmt.Errorf("failed to start embedded redis: %w", err)
+		}
+
+		// miniredis only expires keys when its clock is moved forward
+		ctx, stop := context.WithCancel(context.Background())
+		go func() {
+			ticker := time.NewTicker(embeddedRedisTick)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					server.FastForward(embeddedRedisTick)
+				}
+			}
+		}()
+
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				stop()
+				server.Close()
+				return nil
+			},
+		})
+
+		logger.Warn("Using embedded in-memory Redis, queued jobs are lost when the process stops",
+			zap.String("addr", server.Addr()),
+		)
+
+		embedded := *cfg
+		embedded.RedisURL = "redis://" + server.Addr()
+		embedded.RedisPassword = ""
+		embedded.RedisDB = 0
+		return &embedded, nil
+	})
+}
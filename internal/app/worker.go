@@ -0,0 +1,210 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hermit/internal/config"
+	"hermit/internal/crawler"
+	"hermit/internal/database"
+	"hermit/internal/jobs"
+	"hermit/internal/llm"
+	"hermit/internal/maintenance"
+	"hermit/internal/repositories"
+	"hermit/internal/resilience"
+	"hermit/internal/vectorizer"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Worker provides the job server processing background jobs (crawling,
+// vectorization, maintenance) with its handlers.
+var Worker = fx.Options(
+	fx.Provide(
+		llm.NewSummarizer,
+		// Evaluation runs answer without the answer cache so every run
+		// measures the current retrieval settings
+		func(vectorizerSvc *vectorizer.Service, ollamaLLM *llm.OllamaLLM, postProcessor *llm.AnswerPostProcessor, guardrails *llm.Guardrails, websiteRepo *repositories.WebsiteRepository, pageRepo *repositories.PageRepository, evaluationRepo *repositories.EvaluationRepository, logger *zap.Logger, cfg *config.Config) *llm.Evaluator {
+			ragService := llm.NewRAGService(vectorizerSvc, ollamaLLM, logger, cfg.RAGTopK, cfg.RAGContextChunks, nil, postProcessor, guardrails, websiteRepo, pageRepo)
+			return llm.NewEvaluator(ragService, ollamaLLM, evaluationRepo, logger)
+		},
+
+		maintenance.NewGarbageCollector,
+		func(websiteRepo *repositories.WebsiteRepository, crawlEventRepo *repositories.CrawlEventRepository, jobClient *jobs.Client, cfg *config.Config, logger *zap.Logger) *maintenance.CrawlWatchdog {
+			return maintenance.NewCrawlWatchdog(websiteRepo, crawlEventRepo, jobClient,
+				time.Duration(cfg.CrawlStallTimeout)*time.Minute, cfg.CrawlStallRequeue, logger)
+		},
+
+		jobs.NewHandlers,
+		func(cfg *config.Config, handlers *jobs.Handlers, logger *zap.Logger) (*jobs.Server, error) {
+			return jobs.NewServer(WorkerServerConfig(cfg), handlers, logger)
+		},
+	),
+	fx.Invoke(RegisterWorkerHooks),
+)
+
+// workerStartTimeout bounds the start of the worker, which waits for the
+// models to be warmed up.
+const workerStartTimeout = 10 * time.Minute
+
+// workerStopTimeout bounds the stop of the worker, which waits for running
+// jobs up to WORKER_SHUTDOWN_TIMEOUT.
+const workerStopTimeout = config.MaxWorkerShutdownTimeout*time.Second + time.Minute
+
+// NewWorkerApp creates the worker.
+func NewWorkerApp() *fx.App {
+	return fx.New(Core, Worker, logConfig, zapEvents, fx.StartTimeout(workerStartTimeout), fx.StopTimeout(workerStopTimeout))
+}
+
+// NewAllInOneApp creates the API server and the worker in a single process,
+// sharing their dependencies. With embeddedRedis, or REDIS_EMBEDDED, jobs are
+// queued in an in-memory Redis so no Redis needs to run.
+func NewAllInOneApp(embeddedRedis bool) *fx.App {
+	return fx.New(Core, API, Worker, EmbeddedRedis(embeddedRedis), logConfig, zapEvents, fx.StartTimeout(workerStartTimeout), fx.StopTimeout(workerStopTimeout))
+}
+
+// WorkerServerConfig returns the job server configuration set in the config.
+func WorkerServerConfig(cfg *config.Config) jobs.ServerConfig {
+	serverCfg := jobs.ServerConfig{
+		RedisURL:    cfg.RedisURL,
+		Concurrency: cfg.WorkerConcurrency,
+		Queues: map[string]int{
+			"critical":    6,
+			"crawl":       4,
+			"vectorize":   3,
+			"default":     2,
+			"maintenance": 1,
+		},
+		VectorizeBatch:  jobs.VectorizeBatchFromConfig(cfg),
+		ShutdownTimeout: time.Duration(cfg.WorkerShutdownTimeout) * time.Second,
+	}
+	if cfg.WorkerAutoscale {
+		serverCfg.Autoscale = &jobs.ScalePolicy{Min: cfg.WorkerMinConcurrency, Max: cfg.WorkerMaxConcurrency}
+		serverCfg.AutoscaleInterval = time.Duration(cfg.WorkerAutoscaleInterval) * time.Second
+	}
+	return serverCfg
+}
+
+// RegisterWorkerHooks starts the job server with the background loops of the
+// worker and stops them on shutdown.
+func RegisterWorkerHooks(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	jobServer *jobs.Server,
+	jobClient *jobs.Client,
+	crawlerSvc *crawler.Crawler,
+	vectorizerSvc *vectorizer.Service,
+	embedder *vectorizer.Embedder,
+	ollamaLLM *llm.OllamaLLM,
+	db *sqlx.DB,
+	chromaDB *database.ChromaDBClient,
+	breakers *resilience.Registry,
+	logger *zap.Logger,
+) {
+	// Background loops run until the worker stops
+	background, stopBackground := context.WithCancel(context.Background())
+	var health *healthServer
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("Starting Hermit worker...")
+
+			// Move collections created before per-owner namespacing into their owner's namespace
+			go func() {
+				migrated, err := vectorizerSvc.MigrateCollections(background)
+				if err != nil {
+					logger.Warn("Failed to migrate ChromaDB collections", zap.Error(err))
+					return
+				}
+				if migrated > 0 {
+					logger.Info("Migrated ChromaDB collections", zap.Int("collections", migrated))
+				}
+			}()
+
+			// Load models into memory so the first crawl or query doesn't pay the cold start
+			if cfg.OllamaWarmupEnabled {
+				if err := warmUpModels(cfg, embedder, ollamaLLM, logger); err != nil {
+					if errors.Is(err, errDimensionMismatch) {
+						return fmt.Errorf("embedding model does not match configured dimensions: %w", err)
+					}
+					logger.Warn("Model warm-up failed, continuing without preloaded models", zap.Error(err))
+				}
+			}
+
+			// Keep the global crawler proxies' health up to date
+			go crawlerSvc.RunProxyHealthChecks(background)
+
+			jobServer.RegisterHandlers()
+			if err := jobServer.Start(); err != nil {
+				return err
+			}
+
+			// Look for crawls left running by crashed workers, prune old tasks
+			// and expired answers and record the queue history
+			go jobClient.RunCrawlWatchdog(background, time.Duration(cfg.CrawlWatchdogInterval)*time.Second)
+			go jobClient.RunTaskPruner(background, time.Duration(cfg.JobPruneInterval)*time.Second)
+			go jobClient.RunAnswerCachePruner(background, time.Duration(cfg.RAGCachePruneInterval)*time.Second)
+			go jobClient.RunQueueSampler(background, time.Duration(cfg.QueueStatsInterval)*time.Second,
+				time.Duration(cfg.QueueStatsRetention)*time.Hour)
+
+			// Expose liveness and readiness probes
+			if cfg.WorkerHealthPort != "" {
+				health = newHealthServer(":"+cfg.WorkerHealthPort, jobServer, db, chromaDB, breakers, cfg, logger)
+				health.Start()
+			}
+
+			logger.Info("Worker started successfully, processing jobs...")
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("Stopping worker...")
+			if health != nil {
+				health.Stop(ctx)
+			}
+			stopBackground()
+			jobServer.Stop()
+			logger.Info("Worker stopped successfully")
+			return nil
+		},
+	})
+}
+
+// errDimensionMismatch is returned when the embedding model produces vectors of
+// a different size than configured.
+var errDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// warmUpModels issues a small embed and generate request to Ollama so both
+// models are loaded before the first job arrives.
+func warmUpModels(cfg *config.Config, embedder *vectorizer.Embedder, ollamaLLM *llm.OllamaLLM, logger *zap.Logger) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.OllamaTimeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	dims, err := embedder.Warmup(ctx, cfg.OllamaEmbeddingDimensions)
+	if err != nil {
+		if dims > 0 {
+			return errors.Join(errDimensionMismatch, err)
+		}
+		return err
+	}
+	logger.Info("Embedding model warmed up",
+		zap.String("model", cfg.OllamaModel),
+		zap.Int("dimensions", dims),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	start = time.Now()
+	if err := ollamaLLM.Warmup(ctx); err != nil {
+		return err
+	}
+	logger.Info("LLM warmed up",
+		zap.String("model", cfg.OllamaLLMModel),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	return nil
+}
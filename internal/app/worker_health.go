@@ -0,0 +1,189 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"hermit/internal/config"
+	"hermit/internal/database"
+	"hermit/internal/jobs"
+	"hermit/internal/resilience"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// readinessTimeout bounds all dependency checks of a readiness probe.
+const readinessTimeout = 5 * time.Second
+
+// healthServer exposes liveness and readiness probes for the worker.
+type healthServer struct {
+	server    *http.Server
+	jobServer *jobs.Server
+	db        *sqlx.DB
+	chromaDB  *database.ChromaDBClient
+	breakers  *resilience.Registry
+	cfg       *config.Config
+	logger    *zap.Logger
+}
+
+// dependencyCheck is the result of checking a single dependency.
+type dependencyCheck struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Latency string `json:"latency"`
+}
+
+// newHealthServer creates a healthServer listening on the given address.
+func newHealthServer(addr string, jobServer *jobs.Server, db *sqlx.DB, chromaDB *database.ChromaDBClient, breakers *resilience.Registry, cfg *config.Config, logger *zap.Logger) *healthServer {
+	h := &healthServer{
+		jobServer: jobServer,
+		db:        db,
+		chromaDB:  chromaDB,
+		breakers:  breakers,
+		cfg:       cfg,
+		logger:    logger,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", h.handleHealthz)
+	mux.HandleFunc("GET /readyz", h.handleReadyz)
+	mux.HandleFunc("GET /metrics", h.handleMetrics)
+
+	h.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	return h
+}
+
+// Start serves the probes in the background.
+func (h *healthServer) Start() {
+	go func() {
+		h.logger.Info("Worker health server listening", zap.String("addr", h.server.Addr))
+		if err := h.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			h.logger.Error("Worker health server failed", zap.Error(err))
+		}
+	}()
+}
+
+// Stop shuts the health server down.
+func (h *healthServer) Stop(ctx context.Context) {
+	if err := h.server.Shutdown(ctx); err != nil {
+		h.logger.Warn("Failed to stop worker health server", zap.Error(err))
+	}
+}
+
+// handleHealthz reports that the worker process is alive along with task
+// stats and the state of the dependency circuit breakers.
+func (h *healthServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":   "ok",
+		"stats":    h.jobServer.Stats(),
+		"breakers": h.breakers.Status(),
+	})
+}
+
+// handleReadyz checks the dependencies needed to process jobs.
+func (h *healthServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := map[string]dependencyCheck{
+		"redis":    runCheck(func() error { return h.jobServer.Ping() }),
+		"postgres": runCheck(func() error { return h.db.PingContext(ctx) }),
+		"chromadb": runCheck(func() error { return h.chromaDB.Heartbeat(ctx) }),
+		"ollama":   runCheck(func() error { return checkOllama(ctx, h.cfg.OllamaURL) }),
+	}
+
+	status, code := "ready", http.StatusOK
+	for name, check := range checks {
+		if check.Status != "healthy" {
+			status, code = "not_ready", http.StatusServiceUnavailable
+			h.logger.Warn("Worker readiness check failed", zap.String("dependency", name), zap.String("error", check.Message))
+		}
+	}
+
+	writeJSON(w, code, map[string]any{
+		"status":   status,
+		"services": checks,
+		"stats":    h.jobServer.Stats(),
+		"breakers": h.breakers.Status(),
+	})
+}
+
+// handleMetrics exposes the queue pressure and the worker's concurrency as
+// Prometheus gauges.
+func (h *healthServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	pressure, err := h.jobServer.Pressure()
+	if err != nil {
+		h.logger.Warn("Failed to collect queue pressure", zap.Error(err))
+		http.Error(w, "failed to collect queue pressure", http.StatusServiceUnavailable)
+		return
+	}
+
+	stats := h.jobServer.Stats()
+	active := 0
+	for _, n := range stats.Active {
+		active += n
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	err = pressure.WritePrometheus(w)
+	if err == nil {
+		err = jobs.WriteGauge(w, "hermit_worker_concurrency_limit", "Tasks this worker currently runs at most.", float64(stats.ConcurrencyLimit))
+	}
+	if err == nil {
+		err = jobs.WriteGauge(w, "hermit_worker_active_tasks", "Tasks this worker is running.", float64(active))
+	}
+	if err != nil {
+		h.logger.Warn("Failed to write metrics", zap.Error(err))
+	}
+}
+
+// runCheck times a dependency check.
+func runCheck(check func() error) dependencyCheck {
+	start := time.Now()
+	err := check()
+	result := dependencyCheck{
+		Status:  "healthy",
+		Latency: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = "unhealthy"
+		result.Message = err.Error()
+	}
+	return result
+}
+
+// checkOllama verifies that Ollama responds.
+func checkOllama(ctx context.Context, ollamaURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ollamaURL+"/api/tags", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
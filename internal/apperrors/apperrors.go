@@ -0,0 +1,188 @@
+// Package apperrors defines typed application errors. Repositories and
+// services return them so the API can answer every failure with a consistent
+// status code and JSON body without each handler mapping errors itself.
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code classifies an error. Codes are part of the API and returned to clients.
+type Code string
+
+const (
+	CodeValidation          Code = "validation"
+	CodeUnauthorized        Code = "unauthorized"
+	CodeForbidden           Code = "forbidden"
+	CodeNotFound            Code = "not_found"
+	CodeConflict            Code = "conflict"
+	CodeQuotaExceeded       Code = "quota_exceeded"
+	CodeUpstreamUnavailable Code = "upstream_unavailable"
+	CodeTimeout             Code = "timeout"
+	CodeNotImplemented      Code = "not_implemented"
+	CodeInternal            Code = "internal"
+)
+
+// Status returns the HTTP status code for an error code.
+func (c Code) Status() int {
+	switch c {
+	case CodeValidation:
+		return http.StatusBadRequest
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeConflict:
+		return http.StatusConflict
+	case CodeQuotaExceeded:
+		return http.StatusTooManyRequests
+	case CodeUpstreamUnavailable:
+		return http.StatusServiceUnavailable
+	case CodeTimeout:
+		return http.StatusGatewayTimeout
+	case CodeNotImplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CodeForStatus returns the error code matching an HTTP status code.
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusRequestEntityTooLarge,
+		http.StatusUnsupportedMediaType, http.StatusMethodNotAllowed:
+		return CodeValidation
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeQuotaExceeded
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return CodeUpstreamUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	default:
+		return CodeInternal
+	}
+}
+
+// Error is an error with a code and a message that is safe to return to
+// clients. The underlying cause is kept for logs only.
+type Error struct {
+	Code    Code
+	Message string
+	Details map[string]interface{}
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithDetails returns a copy of e carrying extra fields for the response body.
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	clone := *e
+	clone.Details = details
+	return &clone
+}
+
+// New creates an error with a code and a client-facing message.
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap creates an error with a code and a client-facing message around a cause.
+func Wrap(code Code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// Validation reports invalid input.
+func Validation(message string) *Error {
+	return New(CodeValidation, message)
+}
+
+// Unauthorized reports a missing or invalid authentication.
+func Unauthorized(message string) *Error {
+	return New(CodeUnauthorized, message)
+}
+
+// Forbidden reports an authenticated caller lacking access.
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+// NotFound reports a missing resource.
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+// Conflict reports a request conflicting with the current state.
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+// QuotaExceeded reports a rate limit or quota being exhausted.
+func QuotaExceeded(message string) *Error {
+	return New(CodeQuotaExceeded, message)
+}
+
+// UpstreamUnavailable reports a failing dependency such as Ollama or ChromaDB.
+func UpstreamUnavailable(message string, err error) *Error {
+	return Wrap(CodeUpstreamUnavailable, message, err)
+}
+
+// Internal reports an unexpected failure.
+func Internal(message string, err error) *Error {
+	return Wrap(CodeInternal, message, err)
+}
+
+// As returns the first *Error in err's chain.
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// CodeOf returns the code of err, or CodeInternal when err is untyped.
+func CodeOf(err error) Code {
+	if appErr, ok := As(err); ok {
+		return appErr.Code
+	}
+	return CodeInternal
+}
+
+// Is reports whether err carries the given code.
+func Is(err error, code Code) bool {
+	appErr, ok := As(err)
+	return ok && appErr.Code == code
+}
+
+// Response is the JSON body of every API error.
+type Response struct {
+	Error     string                 `json:"error" example:"Website not found"`
+	Code      Code                   `json:"code" example:"not_found"`
+	RequestID string                 `json:"request_id,omitempty" example:"3f1c2b7e9a4d4f0e8b6a5c7d9e1f2a3b"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
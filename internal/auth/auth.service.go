@@ -9,36 +9,54 @@ import (
 	"strings"
 	"time"
 
+	"hermit/internal/apperrors"
 	"hermit/internal/repositories"
 	"hermit/internal/schema"
 
 	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// SessionTTL is how long a web session lasts, the session cookie expires
+// at the same time
+const SessionTTL = 7 * 24 * time.Hour
+
 // Service handles authentication operations
 type Service struct {
 	userRepo   *repositories.UserRepository
 	apiKeyRepo *repositories.APIKeyRepository
+	auditRepo  *repositories.LoginAuditRepository
+	guard      *LoginGuard
+	logger     *zap.Logger
 }
 
 // NewService creates a new auth service
-func NewService(userRepo *repositories.UserRepository, apiKeyRepo *repositories.APIKeyRepository) *Service {
+func NewService(
+	userRepo *repositories.UserRepository,
+	apiKeyRepo *repositories.APIKeyRepository,
+	auditRepo *repositories.LoginAuditRepository,
+	guard *LoginGuard,
+	logger *zap.Logger,
+) *Service {
 	return &Service{
 		userRepo:   userRepo,
 		apiKeyRepo: apiKeyRepo,
+		auditRepo:  auditRepo,
+		guard:      guard,
+		logger:     logger,
 	}
 }
 
 // Register creates a new user account
-func (s *Service) Register(email, password string) (*schema.User, error) {
+func (s *Service) Register(ctx context.Context, email, password string) (*schema.User, error) {
 	// Check if email already exists
-	exists, err := s.userRepo.EmailExists(context.TODO(), email)
+	exists, err := s.userRepo.EmailExists(ctx, email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check email: %w", err)
 	}
 	if exists {
-		return nil, fmt.Errorf("email already registered")
+		return nil, apperrors.Conflict("email already registered")
 	}
 
 	// Hash password
@@ -56,7 +74,7 @@ func (s *Service) Register(email, password string) (*schema.User, error) {
 		WebsiteLimit: 10,
 	}
 
-	err = s.userRepo.Create(context.TODO(), user)
+	err = s.userRepo.Create(ctx, user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -64,35 +82,167 @@ func (s *Service) Register(email, password string) (*schema.User, error) {
 	return user, nil
 }
 
-// Login authenticates a user and returns the user object
-func (s *Service) Login(email, password string) (*schema.User, error) {
+// CreateAdmin creates an admin account, or makes the existing user with the
+// email an admin and sets their password. It reports whether the account was
+// created.
+func (s *Service) CreateAdmin(ctx context.Context, email, password string) (*schema.User, bool, error) {
+	if email == "" {
+		return nil, false, apperrors.Validation("email is required")
+	}
+	if len(password) < 8 {
+		return nil, false, apperrors.Validation("password must be at least 8 characters")
+	}
+
+	hashedPassword, err := s.HashPassword(password)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	exists, err := s.userRepo.EmailExists(ctx, email)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check email: %w", err)
+	}
+	if exists {
+		user, err := s.userRepo.GetByEmail(ctx, email)
+		if err != nil {
+			return nil, false, err
+		}
+		user.PasswordHash = hashedPassword
+		user.Role = schema.RoleAdmin
+		user.IsActive = true
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, false, fmt.Errorf("failed to update user: %w", err)
+		}
+		return user, false, nil
+	}
+
+	user := &schema.User{
+		Email:        email,
+		PasswordHash: hashedPassword,
+		Role:         schema.RoleAdmin,
+		IsActive:     true,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, false, fmt.Errorf("failed to create user: %w", err)
+	}
+	return user, true, nil
+}
+
+// BootstrapAdmin creates the first admin, or makes the existing user with the
+// email an admin, while there is no admin. Once an admin exists it does
+// nothing and returns nil, so the bootstrap credentials can't be used to
+// take over an account later.
+func (s *Service) BootstrapAdmin(ctx context.Context, email, password string) (*schema.User, error) {
+	exists, err := s.userRepo.AdminExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, nil
+	}
+
+	user, _, err := s.CreateAdmin(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Login authenticates a user and returns the user object. Attempts are
+// recorded in the login audit with the client's IP and user agent, and
+// refused while the email or IP is locked out after repeated failures.
+func (s *Service) Login(ctx context.Context, email, password, ip, userAgent string) (*schema.User, error) {
+	attempt := &schema.LoginAttempt{Email: email, IP: ip, UserAgent: userAgent}
+
 	// Get user by email
-	user, err := s.userRepo.GetByEmail(context.TODO(), email)
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		attempt.UserID = &user.ID
+	}
+
+	if locked := s.guard.Check(ctx, email, ip); locked > 0 {
+		attempt.Reason = schema.LoginReasonLockedOut
+		s.recordLogin(ctx, attempt)
+		retryAfter := int(locked.Round(time.Second).Seconds())
+		return nil, apperrors.QuotaExceeded("too many failed login attempts, try again later").
+			WithDetails(map[string]interface{}{"retry_after_seconds": retryAfter})
+	}
+
 	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		s.failLogin(ctx, attempt, schema.LoginReasonInvalidCredentials)
+		return nil, apperrors.Unauthorized("invalid credentials")
 	}
 
 	// Check if user is active
 	if !user.IsActive {
-		return nil, fmt.Errorf("account is inactive")
+		s.failLogin(ctx, attempt, schema.LoginReasonInactive)
+		return nil, apperrors.Forbidden("account is inactive")
 	}
 
 	// Verify password
 	if !s.VerifyPassword(password, user.PasswordHash) {
-		return nil, fmt.Errorf("invalid credentials")
+		s.failLogin(ctx, attempt, schema.LoginReasonInvalidCredentials)
+		return nil, apperrors.Unauthorized("invalid credentials")
 	}
 
+	s.guard.Succeed(ctx, email)
+	attempt.Success = true
+	s.recordLogin(ctx, attempt)
+
 	return user, nil
 }
 
+// failLogin counts a failed login towards the lockout and records it.
+func (s *Service) failLogin(ctx context.Context, attempt *schema.LoginAttempt, reason string) {
+	s.guard.Fail(ctx, attempt.Email, attempt.IP)
+	attempt.Reason = reason
+	s.recordLogin(ctx, attempt)
+}
+
+// recordLogin stores a login attempt in the audit. Failing to do so doesn't
+// fail the login.
+func (s *Service) recordLogin(ctx context.Context, attempt *schema.LoginAttempt) {
+	if err := s.auditRepo.Create(ctx, attempt); err != nil {
+		s.logger.Warn("Failed to record login attempt", zap.Bool("success", attempt.Success), zap.Error(err))
+	}
+}
+
+// GetLoginActivity returns the most recent login attempts on a user's account
+func (s *Service) GetLoginActivity(ctx context.Context, userID ulid.ULID, limit int) ([]schema.LoginAttempt, error) {
+	return s.auditRepo.ListByUserID(ctx, userID, limit)
+}
+
 // CreateAPIKey generates a new API key for a user
-func (s *Service) CreateAPIKey(userID ulid.ULID, name string, scopes []string, expiresAt *time.Time) (*schema.APIKey, string, error) {
+func (s *Service) CreateAPIKey(ctx context.Context, userID ulid.ULID, name string, scopes []string, expiresAt *time.Time) (*schema.APIKey, string, error) {
 	// Generate random API key
 	plainKey, err := s.GenerateAPIKey()
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
 	}
 
+	apiKey, err := s.storeAPIKey(ctx, userID, name, plainKey, scopes, expiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return apiKey, plainKey, nil
+}
+
+// ImportAPIKey creates an API key for a user with a key chosen by the
+// caller instead of a random one, e.g. the fixed key of seeded demo data.
+func (s *Service) ImportAPIKey(ctx context.Context, userID ulid.ULID, name, plainKey string, scopes []string) (*schema.APIKey, error) {
+	if !strings.HasPrefix(plainKey, "hmt_") || strings.HasPrefix(plainKey, schema.PublicKeyPrefix) {
+		return nil, apperrors.Validation("API key must start with hmt_")
+	}
+	if len(plainKey) < 24 {
+		return nil, apperrors.Validation("API key must be at least 24 characters")
+	}
+
+	return s.storeAPIKey(ctx, userID, name, plainKey, scopes, nil)
+}
+
+// storeAPIKey saves the hash of an API key for a user.
+func (s *Service) storeAPIKey(ctx context.Context, userID ulid.ULID, name, plainKey string, scopes []string, expiresAt *time.Time) (*schema.APIKey, error) {
 	// Hash the API key
 	keyHash := s.HashAPIKey(plainKey)
 
@@ -110,80 +260,194 @@ func (s *Service) CreateAPIKey(userID ulid.ULID, name string, scopes []string, e
 		ExpiresAt: expiresAt,
 	}
 
-	err = s.apiKeyRepo.Create(context.TODO(), apiKey)
+	err := s.apiKeyRepo.Create(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return apiKey, nil
+}
+
+// CreatePublicKey creates a public key for the chat widget of a website. It
+// can only query the website, from the allowed origins, and rateLimitPerMin
+// requests per minute per visitor, 0 for the server default.
+func (s *Service) CreatePublicKey(ctx context.Context, userID ulid.ULID, websiteID uint, name string, allowedOrigins []string, rateLimitPerMin int) (*schema.APIKey, string, error) {
+	plainKey, err := s.generateKey(schema.PublicKeyPrefix)
 	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	apiKey := &schema.APIKey{
+		UserID:          userID,
+		KeyHash:         s.HashAPIKey(plainKey),
+		KeyPrefix:       plainKey[:12],
+		Name:            name,
+		Scopes:          []string{fmt.Sprintf("websites:%d:query", websiteID)},
+		IsActive:        true,
+		Public:          true,
+		WebsiteID:       &websiteID,
+		AllowedOrigins:  allowedOrigins,
+		RateLimitPerMin: rateLimitPerMin,
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
 		return nil, "", fmt.Errorf("failed to create API key: %w", err)
 	}
 
 	return apiKey, plainKey, nil
 }
 
-// ValidateAPIKey validates an API key and returns the associated user
-func (s *Service) ValidateAPIKey(plainKey string) (*schema.User, *schema.APIKey, error) {
+// CreateSession creates the API key of a web session for a user, expiring
+// after SessionTTL. The user's expired sessions are deleted
+func (s *Service) CreateSession(ctx context.Context, userID ulid.ULID, ip, userAgent string) (*schema.APIKey, string, error) {
+	if _, err := s.apiKeyRepo.DeleteExpiredSessions(ctx, userID); err != nil {
+		s.logger.Warn("Failed to delete expired sessions", zap.Error(err))
+	}
+
+	plainKey, err := s.GenerateAPIKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	expiresAt := time.Now().Add(SessionTTL)
+	session := &schema.APIKey{
+		UserID:    userID,
+		KeyHash:   s.HashAPIKey(plainKey),
+		KeyPrefix: plainKey[:8],
+		Name:      "Web Session - " + time.Now().Format("2006-01-02 15:04:05"),
+		Scopes:    []string{"*"},
+		IsActive:  true,
+		Session:   true,
+		IP:        ip,
+		UserAgent: userAgent,
+		ExpiresAt: &expiresAt,
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, session); err != nil {
+		return nil, "", fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return session, plainKey, nil
+}
+
+// GetUserSessions retrieves the active web sessions of a user
+func (s *Service) GetUserSessions(ctx context.Context, userID ulid.ULID) ([]*schema.APIKey, error) {
+	return s.apiKeyRepo.ListSessions(ctx, userID)
+}
+
+// RevokeSession revokes (deletes) a web session of a user
+func (s *Service) RevokeSession(ctx context.Context, sessionID, userID ulid.ULID) error {
+	session, err := s.apiKeyRepo.GetByID(ctx, sessionID)
+	if err != nil || !session.Session || session.UserID != userID {
+		return apperrors.NotFound("session not found")
+	}
+
+	return s.apiKeyRepo.Delete(ctx, sessionID)
+}
+
+// RevokeSessions revokes all web sessions of a user except exceptID, which
+// may be nil, and returns the number revoked
+func (s *Service) RevokeSessions(ctx context.Context, userID ulid.ULID, exceptID *ulid.ULID) (int64, error) {
+	return s.apiKeyRepo.DeleteSessions(ctx, userID, exceptID)
+}
+
+// ValidateAPIKey validates an API key and returns the associated user.
+// Public keys are rejected, see ValidatePublicKey
+func (s *Service) ValidateAPIKey(ctx context.Context, plainKey string) (*schema.User, *schema.APIKey, error) {
+	user, apiKey, err := s.validateKey(ctx, plainKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if apiKey.Public {
+		return nil, nil, apperrors.Unauthorized("public keys can only be used by the chat widget")
+	}
+	return user, apiKey, nil
+}
+
+// ValidatePublicKey validates a public key of the chat widget and returns the
+// associated user
+func (s *Service) ValidatePublicKey(ctx context.Context, plainKey string) (*schema.User, *schema.APIKey, error) {
+	user, apiKey, err := s.validateKey(ctx, plainKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !apiKey.Public || apiKey.WebsiteID == nil {
+		return nil, nil, apperrors.Unauthorized("invalid public key")
+	}
+	return user, apiKey, nil
+}
+
+func (s *Service) validateKey(ctx context.Context, plainKey string) (*schema.User, *schema.APIKey, error) {
 	// Hash the provided key
 	keyHash := s.HashAPIKey(plainKey)
 
 	// Get API key from database
-	apiKey, err := s.apiKeyRepo.GetByKeyHash(context.TODO(), keyHash)
+	apiKey, err := s.apiKeyRepo.GetByKeyHash(ctx, keyHash)
 	if err != nil {
-		return nil, nil, fmt.Errorf("invalid API key")
+		return nil, nil, apperrors.Unauthorized("invalid API key")
 	}
 
 	// Check if key is valid (active and not expired)
 	if !apiKey.IsValid() {
-		return nil, nil, fmt.Errorf("API key is invalid or expired")
+		return nil, nil, apperrors.Unauthorized("API key is invalid or expired")
 	}
 
 	// Get associated user
-	user, err := s.userRepo.GetByID(context.TODO(), apiKey.UserID)
+	user, err := s.userRepo.GetByID(ctx, apiKey.UserID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("user not found")
+		return nil, nil, apperrors.Unauthorized("user not found")
 	}
 
 	// Check if user is active
 	if !user.IsActive {
-		return nil, nil, fmt.Errorf("user account is inactive")
+		return nil, nil, apperrors.Forbidden("user account is inactive")
 	}
 
-	// Update last used timestamp (async, don't block)
-	go s.apiKeyRepo.UpdateLastUsed(context.TODO(), apiKey.ID)
+	// Update last used timestamp (async, don't block), also after the
+	// request is done
+	go s.apiKeyRepo.UpdateLastUsed(context.WithoutCancel(ctx), apiKey.ID)
 
 	return user, apiKey, nil
 }
 
 // GetUserAPIKeys retrieves all API keys for a user
-func (s *Service) GetUserAPIKeys(userID ulid.ULID) ([]*schema.APIKey, error) {
-	return s.apiKeyRepo.GetByUserID(context.TODO(), userID)
+func (s *Service) GetUserAPIKeys(ctx context.Context, userID ulid.ULID) ([]*schema.APIKey, error) {
+	return s.apiKeyRepo.GetByUserID(ctx, userID)
 }
 
 // RevokeAPIKey revokes (deletes) an API key
-func (s *Service) RevokeAPIKey(keyID, userID ulid.ULID) error {
+func (s *Service) RevokeAPIKey(ctx context.Context, keyID, userID ulid.ULID) error {
 	// Get the API key to verify ownership
-	apiKey, err := s.apiKeyRepo.GetByID(context.TODO(), keyID)
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, keyID)
 	if err != nil {
-		return fmt.Errorf("API key not found")
+		return apperrors.NotFound("API key not found")
 	}
 
 	// Verify the key belongs to the user
 	if apiKey.UserID != userID {
-		return fmt.Errorf("unauthorized")
+		return apperrors.Forbidden("you don't have permission to manage this API key")
 	}
 
 	// Delete the key
-	return s.apiKeyRepo.Delete(context.TODO(), keyID)
+	return s.apiKeyRepo.Delete(ctx, keyID)
 }
 
 // UpdateAPIKey updates an API key
-func (s *Service) UpdateAPIKey(keyID, userID ulid.ULID, name *string, scopes []string, isActive *bool, expiresAt *time.Time) (*schema.APIKey, error) {
+func (s *Service) UpdateAPIKey(ctx context.Context, keyID, userID ulid.ULID, name *string, scopes []string, isActive *bool, expiresAt *time.Time) (*schema.APIKey, error) {
 	// Get the API key to verify ownership
-	apiKey, err := s.apiKeyRepo.GetByID(context.TODO(), keyID)
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, keyID)
 	if err != nil {
-		return nil, fmt.Errorf("API key not found")
+		return nil, apperrors.NotFound("API key not found")
 	}
 
 	// Verify the key belongs to the user
 	if apiKey.UserID != userID {
-		return nil, fmt.Errorf("unauthorized")
+		return nil, apperrors.Forbidden("you don't have permission to manage this API key")
+	}
+
+	// The scope of public keys is their website
+	if apiKey.Public && scopes != nil {
+		return nil, apperrors.Validation("the scopes of public keys can't be changed")
 	}
 
 	// Update fields
@@ -201,7 +465,7 @@ func (s *Service) UpdateAPIKey(keyID, userID ulid.ULID, name *string, scopes []s
 	}
 
 	// Save changes
-	err = s.apiKeyRepo.Update(context.TODO(), apiKey)
+	err = s.apiKeyRepo.Update(ctx, apiKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update API key: %w", err)
 	}
@@ -226,6 +490,10 @@ func (s *Service) VerifyPassword(password, hash string) bool {
 
 // GenerateAPIKey generates a random API key
 func (s *Service) GenerateAPIKey() (string, error) {
+	return s.generateKey("hmt_")
+}
+
+func (s *Service) generateKey(prefix string) (string, error) {
 	// Generate 32 random bytes
 	b := make([]byte, 32)
 	_, err := rand.Read(b)
@@ -238,7 +506,7 @@ func (s *Service) GenerateAPIKey() (string, error) {
 	// Remove padding
 	key = strings.TrimRight(key, "=")
 
-	return fmt.Sprintf("hmt_%s", key), nil
+	return prefix + key, nil
 }
 
 // HashAPIKey hashes an API key using SHA256
@@ -248,6 +516,6 @@ func (s *Service) HashAPIKey(apiKey string) string {
 }
 
 // CleanupExpiredAPIKeys removes expired API keys
-func (s *Service) CleanupExpiredAPIKeys() (int64, error) {
-	return s.apiKeyRepo.CleanupExpired(context.TODO())
+func (s *Service) CleanupExpiredAPIKeys(ctx context.Context) (int64, error) {
+	return s.apiKeyRepo.CleanupExpired(ctx)
 }
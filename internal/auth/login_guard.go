@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"hermit/internal/config"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// loginKeyPrefix prefixes the Redis keys counting failed logins.
+const loginKeyPrefix = "hermit:login:failures:"
+
+// LoginGuard counts failed logins per email and per IP in Redis and locks
+// logins out once either reaches its limit. Counters expire after the lockout
+// duration, so failures older than that are forgotten.
+type LoginGuard struct {
+	client      redis.UniversalClient
+	maxPerEmail int
+	maxPerIP    int
+	lockout     time.Duration
+	logger      *zap.Logger
+}
+
+// NewLoginGuard creates a LoginGuard using the job queue's Redis. It returns
+// nil when both limits are disabled, a nil guard never locks logins out.
+func NewLoginGuard(cfg *config.Config, logger *zap.Logger) (*LoginGuard, error) {
+	if cfg.LoginMaxAttempts <= 0 && cfg.LoginMaxAttemptsPerIP <= 0 {
+		logger.Info("Login lockout disabled")
+		return nil, nil
+	}
+
+	opt, err := asynq.ParseRedisURI(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+	}
+	client, ok := opt.MakeRedisClient().(redis.UniversalClient)
+	if !ok {
+		return nil, fmt.Errorf("unsupported redis connection")
+	}
+
+	lockout := time.Duration(cfg.LoginLockoutMinutes) * time.Minute
+	if lockout <= 0 {
+		lockout = 15 * time.Minute
+	}
+
+	return &LoginGuard{
+		client:      client,
+		maxPerEmail: cfg.LoginMaxAttempts,
+		maxPerIP:    cfg.LoginMaxAttemptsPerIP,
+		lockout:     lockout,
+		logger:      logger,
+	}, nil
+}
+
+// counters returns the keys of the counters a login attempt is tracked in
+// with their limits. Disabled limits are left out.
+func (g *LoginGuard) counters(email, ip string) map[string]int {
+	counters := make(map[string]int, 2)
+	if g.maxPerEmail > 0 && email != "" {
+		counters[loginKeyPrefix+"email:"+strings.ToLower(strings.TrimSpace(email))] = g.maxPerEmail
+	}
+	if g.maxPerIP > 0 && ip != "" {
+		counters[loginKeyPrefix+"ip:"+ip] = g.maxPerIP
+	}
+	return counters
+}
+
+// Check returns how long logins for the email or from the IP remain locked
+// out, 0 when they aren't. Logins are allowed when Redis can't be reached.
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) time.Duration {
+	if g == nil {
+		return 0
+	}
+
+	var locked time.Duration
+	for key, limit := range g.counters(email, ip) {
+		failures, err := g.client.Get(ctx, key).Int()
+		if err == redis.Nil || (err == nil && failures < limit) {
+			continue
+		}
+		if err != nil {
+			g.logger.Warn("Failed to check login failures", zap.Error(err))
+			continue
+		}
+
+		ttl, err := g.client.TTL(ctx, key).Result()
+		if err != nil || ttl <= 0 {
+			ttl = g.lockout
+		}
+		if ttl > locked {
+			locked = ttl
+		}
+	}
+	return locked
+}
+
+// Fail counts a failed login for the email and the IP. Reaching a limit
+// restarts the lockout.
+func (g *LoginGuard) Fail(ctx context.Context, email, ip string) {
+	if g == nil {
+		return
+	}
+
+	for key, limit := range g.counters(email, ip) {
+		failures, err := g.client.Incr(ctx, key).Result()
+		if err != nil {
+			g.logger.Warn("Failed to count login failure", zap.Error(err))
+			continue
+		}
+		if failures == 1 || failures >= int64(limit) {
+			if err := g.client.Expire(ctx, key, g.lockout).Err(); err != nil {
+				g.logger.Warn("Failed to expire login failures", zap.Error(err))
+			}
+		}
+		if failures == int64(limit) {
+			g.logger.Warn("Login locked out after repeated failures",
+				zap.String("counter", strings.TrimPrefix(key, loginKeyPrefix)),
+				zap.Int64("failures", failures),
+				zap.Duration("lockout", g.lockout),
+			)
+		}
+	}
+}
+
+// Succeed clears the failed logins of the email. Failures from the IP keep
+// counting, so one valid account doesn't reset guessing others.
+func (g *LoginGuard) Succeed(ctx context.Context, email string) {
+	if g == nil {
+		return
+	}
+
+	for key := range g.counters(email, "") {
+		if err := g.client.Del(ctx, key).Err(); err != nil {
+			g.logger.Warn("Failed to clear login failures", zap.Error(err))
+		}
+	}
+}
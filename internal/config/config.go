@@ -21,22 +21,164 @@ type Config struct {
 	OllamaURL        string
 	OllamaModel      string
 	OllamaLLMModel   string
+	// Model warm-up at worker start
+	OllamaWarmupEnabled       bool
+	OllamaEmbeddingDimensions int // 0 skips the dimension check
+	// Ollama retries and circuit breaker
+	OllamaMaxRetries       int
+	OllamaBreakerThreshold int
+	OllamaBreakerCooldown  int // seconds
+	// LLM summaries of crawled pages and websites
+	SummariesEnabled bool
+	// Retries and circuit breaker of ChromaDB and object storage
+	DependencyMaxRetries       int
+	DependencyBreakerThreshold int
+	DependencyBreakerCooldown  int // seconds
+	// Storage settings
+	StorageDriver      string // "s3" (Garage, MinIO) or "local"
+	StorageLocalPath   string
+	StorageCompression string // gzip, zstd or none
+	StorageCacheMaxMB  int    // in-memory page content cache, 0 disables it
+	// Worker health probes (empty disables them)
+	WorkerHealthPort string
+	// Worker concurrency, scaled between the min and max with the queue
+	// pressure when autoscaling is enabled
+	WorkerConcurrency       int
+	WorkerAutoscale         bool
+	WorkerMinConcurrency    int
+	WorkerMaxConcurrency    int
+	WorkerAutoscaleInterval int // in seconds
+	// Time running jobs get to finish on shutdown, crawls are checkpointed
+	WorkerShutdownTimeout int // in seconds
+	// Base64 encoded 32 byte key encrypting website credentials (empty disables them)
+	CredentialsEncryptionKey string
+	// Keys credentials were encrypted with before the current key was rotated
+	CredentialsEncryptionPreviousKeys []string
 	// Redis settings
 	RedisURL      string
 	RedisPassword string
 	RedisDB       int
+	// All-in-one mode runs an in-memory Redis instead of connecting to one
+	RedisEmbedded bool
 	// Crawler settings
 	CrawlerMaxDepth      int
 	CrawlerMaxPages      int
 	CrawlerDelayMS       int
 	CrawlerRespectRobots bool
 	CrawlerUserAgent     string
+	// Retries of pages that failed to fetch
+	CrawlerPageMaxRetries   int
+	CrawlerPageRetryBackoff int // in seconds, doubled on every retry
+	// Default crawl budgets per website, 0 means unlimited
+	CrawlerMaxBytes          int
+	CrawlerMaxDuration       int // in seconds
+	CrawlerMaxPagesPerPrefix int
+	// Recrawls don't index pages whose sitemap lastmod is older than their
+	// last crawl again
+	CrawlerSitemapLastmod bool
+	// Crawls without page activity for the stall timeout are marked as
+	// failed, and queued again with CrawlStallRequeue
+	CrawlWatchdogInterval int // in seconds, 0 disables the watchdog
+	CrawlStallTimeout     int // in minutes
+	CrawlStallRequeue     bool
+	// Completed jobs keep their result for the retention, archived jobs are
+	// pruned after the archive TTL every prune interval
+	JobRetention     int // in hours
+	JobArchiveTTL    int // in hours, 0 keeps archived jobs
+	JobPruneInterval int // in seconds, 0 disables pruning
+	// Queue stats are sampled into Postgres for the queue history
+	QueueStatsInterval  int // in seconds, 0 disables sampling
+	QueueStatsRetention int // in hours, 0 keeps samples
+	// Crawls wait to enqueue vectorize tasks while the website has the max
+	// in flight, and give up on the page after the backpressure wait
+	VectorizeMaxInFlight      int // per website, 0 disables the cap
+	VectorizeBackpressureWait int // in seconds
+	// Pages up to the max chars are vectorized in batches per website,
+	// embedded in a single request
+	VectorizeBatchSize        int // 0 disables batching
+	VectorizeBatchMaxChars    int
+	VectorizeBatchGracePeriod int // in seconds
+	VectorizeBatchMaxDelay    int // in seconds
+	// Crawler transport settings
+	CrawlerHTTP2Enabled    bool
+	CrawlerMaxIdleConns    int
+	CrawlerMaxConnsPerHost int
+	CrawlerIdleConnTimeout int // in seconds
+	CrawlerDNSCacheTTL     int // in seconds
+	CrawlerTLSSkipVerify   bool
+	CrawlerTLSMinVersion   string
+	// Crawler response limits
+	CrawlerMaxBodySize  int    // in bytes, of the decoded body
+	CrawlerContentTypes string // comma separated media types that are indexed
+	// Crawler proxies, comma separated http(s):// or socks5:// URLs
+	CrawlerProxies            string
+	CrawlerProxyCheckInterval int // in seconds, 0 disables health checks
+	CrawlerProxyCheckURL      string
+	CrawlerProxyMaxFailures   int
+	// Visual change detection
+	ScreenshotServiceURL  string
+	ScreenshotWidth       int
+	ScreenshotHeight      int
+	VisualChangeThreshold float64
+	// Store a screenshot of every crawled page, served for verification
+	ScreenshotPages bool
+	// Archive the raw HTML of every fetched page for reprocessing
+	ArchiveRawHTML bool
+	// Notifications
+	NotificationWebhookURL string
+	// Slack app answering questions in linked channels (empty disables it)
+	SlackBotToken      string
+	SlackSigningSecret string
+	// Discord app answering the /ask command in linked channels (empty
+	// disables it); the bot token registers the command at startup
+	DiscordApplicationID string
+	DiscordPublicKey     string
+	DiscordBotToken      string
 	// RAG settings
 	RAGTopK          int
 	RAGContextChunks int
+	// RAG answer cache
+	RAGCacheEnabled       bool
+	RAGCacheSimilarity    float64
+	RAGCacheTTLMinutes    int
+	RAGCacheMaxCandidates int
+	RAGCachePruneInterval int // in seconds, 0 disables pruning
+	// Answer post-processing
+	AnswerSanitize      bool
+	AnswerRewriteLinks  bool
+	AnswerStripPreamble bool
+	AnswerMaxLength     int // in characters, 0 means unlimited
+	// RAG guardrails
+	GuardrailsEnabled       bool
+	GuardrailsMinSimilarity float64 // 0 disables the off-topic check
+	GuardrailsModeration    bool
+	// Cost per 1,000 LLM tokens for usage reports, in any currency
+	LLMCostPer1KPromptTokens     float64
+	LLMCostPer1KCompletionTokens float64
 	// Content processing
 	ContentMinLength  int
-	ContentMinQuality float64
+	ContentMinQuality float64 // default for websites without their own threshold
+	// Weights of the content quality features
+	ContentQualityWeightLength      float64
+	ContentQualityWeightWords       float64
+	ContentQualityWeightSentences   float64
+	ContentQualityWeightBoilerplate float64
+	ContentQualityWeightLinkDensity float64
+	// Ask the LLM whether pages passing the quality heuristics are useful
+	ContentQualityClassifier bool
+	// Strip text blocks recurring on more than the threshold share of a
+	// site's pages once the minimum number of pages was crawled
+	ContentBoilerplateRemoval   bool
+	ContentBoilerplateMinPages  int
+	ContentBoilerplateThreshold float64
+	// Phrases, or /regexp/ entries, removed from pages when a text block
+	// consists only of them, semicolon separated; empty disables it
+	ContentNoisePatterns string
+	// Custom personal data patterns redacted from the pages of websites with
+	// PII redaction, as semicolon separated kind=regexp entries
+	PIIPatterns string
+	// Index the alt text and captions of a page's images along with its text
+	ContentImageText bool
 	// HTTP timeouts
 	HTTPTimeout     int
 	CrawlerTimeout  int
@@ -50,8 +192,36 @@ type Config struct {
 	RateLimitEnabled        bool
 	RateLimitRequestsPerMin int64
 	RateLimitBurst          int64
+	// Requests per minute per visitor of the chat widget, for public keys
+	// without their own limit
+	WidgetRateLimitPerMin int64
+	// Requests per minute of all visitors of a chat widget key together, at
+	// least the key's visitor limit, 0 disables it
+	WidgetKeyRateLimitPerMin int64
+	// Proxies, as IPs or CIDR ranges, whose X-Forwarded-For header gives the
+	// client IP used by rate limits and the login lockout. Without any the
+	// IP of the connection is used
+	TrustedProxies []string
+	// Login lockout after repeated failures per email and per IP, 0 disables it
+	LoginMaxAttempts      int
+	LoginMaxAttemptsPerIP int
+	LoginLockoutMinutes   int
+	// First admin created on start while there is no admin
+	AdminEmail    string
+	AdminPassword string
+	// Request deadlines in seconds, 0 disables them
+	RequestTimeout       int
+	RequestTimeoutQuery  int
+	RequestTimeoutStatus int
+
+	// Environment variables that couldn't be parsed, reported by Validate
+	invalidEnv []string
 }
 
+// defaultNoisePatterns are the calls to action and legal links removed from
+// pages unless CONTENT_NOISE_PATTERNS is set.
+const defaultNoisePatterns = "Click here;Read more;Subscribe now;Sign up;Advertisement;Cookie policy;Privacy policy;Terms of service"
+
 // NewConfig creates a new Config struct
 func NewConfig() *Config {
 	if os.Getenv("APP_ENV") != "production" {
@@ -61,7 +231,8 @@ func NewConfig() *Config {
 		}
 	}
 
-	return &Config{
+	invalidEnv = nil
+	cfg := &Config{
 		Port:             getEnv("PORT", "8080"),
 		DatabaseURL:      getEnv("DATABASE_URL", ""),
 		GarageEndpoint:   getEnv("GARAGE_ENDPOINT", "localhost:3902"),
@@ -73,22 +244,152 @@ func NewConfig() *Config {
 		OllamaURL:        getEnv("OLLAMA_URL", "http://localhost:11434"),
 		OllamaModel:      getEnv("OLLAMA_MODEL", "mxbai-embed-large"),
 		OllamaLLMModel:   getEnv("OLLAMA_LLM_MODEL", "llama3.1"),
+		// Model warm-up at worker start
+		OllamaWarmupEnabled:       getEnvBool("OLLAMA_WARMUP_ENABLED", true),
+		OllamaEmbeddingDimensions: getEnvInt("OLLAMA_EMBEDDING_DIMENSIONS", 0),
+		// Ollama retries and circuit breaker
+		OllamaMaxRetries:       getEnvInt("OLLAMA_MAX_RETRIES", 2),
+		OllamaBreakerThreshold: getEnvInt("OLLAMA_BREAKER_THRESHOLD", 5),
+		OllamaBreakerCooldown:  getEnvInt("OLLAMA_BREAKER_COOLDOWN", 30),
+		// LLM summaries of crawled pages and websites
+		SummariesEnabled: getEnvBool("SUMMARIES_ENABLED", true),
+		// Retries and circuit breaker of ChromaDB and object storage
+		DependencyMaxRetries:       getEnvInt("DEPENDENCY_MAX_RETRIES", 2),
+		DependencyBreakerThreshold: getEnvInt("DEPENDENCY_BREAKER_THRESHOLD", 5),
+		DependencyBreakerCooldown:  getEnvInt("DEPENDENCY_BREAKER_COOLDOWN", 30),
+		// Storage settings
+		StorageDriver:      getEnv("STORAGE_DRIVER", "s3"),
+		StorageLocalPath:   getEnv("STORAGE_LOCAL_PATH", "./data/storage"),
+		StorageCompression: getEnv("STORAGE_COMPRESSION", "gzip"),
+		StorageCacheMaxMB:  getEnvInt("STORAGE_CACHE_MAX_MB", 64),
+		// Worker health probes (empty disables them)
+		WorkerHealthPort: getEnv("WORKER_HEALTH_PORT", "8081"),
+		// Worker concurrency, scaled between the min and max with the queue
+		// pressure when autoscaling is enabled
+		WorkerConcurrency:       getEnvInt("WORKER_CONCURRENCY", 10),
+		WorkerAutoscale:         getEnvBool("WORKER_AUTOSCALE", false),
+		WorkerMinConcurrency:    getEnvInt("WORKER_MIN_CONCURRENCY", 2),
+		WorkerMaxConcurrency:    getEnvInt("WORKER_MAX_CONCURRENCY", 20),
+		WorkerAutoscaleInterval: getEnvInt("WORKER_AUTOSCALE_INTERVAL", 10),
+		// Time running jobs get to finish on shutdown, crawls are checkpointed
+		WorkerShutdownTimeout: getEnvInt("WORKER_SHUTDOWN_TIMEOUT", 30),
+		// Base64 encoded 32 byte key encrypting website credentials (empty disables them)
+		CredentialsEncryptionKey: getEnv("CREDENTIALS_ENCRYPTION_KEY", ""),
+		// Keys credentials were encrypted with before the current key was rotated
+		CredentialsEncryptionPreviousKeys: getEnvList("CREDENTIALS_ENCRYPTION_PREVIOUS_KEYS"),
 		// Redis settings
-		RedisURL:      getEnv("REDIS_URL", "localhost:6379"),
+		RedisURL:      getEnv("REDIS_URL", "redis://localhost:6379"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		RedisDB:       getEnvInt("REDIS_DB", 0),
+		// All-in-one mode runs an in-memory Redis instead of connecting to one
+		RedisEmbedded: getEnvBool("REDIS_EMBEDDED", false),
 		// Crawler settings
 		CrawlerMaxDepth:      getEnvInt("CRAWLER_MAX_DEPTH", 10),
 		CrawlerMaxPages:      getEnvInt("CRAWLER_MAX_PAGES", 1000),
 		CrawlerDelayMS:       getEnvInt("CRAWLER_DELAY_MS", 500),
 		CrawlerRespectRobots: getEnvBool("CRAWLER_RESPECT_ROBOTS_TXT", true),
 		CrawlerUserAgent:     getEnv("CRAWLER_USER_AGENT", "Hermit Crawler/1.0"),
+		// Retries of pages that failed to fetch
+		CrawlerPageMaxRetries:   getEnvInt("CRAWLER_PAGE_MAX_RETRIES", 3),
+		CrawlerPageRetryBackoff: getEnvInt("CRAWLER_PAGE_RETRY_BACKOFF", 60),
+		// Default crawl budgets per website, 0 means unlimited
+		CrawlerMaxBytes:          getEnvInt("CRAWLER_MAX_BYTES", 0),
+		CrawlerMaxDuration:       getEnvInt("CRAWLER_MAX_DURATION", 0),
+		CrawlerMaxPagesPerPrefix: getEnvInt("CRAWLER_MAX_PAGES_PER_PREFIX", 0),
+		// Recrawls don't index pages whose sitemap lastmod is older than their
+		// last crawl again
+		CrawlerSitemapLastmod: getEnvBool("CRAWLER_SITEMAP_LASTMOD", true),
+		// Crawls without page activity for the stall timeout are marked as
+		// failed, and queued again with CrawlStallRequeue
+		CrawlWatchdogInterval: getEnvInt("CRAWL_WATCHDOG_INTERVAL", 300),
+		CrawlStallTimeout:     getEnvInt("CRAWL_STALL_TIMEOUT", 30),
+		CrawlStallRequeue:     getEnvBool("CRAWL_STALL_REQUEUE", false),
+		// Completed jobs keep their result for the retention, archived jobs are
+		// pruned after the archive TTL every prune interval
+		JobRetention:     getEnvInt("JOB_RETENTION", 24),
+		JobArchiveTTL:    getEnvInt("JOB_ARCHIVE_TTL", 168),
+		JobPruneInterval: getEnvInt("JOB_PRUNE_INTERVAL", 3600),
+		// Queue stats are sampled into Postgres for the queue history
+		QueueStatsInterval:  getEnvInt("QUEUE_STATS_INTERVAL", 60),
+		QueueStatsRetention: getEnvInt("QUEUE_STATS_RETENTION", 168),
+		// Crawls wait to enqueue vectorize tasks while the website has the max
+		// in flight, and give up on the page after the backpressure wait
+		VectorizeMaxInFlight:      getEnvInt("VECTORIZE_MAX_IN_FLIGHT", 200),
+		VectorizeBackpressureWait: getEnvInt("VECTORIZE_BACKPRESSURE_WAIT", 600),
+		// Pages up to the max chars are vectorized in batches per website,
+		// embedded in a single request
+		VectorizeBatchSize:        getEnvInt("VECTORIZE_BATCH_SIZE", 16),
+		VectorizeBatchMaxChars:    getEnvInt("VECTORIZE_BATCH_MAX_CHARS", 4000),
+		VectorizeBatchGracePeriod: getEnvInt("VECTORIZE_BATCH_GRACE_PERIOD", 2),
+		VectorizeBatchMaxDelay:    getEnvInt("VECTORIZE_BATCH_MAX_DELAY", 10),
+		// Crawler transport settings
+		CrawlerHTTP2Enabled:    getEnvBool("CRAWLER_HTTP2_ENABLED", true),
+		CrawlerMaxIdleConns:    getEnvInt("CRAWLER_MAX_IDLE_CONNS", 100),
+		CrawlerMaxConnsPerHost: getEnvInt("CRAWLER_MAX_CONNS_PER_HOST", 10),
+		CrawlerIdleConnTimeout: getEnvInt("CRAWLER_IDLE_CONN_TIMEOUT", 90),
+		CrawlerDNSCacheTTL:     getEnvInt("CRAWLER_DNS_CACHE_TTL", 300),
+		CrawlerTLSSkipVerify:   getEnvBool("CRAWLER_TLS_SKIP_VERIFY", false),
+		CrawlerTLSMinVersion:   getEnv("CRAWLER_TLS_MIN_VERSION", "1.2"),
+		// Crawler response limits
+		CrawlerMaxBodySize:  getEnvInt("CRAWLER_MAX_BODY_SIZE", 10*1024*1024),
+		CrawlerContentTypes: getEnv("CRAWLER_CONTENT_TYPES", "text/html,application/xhtml+xml"),
+		// Crawler proxies, comma separated http(s):// or socks5:// URLs
+		CrawlerProxies:            getEnv("CRAWLER_PROXIES", ""),
+		CrawlerProxyCheckInterval: getEnvInt("CRAWLER_PROXY_CHECK_INTERVAL", 60),
+		CrawlerProxyCheckURL:      getEnv("CRAWLER_PROXY_CHECK_URL", ""),
+		CrawlerProxyMaxFailures:   getEnvInt("CRAWLER_PROXY_MAX_FAILURES", 3),
+		// Visual change detection
+		ScreenshotServiceURL:  getEnv("SCREENSHOT_SERVICE_URL", ""),
+		ScreenshotWidth:       getEnvInt("SCREENSHOT_WIDTH", 1280),
+		ScreenshotHeight:      getEnvInt("SCREENSHOT_HEIGHT", 800),
+		VisualChangeThreshold: getEnvFloat("VISUAL_CHANGE_THRESHOLD", 0.15),
+		ScreenshotPages:       getEnvBool("SCREENSHOT_PAGES", false),
+		ArchiveRawHTML:        getEnvBool("ARCHIVE_RAW_HTML", false),
+		// Notifications
+		NotificationWebhookURL: getEnv("NOTIFICATION_WEBHOOK_URL", ""),
+		// Slack app
+		SlackBotToken:      getEnv("SLACK_BOT_TOKEN", ""),
+		SlackSigningSecret: getEnv("SLACK_SIGNING_SECRET", ""),
+		// Discord app
+		DiscordApplicationID: getEnv("DISCORD_APPLICATION_ID", ""),
+		DiscordPublicKey:     getEnv("DISCORD_PUBLIC_KEY", ""),
+		DiscordBotToken:      getEnv("DISCORD_BOT_TOKEN", ""),
 		// RAG settings
 		RAGTopK:          getEnvInt("RAG_TOP_K", 5),
 		RAGContextChunks: getEnvInt("RAG_CONTEXT_CHUNKS", 3),
+		// RAG answer cache
+		RAGCacheEnabled:       getEnvBool("RAG_CACHE_ENABLED", true),
+		RAGCacheSimilarity:    getEnvFloat("RAG_CACHE_SIMILARITY", 0.95),
+		RAGCacheTTLMinutes:    getEnvInt("RAG_CACHE_TTL_MINUTES", 60),
+		RAGCacheMaxCandidates: getEnvInt("RAG_CACHE_MAX_CANDIDATES", 200),
+		RAGCachePruneInterval: getEnvInt("RAG_CACHE_PRUNE_INTERVAL", 3600),
+		// Answer post-processing
+		AnswerSanitize:      getEnvBool("ANSWER_SANITIZE", true),
+		AnswerRewriteLinks:  getEnvBool("ANSWER_REWRITE_LINKS", true),
+		AnswerStripPreamble: getEnvBool("ANSWER_STRIP_PREAMBLE", true),
+		AnswerMaxLength:     getEnvInt("ANSWER_MAX_LENGTH", 0),
+		// RAG guardrails
+		GuardrailsEnabled:       getEnvBool("GUARDRAILS_ENABLED", true),
+		GuardrailsMinSimilarity: getEnvFloat("GUARDRAILS_MIN_SIMILARITY", 0),
+		GuardrailsModeration:    getEnvBool("GUARDRAILS_MODERATION", false),
+		// Cost per 1,000 LLM tokens for usage reports
+		LLMCostPer1KPromptTokens:     getEnvFloat("LLM_COST_PER_1K_PROMPT_TOKENS", 0),
+		LLMCostPer1KCompletionTokens: getEnvFloat("LLM_COST_PER_1K_COMPLETION_TOKENS", 0),
 		// Content processing
-		ContentMinLength:  getEnvInt("CONTENT_MIN_LENGTH", 100),
-		ContentMinQuality: getEnvFloat("CONTENT_MIN_QUALITY", 0.3),
+		ContentMinLength:                getEnvInt("CONTENT_MIN_LENGTH", 100),
+		ContentMinQuality:               getEnvFloat("CONTENT_MIN_QUALITY", 0.3),
+		ContentQualityWeightLength:      getEnvFloat("CONTENT_QUALITY_WEIGHT_LENGTH", 0.4),
+		ContentQualityWeightWords:       getEnvFloat("CONTENT_QUALITY_WEIGHT_WORDS", 0.3),
+		ContentQualityWeightSentences:   getEnvFloat("CONTENT_QUALITY_WEIGHT_SENTENCES", 0.2),
+		ContentQualityWeightBoilerplate: getEnvFloat("CONTENT_QUALITY_WEIGHT_BOILERPLATE", 0.05),
+		ContentQualityWeightLinkDensity: getEnvFloat("CONTENT_QUALITY_WEIGHT_LINK_DENSITY", 0.05),
+		ContentQualityClassifier:        getEnvBool("CONTENT_QUALITY_CLASSIFIER", false),
+		ContentBoilerplateRemoval:       getEnvBool("CONTENT_BOILERPLATE_REMOVAL", true),
+		ContentBoilerplateMinPages:      getEnvInt("CONTENT_BOILERPLATE_MIN_PAGES", 10),
+		ContentBoilerplateThreshold:     getEnvFloat("CONTENT_BOILERPLATE_THRESHOLD", 0.5),
+		ContentNoisePatterns:            getEnv("CONTENT_NOISE_PATTERNS", defaultNoisePatterns),
+		PIIPatterns:                     getEnv("PII_PATTERNS", ""),
+		ContentImageText:                getEnvBool("CONTENT_IMAGE_TEXT", false),
 		// HTTP timeouts
 		HTTPTimeout:     getEnvInt("HTTP_TIMEOUT", 30),
 		CrawlerTimeout:  getEnvInt("CRAWLER_TIMEOUT", 60),
@@ -99,10 +400,27 @@ func NewConfig() *Config {
 		DBMaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 5),
 		DBConnMaxLifetime: getEnvInt("DB_CONN_MAX_LIFETIME", 5), // 5 minutes default
 		// Rate limiting
-		RateLimitEnabled:        getEnvBool("RATE_LIMIT_ENABLED", true),
-		RateLimitRequestsPerMin: int64(getEnvInt("RATE_LIMIT_REQUESTS_PER_MIN", 60)),
-		RateLimitBurst:          int64(getEnvInt("RATE_LIMIT_BURST", 10)),
+		RateLimitEnabled:         getEnvBool("RATE_LIMIT_ENABLED", true),
+		RateLimitRequestsPerMin:  int64(getEnvInt("RATE_LIMIT_REQUESTS_PER_MIN", 60)),
+		RateLimitBurst:           int64(getEnvInt("RATE_LIMIT_BURST", 10)),
+		WidgetRateLimitPerMin:    int64(getEnvInt("WIDGET_RATE_LIMIT_PER_MIN", 10)),
+		WidgetKeyRateLimitPerMin: int64(getEnvInt("WIDGET_KEY_RATE_LIMIT_PER_MIN", 300)),
+		TrustedProxies:           getEnvList("TRUSTED_PROXIES"),
+		// Login lockout after repeated failures per email and per IP, 0 disables it
+		LoginMaxAttempts:      getEnvInt("LOGIN_MAX_ATTEMPTS", 5),
+		LoginMaxAttemptsPerIP: getEnvInt("LOGIN_MAX_ATTEMPTS_PER_IP", 20),
+		LoginLockoutMinutes:   getEnvInt("LOGIN_LOCKOUT_MINUTES", 15),
+		// First admin created on start while there is no admin
+		AdminEmail:    getEnv("ADMIN_EMAIL", ""),
+		AdminPassword: getEnv("ADMIN_PASSWORD", ""),
+		// Request deadlines in seconds, 0 disables them
+		RequestTimeout:       getEnvInt("REQUEST_TIMEOUT", 30),
+		RequestTimeoutQuery:  getEnvInt("REQUEST_TIMEOUT_QUERY", 60),
+		RequestTimeoutStatus: getEnvInt("REQUEST_TIMEOUT_STATUS", 5),
 	}
+	cfg.invalidEnv = invalidEnv
+
+	return cfg
 }
 
 // Simple helper function to read an environment variable or return a default value
@@ -119,6 +437,9 @@ func getEnvInt(key string, defaultValue int) int {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
+		if value != "" {
+			recordInvalid(key, value, "integer")
+		}
 	}
 	return defaultValue
 }
@@ -129,6 +450,9 @@ func getEnvBool(key string, defaultValue bool) bool {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
 			return boolValue
 		}
+		if value != "" {
+			recordInvalid(key, value, "boolean")
+		}
 	}
 	return defaultValue
 }
@@ -139,6 +463,15 @@ func getEnvFloat(key string, defaultValue float64) float64 {
 		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
 			return floatValue
 		}
+		if value != "" {
+			recordInvalid(key, value, "number")
+		}
 	}
 	return defaultValue
 }
+
+// getEnvList reads an environment variable as a comma separated list, skipping
+// empty entries
+func getEnvList(key string) []string {
+	return getList(os.Getenv(key))
+}
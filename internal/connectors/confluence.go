@@ -0,0 +1,113 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"hermit/internal/schema"
+)
+
+// confluencePageSize is the number of pages requested at a time.
+const confluencePageSize = 25
+
+// confluence ingests the pages of a Confluence space.
+type confluence struct {
+	client   *http.Client
+	baseURL  string
+	spaceKey string
+	// Cloud authenticates with an email and API token, Server and Data
+	// Center with a personal access token
+	email    string
+	apiToken string
+	token    string
+}
+
+func newConfluence(client *http.Client, cfg *schema.ConnectorConfig) *confluence {
+	return &confluence{
+		client:   client,
+		baseURL:  strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/"),
+		spaceKey: cfg.SpaceKey,
+		email:    cfg.Email,
+		apiToken: cfg.APIToken,
+		token:    cfg.Token,
+	}
+}
+
+type confluenceContent struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Body  struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+	Links struct {
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+// Documents lists the current pages of the space.
+func (c *confluence) Documents(ctx context.Context, fn func(Document) error) error {
+	for start := 0; ; start += confluencePageSize {
+		query := url.Values{
+			"spaceKey": {c.spaceKey},
+			"type":     {"page"},
+			"status":   {"current"},
+			"expand":   {"body.storage"},
+			"limit":    {strconv.Itoa(confluencePageSize)},
+			"start":    {strconv.Itoa(start)},
+		}
+
+		var page struct {
+			Results []confluenceContent `json:"results"`
+			Size    int                 `json:"size"`
+		}
+		if err := c.get(ctx, "/rest/api/content?"+query.Encode(), &page); err != nil {
+			return fmt.Errorf("failed to list pages: %w", err)
+		}
+
+		for _, content := range page.Results {
+			doc := Document{
+				URL:     c.baseURL + content.Links.WebUI,
+				Title:   content.Title,
+				Content: htmlText(content.Body.Storage.Value),
+			}
+			if err := fn(doc); err != nil {
+				return err
+			}
+		}
+
+		if page.Size < confluencePageSize {
+			return nil
+		}
+	}
+}
+
+func (c *confluence) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else {
+		req.SetBasicAuth(c.email, c.apiToken)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,80 @@
+// Package connectors ingests the pages of knowledge bases such as Notion and
+// Confluence through their APIs, as an alternative to crawling them.
+package connectors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"hermit/internal/schema"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Document is a page fetched by a connector.
+type Document struct {
+	URL     string
+	Title   string
+	Content string // plain text, one block per line
+}
+
+// Connector lists the documents of a knowledge base.
+type Connector interface {
+	// Documents calls fn with each document. It stops at the first error
+	// returned by fn.
+	Documents(ctx context.Context, fn func(Document) error) error
+}
+
+// New creates the connector described by cfg.
+func New(cfg *schema.ConnectorConfig, timeout time.Duration) (Connector, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: timeout}
+	switch cfg.Kind {
+	case schema.ConnectorKindNotion:
+		return newNotion(client, cfg.Token), nil
+	case schema.ConnectorKindConfluence:
+		return newConfluence(client, cfg), nil
+	}
+	return nil, fmt.Errorf("unsupported connector kind %q", cfg.Kind)
+}
+
+// checkResponse returns an error for unsuccessful API responses.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s %s: HTTP %d: %s", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode,
+		strings.TrimSpace(string(body)))
+}
+
+// htmlText converts an HTML fragment to text with one line per block.
+func htmlText(fragment string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(fragment))
+	var b strings.Builder
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(b.String())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			switch atom.Lookup(name) {
+			case atom.P, atom.Div, atom.Br, atom.Li, atom.Tr, atom.H1, atom.H2, atom.H3,
+				atom.H4, atom.H5, atom.H6, atom.Pre, atom.Blockquote, atom.Table:
+				b.WriteString("\n")
+			case atom.Td, atom.Th:
+				b.WriteString(" ")
+			}
+		case html.TextToken:
+			b.WriteString(string(tokenizer.Text()))
+		}
+	}
+}
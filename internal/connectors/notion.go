@@ -0,0 +1,205 @@
+package connectors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	notionAPIURL  = "https://api.notion.com/v1"
+	notionVersion = "2022-06-28"
+	// notionMaxDepth limits how deep nested blocks are read
+	notionMaxDepth = 5
+)
+
+// notion ingests the pages shared with a Notion integration.
+type notion struct {
+	client *http.Client
+	token  string
+}
+
+func newNotion(client *http.Client, token string) *notion {
+	return &notion{client: client, token: token}
+}
+
+type notionRichText struct {
+	PlainText string `json:"plain_text"`
+}
+
+type notionPage struct {
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	Properties map[string]struct {
+		Type  string           `json:"type"`
+		Title []notionRichText `json:"title"`
+	} `json:"properties"`
+}
+
+// title returns the text of the page's title property.
+func (p notionPage) title() string {
+	for _, property := range p.Properties {
+		if property.Type == "title" {
+			return richText(property.Title)
+		}
+	}
+	return ""
+}
+
+type notionBlock struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	HasChildren bool   `json:"has_children"`
+	// The content of the block is keyed by its type
+	Content map[string]json.RawMessage `json:"-"`
+}
+
+func (b *notionBlock) UnmarshalJSON(data []byte) error {
+	type plain notionBlock
+	if err := json.Unmarshal(data, (*plain)(b)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &b.Content)
+}
+
+// text returns the text of a block.
+func (b notionBlock) text() string {
+	var content struct {
+		RichText []notionRichText `json:"rich_text"`
+		Title    string           `json:"title"`
+	}
+	if raw, ok := b.Content[b.Type]; ok {
+		_ = json.Unmarshal(raw, &content)
+	}
+	if content.Title != "" {
+		return content.Title
+	}
+	return richText(content.RichText)
+}
+
+type notionList[T any] struct {
+	Results    []T    `json:"results"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// Documents lists the pages the integration was shared with.
+func (n *notion) Documents(ctx context.Context, fn func(Document) error) error {
+	cursor := ""
+	for {
+		search := map[string]any{
+			"filter":    map[string]string{"property": "object", "value": "page"},
+			"page_size": 100,
+		}
+		if cursor != "" {
+			search["start_cursor"] = cursor
+		}
+
+		var pages notionList[notionPage]
+		if err := n.do(ctx, http.MethodPost, "/search", search, &pages); err != nil {
+			return fmt.Errorf("failed to search pages: %w", err)
+		}
+
+		for _, page := range pages.Results {
+			var lines []string
+			if err := n.readBlocks(ctx, page.ID, 0, &lines); err != nil {
+				return fmt.Errorf("failed to read page %s: %w", page.ID, err)
+			}
+			doc := Document{
+				URL:     page.URL,
+				Title:   page.title(),
+				Content: strings.Join(lines, "\n"),
+			}
+			if err := fn(doc); err != nil {
+				return err
+			}
+		}
+
+		if !pages.HasMore || pages.NextCursor == "" {
+			return nil
+		}
+		cursor = pages.NextCursor
+	}
+}
+
+// readBlocks appends the text of a block's children to lines. Child pages
+// are skipped as they are documents of their own.
+func (n *notion) readBlocks(ctx context.Context, blockID string, depth int, lines *[]string) error {
+	cursor := ""
+	for {
+		query := url.Values{"page_size": {"100"}}
+		if cursor != "" {
+			query.Set("start_cursor", cursor)
+		}
+
+		var blocks notionList[notionBlock]
+		path := "/blocks/" + url.PathEscape(blockID) + "/children?" + query.Encode()
+		if err := n.do(ctx, http.MethodGet, path, nil, &blocks); err != nil {
+			return err
+		}
+
+		for _, block := range blocks.Results {
+			if block.Type == "child_page" || block.Type == "child_database" {
+				continue
+			}
+			if text := block.text(); text != "" {
+				*lines = append(*lines, text)
+			}
+			if block.HasChildren && depth < notionMaxDepth {
+				if err := n.readBlocks(ctx, block.ID, depth+1, lines); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !blocks.HasMore || blocks.NextCursor == "" {
+			return nil
+		}
+		cursor = blocks.NextCursor
+	}
+}
+
+func (n *notion) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, notionAPIURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+n.token)
+	req.Header.Set("Notion-Version", notionVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func richText(parts []notionRichText) string {
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteString(part.PlainText)
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,111 @@
+package contentprocessor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BoilerplateDetector finds text blocks that recur on many pages of a site,
+// such as navigation and footers readability didn't remove, and strips them
+// from the content of pages. Blocks are the lines of the extracted text.
+//
+// Until minPages pages were seen, only the blocks known from the previous
+// crawl are stripped. It is safe for concurrent use.
+type BoilerplateDetector struct {
+	mu        sync.Mutex
+	minPages  int
+	threshold float64
+	pages     int
+	counts    map[string]int
+	known     map[string]bool
+}
+
+// NewBoilerplateDetector creates a detector treating blocks found on more
+// than threshold of the pages, a fraction between 0 and 1, as boilerplate.
+// known are the block hashes detected by a previous crawl of the site.
+func NewBoilerplateDetector(minPages int, threshold float64, known []string) *BoilerplateDetector {
+	d := &BoilerplateDetector{
+		minPages:  minPages,
+		threshold: threshold,
+		counts:    make(map[string]int),
+		known:     make(map[string]bool, len(known)),
+	}
+	for _, hash := range known {
+		d.known[hash] = true
+	}
+	return d
+}
+
+// Strip records the blocks of a page's extracted text and returns the text
+// without its boilerplate blocks.
+func (d *BoilerplateDetector) Strip(content string) string {
+	lines := strings.Split(content, "\n")
+	hashes := make([]string, len(lines))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pages++
+	seen := make(map[string]bool, len(lines))
+	for i, line := range lines {
+		hashes[i] = blockHash(line)
+		if hashes[i] != "" && !seen[hashes[i]] {
+			seen[hashes[i]] = true
+			d.counts[hashes[i]]++
+		}
+	}
+
+	kept := lines[:0]
+	for i, line := range lines {
+		if hashes[i] == "" || !d.isBoilerplate(hashes[i]) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// isBoilerplate reports whether a block is boilerplate. d.mu must be held.
+func (d *BoilerplateDetector) isBoilerplate(hash string) bool {
+	if !d.warm() {
+		return d.known[hash]
+	}
+	return float64(d.counts[hash]) > d.threshold*float64(d.pages)
+}
+
+func (d *BoilerplateDetector) warm() bool {
+	return d.pages >= d.minPages
+}
+
+// Blocks returns the hashes of the blocks detected as boilerplate, to seed
+// the next crawl, and whether enough pages were seen to detect them.
+func (d *BoilerplateDetector) Blocks() ([]string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.warm() {
+		return nil, false
+	}
+
+	hashes := []string{}
+	for hash := range d.counts {
+		if d.isBoilerplate(hash) {
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Strings(hashes)
+	return hashes, true
+}
+
+// blockHash identifies a block regardless of case and spacing. Blank blocks
+// have no hash.
+func blockHash(block string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(block), " "))
+	if normalized == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:12])
+}
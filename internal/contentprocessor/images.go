@@ -0,0 +1,162 @@
+package contentprocessor
+
+import (
+	"net/url"
+	"strings"
+
+	"hermit/internal/schema"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxImageContextLength caps the surrounding text stored with an image.
+const maxImageContextLength = 300
+
+// maxPageImages caps the number of images extracted from a page.
+const maxPageImages = 50
+
+// ExtractImages returns the images of the main content of a page, as
+// rendered by ExtractMainContent, that have alt text or a caption. Relative
+// sources are resolved against pageURL.
+func ExtractImages(contentHTML, pageURL string) []schema.PageImage {
+	if contentHTML == "" {
+		return nil
+	}
+	root, err := html.Parse(strings.NewReader(contentHTML))
+	if err != nil {
+		return nil
+	}
+	base, _ := url.Parse(pageURL)
+
+	var images []schema.PageImage
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if len(images) >= maxPageImages {
+			return
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.Img {
+			if image, ok := extractImage(n, base); ok {
+				images = append(images, image)
+			}
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return images
+}
+
+// extractImage describes an img element. It reports false for images with
+// neither alt text nor a caption, such as spacers and decorative icons.
+func extractImage(img *html.Node, base *url.URL) (schema.PageImage, bool) {
+	image := schema.PageImage{
+		Src: attr(img, "src"),
+		Alt: normalizeSpace(attr(img, "alt")),
+	}
+	if base != nil && image.Src != "" {
+		if ref, err := url.Parse(image.Src); err == nil {
+			image.Src = base.ResolveReference(ref).String()
+		}
+	}
+
+	for n := img.Parent; n != nil; n = n.Parent {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if n.DataAtom == atom.Figure {
+			if caption := findChild(n, atom.Figcaption); caption != nil {
+				image.Caption = normalizeSpace(nodeText(caption))
+			}
+			continue
+		}
+		if isBlock(n.DataAtom) {
+			image.Context = Snippet(normalizeSpace(nodeText(n)), maxImageContextLength)
+			break
+		}
+	}
+
+	if image.Alt == "" && image.Caption == "" {
+		return schema.PageImage{}, false
+	}
+	return image, true
+}
+
+// ImagesText renders the alt text and captions of images as text to index
+// along with a page's content.
+func ImagesText(images []schema.PageImage) string {
+	var b strings.Builder
+	for _, image := range images {
+		parts := make([]string, 0, 2)
+		if image.Alt != "" {
+			parts = append(parts, image.Alt)
+		}
+		if image.Caption != "" && image.Caption != image.Alt {
+			parts = append(parts, image.Caption)
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("Image: ")
+		b.WriteString(strings.Join(parts, " - "))
+		if image.Context != "" {
+			b.WriteString(" (")
+			b.WriteString(image.Context)
+			b.WriteString(")")
+		}
+	}
+	return b.String()
+}
+
+// isBlock reports whether an element holds the paragraph around an image.
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Li, atom.Td, atom.Blockquote, atom.Section, atom.Article, atom.Div:
+		return true
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func findChild(n *html.Node, a atom.Atom) *html.Node {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == a {
+			return child
+		}
+		if found := findChild(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteString(" ")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
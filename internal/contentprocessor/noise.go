@@ -0,0 +1,96 @@
+package contentprocessor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// NoiseFilter removes text blocks made up only of noise phrases, such as a
+// "Read more" link or a "Privacy policy | Terms of service" footer, from the
+// content of pages. Blocks are the lines of the extracted text; blocks with
+// any other text are kept whole, so body text mentioning a phrase is never
+// changed.
+type NoiseFilter struct {
+	patterns []*regexp.Regexp
+}
+
+// NewNoiseFilter creates a filter for the patterns, nil when there are none.
+func NewNoiseFilter(patterns []*regexp.Regexp) *NoiseFilter {
+	if len(patterns) == 0 {
+		return nil
+	}
+	return &NoiseFilter{patterns: patterns}
+}
+
+// ParseNoisePatterns parses noise patterns. Patterns are phrases matched case
+// insensitively on word boundaries, or regular expressions when wrapped in
+// slashes, e.g. "/^share on \w+$/".
+func ParseNoisePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	parsed := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		expr := `(?i)\b` + regexp.QuoteMeta(pattern) + `\b`
+		if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			expr = `(?i)` + pattern[1:len(pattern)-1]
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid noise pattern %q: %w", pattern, err)
+		}
+		parsed = append(parsed, re)
+	}
+	return parsed, nil
+}
+
+// SplitNoisePatterns splits a semicolon separated list of noise patterns.
+func SplitNoisePatterns(list string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ";") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// Strip returns the text without its noise blocks. A nil filter returns the
+// text as is.
+func (f *NoiseFilter) Strip(content string) string {
+	if f == nil {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !f.isNoise(line) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// isNoise reports whether a block has matches of the patterns and nothing
+// but punctuation and separators besides them.
+func (f *NoiseFilter) isNoise(block string) bool {
+	rest := block
+	matched := false
+	for _, pattern := range f.patterns {
+		if pattern.MatchString(rest) {
+			matched = true
+			rest = pattern.ReplaceAllString(rest, "")
+		}
+	}
+	if !matched {
+		return false
+	}
+	return strings.IndexFunc(rest, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) < 0
+}
@@ -0,0 +1,100 @@
+package contentprocessor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"hermit/internal/schema"
+)
+
+// Built-in kinds of personal data redacted from page content.
+const (
+	PIIEmail = "email"
+	PIIPhone = "phone"
+	PIISSN   = "ssn"
+)
+
+// piiPattern matches one kind of personal data.
+type piiPattern struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// builtinPIIPatterns match emails, phone numbers and US social security
+// numbers.
+var builtinPIIPatterns = []piiPattern{
+	{PIIEmail, regexp.MustCompile(`(?i)\b[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}\b`)},
+	{PIISSN, regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	{PIIPhone, regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{2,4}\)[\s.\-]?|\b\d{2,4}[\s.\-])\d{3,4}[\s.\-]\d{4}\b`)},
+}
+
+// PIIRedactor replaces personal data in page content with placeholders such
+// as [REDACTED:email] before it is stored and vectorized.
+type PIIRedactor struct {
+	patterns []piiPattern
+}
+
+// NewPIIRedactor creates a redactor for the built-in kinds of personal data
+// and the custom patterns, keyed by the kind they are reported as.
+func NewPIIRedactor(custom map[string]*regexp.Regexp) *PIIRedactor {
+	patterns := append([]piiPattern{}, builtinPIIPatterns...)
+	for kind, pattern := range custom {
+		patterns = append(patterns, piiPattern{kind: kind, pattern: pattern})
+	}
+	return &PIIRedactor{patterns: patterns}
+}
+
+// ParsePIIPatterns parses custom PII patterns from a semicolon separated list
+// of kind=regexp entries, e.g. "employee_id=EMP-\d{6};badge=B\d{5}".
+func ParsePIIPatterns(list string) (map[string]*regexp.Regexp, error) {
+	patterns := make(map[string]*regexp.Regexp)
+	for _, entry := range strings.Split(list, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kind, expr, ok := strings.Cut(entry, "=")
+		kind = strings.TrimSpace(kind)
+		if !ok || kind == "" || expr == "" {
+			return nil, fmt.Errorf("invalid PII pattern %q, expected kind=regexp", entry)
+		}
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PII pattern %q: %w", kind, err)
+		}
+		patterns[kind] = pattern
+	}
+	return patterns, nil
+}
+
+// Redact replaces the personal data in text and adds the number of
+// replacements of each kind to counts.
+func (r *PIIRedactor) Redact(text string, counts map[string]int) string {
+	for _, p := range r.patterns {
+		text = p.pattern.ReplaceAllStringFunc(text, func(string) string {
+			counts[p.kind]++
+			return "[REDACTED:" + p.kind + "]"
+		})
+	}
+	return text
+}
+
+// RedactPage redacts the title, text and image descriptions of a page and
+// returns them with the number of replacements of each kind.
+func (r *PIIRedactor) RedactPage(title, text string, images []schema.PageImage) (string, string, []schema.PageImage, map[string]int) {
+	counts := make(map[string]int)
+	title = r.Redact(title, counts)
+	text = r.Redact(text, counts)
+
+	redacted := make([]schema.PageImage, len(images))
+	for i, image := range images {
+		image.Alt = r.Redact(image.Alt, counts)
+		image.Caption = r.Redact(image.Caption, counts)
+		image.Context = r.Redact(image.Context, counts)
+		redacted[i] = image
+	}
+
+	return title, text, redacted, counts
+}
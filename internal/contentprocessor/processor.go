@@ -6,19 +6,24 @@ import (
 	"net/url"
 	"strings"
 
+	"hermit/internal/schema"
+
 	readability "codeberg.org/readeck/go-readability/v2"
 	"go.uber.org/zap"
 )
 
 // ContentProcessor handles HTML content cleaning and text extraction.
 type ContentProcessor struct {
-	logger *zap.Logger
+	logger  *zap.Logger
+	weights QualityWeights
 }
 
-// NewContentProcessor creates a new ContentProcessor.
-func NewContentProcessor(logger *zap.Logger) *ContentProcessor {
+// NewContentProcessor creates a new ContentProcessor scoring content quality
+// with the given weights.
+func NewContentProcessor(logger *zap.Logger, weights QualityWeights) *ContentProcessor {
 	return &ContentProcessor{
-		logger: logger,
+		logger:  logger,
+		weights: weights,
 	}
 }
 
@@ -28,10 +33,14 @@ type ProcessedContent struct {
 	Content     string
 	Excerpt     string
 	Byline      string
+	Language    string
 	Length      int
 	Quality     float64
+	Features    QualityFeatures
 	IsReadable  bool
 	CleanedHTML string
+	// Images of the main content with alt text or a caption
+	Images []schema.PageImage
 }
 
 // ExtractMainContent extracts the main content from HTML, removing navigation, ads, etc.
@@ -89,19 +98,23 @@ func (p *ContentProcessor) ExtractMainContent(htmlContent string, pageURL string
 		)
 	}
 
-	// Calculate quality score (simple heuristic)
-	length := len(textContent)
-	quality := p.calculateQualityScore(textContent, length)
+	// Calculate quality score from the content and how much of the page
+	// was boilerplate
+	features := qualityFeatures(textContent, p.fallbackExtraction(htmlContent), htmlBuf.String())
+	quality := p.weights.Score(features)
 
 	processed := &ProcessedContent{
 		Title:       article.Title(),
 		Content:     textContent,
 		Excerpt:     article.Excerpt(),
 		Byline:      article.Byline(),
-		Length:      length,
+		Language:    article.Language(),
+		Length:      features.Length,
 		Quality:     quality,
+		Features:    features,
 		IsReadable:  quality >= 0.3,
 		CleanedHTML: htmlBuf.String(),
+		Images:      ExtractImages(htmlBuf.String(), pageURL),
 	}
 
 	p.logger.Debug("Content processed",
@@ -109,6 +122,8 @@ func (p *ContentProcessor) ExtractMainContent(htmlContent string, pageURL string
 		zap.String("title", processed.Title),
 		zap.Int("length", processed.Length),
 		zap.Float64("quality", processed.Quality),
+		zap.Float64("boilerplateRatio", features.BoilerplateRatio),
+		zap.Float64("linkDensity", features.LinkDensity),
 		zap.Bool("readable", processed.IsReadable),
 	)
 
@@ -123,77 +138,9 @@ func (p *ContentProcessor) CleanText(text string) string {
 	// Replace multiple spaces with single space
 	text = strings.Join(strings.Fields(text), " ")
 
-	// Remove common noise patterns
-	text = p.removeNoisePatterns(text)
-
 	return text
 }
 
-// calculateQualityScore calculates a simple quality score for the content.
-func (p *ContentProcessor) calculateQualityScore(content string, length int) float64 {
-	if length == 0 {
-		return 0.0
-	}
-
-	score := 0.0
-
-	// Length score (prefer 500-5000 chars)
-	if length >= 500 && length <= 5000 {
-		score += 0.4
-	} else if length > 5000 {
-		score += 0.3
-	} else if length > 200 {
-		score += 0.2
-	}
-
-	// Word count score
-	words := strings.Fields(content)
-	wordCount := len(words)
-	if wordCount > 100 {
-		score += 0.3
-	} else if wordCount > 50 {
-		score += 0.2
-	}
-
-	// Sentence structure score (rough estimate)
-	sentences := strings.Count(content, ".") + strings.Count(content, "!") + strings.Count(content, "?")
-	if sentences > 5 {
-		score += 0.2
-	}
-
-	// Avoid score > 1.0
-	if score > 1.0 {
-		score = 1.0
-	}
-
-	return score
-}
-
-// removeNoisePatterns removes common noise patterns from text.
-func (p *ContentProcessor) removeNoisePatterns(text string) string {
-	// Common noise patterns
-	noisePatterns := []string{
-		"Click here",
-		"Read more",
-		"Subscribe now",
-		"Sign up",
-		"Advertisement",
-		"Cookie policy",
-		"Privacy policy",
-		"Terms of service",
-	}
-
-	cleaned := text
-	for _, pattern := range noisePatterns {
-		// Case-insensitive replacement
-		cleaned = strings.ReplaceAll(cleaned, pattern, "")
-		cleaned = strings.ReplaceAll(cleaned, strings.ToLower(pattern), "")
-		cleaned = strings.ReplaceAll(cleaned, strings.ToUpper(pattern), "")
-	}
-
-	return cleaned
-}
-
 // fallbackExtraction provides a basic fallback if readability fails.
 func (p *ContentProcessor) fallbackExtraction(htmlContent string) string {
 	// Very basic HTML tag removal as fallback
@@ -319,3 +266,19 @@ func (p *ContentProcessor) ExtractMetadata(htmlContent string) map[string]string
 
 	return metadata
 }
+
+// Snippet returns the leading text of cleaned content, at most maxLen
+// characters long and cut at a word boundary where possible.
+func Snippet(text string, maxLen int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLen {
+		return text
+	}
+
+	cut := string(runes[:maxLen])
+	if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+
+	return strings.TrimSpace(cut)
+}
@@ -0,0 +1,146 @@
+package contentprocessor
+
+import (
+	"context"
+	"strings"
+
+	"hermit/internal/config"
+
+	"golang.org/x/net/html"
+)
+
+// QualityWeights weigh the features of a page's quality score. Each feature
+// scores between 0 and 1; the quality is their weighted sum, capped at 1.
+type QualityWeights struct {
+	Length    float64
+	Words     float64
+	Sentences float64
+	// Boilerplate rewards pages whose text is mostly main content rather
+	// than navigation, footers and other boilerplate
+	Boilerplate float64
+	// LinkDensity rewards main content that isn't mostly link text, such as
+	// index and tag pages
+	LinkDensity float64
+}
+
+// QualityWeightsFromConfig returns the quality weights set in the config.
+func QualityWeightsFromConfig(cfg *config.Config) QualityWeights {
+	return QualityWeights{
+		Length:      cfg.ContentQualityWeightLength,
+		Words:       cfg.ContentQualityWeightWords,
+		Sentences:   cfg.ContentQualityWeightSentences,
+		Boilerplate: cfg.ContentQualityWeightBoilerplate,
+		LinkDensity: cfg.ContentQualityWeightLinkDensity,
+	}
+}
+
+// QualityFeatures are the measurements a page's quality score is computed
+// from.
+type QualityFeatures struct {
+	Length    int
+	Words     int
+	Sentences int
+	// BoilerplateRatio is the share of the page's words removed with the
+	// boilerplate around the main content
+	BoilerplateRatio float64
+	// LinkDensity is the share of the main content's text inside links
+	LinkDensity float64
+}
+
+// Score returns the quality score of a page with the given features.
+func (w QualityWeights) Score(f QualityFeatures) float64 {
+	if f.Length == 0 {
+		return 0.0
+	}
+
+	// Length score (prefer 500-5000 chars)
+	lengthScore := 0.0
+	if f.Length >= 500 && f.Length <= 5000 {
+		lengthScore = 1.0
+	} else if f.Length > 5000 {
+		lengthScore = 0.75
+	} else if f.Length > 200 {
+		lengthScore = 0.5
+	}
+
+	wordScore := 0.0
+	if f.Words > 100 {
+		wordScore = 1.0
+	} else if f.Words > 50 {
+		wordScore = 2.0 / 3.0
+	}
+
+	sentenceScore := 0.0
+	if f.Sentences > 5 {
+		sentenceScore = 1.0
+	}
+
+	score := w.Length*lengthScore +
+		w.Words*wordScore +
+		w.Sentences*sentenceScore +
+		w.Boilerplate*(1-f.BoilerplateRatio) +
+		w.LinkDensity*(1-f.LinkDensity)
+
+	// Avoid score > 1.0
+	if score > 1.0 {
+		score = 1.0
+	}
+
+	return score
+}
+
+// qualityFeatures measures the main content of a page. pageText is the text
+// of the whole page and contentHTML the HTML of the main content.
+func qualityFeatures(content, pageText, contentHTML string) QualityFeatures {
+	words := len(strings.Fields(content))
+	features := QualityFeatures{
+		Length:    len(content),
+		Words:     words,
+		Sentences: strings.Count(content, ".") + strings.Count(content, "!") + strings.Count(content, "?"),
+	}
+
+	if pageWords := len(strings.Fields(pageText)); pageWords > words {
+		features.BoilerplateRatio = 1 - float64(words)/float64(pageWords)
+	}
+	features.LinkDensity = linkDensity(contentHTML)
+
+	return features
+}
+
+// linkDensity returns the share of the text of an HTML fragment that is
+// inside links.
+func linkDensity(fragment string) float64 {
+	tokenizer := html.NewTokenizer(strings.NewReader(fragment))
+	depth := 0
+	total, linked := 0, 0
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if total == 0 {
+				return 0
+			}
+			return float64(linked) / float64(total)
+		case html.StartTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "a" {
+				depth++
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "a" && depth > 0 {
+				depth--
+			}
+		case html.TextToken:
+			n := len(strings.TrimSpace(string(tokenizer.Text())))
+			total += n
+			if depth > 0 {
+				linked += n
+			}
+		}
+	}
+}
+
+// PageClassifier decides whether the content of a page is useful to answer
+// questions from, e.g. with an LLM. It complements the heuristic score for
+// pages that pass it.
+type PageClassifier interface {
+	IsUseful(ctx context.Context, pageURL, title, content string) (bool, error)
+}
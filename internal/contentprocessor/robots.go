@@ -3,7 +3,6 @@ package contentprocessor
 import (
 	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -17,12 +16,26 @@ import (
 // RobotsEnforcer handles robots.txt parsing and enforcement.
 type RobotsEnforcer struct {
 	logger      *zap.Logger
-	cache       map[string]*robotsCacheEntry
-	cacheMutex  sync.RWMutex
+	cache       *robotsCache
 	userAgent   string
+	headers     http.Header
 	httpTimeout time.Duration
 }
 
+// robotsCache holds the robots.txt of domains per user agent, shared by the
+// enforcers of all user agents.
+type robotsCache struct {
+	mu      sync.RWMutex
+	entries map[robotsCacheKey]*robotsCacheEntry
+}
+
+// robotsCacheKey identifies the robots.txt of a domain fetched with a user
+// agent, since sites may serve different rules to different agents.
+type robotsCacheKey struct {
+	domain    string
+	userAgent string
+}
+
 // robotsCacheEntry represents a cached robots.txt entry.
 type robotsCacheEntry struct {
 	data      *robotstxt.RobotsData
@@ -33,12 +46,42 @@ type robotsCacheEntry struct {
 func NewRobotsEnforcer(userAgent string, logger *zap.Logger) *RobotsEnforcer {
 	return &RobotsEnforcer{
 		logger:      logger,
-		cache:       make(map[string]*robotsCacheEntry),
+		cache:       &robotsCache{entries: make(map[robotsCacheKey]*robotsCacheEntry)},
 		userAgent:   userAgent,
 		httpTimeout: 10 * time.Second,
 	}
 }
 
+// ForAgent returns an enforcer that fetches robots.txt and sitemaps with the
+// given user agent and extra headers and matches the robots.txt rules for
+// that user agent. An empty user agent keeps the default one. The returned
+// enforcer shares the cache of r.
+func (r *RobotsEnforcer) ForAgent(userAgent string, headers http.Header) *RobotsEnforcer {
+	if userAgent == "" {
+		userAgent = r.userAgent
+	}
+	return &RobotsEnforcer{
+		logger:      r.logger,
+		cache:       r.cache,
+		userAgent:   userAgent,
+		headers:     headers,
+		httpTimeout: r.httpTimeout,
+	}
+}
+
+// newRequest creates a GET request with the enforcer's user agent and headers.
+func (r *RobotsEnforcer) newRequest(ctx context.Context, rawURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range r.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("User-Agent", r.userAgent)
+	return req, nil
+}
+
 // CanFetch checks if the given URL can be crawled according to robots.txt.
 func (r *RobotsEnforcer) CanFetch(ctx context.Context, pageURL string) (bool, error) {
 	parsedURL, err := url.Parse(pageURL)
@@ -107,11 +150,12 @@ func (r *RobotsEnforcer) GetCrawlDelay(ctx context.Context, pageURL string) (tim
 // getRobotsData fetches and parses robots.txt for a domain.
 func (r *RobotsEnforcer) getRobotsData(ctx context.Context, pageURL *url.URL) (*robotstxt.RobotsData, error) {
 	domain := pageURL.Scheme + "://" + pageURL.Host
+	key := robotsCacheKey{domain: domain, userAgent: r.userAgent}
 
 	// Check cache first
-	r.cacheMutex.RLock()
-	entry, exists := r.cache[domain]
-	r.cacheMutex.RUnlock()
+	r.cache.mu.RLock()
+	entry, exists := r.cache.entries[key]
+	r.cache.mu.RUnlock()
 
 	if exists && time.Now().Before(entry.expiresAt) {
 		r.logger.Debug("Using cached robots.txt",
@@ -126,13 +170,11 @@ func (r *RobotsEnforcer) getRobotsData(ctx context.Context, pageURL *url.URL) (*
 		zap.String("url", robotsURL),
 	)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
+	req, err := r.newRequest(ctx, robotsURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("User-Agent", r.userAgent)
-
 	client := &http.Client{
 		Timeout: r.httpTimeout,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
@@ -173,26 +215,26 @@ func (r *RobotsEnforcer) getRobotsData(ctx context.Context, pageURL *url.URL) (*
 	}
 
 	// Cache the result (cache for 24 hours)
-	r.cacheMutex.Lock()
-	r.cache[domain] = &robotsCacheEntry{
+	r.cache.mu.Lock()
+	r.cache.entries[key] = &robotsCacheEntry{
 		data:      robotsData,
 		expiresAt: time.Now().Add(24 * time.Hour),
 	}
-	r.cacheMutex.Unlock()
+	r.cache.mu.Unlock()
 
 	return robotsData, nil
 }
 
 // ClearCache clears the robots.txt cache.
 func (r *RobotsEnforcer) ClearCache() {
-	r.cacheMutex.Lock()
-	defer r.cacheMutex.Unlock()
+	r.cache.mu.Lock()
+	defer r.cache.mu.Unlock()
 
-	r.cache = make(map[string]*robotsCacheEntry)
+	r.cache.entries = make(map[robotsCacheKey]*robotsCacheEntry)
 	r.logger.Info("Robots.txt cache cleared")
 }
 
-// ClearDomainCache clears the cache for a specific domain.
+// ClearDomainCache clears the cache for a specific domain and all user agents.
 func (r *RobotsEnforcer) ClearDomainCache(pageURL string) error {
 	parsedURL, err := url.Parse(pageURL)
 	if err != nil {
@@ -201,10 +243,14 @@ func (r *RobotsEnforcer) ClearDomainCache(pageURL string) error {
 
 	domain := parsedURL.Scheme + "://" + parsedURL.Host
 
-	r.cacheMutex.Lock()
-	defer r.cacheMutex.Unlock()
+	r.cache.mu.Lock()
+	defer r.cache.mu.Unlock()
 
-	delete(r.cache, domain)
+	for key := range r.cache.entries {
+		if key.domain == domain {
+			delete(r.cache.entries, key)
+		}
+	}
 	r.logger.Debug("Cleared robots.txt cache for domain",
 		zap.String("domain", domain),
 	)
@@ -253,72 +299,3 @@ func NormalizeURL(rawURL string) (string, error) {
 
 	return parsedURL.String(), nil
 }
-
-// GetSitemapURLs extracts URLs from a sitemap.xml.
-func (r *RobotsEnforcer) GetSitemapURLs(ctx context.Context, sitemapURL string) ([]string, error) {
-	r.logger.Info("Fetching sitemap",
-		zap.String("url", sitemapURL),
-	)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", sitemapURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("User-Agent", r.userAgent)
-
-	client := &http.Client{
-		Timeout: r.httpTimeout,
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("sitemap returned status %d", resp.StatusCode)
-	}
-
-	// Parse sitemap XML (basic implementation)
-	// For production, use encoding/xml for proper parsing
-	var urls []string
-
-	// This is a simplified parser - in production use proper XML parsing
-	buf := new(strings.Builder)
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read sitemap: %w", err)
-	}
-	buf.Write(body)
-
-	content := buf.String()
-
-	// Extract URLs from <loc> tags
-	for {
-		start := strings.Index(content, "<loc>")
-		if start == -1 {
-			break
-		}
-		start += 5
-		end := strings.Index(content[start:], "</loc>")
-		if end == -1 {
-			break
-		}
-
-		urlStr := strings.TrimSpace(content[start : start+end])
-		if urlStr != "" {
-			urls = append(urls, urlStr)
-		}
-
-		content = content[start+end+6:]
-	}
-
-	r.logger.Info("Parsed sitemap",
-		zap.String("url", sitemapURL),
-		zap.Int("urlCount", len(urls)),
-	)
-
-	return urls, nil
-}
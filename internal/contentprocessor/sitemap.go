@@ -0,0 +1,238 @@
+package contentprocessor
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// commonSitemapPaths are probed when robots.txt doesn't list any sitemap.
+var commonSitemapPaths = []string{
+	"/sitemap.xml",
+	"/sitemap_index.xml",
+	"/sitemap-index.xml",
+	"/wp-sitemap.xml",
+}
+
+const (
+	// maxNestedSitemaps limits how many sitemaps listed by sitemap indexes
+	// are fetched.
+	maxNestedSitemaps = 50
+	// maxSitemapDepth limits how deep nested sitemap indexes are followed.
+	maxSitemapDepth = 3
+	// maxSitemapBytes is the size limit of an uncompressed sitemap set by
+	// the sitemaps.org protocol.
+	maxSitemapBytes = 50 << 20
+)
+
+// DiscoverSitemaps finds the sitemaps of a website from the Sitemap directives
+// in its robots.txt, falling back to probing common sitemap locations. Only
+// sitemaps on the website's host are returned.
+func (r *RobotsEnforcer) DiscoverSitemaps(ctx context.Context, siteURL string) ([]string, error) {
+	parsedURL, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	var sitemaps []string
+	seen := make(map[string]bool)
+
+	robotsData, err := r.getRobotsData(ctx, parsedURL)
+	if err != nil {
+		r.logger.Debug("Failed to fetch robots.txt for sitemap discovery",
+			zap.String("url", siteURL),
+			zap.Error(err),
+		)
+	} else {
+		for _, sitemap := range robotsData.Sitemaps {
+			u, err := url.Parse(strings.TrimSpace(sitemap))
+			if err != nil || !strings.EqualFold(u.Host, parsedURL.Host) || seen[u.String()] {
+				continue
+			}
+			seen[u.String()] = true
+			sitemaps = append(sitemaps, u.String())
+		}
+	}
+
+	if len(sitemaps) == 0 {
+		base := parsedURL.Scheme + "://" + parsedURL.Host
+		for _, path := range commonSitemapPaths {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if r.isSitemap(ctx, base+path) {
+				sitemaps = append(sitemaps, base+path)
+			}
+		}
+	}
+
+	r.logger.Info("Discovered sitemaps",
+		zap.String("url", siteURL),
+		zap.Strings("sitemaps", sitemaps),
+	)
+
+	return sitemaps, nil
+}
+
+// isSitemap reports whether a URL serves an XML sitemap.
+func (r *RobotsEnforcer) isSitemap(ctx context.Context, sitemapURL string) bool {
+	req, err := r.newRequest(ctx, sitemapURL)
+	if err != nil {
+		return false
+	}
+
+	client := &http.Client{Timeout: r.httpTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+
+	// Sites often answer unknown paths with an HTML page, so check the body
+	head := make([]byte, 512)
+	n, _ := resp.Body.Read(head)
+	body := string(head[:n])
+	return strings.Contains(body, "<urlset") || strings.Contains(body, "<sitemapindex")
+}
+
+// GetSitemapEntries returns the pages listed in the given sitemaps, following
+// nested sitemap indexes up to maxSitemapDepth levels deep. Pages listed more
+// than once are returned once. At most limit entries are returned when limit
+// is positive.
+func (r *RobotsEnforcer) GetSitemapEntries(ctx context.Context, sitemaps []string, limit int) []SitemapEntry {
+	var entries []SitemapEntry
+	seen := make(map[string]bool)
+	fetched := make(map[string]bool)
+	nested := 0
+
+	var collect func(sitemapURL string, depth int)
+	collect = func(sitemapURL string, depth int) {
+		if fetched[sitemapURL] || ctx.Err() != nil {
+			return
+		}
+		fetched[sitemapURL] = true
+
+		sitemap, err := r.GetSitemap(ctx, sitemapURL)
+		if err != nil {
+			r.logger.Warn("Failed to read sitemap", zap.String("url", sitemapURL), zap.Error(err))
+			return
+		}
+
+		for _, entry := range sitemap.Entries {
+			if limit > 0 && len(entries) >= limit {
+				return
+			}
+			if !seen[entry.URL] {
+				seen[entry.URL] = true
+				entries = append(entries, entry)
+			}
+		}
+
+		if depth >= maxSitemapDepth {
+			return
+		}
+		for _, child := range sitemap.Sitemaps {
+			if limit > 0 && len(entries) >= limit {
+				return
+			}
+			if nested >= maxNestedSitemaps {
+				r.logger.Warn("Too many nested sitemaps, ignoring the rest",
+					zap.String("url", sitemapURL),
+					zap.Int("limit", maxNestedSitemaps),
+				)
+				return
+			}
+			if !fetched[child] {
+				nested++
+				collect(child, depth+1)
+			}
+		}
+	}
+
+	for _, sitemap := range sitemaps {
+		collect(sitemap, 0)
+	}
+
+	return entries
+}
+
+// GetSitemap fetches and parses a sitemap or sitemap index, gzipped or not.
+func (r *RobotsEnforcer) GetSitemap(ctx context.Context, sitemapURL string) (*Sitemap, error) {
+	r.logger.Info("Fetching sitemap",
+		zap.String("url", sitemapURL),
+	)
+
+	req, err := r.newRequest(ctx, sitemapURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client := &http.Client{
+		Timeout: r.httpTimeout,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sitemap returned status %d", resp.StatusCode)
+	}
+
+	body, err := readSitemapBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	sitemap, err := ParseSitemap(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.Info("Parsed sitemap",
+		zap.String("url", sitemapURL),
+		zap.Int("urlCount", len(sitemap.Entries)),
+		zap.Int("sitemapCount", len(sitemap.Sitemaps)),
+	)
+
+	return sitemap, nil
+}
+
+// readSitemapBody reads a sitemap response, decompressing it when gzipped.
+// Gzipped sitemaps are recognized by their content rather than the .gz
+// extension or headers, as servers label them inconsistently.
+func readSitemapBody(body io.Reader) ([]byte, error) {
+	buffered := bufio.NewReader(body)
+	reader := io.Reader(buffered)
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress sitemap: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxSitemapBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sitemap: %w", err)
+	}
+	if len(data) > maxSitemapBytes {
+		return nil, fmt.Errorf("sitemap exceeds %d bytes", maxSitemapBytes)
+	}
+	return data, nil
+}
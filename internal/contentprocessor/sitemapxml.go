@@ -0,0 +1,184 @@
+package contentprocessor
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// maxSitemapEntries is the number of URLs a sitemap may list under the
+// sitemaps.org protocol; further URLs are ignored.
+const maxSitemapEntries = 50000
+
+// Sitemap is a parsed sitemap. A urlset lists pages in Entries, a sitemap
+// index lists other sitemaps in Sitemaps.
+type Sitemap struct {
+	Entries  []SitemapEntry
+	Sitemaps []string
+}
+
+// SitemapEntry is a page listed in a sitemap.
+type SitemapEntry struct {
+	URL string
+	// LastMod is when the page last changed, zero when not given
+	LastMod time.Time
+	// ChangeFreq is one of always, hourly, daily, weekly, monthly, yearly
+	// and never, empty when not given
+	ChangeFreq string
+	// Priority of the page relative to the site's other pages, between 0
+	// and 1 and 0.5 when not given
+	Priority float64
+}
+
+// sitemapChangeFreqs are the changefreq values of the sitemaps.org protocol.
+var sitemapChangeFreqs = map[string]bool{
+	"always":  true,
+	"hourly":  true,
+	"daily":   true,
+	"weekly":  true,
+	"monthly": true,
+	"yearly":  true,
+	"never":   true,
+}
+
+// sitemapTimeLayouts are the W3C datetime formats lastmod values use.
+var sitemapTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// sitemapURLElement is a <url> of a urlset.
+type sitemapURLElement struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+// sitemapElement is a <sitemap> of a sitemap index.
+type sitemapElement struct {
+	Loc string `xml:"loc"`
+}
+
+// ParseSitemap parses a urlset or sitemap index. Entries without a valid
+// absolute http(s) URL are dropped, invalid optional fields are ignored.
+// Sitemaps declaring an encoding other than UTF-8 are decoded from it.
+func ParseSitemap(r io.Reader) (*Sitemap, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+	sitemap := &Sitemap{}
+	root := ""
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sitemap: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if root == "" {
+			root = start.Name.Local
+			if root != "urlset" && root != "sitemapindex" {
+				return nil, fmt.Errorf("not a sitemap: root element is <%s>", root)
+			}
+			continue
+		}
+
+		switch {
+		case root == "urlset" && start.Name.Local == "url":
+			var element sitemapURLElement
+			if err := decoder.DecodeElement(&element, &start); err != nil {
+				return nil, fmt.Errorf("failed to parse sitemap: %w", err)
+			}
+			if len(sitemap.Entries) >= maxSitemapEntries {
+				continue
+			}
+			if entry, ok := newSitemapEntry(element); ok {
+				sitemap.Entries = append(sitemap.Entries, entry)
+			}
+		case root == "sitemapindex" && start.Name.Local == "sitemap":
+			var element sitemapElement
+			if err := decoder.DecodeElement(&element, &start); err != nil {
+				return nil, fmt.Errorf("failed to parse sitemap: %w", err)
+			}
+			if len(sitemap.Sitemaps) >= maxSitemapEntries {
+				continue
+			}
+			if loc, ok := sitemapLoc(element.Loc); ok {
+				sitemap.Sitemaps = append(sitemap.Sitemaps, loc)
+			}
+		default:
+			if err := decoder.Skip(); err != nil {
+				return nil, fmt.Errorf("failed to parse sitemap: %w", err)
+			}
+		}
+	}
+
+	if root == "" {
+		return nil, errors.New("not a sitemap: no root element")
+	}
+	return sitemap, nil
+}
+
+// newSitemapEntry converts a <url> element, reporting whether its location
+// is valid.
+func newSitemapEntry(element sitemapURLElement) (SitemapEntry, bool) {
+	loc, ok := sitemapLoc(element.Loc)
+	if !ok {
+		return SitemapEntry{}, false
+	}
+
+	entry := SitemapEntry{URL: loc, Priority: 0.5}
+	entry.LastMod = parseSitemapTime(element.LastMod)
+	if changeFreq := strings.ToLower(strings.TrimSpace(element.ChangeFreq)); sitemapChangeFreqs[changeFreq] {
+		entry.ChangeFreq = changeFreq
+	}
+	if priority, err := strconv.ParseFloat(strings.TrimSpace(element.Priority), 64); err == nil && priority >= 0 && priority <= 1 {
+		entry.Priority = priority
+	}
+	return entry, true
+}
+
+// sitemapLoc returns the trimmed location of a sitemap entry when it is an
+// absolute http(s) URL.
+func sitemapLoc(loc string) (string, bool) {
+	loc = strings.TrimSpace(loc)
+	u, err := url.Parse(loc)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+	return loc, true
+}
+
+// parseSitemapTime parses a W3C datetime, returning the zero time when it
+// is missing or invalid.
+func parseSitemapTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range sitemapTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,155 @@
+package contentprocessor
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MaxTags is the number of tags extracted from a page at most.
+	MaxTags = 8
+	// MaxTagLength limits the length of a tag.
+	MaxTagLength = 64
+	// minTagScore is the score a term needs to become a tag, so words that
+	// appear once in the body are not tags.
+	minTagScore = 3
+	// titleBoost weighs a term in the title as this many body occurrences.
+	titleBoost = 3
+)
+
+// stopWords are common English words that never make a useful tag.
+var stopWords = toSet(`a about above after again against all also am an and any are
+as at be because been before being below between both but by can could did do does
+doing down during each either else etc even ever every few for from further get gets
+got had has have having he her here hers herself him himself his how however i if in
+into is it its itself just last least less let like made make many may me might more
+most much must my myself near need new next no nor not now of off often on once one
+only or other our ours ourselves out over own per please same see she should since so
+some still such than that the their theirs them themselves then there these they this
+those through to too under until up upon us use used using very via was we well were
+what when where whether which while who whom whose why will with within without would
+yes yet you your yours yourself yourselves read click page pages home menu skip content
+contact privacy policy terms cookie cookies copyright reserved rights http https www com`)
+
+func toSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// ExtractTags returns up to MaxTags topics of a page, most relevant first.
+// Terms are scored by how often they appear in the content, with the title
+// weighing more, and repeated two-word phrases are preferred over their
+// words. Tags are lowercase with words joined by a hyphen, e.g.
+// "machine-learning".
+func ExtractTags(title, content string) []string {
+	scores := make(map[string]int)
+	addTerms(scores, content, 1)
+	addTerms(scores, title, titleBoost)
+
+	type candidate struct {
+		term  string
+		score int
+	}
+	var candidates []candidate
+	for term, score := range scores {
+		// Phrases are more specific, so they win over their words on ties
+		if strings.Contains(term, "-") {
+			score *= 2
+		}
+		if score >= minTagScore {
+			candidates = append(candidates, candidate{term: term, score: score})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].term < candidates[j].term
+	})
+
+	tags := make([]string, 0, MaxTags)
+	covered := make(map[string]bool)
+	for _, c := range candidates {
+		if len(tags) == MaxTags {
+			break
+		}
+		if covered[c.term] {
+			continue
+		}
+		tags = append(tags, c.term)
+		covered[c.term] = true
+		// Skip the words of a chosen phrase
+		for _, word := range strings.Split(c.term, "-") {
+			covered[word] = true
+		}
+	}
+
+	return tags
+}
+
+// NormalizeTag returns tag in the form tags are stored in, so "Machine
+// Learning" finds pages tagged "machine-learning".
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(tag, "-", " "))), "-")
+}
+
+// addTerms adds weight to the score of every word and two-word phrase in text.
+// Phrases never span a stop word or the end of a sentence.
+func addTerms(scores map[string]int, text string, weight int) {
+	previous := ""
+	word := strings.Builder{}
+
+	flush := func(boundary bool) {
+		term := strings.ToLower(word.String())
+		word.Reset()
+
+		if term == "" {
+			if boundary {
+				previous = ""
+			}
+			return
+		}
+		if !isTagWord(term) {
+			previous = ""
+			return
+		}
+		scores[term] += weight
+		if previous != "" {
+			scores[previous+"-"+term] += weight
+		}
+		previous = term
+		if boundary {
+			previous = ""
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		case r == '\'' || r == '’':
+			// Drop possessives and contractions, e.g. "hermit's"
+			flush(true)
+		default:
+			flush(r == '.' || r == '!' || r == '?' || r == ',' || r == ';' || r == ':' || r == '\n')
+		}
+	}
+	flush(true)
+}
+
+// isTagWord reports whether a lowercase word can be part of a tag.
+func isTagWord(word string) bool {
+	if len(word) < 3 || len(word) > MaxTagLength/2 || stopWords[word] {
+		return false
+	}
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package crawler
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"hermit/internal/contentprocessor"
+	"hermit/internal/schema"
+)
+
+// requestProfile is the user agent and extra headers the requests for a
+// website are sent with.
+type requestProfile struct {
+	userAgent string
+	// rotation are user agents used in turn instead of userAgent
+	rotation []string
+	headers  schema.RequestHeaders
+	next     atomic.Uint64
+}
+
+// requestProfile returns the request profile of a website, falling back to
+// CRAWLER_USER_AGENT.
+func (cr *Crawler) requestProfile(website *schema.Website) *requestProfile {
+	profile := &requestProfile{userAgent: cr.config.CrawlerUserAgent}
+	if website == nil {
+		return profile
+	}
+	if website.UserAgent != "" {
+		profile.userAgent = website.UserAgent
+	}
+	profile.rotation = website.UserAgentRotation
+	profile.headers = website.RequestHeaders
+	return profile
+}
+
+// apply sets the extra headers on a request and, when user agents are
+// rotated, the next user agent.
+func (p *requestProfile) apply(header http.Header) {
+	p.headers.Apply(header)
+	if len(p.rotation) > 0 {
+		i := (p.next.Add(1) - 1) % uint64(len(p.rotation))
+		header.Set("User-Agent", p.rotation[i])
+	}
+}
+
+// robots returns an enforcer fetching robots.txt and sitemaps with the
+// profile's user agent and headers. Rules are matched for the website's user
+// agent even when user agents are rotated.
+func (p *requestProfile) robots(enforcer *contentprocessor.RobotsEnforcer) *contentprocessor.RobotsEnforcer {
+	header := http.Header{}
+	p.headers.Apply(header)
+	return enforcer.ForAgent(p.userAgent, header)
+}
@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"hermit/internal/config"
+	"hermit/internal/schema"
+
+	"go.uber.org/zap"
+)
+
+// Crawl budgets that can stop a crawl early.
+const (
+	BudgetPages    = "pages"
+	BudgetBytes    = "bytes"
+	BudgetDuration = "duration"
+)
+
+// crawlBudget tracks how much of its budgets a crawl has used. Website
+// settings override the global defaults, a limit of 0 means unlimited.
+type crawlBudget struct {
+	maxPages     int
+	maxBytes     int64
+	deadline     time.Time
+	maxPerPrefix int
+
+	bytes       int64
+	prefixPages map[string]int
+	exhausted   string
+}
+
+// newCrawlBudget creates the budget for crawling a website.
+func newCrawlBudget(cfg *config.Config, website *schema.Website) *crawlBudget {
+	b := &crawlBudget{
+		maxPages:     cfg.CrawlerMaxPages,
+		maxBytes:     int64(cfg.CrawlerMaxBytes),
+		maxPerPrefix: cfg.CrawlerMaxPagesPerPrefix,
+		prefixPages:  make(map[string]int),
+	}
+	maxDuration := cfg.CrawlerMaxDuration
+
+	if website != nil {
+		if website.MaxCrawlBytes > 0 {
+			b.maxBytes = website.MaxCrawlBytes
+		}
+		if website.MaxCrawlDurationSeconds > 0 {
+			maxDuration = website.MaxCrawlDurationSeconds
+		}
+		if website.MaxPagesPerPrefix > 0 {
+			b.maxPerPrefix = website.MaxPagesPerPrefix
+		}
+	}
+
+	if maxDuration > 0 {
+		b.deadline = time.Now().Add(time.Duration(maxDuration) * time.Second)
+	}
+
+	return b
+}
+
+// addBytes records downloaded bytes.
+func (b *crawlBudget) addBytes(n int) {
+	b.bytes += int64(n)
+}
+
+// check returns the budget that stops the crawl, or "" if it may continue.
+// pageCount is the number of pages requested so far.
+func (b *crawlBudget) check(pageCount int) (string, string) {
+	switch {
+	case b.maxPages > 0 && pageCount >= b.maxPages:
+		return BudgetPages, fmt.Sprintf("page budget of %d pages exhausted", b.maxPages)
+	case b.maxBytes > 0 && b.bytes >= b.maxBytes:
+		return BudgetBytes, fmt.Sprintf("byte budget of %d bytes exhausted after %d bytes", b.maxBytes, b.bytes)
+	case !b.deadline.IsZero() && time.Now().After(b.deadline):
+		return BudgetDuration, "crawl duration budget exhausted"
+	}
+	return "", ""
+}
+
+// takePrefix reserves a page for the path prefix of u and reports whether the
+// prefix still had budget left. The second result is true the first time a
+// prefix runs out.
+func (b *crawlBudget) takePrefix(u *url.URL) (bool, bool) {
+	if b.maxPerPrefix <= 0 {
+		return true, false
+	}
+
+	prefix := pathPrefix(u)
+	b.prefixPages[prefix]++
+	count := b.prefixPages[prefix]
+	return count <= b.maxPerPrefix, count == b.maxPerPrefix+1
+}
+
+// pathPrefix returns the first path segment of u, e.g. "/docs" for
+// "/docs/getting-started".
+func pathPrefix(u *url.URL) string {
+	path := strings.Trim(u.Path, "/")
+	if path == "" {
+		return "/"
+	}
+	if i := strings.Index(path, "/"); i >= 0 {
+		path = path[:i]
+	}
+	return "/" + path
+}
+
+// exhaust marks the crawl as stopped by budget and records why. Only the
+// first exhausted budget is recorded.
+func (cr *Crawler) exhaust(ctx context.Context, logger *zap.Logger, b *crawlBudget, websiteID uint, pageURL, budget, reason string) {
+	if b.exhausted != "" {
+		return
+	}
+	b.exhausted = budget
+
+	logger.Warn("Crawl budget exhausted, stopping crawler",
+		zap.Uint("websiteID", websiteID),
+		zap.String("budget", budget),
+		zap.String("reason", reason),
+	)
+	cr.recordEvent(ctx, websiteID, pageURL, schema.CrawlEventLevelWarn, schema.CrawlEventBudget, reason)
+	if err := cr.websiteRepo.SetBudgetExhausted(ctx, websiteID, budget); err != nil {
+		logger.Error("Failed to record exhausted crawl budget", zap.Error(err))
+	}
+}
@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"context"
+	"time"
+
+	"hermit/internal/connectors"
+	"hermit/internal/contentprocessor"
+	"hermit/internal/schema"
+	"hermit/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+// loadConnector returns the connector config of a website, or nil if its
+// pages are crawled. Websites whose connector can't be loaded are crawled.
+func (cr *Crawler) loadConnector(ctx context.Context, logger *zap.Logger, websiteID uint) *schema.ConnectorConfig {
+	if cr.connectorRepo == nil || !cr.connectorRepo.Enabled() {
+		return nil
+	}
+
+	connector, err := cr.connectorRepo.Get(ctx, websiteID)
+	if err != nil {
+		logger.Warn("Failed to load website connector, crawling instead",
+			zap.Uint("websiteID", websiteID),
+			zap.Error(err),
+		)
+		return nil
+	}
+	return connector
+}
+
+// syncConnector ingests the pages of a website from its connector in place
+// of a crawl, through the same storage and vectorization pipeline.
+func (cr *Crawler) syncConnector(ctx context.Context, logger *zap.Logger, websiteID uint, cfg *schema.ConnectorConfig) {
+	logger = logger.With(zap.String("connector", cfg.Kind))
+	logger.Info("Connector sync started", zap.Uint("websiteID", websiteID))
+
+	connector, err := connectors.New(cfg, time.Duration(cr.config.HTTPTimeout)*time.Second)
+	if err != nil {
+		logger.Error("Invalid connector", zap.Error(err))
+		cr.websiteRepo.FailCrawl(ctx, websiteID, "Invalid connector: "+err.Error())
+		return
+	}
+
+	// Documents skip the crawl-only settings but are redacted and filtered for
+	// noise like pages
+	var settings pageSettings
+	website, err := cr.websiteRepo.GetByID(ctx, websiteID)
+	if err != nil {
+		logger.Error("Failed to load website settings", zap.Error(err))
+		cr.websiteRepo.FailCrawl(ctx, websiteID, "Failed to load website settings: "+err.Error())
+		return
+	}
+	if website != nil {
+		settings.piiRedaction = website.PIIRedaction
+	}
+	settings.noise = cr.noiseFilter(logger, website)
+
+	successCount := 0
+	failureCount := 0
+	err = connector.Documents(ctx, func(doc connectors.Document) error {
+		normalizedURL, err := contentprocessor.NormalizeURL(doc.URL)
+		if err != nil {
+			logger.Warn("Skipping document with invalid URL", zap.String("url", doc.URL), zap.Error(err))
+			failureCount++
+			return nil
+		}
+
+		cleanedText := cr.contentProcessor.CleanText(settings.noise.Strip(doc.Content))
+		if len(cleanedText) < cr.config.ContentMinLength {
+			detail := "document too short"
+			cr.recordEvent(ctx, websiteID, doc.URL, schema.CrawlEventLevelInfo, schema.CrawlEventLowQuality, detail)
+			cr.recordSkip(ctx, logger, websiteID, normalizedURL, schema.SkipReasonQuality, detail)
+			return nil
+		}
+
+		if cr.savePage(ctx, logger, websiteID, doc.URL, normalizedURL, doc.Title, cleanedText, nil, storage.PageMetadata{}, settings) {
+			successCount++
+		} else {
+			failureCount++
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Connector sync failed", zap.Error(err))
+		if successCount == 0 {
+			cr.websiteRepo.FailCrawl(ctx, websiteID, "Connector sync failed: "+err.Error())
+			return
+		}
+		cr.recordEvent(ctx, websiteID, "", schema.CrawlEventLevelError, schema.CrawlEventError,
+			"connector sync stopped early: "+err.Error())
+	}
+
+	if err := cr.websiteRepo.CompleteCrawl(ctx, websiteID, successCount, failureCount); err != nil {
+		logger.Error("Failed to update crawl completion status", zap.Error(err))
+	}
+
+	logger.Info("Connector sync completed",
+		zap.Uint("websiteID", websiteID),
+		zap.Int("successCount", successCount),
+		zap.Int("failureCount", failureCount),
+	)
+}
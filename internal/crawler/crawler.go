@@ -4,12 +4,19 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"hermit/internal/config"
 	"hermit/internal/contentprocessor"
 	"hermit/internal/repositories"
+	"hermit/internal/schema"
 	"hermit/internal/storage"
+	"hermit/internal/tenant"
 	"hermit/internal/vectorizer"
+	"hermit/internal/visual"
 	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gocolly/colly/v2"
@@ -25,10 +32,23 @@ type Crawler struct {
 	vectorizerSvc    *vectorizer.Service
 	contentProcessor *contentprocessor.ContentProcessor
 	robotsEnforcer   *contentprocessor.RobotsEnforcer
+	classifier       contentprocessor.PageClassifier
 	jobClient        interface {
-		EnqueueVectorizePage(ctx context.Context, websiteID, pageID uint, pageURL, content string) error
+		EnqueueVectorizePage(ctx, stop context.Context, websiteID, pageID uint, pageURL, content string, tags []string) error
+		EnqueueRetryPage(ctx context.Context, websiteID, pageID uint, pageURL string, retry int, delay time.Duration) error
+		EnqueueSummarizePage(ctx context.Context, websiteID, pageID uint) error
+		ReportProgress(ctx context.Context, done, total int)
 	}
-	config *config.Config
+	visualDetector  *visual.Detector
+	crawlEventRepo  *repositories.CrawlEventRepository
+	credentialsRepo *repositories.WebsiteCredentialsRepository
+	connectorRepo   *repositories.WebsiteConnectorRepository
+	config          *config.Config
+	dnsCache        *dnsCache
+	proxies         *proxyPool
+	contentTypes    map[string]bool
+	piiRedactor     *contentprocessor.PIIRedactor
+	noisePatterns   []*regexp.Regexp
 }
 
 // NewCrawler creates a new Crawler service.
@@ -40,9 +60,17 @@ func NewCrawler(
 	vectorizerSvc *vectorizer.Service,
 	contentProcessor *contentprocessor.ContentProcessor,
 	robotsEnforcer *contentprocessor.RobotsEnforcer,
+	classifier contentprocessor.PageClassifier,
 	jobClient interface {
-		EnqueueVectorizePage(ctx context.Context, websiteID, pageID uint, pageURL, content string) error
+		EnqueueVectorizePage(ctx, stop context.Context, websiteID, pageID uint, pageURL, content string, tags []string) error
+		EnqueueRetryPage(ctx context.Context, websiteID, pageID uint, pageURL string, retry int, delay time.Duration) error
+		EnqueueSummarizePage(ctx context.Context, websiteID, pageID uint) error
+		ReportProgress(ctx context.Context, done, total int)
 	},
+	visualDetector *visual.Detector,
+	crawlEventRepo *repositories.CrawlEventRepository,
+	credentialsRepo *repositories.WebsiteCredentialsRepository,
+	connectorRepo *repositories.WebsiteConnectorRepository,
 	cfg *config.Config,
 ) *Crawler {
 	return &Crawler{
@@ -53,43 +81,171 @@ func NewCrawler(
 		vectorizerSvc:    vectorizerSvc,
 		contentProcessor: contentProcessor,
 		robotsEnforcer:   robotsEnforcer,
+		classifier:       classifier,
 		jobClient:        jobClient,
+		visualDetector:   visualDetector,
+		crawlEventRepo:   crawlEventRepo,
+		credentialsRepo:  credentialsRepo,
+		connectorRepo:    connectorRepo,
 		config:           cfg,
+		dnsCache:         newDNSCache(time.Duration(cfg.CrawlerDNSCacheTTL) * time.Second),
+		proxies:          newProxyPool(strings.Split(cfg.CrawlerProxies, ","), cfg.CrawlerProxyMaxFailures, logger),
+		contentTypes:     parseContentTypes(cfg.CrawlerContentTypes),
+		piiRedactor:      newPIIRedactor(cfg.PIIPatterns, logger),
+		noisePatterns:    parseNoisePatterns(contentprocessor.SplitNoisePatterns(cfg.ContentNoisePatterns), "CONTENT_NOISE_PATTERNS", logger),
 	}
 }
 
-// Crawl starts the crawling process for a given URL.
-func (cr *Crawler) Crawl(websiteID uint, startURL string) {
-	cr.logger.Info("Crawling started", zap.String("url", startURL), zap.Uint("websiteID", websiteID))
+// newPIIRedactor creates the redactor of websites with PII redaction,
+// skipping invalid custom patterns.
+func newPIIRedactor(patterns string, logger *zap.Logger) *contentprocessor.PIIRedactor {
+	custom, err := contentprocessor.ParsePIIPatterns(patterns)
+	if err != nil {
+		logger.Error("Ignoring invalid PII_PATTERNS, only the built-in patterns are redacted", zap.Error(err))
+		custom = nil
+	}
+	return contentprocessor.NewPIIRedactor(custom)
+}
+
+// parseNoisePatterns parses noise patterns, skipping invalid ones.
+func parseNoisePatterns(patterns []string, source string, logger *zap.Logger) []*regexp.Regexp {
+	parsed := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := contentprocessor.ParseNoisePatterns([]string{pattern})
+		if err != nil {
+			logger.Error("Ignoring invalid noise pattern", zap.String("source", source), zap.Error(err))
+			continue
+		}
+		parsed = append(parsed, re...)
+	}
+	return parsed
+}
+
+// noiseFilter returns the noise filter of a website's pages, nil when noise
+// removal is off.
+func (cr *Crawler) noiseFilter(logger *zap.Logger, website *schema.Website) *contentprocessor.NoiseFilter {
+	if website == nil {
+		return contentprocessor.NewNoiseFilter(cr.noisePatterns)
+	}
+
+	if website.NoiseRemoval == schema.NoiseRemovalOff {
+		return nil
+	}
+	var patterns []*regexp.Regexp
+	if website.NoiseRemoval != schema.NoiseRemovalReplace {
+		patterns = append(patterns, cr.noisePatterns...)
+	}
+	patterns = append(patterns, parseNoisePatterns(website.NoisePatterns, "website", logger)...)
+	return contentprocessor.NewNoiseFilter(patterns)
+}
+
+// ErrInterrupted is returned by Crawl when its context was cancelled, e.g.
+// by a worker shutdown. The progress of the crawl is saved and the next crawl
+// of the website resumes it.
+var ErrInterrupted = errors.New("crawl interrupted")
+
+// depthOffsetKey is the colly context key of the link depth of the page a
+// resumed crawl started from, added to the depth colly counts.
+const depthOffsetKey = "depthOffset"
+
+// Crawl starts the crawling process for a given URL. When ctx is cancelled it
+// stops fetching pages, saves a checkpoint and returns ErrInterrupted; other
+// failures are recorded with the website.
+func (cr *Crawler) Crawl(ctx context.Context, websiteID uint, startURL string) error {
+	// Keep the tenant of the task but finish recording the crawl even if the
+	// task is cancelled; stop only stops fetching pages
+	stop := ctx
+	ctx = context.WithoutCancel(ctx)
+	logger := tenant.Logger(ctx, cr.logger)
+
+	logger.Info("Crawling started", zap.String("url", startURL), zap.Uint("websiteID", websiteID))
 
 	// Ensure Garage bucket exists
-	ctx := context.Background()
 	if err := cr.storage.EnsureBucket(ctx); err != nil {
-		cr.logger.Error("Failed to ensure Garage bucket", zap.Error(err))
+		logger.Error("Failed to ensure Garage bucket", zap.Error(err))
 		cr.websiteRepo.FailCrawl(ctx, websiteID, "Failed to ensure Garage bucket: "+err.Error())
-		return
+		return nil
 	}
 
-	// Mark crawl as started
-	if err := cr.websiteRepo.StartCrawl(ctx, websiteID); err != nil {
-		cr.logger.Error("Failed to update crawl status", zap.Error(err))
+	// Resume an interrupted crawl, or mark crawl as started
+	checkpoint, err := cr.websiteRepo.GetCrawlCheckpoint(ctx, websiteID)
+	if err != nil {
+		logger.Warn("Failed to load crawl checkpoint, crawling from the start", zap.Error(err))
+	}
+	if checkpoint != nil {
+		logger.Info("Resuming interrupted crawl",
+			zap.Int("visited", len(checkpoint.Visited)),
+			zap.Int("pending", len(checkpoint.Pending)),
+		)
+		if err := cr.websiteRepo.ResumeCrawl(ctx, websiteID); err != nil {
+			logger.Error("Failed to update crawl status", zap.Error(err))
+		}
+		cr.recordEvent(ctx, websiteID, startURL, schema.CrawlEventLevelInfo, schema.CrawlEventResumed,
+			fmt.Sprintf("resumed with %d pages left to fetch", len(checkpoint.Pending)))
+	} else if err := cr.websiteRepo.StartCrawl(ctx, websiteID); err != nil {
+		logger.Error("Failed to update crawl status", zap.Error(err))
+	}
+
+	// Websites with a connector are ingested through its API instead
+	if connector := cr.loadConnector(ctx, logger, websiteID); connector != nil {
+		cr.syncConnector(ctx, logger, websiteID, connector)
+		return nil
 	}
 
 	// Parse the starting URL to extract the domain
 	parsedURL, err := url.Parse(startURL)
 	if err != nil {
-		cr.logger.Error("Failed to parse URL", zap.String("url", startURL), zap.Error(err))
+		logger.Error("Failed to parse URL", zap.String("url", startURL), zap.Error(err))
 		cr.websiteRepo.FailCrawl(ctx, websiteID, "Failed to parse URL: "+err.Error())
-		return
+		return nil
+	}
+
+	// Look up per-website crawl settings
+	skipVerify := false
+	website, err := cr.websiteRepo.GetByID(ctx, websiteID)
+	if err != nil {
+		logger.Warn("Failed to load website settings, using defaults", zap.Uint("websiteID", websiteID), zap.Error(err))
+	} else if website != nil {
+		skipVerify = website.TLSSkipVerify
+	}
+	budget := newCrawlBudget(cr.config, website)
+	settings := cr.pageSettings(ctx, logger, website)
+	settings.stop = stop
+	scope := &Scope{host: parsedURL.Host}
+	var seedURLs []string
+	if website != nil {
+		seedURLs = website.SeedURLs
+		if websiteScope, err := NewScope(startURL, website.ScopePrefixes); err != nil {
+			logger.Warn("Invalid crawl scope, crawling the whole host", zap.Uint("websiteID", websiteID), zap.Error(err))
+		} else {
+			scope = websiteScope
+		}
+	}
+	creds := cr.loadCredentials(ctx, logger, websiteID)
+	var websiteProxies []string
+	if creds != nil {
+		websiteProxies = creds.Proxies
+	}
+	proxies := cr.proxyPoolFor(ctx, logger, websiteProxies)
+	profile := cr.requestProfile(website)
+	robots := cr.robotsPolicy(website, profile)
+	if !robots.respect {
+		logger.Warn("robots.txt is not respected for this crawl", zap.Uint("websiteID", websiteID))
 	}
 
-	// Create collector with allowed domain and configuration
+	// Create collector with allowed domain and configuration. The depth is
+	// checked when following links, as resumed pages don't start at depth 1
 	c := colly.NewCollector(
-		colly.AllowedDomains(parsedURL.Host),
-		colly.MaxDepth(cr.config.CrawlerMaxDepth),
-		colly.UserAgent(cr.config.CrawlerUserAgent),
+		// colly compares hostnames, without the port
+		colly.AllowedDomains(parsedURL.Hostname()),
+		colly.UserAgent(profile.userAgent),
+		// Requests in flight are cancelled with the crawl
+		colly.StdlibContext(stop),
 	)
 
+	transport := cr.configureTransport(c, skipVerify, proxies)
+	defer transport.CloseIdleConnections()
+
 	// Set up rate limiting with delay
 	if cr.config.CrawlerDelayMS > 0 {
 		c.Limit(&colly.LimitRule{
@@ -103,8 +259,48 @@ func (cr *Crawler) Crawl(websiteID uint, startURL string) {
 	pageCount := 0
 	successCount := 0
 	failureCount := 0
-	maxPages := cr.config.CrawlerMaxPages
 	visitedURLs := make(map[string]bool)
+	statusCodes := make(map[int]int) // of failed requests, 0 for network errors
+	requestedURLs := make(map[uint32]string)
+	skippedResponses := make(map[uint32]bool)
+	// URLs left to fetch once the crawl is stopped, saved in its checkpoint
+	var pending []schema.PendingURL
+	pendingURLs := make(map[string]bool)
+	// Pages listed in the website's sitemaps, loaded before the crawl starts
+	var sitemap sitemapSeeds
+	// Pages fetched for their links but not indexed again, unchanged since
+	// their last crawl
+	var unchangedURLs []string
+	if checkpoint != nil {
+		for _, visited := range checkpoint.Visited {
+			visitedURLs[visited] = true
+		}
+		pageCount = checkpoint.Pages
+		successCount = checkpoint.Succeeded
+		failureCount = checkpoint.Failed
+	}
+	var report func(ctx context.Context, done, total int)
+	if cr.jobClient != nil {
+		report = cr.jobClient.ReportProgress
+	}
+	progress := newCrawlProgress(report, pageCount, budget.maxPages)
+	// A task reusing the ID of an earlier crawl doesn't show its progress
+	progress.update(ctx, pageCount, true)
+
+	// depthOf returns the link depth of a request from the start URL
+	depthOf := func(r *colly.Request) int {
+		offset, _ := r.Ctx.GetAny(depthOffsetKey).(int)
+		return r.Depth + offset
+	}
+	// keepPending saves a URL the stopped crawl didn't fetch for the next one
+	keepPending := func(pageURL string, depth int) {
+		normalizedURL, err := contentprocessor.NormalizeURL(pageURL)
+		if err != nil || pendingURLs[normalizedURL] {
+			return
+		}
+		pendingURLs[normalizedURL] = true
+		pending = append(pending, schema.PendingURL{URL: normalizedURL, Depth: depth})
+	}
 
 	// Extract and process HTML content
 	c.OnHTML("html", func(e *colly.HTMLElement) {
@@ -114,217 +310,753 @@ func (cr *Crawler) Crawl(websiteID uint, startURL string) {
 		// Normalize URL to prevent duplicates
 		normalizedURL, err := contentprocessor.NormalizeURL(pageURL)
 		if err != nil {
-			cr.logger.Error("Failed to normalize URL", zap.String("url", pageURL), zap.Error(err))
+			logger.Error("Failed to normalize URL", zap.String("url", pageURL), zap.Error(err))
 			failureCount++
 			return
 		}
 
+		// Responses that were skipped in OnResponse are not processed
+		if skippedResponses[e.Request.ID] {
+			return
+		}
+
 		// Check if already visited (in-memory dedup)
 		if visitedURLs[normalizedURL] {
-			cr.logger.Debug("Skipping duplicate URL", zap.String("url", pageURL))
+			logger.Debug("Skipping duplicate URL", zap.String("url", pageURL))
+			if requested := requestedURLs[e.Request.ID]; requested != "" && requested != pageURL {
+				cr.recordSkip(ctx, logger, websiteID, requested, schema.SkipReasonDuplicate,
+					"redirects to already crawled "+normalizedURL)
+			}
 			return
 		}
 		visitedURLs[normalizedURL] = true
 
-		cr.logger.Info("Processing page",
-			zap.String("url", pageURL),
-			zap.Int("htmlSize", len(htmlContent)),
-		)
+		// Redirects are followed without OnRequest, so check where they led
+		if requested := requestedURLs[e.Request.ID]; requested != "" && requested != pageURL {
+			allowed, err := cr.robotsAllows(ctx, logger, robots, websiteID, normalizedURL)
+			if err != nil {
+				logger.Warn("Error checking robots.txt, skipping URL", zap.String("url", normalizedURL), zap.Error(err))
+				return
+			}
+			if !allowed {
+				cr.recordRobotsBlocked(ctx, logger, websiteID, normalizedURL)
+				return
+			}
+		}
 
-		// Extract main content using readability
-		processed, err := cr.contentProcessor.ExtractMainContent(string(htmlContent), pageURL)
-		if err != nil {
-			cr.logger.Error("Failed to extract main content", zap.String("url", pageURL), zap.Error(err))
-			failureCount++
-			cr.websiteRepo.IncrementPageCount(ctx, websiteID, false)
+		// Pages unchanged according to the sitemaps are only fetched for their
+		// links, which the a[href] handler follows
+		if sitemap.unchanged[normalizedURL] {
+			unchangedURLs = append(unchangedURLs, normalizedURL)
+			successCount++
+			cr.websiteRepo.IncrementPageCount(ctx, websiteID, true)
 			return
 		}
 
-		// Validate content quality
-		if !cr.contentProcessor.IsContentValid(processed, cr.config.ContentMinLength, cr.config.ContentMinQuality) {
-			cr.logger.Warn("Content quality too low, skipping",
-				zap.String("url", pageURL),
-				zap.Int("length", processed.Length),
-				zap.Float64("quality", processed.Quality),
-			)
+		contentType := e.Response.Headers.Get("Content-Type")
+		saved := cr.processPage(ctx, logger, websiteID, pageURL, normalizedURL, contentType, htmlContent, settings)
+		switch {
+		case saved:
+			successCount++
+			cr.websiteRepo.IncrementPageCount(ctx, websiteID, true)
+		case stop.Err() != nil:
+			// Pages processed while the crawl was stopped may not be queued
+			// for vectorization, the next crawl fetches them again
+			pageCount--
+			delete(visitedURLs, normalizedURL)
+			keepPending(pageURL, depthOf(e.Request))
+		default:
 			failureCount++
 			cr.websiteRepo.IncrementPageCount(ctx, websiteID, false)
-			return
 		}
+	})
 
-		// Clean text
-		cleanedText := cr.contentProcessor.CleanText(processed.Content)
-
-		cr.logger.Info("Extracted and cleaned content",
-			zap.String("url", pageURL),
-			zap.String("title", processed.Title),
-			zap.Int("length", processed.Length),
-			zap.Float64("quality", processed.Quality),
-		)
-
-		// Create or update page record
-		page, err := cr.pageRepo.Upsert(ctx, websiteID, normalizedURL)
+	// shouldVisit reports whether a discovered URL should be crawled. The
+	// source is the page the URL was found on, used when a budget runs out.
+	shouldVisit := func(source, absoluteURL string) bool {
+		// Normalize URL before checking robots.txt
+		normalizedURL, err := contentprocessor.NormalizeURL(absoluteURL)
 		if err != nil {
-			cr.logger.Error("Failed to upsert page", zap.String("url", pageURL), zap.Error(err))
-			failureCount++
-			cr.websiteRepo.IncrementPageCount(ctx, websiteID, false)
-			return
+			logger.Debug("Failed to normalize link URL", zap.String("url", absoluteURL), zap.Error(err))
+			return false
 		}
 
-		// Generate content hash
-		contentHash := hashContent(cleanedText)
+		// Check if already visited
+		if visitedURLs[normalizedURL] {
+			return false
+		}
 
-		// Save content to Garage
-		objectKey, err := cr.storage.SavePageContent(ctx, int(websiteID), normalizedURL, cleanedText)
-		if err != nil {
-			cr.logger.Error("Failed to save content to Garage", zap.String("url", pageURL), zap.Error(err))
-			cr.pageRepo.UpdateError(ctx, page.ID, err.Error())
-			failureCount++
-			cr.websiteRepo.IncrementPageCount(ctx, websiteID, false)
-			return
+		// Stay within the crawl scope of the website
+		if !scope.Contains(normalizedURL) {
+			visitedURLs[normalizedURL] = true
+			if sameHost(normalizedURL, parsedURL.Host) {
+				cr.recordSkip(ctx, logger, websiteID, normalizedURL, schema.SkipReasonScope, "outside the crawl scope")
+			}
+			return false
 		}
 
-		// Update page with success status
-		err = cr.pageRepo.UpdateSuccess(ctx, page.ID, objectKey, contentHash)
-		if err != nil {
-			cr.logger.Error("Failed to update page status", zap.String("url", pageURL), zap.Error(err))
-			failureCount++
-			cr.websiteRepo.IncrementPageCount(ctx, websiteID, false)
-			return
+		// Stop following links once a crawl budget is exhausted
+		if budget.exhausted == "" {
+			if name, reason := budget.check(pageCount); name != "" {
+				cr.exhaust(ctx, logger, budget, websiteID, source, name, reason)
+			}
+		}
+		if budget.exhausted != "" {
+			visitedURLs[normalizedURL] = true
+			if sameHost(normalizedURL, parsedURL.Host) {
+				cr.recordSkip(ctx, logger, websiteID, normalizedURL, schema.SkipReasonBudget,
+					"crawl budget exhausted: "+budget.exhausted)
+			}
+			return false
 		}
 
-		successCount++
-		cr.websiteRepo.IncrementPageCount(ctx, websiteID, true)
+		// Skip URLs that look like crawler traps
+		if reason := trapReason(normalizedURL); reason != "" {
+			logger.Debug("Skipping likely crawler trap", zap.String("url", normalizedURL), zap.String("reason", reason))
+			visitedURLs[normalizedURL] = true
+			cr.recordEvent(ctx, websiteID, normalizedURL, schema.CrawlEventLevelWarn, schema.CrawlEventTrapped, reason)
+			if sameHost(normalizedURL, parsedURL.Host) {
+				cr.recordSkip(ctx, logger, websiteID, normalizedURL, schema.SkipReasonPattern, reason)
+			}
+			return false
+		}
 
-		cr.logger.Info("Successfully saved page",
-			zap.String("url", pageURL),
-			zap.String("objectKey", objectKey),
-		)
+		// Check robots.txt before visiting. URLs fetched while it is bypassed
+		// are audited in OnRequest
+		if !robots.respect {
+			return true
+		}
+		allowed, err := robots.enforcer.CanFetch(ctx, normalizedURL)
+		if err != nil {
+			logger.Warn("Error checking robots.txt, skipping URL",
+				zap.String("url", normalizedURL),
+				zap.Error(err),
+			)
+			return false
+		}
 
-		// Vectorize the content via job queue or directly
-		if cr.jobClient != nil {
-			// Enqueue vectorization job
-			err := cr.jobClient.EnqueueVectorizePage(ctx, websiteID, page.ID, normalizedURL, cleanedText)
-			if err != nil {
-				cr.logger.Error("Failed to enqueue vectorization job",
-					zap.String("url", pageURL),
-					zap.Uint("pageID", page.ID),
-					zap.Error(err),
-				)
+		if !allowed {
+			visitedURLs[normalizedURL] = true
+			if sameHost(normalizedURL, parsedURL.Host) {
+				cr.recordRobotsBlocked(ctx, logger, websiteID, normalizedURL)
 			} else {
-				cr.logger.Debug("Enqueued vectorization job",
-					zap.String("url", pageURL),
-					zap.Uint("pageID", page.ID),
-				)
+				cr.recordEvent(ctx, websiteID, normalizedURL, schema.CrawlEventLevelInfo, schema.CrawlEventRobotsBlocked,
+					"disallowed by robots.txt")
 			}
-		} else {
-			// Fallback: vectorize directly (async)
-			go func() {
-				err := cr.vectorizerSvc.ProcessPageContent(ctx, websiteID, page.ID, normalizedURL, cleanedText)
-				if err != nil {
-					cr.logger.Error("Failed to vectorize page content",
-						zap.String("url", pageURL),
-						zap.Uint("pageID", page.ID),
-						zap.Error(err),
-					)
-					return
-				}
-				cr.logger.Info("Successfully vectorized page",
-					zap.String("url", pageURL),
-					zap.Uint("pageID", page.ID),
-				)
-			}()
+			return false
 		}
-	})
+
+		return true
+	}
 
 	// Find and visit all same-domain links
 	c.OnHTML("a[href]", func(e *colly.HTMLElement) {
-		// Check if max pages limit reached
-		if maxPages > 0 && pageCount >= maxPages {
-			cr.logger.Info("Max pages limit reached, stopping crawler",
-				zap.Int("maxPages", maxPages),
-			)
-			return
-		}
-
 		link := e.Attr("href")
 		absoluteURL := e.Request.AbsoluteURL(link)
-
-		// Normalize URL before checking robots.txt
-		normalizedURL, err := contentprocessor.NormalizeURL(absoluteURL)
-		if err != nil {
-			cr.logger.Debug("Failed to normalize link URL", zap.String("url", absoluteURL), zap.Error(err))
+		if !shouldVisit(e.Request.URL.String(), absoluteURL) {
 			return
 		}
 
-		// Check if already visited
-		if visitedURLs[normalizedURL] {
+		if depth := depthOf(e.Request) + 1; cr.config.CrawlerMaxDepth > 0 && depth > cr.config.CrawlerMaxDepth {
+			if normalizedURL, err := contentprocessor.NormalizeURL(absoluteURL); err == nil {
+				visitedURLs[normalizedURL] = true
+				cr.recordSkip(ctx, logger, websiteID, normalizedURL, schema.SkipReasonDepth,
+					fmt.Sprintf("depth %d exceeds the maximum crawl depth of %d", depth, cr.config.CrawlerMaxDepth))
+			}
 			return
 		}
 
-		// Check robots.txt before visiting
-		allowed, err := cr.robotsEnforcer.CanFetch(ctx, normalizedURL)
+		// Visit the link (colly handles same-domain filtering)
+		progress.discover(absoluteURL)
+		e.Request.Visit(link)
+	})
+
+	c.OnRequest(func(r *colly.Request) {
+		if budget.exhausted != "" {
+			r.Abort()
+			return
+		}
+		// Keep the pages found after the crawl was stopped for the next one
+		if stop.Err() != nil {
+			keepPending(r.URL.String(), depthOf(r))
+			r.Abort()
+			return
+		}
+		// Check robots.txt for URLs no link led to, such as the start URL
+		allowed, err := cr.robotsAllows(ctx, logger, robots, websiteID, r.URL.String())
 		if err != nil {
-			cr.logger.Warn("Error checking robots.txt, skipping URL",
-				zap.String("url", normalizedURL),
-				zap.Error(err),
-			)
+			logger.Warn("Error checking robots.txt, skipping URL", zap.String("url", r.URL.String()), zap.Error(err))
+			r.Abort()
 			return
 		}
-
 		if !allowed {
-			cr.logger.Debug("URL disallowed by robots.txt",
-				zap.String("url", normalizedURL),
-			)
+			cr.recordRobotsBlocked(ctx, logger, websiteID, r.URL.String())
+			r.Abort()
+			return
+		}
+		if ok, first := budget.takePrefix(r.URL); !ok {
+			if first {
+				cr.recordEvent(ctx, websiteID, r.URL.String(), schema.CrawlEventLevelInfo, schema.CrawlEventBudget,
+					fmt.Sprintf("page budget of %d pages for %s exhausted", budget.maxPerPrefix, pathPrefix(r.URL)))
+			}
+			logger.Debug("Path prefix budget exhausted, skipping URL", zap.String("url", r.URL.String()))
+			cr.recordSkip(ctx, logger, websiteID, r.URL.String(), schema.SkipReasonBudget,
+				fmt.Sprintf("page budget of %d pages for %s exhausted", budget.maxPerPrefix, pathPrefix(r.URL)))
+			r.Abort()
 			return
 		}
 
-		// Visit the link (colly handles same-domain filtering)
-		e.Request.Visit(link)
-	})
-
-	c.OnRequest(func(r *colly.Request) {
 		pageCount++
-		cr.logger.Info("Visiting",
+		progress.discover(r.URL.String())
+		requestedURLs[r.ID] = r.URL.String()
+		profile.apply(*r.Headers)
+		if creds != nil {
+			creds.Apply(*r.Headers)
+		}
+		// Remember the requested URL so redirects can be detected in OnResponse
+		r.Ctx.Put("requestedURL", r.URL.String())
+		logger.Info("Visiting",
 			zap.String("url", r.URL.String()),
 			zap.Int("pageCount", pageCount),
-			zap.Int("maxPages", maxPages),
+			zap.Int("maxPages", budget.maxPages),
 		)
 
 		// Check crawl delay from robots.txt
-		crawlDelay, err := cr.robotsEnforcer.GetCrawlDelay(ctx, r.URL.String())
+		crawlDelay, err := robots.enforcer.GetCrawlDelay(ctx, r.URL.String())
 		if err == nil && crawlDelay > 0 {
 			// If robots.txt specifies a delay, respect it
 			if crawlDelay > time.Duration(cr.config.CrawlerDelayMS)*time.Millisecond {
-				cr.logger.Debug("Respecting robots.txt crawl delay",
+				logger.Debug("Respecting robots.txt crawl delay",
 					zap.String("url", r.URL.String()),
 					zap.Duration("delay", crawlDelay),
 				)
-				time.Sleep(crawlDelay)
+				select {
+				case <-stop.Done():
+				case <-time.After(crawlDelay):
+				}
 			}
 		}
 	})
 
+	// Skip responses that won't be indexed before downloading their body
+	c.OnResponseHeaders(func(r *colly.Response) {
+		if reason, detail := cr.skipHeaders(c, r); reason != "" {
+			r.Request.Abort()
+			cr.recordSkip(ctx, logger, websiteID, r.Request.URL.String(), reason, detail)
+		}
+	})
+
+	c.OnResponse(func(r *colly.Response) {
+		budget.addBytes(len(r.Body))
+		if proxies != nil {
+			proxies.report(r.Request.ProxyURL, true)
+		}
+
+		if reason, detail := cr.skipResponse(c, r); reason != "" {
+			skippedResponses[r.Request.ID] = true
+			cr.recordSkip(ctx, logger, websiteID, r.Request.URL.String(), reason, detail)
+		}
+
+		requestedURL := r.Ctx.Get("requestedURL")
+		finalURL := r.Request.URL.String()
+		if requestedURL != "" && requestedURL != finalURL {
+			cr.recordEvent(ctx, websiteID, requestedURL, schema.CrawlEventLevelInfo, schema.CrawlEventRedirected,
+				"redirected to "+finalURL)
+		}
+	})
+
+	c.OnScraped(func(r *colly.Response) {
+		progress.update(ctx, pageCount, false)
+	})
+
 	c.OnError(func(r *colly.Response, err error) {
-		cr.logger.Error("Request failed",
+		progress.update(ctx, pageCount, false)
+		// Responses aborted after their headers were recorded as skipped
+		if errors.Is(err, colly.ErrAbortedAfterHeaders) {
+			return
+		}
+		// Requests cancelled by stopping the crawl are fetched by the next one
+		if stop.Err() != nil && errors.Is(err, stop.Err()) {
+			pageCount--
+			keepPending(r.Request.URL.String(), depthOf(r.Request))
+			return
+		}
+		logger.Error("Request failed",
 			zap.String("url", r.Request.URL.String()),
 			zap.Error(err),
 		)
+		reason := err.Error()
+		if r.StatusCode > 0 {
+			reason = fmt.Sprintf("HTTP %d: %s", r.StatusCode, reason)
+		}
+		cr.recordEvent(ctx, websiteID, r.Request.URL.String(), schema.CrawlEventLevelError, schema.CrawlEventError, reason)
+		cr.recordFetchError(ctx, logger, websiteID, r.Request.URL.String(), r.StatusCode, reason)
+		statusCodes[r.StatusCode]++
+		if proxies != nil {
+			proxies.report(r.Request.ProxyURL, !isProxyFailure(r.StatusCode))
+		}
 	})
 
-	c.Visit(startURL)
+	// Load the sitemaps before following links, so a recrawl doesn't index
+	// the pages they list as unchanged again
+	if stop.Err() == nil {
+		sitemap = cr.sitemapPages(ctx, logger, robots.enforcer, website, startURL, budget.maxPages)
+	}
+
+	// The pages the crawl starts from make up the first estimate of its size
+	var startPages []string
+	if checkpoint != nil {
+		for _, page := range checkpoint.Pending {
+			startPages = append(startPages, page.URL)
+		}
+	} else {
+		startPages = append(startPages, startURL)
+	}
+	startPages = append(startPages, seedURLs...)
+	for _, pageURL := range append(startPages, sitemap.pages...) {
+		normalizedURL, err := contentprocessor.NormalizeURL(pageURL)
+		if err == nil && !visitedURLs[normalizedURL] {
+			progress.discover(normalizedURL)
+		}
+	}
+	progress.update(ctx, pageCount, true)
+
+	// A resumed crawl continues with the pages it had left, at their depth
+	if checkpoint != nil {
+		for _, page := range checkpoint.Pending {
+			if visitedURLs[page.URL] {
+				continue
+			}
+			depth := colly.NewContext()
+			depth.Put(depthOffsetKey, page.Depth-1)
+			c.Request("GET", page.URL, nil, depth, nil)
+		}
+	} else {
+		c.Visit(startURL)
+	}
+
+	// Visit the website's other start URLs that no link led to
+	for _, seedURL := range seedURLs {
+		if budget.exhausted != "" || stop.Err() != nil {
+			break
+		}
+		if shouldVisit(startURL, seedURL) {
+			c.Visit(seedURL)
+		}
+	}
+
+	// Visit pages listed in the website's sitemaps that no link led to. A
+	// stopped crawl finds them again when resumed
+	seeded := 0
+	for _, pageURL := range sitemap.pages {
+		if budget.exhausted != "" || stop.Err() != nil {
+			break
+		}
+		if !shouldVisit(startURL, pageURL) {
+			continue
+		}
+		if err := c.Visit(pageURL); err == nil {
+			seeded++
+		}
+	}
+
+	// Remember the boilerplate found so pages indexed later are stripped too
+	if settings.boilerplate != nil {
+		if hashes, ok := settings.boilerplate.Blocks(); ok {
+			if err := cr.websiteRepo.SetBoilerplate(ctx, websiteID, hashes); err != nil {
+				logger.Warn("Failed to save boilerplate", zap.Error(err))
+			}
+		}
+	}
+
+	// Pages not indexed again were confirmed current by the sitemaps
+	if len(unchangedURLs) > 0 {
+		if err := cr.pageRepo.TouchCrawled(ctx, websiteID, unchangedURLs); err != nil {
+			logger.Warn("Failed to record unchanged pages as crawled", zap.Error(err))
+		}
+		cr.recordEvent(ctx, websiteID, startURL, schema.CrawlEventLevelInfo, schema.CrawlEventUnchanged,
+			fmt.Sprintf("%d pages not indexed again, unchanged since their last crawl according to the sitemaps", len(unchangedURLs)))
+	}
+
+	// Save the progress of a stopped crawl for the next one to resume
+	if stop.Err() != nil {
+		visited := make([]string, 0, len(visitedURLs))
+		for visitedURL := range visitedURLs {
+			visited = append(visited, visitedURL)
+		}
+		checkpoint := &schema.CrawlCheckpoint{
+			Visited:   visited,
+			Pending:   pending,
+			Pages:     pageCount,
+			Succeeded: successCount,
+			Failed:    failureCount,
+		}
+		if err := cr.websiteRepo.InterruptCrawl(ctx, websiteID, checkpoint); err != nil {
+			logger.Error("Failed to save crawl checkpoint", zap.Error(err))
+		}
+		cr.recordEvent(ctx, websiteID, startURL, schema.CrawlEventLevelWarn, schema.CrawlEventInterrupted,
+			fmt.Sprintf("interrupted after %d pages with %d pages left to fetch", pageCount, len(pending)))
+		progress.update(ctx, pageCount, true)
+		logger.Warn("Crawling interrupted",
+			zap.String("url", startURL),
+			zap.Int("totalPages", pageCount),
+			zap.Int("successCount", successCount),
+			zap.Int("failureCount", failureCount),
+			zap.Int("pendingPages", len(pending)),
+			zap.NamedError("cause", stop.Err()),
+		)
+		return ErrInterrupted
+	}
+
+	progress.finish(ctx, pageCount)
 
 	// Mark crawl as completed
 	if err := cr.websiteRepo.CompleteCrawl(ctx, websiteID, successCount, failureCount); err != nil {
-		cr.logger.Error("Failed to update crawl completion status", zap.Error(err))
+		logger.Error("Failed to update crawl completion status", zap.Error(err))
 	}
 
-	cr.logger.Info("Crawling completed",
+	logger.Info("Crawling completed",
 		zap.String("url", startURL),
 		zap.Int("totalPages", pageCount),
 		zap.Int("successCount", successCount),
 		zap.Int("failureCount", failureCount),
+		zap.Any("errorStatusCodes", statusCodes),
+		zap.Int("seedURLs", len(seedURLs)),
+		zap.Int("sitemapPages", len(sitemap.pages)),
+		zap.Int("seededFromSitemaps", seeded),
+		zap.Int("unchangedPages", len(unchangedURLs)),
+		zap.String("budgetExhausted", budget.exhausted),
+	)
+	return nil
+}
+
+// minQuality returns the minimum content quality of a website's pages.
+func (cr *Crawler) minQuality(website *schema.Website) float64 {
+	if website != nil && website.MinContentQuality > 0 {
+		return website.MinContentQuality
+	}
+	return cr.config.ContentMinQuality
+}
+
+// IndexedContent returns the text of a page to chunk and embed: its content
+// followed by the text of its images when enabled.
+func (cr *Crawler) IndexedContent(content string, images []schema.PageImage) string {
+	if !cr.config.ContentImageText || len(images) == 0 {
+		return content
+	}
+	return content + "\n\n" + contentprocessor.ImagesText(images)
+}
+
+// pageSettings are the per-website settings used to process its pages.
+type pageSettings struct {
+	visualMonitoring bool
+	screenshots      bool
+	archiveHTML      bool
+	piiRedaction     bool
+	minQuality       float64
+	// onlyChanged leaves pages whose text didn't change as they are instead
+	// of storing and vectorizing it again
+	onlyChanged bool
+	// boilerplate strips text recurring across the site's pages, nil when
+	// boilerplate removal is disabled
+	boilerplate *contentprocessor.BoilerplateDetector
+	// noise strips blocks made up only of noise phrases, nil when noise
+	// removal is off
+	noise *contentprocessor.NoiseFilter
+	// stop gives up waiting for a vectorize slot when the crawl is stopped,
+	// nil waits as long as the backpressure allows
+	stop context.Context
+}
+
+// pageSettings returns the settings used to process a website's pages.
+func (cr *Crawler) pageSettings(ctx context.Context, logger *zap.Logger, website *schema.Website) pageSettings {
+	settings := pageSettings{
+		minQuality:  cr.minQuality(website),
+		screenshots: cr.config.ScreenshotPages && cr.visualDetector != nil,
+		archiveHTML: cr.config.ArchiveRawHTML,
+		noise:       cr.noiseFilter(logger, website),
+	}
+	if website == nil {
+		return settings
+	}
+	settings.visualMonitoring = website.VisualMonitoring && cr.visualDetector != nil
+	settings.piiRedaction = website.PIIRedaction
+	if cr.config.ContentBoilerplateRemoval {
+		known, err := cr.websiteRepo.GetBoilerplate(ctx, website.ID)
+		if err != nil {
+			logger.Warn("Failed to load known boilerplate", zap.Uint("websiteID", website.ID), zap.Error(err))
+		}
+		settings.boilerplate = contentprocessor.NewBoilerplateDetector(
+			cr.config.ContentBoilerplateMinPages, cr.config.ContentBoilerplateThreshold, known)
+	}
+	return settings
+}
+
+// processPage extracts, stores and vectorizes the content of a fetched page.
+// It reports whether the page was saved.
+func (cr *Crawler) processPage(ctx context.Context, logger *zap.Logger, websiteID uint, pageURL, normalizedURL, contentType string, htmlContent []byte, settings pageSettings) bool {
+	logger.Info("Processing page",
+		zap.String("url", pageURL),
+		zap.Int("htmlSize", len(htmlContent)),
+	)
+
+	// Archive the HTML before extraction so pages it fails on can be reprocessed
+	if settings.archiveHTML {
+		cr.archiveHTML(ctx, logger, websiteID, pageURL, normalizedURL, contentType, htmlContent)
+	}
+
+	// Extract main content using readability
+	processed, err := cr.contentProcessor.ExtractMainContent(string(htmlContent), pageURL)
+	if err != nil {
+		logger.Error("Failed to extract main content", zap.String("url", pageURL), zap.Error(err))
+		cr.recordEvent(ctx, websiteID, pageURL, schema.CrawlEventLevelError, schema.CrawlEventError,
+			"failed to extract main content: "+err.Error())
+		return false
+	}
+
+	// Validate content quality
+	if !cr.contentProcessor.IsContentValid(processed, cr.config.ContentMinLength, settings.minQuality) {
+		logger.Warn("Content quality too low, skipping",
+			zap.String("url", pageURL),
+			zap.Int("length", processed.Length),
+			zap.Float64("quality", processed.Quality),
+		)
+		detail := fmt.Sprintf("content length %d, quality %.2f (min length %d, min quality %.2f)",
+			processed.Length, processed.Quality, cr.config.ContentMinLength, settings.minQuality)
+		cr.recordEvent(ctx, websiteID, pageURL, schema.CrawlEventLevelInfo, schema.CrawlEventLowQuality, detail)
+		cr.recordSkip(ctx, logger, websiteID, normalizedURL, schema.SkipReasonQuality, detail)
+		return false
+	}
+
+	// Let the classifier reject pages the heuristics let through. Pages are
+	// kept when it fails
+	if cr.classifier != nil {
+		useful, err := cr.classifier.IsUseful(ctx, pageURL, processed.Title, processed.Content)
+		if err != nil {
+			logger.Warn("Failed to classify page, keeping it", zap.String("url", pageURL), zap.Error(err))
+		} else if !useful {
+			logger.Info("Page classified as not useful, skipping", zap.String("url", pageURL))
+			detail := fmt.Sprintf("classified as not useful (quality %.2f)", processed.Quality)
+			cr.recordEvent(ctx, websiteID, pageURL, schema.CrawlEventLevelInfo, schema.CrawlEventLowQuality, detail)
+			cr.recordSkip(ctx, logger, websiteID, normalizedURL, schema.SkipReasonQuality, detail)
+			return false
+		}
+	}
+
+	// Strip text recurring across the site and noise blocks before the
+	// lines are collapsed
+	if settings.boilerplate != nil {
+		processed.Content = settings.boilerplate.Strip(processed.Content)
+	}
+	processed.Content = settings.noise.Strip(processed.Content)
+
+	// Clean text
+	cleanedText := cr.contentProcessor.CleanText(processed.Content)
+
+	logger.Info("Extracted and cleaned content",
+		zap.String("url", pageURL),
+		zap.String("title", processed.Title),
+		zap.Int("length", processed.Length),
+		zap.Float64("quality", processed.Quality),
+	)
+
+	meta := storage.PageMetadata{Language: processed.Language, SourceContentType: contentType}
+	return cr.savePage(ctx, logger, websiteID, pageURL, normalizedURL, processed.Title, cleanedText, processed.Images, meta, settings)
+}
+
+// archiveHTML stores the raw HTML of a fetched page and links it to the page.
+func (cr *Crawler) archiveHTML(ctx context.Context, logger *zap.Logger, websiteID uint, pageURL, normalizedURL, contentType string, htmlContent []byte) {
+	page, err := cr.pageRepo.Upsert(ctx, websiteID, normalizedURL)
+	if err != nil {
+		logger.Warn("Failed to upsert page for HTML archive", zap.String("url", pageURL), zap.Error(err))
+		return
+	}
+
+	objectKey, err := cr.storage.SavePageHTML(ctx, int(websiteID), normalizedURL, htmlContent, contentType)
+	if err != nil {
+		logger.Warn("Failed to archive page HTML", zap.String("url", pageURL), zap.Error(err))
+		return
+	}
+	if err := cr.pageRepo.SetHTMLArchive(ctx, page.ID, objectKey); err != nil {
+		logger.Warn("Failed to save page HTML archive", zap.String("url", pageURL), zap.Error(err))
+	}
+}
+
+// screenshotPage captures the rendered page once, stores it for verification
+// and compares it with the previous crawl, as enabled by the settings.
+func (cr *Crawler) screenshotPage(ctx context.Context, logger *zap.Logger, websiteID, pageID uint, pageURL, normalizedURL string, textChanged bool, settings pageSettings) {
+	image, err := cr.visualDetector.Capture(ctx, normalizedURL)
+	if err != nil {
+		logger.Warn("Failed to capture page screenshot", zap.String("url", pageURL), zap.Error(err))
+		return
+	}
+
+	if settings.screenshots {
+		objectKey, err := cr.storage.SavePageScreenshot(ctx, int(websiteID), normalizedURL, image)
+		if err != nil {
+			logger.Warn("Failed to store page screenshot", zap.String("url", pageURL), zap.Error(err))
+		} else if err := cr.pageRepo.SetScreenshot(ctx, pageID, objectKey); err != nil {
+			logger.Warn("Failed to save page screenshot", zap.String("url", pageURL), zap.Error(err))
+		}
+	}
+
+	// Compare the rendered page with the previous crawl
+	if settings.visualMonitoring {
+		snapshot, err := cr.visualDetector.Check(ctx, websiteID, pageID, normalizedURL, image, textChanged)
+		if err != nil {
+			logger.Warn("Visual change check failed", zap.String("url", pageURL), zap.Error(err))
+		} else {
+			logger.Debug("Recorded visual snapshot",
+				zap.String("url", pageURL),
+				zap.Float64("changeScore", snapshot.ChangeScore),
+				zap.Bool("textChanged", textChanged),
+			)
+		}
+	}
+}
+
+// savePage stores, tags and vectorizes the cleaned text of a page. The title,
+// hash and extraction time are added to the stored metadata. It reports
+// whether the page was saved.
+func (cr *Crawler) savePage(ctx context.Context, logger *zap.Logger, websiteID uint, pageURL, normalizedURL, title, cleanedText string, images []schema.PageImage, meta storage.PageMetadata, settings pageSettings) bool {
+	// Redact personal data before anything is stored or embedded
+	var redactions map[string]int
+	if settings.piiRedaction {
+		title, cleanedText, images, redactions = cr.piiRedactor.RedactPage(title, cleanedText, images)
+	}
+
+	// Create or update page record
+	page, err := cr.pageRepo.Upsert(ctx, websiteID, normalizedURL)
+	if err != nil {
+		logger.Error("Failed to upsert page", zap.String("url", pageURL), zap.Error(err))
+		return false
+	}
+
+	// Generate content hash
+	contentHash := hashContent(cleanedText)
+	if settings.onlyChanged && page.ContentHash.String == contentHash && page.VectorStatus.String == schema.VectorStatusVectorized {
+		logger.Debug("Page content unchanged, skipping", zap.String("url", pageURL))
+		return true
+	}
+
+	// Save content to Garage
+	meta.Title = title
+	meta.ContentHash = contentHash
+	meta.ExtractedAt = time.Now()
+	objectKey, err := cr.storage.SavePageContent(ctx, int(websiteID), normalizedURL, cleanedText, meta)
+	if err != nil {
+		logger.Error("Failed to save content to Garage", zap.String("url", pageURL), zap.Error(err))
+		cr.pageRepo.UpdateError(ctx, page.ID, err.Error())
+		return false
+	}
+
+	// Update page with success status
+	snippet := contentprocessor.Snippet(cleanedText, schema.PageSnippetLength)
+	err = cr.pageRepo.UpdateSuccess(ctx, page.ID, objectKey, contentHash, title, snippet)
+	if err != nil {
+		logger.Error("Failed to update page status", zap.String("url", pageURL), zap.Error(err))
+		return false
+	}
+
+	if err := cr.pageRepo.SetImages(ctx, page.ID, images); err != nil {
+		logger.Warn("Failed to save page images", zap.String("url", pageURL), zap.Error(err))
+	}
+
+	// Index the text for keyword search, besides the embeddings
+	if err := cr.pageRepo.SetSearchText(ctx, page.ID, title, cr.IndexedContent(cleanedText, images)); err != nil {
+		logger.Warn("Failed to index page text for search", zap.String("url", pageURL), zap.Error(err))
+	}
+
+	if redactions != nil {
+		if err := cr.pageRepo.SetPIIRedactions(ctx, page.ID, redactions); err != nil {
+			logger.Warn("Failed to save PII redaction counts", zap.String("url", pageURL), zap.Error(err))
+		}
+	}
+
+	logger.Info("Successfully saved page",
+		zap.String("url", pageURL),
+		zap.String("objectKey", objectKey),
 	)
+
+	// Tag the page with its topics, stored with the chunks for filtered retrieval
+	tags := contentprocessor.ExtractTags(title, cleanedText)
+	if err := cr.pageRepo.SetTags(ctx, websiteID, page.ID, tags); err != nil {
+		logger.Warn("Failed to save page tags", zap.String("url", pageURL), zap.Error(err))
+	}
+
+	// Summarize the page again only when its content changed
+	if cr.jobClient != nil && cr.config.SummariesEnabled && page.SummaryContentHash.String != contentHash {
+		if err := cr.jobClient.EnqueueSummarizePage(ctx, websiteID, page.ID); err != nil {
+			logger.Warn("Failed to enqueue summary job",
+				zap.String("url", pageURL),
+				zap.Uint("pageID", page.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if settings.visualMonitoring || settings.screenshots {
+		textChanged := !page.ContentHash.Valid || page.ContentHash.String != contentHash
+		cr.screenshotPage(ctx, logger, websiteID, page.ID, pageURL, normalizedURL, textChanged, settings)
+	}
+
+	// Vectorize the content via job queue or directly
+	indexedText := cr.IndexedContent(cleanedText, images)
+	if cr.jobClient != nil {
+		// Enqueue vectorization job
+		stop := settings.stop
+		if stop == nil {
+			stop = ctx
+		}
+		err := cr.jobClient.EnqueueVectorizePage(ctx, stop, websiteID, page.ID, normalizedURL, indexedText, tags)
+		if stop.Err() != nil && errors.Is(err, stop.Err()) {
+			// The page stays pending and is fetched again by the next crawl
+			logger.Info("Crawl stopped before the page was queued for vectorization",
+				zap.String("url", pageURL),
+				zap.Uint("pageID", page.ID),
+			)
+			return false
+		} else if err != nil {
+			logger.Error("Failed to enqueue vectorization job",
+				zap.String("url", pageURL),
+				zap.Uint("pageID", page.ID),
+				zap.Error(err),
+			)
+			if err := cr.pageRepo.SetVectorFailed(ctx, page.ID, err.Error()); err != nil {
+				logger.Warn("Failed to record page vector status", zap.Uint("pageID", page.ID), zap.Error(err))
+			}
+		} else {
+			logger.Debug("Enqueued vectorization job",
+				zap.String("url", pageURL),
+				zap.Uint("pageID", page.ID),
+			)
+		}
+	} else {
+		// Fallback: vectorize directly (async)
+		go func() {
+			chunks, err := cr.vectorizerSvc.ProcessPageContent(ctx, websiteID, page.ID, normalizedURL, indexedText, tags)
+			if err != nil {
+				logger.Error("Failed to vectorize page content",
+					zap.String("url", pageURL),
+					zap.Uint("pageID", page.ID),
+					zap.Error(err),
+				)
+				if err := cr.pageRepo.SetVectorFailed(ctx, page.ID, err.Error()); err != nil {
+					logger.Warn("Failed to record page vector status", zap.Uint("pageID", page.ID), zap.Error(err))
+				}
+				return
+			}
+			if err := cr.pageRepo.SetVectorized(ctx, page.ID, chunks); err != nil {
+				logger.Warn("Failed to record page vector status", zap.Uint("pageID", page.ID), zap.Error(err))
+			}
+			logger.Info("Successfully vectorized page",
+				zap.String("url", pageURL),
+				zap.Uint("pageID", page.ID),
+			)
+		}()
+	}
+
+	return true
 }
 
 // hashContent creates a SHA256 hash of content.
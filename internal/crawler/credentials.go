@@ -0,0 +1,27 @@
+package crawler
+
+import (
+	"context"
+
+	"hermit/internal/schema"
+
+	"go.uber.org/zap"
+)
+
+// loadCredentials returns the fetch credentials of a website, or nil if it has
+// none. Websites whose credentials can't be loaded are crawled without them.
+func (cr *Crawler) loadCredentials(ctx context.Context, logger *zap.Logger, websiteID uint) *schema.FetchCredentials {
+	if cr.credentialsRepo == nil || !cr.credentialsRepo.Enabled() {
+		return nil
+	}
+
+	creds, err := cr.credentialsRepo.Get(ctx, websiteID)
+	if err != nil {
+		logger.Warn("Failed to load website credentials, crawling without them",
+			zap.Uint("websiteID", websiteID),
+			zap.Error(err),
+		)
+		return nil
+	}
+	return creds
+}
@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// acceptEncoding lists the content encodings the crawler asks for and decodes.
+const acceptEncoding = "gzip, br"
+
+// decodingTransport asks servers for compressed responses and decodes gzip
+// and brotli bodies, so the crawler downloads less and the body size limit
+// applies to the decoded content. The Go transport only decodes gzip on its
+// own.
+type decodingTransport struct {
+	base http.RoundTripper
+}
+
+// newDecodingTransport wraps base in a decodingTransport.
+func newDecodingTransport(base http.RoundTripper) *decodingTransport {
+	return &decodingTransport{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *decodingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Leave requests that negotiate their own encoding alone
+	if req.Header.Get("Accept-Encoding") != "" || req.Method == http.MethodHead {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding != "gzip" && encoding != "br" {
+		return resp, nil
+	}
+
+	resp.Body = &decodedBody{body: resp.Body, encoding: encoding}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+
+	return resp, nil
+}
+
+// decodedBody decodes a compressed response body. The decoder is created on
+// the first read, so empty bodies and bodies that are never read don't fail.
+type decodedBody struct {
+	body     io.ReadCloser
+	encoding string
+	reader   io.Reader
+	err      error
+}
+
+// Read implements io.Reader.
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		switch b.encoding {
+		case "gzip":
+			zr, err := gzip.NewReader(b.body)
+			if err != nil {
+				b.err = err
+			} else {
+				b.reader = zr
+			}
+		case "br":
+			b.reader = brotli.NewReader(b.body)
+		}
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p)
+}
+
+// Close implements io.Closer.
+func (b *decodedBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,70 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"hermit/internal/schema"
+
+	"go.uber.org/zap"
+)
+
+// Limits used to detect crawler traps such as calendars or endlessly nested paths.
+const (
+	maxTrapURLLength    = 2048
+	maxTrapPathSegments = 15
+	maxRepeatedSegments = 3
+)
+
+// recordEvent persists a notable crawl event so users can see why a page was
+// skipped or failed. Failures are only logged since events are diagnostic.
+func (cr *Crawler) recordEvent(ctx context.Context, websiteID uint, pageURL, level, event, reason string) {
+	if cr.crawlEventRepo == nil {
+		return
+	}
+
+	err := cr.crawlEventRepo.Create(ctx, &schema.CrawlEvent{
+		WebsiteID: websiteID,
+		URL:       pageURL,
+		Level:     level,
+		Event:     event,
+		Reason:    reason,
+	})
+	if err != nil {
+		cr.logger.Warn("Failed to record crawl event",
+			zap.Uint("websiteID", websiteID),
+			zap.String("event", event),
+			zap.Error(err),
+		)
+	}
+}
+
+// trapReason returns why a URL looks like a crawler trap, or an empty string
+// if it looks fine.
+func trapReason(rawURL string) string {
+	if len(rawURL) > maxTrapURLLength {
+		return fmt.Sprintf("URL length %d exceeds %d characters", len(rawURL), maxTrapURLLength)
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	segments := strings.FieldsFunc(parsed.Path, func(r rune) bool { return r == '/' })
+	if len(segments) > maxTrapPathSegments {
+		return fmt.Sprintf("path has %d segments (max %d)", len(segments), maxTrapPathSegments)
+	}
+
+	counts := make(map[string]int, len(segments))
+	for _, segment := range segments {
+		counts[segment]++
+		if counts[segment] > maxRepeatedSegments {
+			return fmt.Sprintf("path segment %q repeats more than %d times", segment, maxRepeatedSegments)
+		}
+	}
+
+	return ""
+}
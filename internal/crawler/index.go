@@ -0,0 +1,186 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"hermit/internal/contentprocessor"
+	"hermit/internal/schema"
+	"hermit/internal/tenant"
+
+	"github.com/gocolly/colly/v2"
+	"go.uber.org/zap"
+)
+
+// Outcomes of indexing a single page.
+const (
+	IndexStatusIndexed = "indexed"
+	IndexStatusFailed  = "failed"
+	IndexStatusBlocked = "blocked"
+)
+
+// IndexResult is the outcome of indexing a single page.
+type IndexResult struct {
+	URL    string `json:"url"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// IndexPages fetches and indexes the given pages of a website without
+// following links. Unlike Crawl it leaves the crawl status of the website
+// untouched. When ctx is cancelled the page being indexed is finished and
+// the others are left for a retry.
+func (cr *Crawler) IndexPages(ctx context.Context, websiteID uint, pageURLs []string) ([]IndexResult, error) {
+	stop := ctx
+	ctx = context.WithoutCancel(ctx)
+	logger := tenant.Logger(ctx, cr.logger)
+
+	website, err := cr.websiteRepo.GetByID(ctx, websiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get website: %w", err)
+	}
+	if website == nil {
+		return nil, fmt.Errorf("website %d not found", websiteID)
+	}
+
+	parsedURL, err := url.Parse(website.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse website URL: %w", err)
+	}
+
+	if err := cr.storage.EnsureBucket(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ensure Garage bucket: %w", err)
+	}
+
+	logger.Info("Indexing pages", zap.Uint("websiteID", websiteID), zap.Int("pages", len(pageURLs)))
+
+	profile := cr.requestProfile(website)
+	robots := cr.robotsPolicy(website, profile)
+	c := colly.NewCollector(
+		// colly compares hostnames, without the port
+		colly.AllowedDomains(parsedURL.Hostname()),
+		colly.MaxDepth(1),
+		colly.UserAgent(profile.userAgent),
+	)
+
+	creds := cr.loadCredentials(ctx, logger, websiteID)
+	var websiteProxies []string
+	if creds != nil {
+		websiteProxies = creds.Proxies
+	}
+	proxies := cr.proxyPoolFor(ctx, logger, websiteProxies)
+
+	transport := cr.configureTransport(c, website.TLSSkipVerify, proxies)
+	defer transport.CloseIdleConnections()
+
+	settings := cr.pageSettings(ctx, logger, website)
+	settings.stop = stop
+	c.OnRequest(func(r *colly.Request) {
+		profile.apply(*r.Headers)
+		if creds != nil {
+			creds.Apply(*r.Headers)
+		}
+	})
+	results := make([]IndexResult, len(pageURLs))
+	current := 0
+	skipped := false
+
+	c.OnResponseHeaders(func(r *colly.Response) {
+		if reason, detail := cr.skipHeaders(c, r); reason != "" {
+			r.Request.Abort()
+			skipped = true
+			results[current].Reason = detail
+			cr.recordSkip(ctx, logger, websiteID, r.Request.URL.String(), reason, detail)
+		}
+	})
+
+	c.OnResponse(func(r *colly.Response) {
+		if proxies != nil {
+			proxies.report(r.Request.ProxyURL, true)
+		}
+		if reason, detail := cr.skipResponse(c, r); reason != "" {
+			skipped = true
+			results[current].Reason = detail
+			cr.recordSkip(ctx, logger, websiteID, r.Request.URL.String(), reason, detail)
+		}
+	})
+
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		if skipped {
+			return
+		}
+		pageURL := e.Request.URL.String()
+		normalizedURL, err := contentprocessor.NormalizeURL(pageURL)
+		if err != nil {
+			results[current].Reason = "failed to normalize URL: " + err.Error()
+			return
+		}
+
+		contentType := e.Response.Headers.Get("Content-Type")
+		if cr.processPage(ctx, logger, websiteID, pageURL, normalizedURL, contentType, e.Response.Body, settings) {
+			results[current].Status = IndexStatusIndexed
+		} else if stop.Err() != nil {
+			results[current].Reason = "indexing stopped before the page was queued for vectorization"
+		} else {
+			results[current].Reason = "page could not be indexed, see crawl events"
+		}
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		if errors.Is(err, colly.ErrAbortedAfterHeaders) {
+			return
+		}
+		reason := err.Error()
+		if r.StatusCode > 0 {
+			reason = fmt.Sprintf("HTTP %d: %s", r.StatusCode, reason)
+		}
+		results[current].Reason = reason
+		logger.Error("Request failed", zap.String("url", r.Request.URL.String()), zap.Error(err))
+		cr.recordEvent(ctx, websiteID, r.Request.URL.String(), schema.CrawlEventLevelError, schema.CrawlEventError, reason)
+		cr.recordFetchError(ctx, logger, websiteID, r.Request.URL.String(), r.StatusCode, reason)
+		if proxies != nil {
+			proxies.report(r.Request.ProxyURL, !isProxyFailure(r.StatusCode))
+		}
+	})
+
+	for i, pageURL := range pageURLs {
+		if err := stop.Err(); err != nil {
+			return nil, fmt.Errorf("indexing stopped after %d of %d pages: %w", i, len(pageURLs), err)
+		}
+		current = i
+		skipped = false
+		results[i] = IndexResult{URL: pageURL, Status: IndexStatusFailed}
+
+		allowed, err := cr.robotsAllows(ctx, logger, robots, websiteID, pageURL)
+		if err != nil {
+			results[i].Reason = "failed to check robots.txt: " + err.Error()
+			continue
+		}
+		if !allowed {
+			results[i].Status = IndexStatusBlocked
+			results[i].Reason = "disallowed by robots.txt"
+			cr.recordRobotsBlocked(ctx, logger, websiteID, pageURL)
+			continue
+		}
+
+		if err := c.Visit(pageURL); err != nil && results[i].Reason == "" {
+			results[i].Reason = err.Error()
+		}
+	}
+
+	indexed := 0
+	for _, result := range results {
+		if result.Status == IndexStatusIndexed {
+			indexed++
+		}
+	}
+	logger.Info("Indexing pages completed",
+		zap.Uint("websiteID", websiteID),
+		zap.Int("pages", len(pageURLs)),
+		zap.Int("indexed", indexed),
+	)
+
+	return results, nil
+}
@@ -0,0 +1,71 @@
+package crawler
+
+import (
+	"context"
+	"time"
+
+	"hermit/internal/contentprocessor"
+)
+
+// progressInterval throttles progress reports, crawls fetch pages faster
+// than a progress bar needs.
+const progressInterval = time.Second
+
+// crawlProgress estimates how far a crawl got and reports it to the job
+// queue. The total is the pages known so far, capped at the page budget, so
+// it grows while links are discovered.
+type crawlProgress struct {
+	report func(ctx context.Context, done, total int)
+	// known holds the normalized URLs queued or fetched by this run
+	known map[string]bool
+	// base is the number of pages fetched before a resumed crawl
+	base       int
+	maxPages   int
+	reportedAt time.Time
+}
+
+// newCrawlProgress creates the progress of a crawl reporting to report, a
+// nil report disables it.
+func newCrawlProgress(report func(ctx context.Context, done, total int), base, maxPages int) *crawlProgress {
+	return &crawlProgress{
+		report:   report,
+		known:    make(map[string]bool),
+		base:     base,
+		maxPages: maxPages,
+	}
+}
+
+// discover adds a URL the crawl will fetch.
+func (p *crawlProgress) discover(pageURL string) {
+	if normalizedURL, err := contentprocessor.NormalizeURL(pageURL); err == nil {
+		p.known[normalizedURL] = true
+	}
+}
+
+// total returns the estimated number of pages of the crawl once done pages
+// were fetched.
+func (p *crawlProgress) total(done int) int {
+	total := p.base + len(p.known)
+	if p.maxPages > 0 && total > p.maxPages {
+		total = p.maxPages
+	}
+	return max(total, done)
+}
+
+// update reports the progress once done pages were fetched, at most every
+// progressInterval unless forced.
+func (p *crawlProgress) update(ctx context.Context, done int, force bool) {
+	if p.report == nil || (!force && time.Since(p.reportedAt) < progressInterval) {
+		return
+	}
+	p.reportedAt = time.Now()
+	p.report(ctx, done, p.total(done))
+}
+
+// finish reports a completed crawl, with every known page fetched.
+func (p *crawlProgress) finish(ctx context.Context, done int) {
+	if p.report == nil {
+		return
+	}
+	p.report(ctx, done, done)
+}
@@ -0,0 +1,290 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"go.uber.org/zap"
+)
+
+// errNoHealthyProxy is returned when every proxy of a pool is unhealthy.
+// Requests fail instead of falling back to a direct connection so that the
+// crawler's own address is never exposed to the site.
+var errNoHealthyProxy = errors.New("no healthy proxy available")
+
+// proxyCheckTimeout bounds a single proxy health check.
+const proxyCheckTimeout = 10 * time.Second
+
+// proxyPool rotates requests across a set of HTTP or SOCKS proxies, skipping
+// proxies that failed their health check or too many requests in a row.
+type proxyPool struct {
+	proxies     []*proxyEntry
+	next        atomic.Uint64
+	maxFailures int32
+	logger      *zap.Logger
+}
+
+// proxyEntry tracks the health of a single proxy.
+type proxyEntry struct {
+	url      *url.URL
+	healthy  atomic.Bool
+	failures atomic.Int32
+}
+
+// parseProxyURL validates a proxy URL. Supported schemes are http, https,
+// socks5 and socks5h.
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy URL %s has no host", u.Redacted())
+	}
+
+	return u, nil
+}
+
+// newProxyPool creates a pool from a list of proxy URLs. Invalid URLs are
+// logged and skipped. It returns nil when no usable proxy is left.
+func newProxyPool(rawURLs []string, maxFailures int, logger *zap.Logger) *proxyPool {
+	pool := &proxyPool{
+		maxFailures: int32(maxFailures),
+		logger:      logger,
+	}
+
+	for _, raw := range rawURLs {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		u, err := parseProxyURL(raw)
+		if err != nil {
+			logger.Warn("Ignoring invalid proxy", zap.Error(err))
+			continue
+		}
+		entry := &proxyEntry{url: u}
+		entry.healthy.Store(true)
+		pool.proxies = append(pool.proxies, entry)
+	}
+
+	if len(pool.proxies) == 0 {
+		return nil
+	}
+	return pool
+}
+
+// proxy picks the next healthy proxy in round-robin order. It matches the
+// signature of http.Transport.Proxy and records the chosen proxy on the
+// request so colly reports it in Request.ProxyURL.
+func (p *proxyPool) proxy(req *http.Request) (*url.URL, error) {
+	n := uint64(len(p.proxies))
+	start := p.next.Add(1) - 1
+
+	for i := uint64(0); i < n; i++ {
+		entry := p.proxies[(start+i)%n]
+		if !entry.healthy.Load() {
+			continue
+		}
+
+		ctx := context.WithValue(req.Context(), colly.ProxyURLKey, entry.url.String())
+		*req = *req.WithContext(ctx)
+		return entry.url, nil
+	}
+
+	return nil, errNoHealthyProxy
+}
+
+// report records the outcome of a request made through a proxy. A proxy is
+// taken out of rotation after maxFailures consecutive failures and put back
+// by the next successful health check.
+func (p *proxyPool) report(proxyURL string, ok bool) {
+	entry := p.find(proxyURL)
+	if entry == nil {
+		return
+	}
+
+	if ok {
+		entry.failures.Store(0)
+		return
+	}
+
+	failures := entry.failures.Add(1)
+	if p.maxFailures > 0 && failures >= p.maxFailures && entry.healthy.CompareAndSwap(true, false) {
+		p.logger.Warn("Proxy marked unhealthy after repeated failures",
+			zap.String("proxy", entry.url.Redacted()),
+			zap.Int32("failures", failures),
+		)
+	}
+}
+
+// find returns the entry for a proxy URL as recorded by proxy.
+func (p *proxyPool) find(proxyURL string) *proxyEntry {
+	if proxyURL == "" {
+		return nil
+	}
+	for _, entry := range p.proxies {
+		if entry.url.String() == proxyURL {
+			return entry
+		}
+	}
+	return nil
+}
+
+// isProxyFailure reports whether a failed response points at the proxy
+// rather than the page: connection errors, and status codes sites use to
+// throttle or ban an address.
+func isProxyFailure(statusCode int) bool {
+	switch statusCode {
+	case 0, http.StatusForbidden, http.StatusTooManyRequests, http.StatusProxyAuthRequired:
+		return true
+	}
+	return false
+}
+
+// check runs a health check against every proxy of the pool. Without a
+// checkURL a proxy is healthy when a TCP connection to it succeeds, otherwise
+// a GET of checkURL through the proxy must return a non-error status.
+func (p *proxyPool) check(ctx context.Context, checkURL string) {
+	var wg sync.WaitGroup
+	for _, entry := range p.proxies {
+		wg.Add(1)
+		go func(entry *proxyEntry) {
+			defer wg.Done()
+
+			err := checkProxy(ctx, entry.url, checkURL)
+			healthy := err == nil
+			if entry.healthy.Swap(healthy) != healthy {
+				if healthy {
+					p.logger.Info("Proxy is healthy again", zap.String("proxy", entry.url.Redacted()))
+				} else {
+					p.logger.Warn("Proxy health check failed",
+						zap.String("proxy", entry.url.Redacted()),
+						zap.Error(err),
+					)
+				}
+			}
+			if healthy {
+				entry.failures.Store(0)
+			}
+		}(entry)
+	}
+	wg.Wait()
+}
+
+// healthyCount returns the number of proxies currently in rotation.
+func (p *proxyPool) healthyCount() int {
+	count := 0
+	for _, entry := range p.proxies {
+		if entry.healthy.Load() {
+			count++
+		}
+	}
+	return count
+}
+
+// checkProxy probes a single proxy.
+func checkProxy(ctx context.Context, proxyURL *url.URL, checkURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, proxyCheckTimeout)
+	defer cancel()
+
+	if checkURL == "" {
+		port := proxyURL.Port()
+		if port == "" {
+			port = defaultProxyPort(proxyURL.Scheme)
+		}
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(proxyURL.Hostname(), port))
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	transport := &http.Transport{Proxy: http.ProxyURL(proxyURL)}
+	defer transport.CloseIdleConnections()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checkURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("health check returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// defaultProxyPort returns the port used for a proxy scheme when the URL
+// doesn't specify one.
+func defaultProxyPort(scheme string) string {
+	switch scheme {
+	case "https":
+		return "443"
+	case "socks5", "socks5h":
+		return "1080"
+	default:
+		return "80"
+	}
+}
+
+// proxyPoolFor returns the proxies used to crawl a website: its own proxies
+// when configured, otherwise the global pool. It returns nil when requests
+// should be sent directly.
+func (cr *Crawler) proxyPoolFor(ctx context.Context, logger *zap.Logger, proxies []string) *proxyPool {
+	if len(proxies) == 0 {
+		return cr.proxies
+	}
+
+	pool := newProxyPool(proxies, cr.config.CrawlerProxyMaxFailures, logger)
+	if pool == nil {
+		logger.Warn("Website has no usable proxies, using the global proxies")
+		return cr.proxies
+	}
+	pool.check(ctx, cr.config.CrawlerProxyCheckURL)
+	return pool
+}
+
+// RunProxyHealthChecks periodically checks the global proxies until ctx is
+// cancelled. It returns immediately when no global proxies are configured.
+func (cr *Crawler) RunProxyHealthChecks(ctx context.Context) {
+	if cr.proxies == nil || cr.config.CrawlerProxyCheckInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(cr.config.CrawlerProxyCheckInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		cr.proxies.check(ctx, cr.config.CrawlerProxyCheckURL)
+		cr.logger.Debug("Checked crawler proxies",
+			zap.Int("proxies", len(cr.proxies.proxies)),
+			zap.Int("healthy", cr.proxies.healthyCount()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"hermit/internal/schema"
+	"hermit/internal/storage"
+	"hermit/internal/tenant"
+
+	"go.uber.org/zap"
+)
+
+// ErrNoArchivedHTML is returned by ReprocessPage for pages without archived
+// HTML, e.g. fetched while ARCHIVE_RAW_HTML was disabled.
+var ErrNoArchivedHTML = errors.New("page has no archived HTML")
+
+// ReprocessReport is the outcome of reprocessing the archived pages of a
+// website.
+type ReprocessReport struct {
+	WebsiteID uint `json:"website_id"`
+	// Pages is the number of pages with archived HTML
+	Pages int `json:"pages"`
+	// Changed pages were stored and queued for vectorization again
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+	// Rejected pages failed extraction or the quality checks
+	Rejected int `json:"rejected"`
+	// Missing pages had their archived HTML deleted from storage
+	Missing     int  `json:"missing"`
+	Interrupted bool `json:"interrupted,omitempty"`
+}
+
+// ReprocessPage runs extraction, chunking and vectorization of a page again
+// from its archived HTML without fetching it. Screenshots are left as they
+// are since they need the live page. It reports whether the page was saved.
+func (cr *Crawler) ReprocessPage(ctx context.Context, websiteID, pageID uint) (bool, error) {
+	logger := tenant.Logger(ctx, cr.logger)
+
+	page, err := cr.pageRepo.GetByID(ctx, pageID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get page: %w", err)
+	}
+	if page == nil || page.WebsiteID != websiteID {
+		return false, fmt.Errorf("page %d of website %d not found", pageID, websiteID)
+	}
+
+	website, err := cr.websiteRepo.GetByID(ctx, websiteID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get website: %w", err)
+	}
+	if website == nil {
+		return false, fmt.Errorf("website %d not found", websiteID)
+	}
+
+	logger.Info("Reprocessing page from archived HTML",
+		zap.Uint("websiteID", websiteID),
+		zap.Uint("pageID", pageID),
+		zap.String("url", page.URL),
+	)
+
+	return cr.reprocessArchived(ctx, logger, page, cr.reprocessSettings(ctx, logger, website))
+}
+
+// ReprocessWebsite runs extraction and cleaning of every page of a website
+// with archived HTML again with the current code and settings, storing and
+// vectorizing only the pages whose text changed. When ctx is cancelled the
+// page being reprocessed is finished and the report covers the pages so far.
+func (cr *Crawler) ReprocessWebsite(ctx context.Context, websiteID uint) (*ReprocessReport, error) {
+	stop := ctx
+	ctx = context.WithoutCancel(ctx)
+	logger := tenant.Logger(ctx, cr.logger)
+
+	website, err := cr.websiteRepo.GetByID(ctx, websiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get website: %w", err)
+	}
+	if website == nil {
+		return nil, fmt.Errorf("website %d not found", websiteID)
+	}
+
+	pages, err := cr.pageRepo.ListArchived(ctx, websiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived pages: %w", err)
+	}
+
+	logger.Info("Reprocessing website from archived HTML",
+		zap.Uint("websiteID", websiteID),
+		zap.Int("pages", len(pages)),
+	)
+
+	settings := cr.reprocessSettings(ctx, logger, website)
+	settings.onlyChanged = true
+
+	report := &ReprocessReport{WebsiteID: websiteID, Pages: len(pages)}
+	for i := range pages {
+		if stop.Err() != nil {
+			report.Interrupted = true
+			break
+		}
+
+		page := &pages[i]
+		saved, err := cr.reprocessArchived(ctx, logger, page, settings)
+		switch {
+		case errors.Is(err, ErrNoArchivedHTML):
+			report.Missing++
+			continue
+		case err != nil:
+			return report, err
+		case !saved:
+			report.Rejected++
+			continue
+		}
+
+		updated, err := cr.pageRepo.GetByID(ctx, page.ID)
+		if err != nil {
+			return report, fmt.Errorf("failed to get page: %w", err)
+		}
+		if updated != nil && updated.ContentHash.String != page.ContentHash.String {
+			report.Changed++
+		} else {
+			report.Unchanged++
+		}
+	}
+
+	logger.Info("Reprocessed website",
+		zap.Uint("websiteID", websiteID),
+		zap.Int("changed", report.Changed),
+		zap.Int("unchanged", report.Unchanged),
+		zap.Int("rejected", report.Rejected),
+		zap.Int("missing", report.Missing),
+	)
+
+	return report, nil
+}
+
+// reprocessSettings returns the settings used to process a website's pages
+// again from archived HTML, without archiving it again or screenshotting.
+func (cr *Crawler) reprocessSettings(ctx context.Context, logger *zap.Logger, website *schema.Website) pageSettings {
+	settings := cr.pageSettings(ctx, logger, website)
+	settings.archiveHTML = false
+	settings.screenshots = false
+	settings.visualMonitoring = false
+	return settings
+}
+
+// reprocessArchived processes the archived HTML of a page. It reports
+// whether the page was saved, or returns ErrNoArchivedHTML.
+func (cr *Crawler) reprocessArchived(ctx context.Context, logger *zap.Logger, page *schema.Page, settings pageSettings) (bool, error) {
+	if !page.HTMLObjectKey.Valid {
+		return false, ErrNoArchivedHTML
+	}
+
+	html, contentType, err := cr.storage.GetPageHTML(ctx, page.HTMLObjectKey.String)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return false, ErrNoArchivedHTML
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get archived HTML: %w", err)
+	}
+
+	return cr.processPage(ctx, logger, page.WebsiteID, page.URL, page.URL, contentType, html, settings), nil
+}
@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"hermit/internal/contentprocessor"
+
+	"go.uber.org/zap"
+)
+
+// maxRetryBackoff caps the delay before a page is retried.
+const maxRetryBackoff = 24 * time.Hour
+
+// isRetryableStatus reports whether a failed fetch may succeed when retried:
+// network errors (status 0), timeouts, throttling and server errors.
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case 0, http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	}
+	return statusCode >= 500 && statusCode != http.StatusNotImplemented && statusCode != http.StatusHTTPVersionNotSupported
+}
+
+// retryBackoff returns the delay before the given retry of a page, doubling
+// the configured backoff with every retry.
+func retryBackoff(base time.Duration, retry int) time.Duration {
+	delay := base << retry
+	if delay <= 0 || delay > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return delay
+}
+
+// recordFetchError stores a failed fetch on the page and schedules a retry
+// with exponential backoff when the failure is transient.
+func (cr *Crawler) recordFetchError(ctx context.Context, logger *zap.Logger, websiteID uint, pageURL string, statusCode int, reason string) {
+	normalizedURL, err := contentprocessor.NormalizeURL(pageURL)
+	if err != nil {
+		return
+	}
+
+	page, err := cr.pageRepo.RecordFetchError(ctx, websiteID, normalizedURL, statusCode, reason)
+	if err != nil {
+		logger.Warn("Failed to record page error", zap.String("url", pageURL), zap.Error(err))
+		return
+	}
+
+	if cr.jobClient == nil || !isRetryableStatus(statusCode) || page.RetryCount >= cr.config.CrawlerPageMaxRetries {
+		return
+	}
+
+	delay := retryBackoff(time.Duration(cr.config.CrawlerPageRetryBackoff)*time.Second, page.RetryCount)
+	if err := cr.jobClient.EnqueueRetryPage(ctx, websiteID, page.ID, normalizedURL, page.RetryCount+1, delay); err != nil {
+		logger.Warn("Failed to schedule page retry", zap.String("url", pageURL), zap.Error(err))
+		return
+	}
+	if err := cr.pageRepo.IncrementRetryCount(ctx, page.ID); err != nil {
+		logger.Warn("Failed to update page retry count", zap.Uint("pageID", page.ID), zap.Error(err))
+	}
+}
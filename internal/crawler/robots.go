@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"context"
+
+	"hermit/internal/contentprocessor"
+	"hermit/internal/schema"
+
+	"go.uber.org/zap"
+)
+
+// robotsPolicy decides how robots.txt is applied to the requests of a crawl.
+type robotsPolicy struct {
+	enforcer *contentprocessor.RobotsEnforcer
+	// respect is false when robots.txt is bypassed, by CRAWLER_RESPECT_ROBOTS_TXT
+	// or the website's override
+	respect bool
+}
+
+// robotsPolicy returns the robots.txt policy of a website: its override or
+// CRAWLER_RESPECT_ROBOTS_TXT.
+func (cr *Crawler) robotsPolicy(website *schema.Website, profile *requestProfile) *robotsPolicy {
+	respect := cr.config.CrawlerRespectRobots
+	if website != nil && website.RespectRobots.Valid {
+		respect = website.RespectRobots.Bool
+	}
+	return &robotsPolicy{enforcer: profile.robots(cr.robotsEnforcer), respect: respect}
+}
+
+// allows reports whether pageURL may be fetched. When robots.txt is bypassed
+// every URL is allowed, and the URLs it disallows are recorded as ignored so
+// the bypass can be audited.
+func (cr *Crawler) robotsAllows(ctx context.Context, logger *zap.Logger, policy *robotsPolicy, websiteID uint, pageURL string) (bool, error) {
+	allowed, err := policy.enforcer.CanFetch(ctx, pageURL)
+	if err != nil {
+		if !policy.respect {
+			return true, nil
+		}
+		return false, err
+	}
+	if allowed || policy.respect {
+		return allowed, nil
+	}
+
+	logger.Warn("Fetching URL disallowed by robots.txt, robots.txt is not respected",
+		zap.Uint("websiteID", websiteID),
+		zap.String("url", pageURL),
+	)
+	cr.recordEvent(ctx, websiteID, pageURL, schema.CrawlEventLevelWarn, schema.CrawlEventRobotsIgnored,
+		"disallowed by robots.txt, fetched because robots.txt is not respected")
+	return true, nil
+}
+
+// recordRobotsBlocked records a URL that robots.txt disallows.
+func (cr *Crawler) recordRobotsBlocked(ctx context.Context, logger *zap.Logger, websiteID uint, pageURL string) {
+	logger.Debug("URL disallowed by robots.txt", zap.String("url", pageURL))
+	cr.recordEvent(ctx, websiteID, pageURL, schema.CrawlEventLevelInfo, schema.CrawlEventRobotsBlocked,
+		"disallowed by robots.txt")
+	cr.recordSkip(ctx, logger, websiteID, pageURL, schema.SkipReasonRobots, "disallowed by robots.txt")
+}
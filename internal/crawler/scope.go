@@ -0,0 +1,57 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Scope restricts a crawl to the host of a website and, when the website has
+// scope prefixes, to the URLs under them.
+type Scope struct {
+	host  string
+	paths []string
+}
+
+// NewScope creates the scope of a website from its URL and scope prefixes.
+// Prefixes must be URLs on the host of the website.
+func NewScope(websiteURL string, prefixes []string) (*Scope, error) {
+	u, err := url.Parse(websiteURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid website URL: %w", err)
+	}
+
+	scope := &Scope{host: u.Host}
+	for _, prefix := range prefixes {
+		p, err := url.Parse(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scope prefix %q: %w", prefix, err)
+		}
+		if !strings.EqualFold(p.Host, u.Host) {
+			return nil, fmt.Errorf("scope prefix %q is not on %s", prefix, u.Host)
+		}
+		scope.paths = append(scope.paths, strings.TrimSuffix(p.Path, "/"))
+	}
+
+	return scope, nil
+}
+
+// Contains reports whether rawURL is in the scope. Prefixes match whole path
+// segments, so "https://example.com/docs/" contains
+// "https://example.com/docs/intro" but not "https://example.com/docsearch".
+func (s *Scope) Contains(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.EqualFold(u.Host, s.host) {
+		return false
+	}
+	if len(s.paths) == 0 {
+		return true
+	}
+
+	for _, path := range s.paths {
+		if u.Path == path || strings.HasPrefix(u.Path, path+"/") {
+			return true
+		}
+	}
+	return false
+}
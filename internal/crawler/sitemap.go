@@ -0,0 +1,95 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"hermit/internal/contentprocessor"
+	"hermit/internal/schema"
+
+	"go.uber.org/zap"
+)
+
+// lastmodSlack is added to sitemap lastmod values before comparing them to
+// crawl times, as many sitemaps only give dates and a page changed later on
+// the day it was crawled would otherwise never be indexed again.
+const lastmodSlack = 24 * time.Hour
+
+// sitemapSeeds are the pages listed in a website's sitemaps.
+type sitemapSeeds struct {
+	// pages are the same-host page URLs to visit
+	pages []string
+	// unchanged holds the normalized URLs of pages whose lastmod is older
+	// than their last successful crawl
+	unchanged map[string]bool
+}
+
+// sitemapPages discovers the sitemaps of a website, stores them when they
+// changed and returns the same-host pages they list, at most limit when
+// positive. With CRAWLER_SITEMAP_LASTMOD, pages with a lastmod older than
+// their last crawl are marked unchanged. Discovery failures only mean the
+// crawl isn't seeded.
+func (cr *Crawler) sitemapPages(ctx context.Context, logger *zap.Logger, robots *contentprocessor.RobotsEnforcer, website *schema.Website, startURL string, limit int) sitemapSeeds {
+	var seeds sitemapSeeds
+	if website == nil {
+		return seeds
+	}
+
+	sitemaps, err := robots.DiscoverSitemaps(ctx, startURL)
+	if err != nil {
+		logger.Warn("Failed to discover sitemaps", zap.String("url", startURL), zap.Error(err))
+		sitemaps = website.SitemapURLs
+	} else if !slices.Equal(sitemaps, website.SitemapURLs) {
+		if err := cr.websiteRepo.SetSitemaps(ctx, website.ID, sitemaps); err != nil {
+			logger.Warn("Failed to store discovered sitemaps", zap.Uint("websiteID", website.ID), zap.Error(err))
+		}
+	}
+	if len(sitemaps) == 0 {
+		return seeds
+	}
+
+	parsedStart, err := url.Parse(startURL)
+	if err != nil {
+		return seeds
+	}
+
+	var crawled map[string]time.Time
+	if cr.config.CrawlerSitemapLastmod {
+		crawled, err = cr.pageRepo.GetCrawlTimes(ctx, website.ID)
+		if err != nil {
+			logger.Warn("Failed to get page crawl times, fetching all sitemap pages", zap.Error(err))
+		}
+	}
+
+	seeds.unchanged = make(map[string]bool)
+	for _, entry := range robots.GetSitemapEntries(ctx, sitemaps, limit) {
+		u, err := url.Parse(entry.URL)
+		if err != nil || !strings.EqualFold(u.Host, parsedStart.Host) {
+			continue
+		}
+		seeds.pages = append(seeds.pages, entry.URL)
+
+		if entry.LastMod.IsZero() || len(crawled) == 0 {
+			continue
+		}
+		normalizedURL, err := contentprocessor.NormalizeURL(entry.URL)
+		if err != nil {
+			continue
+		}
+		if crawledAt, ok := crawled[normalizedURL]; ok && entry.LastMod.Add(lastmodSlack).Before(crawledAt) {
+			seeds.unchanged[normalizedURL] = true
+		}
+	}
+
+	logger.Info("Loaded pages from sitemaps",
+		zap.Uint("websiteID", website.ID),
+		zap.Int("sitemaps", len(sitemaps)),
+		zap.Int("pages", len(seeds.pages)),
+		zap.Int("unchanged", len(seeds.unchanged)),
+	)
+
+	return seeds
+}
@@ -0,0 +1,96 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"hermit/internal/contentprocessor"
+	"hermit/internal/schema"
+
+	"github.com/gocolly/colly/v2"
+	"go.uber.org/zap"
+)
+
+// recordSkip records why a discovered URL was not indexed so it shows up in
+// the pages API with status skipped.
+func (cr *Crawler) recordSkip(ctx context.Context, logger *zap.Logger, websiteID uint, pageURL, reason, detail string) {
+	normalizedURL, err := contentprocessor.NormalizeURL(pageURL)
+	if err != nil {
+		return
+	}
+
+	if err := cr.pageRepo.RecordSkipped(ctx, websiteID, normalizedURL, reason, detail); err != nil {
+		logger.Warn("Failed to record skipped page",
+			zap.String("url", pageURL),
+			zap.String("reason", reason),
+			zap.Error(err),
+		)
+	}
+}
+
+// parseContentTypes parses a comma separated list of media types.
+func parseContentTypes(list string) map[string]bool {
+	types := make(map[string]bool)
+	for _, mediaType := range strings.Split(list, ",") {
+		if mediaType = strings.ToLower(strings.TrimSpace(mediaType)); mediaType != "" {
+			types[mediaType] = true
+		}
+	}
+	return types
+}
+
+// indexable reports whether a Content-Type header describes a document the
+// crawler indexes. Responses without a Content-Type are assumed to be HTML.
+func (cr *Crawler) indexable(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return cr.contentTypes[mediaType]
+}
+
+// skipHeaders returns the reason a response should not be downloaded based
+// on its headers, or an empty reason when it should. Error responses are
+// left to the error handling.
+func (cr *Crawler) skipHeaders(c *colly.Collector, r *colly.Response) (string, string) {
+	if r.StatusCode < 200 || r.StatusCode >= 300 {
+		return "", ""
+	}
+	contentType := r.Headers.Get("Content-Type")
+	if !cr.indexable(contentType) {
+		return schema.SkipReasonMIME, "content type " + contentType + " is not indexed"
+	}
+	// Compressed responses have no length until they are decoded
+	if length, err := strconv.ParseInt(r.Headers.Get("Content-Length"), 10, 64); err == nil && c.MaxBodySize > 0 && length > int64(c.MaxBodySize) {
+		return schema.SkipReasonSize, fmt.Sprintf("response of %d bytes exceeds the maximum body size of %d bytes", length, c.MaxBodySize)
+	}
+	return "", ""
+}
+
+// skipResponse returns the reason a fetched response should not be indexed,
+// or an empty reason when it should be processed.
+func (cr *Crawler) skipResponse(c *colly.Collector, r *colly.Response) (string, string) {
+	contentType := r.Headers.Get("Content-Type")
+	if !cr.indexable(contentType) {
+		return schema.SkipReasonMIME, "content type " + contentType + " is not indexed"
+	}
+	// colly truncates bodies at MaxBodySize, so a body of that size is incomplete
+	if c.MaxBodySize > 0 && len(r.Body) >= c.MaxBodySize {
+		return schema.SkipReasonSize, fmt.Sprintf("response exceeds the maximum body size of %d bytes", c.MaxBodySize)
+	}
+	return "", ""
+}
+
+// sameHost reports whether rawURL is on host. Links to other hosts are not
+// part of the website and aren't recorded as skipped pages.
+func sameHost(rawURL, host string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && strings.EqualFold(u.Host, host)
+}
@@ -0,0 +1,179 @@
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"hermit/internal/config"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// dnsCache caches host lookups so that a crawl does not resolve the same
+// host for every request.
+type dnsCache struct {
+	resolver *net.Resolver
+	ttl      time.Duration
+	mu       sync.RWMutex
+	entries  map[string]dnsCacheEntry
+}
+
+// dnsCacheEntry represents a cached DNS lookup.
+type dnsCacheEntry struct {
+	addrs     []string
+	expiresAt time.Time
+}
+
+// newDNSCache creates a new dnsCache.
+func newDNSCache(ttl time.Duration) *dnsCache {
+	return &dnsCache{
+		resolver: net.DefaultResolver,
+		ttl:      ttl,
+		entries:  make(map[string]dnsCacheEntry),
+	}
+}
+
+// lookup returns the addresses for host, resolving them if not cached.
+func (d *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	d.mu.RLock()
+	entry, ok := d.entries[host]
+	d.mu.RUnlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := d.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.entries[host] = dnsCacheEntry{
+		addrs:     addrs,
+		expiresAt: time.Now().Add(d.ttl),
+	}
+	d.mu.Unlock()
+
+	return addrs, nil
+}
+
+// dialContext returns a DialContext function that resolves hosts through the cache.
+func (d *dnsCache) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		// IP literals don't need resolving
+		if net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		addrs, err := d.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("no addresses found for host %s", host)
+		}
+
+		// Try addresses starting from a random offset to spread load
+		offset := rand.Intn(len(addrs))
+		var lastErr error
+		for i := range addrs {
+			ip := addrs[(offset+i)%len(addrs)]
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+
+		return nil, lastErr
+	}
+}
+
+// newTransport builds the HTTP transport used by the crawler. Requests go
+// through the given proxy pool, or the proxy from the environment when nil.
+func newTransport(cfg *config.Config, dns *dnsCache, skipVerify bool, proxies *proxyPool) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout(cfg),
+		KeepAlive: 30 * time.Second,
+	}
+
+	dial := dialer.DialContext
+	if dns != nil {
+		dial = dns.dialContext(dialer)
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if proxies != nil {
+		proxy = proxies.proxy
+	}
+
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dial,
+		ForceAttemptHTTP2:     cfg.CrawlerHTTP2Enabled,
+		MaxIdleConns:          cfg.CrawlerMaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.CrawlerMaxConnsPerHost,
+		MaxConnsPerHost:       cfg.CrawlerMaxConnsPerHost,
+		IdleConnTimeout:       time.Duration(cfg.CrawlerIdleConnTimeout) * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: time.Duration(cfg.CrawlerTimeout) * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig: &tls.Config{
+			MinVersion:         tlsVersion(cfg.CrawlerTLSMinVersion),
+			InsecureSkipVerify: skipVerify || cfg.CrawlerTLSSkipVerify, // Opt-in for intranet sites with self-signed certs
+		},
+	}
+}
+
+// dialTimeout returns the timeout for connecting to a server, at most the
+// crawler timeout.
+func dialTimeout(cfg *config.Config) time.Duration {
+	timeout := 30 * time.Second
+	if crawlerTimeout := time.Duration(cfg.CrawlerTimeout) * time.Second; crawlerTimeout > 0 && crawlerTimeout < timeout {
+		timeout = crawlerTimeout
+	}
+	return timeout
+}
+
+// configureTransport sets up the transport, timeouts and body size limit of
+// a collector. The returned transport's idle connections should be closed
+// once the collector is done.
+func (cr *Crawler) configureTransport(c *colly.Collector, skipVerify bool, proxies *proxyPool) *http.Transport {
+	// Use a tuned transport with keep-alive pooling, HTTP/2, cached DNS,
+	// rotating proxies and gzip/brotli decoding
+	transport := newTransport(cr.config, cr.dnsCache, skipVerify, proxies)
+	c.WithTransport(newDecodingTransport(transport))
+	if cr.config.CrawlerTimeout > 0 {
+		c.SetRequestTimeout(time.Duration(cr.config.CrawlerTimeout) * time.Second)
+	}
+	if cr.config.CrawlerMaxBodySize > 0 {
+		c.MaxBodySize = cr.config.CrawlerMaxBodySize
+	}
+	return transport
+}
+
+// tlsVersion maps a version string like "1.2" to its tls constant.
+func tlsVersion(version string) uint16 {
+	switch version {
+	case "1.0":
+		return tls.VersionTLS10
+	case "1.1":
+		return tls.VersionTLS11
+	case "1.3":
+		return tls.VersionTLS13
+	default:
+		return tls.VersionTLS12
+	}
+}
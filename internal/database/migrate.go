@@ -0,0 +1,28 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
+
+// Migrate runs a goose command (up, down, status, ...) with the migrations of
+// dir against the database.
+func Migrate(ctx context.Context, databaseURL, dir, command string, args ...string) error {
+	if databaseURL == "" {
+		return fmt.Errorf("missing DATABASE_URL environment variable")
+	}
+
+	db, err := goose.OpenDBWithDriver("postgres", databaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open DB: %w", err)
+	}
+	defer db.Close()
+
+	if err := goose.RunContext(ctx, command, db, dir, args...); err != nil {
+		return fmt.Errorf("goose %s: %w", command, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Package deadline records which stages of a request completed, so requests
+// that run out of time can report how far they got.
+package deadline
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type contextKey struct{}
+
+// Stage is a completed stage of a request.
+type Stage struct {
+	Name     string `json:"stage"`
+	Duration string `json:"duration"`
+}
+
+// Trace collects the stages of a single request.
+type Trace struct {
+	mu        sync.Mutex
+	completed []Stage
+	pending   map[string]time.Time
+}
+
+// WithTrace returns a copy of ctx carrying a new Trace.
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	t := &Trace{pending: make(map[string]time.Time)}
+	return context.WithValue(ctx, contextKey{}, t), t
+}
+
+// Track marks the start of a stage and returns a function marking its end.
+// It is a no-op when ctx carries no Trace.
+func Track(ctx context.Context, name string) func() {
+	t, ok := ctx.Value(contextKey{}).(*Trace)
+	if !ok {
+		return func() {}
+	}
+
+	start := time.Now()
+	t.mu.Lock()
+	t.pending[name] = start
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.pending, name)
+		t.completed = append(t.completed, Stage{
+			Name:     name,
+			Duration: time.Since(start).Round(time.Millisecond).String(),
+		})
+	}
+}
+
+// Completed returns the stages that finished, in order.
+func (t *Trace) Completed() []Stage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stages := make([]Stage, len(t.completed))
+	copy(stages, t.completed)
+	return stages
+}
+
+// Pending returns the stages that were still running.
+func (t *Trace) Pending() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	names := make([]string, 0, len(t.pending))
+	for name := range t.pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
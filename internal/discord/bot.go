@@ -0,0 +1,221 @@
+package discord
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/config"
+	"hermit/internal/llm"
+	"hermit/internal/repositories"
+	"hermit/internal/schema"
+	"hermit/internal/service"
+	"hermit/internal/tenant"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// updateInterval throttles the edits streaming an answer, Discord rate
+	// limits webhook edits to about five per few seconds
+	updateInterval = 1500 * time.Millisecond
+	// answerTimeout bounds answering a question, interaction tokens are valid
+	// for 15 minutes
+	answerTimeout = 2 * time.Minute
+	// maxSources is the number of source links listed under an answer
+	maxSources = 5
+	// maxThreadHistory is the number of messages of a thread passed as
+	// context to follow-up questions
+	maxThreadHistory = 10
+	// threadHistoryTTL forgets the conversation of idle threads
+	threadHistoryTTL = 24 * time.Hour
+)
+
+// Bot answers questions asked in linked channels from their website.
+type Bot struct {
+	client      *Client
+	publicKey   ed25519.PublicKey
+	channelRepo *repositories.DiscordChannelRepository
+	websiteRepo *repositories.WebsiteRepository
+	queries     *service.QueryService
+	threads     *llm.ChatHistories
+	logger      *zap.Logger
+}
+
+// NewBot creates a new Bot. It returns nil when no application ID or valid
+// public key is configured, which disables the Discord app.
+func NewBot(
+	cfg *config.Config,
+	channelRepo *repositories.DiscordChannelRepository,
+	websiteRepo *repositories.WebsiteRepository,
+	queries *service.QueryService,
+	logger *zap.Logger,
+) *Bot {
+	if cfg.DiscordApplicationID == "" || cfg.DiscordPublicKey == "" {
+		return nil
+	}
+	publicKey, err := hex.DecodeString(cfg.DiscordPublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		logger.Warn("Invalid DISCORD_PUBLIC_KEY, the Discord app is disabled")
+		return nil
+	}
+	return &Bot{
+		client:      NewClient(cfg.DiscordApplicationID, cfg.DiscordBotToken, time.Duration(cfg.HTTPTimeout)*time.Second),
+		publicKey:   publicKey,
+		channelRepo: channelRepo,
+		websiteRepo: websiteRepo,
+		queries:     queries,
+		threads:     llm.NewChatHistories(maxThreadHistory, threadHistoryTTL),
+		logger:      logger.Named("discord"),
+	}
+}
+
+// Verify checks that an interaction was signed by Discord.
+func (b *Bot) Verify(header http.Header, body []byte) error {
+	return VerifyRequest(b.publicKey, header, body)
+}
+
+// CanRegisterCommands reports whether a bot token is configured to register
+// the /ask command.
+func (b *Bot) CanRegisterCommands() bool {
+	return b.client.botToken != ""
+}
+
+// RegisterCommands registers the /ask command of the app.
+func (b *Bot) RegisterCommands(ctx context.Context) error {
+	return b.client.RegisterCommands(ctx)
+}
+
+// Resolve returns the channel link and website a question asked in the
+// channel of an interaction is answered from. Questions asked in a thread
+// use the link of its parent channel. It returns apperrors.NotFound when the
+// channel isn't linked.
+func (b *Bot) Resolve(ctx context.Context, interaction *Interaction) (*schema.DiscordChannel, *schema.Website, error) {
+	channelID := interaction.ChannelID
+	if interaction.InThread() && interaction.Channel.ParentID != "" {
+		channelID = interaction.Channel.ParentID
+	}
+
+	channel, err := b.channelRepo.Get(ctx, channelID)
+	if err != nil {
+		return nil, nil, apperrors.Internal("Failed to look up channel", err)
+	}
+	if channel == nil {
+		return nil, nil, apperrors.NotFound("This channel isn't linked to a website")
+	}
+
+	website, err := b.websiteRepo.GetByID(ctx, channel.WebsiteID)
+	if err != nil {
+		return nil, nil, apperrors.Internal("Failed to look up website", err)
+	}
+	if website == nil {
+		return nil, nil, apperrors.NotFound("The website linked to this channel no longer exists")
+	}
+	return channel, website, nil
+}
+
+// Answer answers the /ask command of an interaction by editing its deferred
+// response as the answer streams in. Questions asked in a thread are
+// answered as follow-ups of the previous questions of the thread. Failures
+// are reported in the response.
+func (b *Bot) Answer(ctx context.Context, interaction *Interaction) {
+	ctx, cancel := context.WithTimeout(ctx, answerTimeout)
+	defer cancel()
+	logger := b.logger.With(zap.String("channel", interaction.ChannelID), zap.String("discordUser", interaction.UserID()))
+
+	channel, website, err := b.Resolve(ctx, interaction)
+	if err != nil {
+		b.reply(ctx, logger, interaction, err)
+		return
+	}
+
+	// Attribute the question to the user who linked the channel
+	if channel.CreatedBy != nil {
+		ctx = tenant.WithTenant(ctx, tenant.Tenant{UserID: channel.CreatedBy.String()})
+	}
+	logger = tenant.Logger(ctx, logger).With(zap.Uint("websiteID", website.ID))
+
+	question := interaction.Option("question")
+	header := fmt.Sprintf("<@%s> asked: %s", interaction.UserID(), question)
+
+	var history []llm.ChatMessage
+	if interaction.InThread() {
+		history = b.threads.Get(interaction.ChannelID)
+	}
+
+	var answer strings.Builder
+	lastUpdate := time.Now()
+	meta, err := b.queries.Chat(ctx, website, question, history,
+		func(*llm.QueryStreamMeta) error { return nil },
+		func(chunk string) error {
+			answer.WriteString(chunk)
+			if time.Since(lastUpdate) < updateInterval {
+				return nil
+			}
+			lastUpdate = time.Now()
+			if err := b.client.EditResponse(ctx, interaction.Token, header+"\n"+strings.TrimSpace(answer.String())+" ..."); err != nil {
+				logger.Debug("Failed to update streaming answer", zap.Error(err))
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		logger.Warn("Failed to answer question", zap.Error(err))
+		b.reply(ctx, logger, interaction, err)
+		return
+	}
+
+	if interaction.InThread() {
+		b.threads.Record(interaction.ChannelID, question, answer.String())
+	}
+
+	// Keep the sources when the answer is too long for a message
+	sources := formatSources(meta.Sources)
+	text := header + "\n" + strings.TrimSpace(answer.String())
+	if limit := maxMessageLength - len(sources); len(text) > limit {
+		text = text[:limit-3] + "..."
+	}
+	if err := b.client.EditResponse(ctx, interaction.Token, text+sources); err != nil {
+		logger.Warn("Failed to post answer", zap.Error(err))
+	}
+}
+
+// reply reports an error in the response to an interaction.
+func (b *Bot) reply(ctx context.Context, logger *zap.Logger, interaction *Interaction, err error) {
+	message := "Sorry, something went wrong answering your question."
+	if appErr, ok := apperrors.As(err); ok && appErr.Code != apperrors.CodeInternal {
+		message = appErr.Message
+	}
+
+	if err := b.client.EditResponse(ctx, interaction.Token, ":warning: "+message); err != nil {
+		logger.Warn("Failed to report error in channel", zap.Error(err))
+	}
+}
+
+// formatSources lists the distinct pages an answer was generated from, with
+// link embeds suppressed.
+func formatSources(sources []llm.QuerySource) string {
+	var b strings.Builder
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		if seen[source.PageURL] || len(seen) == maxSources {
+			continue
+		}
+		seen[source.PageURL] = true
+
+		title := source.PageTitle
+		if title == "" {
+			title = source.PageURL
+		}
+		if b.Len() == 0 {
+			b.WriteString("\n\n**Sources**")
+		}
+		fmt.Fprintf(&b, "\n- [%s](<%s>)", strings.NewReplacer("[", "(", "]", ")").Replace(title), source.PageURL)
+	}
+	return b.String()
+}
@@ -0,0 +1,208 @@
+// Package discord answers the /ask command of a Discord app in channels
+// linked to a website, through Discord's HTTP interactions.
+package discord
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const apiURL = "https://discord.com/api/v10"
+
+// Interaction types and the responses to them.
+const (
+	InteractionPing               = 1
+	InteractionApplicationCommand = 2
+
+	ResponsePong                   = 1
+	ResponseChannelMessage         = 4
+	ResponseDeferredChannelMessage = 5
+)
+
+// Channel types of threads, whose questions are follow-ups of each other.
+const (
+	channelAnnouncementThread = 10
+	channelPublicThread       = 11
+	channelPrivateThread      = 12
+)
+
+// messageFlagEphemeral shows a message to the user who invoked the command
+// only.
+const messageFlagEphemeral = 1 << 6
+
+// maxMessageLength is the maximum length of a message's content.
+const maxMessageLength = 2000
+
+// ErrInvalidSignature is returned for requests not signed by Discord.
+var ErrInvalidSignature = errors.New("invalid discord signature")
+
+// VerifyRequest checks that an interaction was signed by Discord with the
+// key of the app.
+func VerifyRequest(publicKey ed25519.PublicKey, header http.Header, body []byte) error {
+	signature, err := hex.DecodeString(header.Get("X-Signature-Ed25519"))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return ErrInvalidSignature
+	}
+
+	message := append([]byte(header.Get("X-Signature-Timestamp")), body...)
+	if !ed25519.Verify(publicKey, message, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Interaction is an interaction sent by Discord, such as a command.
+type Interaction struct {
+	Type      int    `json:"type" example:"2"`
+	Token     string `json:"token"`
+	ChannelID string `json:"channel_id"`
+	Channel   struct {
+		Type     int    `json:"type"`
+		ParentID string `json:"parent_id,omitempty"`
+	} `json:"channel"`
+	Data struct {
+		Name    string `json:"name" example:"ask"`
+		Options []struct {
+			Name  string `json:"name" example:"question"`
+			Value any    `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+	// Member is set in guilds, User in direct messages
+	Member *struct {
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	} `json:"member,omitempty"`
+	User *struct {
+		ID string `json:"id"`
+	} `json:"user,omitempty"`
+}
+
+// Option returns the string value of a command option.
+func (i *Interaction) Option(name string) string {
+	for _, option := range i.Data.Options {
+		if option.Name == name {
+			value, _ := option.Value.(string)
+			return value
+		}
+	}
+	return ""
+}
+
+// UserID returns the ID of the user who sent the interaction.
+func (i *Interaction) UserID() string {
+	if i.Member != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// InThread reports whether the interaction was sent in a thread.
+func (i *Interaction) InThread() bool {
+	switch i.Channel.Type {
+	case channelAnnouncementThread, channelPublicThread, channelPrivateThread:
+		return true
+	}
+	return false
+}
+
+// InteractionResponse answers an interaction.
+type InteractionResponse struct {
+	Type int                  `json:"type" example:"5"`
+	Data *InteractionCallback `json:"data,omitempty"`
+}
+
+// InteractionCallback is the message answering an interaction.
+type InteractionCallback struct {
+	Content string `json:"content"`
+	Flags   int    `json:"flags,omitempty"`
+}
+
+// EphemeralMessage returns a response showing content to the user who sent
+// the interaction only.
+func EphemeralMessage(content string) InteractionResponse {
+	return InteractionResponse{
+		Type: ResponseChannelMessage,
+		Data: &InteractionCallback{Content: content, Flags: messageFlagEphemeral},
+	}
+}
+
+// Client calls the Discord API for an app.
+type Client struct {
+	applicationID string
+	botToken      string
+	client        *http.Client
+}
+
+// NewClient creates a new Client. The bot token is only needed to register
+// commands.
+func NewClient(applicationID, botToken string, timeout time.Duration) *Client {
+	return &Client{applicationID: applicationID, botToken: botToken, client: &http.Client{Timeout: timeout}}
+}
+
+// EditResponse replaces the content of the response to an interaction.
+func (c *Client) EditResponse(ctx context.Context, token, content string) error {
+	if len(content) > maxMessageLength {
+		content = content[:maxMessageLength-3] + "..."
+	}
+	path := fmt.Sprintf("/webhooks/%s/%s/messages/@original", c.applicationID, token)
+	return c.call(ctx, http.MethodPatch, path, map[string]any{
+		"content":          content,
+		"allowed_mentions": map[string]any{"parse": []string{}},
+	}, false)
+}
+
+// RegisterCommands registers the /ask command of the app, replacing its
+// global commands.
+func (c *Client) RegisterCommands(ctx context.Context) error {
+	commands := []map[string]any{{
+		"name":        "ask",
+		"description": "Ask a question about the website linked to this channel",
+		"options": []map[string]any{{
+			"type":        3, // string
+			"name":        "question",
+			"description": "Your question",
+			"required":    true,
+		}},
+	}}
+	return c.call(ctx, http.MethodPut, fmt.Sprintf("/applications/%s/commands", c.applicationID), commands, true)
+}
+
+func (c *Client) call(ctx context.Context, method, path string, payload any, authenticated bool) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, apiURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authenticated {
+		req.Header.Set("Authorization", "Bot "+c.botToken)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("discord %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("discord %s: HTTP %d: %s", method, resp.StatusCode, bytes.TrimSpace(data))
+	}
+	return nil
+}
@@ -0,0 +1,560 @@
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// chromaVersion is the ChromaDB version the stub reports, one with tenants
+// and databases so the client runs its pre-flight checks.
+const chromaVersion = "0.5.23"
+
+// Chroma is a stub ChromaDB server keeping collections in memory. It serves
+// the parts of the v1 API the vectorizer uses: collections, upserts, gets,
+// deletes and nearest neighbor queries filtered with where clauses.
+type Chroma struct {
+	server *httptest.Server
+
+	mu          sync.Mutex
+	collections map[string]*chromaCollection
+}
+
+// chromaCollection is a collection with its records in insertion order.
+type chromaCollection struct {
+	ID       string                 `json:"id"`
+	Name     string                 `json:"name"`
+	Metadata map[string]interface{} `json:"metadata"`
+	Tenant   string                 `json:"tenant"`
+	Database string                 `json:"database"`
+
+	records []chromaRecord
+	index   map[string]int
+}
+
+// chromaRecord is an embedded chunk of a collection.
+type chromaRecord struct {
+	ID        string
+	Embedding []float32
+	Document  string
+	Metadata  map[string]interface{}
+}
+
+// newChroma starts a stub ChromaDB server.
+func newChroma() *Chroma {
+	c := &Chroma{collections: map[string]*chromaCollection{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`"` + chromaVersion + `"`))
+	})
+	mux.HandleFunc("GET /api/v1/heartbeat", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]int64{"nanosecond heartbeat": time.Now().UnixNano()})
+	})
+	mux.HandleFunc("GET /api/v1/tenants/{tenant}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"name": r.PathValue("tenant")})
+	})
+	mux.HandleFunc("GET /api/v1/databases/{database}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"id":     r.PathValue("database"),
+			"name":   r.PathValue("database"),
+			"tenant": r.URL.Query().Get("tenant"),
+		})
+	})
+	mux.HandleFunc("GET /api/v1/pre-flight-checks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]int{"max_batch_size": 5461})
+	})
+	mux.HandleFunc("GET /api/v1/collections", c.listCollections)
+	mux.HandleFunc("POST /api/v1/collections", c.createCollection)
+	mux.HandleFunc("GET /api/v1/collections/{name}", c.getCollection)
+	mux.HandleFunc("DELETE /api/v1/collections/{name}", c.deleteCollection)
+	mux.HandleFunc("POST /api/v1/collections/{id}/add", c.upsert)
+	mux.HandleFunc("POST /api/v1/collections/{id}/upsert", c.upsert)
+	mux.HandleFunc("POST /api/v1/collections/{id}/get", c.get)
+	mux.HandleFunc("POST /api/v1/collections/{id}/delete", c.delete)
+	mux.HandleFunc("POST /api/v1/collections/{id}/query", c.query)
+	mux.HandleFunc("GET /api/v1/collections/{id}/count", c.count)
+	c.server = httptest.NewServer(mux)
+	return c
+}
+
+// URL returns the base URL of the server.
+func (c *Chroma) URL() string {
+	return c.server.URL
+}
+
+// Count returns the number of records of a collection, 0 when it doesn't
+// exist.
+func (c *Chroma) Count(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if collection, ok := c.collections[name]; ok {
+		return len(collection.records)
+	}
+	return 0
+}
+
+// Collections returns the names of the collections.
+func (c *Chroma) Collections() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.collections))
+	for name := range c.collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close stops the server.
+func (c *Chroma) Close() {
+	c.server.Close()
+}
+
+// chromaError writes an error the way ChromaDB does.
+func chromaError(w http.ResponseWriter, code int, id, format string, args ...interface{}) {
+	writeJSON(w, code, map[string]string{"error": id, "message": fmt.Sprintf(format, args...)})
+}
+
+// decode reads a JSON request body, answering bad requests itself.
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		chromaError(w, http.StatusBadRequest, "InvalidArgumentError", "invalid request body: %v", err)
+		return false
+	}
+	return true
+}
+
+// byID returns the collection of the id path value, answering with an error
+// when it doesn't exist. It is called with mu held.
+func (c *Chroma) byID(w http.ResponseWriter, r *http.Request) *chromaCollection {
+	id := r.PathValue("id")
+	for _, collection := range c.collections {
+		if collection.ID == id {
+			return collection
+		}
+	}
+	chromaError(w, http.StatusNotFound, "NotFoundError", "Collection %s does not exist.", id)
+	return nil
+}
+
+func (c *Chroma) listCollections(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	collections := make([]*chromaCollection, 0, len(c.collections))
+	for _, collection := range c.collections {
+		collections = append(collections, collection)
+	}
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Name < collections[j].Name })
+	writeJSON(w, http.StatusOK, collections)
+}
+
+func (c *Chroma) createCollection(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name        string                 `json:"name"`
+		Metadata    map[string]interface{} `json:"metadata"`
+		GetOrCreate bool                   `json:"get_or_create"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if existing, ok := c.collections[req.Name]; ok {
+		if !req.GetOrCreate {
+			chromaError(w, http.StatusConflict, "UniqueConstraintError", "Collection %s already exists", req.Name)
+			return
+		}
+		writeJSON(w, http.StatusOK, existing)
+		return
+	}
+
+	collection := &chromaCollection{
+		ID:       ulid.Make().String(),
+		Name:     req.Name,
+		Metadata: req.Metadata,
+		Tenant:   r.URL.Query().Get("tenant"),
+		Database: r.URL.Query().Get("database"),
+		index:    map[string]int{},
+	}
+	c.collections[req.Name] = collection
+	writeJSON(w, http.StatusOK, collection)
+}
+
+func (c *Chroma) getCollection(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	collection, ok := c.collections[r.PathValue("name")]
+	if !ok {
+		chromaError(w, http.StatusNotFound, "NotFoundError", "Collection %s does not exist.", r.PathValue("name"))
+		return
+	}
+	writeJSON(w, http.StatusOK, collection)
+}
+
+func (c *Chroma) deleteCollection(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := r.PathValue("name")
+	if _, ok := c.collections[name]; !ok {
+		chromaError(w, http.StatusNotFound, "NotFoundError", "Collection %s does not exist.", name)
+		return
+	}
+	delete(c.collections, name)
+	writeJSON(w, http.StatusOK, nil)
+}
+
+func (c *Chroma) upsert(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Embeddings [][]float32              `json:"embeddings"`
+		Metadatas  []map[string]interface{} `json:"metadatas"`
+		Documents  []string                 `json:"documents"`
+		IDs        []string                 `json:"ids"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	if len(req.Embeddings) != len(req.IDs) {
+		chromaError(w, http.StatusBadRequest, "InvalidArgumentError", "got %d embeddings for %d ids", len(req.Embeddings), len(req.IDs))
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	collection := c.byID(w, r)
+	if collection == nil {
+		return
+	}
+	for i, id := range req.IDs {
+		record := chromaRecord{ID: id, Embedding: req.Embeddings[i]}
+		if i < len(req.Documents) {
+			record.Document = req.Documents[i]
+		}
+		if i < len(req.Metadatas) {
+			record.Metadata = req.Metadatas[i]
+		}
+		if at, ok := collection.index[id]; ok {
+			collection.records[at] = record
+			continue
+		}
+		collection.index[id] = len(collection.records)
+		collection.records = append(collection.records, record)
+	}
+	writeJSON(w, http.StatusOK, true)
+}
+
+func (c *Chroma) get(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs     []string               `json:"ids"`
+		Where   map[string]interface{} `json:"where"`
+		Limit   int                    `json:"limit"`
+		Offset  int                    `json:"offset"`
+		Include []string               `json:"include"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	collection := c.byID(w, r)
+	if collection == nil {
+		return
+	}
+
+	matches := collection.filter(req.IDs, req.Where)
+	if req.Offset >= len(matches) {
+		matches = nil
+	} else {
+		matches = matches[req.Offset:]
+	}
+	if req.Limit > 0 && req.Limit < len(matches) {
+		matches = matches[:req.Limit]
+	}
+
+	include := includes(req.Include)
+	resp := struct {
+		IDs        []string                 `json:"ids"`
+		Embeddings [][]float32              `json:"embeddings"`
+		Documents  []string                 `json:"documents"`
+		Metadatas  []map[string]interface{} `json:"metadatas"`
+	}{IDs: []string{}}
+	for _, record := range matches {
+		resp.IDs = append(resp.IDs, record.ID)
+		if include["embeddings"] {
+			resp.Embeddings = append(resp.Embeddings, record.Embedding)
+		}
+		if include["documents"] {
+			resp.Documents = append(resp.Documents, record.Document)
+		}
+		if include["metadatas"] {
+			resp.Metadatas = append(resp.Metadatas, record.Metadata)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (c *Chroma) delete(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs   []string               `json:"ids"`
+		Where map[string]interface{} `json:"where"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	collection := c.byID(w, r)
+	if collection == nil {
+		return
+	}
+
+	deleted := map[string]bool{}
+	for _, record := range collection.filter(req.IDs, req.Where) {
+		deleted[record.ID] = true
+	}
+	kept := collection.records[:0]
+	collection.index = map[string]int{}
+	for _, record := range collection.records {
+		if deleted[record.ID] {
+			continue
+		}
+		collection.index[record.ID] = len(kept)
+		kept = append(kept, record)
+	}
+	collection.records = kept
+
+	ids := make([]string, 0, len(deleted))
+	for id := range deleted {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	writeJSON(w, http.StatusOK, ids)
+}
+
+func (c *Chroma) query(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Where           map[string]interface{} `json:"where"`
+		QueryEmbeddings [][]float32            `json:"query_embeddings"`
+		NResults        int                    `json:"n_results"`
+		Include         []string               `json:"include"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.NResults <= 0 {
+		req.NResults = 10
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	collection := c.byID(w, r)
+	if collection == nil {
+		return
+	}
+
+	include := includes(req.Include)
+	if len(req.Include) == 0 {
+		include = map[string]bool{"documents": true, "metadatas": true, "distances": true}
+	}
+	resp := struct {
+		IDs        [][]string                 `json:"ids"`
+		Embeddings [][][]float32              `json:"embeddings"`
+		Documents  [][]string                 `json:"documents"`
+		Metadatas  [][]map[string]interface{} `json:"metadatas"`
+		Distances  [][]float32                `json:"distances"`
+	}{}
+	candidates := collection.filter(nil, req.Where)
+	space, _ := collection.Metadata["hnsw:space"].(string)
+
+	for _, embedding := range req.QueryEmbeddings {
+		type neighbor struct {
+			record   chromaRecord
+			distance float32
+		}
+		neighbors := make([]neighbor, 0, len(candidates))
+		for _, record := range candidates {
+			if len(record.Embedding) != len(embedding) {
+				chromaError(w, http.StatusBadRequest, "InvalidDimensionException",
+					"Embedding dimension %d does not match collection dimensionality %d", len(embedding), len(record.Embedding))
+				return
+			}
+			neighbors = append(neighbors, neighbor{record: record, distance: distance(space, embedding, record.Embedding)})
+		}
+		sort.SliceStable(neighbors, func(i, j int) bool { return neighbors[i].distance < neighbors[j].distance })
+		if len(neighbors) > req.NResults {
+			neighbors = neighbors[:req.NResults]
+		}
+
+		ids, documents, metadatas, distances, embeddings := []string{}, []string{}, []map[string]interface{}{}, []float32{}, [][]float32{}
+		for _, n := range neighbors {
+			ids = append(ids, n.record.ID)
+			documents = append(documents, n.record.Document)
+			metadatas = append(metadatas, n.record.Metadata)
+			distances = append(distances, n.distance)
+			embeddings = append(embeddings, n.record.Embedding)
+		}
+		resp.IDs = append(resp.IDs, ids)
+		if include["documents"] {
+			resp.Documents = append(resp.Documents, documents)
+		}
+		if include["metadatas"] {
+			resp.Metadatas = append(resp.Metadatas, metadatas)
+		}
+		if include["distances"] {
+			resp.Distances = append(resp.Distances, distances)
+		}
+		if include["embeddings"] {
+			resp.Embeddings = append(resp.Embeddings, embeddings)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (c *Chroma) count(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	collection := c.byID(w, r)
+	if collection == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, len(collection.records))
+}
+
+// filter returns the records with any of the IDs, all when there are none,
+// matching the where clause.
+func (c *chromaCollection) filter(ids []string, where map[string]interface{}) []chromaRecord {
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var matches []chromaRecord
+	for _, record := range c.records {
+		if len(wanted) > 0 && !wanted[record.ID] {
+			continue
+		}
+		if !matchWhere(record.Metadata, where) {
+			continue
+		}
+		matches = append(matches, record)
+	}
+	return matches
+}
+
+// includes returns the set of included fields of a request.
+func includes(fields []string) map[string]bool {
+	include := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		include[field] = true
+	}
+	return include
+}
+
+// matchWhere reports whether metadata matches a where clause: field values or
+// $eq, $ne, $gt, $gte, $lt, $lte, $in and $nin operators, combined with $and
+// and $or.
+func matchWhere(metadata map[string]interface{}, where map[string]interface{}) bool {
+	for key, condition := range where {
+		switch key {
+		case "$and", "$or":
+			clauses, _ := condition.([]interface{})
+			matchedAny := false
+			for _, clause := range clauses {
+				clause, _ := clause.(map[string]interface{})
+				matched := matchWhere(metadata, clause)
+				if key == "$and" && !matched {
+					return false
+				}
+				matchedAny = matchedAny || matched
+			}
+			if key == "$or" && !matchedAny {
+				return false
+			}
+		default:
+			value, present := metadata[key]
+			operators, ok := condition.(map[string]interface{})
+			if !ok {
+				operators = map[string]interface{}{"$eq": condition}
+			}
+			for operator, operand := range operators {
+				if !matchOperator(operator, value, present, operand) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// matchOperator reports whether a metadata value satisfies a where operator.
+func matchOperator(operator string, value interface{}, present bool, operand interface{}) bool {
+	switch operator {
+	case "$eq":
+		return present && equal(value, operand)
+	case "$ne":
+		return !present || !equal(value, operand)
+	case "$in", "$nin":
+		values, _ := operand.([]interface{})
+		in := false
+		for _, v := range values {
+			in = in || (present && equal(value, v))
+		}
+		return in == (operator == "$in")
+	case "$gt", "$gte", "$lt", "$lte":
+		a, ok := value.(float64)
+		b, ok2 := operand.(float64)
+		if !present || !ok || !ok2 {
+			return false
+		}
+		switch operator {
+		case "$gt":
+			return a > b
+		case "$gte":
+			return a >= b
+		case "$lt":
+			return a < b
+		default:
+			return a <= b
+		}
+	}
+	return false
+}
+
+// equal compares metadata values decoded from JSON.
+func equal(a, b interface{}) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// distance returns the distance of two embeddings in a collection's space:
+// squared L2 by default like ChromaDB, or cosine or inner product distance.
+func distance(space string, a, b []float32) float32 {
+	var dot, normA, normB, l2 float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+		l2 += (x - y) * (x - y)
+	}
+	switch space {
+	case "cosine":
+		if normA == 0 || normB == 0 {
+			return 1
+		}
+		return float32(1 - dot/(math.Sqrt(normA)*math.Sqrt(normB)))
+	case "ip":
+		return float32(1 - dot)
+	default:
+		return float32(l2)
+	}
+}
@@ -0,0 +1,51 @@
+package harness
+
+// CrawlerSiteMaxDepth is the crawl depth CrawlerSite is laid out for, set by
+// the harness config.
+const CrawlerSiteMaxDepth = 3
+
+// CrawlerSite is a fixture website exercising the crawl rules. From the home
+// page a chain of guide pages goes one level deeper than
+// CrawlerSiteMaxDepth, /private/ is disallowed by robots.txt, /tags is too
+// thin to be indexed and /orphan is only listed in the sitemap. Links to the
+// guide carry tracking parameters, fragments and trailing slashes so the
+// crawler has to normalize them to fetch it once.
+var CrawlerSite = Site{
+	Robots:  "User-agent: *\nDisallow: /private/\n",
+	Sitemap: []string{"/", "/orphan", "/private/keys"},
+	Pages: map[string]string{
+		"/": crawlerPage("Crawler Fixture", `
+<p>The crawler fixture is a small website laid out to check how the crawler follows links. It has a guide nested a few levels deep, a private section and a page listed only in the sitemap. Every page is served from memory. The server counts the requests of each path. The checks compare those counts with the pages the crawler recorded.</p>
+<p>Start with the <a href="/guide?utm_source=home">guide</a>, also linked as <a href="/guide/">the guide index</a> and <a href="/guide#setup">its setup section</a>. Browse the <a href="/tags">tags</a>, keep out of the <a href="/private/keys">private keys</a>, or visit <a href="http://example.invalid/elsewhere">another website</a>.</p>`),
+		"/guide": crawlerPage("Guide", `
+<p>The guide explains the layout of the fixture step by step. Each step is a page one level deeper than the previous one. The crawl depth decides how many of them are indexed. The home page is the first level and the guide is the second. The links to the guide differ in their query, fragment and trailing slash. They all lead to this page, which is fetched a single time.</p>
+<p>Continue with the <a href="/guide/setup">setup</a>.</p>`),
+		"/guide/setup": crawlerPage("Setup", `
+<p>The setup page is as deep as the crawler goes in the fixture. It is the third level, below the home page and the guide. It links to the advanced setup, which is one level deeper. The crawler records that page as skipped because of its depth. It never requests it from the server. Raising the maximum depth would index it too.</p>
+<p>Continue with the <a href="/guide/setup/advanced">advanced setup</a>.</p>`),
+		"/guide/setup/advanced": crawlerPage("Advanced setup", `
+<p>The advanced setup is deeper than the maximum crawl depth. The crawler records it as skipped without fetching it, so this text is never indexed.</p>`),
+		"/private/keys": crawlerPage("Private keys", `
+<p>The private keys are disallowed by robots.txt. The crawler records the page as skipped without fetching it, whether it is linked from a page or listed in the sitemap.</p>`),
+		"/tags": crawlerPage("Tags", `
+<p>guide, setup</p>`),
+		"/orphan": crawlerPage("Orphan", `
+<p>The orphan page is not linked from any page of the fixture. The crawler only finds it in the sitemap that robots.txt points to. It visits the pages of the sitemap after following the links. Pages already crawled are not fetched again. The private keys are listed in the sitemap as well. They stay skipped, as robots.txt disallows them.</p>`),
+	},
+}
+
+// crawlerPage returns a page of CrawlerSite with a navigation and a footer
+// linking back to pages already crawled.
+func crawlerPage(title, body string) string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head><title>` + title + `</title></head>
+<body>
+<nav><a href="/">Home</a> <a href="/guide/">Guide</a></nav>
+<main>
+<h1>` + title + `</h1>` + body + `
+</main>
+<footer>This fixture uses no cookies. <a href="/?ref=footer">Back to the home page</a></footer>
+</body>
+</html>`
+}
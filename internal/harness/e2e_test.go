@@ -0,0 +1,185 @@
+//go:build integration
+
+package harness
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"hermit/internal/schema"
+	"hermit/pkg/client"
+
+	"go.uber.org/zap"
+)
+
+// smokeQuestion is the question TestSmoke asks about DefaultSite.
+const smokeQuestion = "How do I publish the reports?"
+
+var appLogs = flag.Bool("harness.logs", false, "log the application")
+
+// shared is the harness of the tests, started once by TestMain. startErr
+// tells why it isn't running.
+var (
+	shared   *Harness
+	startErr error
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	opts := Options{}
+	if *appLogs {
+		logger, err := zap.NewDevelopment()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating logger: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Logger = logger
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	shared, startErr = Start(ctx, opts)
+	cancel()
+
+	code := m.Run()
+	if shared != nil {
+		if err := shared.Stop(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error stopping harness: %v\n", err)
+		}
+	}
+	os.Exit(code)
+}
+
+// startedHarness returns the shared harness, skipping the test when its
+// dependencies can't run here.
+func startedHarness(t *testing.T) *Harness {
+	t.Helper()
+	if errors.Is(startErr, ErrUnavailable) {
+		t.Skip(startErr)
+	}
+	if startErr != nil {
+		t.Fatalf("Start() error = %v", startErr)
+	}
+	return shared
+}
+
+// TestSmoke runs the main flow against the fixture website: it registers a
+// user, creates a website for the site, waits for it to be crawled and
+// vectorized, and asks smokeQuestion, checking the answer cites the site.
+func TestSmoke(t *testing.T) {
+	h := startedHarness(t)
+	ctx := t.Context()
+
+	c, err := h.Register(ctx, "smoke@example.com", "smoke-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	website, err := c.CreateWebsite(ctx, client.CreateWebsiteRequest{URL: h.SiteURL})
+	if err != nil {
+		t.Fatalf("CreateWebsite() error = %v", err)
+	}
+	status, err := WaitForIndexed(ctx, c, website.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("Crawled %d pages of %s", status.TotalPagesCrawled, h.SiteURL)
+
+	answer, err := c.Query(ctx, website.ID, client.QueryRequest{Query: smokeQuestion})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	t.Logf("Q: %s\nA: %s", smokeQuestion, answer.Answer)
+
+	if answer.Answer == "" {
+		t.Error("empty answer")
+	}
+	if len(answer.Sources) == 0 {
+		t.Error("answer without sources")
+	}
+	for _, source := range answer.Sources {
+		if !strings.HasPrefix(source.PageURL, h.SiteURL) {
+			t.Errorf("answer cites %s outside of the site", source.PageURL)
+		}
+	}
+}
+
+// TestCrawlRules crawls CrawlerSite and checks the crawler respected the
+// depth limit and robots.txt, seeded the crawl from the sitemap, normalized
+// the links and fetched each page once.
+func TestCrawlRules(t *testing.T) {
+	h := startedHarness(t)
+	ctx := t.Context()
+	if h.Config.CrawlerMaxDepth != CrawlerSiteMaxDepth {
+		t.Fatalf("CrawlerMaxDepth = %d, want %d for CrawlerSite", h.Config.CrawlerMaxDepth, CrawlerSiteMaxDepth)
+	}
+
+	site := h.ServeSite(CrawlerSite)
+	c, err := h.Register(ctx, "crawler@example.com", "crawler-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	website, err := c.CreateWebsite(ctx, client.CreateWebsiteRequest{URL: site.URL()})
+	if err != nil {
+		t.Fatalf("CreateWebsite() error = %v", err)
+	}
+	if _, err := WaitForIndexed(ctx, c, website.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	pages, err := c.ListPages(ctx, website.ID, client.PageListOptions{ListOptions: client.ListOptions{Limit: 100}})
+	if err != nil {
+		t.Fatalf("ListPages() error = %v", err)
+	}
+	recorded := make(map[string]client.Page, len(pages.Data))
+	for _, page := range pages.Data {
+		recorded[page.URL] = page
+	}
+
+	tests := []struct {
+		path string
+		// skipReason is empty for indexed pages
+		skipReason string
+	}{
+		{path: "/"},
+		{path: "/guide"},
+		{path: "/guide/setup"},
+		{path: "/orphan"},
+		{path: "/guide/setup/advanced", skipReason: schema.SkipReasonDepth},
+		{path: "/private/keys", skipReason: schema.SkipReasonRobots},
+		{path: "/tags", skipReason: schema.SkipReasonQuality},
+	}
+	for _, tt := range tests {
+		pageURL := site.URL() + tt.path
+		page, ok := recorded[pageURL]
+		delete(recorded, pageURL)
+		switch {
+		case !ok:
+			t.Errorf("%s: not recorded", tt.path)
+		case tt.skipReason == "" && page.Status != "success":
+			t.Errorf("%s: status %s, want success", tt.path, page.Status)
+		case tt.skipReason != "" && (page.Status != schema.PageStatusSkipped || page.SkipReason.String != tt.skipReason):
+			t.Errorf("%s: status %s (%s), want skipped (%s)", tt.path, page.Status, page.SkipReason.String, tt.skipReason)
+		}
+
+		// Indexed and quality skipped pages are fetched once, others never
+		fetches := 1
+		if tt.skipReason == schema.SkipReasonDepth || tt.skipReason == schema.SkipReasonRobots {
+			fetches = 0
+		}
+		if got := site.Requests(tt.path); got != fetches {
+			t.Errorf("%s: fetched %d times, want %d", tt.path, got, fetches)
+		}
+	}
+	for pageURL := range recorded {
+		t.Errorf("%s: unexpected page", strings.TrimPrefix(pageURL, site.URL()))
+	}
+	if got := site.Requests("/guide/"); got != 0 {
+		t.Errorf("/guide/: fetched %d times, want 0 as it normalizes to /guide", got)
+	}
+}
@@ -0,0 +1,370 @@
+// Package harness boots the API server and the worker in the process against
+// throwaway dependencies, for end-to-end tests: an embedded Postgres (or a
+// database created on an existing server), an in-memory Redis, pages stored
+// on disk, and stub Ollama and ChromaDB servers. A fixture website is served
+// locally for the crawler.
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"hermit/internal/app"
+	"hermit/internal/config"
+	"hermit/internal/database"
+	"hermit/pkg/client"
+
+	"github.com/alicebob/miniredis/v2"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// DatabaseURLEnv names the environment variable with the URL of a Postgres
+// server to use instead of the embedded one, e.g. when running as root.
+const DatabaseURLEnv = "HERMIT_TEST_DATABASE_URL"
+
+// ErrUnavailable is wrapped by the errors of Start when the embedded Postgres
+// or the in-memory Redis can't run, e.g. as root or without the Postgres
+// binaries, so tests can be skipped.
+var ErrUnavailable = errors.New("harness dependency unavailable")
+
+const (
+	defaultDimensions = 256
+	appStartTimeout   = time.Minute
+	pollInterval      = 200 * time.Millisecond
+)
+
+// Options configures a harness. The zero value runs the embedded Postgres and
+// serves DefaultSite.
+type Options struct {
+	// DatabaseURL is a Postgres server on which a throwaway database is
+	// created and dropped on Stop. Defaults to HERMIT_TEST_DATABASE_URL,
+	// running an embedded Postgres when both are empty.
+	DatabaseURL string
+	// MigrationsDir defaults to the migrations of the repository.
+	MigrationsDir string
+	// Site defaults to DefaultSite.
+	Site *Site
+	// Answer answers the prompts of the stub LLM, StubAnswer by default.
+	Answer func(prompt string) string
+	// Dimensions of the stub embeddings, 256 by default.
+	Dimensions int
+	// Logger of the application, discarding logs by default.
+	Logger *zap.Logger
+	// Configure changes the config before the application starts.
+	Configure func(cfg *config.Config)
+}
+
+// Harness is a running application with its dependencies.
+type Harness struct {
+	// URL is the base URL of the API server.
+	URL string
+	// SiteURL is the base URL of the fixture website.
+	SiteURL string
+	Config  *config.Config
+	Ollama  *Ollama
+	Chroma  *Chroma
+
+	site     *SiteServer
+	app      *fx.App
+	cleanups []func() error
+}
+
+// Start starts the dependencies and the application, and waits for the API
+// server to answer. Stop releases everything, also when Start fails.
+func Start(ctx context.Context, opts Options) (h *Harness, err error) {
+	h = &Harness{}
+	defer func() {
+		if err != nil {
+			_ = h.Stop(context.Background())
+			h = nil
+		}
+	}()
+
+	if opts.Dimensions == 0 {
+		opts.Dimensions = defaultDimensions
+	}
+	if opts.Site == nil {
+		opts.Site = &DefaultSite
+	}
+	if opts.MigrationsDir == "" {
+		opts.MigrationsDir = migrationsDir()
+	}
+	if opts.DatabaseURL == "" {
+		opts.DatabaseURL = os.Getenv(DatabaseURLEnv)
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	dir, err := os.MkdirTemp("", "hermit-harness-")
+	if err != nil {
+		return h, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	h.cleanup(func() error { return os.RemoveAll(dir) })
+
+	databaseURL, err := h.startDatabase(ctx, opts.DatabaseURL, filepath.Join(dir, "postgres"))
+	if err != nil {
+		return h, err
+	}
+	if err := database.Migrate(ctx, databaseURL, opts.MigrationsDir, "up"); err != nil {
+		return h, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	redisAddr, err := h.startRedis()
+	if err != nil {
+		return h, err
+	}
+
+	h.Ollama = newOllama(opts.Dimensions, opts.Answer)
+	h.cleanup(func() error { h.Ollama.Close(); return nil })
+	h.Chroma = newChroma()
+	h.cleanup(func() error { h.Chroma.Close(); return nil })
+	h.site = h.ServeSite(*opts.Site)
+	h.SiteURL = h.site.URL()
+
+	port, err := freePort()
+	if err != nil {
+		return h, err
+	}
+	h.URL = "http://localhost:" + strconv.Itoa(port)
+
+	cfg := testConfig(databaseURL, redisAddr, h.Ollama.URL(), h.Chroma.URL(), filepath.Join(dir, "storage"), port, opts.Dimensions)
+	if opts.Configure != nil {
+		opts.Configure(cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		return h, err
+	}
+	h.Config = cfg
+
+	h.app = fx.New(app.Core, app.API, app.Worker,
+		fx.Replace(cfg),
+		fx.Replace(logger),
+		fx.NopLogger,
+		fx.StartTimeout(appStartTimeout),
+	)
+	if err := h.app.Err(); err != nil {
+		return h, fmt.Errorf("failed to build application: %w", err)
+	}
+	if err := h.app.Start(ctx); err != nil {
+		h.app = nil
+		return h, fmt.Errorf("failed to start application: %w", err)
+	}
+
+	if err := h.waitForServer(ctx); err != nil {
+		return h, err
+	}
+	return h, nil
+}
+
+// testConfig returns the config pointing at the harness dependencies, with
+// the integrations needing external services disabled and the crawl depth
+// CrawlerSite is laid out for.
+func testConfig(databaseURL, redisAddr, ollamaURL, chromaURL, storagePath string, port, dimensions int) *config.Config {
+	cfg := config.NewConfig()
+
+	cfg.Port = strconv.Itoa(port)
+	cfg.DatabaseURL = databaseURL
+	cfg.RedisURL = "redis://" + redisAddr
+	cfg.RedisPassword = ""
+	cfg.RedisDB = 0
+	cfg.RedisEmbedded = false
+	cfg.ChromaDBURL = chromaURL
+	cfg.OllamaURL = ollamaURL
+	cfg.OllamaEmbeddingDimensions = dimensions
+	cfg.StorageDriver = "local"
+	cfg.StorageLocalPath = storagePath
+	cfg.WorkerHealthPort = ""
+	cfg.WorkerAutoscale = false
+	if cfg.WorkerConcurrency < 1 {
+		cfg.WorkerConcurrency = 1
+	}
+
+	cfg.CrawlerDelayMS = 0
+	cfg.CrawlerMaxDepth = CrawlerSiteMaxDepth
+	cfg.CrawlerProxies = ""
+	cfg.RateLimitEnabled = false
+	cfg.ScreenshotServiceURL = ""
+	cfg.ScreenshotPages = false
+	cfg.NotificationWebhookURL = ""
+	cfg.SlackBotToken, cfg.SlackSigningSecret = "", ""
+	cfg.DiscordApplicationID, cfg.DiscordPublicKey, cfg.DiscordBotToken = "", "", ""
+	cfg.AdminEmail, cfg.AdminPassword = "", ""
+	return cfg
+}
+
+// startDatabase creates a throwaway database on serverURL, or runs an
+// embedded Postgres keeping its files in dir when serverURL is empty.
+func (h *Harness) startDatabase(ctx context.Context, serverURL, dir string) (string, error) {
+	if serverURL != "" {
+		databaseURL, drop, err := createDatabase(ctx, serverURL)
+		if err != nil {
+			return "", err
+		}
+		h.cleanup(drop)
+		return databaseURL, nil
+	}
+
+	databaseURL, stop, err := startPostgres(dir, io.Discard)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w (set %s to use an existing server)", ErrUnavailable, err, DatabaseURLEnv)
+	}
+	h.cleanup(stop)
+	return databaseURL, nil
+}
+
+// startRedis runs an in-memory Redis whose clock advances in real time, so
+// keys such as unique task locks expire.
+func (h *Harness) startRedis() (string, error) {
+	server := miniredis.NewMiniRedis()
+	if err := server.Start(); err != nil {
+		return "", fmt.Errorf("%w: failed to start redis: %w", ErrUnavailable, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				server.FastForward(time.Second)
+			}
+		}
+	}()
+
+	h.cleanup(func() error {
+		close(done)
+		server.Close()
+		return nil
+	})
+	return server.Addr(), nil
+}
+
+// waitForServer waits for the health endpoint of the API server to answer.
+func (h *Harness) waitForServer(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, appStartTimeout)
+	defer cancel()
+
+	c := h.Client()
+	for {
+		if _, err := c.Health(ctx); err == nil {
+			return nil
+		} else if ctx.Err() != nil {
+			return fmt.Errorf("API server not answering: %w", err)
+		}
+		if err := sleep(ctx, pollInterval); err != nil {
+			return fmt.Errorf("API server not answering: %w", err)
+		}
+	}
+}
+
+// cleanup registers a function run by Stop, in reverse order.
+func (h *Harness) cleanup(f func() error) {
+	h.cleanups = append(h.cleanups, f)
+}
+
+// Stop stops the application and its dependencies and removes their files.
+func (h *Harness) Stop(ctx context.Context) error {
+	var errs []error
+	if h.app != nil {
+		if err := h.app.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop application: %w", err))
+		}
+		h.app = nil
+	}
+	for i := len(h.cleanups) - 1; i >= 0; i-- {
+		if err := h.cleanups[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	h.cleanups = nil
+	return errors.Join(errs...)
+}
+
+// ServeSite serves another fixture website until Stop.
+func (h *Harness) ServeSite(site Site) *SiteServer {
+	server := newSiteServer(site)
+	h.cleanup(func() error { server.Close(); return nil })
+	return server
+}
+
+// SiteRequests returns the number of requests the fixture website received
+// for a path.
+func (h *Harness) SiteRequests(path string) int {
+	return h.site.Requests(path)
+}
+
+// Client returns an API client of the server.
+func (h *Harness) Client(opts ...client.Option) *client.Client {
+	return client.New(h.URL, opts...)
+}
+
+// Register registers a user and returns a client authenticated with their
+// API key.
+func (h *Harness) Register(ctx context.Context, email, password string) (*client.Client, error) {
+	resp, err := h.Client().Register(ctx, email, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register %s: %w", email, err)
+	}
+	return h.Client(client.WithAPIKey(resp.APIKey)), nil
+}
+
+// WaitForIndexed waits for the crawl of a website to complete and for its
+// pages to be vectorized. It fails when the crawl fails or no page could be
+// vectorized.
+func WaitForIndexed(ctx context.Context, c *client.Client, websiteID uint) (*client.WebsiteStatus, error) {
+	for {
+		status, err := c.GetWebsiteStatus(ctx, websiteID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch status.CrawlStatus {
+		case "failed":
+			return status, fmt.Errorf("crawl of website %d failed: %s", websiteID, status.LastError.String)
+		case "completed":
+			pending, err := c.ListPages(ctx, websiteID, client.PageListOptions{VectorStatus: "pending"})
+			if err != nil {
+				return nil, err
+			}
+			if pending.Total > 0 {
+				break
+			}
+			vectorized, err := c.ListPages(ctx, websiteID, client.PageListOptions{VectorStatus: "vectorized"})
+			if err != nil {
+				return nil, err
+			}
+			if vectorized.Total == 0 {
+				return status, fmt.Errorf("no page of website %d was vectorized", websiteID)
+			}
+			return status, nil
+		}
+
+		if err := sleep(ctx, pollInterval); err != nil {
+			return status, fmt.Errorf("website %d not indexed, crawl %s: %w", websiteID, status.CrawlStatus, err)
+		}
+	}
+}
+
+// sleep waits for d, or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
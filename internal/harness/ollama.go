@@ -0,0 +1,190 @@
+package harness
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"hermit/internal/seed"
+
+	"github.com/ollama/ollama/api"
+)
+
+// Ollama is a stub Ollama server. It embeds texts with seed.CannedEmbedding,
+// so texts sharing words are close to each other, and answers prompts with
+// its Answer function, streaming the answer word by word.
+type Ollama struct {
+	server     *httptest.Server
+	dimensions int
+	answer     func(prompt string) string
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+// newOllama starts a stub Ollama server producing embeddings of the
+// dimensions. answer defaults to StubAnswer.
+func newOllama(dimensions int, answer func(prompt string) string) *Ollama {
+	if answer == nil {
+		answer = StubAnswer
+	}
+	o := &Ollama{dimensions: dimensions, answer: answer}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/embed", o.embed)
+	mux.HandleFunc("POST /api/generate", o.generate)
+	mux.HandleFunc("POST /api/chat", o.chat)
+	mux.HandleFunc("POST /api/show", o.show)
+	mux.HandleFunc("GET /api/tags", o.tags)
+	o.server = httptest.NewServer(mux)
+	return o
+}
+
+// URL returns the base URL of the server.
+func (o *Ollama) URL() string {
+	return o.server.URL
+}
+
+// Prompts returns the prompts and chat messages received, in order.
+func (o *Ollama) Prompts() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.prompts...)
+}
+
+// Close stops the server.
+func (o *Ollama) Close() {
+	o.server.Close()
+}
+
+// StubAnswer answers a prompt by repeating its question, the line starting
+// with "Question: " of RAG prompts.
+func StubAnswer(prompt string) string {
+	for _, line := range strings.Split(prompt, "\n") {
+		if question, ok := strings.CutPrefix(line, "Question: "); ok {
+			return "Stub answer to: " + question
+		}
+	}
+	return "Stub answer."
+}
+
+func (o *Ollama) embed(w http.ResponseWriter, r *http.Request) {
+	var req api.EmbedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var inputs []string
+	switch input := req.Input.(type) {
+	case string:
+		inputs = []string{input}
+	case []interface{}:
+		for _, text := range input {
+			s, _ := text.(string)
+			inputs = append(inputs, s)
+		}
+	}
+
+	resp := api.EmbedResponse{Model: req.Model, Embeddings: make([][]float32, len(inputs))}
+	for i, text := range inputs {
+		resp.Embeddings[i] = seed.CannedEmbedding(text, o.dimensions)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (o *Ollama) generate(w http.ResponseWriter, r *http.Request) {
+	var req api.GenerateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// An empty prompt loads the model, as the worker's warm-up does
+	if req.Prompt == "" {
+		writeJSON(w, http.StatusOK, api.GenerateResponse{Model: req.Model, CreatedAt: time.Now(), Done: true})
+		return
+	}
+
+	o.record(req.Prompt)
+	o.stream(w, req.Stream, o.answer(req.Prompt), func(chunk string, done bool) interface{} {
+		resp := api.GenerateResponse{Model: req.Model, CreatedAt: time.Now(), Response: chunk, Done: done}
+		if done {
+			resp.DoneReason = "stop"
+			resp.PromptEvalCount = len(strings.Fields(req.Prompt))
+			resp.EvalCount = 1
+		}
+		return resp
+	})
+}
+
+func (o *Ollama) chat(w http.ResponseWriter, r *http.Request) {
+	var req api.ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var prompt strings.Builder
+	for _, message := range req.Messages {
+		prompt.WriteString(message.Role + ": " + message.Content + "\n")
+	}
+	o.record(prompt.String())
+
+	o.stream(w, req.Stream, o.answer(prompt.String()), func(chunk string, done bool) interface{} {
+		resp := api.ChatResponse{Model: req.Model, CreatedAt: time.Now(), Message: api.Message{Role: "assistant", Content: chunk}, Done: done}
+		if done {
+			resp.DoneReason = "stop"
+		}
+		return resp
+	})
+}
+
+func (o *Ollama) show(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.ShowResponse{
+		Details: api.ModelDetails{Format: "gguf", Family: "stub"},
+	})
+}
+
+func (o *Ollama) tags(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.ListResponse{})
+}
+
+// record keeps a prompt for Prompts.
+func (o *Ollama) record(prompt string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.prompts = append(o.prompts, prompt)
+}
+
+// stream writes an answer as one response, or word by word as newline
+// delimited JSON unless streaming is disabled.
+func (o *Ollama) stream(w http.ResponseWriter, stream *bool, answer string, response func(chunk string, done bool) interface{}) {
+	if stream != nil && !*stream {
+		writeJSON(w, http.StatusOK, response(answer, true))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	for _, word := range strings.SplitAfter(answer, " ") {
+		if word == "" {
+			continue
+		}
+		_ = encoder.Encode(response(word, false))
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	_ = encoder.Encode(response("", true))
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,124 @@
+package harness
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Site is a fixture website served to the crawler. Pages maps paths to
+// their HTML; /robots.txt is served from Robots, allowing everything when
+// empty. When Sitemap lists paths, they are served as /sitemap.xml, which
+// robots.txt points to.
+type Site struct {
+	Pages   map[string]string
+	Robots  string
+	Sitemap []string
+}
+
+// DefaultSite is a small documentation site linking its pages from the home
+// page, with text long enough to pass the content quality checks.
+var DefaultSite = Site{
+	Pages: map[string]string{
+		"/": fixturePage("Fixture Docs", `
+<p>Fixture Docs documents the fixture command line tool used by the end-to-end tests. The tool builds reports from local data files and publishes them to a shared folder.</p>
+<p>Each report collects the rows of one data file, groups them by the columns listed in the report settings, and renders a summary table followed by a chart of the totals. Reports are rebuilt only when their data file changed since the last build.</p>
+<p>Read the <a href="/install">installation guide</a> first, then the <a href="/usage">usage guide</a> explaining how reports are built and published.</p>`),
+		"/install": fixturePage("Installing the fixture tool", `
+<p>The fixture tool is installed by downloading the archive for your platform and extracting the fixture binary into a directory on your PATH.</p>
+<p>The tool requires version 3 of the report runtime. Check the installation by running fixture version, which prints the installed version and the path of the configuration file.</p>`),
+		"/usage": fixturePage("Using the fixture tool", `
+<p>Run fixture build to build the reports of the current directory. Reports are written to the out directory as HTML files, one per data file.</p>
+<p>Run fixture publish to copy the built reports to the shared folder configured in fixture.toml. Publishing keeps the previous reports for seven days so they can be restored with fixture restore.</p>`),
+	},
+}
+
+// fixturePage returns an HTML page with a navigation and the body.
+func fixturePage(title, body string) string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head><title>` + title + `</title></head>
+<body>
+<nav><a href="/">Home</a> <a href="/install">Install</a> <a href="/usage">Usage</a></nav>
+<main>
+<h1>` + title + `</h1>` + body + `
+</main>
+</body>
+</html>`
+}
+
+// SiteServer serves a Site and counts the requests of each path.
+type SiteServer struct {
+	server *httptest.Server
+	site   Site
+
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+// newSiteServer starts a server for the site.
+func newSiteServer(site Site) *SiteServer {
+	s := &SiteServer{site: site, requests: map[string]int{}}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// URL returns the base URL of the site.
+func (s *SiteServer) URL() string {
+	return s.server.URL
+}
+
+// Close stops the server.
+func (s *SiteServer) Close() {
+	s.server.Close()
+}
+
+func (s *SiteServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/robots.txt":
+		w.Header().Set("Content-Type", "text/plain")
+		robots := s.site.Robots
+		if robots == "" {
+			robots = "User-agent: *\nAllow: /\n"
+		}
+		if len(s.site.Sitemap) > 0 {
+			robots += "\nSitemap: " + s.server.URL + "/sitemap.xml\n"
+		}
+		_, _ = w.Write([]byte(robots))
+		return
+	case "/sitemap.xml":
+		if len(s.site.Sitemap) == 0 {
+			break
+		}
+		var sitemap strings.Builder
+		sitemap.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+		sitemap.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+		for _, path := range s.site.Sitemap {
+			sitemap.WriteString("  <url><loc>" + s.server.URL + path + "</loc></url>\n")
+		}
+		sitemap.WriteString("</urlset>\n")
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(sitemap.String()))
+		return
+	}
+
+	page, ok := s.site.Pages[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(strings.TrimSpace(page)))
+}
+
+// Requests returns the number of requests of a path.
+func (s *SiteServer) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
@@ -0,0 +1,109 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"hermit/internal/config"
+	"hermit/internal/requestid"
+	"hermit/internal/tenant"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+)
+
+// vectorizeGroupPrefix prefixes the asynq groups small pages of a website are
+// aggregated in.
+const vectorizeGroupPrefix = "vectorize:website:"
+
+// VectorizeBatch aggregates the vectorize tasks of small pages per website
+// into batches embedded in a single request.
+type VectorizeBatch struct {
+	// Size is the number of pages per batch at most, 0 disables batching
+	Size int
+	// MaxChars is the content length up to which pages are batched, larger
+	// pages are vectorized by their own task
+	MaxChars int
+	// GracePeriod is how long a batch waits for more pages after the last
+	// one was added
+	GracePeriod time.Duration
+	// MaxDelay is how long the first page of a batch waits at most
+	MaxDelay time.Duration
+}
+
+// VectorizeBatchFromConfig returns the vectorize batching set in the config.
+func VectorizeBatchFromConfig(cfg *config.Config) VectorizeBatch {
+	return VectorizeBatch{
+		Size:        cfg.VectorizeBatchSize,
+		MaxChars:    cfg.VectorizeBatchMaxChars,
+		GracePeriod: time.Duration(cfg.VectorizeBatchGracePeriod) * time.Second,
+		MaxDelay:    time.Duration(cfg.VectorizeBatchMaxDelay) * time.Second,
+	}
+}
+
+// enabled reports whether the vectorize tasks of small pages are batched.
+func (b VectorizeBatch) enabled() bool {
+	return b.Size > 1 && b.MaxChars > 0
+}
+
+// group returns the asynq group a page of the content length is aggregated
+// in, or "" when the page is vectorized by its own task.
+func (b VectorizeBatch) group(websiteID uint, contentLength int) string {
+	if !b.enabled() || contentLength > b.MaxChars {
+		return ""
+	}
+	return fmt.Sprintf("%s%d", vectorizeGroupPrefix, websiteID)
+}
+
+// vectorizeAggregator combines the vectorize page tasks of a website's group
+// into a vectorize batch task.
+type vectorizeAggregator struct {
+	logger *zap.Logger
+}
+
+// Aggregate implements asynq.GroupAggregator. Tasks whose payload can't be
+// parsed are dropped, like a vectorize page task failing to parse would be.
+func (a *vectorizeAggregator) Aggregate(group string, tasks []*asynq.Task) *asynq.Task {
+	// Pages of a group share the website, and so its owner
+	var (
+		websiteID uint
+		owner     tenant.Tenant
+		meta      requestid.Meta
+	)
+	pages := make([]VectorizeBatchPage, 0, len(tasks))
+	for _, task := range tasks {
+		payload, err := ParseVectorizePagePayload(task.Payload())
+		if err != nil {
+			a.logger.Error("Dropping vectorize task from batch",
+				zap.String("group", group),
+				zap.Error(err),
+			)
+			continue
+		}
+		if len(pages) == 0 {
+			websiteID, owner, meta = payload.WebsiteID, payload.Tenant, payload.Meta
+		}
+		pages = append(pages, VectorizeBatchPage{
+			PageID:  payload.PageID,
+			PageURL: payload.PageURL,
+			Content: payload.Content,
+			Tags:    payload.Tags,
+			Slot:    payload.Slot,
+		})
+	}
+
+	payload, err := NewVectorizeBatchPayload(websiteID, pages, owner, meta)
+	if err != nil {
+		a.logger.Error("Failed to create vectorize batch payload", zap.String("group", group), zap.Error(err))
+	}
+
+	a.logger.Debug("Aggregated vectorize tasks",
+		zap.String("group", group),
+		zap.Int("pages", len(pages)),
+	)
+
+	return asynq.NewTask(TypeVectorizeBatch, payload,
+		asynq.MaxRetry(5),
+		asynq.Timeout(10*time.Minute),
+	)
+}
@@ -0,0 +1,175 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+)
+
+// ErrThrottled is returned for tasks the autoscaler holds back. Throttled
+// tasks are retried shortly without counting as a failed attempt.
+var ErrThrottled = errors.New("task throttled by autoscaler")
+
+// throttleDelay is the base delay before a throttled task is tried again.
+const throttleDelay = 2 * time.Second
+
+// autoscaler adjusts how many tasks a worker runs at once between the bounds
+// of a ScalePolicy. The asynq server runs at the maximum concurrency and the
+// autoscaler turns tasks above the current limit away. While other queues
+// have work waiting, a queue may only use its weighted share of the limit so
+// a burst of long crawls can't starve vectorization.
+type autoscaler struct {
+	inspector *asynq.Inspector
+	policy    ScalePolicy
+	interval  time.Duration
+	weights   map[string]int
+	logger    *zap.Logger
+
+	mu      sync.Mutex
+	limit   int
+	running map[string]int
+	waiting map[string]bool
+}
+
+// newAutoscaler creates an autoscaler starting at the minimum concurrency.
+func newAutoscaler(inspector *asynq.Inspector, policy ScalePolicy, interval time.Duration, weights map[string]int, logger *zap.Logger) *autoscaler {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	return &autoscaler{
+		inspector: inspector,
+		policy:    policy,
+		interval:  interval,
+		weights:   weights,
+		logger:    logger,
+		limit:     policy.Target(0),
+		running:   make(map[string]int),
+		waiting:   make(map[string]bool),
+	}
+}
+
+// Run polls the queues and updates the limit until ctx is done.
+func (a *autoscaler) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		a.update()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update sets the limit from the current queue pressure.
+func (a *autoscaler) update() {
+	pressure, err := CollectPressure(a.inspector, a.policy)
+	if err != nil {
+		a.logger.Warn("Failed to collect queue pressure", zap.Error(err))
+		return
+	}
+
+	waiting := make(map[string]bool, len(pressure.Queues))
+	for _, queue := range pressure.Queues {
+		if queue.Pending > 0 && !queue.Paused {
+			waiting[queue.Queue] = true
+		}
+	}
+
+	a.mu.Lock()
+	previous := a.limit
+	a.limit = pressure.TargetConcurrency
+	a.waiting = waiting
+	a.mu.Unlock()
+
+	if previous != pressure.TargetConcurrency {
+		a.logger.Info("Worker concurrency scaled",
+			zap.Int("from", previous),
+			zap.Int("to", pressure.TargetConcurrency),
+			zap.Int("pending", pressure.Pending),
+			zap.Int("active", pressure.Active),
+		)
+	}
+}
+
+// Limit returns the current concurrency limit.
+func (a *autoscaler) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.limit
+}
+
+// acquire reserves a slot for a task of queue. It reports false when the
+// limit is reached or the queue used up its share while others are waiting.
+func (a *autoscaler) acquire(queue string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	total := 0
+	for _, n := range a.running {
+		total += n
+	}
+	if total >= a.limit {
+		return false
+	}
+
+	othersWaiting := false
+	weightSum := 0
+	for name, weight := range a.weights {
+		if name == queue || a.waiting[name] || a.running[name] > 0 {
+			weightSum += weight
+		}
+		if name != queue && a.waiting[name] {
+			othersWaiting = true
+		}
+	}
+	if othersWaiting && weightSum > 0 {
+		share := (a.limit*a.weights[queue] + weightSum - 1) / weightSum
+		if share < 1 {
+			share = 1
+		}
+		if a.running[queue] >= share {
+			return false
+		}
+	}
+
+	a.running[queue]++
+	return true
+}
+
+// release frees the slot of a finished task of queue.
+func (a *autoscaler) release(queue string) {
+	a.mu.Lock()
+	a.running[queue]--
+	a.mu.Unlock()
+}
+
+// throttle is a middleware turning tasks away while the autoscaler has no
+// slot for them. Tasks on their last attempt always run, asynq would archive
+// them otherwise.
+func (a *autoscaler) throttle(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		queue, _ := asynq.GetQueueName(ctx)
+		retried, _ := asynq.GetRetryCount(ctx)
+		maxRetry, _ := asynq.GetMaxRetry(ctx)
+
+		if !a.acquire(queue) {
+			if retried < maxRetry {
+				return ErrThrottled
+			}
+			a.mu.Lock()
+			a.running[queue]++
+			a.mu.Unlock()
+		}
+		defer a.release(queue)
+
+		return next.ProcessTask(ctx, task)
+	})
+}
@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"hermit/internal/config"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// vectorizeKeyPrefix prefixes the Redis keys holding the vectorize tasks of
+// a website in flight.
+const vectorizeKeyPrefix = "hermit:vectorize:inflight:"
+
+// vectorizeSlotTTL forgets slots of tasks that were lost without releasing
+// them, e.g. deleted from the queue by hand, so they don't hold the cap
+// forever.
+const vectorizeSlotTTL = time.Hour
+
+// vectorizePollInterval is how often enqueueing checks for a free slot while
+// the cap is reached.
+const vectorizePollInterval = time.Second
+
+// ErrBackpressure is returned when no vectorize slot of a website was freed
+// within the wait of the VectorizeLimit.
+var ErrBackpressure = errors.New("too many vectorize tasks in flight")
+
+// VectorizeLimit caps the vectorize tasks of a website that are queued or
+// running, so a large crawl waits for the embedding model instead of flooding
+// Redis.
+type VectorizeLimit struct {
+	// PerWebsite is the number of tasks in flight per website, 0 disables
+	// the cap
+	PerWebsite int
+	// Wait is how long enqueueing waits for a slot before giving up
+	Wait time.Duration
+}
+
+// VectorizeLimitFromConfig returns the vectorize cap set in the config.
+func VectorizeLimitFromConfig(cfg *config.Config) VectorizeLimit {
+	return VectorizeLimit{
+		PerWebsite: cfg.VectorizeMaxInFlight,
+		Wait:       time.Duration(cfg.VectorizeBackpressureWait) * time.Second,
+	}
+}
+
+// acquireScript takes a slot of a website when less than the cap are in
+// flight, after forgetting expired slots.
+var acquireScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+// vectorizeSemaphore holds a slot per vectorize task in flight in a sorted
+// set per website, scored by the time it was taken.
+type vectorizeSemaphore struct {
+	client redis.UniversalClient
+	limit  VectorizeLimit
+	logger *zap.Logger
+}
+
+// newVectorizeSemaphore creates a semaphore on the job queue's Redis. It
+// returns nil when the cap is disabled, a nil semaphore never blocks.
+func newVectorizeSemaphore(opt asynq.RedisConnOpt, limit VectorizeLimit, logger *zap.Logger) (*vectorizeSemaphore, error) {
+	if limit.PerWebsite <= 0 {
+		return nil, nil
+	}
+
+	client, ok := opt.MakeRedisClient().(redis.UniversalClient)
+	if !ok {
+		return nil, fmt.Errorf("unsupported redis connection")
+	}
+
+	return &vectorizeSemaphore{client: client, limit: limit, logger: logger}, nil
+}
+
+// key returns the key of the slots of a website.
+func (s *vectorizeSemaphore) key(websiteID uint) string {
+	return vectorizeKeyPrefix + strconv.FormatUint(uint64(websiteID), 10)
+}
+
+// acquire takes a slot for the task, waiting while the cap of the website is
+// reached. It returns ErrBackpressure when no slot was freed within the wait,
+// and the error of stop when it is cancelled first.
+func (s *vectorizeSemaphore) acquire(ctx, stop context.Context, websiteID uint, taskID string) error {
+	if s == nil {
+		return nil
+	}
+
+	key := s.key(websiteID)
+	deadline := time.Now().Add(s.limit.Wait)
+	waiting := false
+	for {
+		now := time.Now()
+		taken, err := acquireScript.Run(ctx, s.client, []string{key},
+			now.Add(-vectorizeSlotTTL).UnixMilli(),
+			s.limit.PerWebsite,
+			now.UnixMilli(),
+			taskID,
+			vectorizeSlotTTL.Milliseconds(),
+		).Int()
+		if err != nil {
+			return fmt.Errorf("failed to acquire vectorize slot: %w", err)
+		}
+		if taken == 1 {
+			if waiting {
+				s.logger.Info("Vectorize backpressure released", zap.Uint("websiteID", websiteID))
+			}
+			return nil
+		}
+
+		if !now.Before(deadline) {
+			return fmt.Errorf("%w for website %d", ErrBackpressure, websiteID)
+		}
+		if !waiting {
+			s.logger.Info("Vectorize tasks of website at cap, pausing enqueueing",
+				zap.Uint("websiteID", websiteID),
+				zap.Int("cap", s.limit.PerWebsite),
+			)
+			waiting = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stop.Done():
+			return fmt.Errorf("stopped waiting for a vectorize slot of website %d: %w", websiteID, stop.Err())
+		case <-time.After(vectorizePollInterval):
+		}
+	}
+}
+
+// release frees the slot of a task.
+func (s *vectorizeSemaphore) release(ctx context.Context, websiteID uint, taskID string) {
+	if s == nil {
+		return
+	}
+	if err := s.client.ZRem(ctx, s.key(websiteID), taskID).Err(); err != nil {
+		s.logger.Warn("Failed to release vectorize slot",
+			zap.Uint("websiteID", websiteID),
+			zap.String("taskID", taskID),
+			zap.Error(err),
+		)
+	}
+}
+
+// close closes the Redis connection of the semaphore.
+func (s *vectorizeSemaphore) close() error {
+	if s == nil {
+		return nil
+	}
+	return s.client.Close()
+}
@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"hermit/internal/requestid"
+	"hermit/internal/tenant"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+)
+
+// batchConcurrency bounds the number of in-flight Redis round trips per batch.
+// asynq has no pipelined enqueue, each task is still a round trip of its own.
+const batchConcurrency = 16
+
+// BatchItem is a single task to enqueue as part of a batch. Only tasks
+// without enqueue side effects belong in a batch: vectorize tasks go through
+// EnqueueVectorizePage for its backpressure and aggregation.
+type BatchItem struct {
+	Task *asynq.Task
+	Opts []asynq.Option
+}
+
+// BatchResult holds the outcome of enqueuing a single BatchItem.
+// Index refers to the position of the item in the submitted batch.
+type BatchResult struct {
+	Index  int
+	TaskID string
+	Queue  string
+	Err    error
+}
+
+// BatchSummary aggregates the results of a batch enqueue.
+type BatchSummary struct {
+	Results   []BatchResult
+	Succeeded int
+	Failed    int
+}
+
+// NewCrawlWebsiteItem builds a batch item for a crawl website task.
+func NewCrawlWebsiteItem(websiteID uint, startURL string, owner tenant.Tenant, meta requestid.Meta) (BatchItem, error) {
+	payload, err := NewCrawlWebsitePayload(websiteID, startURL, owner, meta)
+	if err != nil {
+		return BatchItem{}, fmt.Errorf("failed to create crawl payload: %w", err)
+	}
+
+	return BatchItem{
+		Task: asynq.NewTask(TypeCrawlWebsite, payload),
+		Opts: []asynq.Option{
+			asynq.MaxRetry(3),
+			asynq.Timeout(30 * time.Minute),
+			asynq.Queue("crawl"),
+			asynq.TaskID(crawlTaskID(websiteID)),
+		},
+	}, nil
+}
+
+// EnqueueBatch enqueues many tasks, up to batchConcurrency at a time, and
+// reports a result per item. It queues the crawls of websites created in
+// bulk. A failure on one item does not stop the rest of the batch; callers
+// inspect the returned summary to decide how to handle partial failures.
+func (c *Client) EnqueueBatch(ctx context.Context, items []BatchItem) *BatchSummary {
+	summary := &BatchSummary{
+		Results: make([]BatchResult, len(items)),
+	}
+
+	if len(items) == 0 {
+		return summary
+	}
+
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		summary.Results[i].Index = i
+
+		if item.Task == nil {
+			summary.Results[i].Err = fmt.Errorf("batch item %d has no task", i)
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, item BatchItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			info, err := c.client.EnqueueContext(ctx, item.Task, item.Opts...)
+			if errors.Is(err, asynq.ErrTaskIDConflict) {
+				err = ErrAlreadyQueued
+			}
+			if err != nil {
+				summary.Results[i].Err = err
+				return
+			}
+			summary.Results[i].TaskID = info.ID
+			summary.Results[i].Queue = info.Queue
+		}(i, item)
+	}
+
+	wg.Wait()
+
+	for _, res := range summary.Results {
+		if res.Err != nil {
+			summary.Failed++
+		} else {
+			summary.Succeeded++
+		}
+	}
+
+	if summary.Failed > 0 {
+		c.logger.Warn("Batch enqueue completed with failures",
+			zap.Int("total", len(items)),
+			zap.Int("succeeded", summary.Succeeded),
+			zap.Int("failed", summary.Failed),
+		)
+	} else {
+		c.logger.Info("Batch enqueue completed",
+			zap.Int("total", len(items)),
+		)
+	}
+
+	return summary
+}
@@ -2,55 +2,319 @@ package jobs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"hermit/internal/apperrors"
+	"hermit/internal/config"
+	"hermit/internal/requestid"
+	"hermit/internal/tenant"
+
 	"github.com/hibiken/asynq"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
+// ErrAlreadyQueued is returned when a task with the same ID is already
+// pending, scheduled, retrying or running.
+var ErrAlreadyQueued = apperrors.Conflict("task is already queued")
+
 // Client wraps asynq.Client for enqueuing tasks.
 type Client struct {
-	client *asynq.Client
-	logger *zap.Logger
+	client    *asynq.Client
+	inspector *asynq.Inspector
+	vectorize *vectorizeSemaphore
+	progress  redis.UniversalClient
+	batch     VectorizeBatch
+	// Completed tasks keep their result for the retention, archived tasks
+	// are pruned after the archive TTL
+	retention  time.Duration
+	archiveTTL time.Duration
+	logger     *zap.Logger
+}
+
+// ClientConfig holds configuration for the job client.
+type ClientConfig struct {
+	RedisURL string
+	// VectorizeLimit caps the vectorize tasks of a website in flight,
+	// enqueueing waits while the cap is reached
+	VectorizeLimit VectorizeLimit
+	// VectorizeBatch aggregates the vectorize tasks of small pages per
+	// website
+	VectorizeBatch VectorizeBatch
+	// Retention is how long completed tasks and their result are kept
+	Retention time.Duration
+	// ArchiveTTL is how long archived tasks are kept, 0 keeps them until
+	// asynq drops them
+	ArchiveTTL time.Duration
+}
+
+// ClientConfigFromConfig returns the job client configuration set in the
+// config.
+func ClientConfigFromConfig(cfg *config.Config) ClientConfig {
+	return ClientConfig{
+		RedisURL:       cfg.RedisURL,
+		VectorizeLimit: VectorizeLimitFromConfig(cfg),
+		VectorizeBatch: VectorizeBatchFromConfig(cfg),
+		Retention:      time.Duration(cfg.JobRetention) * time.Hour,
+		ArchiveTTL:     time.Duration(cfg.JobArchiveTTL) * time.Hour,
+	}
+}
+
+// crawlTaskID returns the task ID shared by crawl and recrawl tasks of a
+// website, so at most one of them is queued at a time.
+func crawlTaskID(websiteID uint) string {
+	return fmt.Sprintf("crawl:website:%d", websiteID)
 }
 
 // NewClient creates a new job client.
-func NewClient(redisURL string, logger *zap.Logger) (*Client, error) {
-	opt, err := asynq.ParseRedisURI(redisURL)
+func NewClient(cfg ClientConfig, logger *zap.Logger) (*Client, error) {
+	opt, err := asynq.ParseRedisURI(cfg.RedisURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
 	}
 
+	vectorize, err := newVectorizeSemaphore(opt, cfg.VectorizeLimit, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := newProgressStore(opt)
+	if err != nil {
+		return nil, err
+	}
+
 	client := asynq.NewClient(opt)
+	inspector := asynq.NewInspector(opt)
 
-	logger.Info("Job client initialized", zap.String("redisURL", redisURL))
+	logger.Info("Job client initialized", zap.String("redisURL", cfg.RedisURL))
 
 	return &Client{
-		client: client,
-		logger: logger,
+		client:     client,
+		inspector:  inspector,
+		vectorize:  vectorize,
+		progress:   progress,
+		batch:      cfg.VectorizeBatch,
+		retention:  cfg.Retention,
+		archiveTTL: cfg.ArchiveTTL,
+		logger:     logger,
 	}, nil
 }
 
 // Close closes the job client.
 func (c *Client) Close() error {
+	if err := c.inspector.Close(); err != nil {
+		c.logger.Warn("Failed to close job inspector", zap.Error(err))
+	}
+	if err := c.vectorize.close(); err != nil {
+		c.logger.Warn("Failed to close vectorize semaphore", zap.Error(err))
+	}
+	if err := c.progress.Close(); err != nil {
+		c.logger.Warn("Failed to close progress store", zap.Error(err))
+	}
 	return c.client.Close()
 }
 
+// enqueueCrawl enqueues a crawl or recrawl task for a website unless one is
+// already queued. Archived or completed tasks left behind by earlier crawls
+// are deleted so they don't block new ones.
+func (c *Client) enqueueCrawl(ctx context.Context, websiteID uint, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	taskID := crawlTaskID(websiteID)
+	opts = append(opts, asynq.Queue("crawl"), asynq.TaskID(taskID))
+
+	info, err := c.client.EnqueueContext(ctx, task, opts...)
+	if !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return info, err
+	}
+
+	existing, err := c.inspector.GetTaskInfo("crawl", taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect existing crawl task: %w", err)
+	}
+	if existing.State != asynq.TaskStateArchived && existing.State != asynq.TaskStateCompleted {
+		return nil, ErrAlreadyQueued
+	}
+
+	if err := c.inspector.DeleteTask("crawl", taskID); err != nil && !errors.Is(err, asynq.ErrTaskNotFound) {
+		return nil, fmt.Errorf("failed to delete stale crawl task: %w", err)
+	}
+
+	info, err = c.client.EnqueueContext(ctx, task, opts...)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return nil, ErrAlreadyQueued
+	}
+	return info, err
+}
+
+// CrawlTaskLive reports whether a crawl task of the website is queued,
+// running or waiting to be retried.
+func (c *Client) CrawlTaskLive(websiteID uint) (bool, error) {
+	info, err := c.inspector.GetTaskInfo("crawl", crawlTaskID(websiteID))
+	if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect crawl task: %w", err)
+	}
+	return info.State != asynq.TaskStateArchived && info.State != asynq.TaskStateCompleted, nil
+}
+
+// RequeueCrawl enqueues a recrawl of a website whose crawl stalled. It
+// returns false when a crawl task of the website is still queued or running,
+// e.g. waiting to be retried after its worker crashed.
+func (c *Client) RequeueCrawl(ctx context.Context, websiteID uint) (bool, error) {
+	err := c.EnqueueRecrawlWebsite(ctx, websiteID, 0)
+	if errors.Is(err, ErrAlreadyQueued) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CrawlJobStatus describes the crawl task of a website in the crawl queue.
+type CrawlJobStatus struct {
+	TaskID string `json:"task_id"`
+	State  string `json:"state"`
+	// Position is the 1-based position among pending crawl tasks, 0 when the
+	// task is not pending
+	Position      int        `json:"position,omitempty"`
+	PendingTotal  int        `json:"pending_total"`
+	NextProcessAt *time.Time `json:"next_process_at,omitempty"`
+	Retried       int        `json:"retried"`
+	LastError     string     `json:"last_error,omitempty"`
+	// Progress is the last progress reported by the crawl while it is active
+	Progress *Progress `json:"progress,omitempty"`
+}
+
+// QueueDepth is the number of tasks per state in a queue, with the tasks
+// processed and failed today.
+type QueueDepth struct {
+	Pending        int  `json:"pending"`
+	Active         int  `json:"active"`
+	Scheduled      int  `json:"scheduled"`
+	Retry          int  `json:"retry"`
+	Archived       int  `json:"archived"`
+	ProcessedToday int  `json:"processed_today"`
+	FailedToday    int  `json:"failed_today"`
+	Paused         bool `json:"paused"`
+}
+
+// GetQueueDepths returns the depth of every queue known to Redis.
+func (c *Client) GetQueueDepths() (map[string]QueueDepth, error) {
+	queues, err := c.inspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+
+	depths := make(map[string]QueueDepth, len(queues))
+	for _, queue := range queues {
+		info, err := c.inspector.GetQueueInfo(queue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect queue %s: %w", queue, err)
+		}
+		depths[queue] = QueueDepth{
+			Pending:        info.Pending,
+			Active:         info.Active,
+			Scheduled:      info.Scheduled,
+			Retry:          info.Retry,
+			Archived:       info.Archived,
+			ProcessedToday: info.Processed,
+			FailedToday:    info.Failed,
+			Paused:         info.Paused,
+		}
+	}
+
+	return depths, nil
+}
+
+// crawlQueuePageSize is the page size used when scanning pending crawl tasks
+// for a queue position.
+const crawlQueuePageSize = 100
+
+// GetCrawlJobStatus returns the state of a website's crawl task and, while it
+// is pending, its position in the crawl queue. It returns nil when no crawl
+// task exists for the website.
+func (c *Client) GetCrawlJobStatus(ctx context.Context, websiteID uint) (*CrawlJobStatus, error) {
+	taskID := crawlTaskID(websiteID)
+	info, err := c.inspector.GetTaskInfo("crawl", taskID)
+	if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect crawl task: %w", err)
+	}
+
+	status := &CrawlJobStatus{
+		TaskID:    info.ID,
+		State:     info.State.String(),
+		Retried:   info.Retried,
+		LastError: info.LastErr,
+	}
+	if !info.NextProcessAt.IsZero() {
+		next := info.NextProcessAt
+		status.NextProcessAt = &next
+	}
+
+	if info.State == asynq.TaskStateActive {
+		progress, err := c.GetProgress(ctx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		status.Progress = progress
+	}
+
+	queue, err := c.inspector.GetQueueInfo("crawl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect crawl queue: %w", err)
+	}
+	status.PendingTotal = queue.Pending
+
+	if info.State != asynq.TaskStatePending {
+		return status, nil
+	}
+
+	// Pending tasks are listed in processing order
+	for page := 1; (page-1)*crawlQueuePageSize < queue.Pending; page++ {
+		tasks, err := c.inspector.ListPendingTasks("crawl", asynq.PageSize(crawlQueuePageSize), asynq.Page(page))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pending crawl tasks: %w", err)
+		}
+		for i, task := range tasks {
+			if task.ID == taskID {
+				status.Position = (page-1)*crawlQueuePageSize + i + 1
+				return status, nil
+			}
+		}
+		if len(tasks) < crawlQueuePageSize {
+			break
+		}
+	}
+
+	return status, nil
+}
+
 // EnqueueCrawlWebsite enqueues a crawl website task.
 func (c *Client) EnqueueCrawlWebsite(ctx context.Context, websiteID uint, startURL string) error {
-	payload, err := NewCrawlWebsitePayload(websiteID, startURL)
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewCrawlWebsitePayload(websiteID, startURL, owner, requestid.MetaFromContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to create crawl payload: %w", err)
 	}
 
 	task := asynq.NewTask(TypeCrawlWebsite, payload)
 
-	info, err := c.client.EnqueueContext(ctx, task,
+	info, err := c.enqueueCrawl(ctx, websiteID, task,
 		asynq.MaxRetry(3),
 		asynq.Timeout(30*time.Minute),
-		asynq.Queue("crawl"),
 	)
+	if errors.Is(err, ErrAlreadyQueued) {
+		c.logger.Info("Crawl task already queued", zap.Uint("websiteID", websiteID))
+		return err
+	}
 	if err != nil {
 		c.logger.Error("Failed to enqueue crawl task",
 			zap.Uint("websiteID", websiteID),
@@ -70,21 +334,45 @@ func (c *Client) EnqueueCrawlWebsite(ctx context.Context, websiteID uint, startU
 	return nil
 }
 
-// EnqueueVectorizePage enqueues a vectorize page task.
-func (c *Client) EnqueueVectorizePage(ctx context.Context, websiteID, pageID uint, pageURL, content string) error {
-	payload, err := NewVectorizePagePayload(websiteID, pageID, pageURL, content)
+// EnqueueVectorizePage enqueues a vectorize page task. While the vectorize
+// tasks of the website in flight are at their cap it waits for one to finish,
+// pausing the crawl enqueueing them, and returns ErrBackpressure when none
+// finishes in time. Cancelling stop gives up the wait, e.g. when the crawl is
+// interrupted, without cancelling ctx. Small pages are vectorized in batches
+// per website.
+func (c *Client) EnqueueVectorizePage(ctx, stop context.Context, websiteID, pageID uint, pageURL, content string, tags []string) error {
+	taskID := fmt.Sprintf("vectorize:page:%d:%s", pageID, ulid.Make())
+
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewVectorizePagePayload(websiteID, pageID, pageURL, content, tags, taskID, owner, requestid.MetaFromContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to create vectorize payload: %w", err)
 	}
 
 	task := asynq.NewTask(TypeVectorizePage, payload)
 
-	info, err := c.client.EnqueueContext(ctx, task,
+	opts := []asynq.Option{
 		asynq.MaxRetry(5),
-		asynq.Timeout(10*time.Minute),
+		asynq.Timeout(10 * time.Minute),
 		asynq.Queue("vectorize"),
-	)
+		asynq.TaskID(taskID),
+	}
+	if group := c.batch.group(websiteID, len(content)); group != "" {
+		opts = append(opts, asynq.Group(group))
+	}
+
+	if err := c.vectorize.acquire(ctx, stop, websiteID, taskID); err != nil {
+		c.logger.Warn("Failed to enqueue vectorize task",
+			zap.Uint("websiteID", websiteID),
+			zap.Uint("pageID", pageID),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	info, err := c.client.EnqueueContext(ctx, task, opts...)
 	if err != nil {
+		c.vectorize.release(ctx, websiteID, taskID)
 		c.logger.Error("Failed to enqueue vectorize task",
 			zap.Uint("websiteID", websiteID),
 			zap.Uint("pageID", pageID),
@@ -103,20 +391,36 @@ func (c *Client) EnqueueVectorizePage(ctx context.Context, websiteID, pageID uin
 	return nil
 }
 
-// EnqueueRecrawlWebsite enqueues a recrawl website task.
-func (c *Client) EnqueueRecrawlWebsite(ctx context.Context, websiteID uint) error {
-	payload, err := NewRecrawlWebsitePayload(websiteID)
+// releaseVectorizeSlots frees the slots of vectorized pages once their task
+// finished for good, so the next pages of the website can be queued.
+func (c *Client) releaseVectorizeSlots(ctx context.Context, websiteID uint, slots ...string) {
+	for _, slot := range slots {
+		if slot != "" {
+			c.vectorize.release(ctx, websiteID, slot)
+		}
+	}
+}
+
+// EnqueueRecrawlWebsite enqueues a recrawl website task, processed after
+// delay when positive.
+func (c *Client) EnqueueRecrawlWebsite(ctx context.Context, websiteID uint, delay time.Duration) error {
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewRecrawlWebsitePayload(websiteID, owner, requestid.MetaFromContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to create recrawl payload: %w", err)
 	}
 
 	task := asynq.NewTask(TypeRecrawlWebsite, payload)
 
-	info, err := c.client.EnqueueContext(ctx, task,
-		asynq.MaxRetry(3),
-		asynq.Timeout(30*time.Minute),
-		asynq.Queue("crawl"),
-	)
+	opts := []asynq.Option{asynq.MaxRetry(3), asynq.Timeout(30 * time.Minute)}
+	if delay > 0 {
+		opts = append(opts, asynq.ProcessIn(delay))
+	}
+	info, err := c.enqueueCrawl(ctx, websiteID, task, opts...)
+	if errors.Is(err, ErrAlreadyQueued) {
+		c.logger.Info("Recrawl task already queued", zap.Uint("websiteID", websiteID))
+		return err
+	}
 	if err != nil {
 		c.logger.Error("Failed to enqueue recrawl task",
 			zap.Uint("websiteID", websiteID),
@@ -127,6 +431,7 @@ func (c *Client) EnqueueRecrawlWebsite(ctx context.Context, websiteID uint) erro
 
 	c.logger.Info("Enqueued recrawl task",
 		zap.Uint("websiteID", websiteID),
+		zap.Duration("delay", delay),
 		zap.String("taskID", info.ID),
 	)
 
@@ -134,8 +439,8 @@ func (c *Client) EnqueueRecrawlWebsite(ctx context.Context, websiteID uint) erro
 }
 
 // EnqueueCleanupOldPages enqueues a cleanup old pages task.
-func (c *Client) EnqueueCleanupOldPages(ctx context.Context, websiteID uint, daysOld int, deleteFrom string) error {
-	payload, err := NewCleanupOldPagesPayload(websiteID, daysOld, deleteFrom)
+func (c *Client) EnqueueCleanupOldPages(ctx context.Context, websiteID uint, daysOld int, deleteFrom string, dryRun bool) error {
+	payload, err := NewCleanupOldPagesPayload(websiteID, daysOld, deleteFrom, dryRun, requestid.MetaFromContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to create cleanup payload: %w", err)
 	}
@@ -165,21 +470,527 @@ func (c *Client) EnqueueCleanupOldPages(ctx context.Context, websiteID uint, day
 	return nil
 }
 
+// EnqueueGarbageCollect enqueues a garbage collection task and returns its task ID.
+// The report is kept as the task result for the retention.
+func (c *Client) EnqueueGarbageCollect(ctx context.Context, del bool) (string, error) {
+	payload, err := NewGarbageCollectPayload(del, requestid.MetaFromContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to create gc payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeGarbageCollect, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(1),
+		asynq.Timeout(1*time.Hour),
+		asynq.Queue("maintenance"),
+		asynq.Retention(c.retention),
+	)
+	if err != nil {
+		c.logger.Error("Failed to enqueue gc task", zap.Bool("delete", del), zap.Error(err))
+		return "", fmt.Errorf("failed to enqueue gc task: %w", err)
+	}
+
+	c.logger.Info("Enqueued gc task",
+		zap.Bool("delete", del),
+		zap.String("taskID", info.ID),
+	)
+
+	return info.ID, nil
+}
+
+// EnqueueCrawlWatchdog enqueues a task marking crawls without page activity
+// as failed. Every worker enqueues it on the same interval, the task ID of
+// the interval bucket makes it run once per interval.
+func (c *Client) EnqueueCrawlWatchdog(ctx context.Context, interval time.Duration) error {
+	payload, err := NewCrawlWatchdogPayload(requestid.MetaFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create crawl watchdog payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeCrawlWatchdog, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(1),
+		asynq.Timeout(10*time.Minute),
+		asynq.Queue("maintenance"),
+		asynq.TaskID(periodicTaskID("crawl-watchdog", interval, time.Now())),
+		asynq.Retention(max(c.retention, interval)),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		c.logger.Debug("Crawl watchdog already queued")
+		return nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to enqueue crawl watchdog task", zap.Error(err))
+		return fmt.Errorf("failed to enqueue crawl watchdog task: %w", err)
+	}
+
+	c.logger.Debug("Enqueued crawl watchdog task", zap.String("taskID", info.ID))
+
+	return nil
+}
+
+// RunCrawlWatchdog enqueues the crawl watchdog every interval until ctx is
+// cancelled. It returns immediately when interval is not positive.
+func (c *Client) RunCrawlWatchdog(ctx context.Context, interval time.Duration) {
+	c.runEvery(ctx, interval, "crawl watchdog", c.EnqueueCrawlWatchdog)
+}
+
+// periodicTaskID returns the task ID of a periodic task for the interval
+// bucket of now. asynq frees unique locks once tasks complete, while a task ID
+// conflicts as long as the task is kept, so periodic tasks are kept for at
+// least their interval and the other workers' enqueues of the bucket fail
+// with asynq.ErrTaskIDConflict.
+func periodicTaskID(name string, interval time.Duration, now time.Time) string {
+	return fmt.Sprintf("%s:%d", name, now.UnixNano()/int64(interval))
+}
+
+// runEvery calls enqueue every interval until ctx is cancelled. It returns
+// immediately when interval is not positive.
+func (c *Client) runEvery(ctx context.Context, interval time.Duration, name string, enqueue func(ctx context.Context, interval time.Duration) error) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := enqueue(ctx, interval); err != nil {
+			c.logger.Warn("Failed to schedule "+name, zap.Error(err))
+		}
+	}
+}
+
+// EnqueueIndexPages enqueues a task indexing specific pages of a website on the
+// critical queue and returns its task ID. The per-page results are kept as the
+// task result for the retention.
+func (c *Client) EnqueueIndexPages(ctx context.Context, websiteID uint, urls []string) (string, error) {
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewIndexPagesPayload(websiteID, urls, owner, requestid.MetaFromContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to create index payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeIndexPages, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(2),
+		asynq.Timeout(10*time.Minute),
+		asynq.Queue("critical"),
+		asynq.Retention(c.retention),
+	)
+	if err != nil {
+		c.logger.Error("Failed to enqueue index task",
+			zap.Uint("websiteID", websiteID),
+			zap.Int("pages", len(urls)),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("failed to enqueue index task: %w", err)
+	}
+
+	c.logger.Info("Enqueued index task",
+		zap.Uint("websiteID", websiteID),
+		zap.Int("pages", len(urls)),
+		zap.String("taskID", info.ID),
+	)
+
+	return info.ID, nil
+}
+
+// EnqueueRetryPage schedules the given retry of a page that could not be
+// fetched. Each retry of a page is only queued once.
+func (c *Client) EnqueueRetryPage(ctx context.Context, websiteID, pageID uint, pageURL string, retry int, delay time.Duration) error {
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewRetryPagePayload(websiteID, pageID, pageURL, owner, requestid.MetaFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create page retry payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeRetryPage, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(0),
+		asynq.Timeout(5*time.Minute),
+		asynq.Queue("crawl"),
+		asynq.ProcessIn(delay),
+		asynq.TaskID(fmt.Sprintf("crawl:page:%d:%d", pageID, retry)),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		c.logger.Debug("Page retry already queued", zap.Uint("pageID", pageID))
+		return nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to enqueue page retry task",
+			zap.Uint("websiteID", websiteID),
+			zap.Uint("pageID", pageID),
+			zap.String("url", pageURL),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to enqueue page retry task: %w", err)
+	}
+
+	c.logger.Info("Enqueued page retry task",
+		zap.Uint("websiteID", websiteID),
+		zap.Uint("pageID", pageID),
+		zap.Int("retry", retry),
+		zap.Duration("delay", delay),
+		zap.String("taskID", info.ID),
+	)
+
+	return nil
+}
+
+// EnqueueRebuildWebsite enqueues a task re-embedding all stored content of a
+// website and returns its task ID. It returns ErrAlreadyQueued while another
+// rebuild of the website is queued or running. The report is kept as the task
+// result for the retention.
+func (c *Client) EnqueueRebuildWebsite(ctx context.Context, websiteID uint) (string, error) {
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewRebuildWebsitePayload(websiteID, owner, requestid.MetaFromContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to create rebuild payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeRebuildWebsite, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(2),
+		asynq.Timeout(2*time.Hour),
+		asynq.Queue("vectorize"),
+		asynq.Unique(2*time.Hour),
+		asynq.Retention(c.retention),
+	)
+	if errors.Is(err, asynq.ErrDuplicateTask) {
+		c.logger.Info("Rebuild task already queued", zap.Uint("websiteID", websiteID))
+		return "", ErrAlreadyQueued
+	}
+	if err != nil {
+		c.logger.Error("Failed to enqueue rebuild task",
+			zap.Uint("websiteID", websiteID),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("failed to enqueue rebuild task: %w", err)
+	}
+
+	c.logger.Info("Enqueued rebuild task",
+		zap.Uint("websiteID", websiteID),
+		zap.String("taskID", info.ID),
+	)
+
+	return info.ID, nil
+}
+
+// EnqueueRevectorizePages enqueues a task vectorizing the pages of a website
+// whose vectorization failed again, and returns its task ID. It returns
+// ErrAlreadyQueued while another such task of the website is queued or
+// running. The report is kept as the task result for the retention.
+func (c *Client) EnqueueRevectorizePages(ctx context.Context, websiteID uint) (string, error) {
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewRevectorizePagesPayload(websiteID, owner, requestid.MetaFromContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to create revectorize payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeRevectorizePages, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(2),
+		asynq.Timeout(time.Hour),
+		asynq.Queue("vectorize"),
+		asynq.Unique(time.Hour),
+		asynq.Retention(c.retention),
+	)
+	if errors.Is(err, asynq.ErrDuplicateTask) {
+		c.logger.Info("Revectorize task already queued", zap.Uint("websiteID", websiteID))
+		return "", ErrAlreadyQueued
+	}
+	if err != nil {
+		c.logger.Error("Failed to enqueue revectorize task",
+			zap.Uint("websiteID", websiteID),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("failed to enqueue revectorize task: %w", err)
+	}
+
+	c.logger.Info("Enqueued revectorize task",
+		zap.Uint("websiteID", websiteID),
+		zap.String("taskID", info.ID),
+	)
+
+	return info.ID, nil
+}
+
+// summarizeWebsiteDelay is how long a website summary waits for more page
+// summaries, so a crawl rolls them up once instead of after every page.
+const summarizeWebsiteDelay = 2 * time.Minute
+
+// evaluateRAGMaxRetry is the number of times an evaluation run is retried
+// before it is marked as failed.
+const evaluateRAGMaxRetry = 3
+
+// EnqueueSummarizePage enqueues a task summarizing the stored content of a
+// page. A summary already queued for the page is not queued twice.
+func (c *Client) EnqueueSummarizePage(ctx context.Context, websiteID, pageID uint) error {
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewSummarizePagePayload(websiteID, pageID, owner, requestid.MetaFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create summarize page payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeSummarizePage, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(3),
+		asynq.Timeout(5*time.Minute),
+		asynq.Queue("default"),
+		asynq.TaskID(fmt.Sprintf("summarize:page:%d", pageID)),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		c.logger.Debug("Page summary already queued", zap.Uint("pageID", pageID))
+		return nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to enqueue summarize page task",
+			zap.Uint("websiteID", websiteID),
+			zap.Uint("pageID", pageID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to enqueue summarize page task: %w", err)
+	}
+
+	c.logger.Debug("Enqueued summarize page task",
+		zap.Uint("websiteID", websiteID),
+		zap.Uint("pageID", pageID),
+		zap.String("taskID", info.ID),
+	)
+
+	return nil
+}
+
+// EnqueueReprocessPage enqueues a task reprocessing a page from its archived
+// HTML and returns its task ID. A reprocess already queued for the page is
+// not queued twice.
+func (c *Client) EnqueueReprocessPage(ctx context.Context, websiteID, pageID uint) (string, error) {
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewReprocessPagePayload(websiteID, pageID, owner, requestid.MetaFromContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to create reprocess page payload: %w", err)
+	}
+
+	taskID := fmt.Sprintf("reprocess:page:%d", pageID)
+	task := asynq.NewTask(TypeReprocessPage, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(3),
+		asynq.Timeout(5*time.Minute),
+		asynq.Queue("default"),
+		asynq.TaskID(taskID),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		c.logger.Debug("Page reprocess already queued", zap.Uint("pageID", pageID))
+		return taskID, nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to enqueue reprocess page task",
+			zap.Uint("websiteID", websiteID),
+			zap.Uint("pageID", pageID),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("failed to enqueue reprocess page task: %w", err)
+	}
+
+	c.logger.Info("Enqueued reprocess page task",
+		zap.Uint("websiteID", websiteID),
+		zap.Uint("pageID", pageID),
+		zap.String("taskID", info.ID),
+	)
+
+	return info.ID, nil
+}
+
+// EnqueueReprocessWebsite enqueues a task reprocessing the archived pages of
+// a website on the maintenance queue and returns its task ID. A reprocess
+// already queued for the website is not queued twice. The report is kept as
+// the task result for the retention.
+func (c *Client) EnqueueReprocessWebsite(ctx context.Context, websiteID uint) (string, error) {
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewReprocessWebsitePayload(websiteID, owner, requestid.MetaFromContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to create reprocess website payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeReprocessWebsite, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(1),
+		asynq.Timeout(1*time.Hour),
+		asynq.Queue("maintenance"),
+		asynq.TaskID(fmt.Sprintf("reprocess:website:%d", websiteID)),
+		asynq.Retention(c.retention),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return "", ErrAlreadyQueued
+	}
+	if err != nil {
+		c.logger.Error("Failed to enqueue reprocess website task",
+			zap.Uint("websiteID", websiteID),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("failed to enqueue reprocess website task: %w", err)
+	}
+
+	c.logger.Info("Enqueued reprocess website task",
+		zap.Uint("websiteID", websiteID),
+		zap.String("taskID", info.ID),
+	)
+
+	return info.ID, nil
+}
+
+// EnqueueSummarizeWebsite enqueues a task rolling the page summaries of a
+// website up into a website summary. The task is delayed and not queued
+// twice, so the summaries of a crawl are rolled up together.
+func (c *Client) EnqueueSummarizeWebsite(ctx context.Context, websiteID uint) error {
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewSummarizeWebsitePayload(websiteID, owner, requestid.MetaFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create summarize website payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeSummarizeWebsite, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(3),
+		asynq.Timeout(5*time.Minute),
+		asynq.Queue("default"),
+		asynq.ProcessIn(summarizeWebsiteDelay),
+		asynq.TaskID(fmt.Sprintf("summarize:website:%d", websiteID)),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		c.logger.Debug("Website summary already queued", zap.Uint("websiteID", websiteID))
+		return nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to enqueue summarize website task",
+			zap.Uint("websiteID", websiteID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to enqueue summarize website task: %w", err)
+	}
+
+	c.logger.Info("Enqueued summarize website task",
+		zap.Uint("websiteID", websiteID),
+		zap.String("taskID", info.ID),
+	)
+
+	return nil
+}
+
+// indexStatsDelay is how long a refresh of the index stats of a website waits
+// for more pages, so a batch of vectorized pages is counted once.
+const indexStatsDelay = 30 * time.Second
+
+// EnqueueIndexStats enqueues a task refreshing the vector count and storage
+// usage of a website. The task is delayed and not queued twice, so the pages
+// vectorized meanwhile are counted together.
+func (c *Client) EnqueueIndexStats(ctx context.Context, websiteID uint) error {
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewIndexStatsPayload(websiteID, owner, requestid.MetaFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create index stats payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeIndexStats, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(3),
+		asynq.Timeout(5*time.Minute),
+		asynq.Queue("default"),
+		asynq.ProcessIn(indexStatsDelay),
+		asynq.TaskID(fmt.Sprintf("stats:index:%d", websiteID)),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		c.logger.Debug("Index stats already queued", zap.Uint("websiteID", websiteID))
+		return nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to enqueue index stats task",
+			zap.Uint("websiteID", websiteID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to enqueue index stats task: %w", err)
+	}
+
+	c.logger.Debug("Enqueued index stats task",
+		zap.Uint("websiteID", websiteID),
+		zap.String("taskID", info.ID),
+	)
+
+	return nil
+}
+
+// EnqueueEvaluateRAG enqueues a task running an evaluation of a website's
+// RAG answers.
+func (c *Client) EnqueueEvaluateRAG(ctx context.Context, websiteID, runID uint) error {
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewEvaluateRAGPayload(websiteID, runID, owner, requestid.MetaFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create evaluate payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeEvaluateRAG, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(evaluateRAGMaxRetry),
+		asynq.Timeout(time.Hour),
+		asynq.Queue("default"),
+		asynq.TaskID(fmt.Sprintf("rag:evaluate:%d", runID)),
+	)
+	if err != nil {
+		c.logger.Error("Failed to enqueue evaluate task",
+			zap.Uint("websiteID", websiteID),
+			zap.Uint("runID", runID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to enqueue evaluate task: %w", err)
+	}
+
+	c.logger.Info("Enqueued evaluate task",
+		zap.Uint("websiteID", websiteID),
+		zap.Uint("runID", runID),
+		zap.String("taskID", info.ID),
+	)
+
+	return nil
+}
+
 // EnqueueCrawlWebsiteDelayed enqueues a crawl task with a delay.
 func (c *Client) EnqueueCrawlWebsiteDelayed(ctx context.Context, websiteID uint, startURL string, delay time.Duration) error {
-	payload, err := NewCrawlWebsitePayload(websiteID, startURL)
+	owner, _ := tenant.FromContext(ctx)
+	payload, err := NewCrawlWebsitePayload(websiteID, startURL, owner, requestid.MetaFromContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to create crawl payload: %w", err)
 	}
 
 	task := asynq.NewTask(TypeCrawlWebsite, payload)
 
-	info, err := c.client.EnqueueContext(ctx, task,
+	info, err := c.enqueueCrawl(ctx, websiteID, task,
 		asynq.MaxRetry(3),
 		asynq.Timeout(30*time.Minute),
-		asynq.Queue("crawl"),
 		asynq.ProcessIn(delay),
 	)
+	if errors.Is(err, ErrAlreadyQueued) {
+		c.logger.Info("Crawl task already queued", zap.Uint("websiteID", websiteID))
+		return err
+	}
 	if err != nil {
 		c.logger.Error("Failed to enqueue delayed crawl task",
 			zap.Uint("websiteID", websiteID),
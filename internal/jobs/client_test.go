@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodicTaskID(t *testing.T) {
+	interval := 10 * time.Minute
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	id := periodicTaskID("crawl-watchdog", interval, start)
+	// Workers ticking anywhere within the bucket share the task ID
+	for _, offset := range []time.Duration{time.Second, 5 * time.Minute, interval - time.Nanosecond} {
+		if got := periodicTaskID("crawl-watchdog", interval, start.Add(offset)); got != id {
+			t.Errorf("periodicTaskID() %v into the bucket = %q, want %q", offset, got, id)
+		}
+	}
+
+	if got := periodicTaskID("crawl-watchdog", interval, start.Add(interval)); got == id {
+		t.Errorf("periodicTaskID() of the next bucket = %q, want a new ID", got)
+	}
+	if got := periodicTaskID("prune-tasks", interval, start); got == id {
+		t.Errorf("periodicTaskID() of another task = %q, want a new ID", got)
+	}
+}
@@ -2,10 +2,18 @@ package jobs
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"hermit/internal/crawler"
+	"hermit/internal/llm"
+	"hermit/internal/maintenance"
 	"hermit/internal/repositories"
+	"hermit/internal/schema"
+	"hermit/internal/storage"
+	"hermit/internal/tenant"
 	"hermit/internal/vectorizer"
 
 	"github.com/hibiken/asynq"
@@ -19,6 +27,14 @@ type Handlers struct {
 	vectorizer  *vectorizer.Service
 	websiteRepo *repositories.WebsiteRepository
 	pageRepo    *repositories.PageRepository
+	storage     *storage.GarageStorage
+	gc          *maintenance.GarbageCollector
+	watchdog    *maintenance.CrawlWatchdog
+	summarizer  *llm.Summarizer
+	evaluator   *llm.Evaluator
+	queueStats  *repositories.QueueStatsRepository
+	answerCache *llm.AnswerCache
+	client      *Client
 }
 
 // NewHandlers creates a new Handlers instance.
@@ -28,6 +44,14 @@ func NewHandlers(
 	vectorizer *vectorizer.Service,
 	websiteRepo *repositories.WebsiteRepository,
 	pageRepo *repositories.PageRepository,
+	storage *storage.GarageStorage,
+	gc *maintenance.GarbageCollector,
+	watchdog *maintenance.CrawlWatchdog,
+	summarizer *llm.Summarizer,
+	evaluator *llm.Evaluator,
+	queueStats *repositories.QueueStatsRepository,
+	answerCache *llm.AnswerCache,
+	client *Client,
 ) *Handlers {
 	return &Handlers{
 		logger:      logger,
@@ -35,6 +59,14 @@ func NewHandlers(
 		vectorizer:  vectorizer,
 		websiteRepo: websiteRepo,
 		pageRepo:    pageRepo,
+		storage:     storage,
+		gc:          gc,
+		watchdog:    watchdog,
+		summarizer:  summarizer,
+		evaluator:   evaluator,
+		queueStats:  queueStats,
+		answerCache: answerCache,
+		client:      client,
 	}
 }
 
@@ -43,21 +75,43 @@ func (h *Handlers) HandleCrawlWebsite(ctx context.Context, task *asynq.Task) err
 	payload, err := ParseCrawlWebsitePayload(task.Payload())
 	if err != nil {
 		h.logger.Error("Failed to parse crawl payload", zap.Error(err))
-		return fmt.Errorf("failed to parse payload: %w", err)
+		return payloadError(err)
 	}
 
-	h.logger.Info("Starting crawl job",
+	logger := tenant.Logger(ctx, h.logger)
+
+	// Wait for the crawl window of the website to open
+	website, err := h.websiteRepo.GetByID(ctx, payload.WebsiteID)
+	if err != nil {
+		logger.Warn("Failed to load website, crawling without checking its crawl window",
+			zap.Uint("websiteID", payload.WebsiteID),
+			zap.Error(err),
+		)
+	} else if website != nil {
+		if err := crawlWindowError(website); err != nil {
+			logger.Info("Deferring crawl job", zap.Uint("websiteID", payload.WebsiteID), zap.Error(err))
+			return err
+		}
+	}
+
+	logger.Info("Starting crawl job",
 		zap.Uint("websiteID", payload.WebsiteID),
 		zap.String("startURL", payload.StartURL),
 	)
 
-	// Execute the crawl (this is synchronous and will block)
-	h.crawler.Crawl(payload.WebsiteID, payload.StartURL)
+	// Execute the crawl (this is synchronous and will block). An interrupted
+	// crawl is retried, resuming from its checkpoint
+	if err := h.crawler.Crawl(ctx, payload.WebsiteID, payload.StartURL); err != nil {
+		logger.Info("Crawl job interrupted", zap.Uint("websiteID", payload.WebsiteID), zap.Error(err))
+		return err
+	}
 
-	h.logger.Info("Crawl job completed",
+	logger.Info("Crawl job completed",
 		zap.Uint("websiteID", payload.WebsiteID),
 		zap.String("startURL", payload.StartURL),
 	)
+	h.invalidateAnswers(ctx, logger, payload.WebsiteID)
+	h.queueIndexStats(ctx, logger, payload.WebsiteID)
 
 	return nil
 }
@@ -67,24 +121,27 @@ func (h *Handlers) HandleVectorizePage(ctx context.Context, task *asynq.Task) er
 	payload, err := ParseVectorizePagePayload(task.Payload())
 	if err != nil {
 		h.logger.Error("Failed to parse vectorize payload", zap.Error(err))
-		return fmt.Errorf("failed to parse payload: %w", err)
+		return payloadError(err)
 	}
 
-	h.logger.Info("Starting vectorize job",
+	logger := tenant.Logger(ctx, h.logger)
+	logger.Info("Starting vectorize job",
 		zap.Uint("websiteID", payload.WebsiteID),
 		zap.Uint("pageID", payload.PageID),
 		zap.String("pageURL", payload.PageURL),
 	)
 
-	err = h.vectorizer.ProcessPageContent(
-		ctx,
-		payload.WebsiteID,
-		payload.PageID,
-		payload.PageURL,
-		payload.Content,
-	)
+	err = h.vectorizePage(ctx, logger, payload.WebsiteID, payload.PageID, payload.PageURL, payload.Content, payload.Tags)
+
+	// Free the website's vectorize slot unless the task runs again
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	if err == nil || retried >= maxRetry {
+		h.client.releaseVectorizeSlots(ctx, payload.WebsiteID, payload.Slot)
+	}
+
 	if err != nil {
-		h.logger.Error("Failed to vectorize page",
+		logger.Error("Failed to vectorize page",
 			zap.Uint("websiteID", payload.WebsiteID),
 			zap.Uint("pageID", payload.PageID),
 			zap.Error(err),
@@ -92,10 +149,81 @@ func (h *Handlers) HandleVectorizePage(ctx context.Context, task *asynq.Task) er
 		return fmt.Errorf("failed to vectorize page: %w", err)
 	}
 
-	h.logger.Info("Vectorize job completed",
+	logger.Info("Vectorize job completed",
 		zap.Uint("websiteID", payload.WebsiteID),
 		zap.Uint("pageID", payload.PageID),
 	)
+	h.queueIndexStats(ctx, logger, payload.WebsiteID)
+
+	return nil
+}
+
+// HandleVectorizeBatch handles the vectorize batch task aggregated from the
+// vectorize page tasks of a website's small pages. The chunks of all pages are
+// embedded in a single request; pages whose chunks fail to be stored are
+// marked as failed without retrying the batch.
+func (h *Handlers) HandleVectorizeBatch(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseVectorizeBatchPayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse vectorize batch payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	logger := tenant.Logger(ctx, h.logger)
+	logger.Info("Starting vectorize batch job",
+		zap.Uint("websiteID", payload.WebsiteID),
+		zap.Int("pages", len(payload.Pages)),
+	)
+
+	pages := make([]vectorizer.PageContent, len(payload.Pages))
+	slots := make([]string, len(payload.Pages))
+	for i, page := range payload.Pages {
+		pages[i] = vectorizer.PageContent{
+			PageID:  page.PageID,
+			PageURL: page.PageURL,
+			Content: page.Content,
+			Tags:    page.Tags,
+		}
+		slots[i] = page.Slot
+	}
+
+	stored, failed, err := h.vectorizer.ProcessPages(ctx, payload.WebsiteID, pages)
+
+	// Free the website's vectorize slots unless the batch runs again
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	if err == nil || retried >= maxRetry {
+		h.client.releaseVectorizeSlots(ctx, payload.WebsiteID, slots...)
+	}
+
+	if err != nil {
+		for _, page := range payload.Pages {
+			h.recordVectorFailure(ctx, logger, page.PageID, err)
+		}
+		logger.Error("Failed to vectorize batch",
+			zap.Uint("websiteID", payload.WebsiteID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to vectorize batch: %w", err)
+	}
+
+	for pageID, chunks := range stored {
+		if err := h.pageRepo.SetVectorized(ctx, pageID, chunks); err != nil {
+			logger.Warn("Failed to record page vector status", zap.Uint("pageID", pageID), zap.Error(err))
+		}
+	}
+	for pageID, cause := range failed {
+		h.recordVectorFailure(ctx, logger, pageID, cause)
+	}
+
+	logger.Info("Vectorize batch job completed",
+		zap.Uint("websiteID", payload.WebsiteID),
+		zap.Int("vectorized", len(stored)),
+		zap.Int("failed", len(failed)),
+	)
+	if len(stored) > 0 {
+		h.queueIndexStats(ctx, logger, payload.WebsiteID)
+	}
 
 	return nil
 }
@@ -105,64 +233,591 @@ func (h *Handlers) HandleRecrawlWebsite(ctx context.Context, task *asynq.Task) e
 	payload, err := ParseRecrawlWebsitePayload(task.Payload())
 	if err != nil {
 		h.logger.Error("Failed to parse recrawl payload", zap.Error(err))
-		return fmt.Errorf("failed to parse payload: %w", err)
+		return payloadError(err)
 	}
 
-	h.logger.Info("Starting recrawl job",
+	logger := tenant.Logger(ctx, h.logger)
+	logger.Info("Starting recrawl job",
 		zap.Uint("websiteID", payload.WebsiteID),
 	)
 
 	// Get website details
 	website, err := h.websiteRepo.GetByID(ctx, payload.WebsiteID)
 	if err != nil {
-		h.logger.Error("Failed to get website",
+		logger.Error("Failed to get website",
 			zap.Uint("websiteID", payload.WebsiteID),
 			zap.Error(err),
 		)
 		return fmt.Errorf("failed to get website: %w", err)
 	}
 
-	// Execute the crawl
-	h.crawler.Crawl(payload.WebsiteID, website.URL)
+	// Wait for the crawl window of the website to open
+	if err := crawlWindowError(website); err != nil {
+		logger.Info("Deferring recrawl job", zap.Uint("websiteID", payload.WebsiteID), zap.Error(err))
+		return err
+	}
+
+	// Execute the crawl, retried from its checkpoint when interrupted
+	if err := h.crawler.Crawl(ctx, payload.WebsiteID, website.URL); err != nil {
+		logger.Info("Recrawl job interrupted", zap.Uint("websiteID", payload.WebsiteID), zap.Error(err))
+		return err
+	}
+
+	logger.Info("Recrawl job completed",
+		zap.Uint("websiteID", payload.WebsiteID),
+	)
+	h.invalidateAnswers(ctx, logger, payload.WebsiteID)
+	h.queueIndexStats(ctx, logger, payload.WebsiteID)
+
+	return nil
+}
+
+// HandleIndexPages handles the index pages task.
+func (h *Handlers) HandleIndexPages(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseIndexPagesPayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse index payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	logger := tenant.Logger(ctx, h.logger)
+	logger.Info("Starting index job",
+		zap.Uint("websiteID", payload.WebsiteID),
+		zap.Strings("urls", payload.URLs),
+	)
+
+	results, err := h.crawler.IndexPages(ctx, payload.WebsiteID, payload.URLs)
+	if err != nil {
+		logger.Error("Failed to index pages",
+			zap.Uint("websiteID", payload.WebsiteID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to index pages: %w", err)
+	}
+
+	// Keep the per-page results as the task result so they can be inspected later
+	if result, err := json.Marshal(results); err == nil {
+		if _, err := task.ResultWriter().Write(result); err != nil {
+			logger.Warn("Failed to write index results", zap.Error(err))
+		}
+	}
+
+	logger.Info("Index job completed", zap.Uint("websiteID", payload.WebsiteID))
+	h.queueIndexStats(ctx, logger, payload.WebsiteID)
+
+	return nil
+}
+
+// HandleRetryPage handles the page retry task.
+func (h *Handlers) HandleRetryPage(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseRetryPagePayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse page retry payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	logger := tenant.Logger(ctx, h.logger)
+	logger.Info("Retrying page",
+		zap.Uint("websiteID", payload.WebsiteID),
+		zap.Uint("pageID", payload.PageID),
+		zap.String("url", payload.URL),
+	)
+
+	// Failures are recorded on the page, which schedules the next retry
+	results, err := h.crawler.IndexPages(ctx, payload.WebsiteID, []string{payload.URL})
+	if err != nil {
+		return fmt.Errorf("failed to retry page: %w", err)
+	}
+
+	logger.Info("Page retry completed",
+		zap.Uint("pageID", payload.PageID),
+		zap.String("status", results[0].Status),
+	)
+	h.queueIndexStats(ctx, logger, payload.WebsiteID)
+
+	return nil
+}
+
+// RebuildReport summarizes the result of a rebuild website or revectorize
+// task.
+type RebuildReport struct {
+	PagesMatched    int `json:"pages_matched"`
+	PagesReembedded int `json:"pages_reembedded"`
+	Errors          int `json:"errors"`
+}
+
+// HandleRebuildWebsite handles the rebuild website task. It drops the vectors
+// of a website and embeds its stored page content again with the configured
+// model, so the website can be queried after the embedding model changed.
+func (h *Handlers) HandleRebuildWebsite(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseRebuildWebsitePayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse rebuild payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	logger := tenant.Logger(ctx, h.logger)
+	logger.Info("Starting rebuild job", zap.Uint("websiteID", payload.WebsiteID))
+
+	pages, err := h.pageRepo.GetByWebsiteID(ctx, payload.WebsiteID)
+	if err != nil {
+		return fmt.Errorf("failed to get pages: %w", err)
+	}
+
+	ids := make([]int64, len(pages))
+	for i, page := range pages {
+		ids[i] = int64(page.ID)
+	}
+	tags, err := h.pageRepo.GetTags(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	if err := h.vectorizer.DeleteWebsiteVectors(ctx, payload.WebsiteID); err != nil {
+		return fmt.Errorf("failed to delete website vectors: %w", err)
+	}
+
+	var report RebuildReport
+	for _, page := range pages {
+		if page.Status != "success" || !page.MinioObjectKey.Valid {
+			continue
+		}
+		report.PagesMatched++
+
+		if err := h.reembedPage(ctx, logger, page, tags[page.ID]); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.Error("Failed to re-embed page",
+				zap.Uint("pageID", page.ID),
+				zap.Error(err),
+			)
+			report.Errors++
+			continue
+		}
+		report.PagesReembedded++
+	}
+
+	logger.Info("Rebuild job completed",
+		zap.Uint("websiteID", payload.WebsiteID),
+		zap.Int("pagesMatched", report.PagesMatched),
+		zap.Int("pagesReembedded", report.PagesReembedded),
+		zap.Int("errors", report.Errors),
+	)
+	h.invalidateAnswers(ctx, logger, payload.WebsiteID)
+	h.queueIndexStats(ctx, logger, payload.WebsiteID)
+
+	if result, err := json.Marshal(report); err == nil {
+		if _, err := task.ResultWriter().Write(result); err != nil {
+			logger.Warn("Failed to write rebuild report", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// HandleRevectorizePages handles the revectorize task. It embeds the stored
+// content of the website's pages whose vectorization failed again.
+func (h *Handlers) HandleRevectorizePages(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseRevectorizePagesPayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse revectorize payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	logger := tenant.Logger(ctx, h.logger)
+	logger.Info("Starting revectorize job", zap.Uint("websiteID", payload.WebsiteID))
+
+	pages, err := h.pageRepo.ListByVectorStatus(ctx, payload.WebsiteID, schema.VectorStatusFailed)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int64, len(pages))
+	for i, page := range pages {
+		ids[i] = int64(page.ID)
+	}
+	tags, err := h.pageRepo.GetTags(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	report := RebuildReport{PagesMatched: len(pages)}
+	for _, page := range pages {
+		if err := h.reembedPage(ctx, logger, page, tags[page.ID]); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.Error("Failed to revectorize page",
+				zap.Uint("pageID", page.ID),
+				zap.Error(err),
+			)
+			report.Errors++
+			continue
+		}
+		report.PagesReembedded++
+	}
+
+	logger.Info("Revectorize job completed",
+		zap.Uint("websiteID", payload.WebsiteID),
+		zap.Int("pagesMatched", report.PagesMatched),
+		zap.Int("pagesReembedded", report.PagesReembedded),
+		zap.Int("errors", report.Errors),
+	)
+	if report.PagesReembedded > 0 {
+		h.invalidateAnswers(ctx, logger, payload.WebsiteID)
+		h.queueIndexStats(ctx, logger, payload.WebsiteID)
+	}
+
+	if result, err := json.Marshal(report); err == nil {
+		if _, err := task.ResultWriter().Write(result); err != nil {
+			logger.Warn("Failed to write revectorize report", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// reembedPage embeds the stored content of a page again, refreshing its text
+// for keyword search.
+func (h *Handlers) reembedPage(ctx context.Context, logger *zap.Logger, page schema.Page, tags []string) error {
+	content, err := h.storage.GetPageContent(ctx, page.MinioObjectKey.String)
+	if err != nil {
+		h.recordVectorFailure(ctx, logger, page.ID, err)
+		return err
+	}
+
+	content = h.crawler.IndexedContent(content, page.Images)
+	if err := h.pageRepo.SetSearchText(ctx, page.ID, page.Title.String, content); err != nil {
+		logger.Warn("Failed to index page text for search", zap.Uint("pageID", page.ID), zap.Error(err))
+	}
+
+	return h.vectorizePage(ctx, logger, page.WebsiteID, page.ID, page.URL, content, tags)
+}
+
+// vectorizePage embeds the content of a page and records on the page whether
+// its chunks were stored.
+func (h *Handlers) vectorizePage(ctx context.Context, logger *zap.Logger, websiteID, pageID uint, pageURL, content string, tags []string) error {
+	chunks, err := h.vectorizer.ProcessPageContent(ctx, websiteID, pageID, pageURL, content, tags)
+	if err != nil {
+		h.recordVectorFailure(ctx, logger, pageID, err)
+		return err
+	}
+
+	if err := h.pageRepo.SetVectorized(ctx, pageID, chunks); err != nil {
+		logger.Warn("Failed to record page vector status", zap.Uint("pageID", pageID), zap.Error(err))
+	}
+	return nil
+}
 
-	h.logger.Info("Recrawl job completed",
+// recordVectorFailure marks the vectorization of a page as failed, unless the
+// job itself was canceled.
+func (h *Handlers) recordVectorFailure(ctx context.Context, logger *zap.Logger, pageID uint, cause error) {
+	if ctx.Err() != nil {
+		return
+	}
+	if err := h.pageRepo.SetVectorFailed(ctx, pageID, cause.Error()); err != nil {
+		logger.Warn("Failed to record page vector status", zap.Uint("pageID", pageID), zap.Error(err))
+	}
+}
+
+// HandleReprocessPage handles the reprocess page task. It extracts, stores
+// and vectorizes a page again from its archived HTML.
+func (h *Handlers) HandleReprocessPage(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseReprocessPagePayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse reprocess page payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	logger := tenant.Logger(ctx, h.logger)
+
+	saved, err := h.crawler.ReprocessPage(ctx, payload.WebsiteID, payload.PageID)
+	if errors.Is(err, crawler.ErrNoArchivedHTML) {
+		logger.Warn("Skipping reprocess of page without archived HTML", zap.Uint("pageID", payload.PageID))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reprocess page: %w", err)
+	}
+
+	logger.Info("Page reprocess completed",
 		zap.Uint("websiteID", payload.WebsiteID),
+		zap.Uint("pageID", payload.PageID),
+		zap.Bool("saved", saved),
+	)
+	if saved {
+		h.invalidateAnswers(ctx, logger, payload.WebsiteID)
+	}
+	h.queueIndexStats(ctx, logger, payload.WebsiteID)
+
+	return nil
+}
+
+// HandleReprocessWebsite handles the reprocess website task. It extracts the
+// archived pages of a website again and keeps the report as the task result.
+func (h *Handlers) HandleReprocessWebsite(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseReprocessWebsitePayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse reprocess website payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	logger := tenant.Logger(ctx, h.logger)
+
+	report, err := h.crawler.ReprocessWebsite(ctx, payload.WebsiteID)
+	if err != nil {
+		return fmt.Errorf("failed to reprocess website: %w", err)
+	}
+
+	if result, err := json.Marshal(report); err == nil {
+		if _, err := task.ResultWriter().Write(result); err != nil {
+			logger.Warn("Failed to write reprocess report", zap.Error(err))
+		}
+	}
+	if report.Changed > 0 {
+		h.invalidateAnswers(ctx, logger, payload.WebsiteID)
+		h.queueIndexStats(ctx, logger, payload.WebsiteID)
+	}
+
+	return nil
+}
+
+// HandleSummarizePage handles the summarize page task. It summarizes the
+// stored content of a page unless its summary is already up to date, then
+// queues the summary of its website.
+func (h *Handlers) HandleSummarizePage(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseSummarizePagePayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse summarize page payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	logger := tenant.Logger(ctx, h.logger)
+
+	page, err := h.pageRepo.GetByID(ctx, payload.PageID)
+	if err != nil {
+		return fmt.Errorf("failed to get page: %w", err)
+	}
+	if page == nil || page.Status != "success" || !page.MinioObjectKey.Valid {
+		logger.Debug("Skipping summary of page without content", zap.Uint("pageID", payload.PageID))
+		return nil
+	}
+	if page.SummaryContentHash.Valid && page.SummaryContentHash.String == page.ContentHash.String {
+		logger.Debug("Page summary is up to date", zap.Uint("pageID", page.ID))
+		return nil
+	}
+
+	content, err := h.storage.GetPageContent(ctx, page.MinioObjectKey.String)
+	if err != nil {
+		return fmt.Errorf("failed to get page content: %w", err)
+	}
+
+	summary, err := h.summarizer.SummarizePage(ctx, page.URL, page.Title.String, content)
+	if err != nil {
+		return err
+	}
+
+	if err := h.pageRepo.SetSummary(ctx, page.ID, summary, page.ContentHash.String); err != nil {
+		return err
+	}
+
+	logger.Info("Summarized page",
+		zap.Uint("websiteID", page.WebsiteID),
+		zap.Uint("pageID", page.ID),
+		zap.Int("length", len(summary)),
+	)
+
+	if err := h.client.EnqueueSummarizeWebsite(ctx, page.WebsiteID); err != nil {
+		logger.Warn("Failed to enqueue website summary",
+			zap.Uint("websiteID", page.WebsiteID),
+			zap.Error(err),
+		)
+	}
+
+	return nil
+}
+
+// HandleSummarizeWebsite handles the summarize website task. It rolls the
+// summaries of the most recently crawled pages up into a website summary.
+func (h *Handlers) HandleSummarizeWebsite(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseSummarizeWebsitePayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse summarize website payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	logger := tenant.Logger(ctx, h.logger)
+
+	website, err := h.websiteRepo.GetByID(ctx, payload.WebsiteID)
+	if err != nil {
+		return fmt.Errorf("failed to get website: %w", err)
+	}
+	if website == nil {
+		logger.Debug("Skipping summary of deleted website", zap.Uint("websiteID", payload.WebsiteID))
+		return nil
+	}
+
+	pages, err := h.pageRepo.ListSummaries(ctx, website.ID, llm.MaxRollupPages)
+	if err != nil {
+		return err
+	}
+	if len(pages) == 0 {
+		logger.Debug("No page summaries to roll up", zap.Uint("websiteID", website.ID))
+		return nil
+	}
+
+	summaries := make([]llm.PageSummary, 0, len(pages))
+	for _, page := range pages {
+		summaries = append(summaries, llm.PageSummary{
+			URL:     page.URL,
+			Title:   page.Title.String,
+			Summary: page.Summary.String,
+		})
+	}
+
+	summary, err := h.summarizer.SummarizeWebsite(ctx, website.URL, summaries)
+	if err != nil {
+		return err
+	}
+
+	if err := h.websiteRepo.SetSummary(ctx, website.ID, summary); err != nil {
+		return err
+	}
+
+	logger.Info("Summarized website",
+		zap.Uint("websiteID", website.ID),
+		zap.Int("pages", len(summaries)),
+	)
+
+	return nil
+}
+
+// HandleIndexStats handles the index stats task. It stores the vector count
+// and storage usage of a website so they can be listed with it.
+func (h *Handlers) HandleIndexStats(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseIndexStatsPayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse index stats payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	logger := tenant.Logger(ctx, h.logger)
+
+	website, err := h.websiteRepo.GetByID(ctx, payload.WebsiteID)
+	if err != nil {
+		return fmt.Errorf("failed to get website: %w", err)
+	}
+	if website == nil {
+		logger.Debug("Skipping index stats of deleted website", zap.Uint("websiteID", payload.WebsiteID))
+		return nil
+	}
+
+	vectors, err := h.vectorizer.GetWebsiteVectorCount(ctx, website.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count website vectors: %w", err)
+	}
+
+	usage, err := h.storage.Usage(ctx, fmt.Sprintf("websites/%d/", website.ID))
+	if err != nil {
+		return fmt.Errorf("failed to get website storage usage: %w", err)
+	}
+
+	if err := h.websiteRepo.SetIndexStats(ctx, website.ID, vectors, usage.Objects, usage.Bytes); err != nil {
+		return err
+	}
+
+	logger.Debug("Updated index stats",
+		zap.Uint("websiteID", website.ID),
+		zap.Int("vectors", vectors),
+		zap.Int("objects", usage.Objects),
+		zap.Int64("bytes", usage.Bytes),
 	)
 
 	return nil
 }
 
+// queueIndexStats queues a refresh of the index stats of a website after its
+// vectors or stored content changed.
+func (h *Handlers) queueIndexStats(ctx context.Context, logger *zap.Logger, websiteID uint) {
+	if err := h.client.EnqueueIndexStats(ctx, websiteID); err != nil {
+		logger.Warn("Failed to enqueue index stats",
+			zap.Uint("websiteID", websiteID),
+			zap.Error(err),
+		)
+	}
+}
+
+// HandleEvaluateRAG handles the evaluate task. It runs the evaluation cases
+// of a website and stores the scores, and marks the run as failed once the
+// task ran out of retries.
+func (h *Handlers) HandleEvaluateRAG(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseEvaluateRAGPayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse evaluate payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	logger := tenant.Logger(ctx, h.logger)
+
+	err = h.evaluator.Run(ctx, payload.RunID)
+	if err == nil {
+		return nil
+	}
+
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	if retried >= maxRetry {
+		if failErr := h.evaluator.Fail(context.WithoutCancel(ctx), payload.RunID, err); failErr != nil {
+			logger.Warn("Failed to mark evaluation run as failed",
+				zap.Uint("runID", payload.RunID),
+				zap.Error(failErr),
+			)
+		}
+	}
+
+	return fmt.Errorf("failed to evaluate run %d: %w", payload.RunID, err)
+}
+
+// CleanupReport summarizes the result of a cleanup old pages task.
+type CleanupReport struct {
+	DryRun         bool   `json:"dry_run"`
+	PagesMatched   int    `json:"pages_matched"`
+	ObjectsDeleted int    `json:"objects_deleted"`
+	VectorsDeleted int    `json:"vectors_deleted"`
+	PagesPurged    int    `json:"pages_purged"`
+	Errors         int    `json:"errors"`
+	CrawledBefore  string `json:"crawled_before"`
+}
+
 // HandleCleanupOldPages handles the cleanup old pages task.
 func (h *Handlers) HandleCleanupOldPages(ctx context.Context, task *asynq.Task) error {
 	payload, err := ParseCleanupOldPagesPayload(task.Payload())
 	if err != nil {
 		h.logger.Error("Failed to parse cleanup payload", zap.Error(err))
-		return fmt.Errorf("failed to parse payload: %w", err)
+		return payloadError(err)
+	}
+
+	deleteStorage := payload.DeleteFrom == "storage" || payload.DeleteFrom == "both"
+	deleteVectors := payload.DeleteFrom == "vectors" || payload.DeleteFrom == "both"
+	if !deleteStorage && !deleteVectors {
+		return fmt.Errorf("invalid delete_from %q: %w", payload.DeleteFrom, asynq.SkipRetry)
+	}
+	if payload.DaysOld <= 0 {
+		return fmt.Errorf("days_old must be positive: %w", asynq.SkipRetry)
 	}
 
+	cutoff := time.Now().AddDate(0, 0, -payload.DaysOld)
+
 	h.logger.Info("Starting cleanup job",
 		zap.Uint("websiteID", payload.WebsiteID),
 		zap.Int("daysOld", payload.DaysOld),
 		zap.String("deleteFrom", payload.DeleteFrom),
+		zap.Bool("dryRun", payload.DryRun),
+		zap.Time("crawledBefore", cutoff),
 	)
 
-	// Query pages older than X days
-	// For now, implementing basic logic. Can be extended with:
-	// - Date-based filtering from database
-	// - Batch processing for large datasets
-	// - Transaction support for atomicity
-
-	deleteCount := 0
-	errorCount := 0
-
-	// Get all pages for the website
-	var pages []struct {
-		ID        uint
-		ObjectKey string
-	}
-
-	query := `SELECT id, object_key FROM pages WHERE website_id = $1 AND object_key IS NOT NULL`
-	err = h.pageRepo.DB().SelectContext(ctx, &pages, query, payload.WebsiteID)
+	pages, err := h.pageRepo.ListCrawledBefore(ctx, payload.WebsiteID, cutoff)
 	if err != nil {
 		h.logger.Error("Failed to query pages for cleanup",
 			zap.Uint("websiteID", payload.WebsiteID),
@@ -171,56 +826,210 @@ func (h *Handlers) HandleCleanupOldPages(ctx context.Context, task *asynq.Task)
 		return fmt.Errorf("failed to query pages: %w", err)
 	}
 
-	h.logger.Info("Found pages for potential cleanup",
-		zap.Int("count", len(pages)),
-		zap.Uint("websiteID", payload.WebsiteID),
-	)
-
-	// Note: Actual date-based filtering should be added when pages table has created_at/updated_at
-	// For now, this provides the framework for cleanup operations
+	report := CleanupReport{
+		DryRun:        payload.DryRun,
+		PagesMatched:  len(pages),
+		CrawledBefore: cutoff.Format(time.RFC3339),
+	}
 
 	for _, page := range pages {
-		shouldDelete := false
-
-		// Delete from storage if requested
-		if payload.DeleteFrom == "storage" || payload.DeleteFrom == "both" {
-			// Storage deletion would go here
-			// Skipping actual deletion for safety - needs storage service integration
-			h.logger.Debug("Would delete from storage",
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if payload.DryRun {
+			h.logger.Debug("Would clean up page",
 				zap.Uint("pageID", page.ID),
-				zap.String("objectKey", page.ObjectKey),
+				zap.String("url", page.URL),
 			)
-			shouldDelete = true
+			continue
 		}
 
-		// Delete from vector store if requested
-		if payload.DeleteFrom == "vectors" || payload.DeleteFrom == "both" {
-			// Vector deletion would go here
-			// Needs ChromaDB integration to delete by page_id metadata
-			h.logger.Debug("Would delete vectors",
-				zap.Uint("pageID", page.ID),
-			)
-			shouldDelete = true
+		failed := false
+
+		// Delete vectors first so a failure leaves the content available for re-vectorizing
+		if deleteVectors {
+			if err := h.vectorizer.DeletePageVectors(ctx, page.WebsiteID, page.ID); err != nil {
+				failed = true
+			} else {
+				report.VectorsDeleted++
+				if err := h.pageRepo.ClearVectorStatus(ctx, page.ID); err != nil {
+					h.logger.Warn("Failed to clear page vector status", zap.Uint("pageID", page.ID), zap.Error(err))
+				}
+			}
+		}
+
+		if deleteStorage && page.MinioObjectKey.Valid {
+			if err := h.storage.DeleteObject(ctx, page.MinioObjectKey.String); err != nil {
+				h.logger.Error("Failed to delete page content",
+					zap.Uint("pageID", page.ID),
+					zap.String("objectKey", page.MinioObjectKey.String),
+					zap.Error(err),
+				)
+				failed = true
+			} else {
+				report.ObjectsDeleted++
+
+				if err := h.pageRepo.MarkPurged(ctx, page.ID); err != nil {
+					h.logger.Error("Failed to mark page as purged",
+						zap.Uint("pageID", page.ID),
+						zap.Error(err),
+					)
+					failed = true
+				} else {
+					report.PagesPurged++
+				}
+			}
 		}
 
-		if shouldDelete {
-			deleteCount++
+		if failed {
+			report.Errors++
 		}
 	}
 
 	h.logger.Info("Cleanup job completed",
 		zap.Uint("websiteID", payload.WebsiteID),
-		zap.Int("pagesProcessed", len(pages)),
-		zap.Int("markedForDeletion", deleteCount),
-		zap.Int("errors", errorCount),
+		zap.Bool("dryRun", payload.DryRun),
+		zap.Int("pagesMatched", report.PagesMatched),
+		zap.Int("objectsDeleted", report.ObjectsDeleted),
+		zap.Int("vectorsDeleted", report.VectorsDeleted),
+		zap.Int("errors", report.Errors),
 	)
+	if !payload.DryRun {
+		h.queueIndexStats(ctx, h.logger, payload.WebsiteID)
+	}
+
+	if result, err := json.Marshal(report); err == nil {
+		if _, err := task.ResultWriter().Write(result); err != nil {
+			h.logger.Warn("Failed to write cleanup report", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// HandleGarbageCollect handles the garbage collection task.
+func (h *Handlers) HandleGarbageCollect(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseGarbageCollectPayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse gc payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	h.logger.Info("Starting garbage collection job", zap.Bool("delete", payload.Delete))
+
+	report, err := h.gc.Run(ctx, payload.Delete)
+	if err != nil {
+		return fmt.Errorf("garbage collection failed: %w", err)
+	}
+
+	// Keep the report as the task result so it can be inspected later
+	result, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode gc report: %w", err)
+	}
+	if _, err := task.ResultWriter().Write(result); err != nil {
+		h.logger.Warn("Failed to write gc report", zap.Error(err))
+	}
 
-	// Return info message for now since we're not doing actual deletion yet
-	// This provides the framework - actual deletion should be carefully implemented with:
-	// 1. Database transaction support
-	// 2. Rollback on failure
-	// 3. Audit logging
-	// 4. Confirmation requirements for production use
+	return nil
+}
+
+// HandleCrawlWatchdog handles the crawl watchdog task.
+func (h *Handlers) HandleCrawlWatchdog(ctx context.Context, task *asynq.Task) error {
+	if _, err := ParseCrawlWatchdogPayload(task.Payload()); err != nil {
+		h.logger.Error("Failed to parse crawl watchdog payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	report, err := h.watchdog.Run(ctx)
+	if err != nil {
+		return fmt.Errorf("crawl watchdog failed: %w", err)
+	}
+
+	// Keep the report as the task result so it can be inspected later
+	if result, err := json.Marshal(report); err == nil {
+		if _, err := task.ResultWriter().Write(result); err != nil {
+			h.logger.Warn("Failed to write crawl watchdog report", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// HandlePruneTasks handles the prune tasks task.
+func (h *Handlers) HandlePruneTasks(ctx context.Context, task *asynq.Task) error {
+	if _, err := ParsePruneTasksPayload(task.Payload()); err != nil {
+		h.logger.Error("Failed to parse prune tasks payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	report, err := h.client.PruneTasks(ctx)
+	if err != nil {
+		return fmt.Errorf("task prune failed: %w", err)
+	}
+
+	// Keep the report as the task result so it can be inspected later
+	if result, err := json.Marshal(report); err == nil {
+		if _, err := task.ResultWriter().Write(result); err != nil {
+			h.logger.Warn("Failed to write task prune report", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// HandlePruneAnswerCache deletes the expired cached answers of all websites.
+func (h *Handlers) HandlePruneAnswerCache(ctx context.Context, task *asynq.Task) error {
+	if _, err := ParsePruneAnswerCachePayload(task.Payload()); err != nil {
+		h.logger.Error("Failed to parse prune answer cache payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	deleted, err := h.answerCache.DeleteExpired(ctx)
+	if err != nil {
+		return fmt.Errorf("answer cache prune failed: %w", err)
+	}
+	if deleted > 0 {
+		h.logger.Info("Deleted expired cached answers", zap.Int64("deleted", deleted))
+	}
+
+	return nil
+}
+
+// invalidateAnswers drops the cached answers of a website whose content
+// changed. Failures are only logged, the answers still expire.
+func (h *Handlers) invalidateAnswers(ctx context.Context, logger *zap.Logger, websiteID uint) {
+	if err := h.answerCache.Invalidate(ctx, websiteID); err != nil {
+		logger.Warn("Failed to invalidate cached answers", zap.Uint("websiteID", websiteID), zap.Error(err))
+	}
+}
+
+// HandleSampleQueues stores the stats of the job queues and deletes samples
+// older than the retention.
+func (h *Handlers) HandleSampleQueues(ctx context.Context, task *asynq.Task) error {
+	payload, err := ParseSampleQueuesPayload(task.Payload())
+	if err != nil {
+		h.logger.Error("Failed to parse sample queues payload", zap.Error(err))
+		return payloadError(err)
+	}
+
+	samples, err := h.client.SampleQueues()
+	if err != nil {
+		return fmt.Errorf("failed to sample queues: %w", err)
+	}
+	if err := h.queueStats.Insert(ctx, samples); err != nil {
+		return err
+	}
+
+	if payload.RetentionHours > 0 {
+		cutoff := time.Now().Add(-time.Duration(payload.RetentionHours) * time.Hour)
+		deleted, err := h.queueStats.DeleteBefore(ctx, cutoff)
+		if err != nil {
+			h.logger.Warn("Failed to delete old queue samples", zap.Error(err))
+		} else if deleted > 0 {
+			h.logger.Debug("Deleted old queue samples", zap.Int64("deleted", deleted))
+		}
+	}
 
 	return nil
 }
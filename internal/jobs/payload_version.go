@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+// Payload schema versions. Bump a version whenever the shape of its payload
+// changes and teach the matching Parse function how to upgrade older versions.
+// Payloads enqueued before versioning was introduced decode as version 0.
+// Optional metadata such as requestid.Meta doesn't need a bump because older
+// workers ignore fields they don't know.
+const (
+	CrawlWebsitePayloadVersion     = 2
+	VectorizePagePayloadVersion    = 2
+	VectorizeBatchPayloadVersion   = 1
+	RecrawlWebsitePayloadVersion   = 2
+	CleanupOldPagesPayloadVersion  = 2
+	GarbageCollectPayloadVersion   = 1
+	CrawlWatchdogPayloadVersion    = 1
+	PruneTasksPayloadVersion       = 1
+	IndexPagesPayloadVersion       = 1
+	RetryPagePayloadVersion        = 1
+	RebuildWebsitePayloadVersion   = 1
+	RevectorizePagesPayloadVersion = 1
+	SummarizePagePayloadVersion    = 1
+	SummarizeWebsitePayloadVersion = 1
+	EvaluateRAGPayloadVersion      = 1
+	IndexStatsPayloadVersion       = 1
+	ReprocessPagePayloadVersion    = 1
+	ReprocessWebsitePayloadVersion = 1
+	SampleQueuesPayloadVersion     = 1
+	PruneAnswerCachePayloadVersion = 1
+)
+
+// ErrUnsupportedPayloadVersion is returned when a payload was written by a newer
+// version of the application than this worker understands. Such tasks are
+// retried so that an up-to-date worker can pick them up during rolling deploys.
+var ErrUnsupportedPayloadVersion = errors.New("unsupported payload version")
+
+// payloadHeader holds the fields shared by every job payload.
+type payloadHeader struct {
+	Version int `json:"version"`
+}
+
+// decodePayload unmarshals data into v after checking that its version is not
+// newer than maxVersion. It returns the version found in the payload.
+func decodePayload(data []byte, v any, maxVersion int) (int, error) {
+	var header payloadHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, err
+	}
+
+	if header.Version > maxVersion {
+		return header.Version, fmt.Errorf("%w: got v%d, supports up to v%d", ErrUnsupportedPayloadVersion, header.Version, maxVersion)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return header.Version, err
+	}
+
+	return header.Version, nil
+}
+
+// payloadError wraps a payload parse error for returning from a handler.
+// Malformed payloads will never succeed and are not retried, while payloads
+// from a newer version are retried until a compatible worker handles them.
+func payloadError(err error) error {
+	if errors.Is(err, ErrUnsupportedPayloadVersion) {
+		return fmt.Errorf("failed to parse payload: %w", err)
+	}
+	return fmt.Errorf("failed to parse payload: %w: %w", err, asynq.SkipRetry)
+}
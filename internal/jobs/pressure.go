@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/hibiken/asynq"
+)
+
+// ScalePolicy bounds the number of tasks a worker runs concurrently.
+type ScalePolicy struct {
+	Min int
+	Max int
+}
+
+// Target returns the concurrency needed to run backlog tasks at once,
+// clamped to the policy bounds.
+func (p ScalePolicy) Target(backlog int) int {
+	target := backlog
+	if target < p.Min {
+		target = p.Min
+	}
+	if p.Max > 0 && target > p.Max {
+		target = p.Max
+	}
+	return target
+}
+
+// QueuePressure is the load of a single queue.
+type QueuePressure struct {
+	Queue     string `json:"queue"`
+	Pending   int    `json:"pending"`
+	Active    int    `json:"active"`
+	Scheduled int    `json:"scheduled"`
+	Retry     int    `json:"retry"`
+	// LatencySeconds is the age of the oldest pending task
+	LatencySeconds float64 `json:"latency_seconds"`
+	Paused         bool    `json:"paused"`
+	// Pressure is the number of pending tasks per worker slot at maximum
+	// concurrency, above 1 the queue can't be drained in a single round
+	Pressure float64 `json:"pressure"`
+}
+
+// Pressure is the load of all queues along with the concurrency a worker
+// would need to absorb it.
+type Pressure struct {
+	Queues            []QueuePressure `json:"queues"`
+	Pending           int             `json:"pending"`
+	Active            int             `json:"active"`
+	Pressure          float64         `json:"pressure"`
+	MinConcurrency    int             `json:"min_concurrency"`
+	MaxConcurrency    int             `json:"max_concurrency"`
+	TargetConcurrency int             `json:"target_concurrency"`
+}
+
+// CollectPressure reads the depth of every queue from Redis and derives the
+// pressure and target concurrency under policy.
+func CollectPressure(inspector *asynq.Inspector, policy ScalePolicy) (*Pressure, error) {
+	queues, err := inspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+	sort.Strings(queues)
+
+	slots := float64(policy.Max)
+	if slots < 1 {
+		slots = 1
+	}
+
+	pressure := &Pressure{
+		Queues:         make([]QueuePressure, 0, len(queues)),
+		MinConcurrency: policy.Min,
+		MaxConcurrency: policy.Max,
+	}
+	for _, queue := range queues {
+		info, err := inspector.GetQueueInfo(queue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect queue %s: %w", queue, err)
+		}
+
+		pressure.Queues = append(pressure.Queues, QueuePressure{
+			Queue:          queue,
+			Pending:        info.Pending,
+			Active:         info.Active,
+			Scheduled:      info.Scheduled,
+			Retry:          info.Retry,
+			LatencySeconds: info.Latency.Seconds(),
+			Paused:         info.Paused,
+			Pressure:       float64(info.Pending) / slots,
+		})
+		if !info.Paused {
+			pressure.Pending += info.Pending
+		}
+		pressure.Active += info.Active
+	}
+
+	pressure.Pressure = float64(pressure.Pending) / slots
+	pressure.TargetConcurrency = policy.Target(pressure.Pending + pressure.Active)
+
+	return pressure, nil
+}
+
+// WritePrometheus writes the pressure as Prometheus gauges in the text
+// exposition format.
+func (p *Pressure) WritePrometheus(w io.Writer) error {
+	perQueue := []struct {
+		name  string
+		help  string
+		value func(QueuePressure) float64
+	}{
+		{"hermit_queue_pending_tasks", "Tasks waiting to be processed.", func(q QueuePressure) float64 { return float64(q.Pending) }},
+		{"hermit_queue_active_tasks", "Tasks being processed.", func(q QueuePressure) float64 { return float64(q.Active) }},
+		{"hermit_queue_scheduled_tasks", "Tasks scheduled for later.", func(q QueuePressure) float64 { return float64(q.Scheduled) }},
+		{"hermit_queue_retry_tasks", "Tasks waiting for a retry.", func(q QueuePressure) float64 { return float64(q.Retry) }},
+		{"hermit_queue_latency_seconds", "Age of the oldest pending task.", func(q QueuePressure) float64 { return q.LatencySeconds }},
+		{"hermit_queue_pressure", "Pending tasks per worker slot at maximum concurrency.", func(q QueuePressure) float64 { return q.Pressure }},
+	}
+
+	for _, metric := range perQueue {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", metric.name, metric.help, metric.name); err != nil {
+			return err
+		}
+		for _, queue := range p.Queues {
+			if _, err := fmt.Fprintf(w, "%s{queue=%q} %g\n", metric.name, queue.Queue, metric.value(queue)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return WriteGauge(w, "hermit_worker_target_concurrency", "Concurrency needed to absorb the current load within the scaling bounds.", float64(p.TargetConcurrency))
+}
+
+// WriteGauge writes a single unlabeled Prometheus gauge.
+func WriteGauge(w io.Writer, name, help string, value float64) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+	return err
+}
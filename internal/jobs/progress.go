@@ -0,0 +1,93 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// progressKeyPrefix prefixes the Redis keys holding the progress of running
+// tasks, keyed by task ID.
+const progressKeyPrefix = "hermit:progress:"
+
+// progressTTL forgets the progress of tasks that stopped reporting, e.g.
+// because their worker died, each report extends it.
+const progressTTL = time.Hour
+
+// Progress is how far a long running task got, as last reported by it.
+type Progress struct {
+	PagesDone int `json:"pages_done"`
+	// PagesTotal is the number of pages known so far, it grows while links
+	// are discovered
+	PagesTotal int       `json:"pages_total"`
+	Percent    float64   `json:"percent"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// newProgressStore creates the Redis client progress is stored with, on the
+// job queue's Redis.
+func newProgressStore(opt asynq.RedisConnOpt) (redis.UniversalClient, error) {
+	client, ok := opt.MakeRedisClient().(redis.UniversalClient)
+	if !ok {
+		return nil, fmt.Errorf("unsupported redis connection")
+	}
+	return client, nil
+}
+
+// progressKey returns the key of the progress of a task.
+func progressKey(taskID string) string {
+	return progressKeyPrefix + taskID
+}
+
+// ReportProgress stores the progress of the task running in ctx. It is a
+// no-op outside of a task, and failures are only logged as progress is
+// informational.
+func (c *Client) ReportProgress(ctx context.Context, done, total int) {
+	taskID, ok := asynq.GetTaskID(ctx)
+	if !ok {
+		return
+	}
+
+	total = max(total, done)
+	progress := Progress{
+		PagesDone:  done,
+		PagesTotal: total,
+		UpdatedAt:  time.Now(),
+	}
+	if total > 0 {
+		progress.Percent = float64(int(float64(done)/float64(total)*1000)) / 10
+	}
+
+	data, err := json.Marshal(progress)
+	if err != nil {
+		c.logger.Warn("Failed to encode task progress", zap.String("taskID", taskID), zap.Error(err))
+		return
+	}
+	if err := c.progress.Set(ctx, progressKey(taskID), data, progressTTL).Err(); err != nil {
+		c.logger.Warn("Failed to report task progress", zap.String("taskID", taskID), zap.Error(err))
+	}
+}
+
+// GetProgress returns the last progress reported by a task, nil when it
+// reported none.
+func (c *Client) GetProgress(ctx context.Context, taskID string) (*Progress, error) {
+	data, err := c.progress.Get(ctx, progressKey(taskID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task progress: %w", err)
+	}
+
+	var progress Progress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("failed to decode task progress: %w", err)
+	}
+	return &progress, nil
+}
@@ -0,0 +1,202 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"hermit/internal/requestid"
+	"hermit/internal/schema"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+)
+
+// maxHistoryPoints caps the points of a queue history, longer windows are
+// summarized in wider steps.
+const maxHistoryPoints = 288
+
+// SampleQueues reads the stats of every queue from Redis.
+func (c *Client) SampleQueues() ([]schema.QueueSample, error) {
+	queues, err := c.inspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+	sort.Strings(queues)
+
+	now := time.Now()
+	samples := make([]schema.QueueSample, 0, len(queues))
+	for _, queue := range queues {
+		info, err := c.inspector.GetQueueInfo(queue)
+		if errors.Is(err, asynq.ErrQueueNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect queue %s: %w", queue, err)
+		}
+		samples = append(samples, schema.QueueSample{
+			Queue:          queue,
+			Pending:        info.Pending,
+			Active:         info.Active,
+			Scheduled:      info.Scheduled,
+			Retry:          info.Retry,
+			Archived:       info.Archived,
+			Completed:      info.Completed,
+			ProcessedTotal: int64(info.ProcessedTotal),
+			FailedTotal:    int64(info.FailedTotal),
+			LatencyMS:      info.Latency.Milliseconds(),
+			Paused:         info.Paused,
+			SampledAt:      now,
+		})
+	}
+	return samples, nil
+}
+
+// EnqueueSampleQueues enqueues a task storing the stats of the job queues and
+// deleting samples older than the retention. Every worker enqueues it on the
+// same interval, the task ID of the interval bucket makes it run once per
+// interval, as rates are computed between consecutive samples.
+func (c *Client) EnqueueSampleQueues(ctx context.Context, interval, retention time.Duration) error {
+	payload, err := NewSampleQueuesPayload(int(retention/time.Hour), requestid.MetaFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create sample queues payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeSampleQueues, payload)
+
+	// Samples are frequent, so their tasks are only kept for the interval
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(0),
+		asynq.Timeout(time.Minute),
+		asynq.Queue("maintenance"),
+		asynq.TaskID(periodicTaskID("sample-queues", interval, time.Now())),
+		asynq.Retention(interval),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		c.logger.Debug("Queue sample already queued")
+		return nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to enqueue sample queues task", zap.Error(err))
+		return fmt.Errorf("failed to enqueue sample queues task: %w", err)
+	}
+
+	c.logger.Debug("Enqueued sample queues task", zap.String("taskID", info.ID))
+
+	return nil
+}
+
+// RunQueueSampler enqueues the queue sample every interval until ctx is
+// cancelled. It returns immediately when interval is not positive.
+func (c *Client) RunQueueSampler(ctx context.Context, interval, retention time.Duration) {
+	c.runEvery(ctx, interval, "queue sample", func(ctx context.Context, interval time.Duration) error {
+		return c.EnqueueSampleQueues(ctx, interval, retention)
+	})
+}
+
+// QueueHistoryPoint summarizes the samples of a queue taken during a step.
+// Depths are those of the last sample of the step.
+type QueueHistoryPoint struct {
+	// Time is the start of the step
+	Time      time.Time `json:"time"`
+	Pending   int       `json:"pending"`
+	Active    int       `json:"active"`
+	Scheduled int       `json:"scheduled"`
+	Retry     int       `json:"retry"`
+	Archived  int       `json:"archived"`
+	// Backlog is the number of tasks waiting to run: pending, scheduled and
+	// waiting for a retry
+	Backlog int `json:"backlog"`
+	// Processed and Failed count the tasks finished during the step,
+	// Processed including the failed ones
+	Processed int64 `json:"processed"`
+	Failed    int64 `json:"failed"`
+	// ThroughputPerMinute is the rate Processed tasks were finished at
+	ThroughputPerMinute float64 `json:"throughput_per_minute"`
+	// LatencySeconds is the highest age of the oldest pending task
+	LatencySeconds float64 `json:"latency_seconds"`
+	Paused         bool    `json:"paused"`
+}
+
+// QueueHistory is the time series of the stats of a queue over a window.
+type QueueHistory struct {
+	Queue  string `json:"queue"`
+	Window string `json:"window"`
+	Step   string `json:"step"`
+	// Points are oldest first; steps without samples are left out
+	Points []QueueHistoryPoint `json:"points"`
+}
+
+// HistoryStep returns the step of the history over a window: the window
+// split in at most maxHistoryPoints, and at least a minute.
+func HistoryStep(window time.Duration) time.Duration {
+	step := (window + maxHistoryPoints - 1) / maxHistoryPoints
+	if step < time.Minute {
+		return time.Minute
+	}
+	return step.Round(time.Second)
+}
+
+// HistoryStart returns when the history over a window until now starts,
+// aligned to its step.
+func HistoryStart(window time.Duration, now time.Time) time.Time {
+	return now.Add(-window).Truncate(HistoryStep(window))
+}
+
+// NewQueueHistory summarizes the samples of a queue, oldest first, in steps
+// of HistoryStep covering the window until now. Samples before the start
+// only serve as the baseline of the counters.
+func NewQueueHistory(queue string, window time.Duration, samples []schema.QueueSample, now time.Time) *QueueHistory {
+	step := HistoryStep(window)
+	start := HistoryStart(window, now)
+
+	history := &QueueHistory{
+		Queue:  queue,
+		Window: window.String(),
+		Step:   step.String(),
+		Points: []QueueHistoryPoint{},
+	}
+
+	var previous *schema.QueueSample
+	var point *QueueHistoryPoint
+	for i := range samples {
+		sample := &samples[i]
+		if sample.SampledAt.Before(start) {
+			previous = sample
+			continue
+		}
+		bucket := start.Add(sample.SampledAt.Sub(start).Truncate(step))
+		if point == nil || !point.Time.Equal(bucket) {
+			history.Points = append(history.Points, QueueHistoryPoint{Time: bucket})
+			point = &history.Points[len(history.Points)-1]
+		}
+
+		point.Pending = sample.Pending
+		point.Active = sample.Active
+		point.Scheduled = sample.Scheduled
+		point.Retry = sample.Retry
+		point.Archived = sample.Archived
+		point.Backlog = sample.Pending + sample.Scheduled + sample.Retry
+		point.Paused = sample.Paused
+		if latency := float64(sample.LatencyMS) / 1000; latency > point.LatencySeconds {
+			point.LatencySeconds = latency
+		}
+
+		// The counters start over when Redis loses its data, so drops count
+		// as nothing processed
+		if previous != nil {
+			if processed := sample.ProcessedTotal - previous.ProcessedTotal; processed > 0 {
+				point.Processed += processed
+			}
+			if failed := sample.FailedTotal - previous.FailedTotal; failed > 0 {
+				point.Failed += failed
+			}
+		}
+		point.ThroughputPerMinute = float64(point.Processed) / step.Minutes()
+		previous = sample
+	}
+
+	return history
+}
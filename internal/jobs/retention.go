@@ -0,0 +1,193 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hermit/internal/requestid"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+)
+
+// pruneBatchSize is the number of tasks listed per page while pruning.
+const pruneBatchSize = 500
+
+// PruneReport summarizes the tasks deleted by a task prune run.
+type PruneReport struct {
+	// Completed tasks kept longer than the retention, e.g. enqueued before it
+	// was lowered
+	Completed int `json:"completed"`
+	// Archived tasks that failed longer than the archive TTL ago
+	Archived  int       `json:"archived"`
+	Errors    []string  `json:"errors,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+}
+
+// PruneTasks deletes the completed tasks of all queues that completed longer
+// than the retention ago and the archived tasks that failed longer than the
+// archive TTL ago. A TTL that is not positive keeps the tasks.
+func (c *Client) PruneTasks(ctx context.Context) (*PruneReport, error) {
+	report := &PruneReport{StartedAt: time.Now()}
+
+	queues, err := c.inspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+
+	for _, queue := range queues {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if c.retention > 0 {
+			n, err := c.pruneQueue(queue, report.StartedAt.Add(-c.retention), c.inspector.ListCompletedTasks,
+				func(task *asynq.TaskInfo) time.Time { return task.CompletedAt })
+			report.Completed += n
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("queue %s: completed tasks: %v", queue, err))
+			}
+		}
+
+		if c.archiveTTL > 0 {
+			n, err := c.pruneQueue(queue, report.StartedAt.Add(-c.archiveTTL), c.inspector.ListArchivedTasks,
+				func(task *asynq.TaskInfo) time.Time { return task.LastFailedAt })
+			report.Archived += n
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("queue %s: archived tasks: %v", queue, err))
+			}
+		}
+	}
+
+	report.Duration = time.Since(report.StartedAt).Round(time.Millisecond).String()
+
+	c.logger.Info("Task prune completed",
+		zap.Int("completed", report.Completed),
+		zap.Int("archived", report.Archived),
+		zap.Int("errors", len(report.Errors)),
+	)
+
+	return report, nil
+}
+
+// pruneQueue deletes the tasks of a queue listed by list whose time is before
+// cutoff, and returns the number of tasks deleted.
+func (c *Client) pruneQueue(
+	queue string,
+	cutoff time.Time,
+	list func(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error),
+	at func(task *asynq.TaskInfo) time.Time,
+) (int, error) {
+	// Collect the tasks first, deleting while paging would skip tasks
+	var expired []string
+	for page := 1; ; page++ {
+		tasks, err := list(queue, asynq.Page(page), asynq.PageSize(pruneBatchSize))
+		if err != nil {
+			if errors.Is(err, asynq.ErrQueueNotFound) {
+				return 0, nil
+			}
+			return 0, err
+		}
+		for _, task := range tasks {
+			if at(task).Before(cutoff) {
+				expired = append(expired, task.ID)
+			}
+		}
+		if len(tasks) < pruneBatchSize {
+			break
+		}
+	}
+
+	deleted := 0
+	for _, id := range expired {
+		err := c.inspector.DeleteTask(queue, id)
+		if errors.Is(err, asynq.ErrTaskNotFound) {
+			// Expired or deleted meanwhile
+			continue
+		}
+		if err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// EnqueuePruneTasks enqueues a task pruning old completed and archived
+// tasks. Every worker enqueues it on the same interval, the task ID of the
+// interval bucket makes it run once per interval.
+func (c *Client) EnqueuePruneTasks(ctx context.Context, interval time.Duration) error {
+	payload, err := NewPruneTasksPayload(requestid.MetaFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create prune tasks payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypePruneTasks, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(1),
+		asynq.Timeout(30*time.Minute),
+		asynq.Queue("maintenance"),
+		asynq.TaskID(periodicTaskID("prune-tasks", interval, time.Now())),
+		asynq.Retention(max(c.retention, interval)),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		c.logger.Debug("Task prune already queued")
+		return nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to enqueue prune tasks task", zap.Error(err))
+		return fmt.Errorf("failed to enqueue prune tasks task: %w", err)
+	}
+
+	c.logger.Debug("Enqueued prune tasks task", zap.String("taskID", info.ID))
+
+	return nil
+}
+
+// RunTaskPruner enqueues the task prune every interval until ctx is
+// cancelled. It returns immediately when interval is not positive.
+func (c *Client) RunTaskPruner(ctx context.Context, interval time.Duration) {
+	c.runEvery(ctx, interval, "task prune", c.EnqueuePruneTasks)
+}
+
+// EnqueuePruneAnswerCache enqueues a task deleting expired cached answers.
+// Every worker enqueues it on the same interval, the task ID of the interval
+// bucket makes it run once per interval.
+func (c *Client) EnqueuePruneAnswerCache(ctx context.Context, interval time.Duration) error {
+	payload, err := NewPruneAnswerCachePayload(requestid.MetaFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create prune answer cache payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypePruneAnswerCache, payload)
+
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.MaxRetry(1),
+		asynq.Timeout(10*time.Minute),
+		asynq.Queue("maintenance"),
+		asynq.TaskID(periodicTaskID("prune-answer-cache", interval, time.Now())),
+		asynq.Retention(max(c.retention, interval)),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		c.logger.Debug("Answer cache prune already queued")
+		return nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to enqueue prune answer cache task", zap.Error(err))
+		return fmt.Errorf("failed to enqueue prune answer cache task: %w", err)
+	}
+
+	c.logger.Debug("Enqueued prune answer cache task", zap.String("taskID", info.ID))
+
+	return nil
+}
+
+// RunAnswerCachePruner enqueues the answer cache prune every interval until
+// ctx is cancelled. It returns immediately when interval is not positive.
+func (c *Client) RunAnswerCachePruner(ctx context.Context, interval time.Duration) {
+	c.runEvery(ctx, interval, "answer cache prune", c.EnqueuePruneAnswerCache)
+}
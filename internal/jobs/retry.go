@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"hermit/internal/crawler"
+	"hermit/internal/requestid"
+	"hermit/internal/resilience"
+
+	"github.com/hibiken/asynq"
+)
+
+// maxRetryAge is how long after being enqueued a task may keep being retried
+// without counting the attempts, a few times the longest delay tasks are
+// enqueued with, e.g. until a crawl window opens or a page is fetched again.
+const maxRetryAge = 72 * time.Hour
+
+// retriedTooLongError wraps the error of a task older than maxRetryAge. It
+// doesn't unwrap, so the attempt counts as failed even when err doesn't.
+type retriedTooLongError struct {
+	err error
+	age time.Duration
+}
+
+// Error implements the error interface.
+func (e *retriedTooLongError) Error() string {
+	return fmt.Sprintf("retried for %s: %v", e.age.Round(time.Minute), e.err)
+}
+
+// retryDelay delays throttled tasks briefly, tasks that found a dependency down
+// until its breaker closes and other failed tasks with the default backoff.
+func retryDelay(n int, err error, task *asynq.Task) time.Duration {
+	var deferred *DeferredError
+	if errors.As(err, &deferred) {
+		return deferred.Delay
+	}
+
+	if errors.Is(err, ErrThrottled) {
+		return throttleDelay + rand.N(throttleDelay)
+	}
+
+	var unavailable *resilience.UnavailableError
+	if errors.As(err, &unavailable) {
+		return unavailable.RetryAfter + rand.N(unavailable.RetryAfter/2+time.Second)
+	}
+
+	if errors.Is(err, crawler.ErrInterrupted) {
+		return interruptedDelay
+	}
+
+	return asynq.DefaultRetryDelayFunc(n, err, task)
+}
+
+// isFailure reports whether err counts as a failed attempt. Tasks turned away
+// while a dependency is down keep their remaining retries for when it is back,
+// deferred crawls for when their window opens and interrupted crawls for when
+// they are resumed, until limitRetries counts them.
+func isFailure(err error) bool {
+	var unavailable *resilience.UnavailableError
+	var deferred *DeferredError
+	return !errors.Is(err, ErrThrottled) && !errors.As(err, &unavailable) && !errors.As(err, &deferred) &&
+		!errors.Is(err, crawler.ErrInterrupted)
+}
+
+// limitRetries is a middleware counting every failed attempt of tasks enqueued
+// more than maxRetryAge ago, so a task that is throttled, deferred or
+// interrupted over and over, or keeps finding a dependency down, runs out of
+// retries and is archived. Tasks enqueued before payloads carried their
+// enqueue time aren't limited.
+func limitRetries(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		err := next.ProcessTask(ctx, task)
+		if err == nil || isFailure(err) {
+			return err
+		}
+
+		var meta requestid.Meta
+		if json.Unmarshal(task.Payload(), &meta) != nil || meta.EnqueuedAt == 0 {
+			return err
+		}
+		if age := time.Since(time.Unix(meta.EnqueuedAt, 0)); age > maxRetryAge {
+			return &retriedTooLongError{err: err, age: age}
+		}
+		return err
+	})
+}
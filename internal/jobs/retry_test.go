@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"hermit/internal/crawler"
+
+	"github.com/hibiken/asynq"
+)
+
+func TestLimitRetries(t *testing.T) {
+	recent := time.Now().Add(-time.Hour).Unix()
+	old := time.Now().Add(-maxRetryAge - time.Hour).Unix()
+	errFetch := errors.New("fetch failed")
+
+	tests := []struct {
+		name        string
+		payload     string
+		err         error
+		wantFailure bool
+	}{
+		{name: "recent throttled task", payload: fmt.Sprintf(`{"enqueued_at":%d}`, recent), err: ErrThrottled},
+		{name: "old throttled task", payload: fmt.Sprintf(`{"enqueued_at":%d}`, old), err: ErrThrottled, wantFailure: true},
+		{name: "old interrupted crawl", payload: fmt.Sprintf(`{"enqueued_at":%d}`, old), err: crawler.ErrInterrupted, wantFailure: true},
+		{name: "old failed task", payload: fmt.Sprintf(`{"enqueued_at":%d}`, old), err: errFetch, wantFailure: true},
+		{name: "task without enqueue time", payload: `{"website_id":7}`, err: ErrThrottled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := limitRetries(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+				return tt.err
+			}))
+			err := handler.ProcessTask(context.Background(), asynq.NewTask(TypeCrawlWebsite, []byte(tt.payload)))
+
+			if err == nil {
+				t.Fatal("error = nil, want the task's error")
+			}
+			if got := isFailure(err); got != tt.wantFailure {
+				t.Errorf("isFailure(%v) = %v, want %v", err, got, tt.wantFailure)
+			}
+		})
+	}
+}
@@ -2,7 +2,16 @@ package jobs
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hermit/internal/crawler"
+	"hermit/internal/requestid"
+	"hermit/internal/tenant"
 
 	"github.com/hibiken/asynq"
 	"go.uber.org/zap"
@@ -14,6 +23,45 @@ type Server struct {
 	mux      *asynq.ServeMux
 	logger   *zap.Logger
 	handlers *Handlers
+	queues   map[string]int
+	stats    *serverStats
+
+	inspector   *asynq.Inspector
+	policy      ScalePolicy
+	scaler      *autoscaler
+	stopScaling context.CancelFunc
+
+	// stopping is cancelled when the server stops, interrupting crawls
+	stopping     context.Context
+	stopCrawling context.CancelFunc
+}
+
+// interruptedDelay is the delay before an interrupted crawl is resumed, e.g.
+// by another worker while this one shuts down.
+const interruptedDelay = 5 * time.Second
+
+// serverStats tracks the tasks processed by this server.
+type serverStats struct {
+	startedAt time.Time
+	mu        sync.Mutex
+	active    map[string]int
+	byTenant  map[string]int64
+	processed atomic.Int64
+	failed    atomic.Int64
+}
+
+// ServerStats is a snapshot of the tasks processed by this server.
+type ServerStats struct {
+	StartedAt time.Time      `json:"started_at"`
+	Uptime    string         `json:"uptime"`
+	Active    map[string]int `json:"active"`
+	Processed int64          `json:"processed"`
+	Failed    int64          `json:"failed"`
+	// ConcurrencyLimit is the number of tasks the server runs at most
+	ConcurrencyLimit int `json:"concurrency_limit"`
+	// ProcessedByTenant counts processed tasks per tenant label (the user
+	// role, or "system" for unattributed work), never per user.
+	ProcessedByTenant map[string]int64 `json:"processed_by_tenant"`
 }
 
 // ServerConfig holds configuration for the job server.
@@ -21,6 +69,16 @@ type ServerConfig struct {
 	RedisURL    string
 	Concurrency int
 	Queues      map[string]int
+	// Autoscale scales concurrency between its bounds with the queue
+	// pressure instead of running a fixed number of tasks
+	Autoscale         *ScalePolicy
+	AutoscaleInterval time.Duration
+	// VectorizeBatch aggregates the vectorize tasks of small pages grouped
+	// by the client
+	VectorizeBatch VectorizeBatch
+	// ShutdownTimeout is how long running tasks are waited for on shutdown
+	// before they are requeued, 8 seconds when zero
+	ShutdownTimeout time.Duration
 }
 
 // NewServer creates a new job server.
@@ -48,46 +106,131 @@ func NewServer(cfg ServerConfig, handlers *Handlers, logger *zap.Logger) (*Serve
 		concurrency = 10
 	}
 
-	server := asynq.NewServer(
-		opt,
-		asynq.Config{
-			Concurrency:  concurrency,
-			Queues:       queues,
-			Logger:       NewAsynqLogger(logger),
-			ErrorHandler: &errorHandler{logger: logger},
-			// Retry failed tasks
-			RetryDelayFunc: asynq.DefaultRetryDelayFunc,
-		},
-	)
+	// With autoscaling the asynq server runs at the maximum concurrency and
+	// the autoscaler holds back tasks above its current limit
+	policy := ScalePolicy{Min: concurrency, Max: concurrency}
+	if cfg.Autoscale != nil {
+		policy = *cfg.Autoscale
+		if policy.Min < 1 {
+			policy.Min = 1
+		}
+		if policy.Max < policy.Min {
+			policy.Max = policy.Min
+		}
+		concurrency = policy.Max
+	}
+
+	serverCfg := asynq.Config{
+		Concurrency:  concurrency,
+		Queues:       queues,
+		Logger:       NewAsynqLogger(logger),
+		ErrorHandler: &errorHandler{logger: logger},
+		// Retry failed tasks, throttled tasks and tasks that found
+		// a dependency down without counting the attempt, until
+		// limitRetries counts them
+		RetryDelayFunc: retryDelay,
+		IsFailure:      isFailure,
+		// The aggregator is registered with batching disabled too, so pages
+		// grouped by other processes are still vectorized
+		GroupAggregator: &vectorizeAggregator{logger: logger},
+	}
+	if cfg.ShutdownTimeout > 0 {
+		serverCfg.ShutdownTimeout = cfg.ShutdownTimeout
+	}
+	if batch := cfg.VectorizeBatch; batch.enabled() {
+		serverCfg.GroupMaxSize = batch.Size
+		serverCfg.GroupGracePeriod = max(batch.GracePeriod, time.Second)
+		serverCfg.GroupMaxDelay = batch.MaxDelay
+	}
+
+	server := asynq.NewServer(opt, serverCfg)
 
 	mux := asynq.NewServeMux()
+	inspector := asynq.NewInspector(opt)
+	stopping, stopCrawling := context.WithCancel(context.Background())
+
+	s := &Server{
+		server:   server,
+		mux:      mux,
+		logger:   logger,
+		handlers: handlers,
+		queues:   queues,
+		stats: &serverStats{
+			startedAt: time.Now(),
+			active:    make(map[string]int),
+			byTenant:  make(map[string]int64),
+		},
+		inspector:    inspector,
+		policy:       policy,
+		stopping:     stopping,
+		stopCrawling: stopCrawling,
+	}
+	if cfg.Autoscale != nil {
+		s.scaler = newAutoscaler(inspector, policy, cfg.AutoscaleInterval, queues, logger)
+	}
 
 	logger.Info("Job server initialized",
 		zap.Int("concurrency", concurrency),
+		zap.Bool("autoscale", s.scaler != nil),
+		zap.Int("minConcurrency", policy.Min),
+		zap.Int("maxConcurrency", policy.Max),
 		zap.Any("queues", queues),
 	)
 
-	return &Server{
-		server:   server,
-		mux:      mux,
-		logger:   logger,
-		handlers: handlers,
-	}, nil
+	return s, nil
 }
 
 // RegisterHandlers registers all task handlers.
 func (s *Server) RegisterHandlers() {
-	s.mux.HandleFunc(TypeCrawlWebsite, s.handlers.HandleCrawlWebsite)
+	s.mux.Use(limitRetries, withTenant, withRequestID)
+	if s.scaler != nil {
+		s.mux.Use(s.scaler.throttle)
+	}
+	s.mux.Use(s.trackTasks)
+
+	s.mux.HandleFunc(TypeCrawlWebsite, s.interruptible(s.handlers.HandleCrawlWebsite))
 	s.mux.HandleFunc(TypeVectorizePage, s.handlers.HandleVectorizePage)
-	s.mux.HandleFunc(TypeRecrawlWebsite, s.handlers.HandleRecrawlWebsite)
+	s.mux.HandleFunc(TypeVectorizeBatch, s.handlers.HandleVectorizeBatch)
+	s.mux.HandleFunc(TypeRecrawlWebsite, s.interruptible(s.handlers.HandleRecrawlWebsite))
 	s.mux.HandleFunc(TypeCleanupOldPages, s.handlers.HandleCleanupOldPages)
+	s.mux.HandleFunc(TypeGarbageCollect, s.handlers.HandleGarbageCollect)
+	s.mux.HandleFunc(TypeCrawlWatchdog, s.handlers.HandleCrawlWatchdog)
+	s.mux.HandleFunc(TypePruneTasks, s.handlers.HandlePruneTasks)
+	s.mux.HandleFunc(TypeIndexPages, s.handlers.HandleIndexPages)
+	s.mux.HandleFunc(TypeRetryPage, s.handlers.HandleRetryPage)
+	s.mux.HandleFunc(TypeRebuildWebsite, s.handlers.HandleRebuildWebsite)
+	s.mux.HandleFunc(TypeRevectorizePages, s.handlers.HandleRevectorizePages)
+	s.mux.HandleFunc(TypeSummarizePage, s.handlers.HandleSummarizePage)
+	s.mux.HandleFunc(TypeSummarizeWebsite, s.handlers.HandleSummarizeWebsite)
+	s.mux.HandleFunc(TypeEvaluateRAG, s.handlers.HandleEvaluateRAG)
+	s.mux.HandleFunc(TypeIndexStats, s.handlers.HandleIndexStats)
+	s.mux.HandleFunc(TypeReprocessPage, s.handlers.HandleReprocessPage)
+	s.mux.HandleFunc(TypeReprocessWebsite, s.handlers.HandleReprocessWebsite)
+	s.mux.HandleFunc(TypeSampleQueues, s.handlers.HandleSampleQueues)
+	s.mux.HandleFunc(TypePruneAnswerCache, s.handlers.HandlePruneAnswerCache)
 
 	s.logger.Info("Job handlers registered",
 		zap.Strings("types", []string{
 			TypeCrawlWebsite,
 			TypeVectorizePage,
+			TypeVectorizeBatch,
 			TypeRecrawlWebsite,
 			TypeCleanupOldPages,
+			TypeGarbageCollect,
+			TypeCrawlWatchdog,
+			TypePruneTasks,
+			TypeIndexPages,
+			TypeRetryPage,
+			TypeRebuildWebsite,
+			TypeRevectorizePages,
+			TypeSummarizePage,
+			TypeSummarizeWebsite,
+			TypeEvaluateRAG,
+			TypeIndexStats,
+			TypeReprocessPage,
+			TypeReprocessWebsite,
+			TypeSampleQueues,
+			TypePruneAnswerCache,
 		}),
 	)
 }
@@ -100,17 +243,144 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to start job server: %w", err)
 	}
 
+	if s.scaler != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopScaling = cancel
+		go s.scaler.Run(ctx)
+	}
+
 	s.logger.Info("Job server started successfully")
 	return nil
 }
 
-// Stop gracefully shuts down the job server.
+// Stop gracefully shuts down the job server. Running crawls are interrupted
+// so they save their progress and are resumed later, other tasks are waited
+// for until the shutdown timeout.
 func (s *Server) Stop() {
 	s.logger.Info("Stopping job server...")
+	if s.stopScaling != nil {
+		s.stopScaling()
+	}
+	s.server.Stop()
+	s.stopCrawling()
 	s.server.Shutdown()
+	if err := s.inspector.Close(); err != nil {
+		s.logger.Warn("Failed to close job inspector", zap.Error(err))
+	}
 	s.logger.Info("Job server stopped")
 }
 
+// Ping checks the connection to Redis.
+func (s *Server) Ping() error {
+	return s.server.Ping()
+}
+
+// Pressure returns the current queue pressure under the server's scaling
+// bounds.
+func (s *Server) Pressure() (*Pressure, error) {
+	return CollectPressure(s.inspector, s.policy)
+}
+
+// ConcurrencyLimit returns the number of tasks the server currently runs at
+// most.
+func (s *Server) ConcurrencyLimit() int {
+	if s.scaler != nil {
+		return s.scaler.Limit()
+	}
+	return s.policy.Max
+}
+
+// Stats returns the number of tasks currently running per queue and the
+// totals processed since the server started.
+func (s *Server) Stats() ServerStats {
+	s.stats.mu.Lock()
+	active := make(map[string]int, len(s.queues))
+	for queue := range s.queues {
+		active[queue] = s.stats.active[queue]
+	}
+	byTenant := make(map[string]int64, len(s.stats.byTenant))
+	for label, count := range s.stats.byTenant {
+		byTenant[label] = count
+	}
+	s.stats.mu.Unlock()
+
+	return ServerStats{
+		StartedAt:         s.stats.startedAt,
+		Uptime:            time.Since(s.stats.startedAt).Round(time.Second).String(),
+		Active:            active,
+		Processed:         s.stats.processed.Load(),
+		Failed:            s.stats.failed.Load(),
+		ConcurrencyLimit:  s.ConcurrencyLimit(),
+		ProcessedByTenant: byTenant,
+	}
+}
+
+// trackTasks is a middleware counting running and processed tasks per queue.
+func (s *Server) trackTasks(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		queue, _ := asynq.GetQueueName(ctx)
+
+		s.stats.mu.Lock()
+		s.stats.active[queue]++
+		s.stats.mu.Unlock()
+
+		defer func() {
+			s.stats.mu.Lock()
+			s.stats.active[queue]--
+			s.stats.mu.Unlock()
+		}()
+
+		err := next.ProcessTask(ctx, task)
+		s.stats.processed.Add(1)
+		if err != nil {
+			s.stats.failed.Add(1)
+		}
+
+		s.stats.mu.Lock()
+		s.stats.byTenant[tenant.Label(ctx)]++
+		s.stats.mu.Unlock()
+
+		return err
+	})
+}
+
+// interruptible is a middleware cancelling the context of a task when the
+// server stops, for crawls to checkpoint their progress and return before
+// the shutdown timeout.
+func (s *Server) interruptible(next asynq.HandlerFunc) asynq.HandlerFunc {
+	return func(ctx context.Context, task *asynq.Task) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		defer context.AfterFunc(s.stopping, cancel)()
+		return next(ctx, task)
+	}
+}
+
+// withTenant is a middleware adding the tenant a task was enqueued for to the
+// task context.
+func withTenant(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		var owner tenant.Tenant
+		if err := json.Unmarshal(task.Payload(), &owner); err == nil {
+			ctx = tenant.WithTenant(ctx, owner)
+		}
+		return next.ProcessTask(ctx, task)
+	})
+}
+
+// withRequestID is a middleware adding the ID of the API request that
+// enqueued a task to the task context, so the task's logs can be correlated
+// with the request and jobs it enqueues carry the same ID.
+func withRequestID(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		var meta requestid.Meta
+		if err := json.Unmarshal(task.Payload(), &meta); err == nil {
+			ctx = requestid.WithRequestID(ctx, meta.RequestID)
+		}
+		return next.ProcessTask(ctx, task)
+	})
+}
+
 // AsynqLogger adapts zap.Logger to asynq.Logger interface.
 type AsynqLogger struct {
 	logger *zap.Logger
@@ -153,8 +423,33 @@ type errorHandler struct {
 
 // HandleError handles task processing errors.
 func (h *errorHandler) HandleError(ctx context.Context, task *asynq.Task, err error) {
-	h.logger.Error("Task processing failed",
+	if errors.Is(err, ErrThrottled) {
+		return
+	}
+	var deferred *DeferredError
+	if errors.As(err, &deferred) {
+		h.logger.Info("Task deferred", zap.String("type", task.Type()), zap.Error(err))
+		return
+	}
+	if errors.Is(err, crawler.ErrInterrupted) {
+		h.logger.Info("Task interrupted, resuming it later", zap.String("type", task.Type()))
+		return
+	}
+
+	fields := []zap.Field{
 		zap.String("type", task.Type()),
 		zap.Error(err),
-	)
+	}
+
+	var owner tenant.Tenant
+	if json.Unmarshal(task.Payload(), &owner) == nil && owner.UserID != "" {
+		fields = append(fields, zap.String("userID", owner.UserID))
+	}
+
+	var meta requestid.Meta
+	if json.Unmarshal(task.Payload(), &meta) == nil && meta.RequestID != "" {
+		fields = append(fields, zap.String("requestID", meta.RequestID))
+	}
+
+	h.logger.Error("Task processing failed", fields...)
 }
@@ -3,27 +3,52 @@ package jobs
 import (
 	"encoding/json"
 	"fmt"
+
+	"hermit/internal/requestid"
+	"hermit/internal/tenant"
 )
 
 // Task types
 const (
-	TypeCrawlWebsite    = "crawl:website"
-	TypeVectorizePage   = "vectorize:page"
-	TypeRecrawlWebsite  = "recrawl:website"
-	TypeCleanupOldPages = "cleanup:old_pages"
+	TypeCrawlWebsite     = "crawl:website"
+	TypeVectorizePage    = "vectorize:page"
+	TypeVectorizeBatch   = "vectorize:batch"
+	TypeRecrawlWebsite   = "recrawl:website"
+	TypeCleanupOldPages  = "cleanup:old_pages"
+	TypeGarbageCollect   = "maintenance:gc"
+	TypeCrawlWatchdog    = "maintenance:crawl_watchdog"
+	TypePruneTasks       = "maintenance:prune_tasks"
+	TypeIndexPages       = "index:pages"
+	TypeRetryPage        = "crawl:page_retry"
+	TypeRebuildWebsite   = "vectorize:rebuild_website"
+	TypeRevectorizePages = "vectorize:failed_pages"
+	TypeSummarizePage    = "summarize:page"
+	TypeSummarizeWebsite = "summarize:website"
+	TypeEvaluateRAG      = "rag:evaluate"
+	TypeIndexStats       = "stats:index"
+	TypeReprocessPage    = "reprocess:page"
+	TypeReprocessWebsite = "reprocess:website"
+	TypeSampleQueues     = "maintenance:sample_queues"
+	TypePruneAnswerCache = "maintenance:prune_answer_cache"
 )
 
 // CrawlWebsitePayload represents the payload for crawling a website.
 type CrawlWebsitePayload struct {
+	Version   int    `json:"version"`
 	WebsiteID uint   `json:"website_id"`
 	StartURL  string `json:"start_url"`
+	tenant.Tenant
+	requestid.Meta
 }
 
 // NewCrawlWebsitePayload creates a new CrawlWebsitePayload.
-func NewCrawlWebsitePayload(websiteID uint, startURL string) ([]byte, error) {
+func NewCrawlWebsitePayload(websiteID uint, startURL string, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
 	payload := CrawlWebsitePayload{
+		Version:   CrawlWebsitePayloadVersion,
 		WebsiteID: websiteID,
 		StartURL:  startURL,
+		Tenant:    owner,
+		Meta:      meta,
 	}
 	return json.Marshal(payload)
 }
@@ -31,27 +56,47 @@ func NewCrawlWebsitePayload(websiteID uint, startURL string) ([]byte, error) {
 // ParseCrawlWebsitePayload parses a CrawlWebsitePayload from bytes.
 func ParseCrawlWebsitePayload(data []byte) (*CrawlWebsitePayload, error) {
 	var payload CrawlWebsitePayload
-	if err := json.Unmarshal(data, &payload); err != nil {
+	version, err := decodePayload(data, &payload, CrawlWebsitePayloadVersion)
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal crawl payload: %w", err)
 	}
+
+	// Payloads before v2 carry no tenant and are otherwise unchanged
+	if version < 2 {
+		payload.Version = CrawlWebsitePayloadVersion
+	}
+
 	return &payload, nil
 }
 
 // VectorizePagePayload represents the payload for vectorizing a page.
 type VectorizePagePayload struct {
+	Version   int    `json:"version"`
 	WebsiteID uint   `json:"website_id"`
 	PageID    uint   `json:"page_id"`
 	PageURL   string `json:"page_url"`
 	Content   string `json:"content"`
+	// Tags are stored with the chunks, older workers store the chunks without
+	Tags []string `json:"tags,omitempty"`
+	// Slot is the vectorize slot of the website the task holds, released
+	// once the page is vectorized
+	Slot string `json:"slot,omitempty"`
+	tenant.Tenant
+	requestid.Meta
 }
 
 // NewVectorizePagePayload creates a new VectorizePagePayload.
-func NewVectorizePagePayload(websiteID, pageID uint, pageURL, content string) ([]byte, error) {
+func NewVectorizePagePayload(websiteID, pageID uint, pageURL, content string, tags []string, slot string, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
 	payload := VectorizePagePayload{
+		Version:   VectorizePagePayloadVersion,
 		WebsiteID: websiteID,
 		PageID:    pageID,
 		PageURL:   pageURL,
 		Content:   content,
+		Tags:      tags,
+		Slot:      slot,
+		Tenant:    owner,
+		Meta:      meta,
 	}
 	return json.Marshal(payload)
 }
@@ -59,21 +104,74 @@ func NewVectorizePagePayload(websiteID, pageID uint, pageURL, content string) ([
 // ParseVectorizePagePayload parses a VectorizePagePayload from bytes.
 func ParseVectorizePagePayload(data []byte) (*VectorizePagePayload, error) {
 	var payload VectorizePagePayload
-	if err := json.Unmarshal(data, &payload); err != nil {
+	version, err := decodePayload(data, &payload, VectorizePagePayloadVersion)
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal vectorize payload: %w", err)
 	}
+
+	// Payloads before v2 carry no tenant and are otherwise unchanged
+	if version < 2 {
+		payload.Version = VectorizePagePayloadVersion
+	}
+
+	return &payload, nil
+}
+
+// VectorizeBatchPayload represents the payload for vectorizing the pages of a
+// website aggregated from vectorize page tasks.
+type VectorizeBatchPayload struct {
+	Version   int                  `json:"version"`
+	WebsiteID uint                 `json:"website_id"`
+	Pages     []VectorizeBatchPage `json:"pages"`
+	tenant.Tenant
+	requestid.Meta
+}
+
+// VectorizeBatchPage is a page of a VectorizeBatchPayload.
+type VectorizeBatchPage struct {
+	PageID  uint     `json:"page_id"`
+	PageURL string   `json:"page_url"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags,omitempty"`
+	Slot    string   `json:"slot,omitempty"`
+}
+
+// NewVectorizeBatchPayload creates a new VectorizeBatchPayload.
+func NewVectorizeBatchPayload(websiteID uint, pages []VectorizeBatchPage, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
+	payload := VectorizeBatchPayload{
+		Version:   VectorizeBatchPayloadVersion,
+		WebsiteID: websiteID,
+		Pages:     pages,
+		Tenant:    owner,
+		Meta:      meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseVectorizeBatchPayload parses a VectorizeBatchPayload from bytes.
+func ParseVectorizeBatchPayload(data []byte) (*VectorizeBatchPayload, error) {
+	var payload VectorizeBatchPayload
+	if _, err := decodePayload(data, &payload, VectorizeBatchPayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal vectorize batch payload: %w", err)
+	}
 	return &payload, nil
 }
 
 // RecrawlWebsitePayload represents the payload for recrawling a website.
 type RecrawlWebsitePayload struct {
+	Version   int  `json:"version"`
 	WebsiteID uint `json:"website_id"`
+	tenant.Tenant
+	requestid.Meta
 }
 
 // NewRecrawlWebsitePayload creates a new RecrawlWebsitePayload.
-func NewRecrawlWebsitePayload(websiteID uint) ([]byte, error) {
+func NewRecrawlWebsitePayload(websiteID uint, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
 	payload := RecrawlWebsitePayload{
+		Version:   RecrawlWebsitePayloadVersion,
 		WebsiteID: websiteID,
+		Tenant:    owner,
+		Meta:      meta,
 	}
 	return json.Marshal(payload)
 }
@@ -81,25 +179,38 @@ func NewRecrawlWebsitePayload(websiteID uint) ([]byte, error) {
 // ParseRecrawlWebsitePayload parses a RecrawlWebsitePayload from bytes.
 func ParseRecrawlWebsitePayload(data []byte) (*RecrawlWebsitePayload, error) {
 	var payload RecrawlWebsitePayload
-	if err := json.Unmarshal(data, &payload); err != nil {
+	version, err := decodePayload(data, &payload, RecrawlWebsitePayloadVersion)
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal recrawl payload: %w", err)
 	}
+
+	// Payloads before v2 carry no tenant and are otherwise unchanged
+	if version < 2 {
+		payload.Version = RecrawlWebsitePayloadVersion
+	}
+
 	return &payload, nil
 }
 
 // CleanupOldPagesPayload represents the payload for cleaning up old pages.
 type CleanupOldPagesPayload struct {
-	WebsiteID  uint   `json:"website_id,omitempty"`
+	Version    int    `json:"version"`
+	WebsiteID  uint   `json:"website_id,omitempty"` // 0 cleans up all websites
 	DaysOld    int    `json:"days_old"`
 	DeleteFrom string `json:"delete_from"` // "storage", "vectors", "both"
+	DryRun     bool   `json:"dry_run"`
+	requestid.Meta
 }
 
 // NewCleanupOldPagesPayload creates a new CleanupOldPagesPayload.
-func NewCleanupOldPagesPayload(websiteID uint, daysOld int, deleteFrom string) ([]byte, error) {
+func NewCleanupOldPagesPayload(websiteID uint, daysOld int, deleteFrom string, dryRun bool, meta requestid.Meta) ([]byte, error) {
 	payload := CleanupOldPagesPayload{
+		Version:    CleanupOldPagesPayloadVersion,
 		WebsiteID:  websiteID,
 		DaysOld:    daysOld,
 		DeleteFrom: deleteFrom,
+		DryRun:     dryRun,
+		Meta:       meta,
 	}
 	return json.Marshal(payload)
 }
@@ -107,8 +218,497 @@ func NewCleanupOldPagesPayload(websiteID uint, daysOld int, deleteFrom string) (
 // ParseCleanupOldPagesPayload parses a CleanupOldPagesPayload from bytes.
 func ParseCleanupOldPagesPayload(data []byte) (*CleanupOldPagesPayload, error) {
 	var payload CleanupOldPagesPayload
-	if err := json.Unmarshal(data, &payload); err != nil {
+	version, err := decodePayload(data, &payload, CleanupOldPagesPayloadVersion)
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal cleanup payload: %w", err)
 	}
+
+	// Before v2 the job never deleted anything, so keep older tasks non-destructive
+	if version < 2 {
+		payload.DryRun = true
+		payload.Version = CleanupOldPagesPayloadVersion
+	}
+
+	return &payload, nil
+}
+
+// GarbageCollectPayload represents the payload for reconciling storage and vectors with the database.
+type GarbageCollectPayload struct {
+	Version int  `json:"version"`
+	Delete  bool `json:"delete"` // false only reports orphans
+	requestid.Meta
+}
+
+// NewGarbageCollectPayload creates a new GarbageCollectPayload.
+func NewGarbageCollectPayload(del bool, meta requestid.Meta) ([]byte, error) {
+	payload := GarbageCollectPayload{
+		Version: GarbageCollectPayloadVersion,
+		Delete:  del,
+		Meta:    meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseGarbageCollectPayload parses a GarbageCollectPayload from bytes.
+func ParseGarbageCollectPayload(data []byte) (*GarbageCollectPayload, error) {
+	var payload GarbageCollectPayload
+	if _, err := decodePayload(data, &payload, GarbageCollectPayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal gc payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// CrawlWatchdogPayload represents the payload for finding stalled crawls.
+type CrawlWatchdogPayload struct {
+	Version int `json:"version"`
+	requestid.Meta
+}
+
+// NewCrawlWatchdogPayload creates a new CrawlWatchdogPayload.
+func NewCrawlWatchdogPayload(meta requestid.Meta) ([]byte, error) {
+	payload := CrawlWatchdogPayload{
+		Version: CrawlWatchdogPayloadVersion,
+		Meta:    meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseCrawlWatchdogPayload parses a CrawlWatchdogPayload from bytes.
+func ParseCrawlWatchdogPayload(data []byte) (*CrawlWatchdogPayload, error) {
+	var payload CrawlWatchdogPayload
+	if _, err := decodePayload(data, &payload, CrawlWatchdogPayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal crawl watchdog payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// PruneTasksPayload represents the payload for pruning old completed and
+// archived tasks.
+type PruneTasksPayload struct {
+	Version int `json:"version"`
+	requestid.Meta
+}
+
+// NewPruneTasksPayload creates a new PruneTasksPayload.
+func NewPruneTasksPayload(meta requestid.Meta) ([]byte, error) {
+	payload := PruneTasksPayload{
+		Version: PruneTasksPayloadVersion,
+		Meta:    meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParsePruneTasksPayload parses a PruneTasksPayload from bytes.
+func ParsePruneTasksPayload(data []byte) (*PruneTasksPayload, error) {
+	var payload PruneTasksPayload
+	if _, err := decodePayload(data, &payload, PruneTasksPayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal prune tasks payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// PruneAnswerCachePayload represents the payload for deleting expired
+// cached answers.
+type PruneAnswerCachePayload struct {
+	Version int `json:"version"`
+	requestid.Meta
+}
+
+// NewPruneAnswerCachePayload creates a new PruneAnswerCachePayload.
+func NewPruneAnswerCachePayload(meta requestid.Meta) ([]byte, error) {
+	payload := PruneAnswerCachePayload{
+		Version: PruneAnswerCachePayloadVersion,
+		Meta:    meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParsePruneAnswerCachePayload parses a PruneAnswerCachePayload from bytes.
+func ParsePruneAnswerCachePayload(data []byte) (*PruneAnswerCachePayload, error) {
+	var payload PruneAnswerCachePayload
+	if _, err := decodePayload(data, &payload, PruneAnswerCachePayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal prune answer cache payload: %w", err)
+	}
 	return &payload, nil
 }
+
+// SampleQueuesPayload represents the payload for sampling the stats of the
+// job queues.
+type SampleQueuesPayload struct {
+	Version int `json:"version"`
+	// RetentionHours is how long samples are kept, 0 keeps them
+	RetentionHours int `json:"retention_hours"`
+	requestid.Meta
+}
+
+// NewSampleQueuesPayload creates a new SampleQueuesPayload.
+func NewSampleQueuesPayload(retentionHours int, meta requestid.Meta) ([]byte, error) {
+	payload := SampleQueuesPayload{
+		Version:        SampleQueuesPayloadVersion,
+		RetentionHours: retentionHours,
+		Meta:           meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseSampleQueuesPayload parses a SampleQueuesPayload from bytes.
+func ParseSampleQueuesPayload(data []byte) (*SampleQueuesPayload, error) {
+	var payload SampleQueuesPayload
+	if _, err := decodePayload(data, &payload, SampleQueuesPayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sample queues payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// IndexPagesPayload represents the payload for indexing specific pages of a website.
+type IndexPagesPayload struct {
+	Version   int      `json:"version"`
+	WebsiteID uint     `json:"website_id"`
+	URLs      []string `json:"urls"`
+	tenant.Tenant
+	requestid.Meta
+}
+
+// NewIndexPagesPayload creates a new IndexPagesPayload.
+func NewIndexPagesPayload(websiteID uint, urls []string, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
+	payload := IndexPagesPayload{
+		Version:   IndexPagesPayloadVersion,
+		WebsiteID: websiteID,
+		URLs:      urls,
+		Tenant:    owner,
+		Meta:      meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseIndexPagesPayload parses an IndexPagesPayload from bytes.
+func ParseIndexPagesPayload(data []byte) (*IndexPagesPayload, error) {
+	var payload IndexPagesPayload
+	if _, err := decodePayload(data, &payload, IndexPagesPayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal index payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// RetryPagePayload represents the payload for retrying a page that could not be fetched.
+type RetryPagePayload struct {
+	Version   int    `json:"version"`
+	WebsiteID uint   `json:"website_id"`
+	PageID    uint   `json:"page_id"`
+	URL       string `json:"url"`
+	tenant.Tenant
+	requestid.Meta
+}
+
+// NewRetryPagePayload creates a new RetryPagePayload.
+func NewRetryPagePayload(websiteID, pageID uint, pageURL string, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
+	payload := RetryPagePayload{
+		Version:   RetryPagePayloadVersion,
+		WebsiteID: websiteID,
+		PageID:    pageID,
+		URL:       pageURL,
+		Tenant:    owner,
+		Meta:      meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseRetryPagePayload parses a RetryPagePayload from bytes.
+func ParseRetryPagePayload(data []byte) (*RetryPagePayload, error) {
+	var payload RetryPagePayload
+	if _, err := decodePayload(data, &payload, RetryPagePayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal page retry payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// RebuildWebsitePayload represents the payload for re-embedding the stored
+// content of a website.
+type RebuildWebsitePayload struct {
+	Version   int  `json:"version"`
+	WebsiteID uint `json:"website_id"`
+	tenant.Tenant
+	requestid.Meta
+}
+
+// NewRebuildWebsitePayload creates a new RebuildWebsitePayload.
+func NewRebuildWebsitePayload(websiteID uint, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
+	payload := RebuildWebsitePayload{
+		Version:   RebuildWebsitePayloadVersion,
+		WebsiteID: websiteID,
+		Tenant:    owner,
+		Meta:      meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseRebuildWebsitePayload parses a RebuildWebsitePayload from bytes.
+func ParseRebuildWebsitePayload(data []byte) (*RebuildWebsitePayload, error) {
+	var payload RebuildWebsitePayload
+	if _, err := decodePayload(data, &payload, RebuildWebsitePayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rebuild payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// RevectorizePagesPayload represents the payload for vectorizing the pages of
+// a website whose vectorization failed again.
+type RevectorizePagesPayload struct {
+	Version   int  `json:"version"`
+	WebsiteID uint `json:"website_id"`
+	tenant.Tenant
+	requestid.Meta
+}
+
+// NewRevectorizePagesPayload creates a new RevectorizePagesPayload.
+func NewRevectorizePagesPayload(websiteID uint, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
+	payload := RevectorizePagesPayload{
+		Version:   RevectorizePagesPayloadVersion,
+		WebsiteID: websiteID,
+		Tenant:    owner,
+		Meta:      meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseRevectorizePagesPayload parses a RevectorizePagesPayload from bytes.
+func ParseRevectorizePagesPayload(data []byte) (*RevectorizePagesPayload, error) {
+	var payload RevectorizePagesPayload
+	if _, err := decodePayload(data, &payload, RevectorizePagesPayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal revectorize payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// SummarizePagePayload represents the payload for summarizing a page.
+type SummarizePagePayload struct {
+	Version   int  `json:"version"`
+	WebsiteID uint `json:"website_id"`
+	PageID    uint `json:"page_id"`
+	tenant.Tenant
+	requestid.Meta
+}
+
+// NewSummarizePagePayload creates a new SummarizePagePayload.
+func NewSummarizePagePayload(websiteID, pageID uint, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
+	payload := SummarizePagePayload{
+		Version:   SummarizePagePayloadVersion,
+		WebsiteID: websiteID,
+		PageID:    pageID,
+		Tenant:    owner,
+		Meta:      meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseSummarizePagePayload parses a SummarizePagePayload from bytes.
+func ParseSummarizePagePayload(data []byte) (*SummarizePagePayload, error) {
+	var payload SummarizePagePayload
+	if _, err := decodePayload(data, &payload, SummarizePagePayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal summarize page payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// ReprocessPagePayload represents the payload for reprocessing a page from
+// its archived HTML.
+type ReprocessPagePayload struct {
+	Version   int  `json:"version"`
+	WebsiteID uint `json:"website_id"`
+	PageID    uint `json:"page_id"`
+	tenant.Tenant
+	requestid.Meta
+}
+
+// NewReprocessPagePayload creates a new ReprocessPagePayload.
+func NewReprocessPagePayload(websiteID, pageID uint, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
+	payload := ReprocessPagePayload{
+		Version:   ReprocessPagePayloadVersion,
+		WebsiteID: websiteID,
+		PageID:    pageID,
+		Tenant:    owner,
+		Meta:      meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseReprocessPagePayload parses a ReprocessPagePayload from bytes.
+func ParseReprocessPagePayload(data []byte) (*ReprocessPagePayload, error) {
+	var payload ReprocessPagePayload
+	if _, err := decodePayload(data, &payload, ReprocessPagePayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal reprocess page payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// ReprocessWebsitePayload represents the payload for reprocessing the pages
+// of a website from their archived HTML.
+type ReprocessWebsitePayload struct {
+	Version   int  `json:"version"`
+	WebsiteID uint `json:"website_id"`
+	tenant.Tenant
+	requestid.Meta
+}
+
+// NewReprocessWebsitePayload creates a new ReprocessWebsitePayload.
+func NewReprocessWebsitePayload(websiteID uint, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
+	payload := ReprocessWebsitePayload{
+		Version:   ReprocessWebsitePayloadVersion,
+		WebsiteID: websiteID,
+		Tenant:    owner,
+		Meta:      meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseReprocessWebsitePayload parses a ReprocessWebsitePayload from bytes.
+func ParseReprocessWebsitePayload(data []byte) (*ReprocessWebsitePayload, error) {
+	var payload ReprocessWebsitePayload
+	if _, err := decodePayload(data, &payload, ReprocessWebsitePayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal reprocess website payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// SummarizeWebsitePayload represents the payload for summarizing a website
+// from the summaries of its pages.
+type SummarizeWebsitePayload struct {
+	Version   int  `json:"version"`
+	WebsiteID uint `json:"website_id"`
+	tenant.Tenant
+	requestid.Meta
+}
+
+// NewSummarizeWebsitePayload creates a new SummarizeWebsitePayload.
+func NewSummarizeWebsitePayload(websiteID uint, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
+	payload := SummarizeWebsitePayload{
+		Version:   SummarizeWebsitePayloadVersion,
+		WebsiteID: websiteID,
+		Tenant:    owner,
+		Meta:      meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseSummarizeWebsitePayload parses a SummarizeWebsitePayload from bytes.
+func ParseSummarizeWebsitePayload(data []byte) (*SummarizeWebsitePayload, error) {
+	var payload SummarizeWebsitePayload
+	if _, err := decodePayload(data, &payload, SummarizeWebsitePayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal summarize website payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// EvaluateRAGPayload represents the payload for running an evaluation of a
+// website's RAG answers.
+type EvaluateRAGPayload struct {
+	Version   int  `json:"version"`
+	WebsiteID uint `json:"website_id"`
+	RunID     uint `json:"run_id"`
+	tenant.Tenant
+	requestid.Meta
+}
+
+// NewEvaluateRAGPayload creates a new EvaluateRAGPayload.
+func NewEvaluateRAGPayload(websiteID, runID uint, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
+	payload := EvaluateRAGPayload{
+		Version:   EvaluateRAGPayloadVersion,
+		WebsiteID: websiteID,
+		RunID:     runID,
+		Tenant:    owner,
+		Meta:      meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseEvaluateRAGPayload parses an EvaluateRAGPayload from bytes.
+func ParseEvaluateRAGPayload(data []byte) (*EvaluateRAGPayload, error) {
+	var payload EvaluateRAGPayload
+	if _, err := decodePayload(data, &payload, EvaluateRAGPayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal evaluate payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// IndexStatsPayload represents the payload for refreshing the vector count
+// and storage usage of a website.
+type IndexStatsPayload struct {
+	Version   int  `json:"version"`
+	WebsiteID uint `json:"website_id"`
+	tenant.Tenant
+	requestid.Meta
+}
+
+// NewIndexStatsPayload creates a new IndexStatsPayload.
+func NewIndexStatsPayload(websiteID uint, owner tenant.Tenant, meta requestid.Meta) ([]byte, error) {
+	payload := IndexStatsPayload{
+		Version:   IndexStatsPayloadVersion,
+		WebsiteID: websiteID,
+		Tenant:    owner,
+		Meta:      meta,
+	}
+	return json.Marshal(payload)
+}
+
+// ParseIndexStatsPayload parses an IndexStatsPayload from bytes.
+func ParseIndexStatsPayload(data []byte) (*IndexStatsPayload, error) {
+	var payload IndexStatsPayload
+	if _, err := decodePayload(data, &payload, IndexStatsPayloadVersion); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal index stats payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// ParsePayload decodes the payload of a task into its payload struct, e.g. a
+// *CrawlWebsitePayload for TypeCrawlWebsite. It returns ok false for task
+// types without a payload struct.
+func ParsePayload(taskType string, data []byte) (payload any, ok bool, err error) {
+	switch taskType {
+	case TypeCrawlWebsite:
+		payload, err = ParseCrawlWebsitePayload(data)
+	case TypeVectorizePage:
+		payload, err = ParseVectorizePagePayload(data)
+	case TypeVectorizeBatch:
+		payload, err = ParseVectorizeBatchPayload(data)
+	case TypeRecrawlWebsite:
+		payload, err = ParseRecrawlWebsitePayload(data)
+	case TypeCleanupOldPages:
+		payload, err = ParseCleanupOldPagesPayload(data)
+	case TypeGarbageCollect:
+		payload, err = ParseGarbageCollectPayload(data)
+	case TypeCrawlWatchdog:
+		payload, err = ParseCrawlWatchdogPayload(data)
+	case TypePruneTasks:
+		payload, err = ParsePruneTasksPayload(data)
+	case TypeSampleQueues:
+		payload, err = ParseSampleQueuesPayload(data)
+	case TypePruneAnswerCache:
+		payload, err = ParsePruneAnswerCachePayload(data)
+	case TypeIndexPages:
+		payload, err = ParseIndexPagesPayload(data)
+	case TypeRetryPage:
+		payload, err = ParseRetryPagePayload(data)
+	case TypeRebuildWebsite:
+		payload, err = ParseRebuildWebsitePayload(data)
+	case TypeRevectorizePages:
+		payload, err = ParseRevectorizePagesPayload(data)
+	case TypeSummarizePage:
+		payload, err = ParseSummarizePagePayload(data)
+	case TypeSummarizeWebsite:
+		payload, err = ParseSummarizeWebsitePayload(data)
+	case TypeEvaluateRAG:
+		payload, err = ParseEvaluateRAGPayload(data)
+	case TypeIndexStats:
+		payload, err = ParseIndexStatsPayload(data)
+	case TypeReprocessPage:
+		payload, err = ParseReprocessPagePayload(data)
+	case TypeReprocessWebsite:
+		payload, err = ParseReprocessWebsitePayload(data)
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	return payload, true, nil
+}
@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hibiken/asynq"
+)
+
+func TestParsePayloadLegacy(t *testing.T) {
+	// Payloads enqueued before versioning carry no version field
+	payload, err := ParseCrawlWebsitePayload([]byte(`{"website_id":7,"start_url":"https://example.com"}`))
+	if err != nil {
+		t.Fatalf("ParseCrawlWebsitePayload() error = %v", err)
+	}
+	if payload.WebsiteID != 7 || payload.StartURL != "https://example.com" {
+		t.Errorf("ParseCrawlWebsitePayload() = %+v, want website 7 at https://example.com", payload)
+	}
+	if payload.Version != CrawlWebsitePayloadVersion {
+		t.Errorf("Version = %d, want upgraded to %d", payload.Version, CrawlWebsitePayloadVersion)
+	}
+
+	// Cleanups enqueued before v2 never deleted anything
+	cleanup, err := ParseCleanupOldPagesPayload([]byte(`{"days_old":30,"delete_from":"both"}`))
+	if err != nil {
+		t.Fatalf("ParseCleanupOldPagesPayload() error = %v", err)
+	}
+	if !cleanup.DryRun {
+		t.Error("DryRun = false, want legacy cleanups to stay dry runs")
+	}
+}
+
+func TestParsePayloadNewerVersion(t *testing.T) {
+	tests := []struct {
+		taskType string
+		data     string
+	}{
+		{TypeCrawlWebsite, `{"version":99,"website_id":1}`},
+		{TypeVectorizePage, `{"version":99,"page_id":1}`},
+		{TypeCleanupOldPages, `{"version":99,"days_old":30}`},
+		{TypePruneAnswerCache, `{"version":99}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.taskType, func(t *testing.T) {
+			_, ok, err := ParsePayload(tt.taskType, []byte(tt.data))
+			if !ok {
+				t.Fatalf("ParsePayload() ok = false, want a payload struct for %s", tt.taskType)
+			}
+			if !errors.Is(err, ErrUnsupportedPayloadVersion) {
+				t.Fatalf("ParsePayload() error = %v, want ErrUnsupportedPayloadVersion", err)
+			}
+
+			// Newer payloads are retried until a compatible worker picks them up
+			if err := payloadError(err); errors.Is(err, asynq.SkipRetry) {
+				t.Errorf("payloadError() = %v, want the task retried", err)
+			}
+		})
+	}
+}
+
+func TestParsePayloadMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not json", `not json`},
+		{"wrong version type", `{"version":"2","website_id":1}`},
+		{"wrong field type", `{"version":2,"website_id":"one"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok, err := ParsePayload(TypeCrawlWebsite, []byte(tt.data))
+			if !ok {
+				t.Fatal("ParsePayload() ok = false, want a payload struct for crawl tasks")
+			}
+			if err == nil {
+				t.Fatal("ParsePayload() error = nil, want a parse error")
+			}
+			if errors.Is(err, ErrUnsupportedPayloadVersion) {
+				t.Fatalf("ParsePayload() error = %v, want a malformed payload error", err)
+			}
+
+			// Malformed payloads never succeed and are not retried
+			if err := payloadError(err); !errors.Is(err, asynq.SkipRetry) {
+				t.Errorf("payloadError() = %v, want it wrapped with asynq.SkipRetry", err)
+			}
+		})
+	}
+}
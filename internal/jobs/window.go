@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"hermit/internal/schema"
+)
+
+// DeferredError defers a crawl started outside the crawl window of its
+// website until the window opens. The attempt doesn't count as failed.
+type DeferredError struct {
+	Window schema.CrawlWindow
+	Delay  time.Duration
+}
+
+// Error implements the error interface.
+func (e *DeferredError) Error() string {
+	return fmt.Sprintf("outside the crawl window %s, deferred for %s", e.Window, e.Delay.Round(time.Second))
+}
+
+// crawlWindowError returns a DeferredError when crawls of the website may not
+// start now, nil otherwise.
+func crawlWindowError(website *schema.Website) error {
+	window, ok := website.CrawlWindow()
+	if !ok {
+		return nil
+	}
+	if delay := window.Delay(time.Now()); delay > 0 {
+		return &DeferredError{Window: window, Delay: delay}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"hermit/internal/repositories"
+	"hermit/internal/schema"
+
+	"go.uber.org/zap"
+)
+
+// AnswerCache serves previously generated answers for semantically similar queries.
+// Entries are matched per website by cosine similarity of the query embeddings.
+type AnswerCache struct {
+	repo          *repositories.QueryCacheRepository
+	logger        *zap.Logger
+	threshold     float64
+	ttl           time.Duration
+	maxCandidates int
+}
+
+// NewAnswerCache creates a new AnswerCache.
+func NewAnswerCache(
+	repo *repositories.QueryCacheRepository,
+	logger *zap.Logger,
+	threshold float64,
+	ttl time.Duration,
+	maxCandidates int,
+) *AnswerCache {
+	if maxCandidates <= 0 {
+		maxCandidates = 200
+	}
+
+	return &AnswerCache{
+		repo:          repo,
+		logger:        logger,
+		threshold:     threshold,
+		ttl:           ttl,
+		maxCandidates: maxCandidates,
+	}
+}
+
+// Lookup returns a cached answer whose query embedding is at least as similar
+// as the configured threshold, or nil when there is no match.
+func (c *AnswerCache) Lookup(ctx context.Context, websiteID uint, query string, embedding []float32) *QueryResponse {
+	entries, err := c.repo.ListRecent(ctx, websiteID, c.maxCandidates)
+	if err != nil {
+		c.logger.Warn("Failed to read answer cache", zap.Uint("websiteID", websiteID), zap.Error(err))
+		return nil
+	}
+
+	var best *schema.QueryCacheEntry
+	bestScore := c.threshold
+	for i := range entries {
+		score := cosineSimilarity(embedding, entries[i].Embedding)
+		if score >= bestScore {
+			best = &entries[i]
+			bestScore = score
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+
+	var sources []QuerySource
+	if err := json.Unmarshal(best.Sources, &sources); err != nil {
+		c.logger.Warn("Failed to decode cached sources", zap.Uint("entryID", best.ID), zap.Error(err))
+		return nil
+	}
+
+	if err := c.repo.IncrementHit(ctx, best.ID); err != nil {
+		c.logger.Debug("Failed to record answer cache hit", zap.Error(err))
+	}
+
+	c.logger.Info("Answer cache hit",
+		zap.Uint("websiteID", websiteID),
+		zap.Uint("entryID", best.ID),
+		zap.Float64("similarity", bestScore),
+	)
+
+	return &QueryResponse{
+		Answer:          best.Answer,
+		Sources:         sources,
+		RetrievedChunks: best.RetrievedChunks,
+		Query:           query,
+		Cached:          true,
+	}
+}
+
+// Store saves an answer for later reuse.
+func (c *AnswerCache) Store(ctx context.Context, websiteID uint, embedding []float32, response *QueryResponse) {
+	sources, err := json.Marshal(response.Sources)
+	if err != nil {
+		c.logger.Warn("Failed to encode sources for answer cache", zap.Error(err))
+		return
+	}
+
+	entry := &schema.QueryCacheEntry{
+		WebsiteID:       websiteID,
+		Query:           response.Query,
+		Embedding:       embedding,
+		Answer:          response.Answer,
+		Sources:         sources,
+		RetrievedChunks: response.RetrievedChunks,
+		ExpiresAt:       time.Now().Add(c.ttl),
+	}
+
+	if err := c.repo.Create(ctx, entry); err != nil {
+		c.logger.Warn("Failed to write answer cache", zap.Uint("websiteID", websiteID), zap.Error(err))
+	}
+}
+
+// Invalidate drops all cached answers for a website, e.g. once its content
+// changed so they may cite pages that no longer exist.
+func (c *AnswerCache) Invalidate(ctx context.Context, websiteID uint) error {
+	deleted, err := c.repo.DeleteByWebsiteID(ctx, websiteID)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		c.logger.Debug("Answer cache invalidated", zap.Uint("websiteID", websiteID), zap.Int64("deleted", deleted))
+	}
+	return nil
+}
+
+// DeleteExpired drops the expired answers of all websites and returns their
+// number.
+func (c *AnswerCache) DeleteExpired(ctx context.Context) (int64, error) {
+	return c.repo.DeleteExpired(ctx)
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, or 0 if they
+// cannot be compared.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
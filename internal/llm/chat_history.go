@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"sync"
+	"time"
+)
+
+// ChatHistories keeps the most recent messages of conversations, keyed by
+// an ID such as a website or a chat thread, for follow-up questions. It is
+// safe for concurrent use.
+type ChatHistories struct {
+	mu          sync.Mutex
+	maxMessages int
+	ttl         time.Duration
+	histories   map[string]*chatHistory
+}
+
+type chatHistory struct {
+	messages []ChatMessage
+	updated  time.Time
+}
+
+// NewChatHistories creates a store keeping maxMessages messages per
+// conversation. Conversations idle for longer than ttl are forgotten, a ttl
+// of 0 keeps them until they are reset.
+func NewChatHistories(maxMessages int, ttl time.Duration) *ChatHistories {
+	return &ChatHistories{
+		maxMessages: maxMessages,
+		ttl:         ttl,
+		histories:   make(map[string]*chatHistory),
+	}
+}
+
+// Get returns the messages of a conversation, oldest first.
+func (h *ChatHistories) Get(key string) []ChatMessage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	history, ok := h.histories[key]
+	if !ok || h.expired(history, time.Now()) {
+		return nil
+	}
+	return append([]ChatMessage(nil), history.messages...)
+}
+
+// Record appends a completed exchange to a conversation, keeping only the
+// most recent messages.
+func (h *ChatHistories) Record(key, question, answer string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.evict(now)
+
+	history, ok := h.histories[key]
+	if !ok || h.expired(history, now) {
+		history = &chatHistory{}
+		h.histories[key] = history
+	}
+	history.messages = append(history.messages,
+		ChatMessage{Role: "user", Content: question},
+		ChatMessage{Role: "assistant", Content: answer},
+	)
+	if len(history.messages) > h.maxMessages {
+		history.messages = history.messages[len(history.messages)-h.maxMessages:]
+	}
+	history.updated = now
+}
+
+// Reset forgets a conversation.
+func (h *ChatHistories) Reset(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.histories, key)
+}
+
+func (h *ChatHistories) expired(history *chatHistory, now time.Time) bool {
+	return h.ttl > 0 && now.Sub(history.updated) > h.ttl
+}
+
+// evict forgets expired conversations. h.mu must be held.
+func (h *ChatHistories) evict(now time.Time) {
+	if h.ttl == 0 {
+		return
+	}
+	for key, history := range h.histories {
+		if h.expired(history, now) {
+			delete(h.histories, key)
+		}
+	}
+}
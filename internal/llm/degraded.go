@@ -0,0 +1,46 @@
+package llm
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// breakerThreshold is the number of consecutive generation failures that
+	// open the breaker.
+	breakerThreshold = 3
+	// breakerCooldown is how long generation is skipped once the breaker opens.
+	breakerCooldown = 30 * time.Second
+	// maxExcerptLength limits each chunk quoted in an extractive answer.
+	maxExcerptLength = 600
+)
+
+// degradedNotice introduces extractive answers returned while the LLM is unavailable.
+const degradedNotice = "The AI model is currently unavailable, so here are the most relevant excerpts from the website:"
+
+// extractiveAnswer builds an answer from the top retrieved chunks, used when
+// the LLM can't generate one.
+func extractiveAnswer(sources []QuerySource, limit int) string {
+	if limit > len(sources) {
+		limit = len(sources)
+	}
+
+	var b strings.Builder
+	b.WriteString(degradedNotice)
+
+	for _, source := range sources[:limit] {
+		excerpt := truncateAnswer(strings.TrimSpace(source.ChunkText), maxExcerptLength)
+		if excerpt == "" {
+			continue
+		}
+
+		b.WriteString("\n\n> ")
+		b.WriteString(strings.ReplaceAll(excerpt, "\n", "\n> "))
+		if source.PageURL != "" {
+			b.WriteString("\n\nSource: ")
+			b.WriteString(source.PageURL)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,241 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"hermit/internal/repositories"
+	"hermit/internal/resilience"
+	"hermit/internal/schema"
+
+	"go.uber.org/zap"
+)
+
+// maxJudgeAnswerLength limits the answers sent to the LLM judge.
+const maxJudgeAnswerLength = 4000
+
+// judgeScorePattern matches the grade in a response of the LLM judge.
+var judgeScorePattern = regexp.MustCompile(`\b(10|[0-9])\b`)
+
+// Evaluator runs the evaluation cases of a website through the RAG pipeline
+// and scores the answers against the expected answers, either by word
+// overlap or with the LLM as a judge.
+type Evaluator struct {
+	rag    *RAGService
+	llm    *OllamaLLM
+	repo   *repositories.EvaluationRepository
+	logger *zap.Logger
+}
+
+// NewEvaluator creates a new Evaluator.
+func NewEvaluator(rag *RAGService, llm *OllamaLLM, repo *repositories.EvaluationRepository, logger *zap.Logger) *Evaluator {
+	return &Evaluator{
+		rag:    rag,
+		llm:    llm,
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Run evaluates the current cases of the website of a run and stores a
+// result per case and the metrics of the run. Runs that already finished are
+// left untouched. Errors of unavailable dependencies are returned so the run
+// can be retried later, while other errors of a case are stored on its
+// result.
+func (e *Evaluator) Run(ctx context.Context, runID uint) error {
+	run, err := e.repo.GetRun(ctx, runID)
+	if err != nil {
+		return err
+	}
+	if run == nil || run.Status == schema.EvalRunCompleted || run.Status == schema.EvalRunFailed {
+		return nil
+	}
+
+	cases, err := e.repo.ListCases(ctx, run.WebsiteID)
+	if err != nil {
+		return err
+	}
+	if len(cases) == 0 {
+		return e.repo.FailRun(ctx, run.ID, "website has no evaluation cases")
+	}
+
+	settings, err := json.Marshal(e.rag.Settings())
+	if err != nil {
+		return fmt.Errorf("failed to marshal retrieval settings: %w", err)
+	}
+	if err := e.repo.StartRun(ctx, run.ID, settings, len(cases)); err != nil {
+		return err
+	}
+
+	e.logger.Info("Starting evaluation run",
+		zap.Uint("runID", run.ID),
+		zap.Uint("websiteID", run.WebsiteID),
+		zap.String("scorer", run.Scorer),
+		zap.Int("cases", len(cases)),
+	)
+
+	for _, c := range cases {
+		result, err := e.evaluateCase(ctx, run, c)
+		if err != nil {
+			return err
+		}
+		if err := e.repo.AddResult(ctx, result); err != nil {
+			return err
+		}
+	}
+
+	if err := e.repo.CompleteRun(ctx, run.ID); err != nil {
+		return err
+	}
+
+	e.logger.Info("Completed evaluation run",
+		zap.Uint("runID", run.ID),
+		zap.Uint("websiteID", run.WebsiteID),
+	)
+
+	return nil
+}
+
+// Fail marks a run as failed with err as the reason.
+func (e *Evaluator) Fail(ctx context.Context, runID uint, err error) error {
+	e.logger.Error("Evaluation run failed", zap.Uint("runID", runID), zap.Error(err))
+	return e.repo.FailRun(ctx, runID, err.Error())
+}
+
+// evaluateCase answers and scores a single case. It only returns an error
+// when the run should stop.
+func (e *Evaluator) evaluateCase(ctx context.Context, run *schema.EvalRun, c schema.EvalCase) (*schema.EvalResult, error) {
+	caseID := c.ID
+	result := &schema.EvalResult{
+		RunID:          run.ID,
+		CaseID:         &caseID,
+		Question:       c.Question,
+		ExpectedAnswer: c.ExpectedAnswer,
+	}
+
+	start := time.Now()
+	response, err := e.rag.Query(ctx, run.WebsiteID, c.Question, nil, "")
+	result.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		if stopEvaluation(ctx, err) {
+			return nil, err
+		}
+		e.logger.Warn("Evaluation case failed",
+			zap.Uint("runID", run.ID),
+			zap.Uint("caseID", c.ID),
+			zap.Error(err),
+		)
+		message := err.Error()
+		result.Error = &message
+		return result, nil
+	}
+
+	result.Answer = response.Answer
+	result.RetrievedChunks = response.RetrievedChunks
+	result.Degraded = response.Degraded
+
+	switch run.Scorer {
+	case schema.EvalScorerLLM:
+		score, err := e.judge(ctx, c.Question, c.ExpectedAnswer, response.Answer)
+		if err != nil {
+			if stopEvaluation(ctx, err) {
+				return nil, err
+			}
+			message := err.Error()
+			result.Error = &message
+			return result, nil
+		}
+		result.Score = score
+	default:
+		result.Score = OverlapScore(response.Answer, c.ExpectedAnswer)
+	}
+
+	return result, nil
+}
+
+// stopEvaluation reports whether err should stop a run instead of failing
+// a single case, because the run was canceled or a dependency is down.
+func stopEvaluation(ctx context.Context, err error) bool {
+	var unavailable *resilience.UnavailableError
+	return ctx.Err() != nil || errors.As(err, &unavailable)
+}
+
+// judge asks the LLM to grade an answer against the expected answer and
+// returns the grade scaled to 0-1.
+func (e *Evaluator) judge(ctx context.Context, question, expected, answer string) (float64, error) {
+	var prompt strings.Builder
+	prompt.WriteString("You are grading the answer of an assistant against a reference answer.\n\n")
+	prompt.WriteString(fmt.Sprintf("Question: %s\n\n", question))
+	prompt.WriteString(fmt.Sprintf("Reference answer: %s\n\n", truncateAnswer(expected, maxJudgeAnswerLength)))
+	prompt.WriteString(fmt.Sprintf("Assistant answer: %s\n\n", truncateAnswer(answer, maxJudgeAnswerLength)))
+	prompt.WriteString("Grade how well the assistant answer matches the facts of the reference answer ")
+	prompt.WriteString("from 0 (wrong or missing) to 10 (fully correct). ")
+	prompt.WriteString("Reply with the grade only.\n\nGrade: ")
+
+	response, err := e.llm.GenerateResponse(ctx, prompt.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to judge answer: %w", err)
+	}
+
+	match := judgeScorePattern.FindString(response)
+	if match == "" {
+		return 0, fmt.Errorf("no grade in judge response %q", truncateAnswer(strings.TrimSpace(response), 100))
+	}
+	grade, _ := strconv.Atoi(match)
+
+	return float64(grade) / 10, nil
+}
+
+// OverlapScore returns the F1 score of the words of answer against the words
+// of expected, from 0 (no common words) to 1 (the same words). Case,
+// punctuation and articles are ignored.
+func OverlapScore(answer, expected string) float64 {
+	answerWords := overlapWords(answer)
+	expectedWords := overlapWords(expected)
+	if len(answerWords) == 0 || len(expectedWords) == 0 {
+		return 0
+	}
+
+	remaining := make(map[string]int, len(expectedWords))
+	for _, word := range expectedWords {
+		remaining[word]++
+	}
+
+	common := 0
+	for _, word := range answerWords {
+		if remaining[word] > 0 {
+			remaining[word]--
+			common++
+		}
+	}
+	if common == 0 {
+		return 0
+	}
+
+	precision := float64(common) / float64(len(answerWords))
+	recall := float64(common) / float64(len(expectedWords))
+	return 2 * precision * recall / (precision + recall)
+}
+
+// overlapWords returns the lowercase words of text without articles.
+func overlapWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := fields[:0]
+	for _, word := range fields {
+		if word == "a" || word == "an" || word == "the" {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"hermit/internal/deadline"
+	"hermit/internal/vectorizer"
+
+	"go.uber.org/zap"
+)
+
+// websiteResult is a retrieval result of one of the websites of a query.
+type websiteResult struct {
+	websiteID uint
+	result    vectorizer.QueryResult
+}
+
+// QueryWebsites answers a query from the content of several websites, e.g.
+// the websites with a label. The chunks most similar to the query across the
+// websites are retrieved and each source names its website. Websites whose
+// content can't be searched, e.g. not crawled yet, are left out. Answers are
+// neither cached nor previewed, and are generated without website summaries.
+func (s *RAGService) QueryWebsites(ctx context.Context, websiteIDs []uint, query string, tags []string) (*QueryResponse, error) {
+	s.logger.Info("Processing RAG query across websites",
+		zap.Uints("websiteIDs", websiteIDs),
+		zap.String("query", query),
+	)
+
+	if query == "" {
+		return nil, fmt.Errorf("query cannot be empty")
+	}
+	if guardrail := s.guardrails.CheckQuestion(0, query); guardrail != "" {
+		return refusedResponse(query, guardrail), nil
+	}
+
+	done := deadline.Track(ctx, "embed")
+	embedding, err := s.vectorizerSvc.EmbedQuery(ctx, query)
+	done()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve content: %w", err)
+	}
+
+	var hits []websiteResult
+	var searchErr error
+	searched := 0
+	done = deadline.Track(ctx, "vector_search")
+	for _, websiteID := range websiteIDs {
+		results, err := s.vectorizerSvc.QuerySimilarByEmbedding(ctx, websiteID, embedding, s.topK, tags)
+		if err != nil {
+			if ctx.Err() != nil {
+				done()
+				return nil, fmt.Errorf("failed to retrieve content: %w", err)
+			}
+			s.logger.Warn("Leaving website out of the query",
+				zap.Uint("websiteID", websiteID),
+				zap.Error(err),
+			)
+			searchErr = err
+			continue
+		}
+		searched++
+		for _, result := range results {
+			hits = append(hits, websiteResult{websiteID: websiteID, result: result})
+		}
+	}
+	done()
+	if searched == 0 && searchErr != nil {
+		return nil, fmt.Errorf("failed to retrieve content: %w", searchErr)
+	}
+
+	// Closest chunks first, whichever website they come from
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].result.Distance < hits[j].result.Distance })
+	if len(hits) > s.topK {
+		hits = hits[:s.topK]
+	}
+	if len(hits) == 0 {
+		return &QueryResponse{
+			Answer:  noResultsAnswer,
+			Sources: []QuerySource{},
+			Query:   query,
+		}, nil
+	}
+
+	results := make([]vectorizer.QueryResult, len(hits))
+	for i, hit := range hits {
+		results[i] = hit.result
+	}
+	contextChunks, sources := s.buildContext(results)
+	for i := range sources {
+		sources[i].WebsiteID = hits[i].websiteID
+	}
+
+	return s.answer(ctx, &retrieval{
+		query:         query,
+		embedding:     embedding,
+		tags:          tags,
+		contextChunks: s.guardrails.SanitizeChunks(0, contextChunks),
+		sources:       sources,
+	})
+}
@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// maxClassifierInput limits the characters of page content sent to the LLM
+// to classify a page.
+const maxClassifierInput = 4000
+
+// PageClassifier asks the LLM whether a page is useful to answer questions
+// from, to skip pages the quality heuristics can't tell apart from content,
+// such as login walls, error pages and lists of links.
+type PageClassifier struct {
+	llm    *OllamaLLM
+	logger *zap.Logger
+}
+
+// NewPageClassifier creates a new PageClassifier.
+func NewPageClassifier(llm *OllamaLLM, logger *zap.Logger) *PageClassifier {
+	return &PageClassifier{
+		llm:    llm,
+		logger: logger,
+	}
+}
+
+// IsUseful reports whether the LLM deems the content of a page useful.
+// Answers other than no count as useful, so unclear replies keep the page.
+func (c *PageClassifier) IsUseful(ctx context.Context, pageURL, title, content string) (bool, error) {
+	var prompt strings.Builder
+	prompt.WriteString("Decide whether the following web page has content that is useful to answer questions about the website, ")
+	prompt.WriteString("as opposed to boilerplate such as login or error pages, cookie notices or lists of links. ")
+	prompt.WriteString("Reply with yes or no only.\n\n")
+	prompt.WriteString(fmt.Sprintf("URL: %s\n", pageURL))
+	if title != "" {
+		prompt.WriteString(fmt.Sprintf("Title: %s\n", title))
+	}
+	prompt.WriteString("\nContent:\n")
+	prompt.WriteString(truncateAnswer(strings.TrimSpace(content), maxClassifierInput))
+	prompt.WriteString("\n\nUseful: ")
+
+	response, err := c.llm.GenerateResponse(ctx, prompt.String())
+	if err != nil {
+		return false, fmt.Errorf("failed to classify page: %w", err)
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(response))
+	useful := !strings.HasPrefix(answer, "no")
+	c.logger.Debug("Classified page", zap.String("url", pageURL), zap.Bool("useful", useful), zap.String("answer", answer))
+
+	return useful, nil
+}
@@ -0,0 +1,346 @@
+package llm
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// errAnswerLimitReached stops generation once a streamed answer reached the
+// maximum answer length.
+var errAnswerLimitReached = errors.New("answer length limit reached")
+
+const (
+	// preambleWindow is how much of a streamed answer is buffered at most while
+	// deciding whether it starts with a preamble.
+	preambleWindow = 200
+	// maxHeldBack limits how much text a stream holds back waiting for an
+	// unfinished tag or link to close.
+	maxHeldBack = 512
+	// truncationSuffix marks answers cut at the maximum length.
+	truncationSuffix = "…"
+)
+
+var (
+	preamblePattern = regexp.MustCompile(`(?i)^\s*(?:(?:based on|according to) (?:the |this )?(?:provided |given |above |available )?(?:context|information|content|sources?|documents?)[^,.:\n]{0,40}[,:.]|(?:sure|certainly|of course)[!,.]?\s*here(?:'s| is)[^:\n]{0,60}:)\s*`)
+	// preambleStarters are the openings preamblePattern can match, used to
+	// decide early that a streamed answer has no preamble.
+	preambleStarters = []string{"based on", "according to", "sure", "certainly", "of course"}
+
+	blockTagPattern   = regexp.MustCompile(`(?is)<(?:script|style|iframe)\b.*?</(?:script|style|iframe)\s*>`)
+	htmlTagPattern    = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^<>]*)?/?>`)
+	unsafeLinkPattern = regexp.MustCompile(`(?i)!?\[([^\]]*)\]\(\s*(?:javascript|data|vbscript):(?:[^()]|\([^()]*\))*\)`)
+	bareURLPattern    = regexp.MustCompile(`(^|[^(\[<"'=])(https?://[^\s()<>\[\]"']+)`)
+)
+
+// AnswerPostProcessor cleans up generated answers before they are returned:
+// it sanitizes markdown and HTML, turns bare source URLs into titled links,
+// strips model preambles and enforces a maximum answer length.
+type AnswerPostProcessor struct {
+	sanitize      bool
+	rewriteLinks  bool
+	stripPreamble bool
+	maxLength     int // in characters, 0 means unlimited
+}
+
+// NewAnswerPostProcessor creates a new AnswerPostProcessor.
+func NewAnswerPostProcessor(sanitize, rewriteLinks, stripPreamble bool, maxLength int) *AnswerPostProcessor {
+	return &AnswerPostProcessor{
+		sanitize:      sanitize,
+		rewriteLinks:  rewriteLinks,
+		stripPreamble: stripPreamble,
+		maxLength:     maxLength,
+	}
+}
+
+// Process applies all enabled post-processing steps to a complete answer.
+func (p *AnswerPostProcessor) Process(answer string, sources []QuerySource) string {
+	if p == nil {
+		return answer
+	}
+
+	if p.stripPreamble {
+		answer = stripPreamble(answer)
+	}
+	answer = p.transform(answer, sourceTitles(sources))
+	if p.maxLength > 0 {
+		answer = truncateAnswer(answer, p.maxLength)
+	}
+
+	return strings.TrimSpace(answer)
+}
+
+// transform sanitizes text and rewrites source links. It works on any part of
+// an answer that doesn't end inside a tag or link.
+func (p *AnswerPostProcessor) transform(text string, titles map[string]string) string {
+	if p.sanitize {
+		text = blockTagPattern.ReplaceAllString(text, "")
+		text = unsafeLinkPattern.ReplaceAllString(text, "$1")
+		text = htmlTagPattern.ReplaceAllString(text, "")
+	}
+	if p.rewriteLinks && len(titles) > 0 {
+		text = rewriteSourceLinks(text, titles)
+	}
+	return text
+}
+
+// answerStream applies post-processing to a streamed answer. Text is held back
+// until it can be processed safely: the start of the answer until a preamble
+// can be ruled out, and any trailing partial word, tag or link.
+type answerStream struct {
+	processor *AnswerPostProcessor
+	titles    map[string]string
+	emit      func(chunk string) error
+	pending   string
+	started   bool
+	emitted   int
+	answer    strings.Builder
+	done      bool
+}
+
+// newStream creates an answerStream that passes processed text to emit.
+func (p *AnswerPostProcessor) newStream(sources []QuerySource, emit func(chunk string) error) *answerStream {
+	if p == nil {
+		p = &AnswerPostProcessor{}
+	}
+
+	return &answerStream{
+		processor: p,
+		titles:    sourceTitles(sources),
+		emit:      emit,
+		started:   !p.stripPreamble,
+	}
+}
+
+// write adds a generated chunk to the stream. It returns errAnswerLimitReached
+// once the maximum answer length has been sent.
+func (s *answerStream) write(chunk string) error {
+	if s.done {
+		return errAnswerLimitReached
+	}
+	s.pending += chunk
+
+	if !s.started {
+		if !preambleSettled(s.pending) {
+			return nil
+		}
+		s.pending = stripPreamble(s.pending)
+		s.started = true
+	}
+
+	cut := len(s.pending)
+	if s.processor.sanitize || s.processor.rewriteLinks {
+		cut = safeCut(s.pending)
+	}
+	if cut == 0 {
+		return nil
+	}
+
+	text := s.pending[:cut]
+	s.pending = s.pending[cut:]
+	return s.send(s.processor.transform(text, s.titles))
+}
+
+// close flushes any held back text.
+func (s *answerStream) close() error {
+	if s.done || s.pending == "" {
+		return nil
+	}
+
+	text := s.pending
+	s.pending = ""
+	if !s.started {
+		text = stripPreamble(text)
+		s.started = true
+	}
+
+	err := s.send(s.processor.transform(text, s.titles))
+	if errors.Is(err, errAnswerLimitReached) {
+		return nil
+	}
+	return err
+}
+
+// send emits processed text, truncating it at the maximum answer length.
+func (s *answerStream) send(text string) error {
+	if text == "" {
+		return nil
+	}
+
+	if limit := s.processor.maxLength; limit > 0 {
+		if remaining := limit - s.emitted; utf8.RuneCountInString(text) > remaining {
+			text = truncateAnswer(text, remaining)
+			s.done = true
+		}
+	}
+
+	s.emitted += utf8.RuneCountInString(text)
+	s.answer.WriteString(text)
+	if err := s.emit(text); err != nil {
+		return err
+	}
+
+	if s.done {
+		return errAnswerLimitReached
+	}
+	return nil
+}
+
+// text returns the processed answer sent so far.
+func (s *answerStream) text() string {
+	return s.answer.String()
+}
+
+// preambleSettled reports whether enough of an answer has been buffered to
+// decide if it starts with a preamble.
+func preambleSettled(text string) bool {
+	trimmed := strings.ToLower(strings.TrimLeftFunc(text, unicode.IsSpace))
+	if len(trimmed) >= preambleWindow || strings.Contains(trimmed, "\n") {
+		return true
+	}
+
+	// Wait for the first word after a preamble so it can be capitalized
+	if loc := preamblePattern.FindStringIndex(text); loc != nil {
+		return strings.TrimSpace(text[loc[1]:]) != ""
+	}
+
+	for _, starter := range preambleStarters {
+		if strings.HasPrefix(trimmed, starter) || strings.HasPrefix(starter, trimmed) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stripPreamble removes phrases like "Based on the context," from the start of
+// an answer and capitalizes what remains.
+func stripPreamble(text string) string {
+	loc := preamblePattern.FindStringIndex(text)
+	if loc == nil {
+		return text
+	}
+
+	rest := text[loc[1]:]
+	r, size := utf8.DecodeRuneInString(rest)
+	if size == 0 {
+		// Nothing follows the preamble, keep the answer as is
+		return text
+	}
+
+	return string(unicode.ToUpper(r)) + rest[size:]
+}
+
+// safeCut returns the length of the prefix of text that can be processed
+// without splitting a word, HTML tag or markdown link.
+func safeCut(text string) int {
+	cut := strings.LastIndexAny(text, " \t\n") + 1
+	if cut == 0 {
+		return 0
+	}
+	head := text[:cut]
+	hold := cut
+
+	// Unclosed HTML tag
+	if i := strings.LastIndex(head, "<"); i >= 0 && i+1 < len(head) && !strings.Contains(head[i:], ">") {
+		if next := head[i+1]; next == '/' || unicode.IsLetter(rune(next)) {
+			hold = min(hold, i)
+		}
+	}
+
+	// Unclosed script, style or iframe block
+	lower := strings.ToLower(head)
+	for _, tag := range []string{"script", "style", "iframe"} {
+		if i := strings.LastIndex(lower, "<"+tag); i >= 0 && !strings.Contains(lower[i:], "</"+tag) {
+			hold = min(hold, i)
+		}
+	}
+
+	// Unclosed markdown link
+	if i := strings.LastIndex(head, "["); i >= 0 {
+		link := head[i:]
+		closing := strings.Index(link, "](")
+		if !strings.Contains(link, "]") || (closing >= 0 && !strings.Contains(link[closing:], ")")) {
+			hold = min(hold, i)
+		}
+	}
+
+	if cut-hold > maxHeldBack {
+		return cut
+	}
+	return hold
+}
+
+// truncateAnswer shortens text to at most limit characters, preferably at a
+// word boundary.
+func truncateAnswer(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	if limit <= 0 {
+		return ""
+	}
+
+	cut := string([]rune(text)[:limit])
+	if i := strings.LastIndexAny(cut, " \n"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	// Don't leave half a markdown link behind
+	if i := strings.LastIndex(cut, "["); i >= 0 && !strings.Contains(cut[i:], ")") {
+		cut = cut[:i]
+	}
+
+	return strings.TrimRight(cut, " \n\t,;:") + truncationSuffix
+}
+
+// sourceTitles maps the URLs of sources to link titles.
+func sourceTitles(sources []QuerySource) map[string]string {
+	titles := make(map[string]string, len(sources))
+	for _, source := range sources {
+		if source.PageURL == "" {
+			continue
+		}
+		titles[strings.TrimSuffix(source.PageURL, "/")] = linkTitle(source.PageURL)
+	}
+	return titles
+}
+
+// rewriteSourceLinks turns bare URLs of sources into markdown links.
+func rewriteSourceLinks(text string, titles map[string]string) string {
+	return bareURLPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := bareURLPattern.FindStringSubmatch(match)
+		prefix, link := parts[1], parts[2]
+
+		// Keep sentence punctuation outside the link
+		trimmed := strings.TrimRight(link, ".,;:!?")
+		title, ok := titles[strings.TrimSuffix(trimmed, "/")]
+		if !ok {
+			return match
+		}
+
+		return prefix + "[" + title + "](" + trimmed + ")" + link[len(trimmed):]
+	})
+}
+
+// linkTitle derives a readable title from a page URL, e.g.
+// "https://example.com/docs/getting-started" becomes "Getting started".
+func linkTitle(pageURL string) string {
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return pageURL
+	}
+
+	name := path.Base(strings.TrimSuffix(parsed.Path, "/"))
+	name = strings.TrimSuffix(name, path.Ext(name))
+	name = strings.TrimSpace(strings.NewReplacer("-", " ", "_", " ").Replace(name))
+	if name == "" || name == "." || name == "/" {
+		return parsed.Host
+	}
+
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
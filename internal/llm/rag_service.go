@@ -2,7 +2,13 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+
+	"hermit/internal/deadline"
+	"hermit/internal/repositories"
+	"hermit/internal/resilience"
 	"hermit/internal/vectorizer"
 
 	"go.uber.org/zap"
@@ -15,6 +21,12 @@ type RAGService struct {
 	logger        *zap.Logger
 	topK          int
 	contextChunks int
+	cache         *AnswerCache
+	postProcessor *AnswerPostProcessor
+	guardrails    *Guardrails
+	previews      *previewStore
+	breaker       *resilience.Breaker
+	summaries     *summaryStore
 }
 
 // NewRAGService creates a new RAG service.
@@ -24,6 +36,11 @@ func NewRAGService(
 	logger *zap.Logger,
 	topK int,
 	contextChunks int,
+	cache *AnswerCache,
+	postProcessor *AnswerPostProcessor,
+	guardrails *Guardrails,
+	websiteRepo *repositories.WebsiteRepository,
+	pageRepo *repositories.PageRepository,
 ) *RAGService {
 	return &RAGService{
 		vectorizerSvc: vectorizerSvc,
@@ -31,6 +48,30 @@ func NewRAGService(
 		logger:        logger,
 		topK:          topK,
 		contextChunks: contextChunks,
+		cache:         cache,
+		postProcessor: postProcessor,
+		guardrails:    guardrails,
+		previews:      newPreviewStore(previewTTL),
+		breaker:       resilience.NewBreaker("llm", breakerThreshold, breakerCooldown, logger),
+		summaries:     &summaryStore{websiteRepo: websiteRepo, pageRepo: pageRepo},
+	}
+}
+
+// RetrievalSettings are the settings that shape the answers of a RAGService.
+type RetrievalSettings struct {
+	TopK           int    `json:"top_k"`
+	ContextChunks  int    `json:"context_chunks"`
+	EmbeddingModel string `json:"embedding_model"`
+	LLMModel       string `json:"llm_model"`
+}
+
+// Settings returns the retrieval settings of the service.
+func (s *RAGService) Settings() RetrievalSettings {
+	return RetrievalSettings{
+		TopK:           s.topK,
+		ContextChunks:  s.contextChunks,
+		EmbeddingModel: s.vectorizerSvc.EmbeddingModel(),
+		LLMModel:       s.llm.model,
 	}
 }
 
@@ -40,8 +81,22 @@ type QueryResponse struct {
 	Sources         []QuerySource `json:"sources"`
 	RetrievedChunks int           `json:"retrieved_chunks"`
 	Query           string        `json:"query"`
+	Cached          bool          `json:"cached,omitempty"`
+	// Degraded is set when the LLM was unavailable and the answer is made of
+	// the top retrieved chunks instead of a generated response.
+	Degraded bool `json:"degraded,omitempty"`
+	// Guardrail names the guardrail that replaced the answer with a refusal:
+	// prompt_injection, off_topic or moderation
+	Guardrail string `json:"guardrail,omitempty"`
+	QueryID   uint   `json:"query_id,omitempty"`
+	// Usage counts the tokens the LLM processed for the answer, it is unset
+	// when no LLM call reported any, e.g. for cached answers
+	Usage *TokenUsage `json:"usage,omitempty"`
 }
 
+// noResultsAnswer is returned when retrieval finds nothing relevant.
+const noResultsAnswer = "I couldn't find any relevant information to answer your question. The website might not have been crawled yet, or there's no content matching your query."
+
 // QuerySource represents a source document used in the answer.
 type QuerySource struct {
 	PageURL    string  `json:"page_url"`
@@ -49,47 +104,236 @@ type QuerySource struct {
 	ChunkIndex int     `json:"chunk_index"`
 	Similarity float32 `json:"similarity"`
 	PageID     uint    `json:"page_id"`
+	PageTitle  string  `json:"page_title,omitempty"`
+	// WebsiteID is set for answers from several websites
+	WebsiteID uint `json:"website_id,omitempty"`
 }
 
-// Query performs a RAG query against a website's content.
-func (s *RAGService) Query(ctx context.Context, websiteID uint, query string) (*QueryResponse, error) {
+// Query performs a RAG query against a website's content. When tags are set
+// only content of pages with any of them is retrieved. When previewID is set,
+// the sources retrieved by PreviewSources are reused instead of running
+// retrieval again and query may be empty.
+func (s *RAGService) Query(ctx context.Context, websiteID uint, query string, tags []string, previewID string) (*QueryResponse, error) {
 	s.logger.Info("Processing RAG query",
 		zap.Uint("websiteID", websiteID),
 		zap.String("query", query),
+		zap.String("previewID", previewID),
 	)
 
-	if query == "" {
-		return nil, fmt.Errorf("query cannot be empty")
+	// Step 1: Embed the query (or load the preview) and check the answer cache
+	ret, err := s.prepare(ctx, websiteID, query, tags, previewID)
+	if err != nil {
+		return nil, err
 	}
+	query = ret.query
 
-	// Step 1: Retrieve similar chunks from ChromaDB
-	results, err := s.vectorizerSvc.QuerySimilarContent(ctx, websiteID, query, s.topK)
-	if err != nil {
-		s.logger.Error("Failed to retrieve similar content",
-			zap.Uint("websiteID", websiteID),
-			zap.Error(err),
-		)
-		return nil, fmt.Errorf("failed to retrieve content: %w", err)
+	if guardrail := s.guardrails.CheckQuestion(websiteID, query); guardrail != "" {
+		return refusedResponse(query, guardrail), nil
 	}
 
-	if len(results) == 0 {
+	if s.cacheable(ret) {
+		done := deadline.Track(ctx, "cache_lookup")
+		cached := s.cache.Lookup(ctx, websiteID, query, ret.embedding)
+		done()
+		if cached != nil {
+			return cached, nil
+		}
+	}
+
+	// Step 2: Retrieve similar chunks from ChromaDB
+	if ret.sources == nil {
+		if err := s.retrieve(ctx, ret); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ret.sources) == 0 {
 		s.logger.Warn("No similar content found",
 			zap.Uint("websiteID", websiteID),
 			zap.String("query", query),
 		)
 		return &QueryResponse{
-			Answer:          "I couldn't find any relevant information to answer your question. The website might not have been crawled yet, or there's no content matching your query.",
+			Answer:          noResultsAnswer,
 			Sources:         []QuerySource{},
 			RetrievedChunks: 0,
 			Query:           query,
 		}, nil
 	}
 
+	// Step 3: Generate answer using LLM with context
+	response, err := s.answer(ctx, ret)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cacheable(ret) && !response.Degraded && response.Guardrail == "" {
+		s.cache.Store(ctx, websiteID, ret.embedding, response)
+	}
+
+	return response, nil
+}
+
+// answer generates the answer to a retrieval with sources, or an extractive
+// answer while the LLM is unavailable, refusing it when a guardrail applies.
+func (s *RAGService) answer(ctx context.Context, ret *retrieval) (*QueryResponse, error) {
+	query, websiteID := ret.query, ret.websiteID
+	if guardrail := s.guardrails.CheckSources(ret.sources); guardrail != "" {
+		return refusedResponse(query, guardrail), nil
+	}
+
+	s.logger.Info("Generating LLM response",
+		zap.Int("contextChunks", len(ret.contextChunks)),
+	)
+
+	var answer string
+	var err error
+	degraded := true
+	if s.breaker.Allow() {
+		done := deadline.Track(ctx, "generate")
+		answer, err = s.llm.GenerateWithContext(ctx, query, ret.overview, ret.contextChunks)
+		done()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("failed to generate answer: %w", err)
+			}
+			s.breaker.Failure()
+			s.logger.Error("Failed to generate LLM response, falling back to an extractive answer",
+				zap.Error(err),
+			)
+		} else {
+			s.breaker.Success()
+			degraded = false
+		}
+	} else {
+		s.logger.Warn("LLM circuit breaker open, returning an extractive answer",
+			zap.Uint("websiteID", websiteID),
+		)
+	}
+
+	// Keep answering from the retrieved content while the LLM is unavailable
+	if degraded {
+		answer = extractiveAnswer(ret.sources, s.contextChunks)
+	} else if guardrail := s.guardrails.Moderate(ctx, query, answer); guardrail != "" {
+		return refusedResponse(query, guardrail), nil
+	}
+	answer = s.postProcessor.Process(answer, ret.sources)
+
+	s.logger.Info("RAG query completed successfully",
+		zap.Uint("websiteID", websiteID),
+		zap.Int("answerLength", len(answer)),
+	)
+
+	return &QueryResponse{
+		Answer:          answer,
+		Sources:         ret.sources,
+		RetrievedChunks: len(ret.sources),
+		Query:           query,
+		Degraded:        degraded,
+	}, nil
+}
+
+// PreviewSources runs retrieval only and returns the sources that would be used
+// to answer the query. The returned preview ID can be passed to Query or
+// QueryStream to generate the answer without searching again.
+func (s *RAGService) PreviewSources(ctx context.Context, websiteID uint, query string, tags []string) (*SourcesPreview, error) {
+	s.logger.Info("Previewing RAG sources",
+		zap.Uint("websiteID", websiteID),
+		zap.String("query", query),
+	)
+
+	ret, err := s.prepare(ctx, websiteID, query, tags, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.retrieve(ctx, ret); err != nil {
+		return nil, err
+	}
+
+	previewID := s.previews.put(ret)
+
+	return &SourcesPreview{
+		PreviewID:       previewID,
+		Query:           query,
+		Sources:         ret.sources,
+		RetrievedChunks: len(ret.sources),
+		ExpiresAt:       ret.expiresAt,
+	}, nil
+}
+
+// prepare embeds the query, or loads a previous retrieval when previewID is set.
+func (s *RAGService) prepare(ctx context.Context, websiteID uint, query string, tags []string, previewID string) (*retrieval, error) {
+	if previewID != "" {
+		ret := s.previews.get(previewID, websiteID)
+		if ret == nil {
+			return nil, ErrPreviewNotFound
+		}
+		return ret, nil
+	}
+
+	if query == "" {
+		return nil, fmt.Errorf("query cannot be empty")
+	}
+
+	done := deadline.Track(ctx, "embed")
+	embedding, err := s.vectorizerSvc.EmbedQuery(ctx, query)
+	done()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve content: %w", err)
+	}
+
+	return &retrieval{
+		websiteID: websiteID,
+		query:     query,
+		embedding: embedding,
+		tags:      tags,
+	}, nil
+}
+
+// cacheable reports whether the answer to ret is looked up in and stored to
+// the answer cache. Answers filtered by tag are not, as the cache is keyed by
+// the query alone.
+func (s *RAGService) cacheable(ret *retrieval) bool {
+	return s.cache != nil && len(ret.tags) == 0
+}
+
+// retrieve fetches similar chunks from ChromaDB and fills in the context and
+// sources of ret.
+func (s *RAGService) retrieve(ctx context.Context, ret *retrieval) error {
+	done := deadline.Track(ctx, "vector_search")
+	results, err := s.vectorizerSvc.QuerySimilarByEmbedding(ctx, ret.websiteID, ret.embedding, s.topK, ret.tags)
+	done()
+	if err != nil {
+		s.logger.Error("Failed to retrieve similar content",
+			zap.Uint("websiteID", ret.websiteID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to retrieve content: %w", err)
+	}
+
 	s.logger.Info("Retrieved similar chunks",
 		zap.Int("count", len(results)),
 	)
 
-	// Step 2: Extract context chunks (limit to configured amount)
+	ret.contextChunks, ret.sources = s.buildContext(results)
+	ret.contextChunks = s.guardrails.SanitizeChunks(ret.websiteID, ret.contextChunks)
+
+	// Summaries are extra context, answer without them if they can't be read
+	overview, err := s.summaries.overview(ctx, ret.websiteID, ret.sources[:len(ret.contextChunks)])
+	if err != nil {
+		s.logger.Warn("Failed to load summaries for the prompt",
+			zap.Uint("websiteID", ret.websiteID),
+			zap.Error(err),
+		)
+	}
+	ret.overview = overview
+
+	return nil
+}
+
+// buildContext splits retrieval results into the chunks passed to the LLM
+// (limited to the configured amount) and the full list of sources.
+func (s *RAGService) buildContext(results []vectorizer.QueryResult) ([]string, []QuerySource) {
 	contextLimit := s.contextChunks
 	if contextLimit > len(results) {
 		contextLimit = len(results)
@@ -128,30 +372,7 @@ func (s *RAGService) Query(ctx context.Context, websiteID uint, query string) (*
 		sources[i] = source
 	}
 
-	// Step 3: Generate answer using LLM with context
-	s.logger.Info("Generating LLM response",
-		zap.Int("contextChunks", len(contextChunks)),
-	)
-
-	answer, err := s.llm.GenerateWithContext(ctx, query, contextChunks)
-	if err != nil {
-		s.logger.Error("Failed to generate LLM response",
-			zap.Error(err),
-		)
-		return nil, fmt.Errorf("failed to generate answer: %w", err)
-	}
-
-	s.logger.Info("RAG query completed successfully",
-		zap.Uint("websiteID", websiteID),
-		zap.Int("answerLength", len(answer)),
-	)
-
-	return &QueryResponse{
-		Answer:          answer,
-		Sources:         sources,
-		RetrievedChunks: len(results),
-		Query:           query,
-	}, nil
+	return contextChunks, sources
 }
 
 // QueryWithCustomContext allows custom context to be provided.
@@ -160,100 +381,120 @@ func (s *RAGService) QueryWithCustomContext(ctx context.Context, query string, c
 		return "", fmt.Errorf("query cannot be empty")
 	}
 
-	answer, err := s.llm.GenerateWithContext(ctx, query, context)
+	answer, err := s.llm.GenerateWithContext(ctx, query, "", s.guardrails.SanitizeChunks(0, context))
 	if err != nil {
 		return "", fmt.Errorf("failed to generate answer: %w", err)
 	}
 
-	return answer, nil
+	return s.postProcessor.Process(answer, nil), nil
 }
 
 // QueryStream performs a streaming RAG query against a website's content.
-// The callback is called for each chunk of the LLM response.
-func (s *RAGService) QueryStream(ctx context.Context, websiteID uint, query string, callback func(chunk string) error) (*QueryStreamMeta, error) {
+// onSources is called once with the retrieved sources before generation starts,
+// and callback is called for each chunk of the LLM response. Streaming stops
+// early when ctx is cancelled or either callback returns an error. tags and
+// previewID behave as in Query.
+func (s *RAGService) QueryStream(
+	ctx context.Context,
+	websiteID uint,
+	query string,
+	tags []string,
+	previewID string,
+	onSources func(meta *QueryStreamMeta) error,
+	callback func(chunk string) error,
+) (*QueryStreamMeta, error) {
 	s.logger.Info("Processing streaming RAG query",
 		zap.Uint("websiteID", websiteID),
 		zap.String("query", query),
+		zap.String("previewID", previewID),
 	)
 
-	if query == "" {
-		return nil, fmt.Errorf("query cannot be empty")
+	// Step 1: Embed the query (or load the preview) and check the answer cache
+	ret, err := s.prepare(ctx, websiteID, query, tags, previewID)
+	if err != nil {
+		return nil, err
 	}
+	query = ret.query
 
-	// Step 1: Retrieve similar chunks from ChromaDB
-	results, err := s.vectorizerSvc.QuerySimilarContent(ctx, websiteID, query, s.topK)
-	if err != nil {
-		s.logger.Error("Failed to retrieve similar content",
-			zap.Uint("websiteID", websiteID),
-			zap.Error(err),
-		)
-		return nil, fmt.Errorf("failed to retrieve content: %w", err)
+	if guardrail := s.guardrails.CheckQuestion(websiteID, query); guardrail != "" {
+		return sendRefusal(query, guardrail, onSources, callback)
+	}
+
+	if s.cacheable(ret) {
+		if cached := s.cache.Lookup(ctx, websiteID, query, ret.embedding); cached != nil {
+			meta := &QueryStreamMeta{
+				Sources:         cached.Sources,
+				RetrievedChunks: cached.RetrievedChunks,
+				Query:           query,
+				Cached:          true,
+			}
+			if err := onSources(meta); err != nil {
+				return nil, err
+			}
+			if err := callback(cached.Answer); err != nil {
+				return nil, err
+			}
+			return meta, nil
+		}
+	}
+
+	// Step 2: Retrieve similar chunks from ChromaDB
+	if ret.sources == nil {
+		if err := s.retrieve(ctx, ret); err != nil {
+			return nil, err
+		}
 	}
 
-	if len(results) == 0 {
+	if len(ret.sources) == 0 {
 		s.logger.Warn("No similar content found",
 			zap.Uint("websiteID", websiteID),
 			zap.String("query", query),
 		)
-		// Send a complete message for no results
-		err := callback("I couldn't find any relevant information to answer your question. The website might not have been crawled yet, or there's no content matching your query.")
-		if err != nil {
-			return nil, err
-		}
-		return &QueryStreamMeta{
+		meta := &QueryStreamMeta{
 			Sources:         []QuerySource{},
 			RetrievedChunks: 0,
 			Query:           query,
-		}, nil
+		}
+		if err := onSources(meta); err != nil {
+			return nil, err
+		}
+		// Send a complete message for no results
+		if err := callback(noResultsAnswer); err != nil {
+			return nil, err
+		}
+		return meta, nil
 	}
 
-	s.logger.Info("Retrieved similar chunks for streaming",
-		zap.Int("count", len(results)),
-	)
-
-	// Step 2: Extract context chunks and build sources
-	contextLimit := s.contextChunks
-	if contextLimit > len(results) {
-		contextLimit = len(results)
+	if guardrail := s.guardrails.CheckSources(ret.sources); guardrail != "" {
+		return sendRefusal(query, guardrail, onSources, callback)
 	}
 
-	contextChunks := make([]string, contextLimit)
-	sources := make([]QuerySource, len(results))
-
-	for i := 0; i < len(results); i++ {
-		result := results[i]
-
-		if i < contextLimit {
-			contextChunks[i] = result.Document
-		}
-
-		source := QuerySource{
-			ChunkText:  result.Document,
-			Similarity: 1.0 - result.Distance,
-		}
-
-		if result.Metadata != nil {
-			if pageURL, ok := result.Metadata["page_url"].(string); ok {
-				source.PageURL = pageURL
-			}
-			if chunkIndex, ok := result.Metadata["chunk_index"].(float64); ok {
-				source.ChunkIndex = int(chunkIndex)
-			}
-			if pageID, ok := result.Metadata["page_id"].(float64); ok {
-				source.PageID = uint(pageID)
-			}
-		}
+	meta := &QueryStreamMeta{
+		Sources:         ret.sources,
+		RetrievedChunks: len(ret.sources),
+		Query:           query,
+	}
 
-		sources[i] = source
+	// Send sources before generation so clients can show citations early
+	if err := onSources(meta); err != nil {
+		return nil, err
 	}
 
 	// Step 3: Generate streaming answer using LLM with context
 	s.logger.Info("Generating streaming LLM response",
-		zap.Int("contextChunks", len(contextChunks)),
+		zap.Int("contextChunks", len(ret.contextChunks)),
 	)
 
-	err = s.llm.GenerateWithContextStream(ctx, query, contextChunks, callback)
+	answer, err := s.streamAnswer(ctx, query, meta, func(onChunk func(chunk string) error) error {
+		return s.llm.GenerateWithContextStream(ctx, query, ret.overview, ret.contextChunks, onChunk)
+	}, callback)
 	if err != nil {
+		if ctx.Err() != nil {
+			s.logger.Info("Streaming RAG query cancelled by client",
+				zap.Uint("websiteID", websiteID),
+			)
+			return nil, ctx.Err()
+		}
 		s.logger.Error("Failed to generate streaming LLM response",
 			zap.Error(err),
 		)
@@ -262,13 +503,209 @@ func (s *RAGService) QueryStream(ctx context.Context, websiteID uint, query stri
 
 	s.logger.Info("Streaming RAG query completed successfully",
 		zap.Uint("websiteID", websiteID),
+		zap.Bool("degraded", meta.Degraded),
 	)
 
-	return &QueryStreamMeta{
-		Sources:         sources,
-		RetrievedChunks: len(results),
-		Query:           query,
-	}, nil
+	if s.cacheable(ret) && !meta.Degraded && meta.Guardrail == "" {
+		s.cache.Store(ctx, websiteID, ret.embedding, &QueryResponse{
+			Answer:          answer,
+			Sources:         ret.sources,
+			RetrievedChunks: len(ret.sources),
+			Query:           query,
+		})
+	}
+
+	return meta, nil
+}
+
+// ChatStream answers the latest message of a conversation using the website's
+// content. Retrieval uses the new message only, while history is passed to the
+// LLM so follow-up questions can be resolved. Answers are not cached because
+// they depend on the conversation. Callbacks behave as in QueryStream.
+func (s *RAGService) ChatStream(
+	ctx context.Context,
+	websiteID uint,
+	message string,
+	history []ChatMessage,
+	onSources func(meta *QueryStreamMeta) error,
+	callback func(chunk string) error,
+) (*QueryStreamMeta, error) {
+	s.logger.Info("Processing RAG chat message",
+		zap.Uint("websiteID", websiteID),
+		zap.Int("historyLength", len(history)),
+	)
+
+	if guardrail := s.guardrails.CheckQuestion(websiteID, message); guardrail != "" {
+		return sendRefusal(message, guardrail, onSources, callback)
+	}
+
+	ret, err := s.prepare(ctx, websiteID, message, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.retrieve(ctx, ret); err != nil {
+		return nil, err
+	}
+
+	if guardrail := s.guardrails.CheckSources(ret.sources); guardrail != "" {
+		return sendRefusal(message, guardrail, onSources, callback)
+	}
+
+	meta := &QueryStreamMeta{
+		Sources:         ret.sources,
+		RetrievedChunks: len(ret.sources),
+		Query:           message,
+	}
+	if err := onSources(meta); err != nil {
+		return nil, err
+	}
+
+	messages := make([]ChatMessage, 0, len(history)+1)
+	messages = append(messages, history...)
+	messages = append(messages, ChatMessage{Role: "user", Content: message})
+
+	_, err = s.streamAnswer(ctx, message, meta, func(onChunk func(chunk string) error) error {
+		return s.llm.ChatStream(ctx, messages, s.llm.buildChatSystemPrompt(ret.overview, ret.contextChunks), onChunk)
+	}, callback)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		s.logger.Error("Failed to generate chat response",
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to generate chat answer: %w", err)
+	}
+
+	return meta, nil
+}
+
+// streamAnswer runs generate and passes the post-processed chunks to callback,
+// returning the full answer. When the LLM fails before anything was sent, or
+// the circuit breaker is open, an extractive answer is sent instead and
+// meta.Degraded is set. When answers are moderated, the answer is buffered
+// until moderation accepts it, or replaced by a refusal naming the guardrail
+// in meta.Guardrail.
+func (s *RAGService) streamAnswer(
+	ctx context.Context,
+	question string,
+	meta *QueryStreamMeta,
+	generate func(onChunk func(chunk string) error) error,
+	callback func(chunk string) error,
+) (string, error) {
+	sources := meta.Sources
+	if s.guardrails.moderates() {
+		generate = s.moderated(ctx, question, meta, generate)
+	}
+
+	// Errors from callback mean the client went away, not that the LLM failed
+	var sendErr error
+	stream := s.postProcessor.newStream(sources, func(chunk string) error {
+		if err := callback(chunk); err != nil {
+			sendErr = err
+			return err
+		}
+		return nil
+	})
+
+	if s.breaker.Allow() {
+		err := generate(func(chunk string) error {
+			// Stop generating as soon as the client goes away
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return stream.write(chunk)
+		})
+		if err == nil {
+			err = stream.close()
+		}
+
+		switch {
+		case err == nil || errors.Is(err, errAnswerLimitReached):
+			s.breaker.Success()
+			return stream.text(), nil
+		case ctx.Err() != nil:
+			return "", ctx.Err()
+		case sendErr != nil:
+			return "", sendErr
+		}
+
+		s.breaker.Failure()
+		if stream.text() != "" {
+			// Part of the answer was already sent and can't be replaced
+			return "", err
+		}
+		s.logger.Error("LLM generation failed, falling back to an extractive answer",
+			zap.Error(err),
+		)
+	} else {
+		s.logger.Warn("LLM circuit breaker open, returning an extractive answer")
+	}
+
+	answer := s.postProcessor.Process(extractiveAnswer(sources, s.contextChunks), sources)
+	if err := callback(answer); err != nil {
+		return "", err
+	}
+
+	meta.Degraded = true
+	return answer, nil
+}
+
+// moderated wraps generate to buffer the whole answer and pass it on once
+// moderation accepted it, or a refusal instead.
+func (s *RAGService) moderated(
+	ctx context.Context,
+	question string,
+	meta *QueryStreamMeta,
+	generate func(onChunk func(chunk string) error) error,
+) func(onChunk func(chunk string) error) error {
+	return func(onChunk func(chunk string) error) error {
+		var answer strings.Builder
+		if err := generate(func(chunk string) error {
+			answer.WriteString(chunk)
+			return ctx.Err()
+		}); err != nil {
+			return err
+		}
+
+		if guardrail := s.guardrails.Moderate(ctx, question, answer.String()); guardrail != "" {
+			meta.Guardrail = guardrail
+			return onChunk(refusal(guardrail))
+		}
+		return onChunk(answer.String())
+	}
+}
+
+// refusedResponse is the response to a question a guardrail refused.
+func refusedResponse(query, guardrail string) *QueryResponse {
+	return &QueryResponse{
+		Answer:    refusal(guardrail),
+		Sources:   []QuerySource{},
+		Query:     query,
+		Guardrail: guardrail,
+	}
+}
+
+// sendRefusal streams the refusal of a question a guardrail refused.
+func sendRefusal(
+	query string,
+	guardrail string,
+	onSources func(meta *QueryStreamMeta) error,
+	callback func(chunk string) error,
+) (*QueryStreamMeta, error) {
+	meta := &QueryStreamMeta{
+		Sources:   []QuerySource{},
+		Query:     query,
+		Guardrail: guardrail,
+	}
+	if err := onSources(meta); err != nil {
+		return nil, err
+	}
+	if err := callback(refusal(guardrail)); err != nil {
+		return nil, err
+	}
+	return meta, nil
 }
 
 // QueryStreamMeta represents metadata from a streaming RAG query.
@@ -276,4 +713,10 @@ type QueryStreamMeta struct {
 	Sources         []QuerySource `json:"sources"`
 	RetrievedChunks int           `json:"retrieved_chunks"`
 	Query           string        `json:"query"`
+	Cached          bool          `json:"cached,omitempty"`
+	Degraded        bool          `json:"degraded,omitempty"`
+	// Guardrail is set when the answer was refused, see QueryResponse
+	Guardrail string `json:"guardrail,omitempty"`
+	// Usage is only known once the answer is complete, see QueryResponse
+	Usage *TokenUsage `json:"usage,omitempty"`
 }
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"sync"
+	"time"
+
+	"hermit/internal/apperrors"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// ErrPreviewNotFound is returned when a sources preview is unknown or has expired.
+var ErrPreviewNotFound = apperrors.NotFound("Preview not found or expired")
+
+// previewTTL is how long retrieved sources are kept for a follow-up generation request.
+const previewTTL = 5 * time.Minute
+
+// SourcesPreview represents the sources retrieved for a query before an answer is generated.
+type SourcesPreview struct {
+	PreviewID       string        `json:"preview_id"`
+	Query           string        `json:"query"`
+	Sources         []QuerySource `json:"sources"`
+	RetrievedChunks int           `json:"retrieved_chunks"`
+	ExpiresAt       time.Time     `json:"expires_at"`
+}
+
+// retrieval holds everything needed to generate an answer for a query.
+type retrieval struct {
+	websiteID     uint
+	query         string
+	embedding     []float32
+	contextChunks []string
+	sources       []QuerySource
+	// tags limits retrieval to chunks of pages with any of them
+	tags []string
+	// overview holds the website and page summaries added to the prompt
+	overview  string
+	expiresAt time.Time
+}
+
+// previewStore keeps retrievals in memory between the preview and generation phases.
+type previewStore struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*retrieval
+}
+
+// newPreviewStore creates a new previewStore.
+func newPreviewStore(ttl time.Duration) *previewStore {
+	return &previewStore{
+		ttl:     ttl,
+		entries: make(map[string]*retrieval),
+	}
+}
+
+// put stores a retrieval and returns its preview ID.
+func (p *previewStore) put(r *retrieval) string {
+	id := ulid.Make().String()
+	now := time.Now()
+	r.expiresAt = now.Add(p.ttl)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Drop expired entries so the map doesn't grow unbounded
+	for key, entry := range p.entries {
+		if now.After(entry.expiresAt) {
+			delete(p.entries, key)
+		}
+	}
+	p.entries[id] = r
+
+	return id
+}
+
+// get returns the retrieval for a preview ID if it exists, belongs to the
+// website and has not expired.
+func (p *previewStore) get(id string, websiteID uint) *retrieval {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.entries[id]
+	if !ok || entry.websiteID != websiteID {
+		return nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(p.entries, id)
+		return nil
+	}
+
+	return entry
+}
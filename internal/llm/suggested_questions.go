@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/repositories"
+	"hermit/internal/schema"
+	"hermit/internal/vectorizer"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// suggestedQuestionsCount is the number of questions generated per website.
+	suggestedQuestionsCount = 5
+	// suggestionSampleChunks is the number of chunks the questions are
+	// generated from.
+	suggestionSampleChunks = 8
+	// maxSampleChunkLength limits each sampled chunk in the prompt.
+	maxSampleChunkLength = 800
+	// maxQuestionLength drops generated lines too long to be a question.
+	maxQuestionLength = 200
+)
+
+// questionPrefixPattern matches list markers the LLM puts before questions.
+var questionPrefixPattern = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)]|Q\d*[:.])\s*`)
+
+// QuestionSuggester generates questions users could ask about a website from
+// a sample of its indexed content. Questions are stored and generated again
+// once a newer crawl completed.
+type QuestionSuggester struct {
+	vectorizerSvc *vectorizer.Service
+	llm           *OllamaLLM
+	websiteRepo   *repositories.WebsiteRepository
+	logger        *zap.Logger
+
+	mu    sync.Mutex
+	locks map[uint]*sync.Mutex
+}
+
+// NewQuestionSuggester creates a new QuestionSuggester.
+func NewQuestionSuggester(
+	vectorizerSvc *vectorizer.Service,
+	llm *OllamaLLM,
+	websiteRepo *repositories.WebsiteRepository,
+	logger *zap.Logger,
+) *QuestionSuggester {
+	return &QuestionSuggester{
+		vectorizerSvc: vectorizerSvc,
+		llm:           llm,
+		websiteRepo:   websiteRepo,
+		logger:        logger,
+		locks:         make(map[uint]*sync.Mutex),
+	}
+}
+
+// Suggest returns the suggested questions of a website, generating them when
+// none were generated since its last crawl completed. Websites without
+// indexed content have no suggestions.
+func (s *QuestionSuggester) Suggest(ctx context.Context, website *schema.Website) (*schema.SuggestedQuestions, error) {
+	suggested, err := s.stored(ctx, website)
+	if err != nil || suggested != nil {
+		return suggested, err
+	}
+
+	if website.TotalPagesCrawled == 0 {
+		return &schema.SuggestedQuestions{Questions: []string{}}, nil
+	}
+
+	// Generate once per website while concurrent requests wait for the result
+	lock := s.lock(website.ID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	suggested, err = s.stored(ctx, website)
+	if err != nil || suggested != nil {
+		return suggested, err
+	}
+
+	chunks, err := s.vectorizerSvc.SampleChunks(ctx, website.ID, suggestionSampleChunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample content: %w", err)
+	}
+	if len(chunks) == 0 {
+		return &schema.SuggestedQuestions{Questions: []string{}}, nil
+	}
+
+	response, err := s.llm.GenerateResponse(ctx, buildSuggestionPrompt(website, chunks))
+	if err != nil {
+		return nil, apperrors.UpstreamUnavailable("LLM service is unavailable", err)
+	}
+
+	questions := parseQuestions(response)
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("no questions in LLM response")
+	}
+
+	suggested, err = s.websiteRepo.SetSuggestedQuestions(ctx, website.ID, questions)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Generated suggested questions",
+		zap.Uint("websiteID", website.ID),
+		zap.Int("questions", len(questions)),
+		zap.Int("sampledChunks", len(chunks)),
+	)
+
+	return suggested, nil
+}
+
+// stored returns the stored questions of a website unless a crawl completed
+// after they were generated.
+func (s *QuestionSuggester) stored(ctx context.Context, website *schema.Website) (*schema.SuggestedQuestions, error) {
+	suggested, err := s.websiteRepo.GetSuggestedQuestions(ctx, website.ID)
+	if err != nil || suggested == nil {
+		return nil, err
+	}
+
+	if website.CrawlCompletedAt.Valid && suggested.GeneratedAt.Before(website.CrawlCompletedAt.Time) {
+		return nil, nil
+	}
+	return suggested, nil
+}
+
+// lock returns the mutex serializing the generation for a website.
+func (s *QuestionSuggester) lock(websiteID uint) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, ok := s.locks[websiteID]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.locks[websiteID] = lock
+	}
+	return lock
+}
+
+// buildSuggestionPrompt asks for questions answered by the sampled chunks.
+func buildSuggestionPrompt(website *schema.Website, chunks []vectorizer.QueryResult) string {
+	var prompt strings.Builder
+	prompt.WriteString("Below are excerpts from the website ")
+	prompt.WriteString(website.URL)
+	prompt.WriteString(".\n")
+	if website.Summary.Valid {
+		prompt.WriteString(fmt.Sprintf("About the website: %s\n", website.Summary.String))
+	}
+	prompt.WriteString("\n")
+
+	for i, chunk := range chunks {
+		prompt.WriteString(fmt.Sprintf("[%d] %s\n\n", i+1, truncateAnswer(strings.TrimSpace(chunk.Document), maxSampleChunkLength)))
+	}
+
+	prompt.WriteString(fmt.Sprintf("Write %d short, distinct questions a visitor of the website could ask ", suggestedQuestionsCount))
+	prompt.WriteString("that the excerpts answer. Cover different topics. ")
+	prompt.WriteString("Reply with one question per line and nothing else.\n")
+
+	return prompt.String()
+}
+
+// parseQuestions extracts the questions from an LLM response, one per line.
+func parseQuestions(response string) []string {
+	questions := make([]string, 0, suggestedQuestionsCount)
+	seen := make(map[string]bool)
+
+	for _, line := range strings.Split(response, "\n") {
+		question := strings.TrimSpace(questionPrefixPattern.ReplaceAllString(line, ""))
+		question = strings.Trim(question, `"*`)
+		if !strings.HasSuffix(question, "?") || len(question) > maxQuestionLength {
+			continue
+		}
+
+		key := strings.ToLower(question)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		questions = append(questions, question)
+		if len(questions) == suggestedQuestionsCount {
+			break
+		}
+	}
+
+	return questions
+}
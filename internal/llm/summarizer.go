@@ -0,0 +1,170 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"hermit/internal/repositories"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxSummaryInput limits the characters of page content sent to the LLM
+	// for a summary. The start of a page usually says what it is about.
+	maxSummaryInput = 12000
+	// maxPageSummaryLength limits a stored page summary.
+	maxPageSummaryLength = 600
+	// maxWebsiteSummaryLength limits a stored website summary.
+	maxWebsiteSummaryLength = 1500
+	// MaxRollupPages is the number of page summaries a website summary is
+	// built from.
+	MaxRollupPages = 50
+	// maxPromptPageSummaries is the number of page summaries added to a RAG
+	// prompt.
+	maxPromptPageSummaries = 5
+)
+
+// PageSummary is the summary of a single page, used to build the website
+// summary.
+type PageSummary struct {
+	URL     string
+	Title   string
+	Summary string
+}
+
+// Summarizer generates short summaries of pages and websites with the LLM.
+type Summarizer struct {
+	llm    *OllamaLLM
+	logger *zap.Logger
+}
+
+// NewSummarizer creates a new Summarizer.
+func NewSummarizer(llm *OllamaLLM, logger *zap.Logger) *Summarizer {
+	return &Summarizer{
+		llm:    llm,
+		logger: logger,
+	}
+}
+
+// SummarizePage summarizes the cleaned content of a page in a few sentences.
+func (s *Summarizer) SummarizePage(ctx context.Context, pageURL, title, content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", fmt.Errorf("cannot summarize empty content")
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Summarize the following web page in 2 to 3 sentences. ")
+	prompt.WriteString("Describe what the page is about and the key facts it contains. ")
+	prompt.WriteString("Reply with the summary only.\n\n")
+	prompt.WriteString(fmt.Sprintf("URL: %s\n", pageURL))
+	if title != "" {
+		prompt.WriteString(fmt.Sprintf("Title: %s\n", title))
+	}
+	prompt.WriteString("\nContent:\n")
+	prompt.WriteString(truncateAnswer(content, maxSummaryInput))
+	prompt.WriteString("\n\nSummary: ")
+
+	summary, err := s.llm.GenerateResponse(ctx, prompt.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize page: %w", err)
+	}
+
+	return truncateAnswer(strings.TrimSpace(summary), maxPageSummaryLength), nil
+}
+
+// SummarizeWebsite builds an overview of a website from the summaries of its
+// pages.
+func (s *Summarizer) SummarizeWebsite(ctx context.Context, websiteURL string, pages []PageSummary) (string, error) {
+	if len(pages) == 0 {
+		return "", fmt.Errorf("no page summaries to summarize")
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Below are summaries of pages of the website ")
+	prompt.WriteString(websiteURL)
+	prompt.WriteString(". Write an overview of the website in one short paragraph: ")
+	prompt.WriteString("who it is for, what it offers and the main topics it covers. ")
+	prompt.WriteString("Reply with the overview only.\n\n")
+	for _, page := range pages {
+		prompt.WriteString(fmt.Sprintf("- %s: %s\n", pageLabel(page.Title, page.URL), page.Summary))
+	}
+	prompt.WriteString("\nOverview: ")
+
+	summary, err := s.llm.GenerateResponse(ctx, prompt.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize website: %w", err)
+	}
+
+	return truncateAnswer(strings.TrimSpace(summary), maxWebsiteSummaryLength), nil
+}
+
+// pageLabel names a page by its title, or its URL when it has none.
+func pageLabel(title, pageURL string) string {
+	if title != "" {
+		return title
+	}
+	return pageURL
+}
+
+// summaryStore loads the stored summaries added to RAG prompts as
+// high-level context.
+type summaryStore struct {
+	websiteRepo *repositories.WebsiteRepository
+	pageRepo    *repositories.PageRepository
+}
+
+// overview returns the summary of a website followed by the summaries of
+// the pages the context chunks come from, or an empty string when nothing
+// was summarized yet.
+func (s *summaryStore) overview(ctx context.Context, websiteID uint, sources []QuerySource) (string, error) {
+	website, err := s.websiteRepo.GetByID(ctx, websiteID)
+	if err != nil {
+		return "", err
+	}
+
+	var ids []int64
+	seen := make(map[uint]bool)
+	for _, source := range sources {
+		if source.PageID == 0 || seen[source.PageID] {
+			continue
+		}
+		seen[source.PageID] = true
+		ids = append(ids, int64(source.PageID))
+		if len(ids) == maxPromptPageSummaries {
+			break
+		}
+	}
+
+	var summaries map[uint]string
+	if len(ids) > 0 {
+		summaries, err = s.pageRepo.GetSummaries(ctx, ids)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	if website != nil && website.Summary.Valid {
+		b.WriteString("About the website: ")
+		b.WriteString(website.Summary.String)
+		b.WriteString("\n")
+	}
+
+	written := make(map[uint]bool)
+	for _, source := range sources {
+		summary, ok := summaries[source.PageID]
+		if !ok || written[source.PageID] {
+			continue
+		}
+		written[source.PageID] = true
+		if len(written) == 1 {
+			b.WriteString("About the pages the context comes from:\n")
+		}
+		b.WriteString(fmt.Sprintf("- %s: %s\n", source.PageURL, summary))
+	}
+
+	return b.String(), nil
+}
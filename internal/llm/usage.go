@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// TokenUsage counts the tokens LLM calls processed.
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type usageContextKey struct{}
+
+// UsageRecorder accumulates the token usage of the LLM calls made with a
+// context, across calls and goroutines.
+type UsageRecorder struct {
+	mu    sync.Mutex
+	usage TokenUsage
+}
+
+// WithUsage returns a copy of ctx carrying a new UsageRecorder.
+func WithUsage(ctx context.Context) (context.Context, *UsageRecorder) {
+	r := &UsageRecorder{}
+	return context.WithValue(ctx, usageContextKey{}, r), r
+}
+
+// RecordUsage adds the tokens of an LLM call to the recorder of ctx. LLM
+// providers call it once per completed call with the counts they report. It
+// is a no-op when ctx carries no UsageRecorder.
+func RecordUsage(ctx context.Context, promptTokens, completionTokens int) {
+	r, ok := ctx.Value(usageContextKey{}).(*UsageRecorder)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usage.PromptTokens += promptTokens
+	r.usage.CompletionTokens += completionTokens
+	r.usage.TotalTokens += promptTokens + completionTokens
+}
+
+// Usage returns the tokens recorded so far, nil when no LLM call reported
+// any, e.g. for cached answers.
+func (r *UsageRecorder) Usage() *TokenUsage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.usage.TotalTokens == 0 {
+		return nil
+	}
+	usage := r.usage
+	return &usage
+}
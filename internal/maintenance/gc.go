@@ -0,0 +1,216 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"hermit/internal/repositories"
+	"hermit/internal/storage"
+	"hermit/internal/vectorizer"
+
+	"go.uber.org/zap"
+)
+
+// GCReport summarizes the orphans found (and optionally deleted) by a garbage collection run.
+type GCReport struct {
+	Deleted bool `json:"deleted"`
+	// Garage objects not referenced by any page or snapshot row
+	OrphanedObjects []string `json:"orphaned_objects"`
+	// ChromaDB collections whose website no longer exists
+	OrphanedCollections []uint `json:"orphaned_collections"`
+	// Chunk counts per website for pages that no longer exist
+	OrphanedChunks    map[uint]map[uint]int `json:"orphaned_chunks"`
+	TotalOrphanChunks int                   `json:"total_orphan_chunks"`
+	Errors            []string              `json:"errors,omitempty"`
+	StartedAt         time.Time             `json:"started_at"`
+	Duration          string                `json:"duration"`
+}
+
+// GarbageCollector reconciles Postgres with Garage and ChromaDB and removes
+// data left behind by failed crawls or deleted pages and websites.
+type GarbageCollector struct {
+	websiteRepo  *repositories.WebsiteRepository
+	pageRepo     *repositories.PageRepository
+	snapshotRepo *repositories.VisualSnapshotRepository
+	storage      *storage.GarageStorage
+	vectorizer   *vectorizer.Service
+	logger       *zap.Logger
+}
+
+// NewGarbageCollector creates a new GarbageCollector.
+func NewGarbageCollector(
+	websiteRepo *repositories.WebsiteRepository,
+	pageRepo *repositories.PageRepository,
+	snapshotRepo *repositories.VisualSnapshotRepository,
+	storage *storage.GarageStorage,
+	vectorizer *vectorizer.Service,
+	logger *zap.Logger,
+) *GarbageCollector {
+	return &GarbageCollector{
+		websiteRepo:  websiteRepo,
+		pageRepo:     pageRepo,
+		snapshotRepo: snapshotRepo,
+		storage:      storage,
+		vectorizer:   vectorizer,
+		logger:       logger,
+	}
+}
+
+// Run finds orphaned Garage objects and ChromaDB chunks. When del is false the
+// run only reports what it would delete.
+func (g *GarbageCollector) Run(ctx context.Context, del bool) (*GCReport, error) {
+	report := &GCReport{
+		Deleted:         del,
+		OrphanedObjects: []string{},
+		OrphanedChunks:  make(map[uint]map[uint]int),
+		StartedAt:       time.Now(),
+	}
+
+	websites, err := g.websiteRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list websites: %w", err)
+	}
+	websiteIDs := make(map[uint]bool, len(websites))
+	var crawling []string
+	for _, website := range websites {
+		websiteIDs[website.ID] = true
+		// Objects of running crawls may not be linked to their page row yet
+		if website.CrawlStatus == "crawling" {
+			crawling = append(crawling, fmt.Sprintf("websites/%d/", website.ID))
+		}
+	}
+
+	if err := g.collectObjects(ctx, report, crawling, del); err != nil {
+		return nil, err
+	}
+
+	if err := g.collectVectors(ctx, report, websiteIDs, del); err != nil {
+		return nil, err
+	}
+
+	report.Duration = time.Since(report.StartedAt).String()
+
+	g.logger.Info("Garbage collection completed",
+		zap.Bool("deleted", del),
+		zap.Int("orphanedObjects", len(report.OrphanedObjects)),
+		zap.Int("orphanedCollections", len(report.OrphanedCollections)),
+		zap.Int("orphanedChunks", report.TotalOrphanChunks),
+		zap.Int("errors", len(report.Errors)),
+	)
+
+	return report, nil
+}
+
+// collectObjects finds Garage objects that no database row references,
+// ignoring objects under the skipped prefixes.
+func (g *GarbageCollector) collectObjects(ctx context.Context, report *GCReport, skip []string, del bool) error {
+	pageKeys, err := g.pageRepo.ListObjectKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list page object keys: %w", err)
+	}
+	snapshotKeys, err := g.snapshotRepo.ListObjectKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	referenced := make(map[string]bool, len(pageKeys)+len(snapshotKeys))
+	for _, key := range pageKeys {
+		referenced[key] = true
+	}
+	for _, key := range snapshotKeys {
+		referenced[key] = true
+	}
+
+	keys, err := g.storage.ListObjectKeys(ctx, "websites/")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if referenced[key] || hasAnyPrefix(key, skip) {
+			continue
+		}
+
+		report.OrphanedObjects = append(report.OrphanedObjects, key)
+		if del {
+			if err := g.storage.DeleteObject(ctx, key); err != nil {
+				report.Errors = append(report.Errors, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// collectVectors finds collections of deleted websites and chunks of deleted pages.
+func (g *GarbageCollector) collectVectors(ctx context.Context, report *GCReport, websiteIDs map[uint]bool, del bool) error {
+	collections, err := g.vectorizer.ListWebsiteCollections(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, websiteID := range collections {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !websiteIDs[websiteID] {
+			report.OrphanedCollections = append(report.OrphanedCollections, websiteID)
+			if del {
+				if err := g.vectorizer.DeleteWebsiteVectors(ctx, websiteID); err != nil {
+					report.Errors = append(report.Errors, err.Error())
+				}
+			}
+			continue
+		}
+
+		chunkCounts, err := g.vectorizer.CountChunksByPage(ctx, websiteID)
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+			continue
+		}
+
+		pageIDs, err := g.pageRepo.ListIDsByWebsiteID(ctx, websiteID)
+		if err != nil {
+			return fmt.Errorf("failed to list pages for website %d: %w", websiteID, err)
+		}
+		existing := make(map[uint]bool, len(pageIDs))
+		for _, id := range pageIDs {
+			existing[id] = true
+		}
+
+		for pageID, count := range chunkCounts {
+			if existing[pageID] {
+				continue
+			}
+
+			if report.OrphanedChunks[websiteID] == nil {
+				report.OrphanedChunks[websiteID] = make(map[uint]int)
+			}
+			report.OrphanedChunks[websiteID][pageID] = count
+			report.TotalOrphanChunks += count
+
+			if del {
+				if err := g.vectorizer.DeletePageVectors(ctx, websiteID, pageID); err != nil {
+					report.Errors = append(report.Errors, err.Error())
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasAnyPrefix reports whether s starts with any of the prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hermit/internal/repositories"
+	"hermit/internal/schema"
+
+	"go.uber.org/zap"
+)
+
+// WatchdogReport summarizes the stalled crawls found by a crawl watchdog run.
+type WatchdogReport struct {
+	// Websites whose crawl was marked as failed
+	Stalled []uint `json:"stalled"`
+	// Websites whose crawl was queued again
+	Requeued []uint `json:"requeued"`
+	// Websites whose crawl task is still queued, e.g. waiting to be retried
+	// after its worker crashed, and runs again by itself
+	Queued    []uint    `json:"queued"`
+	Errors    []string  `json:"errors,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+}
+
+// CrawlRequeuer queues the crawl of a website again.
+type CrawlRequeuer interface {
+	// RequeueCrawl reports false when a crawl task of the website is still
+	// queued or running
+	RequeueCrawl(ctx context.Context, websiteID uint) (bool, error)
+}
+
+// CrawlWatchdog finds websites left crawling without page activity, e.g.
+// because the worker running the crawl crashed, marks their crawl as failed
+// and optionally queues it again.
+type CrawlWatchdog struct {
+	websiteRepo  *repositories.WebsiteRepository
+	eventRepo    *repositories.CrawlEventRepository
+	requeuer     CrawlRequeuer
+	stallTimeout time.Duration
+	requeue      bool
+	logger       *zap.Logger
+}
+
+// NewCrawlWatchdog creates a watchdog for crawls without page activity for
+// stallTimeout. Stalled crawls are queued again when requeue is true.
+func NewCrawlWatchdog(
+	websiteRepo *repositories.WebsiteRepository,
+	eventRepo *repositories.CrawlEventRepository,
+	requeuer CrawlRequeuer,
+	stallTimeout time.Duration,
+	requeue bool,
+	logger *zap.Logger,
+) *CrawlWatchdog {
+	return &CrawlWatchdog{
+		websiteRepo:  websiteRepo,
+		eventRepo:    eventRepo,
+		requeuer:     requeuer,
+		stallTimeout: stallTimeout,
+		requeue:      requeue,
+		logger:       logger,
+	}
+}
+
+// Run marks the crawls without page activity for the stall timeout as failed.
+func (w *CrawlWatchdog) Run(ctx context.Context) (*WatchdogReport, error) {
+	report := &WatchdogReport{
+		Stalled:   []uint{},
+		Requeued:  []uint{},
+		Queued:    []uint{},
+		StartedAt: time.Now(),
+	}
+
+	stalled, err := w.websiteRepo.ListStalledCrawls(ctx, report.StartedAt.Add(-w.stallTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, crawl := range stalled {
+		reason := fmt.Sprintf("Crawl stalled: no page activity since %s", crawl.LastActivityAt.UTC().Format(time.RFC3339))
+
+		ok, err := w.websiteRepo.StallCrawl(ctx, crawl.WebsiteID, reason)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("website %d: %v", crawl.WebsiteID, err))
+			continue
+		}
+		if !ok {
+			// The crawl finished meanwhile
+			continue
+		}
+		report.Stalled = append(report.Stalled, crawl.WebsiteID)
+
+		w.logger.Warn("Marked stalled crawl as failed",
+			zap.Uint("websiteID", crawl.WebsiteID),
+			zap.Time("lastActivityAt", crawl.LastActivityAt),
+		)
+
+		event := &schema.CrawlEvent{
+			WebsiteID: crawl.WebsiteID,
+			URL:       crawl.URL,
+			Level:     schema.CrawlEventLevelError,
+			Event:     schema.CrawlEventStalled,
+			Reason:    reason,
+		}
+		if err := w.eventRepo.Create(ctx, event); err != nil {
+			w.logger.Warn("Failed to record stalled crawl event", zap.Uint("websiteID", crawl.WebsiteID), zap.Error(err))
+		}
+
+		if !w.requeue {
+			continue
+		}
+		queued, err := w.requeuer.RequeueCrawl(ctx, crawl.WebsiteID)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("website %d: failed to requeue crawl: %v", crawl.WebsiteID, err))
+			continue
+		}
+		if queued {
+			report.Requeued = append(report.Requeued, crawl.WebsiteID)
+		} else {
+			report.Queued = append(report.Queued, crawl.WebsiteID)
+		}
+	}
+
+	report.Duration = time.Since(report.StartedAt).Round(time.Millisecond).String()
+
+	w.logger.Info("Crawl watchdog completed",
+		zap.Int("stalled", len(report.Stalled)),
+		zap.Int("requeued", len(report.Requeued)),
+		zap.Int("errors", len(report.Errors)),
+	)
+
+	return report, nil
+}
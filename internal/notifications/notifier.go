@@ -0,0 +1,85 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"hermit/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// Event types
+const (
+	EventVisualChange = "page.visual_change"
+)
+
+// Event represents a notification about something that happened to a website.
+type Event struct {
+	Type      string         `json:"type"`
+	WebsiteID uint           `json:"website_id"`
+	PageURL   string         `json:"page_url,omitempty"`
+	Data      map[string]any `json:"data,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Notifier delivers events to the configured webhook. Events are always logged,
+// so the notifier is usable without a webhook configured.
+type Notifier struct {
+	webhookURL string
+	client     *http.Client
+	logger     *zap.Logger
+}
+
+// NewNotifier creates a new Notifier.
+func NewNotifier(cfg *config.Config, logger *zap.Logger) *Notifier {
+	return &Notifier{
+		webhookURL: cfg.NotificationWebhookURL,
+		client:     &http.Client{Timeout: time.Duration(cfg.HTTPTimeout) * time.Second},
+		logger:     logger,
+	}
+}
+
+// Notify sends an event to the webhook, if one is configured.
+func (n *Notifier) Notify(ctx context.Context, event Event) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	n.logger.Info("Notification",
+		zap.String("type", event.Type),
+		zap.Uint("websiteID", event.WebsiteID),
+		zap.String("pageURL", event.PageURL),
+	)
+
+	if n.webhookURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+// Package ollama provides the Ollama client shared by the embedder and the
+// LLM. It pools connections to the configured Ollama URL, retries transient
+// failures and stops calling Ollama for a while once it looks down.
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"hermit/internal/config"
+	"hermit/internal/resilience"
+
+	"github.com/ollama/ollama/api"
+	"go.uber.org/zap"
+)
+
+// Options configures a Client.
+type Options struct {
+	URL        string
+	Timeout    time.Duration
+	Resilience resilience.Options
+}
+
+// OptionsFromConfig returns the client options set in cfg.
+func OptionsFromConfig(cfg *config.Config) Options {
+	return Options{
+		URL:     cfg.OllamaURL,
+		Timeout: time.Duration(cfg.OllamaTimeout) * time.Second,
+		Resilience: resilience.Options{
+			MaxRetries:       cfg.OllamaMaxRetries,
+			BreakerThreshold: cfg.OllamaBreakerThreshold,
+			BreakerCooldown:  time.Duration(cfg.OllamaBreakerCooldown) * time.Second,
+		},
+	}
+}
+
+// Client wraps api.Client with retries and a circuit breaker.
+type Client struct {
+	api     *api.Client
+	timeout time.Duration
+	guard   *resilience.Guard
+}
+
+// NewClient creates a Client for the Ollama server at opts.URL. Its breaker
+// is registered in breakers as "ollama".
+func NewClient(opts Options, breakers *resilience.Registry, logger *zap.Logger) (*Client, error) {
+	base, err := url.Parse(opts.URL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid Ollama URL %q", opts.URL)
+	}
+
+	// Ollama serves a handful of models, keep enough idle connections for
+	// concurrent embed and generate requests. Timeout bounds the wait for
+	// response headers only, generation streams can take longer.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 32
+	transport.MaxIdleConnsPerHost = 32
+	transport.IdleConnTimeout = 90 * time.Second
+	transport.ResponseHeaderTimeout = opts.Timeout
+
+	logger.Info("Ollama client initialized",
+		zap.String("url", base.String()),
+		zap.Duration("timeout", opts.Timeout),
+		zap.Int("maxRetries", opts.Resilience.MaxRetries),
+	)
+
+	return &Client{
+		api:     api.NewClient(base, &http.Client{Transport: transport}),
+		timeout: opts.Timeout,
+		guard:   breakers.Guard("ollama", opts.Resilience, isTransient),
+	}, nil
+}
+
+// Available reports whether Ollama is called at all, false while the breaker
+// is open.
+func (c *Client) Available() bool {
+	return c.guard.Available()
+}
+
+// Embed generates embeddings. Each attempt is bounded by the client timeout.
+func (c *Client) Embed(ctx context.Context, req *api.EmbedRequest) (*api.EmbedResponse, error) {
+	var resp *api.EmbedResponse
+	err := c.guard.Do(ctx, func(ctx context.Context) error {
+		if c.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+		}
+
+		var err error
+		resp, err = c.api.Embed(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// Generate runs a generate request. A request is only retried while no part
+// of the response reached fn.
+func (c *Client) Generate(ctx context.Context, req *api.GenerateRequest, fn api.GenerateResponseFunc) error {
+	return c.guard.Do(ctx, func(ctx context.Context) error {
+		started := false
+		err := c.api.Generate(ctx, req, func(resp api.GenerateResponse) error {
+			started = true
+			if err := fn(resp); err != nil {
+				return &callbackError{err: err}
+			}
+			return nil
+		})
+		if started {
+			return resilience.Stop(err)
+		}
+		return err
+	})
+}
+
+// Chat runs a chat request. A request is only retried while no part of the
+// response reached fn.
+func (c *Client) Chat(ctx context.Context, req *api.ChatRequest, fn api.ChatResponseFunc) error {
+	return c.guard.Do(ctx, func(ctx context.Context) error {
+		started := false
+		err := c.api.Chat(ctx, req, func(resp api.ChatResponse) error {
+			started = true
+			if err := fn(resp); err != nil {
+				return &callbackError{err: err}
+			}
+			return nil
+		})
+		if started {
+			return resilience.Stop(err)
+		}
+		return err
+	})
+}
+
+// Show returns information about a model.
+func (c *Client) Show(ctx context.Context, req *api.ShowRequest) (*api.ShowResponse, error) {
+	var resp *api.ShowResponse
+	err := c.guard.Do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.api.Show(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// callbackError carries an error returned by a response callback, e.g. when
+// the client of a stream went away. It says nothing about Ollama's health.
+type callbackError struct {
+	err error
+}
+
+func (e *callbackError) Error() string { return e.err.Error() }
+func (e *callbackError) Unwrap() error { return e.err }
+
+// isTransient reports whether err means Ollama is down or overloaded rather
+// than the request being invalid.
+func isTransient(err error) bool {
+	var cbErr *callbackError
+	if errors.As(err, &cbErr) {
+		return false
+	}
+
+	var statusErr api.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	return resilience.IsNetworkError(err)
+}
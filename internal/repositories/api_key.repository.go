@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"hermit/internal/apperrors"
 	"hermit/internal/schema"
 
 	"github.com/jmoiron/sqlx"
@@ -26,8 +27,8 @@ func NewAPIKeyRepository(db *sqlx.DB) *APIKeyRepository {
 // Create creates a new API key
 func (r *APIKeyRepository) Create(ctx context.Context, apiKey *schema.APIKey) error {
 	query := `
-		INSERT INTO api_keys (id, user_id, key_hash, key_prefix, name, scopes, is_active, expires_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO api_keys (id, user_id, key_hash, key_prefix, name, scopes, is_active, session, ip, user_agent, public, website_id, allowed_origins, rate_limit_per_min, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -36,6 +37,9 @@ func (r *APIKeyRepository) Create(ctx context.Context, apiKey *schema.APIKey) er
 	apiKey.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy)
 	apiKey.CreatedAt = time.Now()
 	apiKey.UpdatedAt = time.Now()
+	if apiKey.AllowedOrigins == nil {
+		apiKey.AllowedOrigins = []string{}
+	}
 
 	err := r.db.QueryRowContext(
 		ctx,
@@ -47,6 +51,13 @@ func (r *APIKeyRepository) Create(ctx context.Context, apiKey *schema.APIKey) er
 		apiKey.Name,
 		apiKey.Scopes,
 		apiKey.IsActive,
+		apiKey.Session,
+		apiKey.IP,
+		apiKey.UserAgent,
+		apiKey.Public,
+		apiKey.WebsiteID,
+		apiKey.AllowedOrigins,
+		apiKey.RateLimitPerMin,
 		apiKey.ExpiresAt,
 		apiKey.CreatedAt,
 		apiKey.UpdatedAt,
@@ -62,7 +73,7 @@ func (r *APIKeyRepository) Create(ctx context.Context, apiKey *schema.APIKey) er
 // GetByID retrieves an API key by ID
 func (r *APIKeyRepository) GetByID(ctx context.Context, id ulid.ULID) (*schema.APIKey, error) {
 	query := `
-		SELECT id, user_id, key_hash, key_prefix, name, scopes, is_active, last_used_at, expires_at, created_at, updated_at
+		SELECT id, user_id, key_hash, key_prefix, name, scopes, is_active, session, ip, user_agent, public, website_id, allowed_origins, rate_limit_per_min, last_used_at, expires_at, created_at, updated_at
 		FROM api_keys
 		WHERE id = $1
 	`
@@ -71,7 +82,7 @@ func (r *APIKeyRepository) GetByID(ctx context.Context, id ulid.ULID) (*schema.A
 	err := r.db.GetContext(ctx, &apiKey, query, id.String())
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("API key not found")
+			return nil, apperrors.NotFound("API key not found")
 		}
 		return nil, fmt.Errorf("failed to get API key: %w", err)
 	}
@@ -82,7 +93,7 @@ func (r *APIKeyRepository) GetByID(ctx context.Context, id ulid.ULID) (*schema.A
 // GetByKeyHash retrieves an API key by its hash
 func (r *APIKeyRepository) GetByKeyHash(ctx context.Context, keyHash string) (*schema.APIKey, error) {
 	query := `
-		SELECT id, user_id, key_hash, key_prefix, name, scopes, is_active, last_used_at, expires_at, created_at, updated_at
+		SELECT id, user_id, key_hash, key_prefix, name, scopes, is_active, session, ip, user_agent, public, website_id, allowed_origins, rate_limit_per_min, last_used_at, expires_at, created_at, updated_at
 		FROM api_keys
 		WHERE key_hash = $1
 	`
@@ -91,7 +102,7 @@ func (r *APIKeyRepository) GetByKeyHash(ctx context.Context, keyHash string) (*s
 	err := r.db.GetContext(ctx, &apiKey, query, keyHash)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("API key not found")
+			return nil, apperrors.NotFound("API key not found")
 		}
 		return nil, fmt.Errorf("failed to get API key: %w", err)
 	}
@@ -102,7 +113,7 @@ func (r *APIKeyRepository) GetByKeyHash(ctx context.Context, keyHash string) (*s
 // GetByUserID retrieves all API keys for a user
 func (r *APIKeyRepository) GetByUserID(ctx context.Context, userID ulid.ULID) ([]*schema.APIKey, error) {
 	query := `
-		SELECT id, user_id, key_hash, key_prefix, name, scopes, is_active, last_used_at, expires_at, created_at, updated_at
+		SELECT id, user_id, key_hash, key_prefix, name, scopes, is_active, session, ip, user_agent, public, website_id, allowed_origins, rate_limit_per_min, last_used_at, expires_at, created_at, updated_at
 		FROM api_keys
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -141,7 +152,7 @@ func (r *APIKeyRepository) Update(ctx context.Context, apiKey *schema.APIKey) er
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return fmt.Errorf("API key not found")
+			return apperrors.NotFound("API key not found")
 		}
 		return fmt.Errorf("failed to update API key: %w", err)
 	}
@@ -180,7 +191,7 @@ func (r *APIKeyRepository) Delete(ctx context.Context, id ulid.ULID) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("API key not found")
+		return apperrors.NotFound("API key not found")
 	}
 
 	return nil
@@ -212,7 +223,7 @@ func (r *APIKeyRepository) List(ctx context.Context, page, limit int) ([]*schema
 
 	// Get API keys
 	query := `
-		SELECT id, user_id, key_hash, key_prefix, name, scopes, is_active, last_used_at, expires_at, created_at, updated_at
+		SELECT id, user_id, key_hash, key_prefix, name, scopes, is_active, session, ip, user_agent, public, website_id, allowed_origins, rate_limit_per_min, last_used_at, expires_at, created_at, updated_at
 		FROM api_keys
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -243,3 +254,53 @@ func (r *APIKeyRepository) CleanupExpired(ctx context.Context) (int64, error) {
 
 	return rowsAffected, nil
 }
+
+// ListSessions retrieves the active, unexpired web sessions of a user, most
+// recently used first
+func (r *APIKeyRepository) ListSessions(ctx context.Context, userID ulid.ULID) ([]*schema.APIKey, error) {
+	query := `
+		SELECT id, user_id, key_hash, key_prefix, name, scopes, is_active, session, ip, user_agent, public, website_id, allowed_origins, rate_limit_per_min, last_used_at, expires_at, created_at, updated_at
+		FROM api_keys
+		WHERE user_id = $1 AND session AND is_active AND (expires_at IS NULL OR expires_at > $2)
+		ORDER BY COALESCE(last_used_at, created_at) DESC
+	`
+
+	var sessions []*schema.APIKey
+	err := r.db.SelectContext(ctx, &sessions, query, userID.String(), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteSessions deletes the web sessions of a user except exceptID, which
+// may be nil. It returns the number of sessions deleted
+func (r *APIKeyRepository) DeleteSessions(ctx context.Context, userID ulid.ULID, exceptID *ulid.ULID) (int64, error) {
+	var except *string
+	if exceptID != nil {
+		id := exceptID.String()
+		except = &id
+	}
+
+	query := `DELETE FROM api_keys WHERE user_id = $1 AND session AND ($2::text IS NULL OR id <> $2)`
+
+	result, err := r.db.ExecContext(ctx, query, userID.String(), except)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteExpiredSessions deletes the expired web sessions of a user
+func (r *APIKeyRepository) DeleteExpiredSessions(ctx context.Context, userID ulid.ULID) (int64, error) {
+	query := `DELETE FROM api_keys WHERE user_id = $1 AND session AND expires_at < $2`
+
+	result, err := r.db.ExecContext(ctx, query, userID.String(), time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
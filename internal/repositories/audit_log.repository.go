@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"hermit/internal/schema"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// auditLogColumns lists the columns selected for a schema.AuditLog.
+const auditLogColumns = `id, actor_user_id, actor_email, api_key_id, action, resource_type, resource_id,
+		before, after, details, status, ip, user_agent, request_id, created_at`
+
+// AuditLogRepository handles database operations for the audit log.
+type AuditLogRepository struct {
+	db *sqlx.DB
+}
+
+// NewAuditLogRepository creates a new AuditLogRepository.
+func NewAuditLogRepository(db *sqlx.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// Create records an audit log entry.
+func (r *AuditLogRepository) Create(ctx context.Context, entry *schema.AuditLog) error {
+	query := `
+		INSERT INTO audit_log (actor_user_id, actor_email, api_key_id, action, resource_type, resource_id,
+			before, after, details, status, ip, user_agent, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowxContext(ctx, query,
+		ulidString(entry.ActorUserID),
+		entry.ActorEmail,
+		ulidString(entry.APIKeyID),
+		entry.Action,
+		entry.ResourceType,
+		entry.ResourceID,
+		entry.Before,
+		entry.After,
+		entry.Details,
+		entry.Status,
+		entry.IP,
+		entry.UserAgent,
+		entry.RequestID,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record audit log entry: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves the audit log entries matching the filter, most recent
+// first, with the total number of matching entries.
+func (r *AuditLogRepository) List(ctx context.Context, filter schema.AuditLogFilter, limit, offset int) ([]schema.AuditLog, int, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.ActorUserID != nil {
+		where("actor_user_id = $%d", filter.ActorUserID.String())
+	}
+	if filter.Action != "" {
+		where("action = $%d", filter.Action)
+	}
+	if filter.ResourceType != "" {
+		where("resource_type = $%d", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		where("resource_id = $%d", filter.ResourceID)
+	}
+	if filter.Since != nil {
+		where("created_at >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		where("created_at < $%d", *filter.Until)
+	}
+
+	clause := ""
+	if len(conditions) > 0 {
+		clause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM audit_log `+clause, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit log entries: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM audit_log
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, auditLogColumns, clause, len(args)+1, len(args)+2)
+
+	entries := []schema.AuditLog{}
+	if err := r.db.SelectContext(ctx, &entries, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit log entries: %w", err)
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"hermit/internal/schema"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CrawlEventRepository handles database operations for crawl events.
+type CrawlEventRepository struct {
+	db *sqlx.DB
+}
+
+// NewCrawlEventRepository creates a new CrawlEventRepository.
+func NewCrawlEventRepository(db *sqlx.DB) *CrawlEventRepository {
+	return &CrawlEventRepository{db: db}
+}
+
+// Create records a crawl event.
+func (r *CrawlEventRepository) Create(ctx context.Context, event *schema.CrawlEvent) error {
+	query := `
+		INSERT INTO crawl_events (website_id, url, level, event, reason)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowxContext(ctx, query,
+		event.WebsiteID,
+		event.URL,
+		event.Level,
+		event.Event,
+		event.Reason,
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create crawl event: %w", err)
+	}
+
+	return nil
+}
+
+// LatestID returns the ID of the most recent crawl event of a website, 0
+// when it has none.
+func (r *CrawlEventRepository) LatestID(ctx context.Context, websiteID uint) (uint, error) {
+	var id uint
+	err := r.db.GetContext(ctx, &id, `SELECT COALESCE(MAX(id), 0) FROM crawl_events WHERE website_id = $1`, websiteID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest crawl event: %w", err)
+	}
+	return id, nil
+}
+
+// ListByWebsiteID retrieves crawl events for a website, most recent first,
+// optionally filtered by level and event type. It also returns the total
+// number of matching events.
+func (r *CrawlEventRepository) ListByWebsiteID(ctx context.Context, websiteID uint, level, event string, limit, offset int) ([]schema.CrawlEvent, int, error) {
+	where := `website_id = $1 AND ($2 = '' OR level = $2) AND ($3 = '' OR event = $3)`
+
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM crawl_events WHERE `+where, websiteID, level, event)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count crawl events: %w", err)
+	}
+
+	query := `
+		SELECT id, website_id, url, level, event, COALESCE(reason, '') AS reason, created_at
+		FROM crawl_events
+		WHERE ` + where + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	var events []schema.CrawlEvent
+	err = r.db.SelectContext(ctx, &events, query, websiteID, level, event, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list crawl events: %w", err)
+	}
+
+	return events, total, nil
+}
@@ -0,0 +1,259 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"hermit/internal/schema"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// EvalPassScore is the score a result needs to count as passed in a run's
+// pass rate.
+const EvalPassScore = 0.5
+
+// EvaluationRepository handles database operations for RAG evaluation cases,
+// runs and results.
+type EvaluationRepository struct {
+	db *sqlx.DB
+}
+
+// NewEvaluationRepository creates a new EvaluationRepository.
+func NewEvaluationRepository(db *sqlx.DB) *EvaluationRepository {
+	return &EvaluationRepository{db: db}
+}
+
+// ReplaceCases replaces the evaluation cases of a website and returns the
+// stored cases.
+func (r *EvaluationRepository) ReplaceCases(ctx context.Context, websiteID uint, cases []schema.EvalCase) ([]schema.EvalCase, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM eval_cases WHERE website_id = $1`, websiteID); err != nil {
+		return nil, fmt.Errorf("failed to delete evaluation cases: %w", err)
+	}
+
+	query := `
+		INSERT INTO eval_cases (website_id, question, expected_answer)
+		VALUES ($1, $2, $3)
+		RETURNING id, website_id, question, expected_answer, created_at
+	`
+
+	stored := make([]schema.EvalCase, 0, len(cases))
+	for _, c := range cases {
+		var created schema.EvalCase
+		if err := tx.GetContext(ctx, &created, query, websiteID, c.Question, c.ExpectedAnswer); err != nil {
+			return nil, fmt.Errorf("failed to create evaluation case: %w", err)
+		}
+		stored = append(stored, created)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit evaluation cases: %w", err)
+	}
+
+	return stored, nil
+}
+
+// ListCases retrieves the evaluation cases of a website in upload order.
+func (r *EvaluationRepository) ListCases(ctx context.Context, websiteID uint) ([]schema.EvalCase, error) {
+	query := `
+		SELECT id, website_id, question, expected_answer, created_at
+		FROM eval_cases
+		WHERE website_id = $1
+		ORDER BY id
+	`
+
+	cases := []schema.EvalCase{}
+	if err := r.db.SelectContext(ctx, &cases, query, websiteID); err != nil {
+		return nil, fmt.Errorf("failed to list evaluation cases: %w", err)
+	}
+
+	return cases, nil
+}
+
+// CountCases returns the number of evaluation cases of a website.
+func (r *EvaluationRepository) CountCases(ctx context.Context, websiteID uint) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM eval_cases WHERE website_id = $1`, websiteID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count evaluation cases: %w", err)
+	}
+	return count, nil
+}
+
+// evalRunColumns are the columns selected for an EvalRun.
+const evalRunColumns = `id, website_id, status, scorer, settings, cases, answered, mean_score, pass_rate,
+	mean_latency_ms, error, created_at, started_at, completed_at`
+
+// CreateRun creates a pending evaluation run of a website's cases.
+func (r *EvaluationRepository) CreateRun(ctx context.Context, websiteID uint, scorer string, cases int) (*schema.EvalRun, error) {
+	query := `
+		INSERT INTO eval_runs (website_id, status, scorer, cases)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + evalRunColumns
+
+	var run schema.EvalRun
+	if err := r.db.GetContext(ctx, &run, query, websiteID, schema.EvalRunPending, scorer, cases); err != nil {
+		return nil, fmt.Errorf("failed to create evaluation run: %w", err)
+	}
+
+	return &run, nil
+}
+
+// GetRun retrieves an evaluation run by ID.
+func (r *EvaluationRepository) GetRun(ctx context.Context, id uint) (*schema.EvalRun, error) {
+	query := `SELECT ` + evalRunColumns + ` FROM eval_runs WHERE id = $1`
+
+	var run schema.EvalRun
+	err := r.db.GetContext(ctx, &run, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get evaluation run: %w", err)
+	}
+
+	return &run, nil
+}
+
+// ListRuns retrieves the evaluation runs of a website, most recent first. It
+// also returns the total number of runs.
+func (r *EvaluationRepository) ListRuns(ctx context.Context, websiteID uint, limit, offset int) ([]schema.EvalRun, int, error) {
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM eval_runs WHERE website_id = $1`, websiteID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count evaluation runs: %w", err)
+	}
+
+	query := `
+		SELECT ` + evalRunColumns + `
+		FROM eval_runs
+		WHERE website_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	runs := []schema.EvalRun{}
+	if err := r.db.SelectContext(ctx, &runs, query, websiteID, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to list evaluation runs: %w", err)
+	}
+
+	return runs, total, nil
+}
+
+// StartRun marks a run as running with the retrieval settings it uses and
+// the number of cases it evaluates. Results of an earlier attempt of the run
+// are deleted.
+func (r *EvaluationRepository) StartRun(ctx context.Context, id uint, settings schema.JSONRaw, cases int) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM eval_results WHERE run_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete evaluation results: %w", err)
+	}
+
+	query := `
+		UPDATE eval_runs
+		SET status = $2, settings = $3, cases = $4, started_at = NOW(), completed_at = NULL, error = NULL
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, schema.EvalRunRunning, settings, cases); err != nil {
+		return fmt.Errorf("failed to start evaluation run: %w", err)
+	}
+
+	return nil
+}
+
+// AddResult records the result of a case in a run.
+func (r *EvaluationRepository) AddResult(ctx context.Context, result *schema.EvalResult) error {
+	query := `
+		INSERT INTO eval_results (run_id, case_id, question, expected_answer, answer, score, retrieved_chunks, degraded, latency_ms, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowxContext(ctx, query,
+		result.RunID,
+		result.CaseID,
+		result.Question,
+		result.ExpectedAnswer,
+		result.Answer,
+		result.Score,
+		result.RetrievedChunks,
+		result.Degraded,
+		result.LatencyMS,
+		result.Error,
+	).Scan(&result.ID, &result.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create evaluation result: %w", err)
+	}
+
+	return nil
+}
+
+// CompleteRun marks a run as completed and computes its metrics from its
+// results. Results that errored count as a score of 0.
+func (r *EvaluationRepository) CompleteRun(ctx context.Context, id uint) error {
+	query := `
+		UPDATE eval_runs
+		SET status = $2,
+			completed_at = NOW(),
+			answered = stats.answered,
+			mean_score = stats.mean_score,
+			pass_rate = stats.pass_rate,
+			mean_latency_ms = stats.mean_latency_ms
+		FROM (
+			SELECT
+				COUNT(*) FILTER (WHERE retrieved_chunks > 0 AND error IS NULL) AS answered,
+				AVG(score) AS mean_score,
+				AVG(CASE WHEN score >= $3 THEN 1.0 ELSE 0.0 END) AS pass_rate,
+				AVG(latency_ms) FILTER (WHERE error IS NULL) AS mean_latency_ms
+			FROM eval_results
+			WHERE run_id = $1
+		) AS stats
+		WHERE eval_runs.id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, schema.EvalRunCompleted, EvalPassScore); err != nil {
+		return fmt.Errorf("failed to complete evaluation run: %w", err)
+	}
+
+	return nil
+}
+
+// FailRun marks a run as failed with the reason.
+func (r *EvaluationRepository) FailRun(ctx context.Context, id uint, reason string) error {
+	query := `
+		UPDATE eval_runs
+		SET status = $2, error = $3, completed_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, schema.EvalRunFailed, reason); err != nil {
+		return fmt.Errorf("failed to fail evaluation run: %w", err)
+	}
+
+	return nil
+}
+
+// ListResults retrieves the results of a run in case order.
+func (r *EvaluationRepository) ListResults(ctx context.Context, runID uint) ([]schema.EvalResult, error) {
+	query := `
+		SELECT id, run_id, case_id, question, expected_answer, answer, score, retrieved_chunks, degraded,
+			latency_ms, error, created_at
+		FROM eval_results
+		WHERE run_id = $1
+		ORDER BY id
+	`
+
+	results := []schema.EvalResult{}
+	if err := r.db.SelectContext(ctx, &results, query, runID); err != nil {
+		return nil, fmt.Errorf("failed to list evaluation results: %w", err)
+	}
+
+	return results, nil
+}
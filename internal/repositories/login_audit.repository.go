@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"hermit/internal/schema"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oklog/ulid/v2"
+)
+
+// LoginAuditRepository handles database operations for the login audit.
+type LoginAuditRepository struct {
+	db *sqlx.DB
+}
+
+// NewLoginAuditRepository creates a new LoginAuditRepository.
+func NewLoginAuditRepository(db *sqlx.DB) *LoginAuditRepository {
+	return &LoginAuditRepository{db: db}
+}
+
+// Create records a login attempt.
+func (r *LoginAuditRepository) Create(ctx context.Context, attempt *schema.LoginAttempt) error {
+	query := `
+		INSERT INTO login_audit (user_id, email, ip, user_agent, success, reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowxContext(ctx, query,
+		ulidString(attempt.UserID),
+		attempt.Email,
+		attempt.IP,
+		attempt.UserAgent,
+		attempt.Success,
+		attempt.Reason,
+	).Scan(&attempt.ID, &attempt.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	return nil
+}
+
+// ListByUserID retrieves the most recent login attempts on a user's account,
+// most recent first.
+func (r *LoginAuditRepository) ListByUserID(ctx context.Context, userID ulid.ULID, limit int) ([]schema.LoginAttempt, error) {
+	query := `
+		SELECT id, user_id, email, ip, user_agent, success, reason, created_at
+		FROM login_audit
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	attempts := []schema.LoginAttempt{}
+	if err := r.db.SelectContext(ctx, &attempts, query, userID.String(), limit); err != nil {
+		return nil, fmt.Errorf("failed to list login attempts: %w", err)
+	}
+
+	return attempts, nil
+}
@@ -3,12 +3,20 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"hermit/internal/schema"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// pageColumns lists the columns selected for a schema.Page.
+const pageColumns = `id, website_id, url, minio_object_key, content_hash, status, error_message, title, snippet, http_status, retry_count, skip_reason, crawled_at, created_at, updated_at,
+	summary, summary_content_hash, summarized_at, images, screenshot_key, screenshot_at,
+	html_object_key, html_archived_at, vector_status, vector_error, chunk_count, vectorized_at`
+
 // PageRepository handles database operations for pages.
 type PageRepository struct {
 	db *sqlx.DB
@@ -29,8 +37,7 @@ func (r *PageRepository) Create(ctx context.Context, websiteID uint, url string)
 	query := `
 		INSERT INTO pages (website_id, url, normalized_url, status)
 		VALUES ($1, $2, $2, $3)
-		RETURNING id, website_id, url, minio_object_key, content_hash, status, error_message, crawled_at, created_at, updated_at
-	`
+		RETURNING ` + pageColumns
 
 	var page schema.Page
 	err := r.db.QueryRowxContext(ctx, query, websiteID, url, "pending").StructScan(&page)
@@ -48,8 +55,7 @@ func (r *PageRepository) Upsert(ctx context.Context, websiteID uint, url string)
 		VALUES ($1, $2, $2, $3)
 		ON CONFLICT (website_id, normalized_url)
 		DO UPDATE SET url = EXCLUDED.url, updated_at = NOW()
-		RETURNING id, website_id, url, minio_object_key, content_hash, status, error_message, crawled_at, created_at, updated_at
-	`
+		RETURNING ` + pageColumns
 
 	var page schema.Page
 	err := r.db.QueryRowxContext(ctx, query, websiteID, url, "pending").StructScan(&page)
@@ -60,19 +66,28 @@ func (r *PageRepository) Upsert(ctx context.Context, websiteID uint, url string)
 	return &page, nil
 }
 
-// UpdateSuccess updates a page with successful crawl data.
-func (r *PageRepository) UpdateSuccess(ctx context.Context, pageID uint, minioObjectKey, contentHash string) error {
+// UpdateSuccess updates a page with successful crawl data along with the
+// title and snippet used for previews.
+func (r *PageRepository) UpdateSuccess(ctx context.Context, pageID uint, minioObjectKey, contentHash, title, snippet string) error {
 	query := `
 		UPDATE pages
 		SET minio_object_key = $1,
 		    content_hash = $2,
 		    status = $3,
 		    crawled_at = $4,
+		    title = NULLIF($5, ''),
+		    snippet = NULLIF($6, ''),
+		    error_message = NULL,
+		    http_status = NULL,
+		    retry_count = 0,
+		    skip_reason = NULL,
+		    vector_status = $7,
+		    vector_error = NULL,
 		    updated_at = NOW()
-		WHERE id = $5
+		WHERE id = $8
 	`
 
-	_, err := r.db.ExecContext(ctx, query, minioObjectKey, contentHash, "success", time.Now(), pageID)
+	_, err := r.db.ExecContext(ctx, query, minioObjectKey, contentHash, "success", time.Now(), title, snippet, schema.VectorStatusPending, pageID)
 	return err
 }
 
@@ -90,11 +105,149 @@ func (r *PageRepository) UpdateError(ctx context.Context, pageID uint, errorMess
 	return err
 }
 
+// RecordFetchError creates or updates the page of a URL that could not be
+// fetched. statusCode is the HTTP status of the response, or 0 when no
+// response was received.
+func (r *PageRepository) RecordFetchError(ctx context.Context, websiteID uint, url string, statusCode int, errorMessage string) (*schema.Page, error) {
+	query := `
+		INSERT INTO pages (website_id, url, normalized_url, status, error_message, http_status)
+		VALUES ($1, $2, $2, $3, $4, $5)
+		ON CONFLICT (website_id, normalized_url)
+		DO UPDATE SET status = EXCLUDED.status,
+		              error_message = EXCLUDED.error_message,
+		              http_status = EXCLUDED.http_status,
+		              skip_reason = NULL,
+		              updated_at = NOW()
+		RETURNING ` + pageColumns
+
+	var page schema.Page
+	err := r.db.QueryRowxContext(ctx, query, websiteID, url, "error", errorMessage, statusCode).StructScan(&page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record fetch error: %w", err)
+	}
+
+	return &page, nil
+}
+
+// RecordSkipped creates or updates the page of a discovered URL that was not
+// indexed, with a machine-readable reason and a human-readable detail. Pages
+// that were indexed before keep their content and status.
+func (r *PageRepository) RecordSkipped(ctx context.Context, websiteID uint, url, reason, detail string) error {
+	query := `
+		INSERT INTO pages (website_id, url, normalized_url, status, skip_reason, error_message)
+		VALUES ($1, $2, $2, $3, $4, $5)
+		ON CONFLICT (website_id, normalized_url)
+		DO UPDATE SET status = EXCLUDED.status,
+		              skip_reason = EXCLUDED.skip_reason,
+		              error_message = EXCLUDED.error_message,
+		              updated_at = NOW()
+		WHERE pages.status <> 'success'
+	`
+
+	_, err := r.db.ExecContext(ctx, query, websiteID, url, schema.PageStatusSkipped, reason, detail)
+	if err != nil {
+		return fmt.Errorf("failed to record skipped page: %w", err)
+	}
+	return nil
+}
+
+// IncrementRetryCount records that a retry of a failed page was scheduled.
+func (r *PageRepository) IncrementRetryCount(ctx context.Context, pageID uint) error {
+	query := `UPDATE pages SET retry_count = retry_count + 1, updated_at = NOW() WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, pageID)
+	return err
+}
+
+// CountErrorsByHTTPStatus returns the number of failed pages of a website per
+// HTTP status, with 0 counting network errors.
+func (r *PageRepository) CountErrorsByHTTPStatus(ctx context.Context, websiteID uint) (map[int]int, error) {
+	var rows []struct {
+		HTTPStatus int `db:"http_status"`
+		Count      int `db:"count"`
+	}
+	query := `
+		SELECT http_status, COUNT(*) AS count
+		FROM pages
+		WHERE website_id = $1 AND status = 'error' AND http_status IS NOT NULL
+		GROUP BY http_status
+	`
+
+	if err := r.db.SelectContext(ctx, &rows, query, websiteID); err != nil {
+		return nil, fmt.Errorf("failed to count page errors: %w", err)
+	}
+
+	counts := make(map[int]int, len(rows))
+	for _, row := range rows {
+		counts[row.HTTPStatus] = row.Count
+	}
+
+	return counts, nil
+}
+
+// CountByStatus returns the number of pages of a website per status. When
+// since is not zero only pages updated after it are counted, which gives the
+// progress of the crawl started at that time.
+func (r *PageRepository) CountByStatus(ctx context.Context, websiteID uint, since time.Time) (map[string]int, error) {
+	var rows []struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}
+	query := `
+		SELECT status, COUNT(*) AS count
+		FROM pages
+		WHERE website_id = $1 AND ($2::timestamptz IS NULL OR updated_at >= $2)
+		GROUP BY status
+	`
+
+	var sinceArg *time.Time
+	if !since.IsZero() {
+		sinceArg = &since
+	}
+
+	if err := r.db.SelectContext(ctx, &rows, query, websiteID, sinceArg); err != nil {
+		return nil, fmt.Errorf("failed to count pages by status: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+
+	return counts, nil
+}
+
+// CountAllByStatusSince returns the number of pages of all websites per
+// status that were updated after since.
+func (r *PageRepository) CountAllByStatusSince(ctx context.Context, since time.Time) (map[string]int, error) {
+	var rows []struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}
+	query := `
+		SELECT status, COUNT(*) AS count
+		FROM pages
+		WHERE updated_at >= $1
+		GROUP BY status
+	`
+
+	if err := r.db.SelectContext(ctx, &rows, query, since); err != nil {
+		return nil, fmt.Errorf("failed to count pages by status: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+
+	return counts, nil
+}
+
 // GetByWebsiteID retrieves all pages for a specific website.
 func (r *PageRepository) GetByWebsiteID(ctx context.Context, websiteID uint) ([]schema.Page, error) {
 	var pages []schema.Page
 	query := `
-		SELECT id, website_id, url, minio_object_key, content_hash, status, error_message, crawled_at, created_at, updated_at
+		SELECT ` + pageColumns + `
 		FROM pages
 		WHERE website_id = $1
 		ORDER BY created_at DESC
@@ -108,11 +261,31 @@ func (r *PageRepository) GetByWebsiteID(ctx context.Context, websiteID uint) ([]
 	return pages, nil
 }
 
+// GetByID retrieves a page by ID. It returns nil when the page doesn't exist.
+func (r *PageRepository) GetByID(ctx context.Context, id uint) (*schema.Page, error) {
+	var page schema.Page
+	query := `
+		SELECT ` + pageColumns + `
+		FROM pages
+		WHERE id = $1
+	`
+
+	err := r.db.QueryRowxContext(ctx, query, id).StructScan(&page)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get page: %w", err)
+	}
+
+	return &page, nil
+}
+
 // GetByURL retrieves a page by website ID and URL.
 func (r *PageRepository) GetByURL(ctx context.Context, websiteID uint, url string) (*schema.Page, error) {
 	var page schema.Page
 	query := `
-		SELECT id, website_id, url, minio_object_key, content_hash, status, error_message, crawled_at, created_at, updated_at
+		SELECT ` + pageColumns + `
 		FROM pages
 		WHERE website_id = $1 AND url = $2
 	`
@@ -132,7 +305,7 @@ func (r *PageRepository) GetByURL(ctx context.Context, websiteID uint, url strin
 func (r *PageRepository) List(ctx context.Context) ([]schema.Page, error) {
 	var pages []schema.Page
 	query := `
-		SELECT id, website_id, url, minio_object_key, content_hash, status, error_message, crawled_at, created_at, updated_at
+		SELECT ` + pageColumns + `
 		FROM pages
 		ORDER BY created_at DESC
 	`
@@ -144,3 +317,527 @@ func (r *PageRepository) List(ctx context.Context) ([]schema.Page, error) {
 
 	return pages, nil
 }
+
+// ListIDsByWebsiteID returns the IDs of all pages of a website.
+func (r *PageRepository) ListIDsByWebsiteID(ctx context.Context, websiteID uint) ([]uint, error) {
+	var ids []uint
+	query := `SELECT id FROM pages WHERE website_id = $1`
+
+	err := r.db.SelectContext(ctx, &ids, query, websiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ListObjectKeys returns the storage object keys referenced by any page,
+// including screenshots and archived HTML.
+func (r *PageRepository) ListObjectKeys(ctx context.Context) ([]string, error) {
+	var keys []string
+	query := `
+		SELECT minio_object_key FROM pages WHERE minio_object_key IS NOT NULL
+		UNION ALL
+		SELECT screenshot_key FROM pages WHERE screenshot_key IS NOT NULL
+		UNION ALL
+		SELECT html_object_key FROM pages WHERE html_object_key IS NOT NULL
+	`
+
+	err := r.db.SelectContext(ctx, &keys, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// ListCrawledBefore retrieves pages last crawled before the given time.
+// When websiteID is zero, pages of all websites are returned.
+func (r *PageRepository) ListCrawledBefore(ctx context.Context, websiteID uint, before time.Time) ([]schema.Page, error) {
+	var pages []schema.Page
+	query := `
+		SELECT ` + pageColumns + `
+		FROM pages
+		WHERE crawled_at < $1 AND ($2 = 0 OR website_id = $2)
+		ORDER BY crawled_at ASC
+	`
+
+	err := r.db.SelectContext(ctx, &pages, query, before, websiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+// GetCrawlTimes returns when the successfully crawled pages of a website
+// were last crawled, keyed by URL.
+func (r *PageRepository) GetCrawlTimes(ctx context.Context, websiteID uint) (map[string]time.Time, error) {
+	var rows []struct {
+		URL       string    `db:"url"`
+		CrawledAt time.Time `db:"crawled_at"`
+	}
+	query := `
+		SELECT url, crawled_at
+		FROM pages
+		WHERE website_id = $1 AND status = 'success' AND crawled_at IS NOT NULL
+	`
+
+	if err := r.db.SelectContext(ctx, &rows, query, websiteID); err != nil {
+		return nil, fmt.Errorf("failed to get page crawl times: %w", err)
+	}
+
+	times := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		times[row.URL] = row.CrawledAt
+	}
+
+	return times, nil
+}
+
+// TouchCrawled records the given pages of a website as crawled now without
+// changing their content, for pages known to be unchanged.
+func (r *PageRepository) TouchCrawled(ctx context.Context, websiteID uint, urls []string) error {
+	query := `
+		UPDATE pages
+		SET crawled_at = NOW(),
+		    updated_at = NOW()
+		WHERE website_id = $1 AND url = ANY($2)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, websiteID, urls)
+	return err
+}
+
+// ListArchived retrieves the pages of a website with archived raw HTML.
+func (r *PageRepository) ListArchived(ctx context.Context, websiteID uint) ([]schema.Page, error) {
+	var pages []schema.Page
+	query := `
+		SELECT ` + pageColumns + `
+		FROM pages
+		WHERE website_id = $1 AND html_object_key IS NOT NULL
+		ORDER BY id ASC
+	`
+
+	err := r.db.SelectContext(ctx, &pages, query, websiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+// MarkPurged clears the stored content of a page after it was deleted from storage.
+func (r *PageRepository) MarkPurged(ctx context.Context, pageID uint) error {
+	query := `
+		UPDATE pages
+		SET minio_object_key = NULL,
+		    content_hash = NULL,
+		    status = $1,
+		    updated_at = NOW()
+		WHERE id = $2
+	`
+
+	_, err := r.db.ExecContext(ctx, query, "purged", pageID)
+	return err
+}
+
+// SetVectorized records that the chunks of a page's content were stored.
+func (r *PageRepository) SetVectorized(ctx context.Context, pageID uint, chunks int) error {
+	query := `
+		UPDATE pages
+		SET vector_status = $1,
+		    vector_error = NULL,
+		    chunk_count = $2,
+		    vectorized_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $3
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, schema.VectorStatusVectorized, chunks, pageID); err != nil {
+		return fmt.Errorf("failed to set page vector status: %w", err)
+	}
+	return nil
+}
+
+// SetVectorFailed records that vectorizing a page's content failed.
+func (r *PageRepository) SetVectorFailed(ctx context.Context, pageID uint, errorMessage string) error {
+	query := `
+		UPDATE pages
+		SET vector_status = $1,
+		    vector_error = $2,
+		    updated_at = NOW()
+		WHERE id = $3
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, schema.VectorStatusFailed, errorMessage, pageID); err != nil {
+		return fmt.Errorf("failed to set page vector status: %w", err)
+	}
+	return nil
+}
+
+// ClearVectorStatus records that the chunks of a page were deleted.
+func (r *PageRepository) ClearVectorStatus(ctx context.Context, pageID uint) error {
+	query := `
+		UPDATE pages
+		SET vector_status = NULL,
+		    vector_error = NULL,
+		    chunk_count = NULL,
+		    vectorized_at = NULL,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, pageID); err != nil {
+		return fmt.Errorf("failed to clear page vector status: %w", err)
+	}
+	return nil
+}
+
+// ListByVectorStatus returns the pages of a website with stored content and
+// the given vectorization status.
+func (r *PageRepository) ListByVectorStatus(ctx context.Context, websiteID uint, status string) ([]schema.Page, error) {
+	var pages []schema.Page
+	query := `
+		SELECT ` + pageColumns + `
+		FROM pages
+		WHERE website_id = $1 AND vector_status = $2 AND minio_object_key IS NOT NULL
+		ORDER BY id
+	`
+
+	if err := r.db.SelectContext(ctx, &pages, query, websiteID, status); err != nil {
+		return nil, fmt.Errorf("failed to list pages by vector status: %w", err)
+	}
+	return pages, nil
+}
+
+// SetSummary stores the LLM summary of a page along with the content hash
+// it was generated from.
+func (r *PageRepository) SetSummary(ctx context.Context, pageID uint, summary, contentHash string) error {
+	query := `
+		UPDATE pages
+		SET summary = $1,
+		    summary_content_hash = $2,
+		    summarized_at = NOW()
+		WHERE id = $3
+	`
+
+	_, err := r.db.ExecContext(ctx, query, summary, contentHash, pageID)
+	if err != nil {
+		return fmt.Errorf("failed to set page summary: %w", err)
+	}
+	return nil
+}
+
+// SetImages replaces the images stored with a page.
+func (r *PageRepository) SetImages(ctx context.Context, pageID uint, images schema.PageImages) error {
+	query := `UPDATE pages SET images = $1 WHERE id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, images, pageID); err != nil {
+		return fmt.Errorf("failed to set page images: %w", err)
+	}
+	return nil
+}
+
+// SetScreenshot records the storage key of a page's latest screenshot.
+func (r *PageRepository) SetScreenshot(ctx context.Context, pageID uint, objectKey string) error {
+	query := `UPDATE pages SET screenshot_key = $1, screenshot_at = NOW() WHERE id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, objectKey, pageID); err != nil {
+		return fmt.Errorf("failed to set page screenshot: %w", err)
+	}
+	return nil
+}
+
+// SetHTMLArchive records the storage key of the archived raw HTML of a page.
+func (r *PageRepository) SetHTMLArchive(ctx context.Context, pageID uint, objectKey string) error {
+	query := `UPDATE pages SET html_object_key = $1, html_archived_at = NOW() WHERE id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, objectKey, pageID); err != nil {
+		return fmt.Errorf("failed to set page HTML archive: %w", err)
+	}
+	return nil
+}
+
+// SetPIIRedactions records the number of redactions of each kind of
+// personal data in a page's content.
+func (r *PageRepository) SetPIIRedactions(ctx context.Context, pageID uint, counts map[string]int) error {
+	encoded, err := json.Marshal(counts)
+	if err != nil {
+		return fmt.Errorf("failed to encode PII redactions: %w", err)
+	}
+
+	query := `UPDATE pages SET pii_redactions = $1 WHERE id = $2`
+	if _, err := r.db.ExecContext(ctx, query, encoded, pageID); err != nil {
+		return fmt.Errorf("failed to set PII redactions: %w", err)
+	}
+	return nil
+}
+
+// ClearPIIRedactions resets the redaction counts of a website's pages.
+func (r *PageRepository) ClearPIIRedactions(ctx context.Context, websiteID uint) error {
+	query := `UPDATE pages SET pii_redactions = '{}' WHERE website_id = $1 AND pii_redactions <> '{}'`
+	if _, err := r.db.ExecContext(ctx, query, websiteID); err != nil {
+		return fmt.Errorf("failed to clear PII redactions: %w", err)
+	}
+	return nil
+}
+
+// GetPIIReport sums the redaction counts of a website's pages.
+func (r *PageRepository) GetPIIReport(ctx context.Context, websiteID uint) (*schema.PIIReport, error) {
+	report := &schema.PIIReport{ByKind: map[string]int{}}
+
+	query := `
+		SELECT COUNT(*)
+		FROM pages
+		WHERE website_id = $1 AND pii_redactions <> '{}'
+	`
+	if err := r.db.GetContext(ctx, &report.PagesRedacted, query, websiteID); err != nil {
+		return nil, fmt.Errorf("failed to count redacted pages: %w", err)
+	}
+
+	var kinds []struct {
+		Kind  string `db:"kind"`
+		Count int    `db:"count"`
+	}
+	query = `
+		SELECT r.key AS kind, SUM(r.value::int) AS count
+		FROM pages p, jsonb_each_text(p.pii_redactions) r
+		WHERE p.website_id = $1
+		GROUP BY r.key
+	`
+	if err := r.db.SelectContext(ctx, &kinds, query, websiteID); err != nil {
+		return nil, fmt.Errorf("failed to sum PII redactions: %w", err)
+	}
+	for _, kind := range kinds {
+		report.ByKind[kind.Kind] = kind.Count
+		report.Redactions += kind.Count
+	}
+
+	return report, nil
+}
+
+// maxSearchTextBytes limits the text of a page indexed for full-text search,
+// as Postgres rejects tsvectors larger than 1MB.
+const maxSearchTextBytes = 256 * 1024
+
+// SetSearchText indexes the title and text of a page for full-text search.
+// Matches in the title rank higher.
+func (r *PageRepository) SetSearchText(ctx context.Context, pageID uint, title, text string) error {
+	if len(text) > maxSearchTextBytes {
+		text = strings.ToValidUTF8(text[:maxSearchTextBytes], "")
+	}
+
+	query := `
+		UPDATE pages
+		SET search_vector = setweight(to_tsvector('english', $1), 'A') || setweight(to_tsvector('english', $2), 'B')
+		WHERE id = $3
+	`
+	if _, err := r.db.ExecContext(ctx, query, title, text, pageID); err != nil {
+		return fmt.Errorf("failed to index page text: %w", err)
+	}
+	return nil
+}
+
+// Search returns the indexed pages of a website matching a web search style
+// query ("quoted phrases", or, -excluded), best match first, and the total
+// number of matches.
+func (r *PageRepository) Search(ctx context.Context, websiteID uint, q string, limit, offset int) ([]schema.SearchHit, int, error) {
+	var rows []struct {
+		schema.SearchHit
+		Total int `db:"total"`
+	}
+	query := `
+		SELECT p.id, p.url, COALESCE(p.title, '') AS title, COALESCE(p.snippet, '') AS snippet,
+		       COALESCE(p.minio_object_key, '') AS minio_object_key,
+		       ts_rank_cd(p.search_vector, q) AS rank, COUNT(*) OVER () AS total
+		FROM pages p, websearch_to_tsquery('english', $2) q
+		WHERE p.website_id = $1 AND p.status = 'success' AND p.search_vector @@ q
+		ORDER BY rank DESC, p.id
+		LIMIT $3 OFFSET $4
+	`
+	if err := r.db.SelectContext(ctx, &rows, query, websiteID, q, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to search pages: %w", err)
+	}
+
+	hits := make([]schema.SearchHit, len(rows))
+	total := 0
+	for i, row := range rows {
+		hits[i] = row.SearchHit
+		total = row.Total
+	}
+	return hits, total, nil
+}
+
+// Headlines returns the passages of each text matching a search query, the
+// way Postgres ranks them. options are ts_headline options.
+func (r *PageRepository) Headlines(ctx context.Context, q, options string, texts []string) ([]string, error) {
+	headlines := make([]string, 0, len(texts))
+	query := `
+		SELECT ts_headline('english', t.text, websearch_to_tsquery('english', $1), $2)
+		FROM unnest($3::text[]) WITH ORDINALITY AS t(text, n)
+		ORDER BY t.n
+	`
+	if err := r.db.SelectContext(ctx, &headlines, query, q, options, texts); err != nil {
+		return nil, fmt.Errorf("failed to highlight search matches: %w", err)
+	}
+	return headlines, nil
+}
+
+// ListSummaries returns the titles and summaries of a website's summarized
+// pages, at most limit, most recently crawled first.
+func (r *PageRepository) ListSummaries(ctx context.Context, websiteID uint, limit int) ([]schema.Page, error) {
+	var pages []schema.Page
+	query := `
+		SELECT ` + pageColumns + `
+		FROM pages
+		WHERE website_id = $1 AND status = 'success' AND summary IS NOT NULL
+		ORDER BY crawled_at DESC NULLS LAST
+		LIMIT $2
+	`
+
+	if err := r.db.SelectContext(ctx, &pages, query, websiteID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list page summaries: %w", err)
+	}
+
+	return pages, nil
+}
+
+// GetSummaries returns the summaries of the given pages keyed by page ID.
+// Pages without a summary are omitted.
+func (r *PageRepository) GetSummaries(ctx context.Context, ids []int64) (map[uint]string, error) {
+	var rows []struct {
+		ID      uint   `db:"id"`
+		Summary string `db:"summary"`
+	}
+	query := `SELECT id, summary FROM pages WHERE id = ANY($1) AND summary IS NOT NULL`
+
+	if err := r.db.SelectContext(ctx, &rows, query, ids); err != nil {
+		return nil, fmt.Errorf("failed to get page summaries: %w", err)
+	}
+
+	summaries := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		summaries[row.ID] = row.Summary
+	}
+
+	return summaries, nil
+}
+
+// GetTitles returns the stored titles of the given pages keyed by page ID.
+// Pages without a title are omitted.
+func (r *PageRepository) GetTitles(ctx context.Context, ids []int64) (map[uint]string, error) {
+	var rows []struct {
+		ID    uint   `db:"id"`
+		Title string `db:"title"`
+	}
+	query := `SELECT id, title FROM pages WHERE id = ANY($1) AND title IS NOT NULL`
+
+	if err := r.db.SelectContext(ctx, &rows, query, ids); err != nil {
+		return nil, fmt.Errorf("failed to get page titles: %w", err)
+	}
+
+	titles := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		titles[row.ID] = row.Title
+	}
+
+	return titles, nil
+}
+
+// SetTags replaces the tags of a page.
+func (r *PageRepository) SetTags(ctx context.Context, websiteID, pageID uint, tags []string) error {
+	if tags == nil {
+		tags = []string{}
+	}
+	query := `
+		WITH removed AS (
+			DELETE FROM page_tags
+			WHERE page_id = $1 AND NOT (tag = ANY($3))
+		)
+		INSERT INTO page_tags (page_id, website_id, tag)
+		SELECT $1, $2, tag FROM unnest($3::text[]) AS tag
+		ON CONFLICT (page_id, tag) DO NOTHING
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, pageID, websiteID, tags); err != nil {
+		return fmt.Errorf("failed to set page tags: %w", err)
+	}
+	return nil
+}
+
+// GetTags returns the tags of the given pages keyed by page ID. Pages without
+// tags are omitted.
+func (r *PageRepository) GetTags(ctx context.Context, ids []int64) (map[uint][]string, error) {
+	var rows []struct {
+		PageID uint   `db:"page_id"`
+		Tag    string `db:"tag"`
+	}
+	query := `SELECT page_id, tag FROM page_tags WHERE page_id = ANY($1) ORDER BY page_id, tag`
+
+	if err := r.db.SelectContext(ctx, &rows, query, ids); err != nil {
+		return nil, fmt.Errorf("failed to get page tags: %w", err)
+	}
+
+	tags := make(map[uint][]string)
+	for _, row := range rows {
+		tags[row.PageID] = append(tags[row.PageID], row.Tag)
+	}
+
+	return tags, nil
+}
+
+// ListIDsByTag returns the IDs of a website's pages tagged with tag.
+func (r *PageRepository) ListIDsByTag(ctx context.Context, websiteID uint, tag string) ([]uint, error) {
+	var ids []uint
+	query := `SELECT page_id FROM page_tags WHERE website_id = $1 AND tag = $2`
+
+	if err := r.db.SelectContext(ctx, &ids, query, websiteID, tag); err != nil {
+		return nil, fmt.Errorf("failed to list pages by tag: %w", err)
+	}
+
+	return ids, nil
+}
+
+// ListTags returns the tags of a website's pages with the number of pages
+// tagged with each, most used first.
+func (r *PageRepository) ListTags(ctx context.Context, websiteID uint, limit int) ([]schema.TagCount, error) {
+	tags := []schema.TagCount{}
+	query := `
+		SELECT tag, COUNT(*) AS pages
+		FROM page_tags
+		WHERE website_id = $1
+		GROUP BY tag
+		ORDER BY pages DESC, tag
+		LIMIT $2
+	`
+
+	if err := r.db.SelectContext(ctx, &tags, query, websiteID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// Version returns the version of a website's pages and their tags.
+func (r *PageRepository) Version(ctx context.Context, websiteID uint) (schema.Version, error) {
+	query := `
+		SELECT p.count + t.count AS count, GREATEST(p.updated_at, t.updated_at) AS updated_at
+		FROM (
+			SELECT COUNT(*) AS count, COALESCE(MAX(updated_at), 'epoch'::timestamptz) AS updated_at
+			FROM pages
+			WHERE website_id = $1
+		) AS p, (
+			SELECT COUNT(*) AS count, COALESCE(MAX(created_at), 'epoch'::timestamptz) AS updated_at
+			FROM page_tags
+			WHERE website_id = $1
+		) AS t
+	`
+
+	var version schema.Version
+	if err := r.db.GetContext(ctx, &version, query, websiteID); err != nil {
+		return version, fmt.Errorf("failed to get pages version: %w", err)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hermit/internal/schema"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// QueryCacheRepository handles database operations for cached RAG answers.
+type QueryCacheRepository struct {
+	db *sqlx.DB
+}
+
+// NewQueryCacheRepository creates a new QueryCacheRepository.
+func NewQueryCacheRepository(db *sqlx.DB) *QueryCacheRepository {
+	return &QueryCacheRepository{db: db}
+}
+
+// Create stores a new cache entry.
+func (r *QueryCacheRepository) Create(ctx context.Context, entry *schema.QueryCacheEntry) error {
+	query := `
+		INSERT INTO query_cache (website_id, query, embedding, answer, sources, retrieved_chunks, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowxContext(ctx, query,
+		entry.WebsiteID,
+		entry.Query,
+		entry.Embedding,
+		entry.Answer,
+		entry.Sources,
+		entry.RetrievedChunks,
+		entry.ExpiresAt,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create query cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListRecent retrieves the most recent unexpired entries for a website.
+func (r *QueryCacheRepository) ListRecent(ctx context.Context, websiteID uint, limit int) ([]schema.QueryCacheEntry, error) {
+	query := `
+		SELECT id, website_id, query, embedding, answer, sources, retrieved_chunks, hit_count, created_at, expires_at
+		FROM query_cache
+		WHERE website_id = $1 AND expires_at > $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	var entries []schema.QueryCacheEntry
+	err := r.db.SelectContext(ctx, &entries, query, websiteID, time.Now(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list query cache entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// IncrementHit records a cache hit for an entry.
+func (r *QueryCacheRepository) IncrementHit(ctx context.Context, id uint) error {
+	query := `UPDATE query_cache SET hit_count = hit_count + 1 WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to increment query cache hit: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByWebsiteID removes all cache entries for a website.
+func (r *QueryCacheRepository) DeleteByWebsiteID(ctx context.Context, websiteID uint) (int64, error) {
+	query := `DELETE FROM query_cache WHERE website_id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, websiteID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete query cache entries: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteExpired removes all expired cache entries.
+func (r *QueryCacheRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `DELETE FROM query_cache WHERE expires_at <= $1`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired query cache entries: %w", err)
+	}
+
+	return result.RowsAffected()
+}
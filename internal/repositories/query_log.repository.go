@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"hermit/internal/schema"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// QueryLogRepository handles database operations for query logs and feedback.
+type QueryLogRepository struct {
+	db *sqlx.DB
+}
+
+// NewQueryLogRepository creates a new QueryLogRepository.
+func NewQueryLogRepository(db *sqlx.DB) *QueryLogRepository {
+	return &QueryLogRepository{db: db}
+}
+
+// Create records a query log entry.
+func (r *QueryLogRepository) Create(ctx context.Context, log *schema.QueryLog) error {
+	query := `
+		INSERT INTO query_logs (website_id, user_id, query, answer, sources, retrieved_chunks, latency_ms, cached, streamed, prompt_tokens, completion_tokens)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`
+
+	var userID *string
+	if log.UserID != nil {
+		id := log.UserID.String()
+		userID = &id
+	}
+
+	err := r.db.QueryRowxContext(ctx, query,
+		log.WebsiteID,
+		userID,
+		log.Query,
+		log.Answer,
+		log.Sources,
+		log.RetrievedChunks,
+		log.LatencyMS,
+		log.Cached,
+		log.Streamed,
+		log.PromptTokens,
+		log.CompletionTokens,
+	).Scan(&log.ID, &log.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create query log: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a query log by ID.
+func (r *QueryLogRepository) GetByID(ctx context.Context, id uint) (*schema.QueryLog, error) {
+	query := `
+		SELECT id, website_id, user_id, query, answer, sources, retrieved_chunks, latency_ms, cached, streamed, prompt_tokens, completion_tokens, created_at
+		FROM query_logs
+		WHERE id = $1
+	`
+
+	var log schema.QueryLog
+	err := r.db.GetContext(ctx, &log, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get query log: %w", err)
+	}
+
+	return &log, nil
+}
+
+// UpsertFeedback records a user's rating for a query, replacing any previous rating.
+func (r *QueryLogRepository) UpsertFeedback(ctx context.Context, feedback *schema.QueryFeedback) error {
+	query := `
+		INSERT INTO query_feedback (query_log_id, user_id, rating, comment)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (query_log_id, user_id)
+		DO UPDATE SET rating = EXCLUDED.rating, comment = EXCLUDED.comment, updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+
+	var userID *string
+	if feedback.UserID != nil {
+		id := feedback.UserID.String()
+		userID = &id
+	}
+
+	err := r.db.QueryRowxContext(ctx, query,
+		feedback.QueryLogID,
+		userID,
+		feedback.Rating,
+		feedback.Comment,
+	).Scan(&feedback.ID, &feedback.CreatedAt, &feedback.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save query feedback: %w", err)
+	}
+
+	return nil
+}
+
+// GetFeedbackStats aggregates query and feedback statistics per website.
+// When websiteID is zero, statistics for all websites are returned.
+func (r *QueryLogRepository) GetFeedbackStats(ctx context.Context, websiteID uint) ([]schema.FeedbackStats, error) {
+	query := `
+		SELECT q.website_id,
+		       COUNT(*) AS total_queries,
+		       COUNT(*) FILTER (WHERE q.cached) AS cached_queries,
+		       COALESCE(AVG(q.latency_ms), 0) AS avg_latency_ms,
+		       COALESCE(SUM(f.total), 0) AS total_feedback,
+		       COALESCE(SUM(f.up), 0) AS thumbs_up,
+		       COALESCE(SUM(f.down), 0) AS thumbs_down
+		FROM query_logs q
+		LEFT JOIN (
+			SELECT query_log_id,
+			       COUNT(*) AS total,
+			       COUNT(*) FILTER (WHERE rating > 0) AS up,
+			       COUNT(*) FILTER (WHERE rating < 0) AS down
+			FROM query_feedback
+			GROUP BY query_log_id
+		) f ON f.query_log_id = q.id
+		WHERE ($1 = 0 OR q.website_id = $1)
+		GROUP BY q.website_id
+		ORDER BY q.website_id
+	`
+
+	var stats []schema.FeedbackStats
+	err := r.db.SelectContext(ctx, &stats, query, websiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feedback stats: %w", err)
+	}
+
+	for i := range stats {
+		if stats[i].TotalFeedback > 0 {
+			stats[i].SatisfactionRate = float64(stats[i].ThumbsUp) / float64(stats[i].TotalFeedback)
+		}
+	}
+
+	return stats, nil
+}
+
+// GetUsage aggregates the token usage of the queries made since a time, per
+// website when byUser is false and per user otherwise. When websiteID is
+// zero, queries on all websites are included.
+func (r *QueryLogRepository) GetUsage(ctx context.Context, websiteID uint, since time.Time, byUser bool) ([]schema.UsageStats, error) {
+	group := "website_id"
+	if byUser {
+		group = "user_id"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %[1]s,
+		       COUNT(*) AS queries,
+		       COUNT(*) FILTER (WHERE cached) AS cached_queries,
+		       COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+		       COALESCE(SUM(completion_tokens), 0) AS completion_tokens
+		FROM query_logs
+		WHERE ($1 = 0 OR website_id = $1) AND created_at >= $2
+		GROUP BY %[1]s
+		ORDER BY SUM(prompt_tokens + completion_tokens) DESC
+	`, group)
+
+	var stats []schema.UsageStats
+	err := r.db.SelectContext(ctx, &stats, query, websiteID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token usage: %w", err)
+	}
+
+	return stats, nil
+}
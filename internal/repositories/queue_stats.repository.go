@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hermit/internal/schema"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// QueueStatsRepository handles database operations for job queue samples.
+type QueueStatsRepository struct {
+	db *sqlx.DB
+}
+
+// NewQueueStatsRepository creates a new QueueStatsRepository.
+func NewQueueStatsRepository(db *sqlx.DB) *QueueStatsRepository {
+	return &QueueStatsRepository{db: db}
+}
+
+// Insert stores samples of the job queues.
+func (r *QueueStatsRepository) Insert(ctx context.Context, samples []schema.QueueSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO queue_stats (queue, pending, active, scheduled, retry, archived, completed,
+		                         processed_total, failed_total, latency_ms, paused, sampled_at)
+		VALUES (:queue, :pending, :active, :scheduled, :retry, :archived, :completed,
+		        :processed_total, :failed_total, :latency_ms, :paused, :sampled_at)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, samples); err != nil {
+		return fmt.Errorf("failed to insert queue samples: %w", err)
+	}
+
+	return nil
+}
+
+// ListSince retrieves the samples of a queue taken since the given time,
+// oldest first.
+func (r *QueueStatsRepository) ListSince(ctx context.Context, queue string, since time.Time) ([]schema.QueueSample, error) {
+	var samples []schema.QueueSample
+	query := `
+		SELECT id, queue, pending, active, scheduled, retry, archived, completed,
+		       processed_total, failed_total, latency_ms, paused, sampled_at
+		FROM queue_stats
+		WHERE queue = $1 AND sampled_at >= $2
+		ORDER BY sampled_at ASC
+	`
+
+	if err := r.db.SelectContext(ctx, &samples, query, queue, since); err != nil {
+		return nil, fmt.Errorf("failed to list queue samples: %w", err)
+	}
+
+	return samples, nil
+}
+
+// DeleteBefore deletes the samples taken before the given time and returns
+// the number deleted.
+func (r *QueueStatsRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM queue_stats WHERE sampled_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete queue samples: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
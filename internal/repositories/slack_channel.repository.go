@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"hermit/internal/schema"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SlackChannelRepository handles database operations for the Slack channels
+// linked to websites.
+type SlackChannelRepository struct {
+	db *sqlx.DB
+}
+
+// NewSlackChannelRepository creates a new SlackChannelRepository.
+func NewSlackChannelRepository(db *sqlx.DB) *SlackChannelRepository {
+	return &SlackChannelRepository{db: db}
+}
+
+// Link links a channel to a website, replacing the website it was linked to.
+func (r *SlackChannelRepository) Link(ctx context.Context, channel *schema.SlackChannel) error {
+	query := `
+		INSERT INTO slack_channels (channel_id, website_id, created_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (channel_id) DO UPDATE
+		SET website_id = EXCLUDED.website_id, created_by = EXCLUDED.created_by, created_at = NOW()
+		RETURNING created_at
+	`
+
+	err := r.db.GetContext(ctx, &channel.CreatedAt, query, channel.ChannelID, channel.WebsiteID, ulidString(channel.CreatedBy))
+	if err != nil {
+		return fmt.Errorf("failed to link slack channel: %w", err)
+	}
+	return nil
+}
+
+// Get returns the link of a channel, or nil if it isn't linked.
+func (r *SlackChannelRepository) Get(ctx context.Context, channelID string) (*schema.SlackChannel, error) {
+	var channel schema.SlackChannel
+	query := `SELECT channel_id, website_id, created_by, created_at FROM slack_channels WHERE channel_id = $1`
+
+	if err := r.db.GetContext(ctx, &channel, query, channelID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get slack channel: %w", err)
+	}
+	return &channel, nil
+}
+
+// ListByWebsite returns the channels linked to a website.
+func (r *SlackChannelRepository) ListByWebsite(ctx context.Context, websiteID uint) ([]schema.SlackChannel, error) {
+	channels := []schema.SlackChannel{}
+	query := `
+		SELECT channel_id, website_id, created_by, created_at
+		FROM slack_channels
+		WHERE website_id = $1
+		ORDER BY created_at
+	`
+
+	if err := r.db.SelectContext(ctx, &channels, query, websiteID); err != nil {
+		return nil, fmt.Errorf("failed to list slack channels: %w", err)
+	}
+	return channels, nil
+}
+
+// Unlink removes the link of a channel to a website. It reports whether the
+// channel was linked to it.
+func (r *SlackChannelRepository) Unlink(ctx context.Context, websiteID uint, channelID string) (bool, error) {
+	query := `DELETE FROM slack_channels WHERE website_id = $1 AND channel_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, websiteID, channelID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unlink slack channel: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
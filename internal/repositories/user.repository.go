@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"hermit/internal/apperrors"
 	"hermit/internal/schema"
 
 	"github.com/jmoiron/sqlx"
@@ -77,7 +78,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id ulid.ULID) (*schema.Use
 	err := r.db.GetContext(ctx, &user, query, id.String())
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperrors.NotFound("user not found")
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -97,7 +98,7 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*schema.
 	err := r.db.GetContext(ctx, &user, query, email)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperrors.NotFound("user not found")
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -130,7 +131,7 @@ func (r *UserRepository) Update(ctx context.Context, user *schema.User) error {
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return fmt.Errorf("user not found")
+			return apperrors.NotFound("user not found")
 		}
 		return fmt.Errorf("failed to update user: %w", err)
 	}
@@ -153,7 +154,7 @@ func (r *UserRepository) Delete(ctx context.Context, id ulid.ULID) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return apperrors.NotFound("user not found")
 	}
 
 	return nil
@@ -188,6 +189,30 @@ func (r *UserRepository) List(ctx context.Context, page, limit int) ([]*schema.U
 	return users, total, nil
 }
 
+// Count returns the total number of users
+func (r *UserRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM users`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return count, nil
+}
+
+// AdminExists checks if any user is an admin
+func (r *UserRepository) AdminExists(ctx context.Context) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE role = $1)`
+
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, query, schema.RoleAdmin)
+	if err != nil {
+		return false, fmt.Errorf("failed to check admin existence: %w", err)
+	}
+
+	return exists, nil
+}
+
 // GetWebsiteCount gets the count of websites for a user
 func (r *UserRepository) GetWebsiteCount(ctx context.Context, userID ulid.ULID) (int, error) {
 	query := `SELECT COUNT(*) FROM websites WHERE user_id = $1`
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"hermit/internal/schema"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// VisualSnapshotRepository handles database operations for page visual snapshots.
+type VisualSnapshotRepository struct {
+	db *sqlx.DB
+}
+
+// NewVisualSnapshotRepository creates a new VisualSnapshotRepository.
+func NewVisualSnapshotRepository(db *sqlx.DB) *VisualSnapshotRepository {
+	return &VisualSnapshotRepository{db: db}
+}
+
+// Create records a new visual snapshot.
+func (r *VisualSnapshotRepository) Create(ctx context.Context, snapshot *schema.VisualSnapshot) error {
+	query := `
+		INSERT INTO page_visual_snapshots (website_id, page_id, phash, object_key, change_score, text_changed)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowxContext(ctx, query,
+		snapshot.WebsiteID,
+		snapshot.PageID,
+		snapshot.PHash,
+		snapshot.ObjectKey,
+		snapshot.ChangeScore,
+		snapshot.TextChanged,
+	).Scan(&snapshot.ID, &snapshot.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create visual snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestByPageID retrieves the most recent snapshot for a page.
+func (r *VisualSnapshotRepository) GetLatestByPageID(ctx context.Context, pageID uint) (*schema.VisualSnapshot, error) {
+	query := `
+		SELECT s.id, s.website_id, s.page_id, p.url AS page_url, s.phash, s.object_key,
+		       s.change_score, s.text_changed, s.created_at
+		FROM page_visual_snapshots s
+		JOIN pages p ON p.id = s.page_id
+		WHERE s.page_id = $1
+		ORDER BY s.created_at DESC
+		LIMIT 1
+	`
+
+	var snapshot schema.VisualSnapshot
+	err := r.db.GetContext(ctx, &snapshot, query, pageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get visual snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// ListChangesByWebsiteID retrieves snapshots for a website whose change score
+// is at least minScore, most recent first.
+func (r *VisualSnapshotRepository) ListChangesByWebsiteID(ctx context.Context, websiteID uint, minScore float64, limit int) ([]schema.VisualSnapshot, error) {
+	query := `
+		SELECT s.id, s.website_id, s.page_id, p.url AS page_url, s.phash, s.object_key,
+		       s.change_score, s.text_changed, s.created_at
+		FROM page_visual_snapshots s
+		JOIN pages p ON p.id = s.page_id
+		WHERE s.website_id = $1 AND s.change_score >= $2
+		ORDER BY s.created_at DESC
+		LIMIT $3
+	`
+
+	var snapshots []schema.VisualSnapshot
+	err := r.db.SelectContext(ctx, &snapshots, query, websiteID, minScore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list visual snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// ListObjectKeys returns the storage object keys referenced by any snapshot.
+func (r *VisualSnapshotRepository) ListObjectKeys(ctx context.Context) ([]string, error) {
+	var keys []string
+	query := `SELECT object_key FROM page_visual_snapshots WHERE object_key IS NOT NULL`
+
+	err := r.db.SelectContext(ctx, &keys, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshot object keys: %w", err)
+	}
+
+	return keys, nil
+}
@@ -3,12 +3,21 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"hermit/internal/apperrors"
 	"hermit/internal/schema"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/oklog/ulid/v2"
 )
 
+// websiteColumns lists the columns selected for a schema.Website.
+const websiteColumns = `id, url, user_id, is_monitored, crawl_status, crawl_started_at, crawl_completed_at,
+		total_pages_crawled, total_pages_failed, last_error, tls_skip_verify, visual_monitoring,
+		max_crawl_bytes, max_crawl_duration_seconds, max_pages_per_prefix, budget_exhausted, min_content_quality, pii_redaction, noise_removal, noise_patterns, respect_robots, crawl_window_start, crawl_window_end, user_agent, user_agent_rotation, request_headers, seed_urls, scope_prefixes, labels, sitemap_urls, summary, summarized_at, vector_count, storage_objects, storage_bytes, index_stats_at, created_at, updated_at`
+
 // WebsiteRepository handles database operations for websites.
 type WebsiteRepository struct {
 	db *sqlx.DB
@@ -24,9 +33,7 @@ func (r *WebsiteRepository) Create(ctx context.Context, url string) (*schema.Web
 	query := `
 		INSERT INTO websites (url, is_monitored, crawl_status)
 		VALUES ($1, $2, $3)
-		RETURNING id, url, user_id, is_monitored, crawl_status, crawl_started_at, crawl_completed_at,
-		          total_pages_crawled, total_pages_failed, last_error, created_at, updated_at
-	`
+		RETURNING ` + websiteColumns
 
 	var website schema.Website
 	err := r.db.QueryRowxContext(ctx, query, url, true, "idle").StructScan(&website)
@@ -41,8 +48,7 @@ func (r *WebsiteRepository) Create(ctx context.Context, url string) (*schema.Web
 func (r *WebsiteRepository) List(ctx context.Context) ([]schema.Website, error) {
 	var websites []schema.Website
 	query := `
-		SELECT id, url, user_id, is_monitored, crawl_status, crawl_started_at, crawl_completed_at,
-		       total_pages_crawled, total_pages_failed, last_error, created_at, updated_at
+		SELECT ` + websiteColumns + `
 		FROM websites
 	`
 
@@ -54,12 +60,52 @@ func (r *WebsiteRepository) List(ctx context.Context) ([]schema.Website, error)
 	return websites, nil
 }
 
+// ListAccessible returns a page of the websites of a user and of the shared
+// websites, all websites for a nil user, in creation order, and the total
+// number of them. When label is set only websites with it are listed. A limit
+// that is not positive lists every website.
+func (r *WebsiteRepository) ListAccessible(ctx context.Context, userID *ulid.ULID, sharedIDs []int64, label string, limit, offset int) ([]schema.Website, int, error) {
+	var owner *string
+	if userID != nil {
+		id := userID.String()
+		owner = &id
+	}
+	if sharedIDs == nil {
+		sharedIDs = []int64{}
+	}
+	where := `($1::text IS NULL OR user_id = $1 OR id = ANY($2)) AND ($3 = '' OR labels @> ARRAY[$3]::text[])`
+
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM websites WHERE `+where, owner, sharedIDs, label)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count websites: %w", err)
+	}
+
+	var pageLimit *int
+	if limit > 0 {
+		pageLimit = &limit
+	}
+	query := `
+		SELECT ` + websiteColumns + `
+		FROM websites
+		WHERE ` + where + `
+		ORDER BY id
+		LIMIT $4 OFFSET $5
+	`
+
+	websites := []schema.Website{}
+	if err := r.db.SelectContext(ctx, &websites, query, owner, sharedIDs, label, pageLimit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to list websites: %w", err)
+	}
+
+	return websites, total, nil
+}
+
 // GetByID retrieves a website by ID.
 func (r *WebsiteRepository) GetByID(ctx context.Context, id uint) (*schema.Website, error) {
 	var website schema.Website
 	query := `
-		SELECT id, url, user_id, is_monitored, crawl_status, crawl_started_at, crawl_completed_at,
-		       total_pages_crawled, total_pages_failed, last_error, created_at, updated_at
+		SELECT ` + websiteColumns + `
 		FROM websites
 		WHERE id = $1
 	`
@@ -75,6 +121,112 @@ func (r *WebsiteRepository) GetByID(ctx context.Context, id uint) (*schema.Websi
 	return &website, nil
 }
 
+// GetOwnerID returns the ID of the user owning a website, or an empty string
+// when the website has no owner.
+func (r *WebsiteRepository) GetOwnerID(ctx context.Context, id uint) (string, error) {
+	var userID *ulid.ULID
+	err := r.db.QueryRowxContext(ctx, `SELECT user_id FROM websites WHERE id = $1`, id).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", apperrors.NotFound(fmt.Sprintf("website %d not found", id))
+		}
+		return "", fmt.Errorf("failed to get website owner: %w", err)
+	}
+
+	if userID == nil {
+		return "", nil
+	}
+	return userID.String(), nil
+}
+
+// CountByCrawlStatus returns the number of websites per crawl status.
+func (r *WebsiteRepository) CountByCrawlStatus(ctx context.Context) (map[string]int, error) {
+	var rows []struct {
+		CrawlStatus string `db:"crawl_status"`
+		Count       int    `db:"count"`
+	}
+	query := `
+		SELECT crawl_status, COUNT(*) AS count
+		FROM websites
+		GROUP BY crawl_status
+	`
+
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to count websites by crawl status: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.CrawlStatus] = row.Count
+	}
+
+	return counts, nil
+}
+
+// ListOrphaned retrieves websites without an owner. If ids is not empty only
+// those websites are considered.
+func (r *WebsiteRepository) ListOrphaned(ctx context.Context, ids []int64) ([]schema.Website, error) {
+	query := `
+		SELECT ` + websiteColumns + `
+		FROM websites
+		WHERE user_id IS NULL
+	`
+	args := []interface{}{}
+	if len(ids) > 0 {
+		query += ` AND id = ANY($1)`
+		args = append(args, ids)
+	}
+	query += ` ORDER BY id`
+
+	websites := []schema.Website{}
+	if err := r.db.SelectContext(ctx, &websites, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list orphaned websites: %w", err)
+	}
+
+	return websites, nil
+}
+
+// AssignOwner sets userID as the owner of the given websites. Websites that
+// already have an owner are left untouched. It returns the number of websites
+// assigned.
+func (r *WebsiteRepository) AssignOwner(ctx context.Context, userID ulid.ULID, ids []int64) (int64, error) {
+	query := `
+		UPDATE websites
+		SET user_id = $1, updated_at = NOW()
+		WHERE user_id IS NULL AND id = ANY($2)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID.String(), ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to assign website owner: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// ListVersion returns the version of the websites of a user, or of all
+// websites when userID is nil.
+func (r *WebsiteRepository) ListVersion(ctx context.Context, userID *ulid.ULID) (schema.Version, error) {
+	var owner *string
+	if userID != nil {
+		id := userID.String()
+		owner = &id
+	}
+
+	query := `
+		SELECT COUNT(*) AS count, COALESCE(MAX(updated_at), 'epoch'::timestamptz) AS updated_at
+		FROM websites
+		WHERE $1::text IS NULL OR user_id = $1
+	`
+
+	var version schema.Version
+	if err := r.db.GetContext(ctx, &version, query, owner); err != nil {
+		return version, fmt.Errorf("failed to get websites version: %w", err)
+	}
+
+	return version, nil
+}
+
 // Update updates a website in the database.
 func (r *WebsiteRepository) Update(ctx context.Context, website *schema.Website) error {
 	query := `
@@ -82,10 +234,41 @@ func (r *WebsiteRepository) Update(ctx context.Context, website *schema.Website)
 		SET url = $1, user_id = $2, is_monitored = $3, crawl_status = $4,
 		    crawl_started_at = $5, crawl_completed_at = $6,
 		    total_pages_crawled = $7, total_pages_failed = $8,
-		    last_error = $9, updated_at = NOW()
-		WHERE id = $10
+		    last_error = $9, tls_skip_verify = $10, visual_monitoring = $11,
+		    max_crawl_bytes = $12, max_crawl_duration_seconds = $13, max_pages_per_prefix = $14,
+		    seed_urls = $15, scope_prefixes = $16, min_content_quality = $17,
+		    pii_redaction = $18, user_agent = $19, user_agent_rotation = $20, request_headers = $21,
+		    respect_robots = $22, crawl_window_start = $23, crawl_window_end = $24,
+		    noise_removal = $25, noise_patterns = $26, labels = $27,
+		    updated_at = NOW()
+		WHERE id = $28
 	`
 
+	seedURLs := website.SeedURLs
+	if seedURLs == nil {
+		seedURLs = []string{}
+	}
+	scopePrefixes := website.ScopePrefixes
+	if scopePrefixes == nil {
+		scopePrefixes = []string{}
+	}
+	userAgentRotation := website.UserAgentRotation
+	if userAgentRotation == nil {
+		userAgentRotation = []string{}
+	}
+	noiseRemoval := website.NoiseRemoval
+	if noiseRemoval == "" {
+		noiseRemoval = schema.NoiseRemovalExtend
+	}
+	noisePatterns := website.NoisePatterns
+	if noisePatterns == nil {
+		noisePatterns = []string{}
+	}
+	labels := website.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+
 	_, err := r.db.ExecContext(ctx, query,
 		website.URL,
 		website.UserID,
@@ -96,6 +279,24 @@ func (r *WebsiteRepository) Update(ctx context.Context, website *schema.Website)
 		website.TotalPagesCrawled,
 		website.TotalPagesFailed,
 		website.LastError,
+		website.TLSSkipVerify,
+		website.VisualMonitoring,
+		website.MaxCrawlBytes,
+		website.MaxCrawlDurationSeconds,
+		website.MaxPagesPerPrefix,
+		seedURLs,
+		scopePrefixes,
+		website.MinContentQuality,
+		website.PIIRedaction,
+		website.UserAgent,
+		userAgentRotation,
+		website.RequestHeaders,
+		website.RespectRobots,
+		website.CrawlWindowStart,
+		website.CrawlWindowEnd,
+		noiseRemoval,
+		noisePatterns,
+		labels,
 		website.ID,
 	)
 	return err
@@ -120,6 +321,8 @@ func (r *WebsiteRepository) StartCrawl(ctx context.Context, id uint) error {
 		SET crawl_status = 'crawling',
 		    crawl_started_at = $1,
 		    crawl_completed_at = NULL,
+		    budget_exhausted = NULL,
+		    crawl_checkpoint = NULL,
 		    updated_at = NOW()
 		WHERE id = $2
 	`
@@ -136,6 +339,7 @@ func (r *WebsiteRepository) CompleteCrawl(ctx context.Context, id uint, totalPag
 		    crawl_completed_at = $1,
 		    total_pages_crawled = $2,
 		    total_pages_failed = $3,
+		    crawl_checkpoint = NULL,
 		    updated_at = NOW()
 		WHERE id = $4
 	`
@@ -144,12 +348,149 @@ func (r *WebsiteRepository) CompleteCrawl(ctx context.Context, id uint, totalPag
 	return err
 }
 
+// SetBudgetExhausted records which crawl budget stopped the current crawl.
+func (r *WebsiteRepository) SetBudgetExhausted(ctx context.Context, id uint, budget string) error {
+	query := `
+		UPDATE websites
+		SET budget_exhausted = $1,
+		    updated_at = NOW()
+		WHERE id = $2
+	`
+
+	_, err := r.db.ExecContext(ctx, query, budget, id)
+	return err
+}
+
+// SetSitemaps stores the sitemaps discovered for a website.
+func (r *WebsiteRepository) SetSitemaps(ctx context.Context, id uint, sitemaps []string) error {
+	if sitemaps == nil {
+		sitemaps = []string{}
+	}
+
+	query := `
+		UPDATE websites
+		SET sitemap_urls = $1,
+		    updated_at = NOW()
+		WHERE id = $2
+	`
+
+	_, err := r.db.ExecContext(ctx, query, sitemaps, id)
+	return err
+}
+
+// GetBoilerplate returns the hashes of the boilerplate text blocks detected
+// by the last crawl of a website.
+func (r *WebsiteRepository) GetBoilerplate(ctx context.Context, id uint) ([]string, error) {
+	var hashes []string
+	query := `SELECT boilerplate_blocks FROM websites WHERE id = $1`
+
+	if err := r.db.GetContext(ctx, &hashes, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get boilerplate blocks: %w", err)
+	}
+	return hashes, nil
+}
+
+// SetBoilerplate stores the hashes of the boilerplate text blocks detected by
+// a crawl of a website.
+func (r *WebsiteRepository) SetBoilerplate(ctx context.Context, id uint, hashes []string) error {
+	if hashes == nil {
+		hashes = []string{}
+	}
+
+	query := `UPDATE websites SET boilerplate_blocks = $1 WHERE id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, hashes, id); err != nil {
+		return fmt.Errorf("failed to set boilerplate blocks: %w", err)
+	}
+	return nil
+}
+
+// SetSummary stores the LLM summary of a website.
+func (r *WebsiteRepository) SetSummary(ctx context.Context, id uint, summary string) error {
+	query := `
+		UPDATE websites
+		SET summary = $1,
+		    summarized_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $2
+	`
+
+	_, err := r.db.ExecContext(ctx, query, summary, id)
+	if err != nil {
+		return fmt.Errorf("failed to set website summary: %w", err)
+	}
+	return nil
+}
+
+// SetIndexStats stores the vector count and storage usage of a website.
+func (r *WebsiteRepository) SetIndexStats(ctx context.Context, id uint, vectors, objects int, bytes int64) error {
+	query := `
+		UPDATE websites
+		SET vector_count = $1,
+		    storage_objects = $2,
+		    storage_bytes = $3,
+		    index_stats_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $4
+	`
+
+	_, err := r.db.ExecContext(ctx, query, vectors, objects, bytes, id)
+	if err != nil {
+		return fmt.Errorf("failed to set website index stats: %w", err)
+	}
+	return nil
+}
+
+// GetSuggestedQuestions returns the questions last generated for a website,
+// or nil when none were generated yet.
+func (r *WebsiteRepository) GetSuggestedQuestions(ctx context.Context, id uint) (*schema.SuggestedQuestions, error) {
+	var suggested schema.SuggestedQuestions
+	query := `
+		SELECT suggested_questions, suggested_questions_at
+		FROM websites
+		WHERE id = $1 AND suggested_questions_at IS NOT NULL
+	`
+
+	err := r.db.QueryRowxContext(ctx, query, id).StructScan(&suggested)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get suggested questions: %w", err)
+	}
+
+	return &suggested, nil
+}
+
+// SetSuggestedQuestions stores the questions generated for a website.
+func (r *WebsiteRepository) SetSuggestedQuestions(ctx context.Context, id uint, questions []string) (*schema.SuggestedQuestions, error) {
+	suggested := schema.SuggestedQuestions{Questions: questions}
+	query := `
+		UPDATE websites
+		SET suggested_questions = $1,
+		    suggested_questions_at = NOW()
+		WHERE id = $2
+		RETURNING suggested_questions_at
+	`
+
+	err := r.db.QueryRowxContext(ctx, query, questions, id).Scan(&suggested.GeneratedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set suggested questions: %w", err)
+	}
+
+	return &suggested, nil
+}
+
 // FailCrawl marks a website crawl as failed with error message.
 func (r *WebsiteRepository) FailCrawl(ctx context.Context, id uint, errorMsg string) error {
 	query := `
 		UPDATE websites
 		SET crawl_status = 'failed',
 		    last_error = $1,
+		    crawl_checkpoint = NULL,
 		    updated_at = NOW()
 		WHERE id = $2
 	`
@@ -158,6 +499,109 @@ func (r *WebsiteRepository) FailCrawl(ctx context.Context, id uint, errorMsg str
 	return err
 }
 
+// InterruptCrawl marks a website crawl as interrupted, saving its progress
+// for the next crawl to resume.
+func (r *WebsiteRepository) InterruptCrawl(ctx context.Context, id uint, checkpoint *schema.CrawlCheckpoint) error {
+	encoded, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to encode crawl checkpoint: %w", err)
+	}
+
+	query := `
+		UPDATE websites
+		SET crawl_status = 'interrupted',
+		    crawl_checkpoint = $1,
+		    updated_at = NOW()
+		WHERE id = $2
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, encoded, id); err != nil {
+		return fmt.Errorf("failed to mark crawl as interrupted: %w", err)
+	}
+	return nil
+}
+
+// GetCrawlCheckpoint returns the progress saved by the interrupted crawl of a
+// website, nil when its last crawl wasn't interrupted.
+func (r *WebsiteRepository) GetCrawlCheckpoint(ctx context.Context, id uint) (*schema.CrawlCheckpoint, error) {
+	var encoded []byte
+	query := `SELECT crawl_checkpoint FROM websites WHERE id = $1`
+
+	if err := r.db.GetContext(ctx, &encoded, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get crawl checkpoint: %w", err)
+	}
+	if encoded == nil {
+		return nil, nil
+	}
+
+	var checkpoint schema.CrawlCheckpoint
+	if err := json.Unmarshal(encoded, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to decode crawl checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// ResumeCrawl marks an interrupted website crawl as crawling again, keeping
+// its start time and page counts.
+func (r *WebsiteRepository) ResumeCrawl(ctx context.Context, id uint) error {
+	query := `
+		UPDATE websites
+		SET crawl_status = 'crawling',
+		    crawl_checkpoint = NULL,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// ListStalledCrawls returns the websites marked as crawling whose crawl
+// started, and whose pages were last updated, before the given time.
+func (r *WebsiteRepository) ListStalledCrawls(ctx context.Context, before time.Time) ([]schema.StalledCrawl, error) {
+	var stalled []schema.StalledCrawl
+	query := `
+		SELECT w.id, w.url,
+		       GREATEST(COALESCE(w.crawl_started_at, w.updated_at), COALESCE(MAX(p.updated_at), 'epoch'::timestamptz)) AS last_activity_at
+		FROM websites w
+		LEFT JOIN pages p ON p.website_id = w.id
+		WHERE w.crawl_status = 'crawling'
+		GROUP BY w.id
+		HAVING GREATEST(COALESCE(w.crawl_started_at, w.updated_at), COALESCE(MAX(p.updated_at), 'epoch'::timestamptz)) < $1
+		ORDER BY w.id
+	`
+
+	if err := r.db.SelectContext(ctx, &stalled, query, before); err != nil {
+		return nil, fmt.Errorf("failed to list stalled crawls: %w", err)
+	}
+	return stalled, nil
+}
+
+// StallCrawl marks the crawl of a website as failed because it stalled. It
+// reports false when the website is no longer crawling.
+func (r *WebsiteRepository) StallCrawl(ctx context.Context, id uint, errorMsg string) (bool, error) {
+	query := `
+		UPDATE websites
+		SET crawl_status = 'failed',
+		    last_error = $1,
+		    updated_at = NOW()
+		WHERE id = $2 AND crawl_status = 'crawling'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, errorMsg, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark crawl as stalled: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark crawl as stalled: %w", err)
+	}
+	return rows > 0, nil
+}
+
 // IncrementPageCount increments the total pages crawled counter.
 func (r *WebsiteRepository) IncrementPageCount(ctx context.Context, id uint, success bool) error {
 	var query string
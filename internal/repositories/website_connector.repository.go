@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"hermit/internal/schema"
+	"hermit/internal/secrets"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WebsiteConnectorRepository stores the connector configs of websites, whose
+// tokens are encrypted at rest.
+type WebsiteConnectorRepository struct {
+	db     *sqlx.DB
+	cipher *secrets.Cipher
+}
+
+// NewWebsiteConnectorRepository creates a new WebsiteConnectorRepository.
+// Without a cipher connectors can't be stored or read.
+func NewWebsiteConnectorRepository(db *sqlx.DB, cipher *secrets.Cipher) *WebsiteConnectorRepository {
+	return &WebsiteConnectorRepository{db: db, cipher: cipher}
+}
+
+// Enabled reports whether connectors can be stored.
+func (r *WebsiteConnectorRepository) Enabled() bool {
+	return r.cipher != nil
+}
+
+// Save encrypts and stores the connector of a website, replacing any
+// existing one.
+func (r *WebsiteConnectorRepository) Save(ctx context.Context, websiteID uint, connector *schema.ConnectorConfig) error {
+	plaintext, err := json.Marshal(connector)
+	if err != nil {
+		return fmt.Errorf("failed to encode connector: %w", err)
+	}
+
+	encrypted, err := r.cipher.Encrypt(plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt connector: %w", err)
+	}
+
+	query := `
+		INSERT INTO website_connectors (website_id, kind, encrypted)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (website_id) DO UPDATE
+		SET kind = EXCLUDED.kind, encrypted = EXCLUDED.encrypted, updated_at = NOW()
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, websiteID, connector.Kind, encrypted); err != nil {
+		return fmt.Errorf("failed to save connector: %w", err)
+	}
+	return nil
+}
+
+// Get loads and decrypts the connector of a website. It returns nil if the
+// website has none.
+func (r *WebsiteConnectorRepository) Get(ctx context.Context, websiteID uint) (*schema.ConnectorConfig, error) {
+	var encrypted []byte
+	query := `SELECT encrypted FROM website_connectors WHERE website_id = $1`
+
+	err := r.db.QueryRowxContext(ctx, query, websiteID).Scan(&encrypted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get connector: %w", err)
+	}
+
+	plaintext, err := r.cipher.Decrypt(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt connector: %w", err)
+	}
+
+	var connector schema.ConnectorConfig
+	if err := json.Unmarshal(plaintext, &connector); err != nil {
+		return nil, fmt.Errorf("failed to decode connector: %w", err)
+	}
+	return &connector, nil
+}
+
+// Delete removes the connector of a website.
+func (r *WebsiteConnectorRepository) Delete(ctx context.Context, websiteID uint) error {
+	query := `DELETE FROM website_connectors WHERE website_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, websiteID); err != nil {
+		return fmt.Errorf("failed to delete connector: %w", err)
+	}
+	return nil
+}
+
+// RotateKeys re-encrypts the stored connectors not yet encrypted with the
+// current key. It returns the number of connectors re-encrypted.
+func (r *WebsiteConnectorRepository) RotateKeys(ctx context.Context) (int, error) {
+	var rows []struct {
+		WebsiteID uint   `db:"website_id"`
+		Encrypted []byte `db:"encrypted"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, `SELECT website_id, encrypted FROM website_connectors`); err != nil {
+		return 0, fmt.Errorf("failed to list connectors: %w", err)
+	}
+
+	rotated := 0
+	for _, row := range rows {
+		encrypted, changed, err := r.cipher.Rotate(row.Encrypted)
+		if err != nil {
+			return rotated, fmt.Errorf("failed to rotate connector of website %d: %w", row.WebsiteID, err)
+		}
+		if !changed {
+			continue
+		}
+
+		// Skip rows saved again since they were listed
+		query := `
+			UPDATE website_connectors SET encrypted = $1
+			WHERE website_id = $2 AND encrypted = $3
+		`
+		result, err := r.db.ExecContext(ctx, query, encrypted, row.WebsiteID, row.Encrypted)
+		if err != nil {
+			return rotated, fmt.Errorf("failed to save rotated connector: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			rotated++
+		}
+	}
+	return rotated, nil
+}
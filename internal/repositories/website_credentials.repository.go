@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"hermit/internal/schema"
+	"hermit/internal/secrets"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WebsiteCredentialsRepository stores the fetch credentials of websites
+// encrypted at rest.
+type WebsiteCredentialsRepository struct {
+	db     *sqlx.DB
+	cipher *secrets.Cipher
+}
+
+// NewWebsiteCredentialsRepository creates a new WebsiteCredentialsRepository.
+// Without a cipher credentials can't be stored or read.
+func NewWebsiteCredentialsRepository(db *sqlx.DB, cipher *secrets.Cipher) *WebsiteCredentialsRepository {
+	return &WebsiteCredentialsRepository{db: db, cipher: cipher}
+}
+
+// Enabled reports whether credentials can be stored.
+func (r *WebsiteCredentialsRepository) Enabled() bool {
+	return r.cipher != nil
+}
+
+// Save encrypts and stores the credentials of a website, replacing any
+// existing ones.
+func (r *WebsiteCredentialsRepository) Save(ctx context.Context, websiteID uint, creds *schema.FetchCredentials) error {
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	encrypted, err := r.cipher.Encrypt(plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt credentials: %w", err)
+	}
+
+	query := `
+		INSERT INTO website_credentials (website_id, encrypted)
+		VALUES ($1, $2)
+		ON CONFLICT (website_id) DO UPDATE
+		SET encrypted = EXCLUDED.encrypted, updated_at = NOW()
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, websiteID, encrypted); err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+	return nil
+}
+
+// Get loads and decrypts the credentials of a website. It returns nil if the
+// website has none.
+func (r *WebsiteCredentialsRepository) Get(ctx context.Context, websiteID uint) (*schema.FetchCredentials, error) {
+	var encrypted []byte
+	query := `SELECT encrypted FROM website_credentials WHERE website_id = $1`
+
+	err := r.db.QueryRowxContext(ctx, query, websiteID).Scan(&encrypted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get credentials: %w", err)
+	}
+
+	plaintext, err := r.cipher.Decrypt(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials: %w", err)
+	}
+
+	var creds schema.FetchCredentials
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return nil, fmt.Errorf("failed to decode credentials: %w", err)
+	}
+	return &creds, nil
+}
+
+// Delete removes the credentials of a website.
+func (r *WebsiteCredentialsRepository) Delete(ctx context.Context, websiteID uint) error {
+	query := `DELETE FROM website_credentials WHERE website_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, websiteID); err != nil {
+		return fmt.Errorf("failed to delete credentials: %w", err)
+	}
+	return nil
+}
+
+// RotateKeys re-encrypts the stored credentials not yet encrypted with the
+// current key, so previous keys can be retired. It returns the number of
+// websites whose credentials were re-encrypted.
+func (r *WebsiteCredentialsRepository) RotateKeys(ctx context.Context) (int, error) {
+	var rows []struct {
+		WebsiteID uint   `db:"website_id"`
+		Encrypted []byte `db:"encrypted"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, `SELECT website_id, encrypted FROM website_credentials`); err != nil {
+		return 0, fmt.Errorf("failed to list credentials: %w", err)
+	}
+
+	rotated := 0
+	for _, row := range rows {
+		encrypted, changed, err := r.cipher.Rotate(row.Encrypted)
+		if err != nil {
+			return rotated, fmt.Errorf("failed to rotate credentials of website %d: %w", row.WebsiteID, err)
+		}
+		if !changed {
+			continue
+		}
+
+		// Skip rows saved again since they were listed
+		query := `
+			UPDATE website_credentials SET encrypted = $1
+			WHERE website_id = $2 AND encrypted = $3
+		`
+		result, err := r.db.ExecContext(ctx, query, encrypted, row.WebsiteID, row.Encrypted)
+		if err != nil {
+			return rotated, fmt.Errorf("failed to save rotated credentials: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			rotated++
+		}
+	}
+	return rotated, nil
+}
@@ -0,0 +1,185 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"hermit/internal/schema"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oklog/ulid/v2"
+)
+
+// WebsitePermissionRepository handles database operations for the
+// permissions website owners grant to other users and API keys.
+type WebsitePermissionRepository struct {
+	db *sqlx.DB
+}
+
+// NewWebsitePermissionRepository creates a new WebsitePermissionRepository.
+func NewWebsitePermissionRepository(db *sqlx.DB) *WebsitePermissionRepository {
+	return &WebsitePermissionRepository{db: db}
+}
+
+// websitePermissionColumns are the columns selected for a WebsitePermission,
+// the table is aliased wp and joined with users as u.
+const websitePermissionColumns = `wp.id, wp.website_id, wp.user_id, u.email AS user_email, wp.api_key_id,
+	wp.permission, wp.created_by, wp.created_at`
+
+// ulidString returns the string form of an optional ULID for query arguments.
+func ulidString(id *ulid.ULID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+// Grant grants a permission on a website to the user or API key set on
+// permission. Granting a permission twice returns the existing grant.
+func (r *WebsitePermissionRepository) Grant(ctx context.Context, permission *schema.WebsitePermission) (*schema.WebsitePermission, error) {
+	query := `
+		INSERT INTO website_permissions (website_id, user_id, api_key_id, permission, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`
+
+	var id uint
+	err := r.db.GetContext(ctx, &id, query,
+		permission.WebsiteID,
+		ulidString(permission.UserID),
+		ulidString(permission.APIKeyID),
+		permission.Permission,
+		ulidString(permission.CreatedBy),
+	)
+	if err == sql.ErrNoRows {
+		// Already granted
+		err = r.db.GetContext(ctx, &id, `
+			SELECT id FROM website_permissions
+			WHERE website_id = $1 AND permission = $4
+			  AND (user_id = $2 OR api_key_id = $3)
+		`, permission.WebsiteID, ulidString(permission.UserID), ulidString(permission.APIKeyID), permission.Permission)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant website permission: %w", err)
+	}
+
+	return r.Get(ctx, permission.WebsiteID, id)
+}
+
+// Get retrieves a permission of a website by ID. It returns nil if the
+// website has no such permission.
+func (r *WebsitePermissionRepository) Get(ctx context.Context, websiteID, id uint) (*schema.WebsitePermission, error) {
+	query := `
+		SELECT ` + websitePermissionColumns + `
+		FROM website_permissions wp
+		LEFT JOIN users u ON u.id = wp.user_id
+		WHERE wp.website_id = $1 AND wp.id = $2
+	`
+
+	var permission schema.WebsitePermission
+	err := r.db.GetContext(ctx, &permission, query, websiteID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get website permission: %w", err)
+	}
+
+	return &permission, nil
+}
+
+// ListByWebsiteID retrieves the permissions granted on a website, oldest first.
+func (r *WebsitePermissionRepository) ListByWebsiteID(ctx context.Context, websiteID uint) ([]schema.WebsitePermission, error) {
+	query := `
+		SELECT ` + websitePermissionColumns + `
+		FROM website_permissions wp
+		LEFT JOIN users u ON u.id = wp.user_id
+		WHERE wp.website_id = $1
+		ORDER BY wp.id
+	`
+
+	permissions := []schema.WebsitePermission{}
+	if err := r.db.SelectContext(ctx, &permissions, query, websiteID); err != nil {
+		return nil, fmt.Errorf("failed to list website permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// Revoke deletes a permission of a website. It reports whether the
+// permission existed.
+func (r *WebsitePermissionRepository) Revoke(ctx context.Context, websiteID, id uint) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM website_permissions WHERE website_id = $1 AND id = $2`, websiteID, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke website permission: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ListGranted returns the permissions a user, or a request authenticated
+// with the API key, holds on a website.
+func (r *WebsitePermissionRepository) ListGranted(ctx context.Context, websiteID uint, userID ulid.ULID, apiKeyID *ulid.ULID) ([]schema.WebsitePermission, error) {
+	query := `
+		SELECT ` + websitePermissionColumns + `
+		FROM website_permissions wp
+		LEFT JOIN users u ON u.id = wp.user_id
+		WHERE wp.website_id = $1 AND (wp.user_id = $2 OR wp.api_key_id = $3)
+	`
+
+	permissions := []schema.WebsitePermission{}
+	if err := r.db.SelectContext(ctx, &permissions, query, websiteID, userID.String(), ulidString(apiKeyID)); err != nil {
+		return nil, fmt.Errorf("failed to list granted website permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// SharedWebsiteIDs returns the IDs of the websites a user, or a request
+// authenticated with the API key, holds any permission on.
+func (r *WebsitePermissionRepository) SharedWebsiteIDs(ctx context.Context, userID ulid.ULID, apiKeyID *ulid.ULID) (map[uint]bool, error) {
+	query := `
+		SELECT DISTINCT website_id FROM website_permissions
+		WHERE user_id = $1 OR api_key_id = $2
+	`
+
+	var ids []uint
+	if err := r.db.SelectContext(ctx, &ids, query, userID.String(), ulidString(apiKeyID)); err != nil {
+		return nil, fmt.Errorf("failed to list shared websites: %w", err)
+	}
+
+	shared := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		shared[id] = true
+	}
+	return shared, nil
+}
+
+// SharedVersion returns the version of the permissions a user, or a request
+// authenticated with the API key, holds and of the websites they are
+// shared, so it changes when a shared website changes too.
+func (r *WebsitePermissionRepository) SharedVersion(ctx context.Context, userID ulid.ULID, apiKeyID *ulid.ULID) (schema.Version, error) {
+	query := `
+		SELECT COUNT(*) AS count,
+		       GREATEST(COALESCE(MAX(wp.updated_at), 'epoch'::timestamptz),
+		                COALESCE(MAX(w.updated_at), 'epoch'::timestamptz)) AS updated_at
+		FROM website_permissions wp
+		JOIN websites w ON w.id = wp.website_id
+		WHERE wp.user_id = $1 OR wp.api_key_id = $2
+	`
+
+	var version schema.Version
+	if err := r.db.GetContext(ctx, &version, query, userID.String(), ulidString(apiKeyID)); err != nil {
+		return version, fmt.Errorf("failed to get shared websites version: %w", err)
+	}
+
+	return version, nil
+}
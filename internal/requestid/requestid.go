@@ -0,0 +1,69 @@
+// Package requestid carries the ID of the API request that started some work,
+// through the request context and into background jobs, so logs of the API
+// and the worker can be correlated.
+package requestid
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type contextKey struct{}
+
+// maxLength limits request IDs accepted from clients.
+const maxLength = 64
+
+// Meta carries the request ID and the time a job was enqueued, in Unix
+// seconds, in job payloads. It is optional, so adding it doesn't change a
+// payload's version.
+type Meta struct {
+	RequestID  string `json:"request_id,omitempty"`
+	EnqueuedAt int64  `json:"enqueued_at,omitempty"`
+}
+
+// WithRequestID returns a copy of ctx carrying id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// MetaFromContext returns the job payload metadata for the request in ctx,
+// for a job enqueued now.
+func MetaFromContext(ctx context.Context) Meta {
+	return Meta{RequestID: FromContext(ctx), EnqueuedAt: time.Now().Unix()}
+}
+
+// Fields returns log fields identifying the request in ctx.
+func Fields(ctx context.Context) []zap.Field {
+	id := FromContext(ctx)
+	if id == "" {
+		return nil
+	}
+	return []zap.Field{zap.String("requestID", id)}
+}
+
+// Valid reports whether a request ID received from a client can be used
+// as is. IDs end up in logs, so only short IDs of safe characters are kept.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
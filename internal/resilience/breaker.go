@@ -0,0 +1,137 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// State is the state of a Breaker.
+type State string
+
+// Breaker states.
+const (
+	// StateClosed lets every call through.
+	StateClosed State = "closed"
+	// StateOpen fails calls without making them.
+	StateOpen State = "open"
+	// StateHalfOpen lets calls through again after the cooldown, the next
+	// result closes or reopens the breaker.
+	StateHalfOpen State = "half_open"
+)
+
+// BreakerStatus is a snapshot of a Breaker.
+type BreakerStatus struct {
+	Name                string     `json:"name"`
+	State               State      `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+}
+
+// Breaker stops calling a dependency after repeated failures so callers fail
+// fast instead of waiting for timeouts.
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	logger    *zap.Logger
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// NewBreaker creates a Breaker opening after threshold consecutive failures
+// for cooldown.
+func NewBreaker(name string, threshold int, cooldown time.Duration, logger *zap.Logger) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Breaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		logger:    logger,
+	}
+}
+
+// Name returns the name of the guarded dependency.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow reports whether the dependency should be called. Once the cooldown
+// has passed, calls are allowed again and the next result decides the state.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return time.Now().After(b.openUntil)
+}
+
+// RetryAfter returns how long the breaker stays open, 0 when it is closed.
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if d := time.Until(b.openUntil); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Success resets the failure count.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures >= b.threshold {
+		b.logger.Info("Dependency recovered, circuit breaker closed", zap.String("dependency", b.name))
+	}
+	b.failures = 0
+	b.openUntil = time.Time{}
+}
+
+// Failure records a failed call and opens the breaker once the threshold is
+// reached. It reports whether the breaker just opened.
+func (b *Breaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failures < b.threshold {
+		return false
+	}
+
+	wasOpen := time.Now().Before(b.openUntil)
+	b.openUntil = time.Now().Add(b.cooldown)
+	if !wasOpen {
+		b.logger.Warn("Dependency looks down, circuit breaker opened",
+			zap.String("dependency", b.name),
+			zap.Int("failures", b.failures),
+			zap.Duration("cooldown", b.cooldown),
+		)
+	}
+	return !wasOpen
+}
+
+// Status returns the current state of the breaker.
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		Name:                b.name,
+		State:               StateClosed,
+		ConsecutiveFailures: b.failures,
+	}
+	switch {
+	case time.Now().Before(b.openUntil):
+		status.State = StateOpen
+		openUntil := b.openUntil
+		status.OpenUntil = &openUntil
+	case b.failures >= b.threshold:
+		status.State = StateHalfOpen
+	}
+	return status
+}
@@ -0,0 +1,208 @@
+// Package resilience guards calls to external dependencies such as ChromaDB,
+// object storage and Ollama. Transient failures are retried with jittered
+// exponential backoff and a circuit breaker per dependency makes callers fail
+// fast while the dependency is down, so jobs back off instead of piling up
+// timeouts and exhausting their retries.
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"hermit/internal/config"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// retryBaseDelay is the delay before the first retry, doubled on every retry.
+	retryBaseDelay = 500 * time.Millisecond
+	// retryMaxDelay caps the delay between retries.
+	retryMaxDelay = 10 * time.Second
+)
+
+// UnavailableError is returned without calling a dependency while its
+// breaker is open. RetryAfter is how long the breaker stays open.
+type UnavailableError struct {
+	Dependency string
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s is unavailable, retry in %s", e.Dependency, e.RetryAfter.Round(time.Second))
+}
+
+// Options configures the retries and breaker of a dependency.
+type Options struct {
+	// MaxRetries is the number of retries of a failed call
+	MaxRetries       int
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// OptionsFromConfig returns the options set for dependencies in cfg.
+func OptionsFromConfig(cfg *config.Config) Options {
+	return Options{
+		MaxRetries:       cfg.DependencyMaxRetries,
+		BreakerThreshold: cfg.DependencyBreakerThreshold,
+		BreakerCooldown:  time.Duration(cfg.DependencyBreakerCooldown) * time.Second,
+	}
+}
+
+// Guard runs the calls to one dependency with retries and its breaker.
+type Guard struct {
+	breaker    *Breaker
+	maxRetries int
+	transient  func(error) bool
+	logger     *zap.Logger
+}
+
+// stopError marks an error that must not be retried.
+type stopError struct {
+	err error
+}
+
+func (e *stopError) Error() string { return e.err.Error() }
+func (e *stopError) Unwrap() error { return e.err }
+
+// Stop marks err so the guard doesn't retry the call, e.g. because part of a
+// streamed response was already consumed. It still counts toward the breaker.
+func Stop(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &stopError{err: err}
+}
+
+// Breaker returns the breaker of the guarded dependency.
+func (g *Guard) Breaker() *Breaker {
+	return g.breaker
+}
+
+// Available reports whether the dependency is called at all, false while
+// its breaker is open.
+func (g *Guard) Available() bool {
+	return g.breaker.Allow()
+}
+
+// Do runs call, retrying failures the guard considers transient with jittered
+// exponential backoff. Transient failures count toward the breaker, other
+// errors are returned as they are. While the breaker is open Do returns an
+// UnavailableError without running call.
+func (g *Guard) Do(ctx context.Context, call func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		if !g.breaker.Allow() {
+			return &UnavailableError{Dependency: g.breaker.Name(), RetryAfter: g.breaker.RetryAfter()}
+		}
+
+		err := call(ctx)
+		if err == nil {
+			g.breaker.Success()
+			return nil
+		}
+
+		var stop *stopError
+		stopped := errors.As(err, &stop)
+		if stopped {
+			err = stop.err
+		}
+		if ctx.Err() != nil || !g.transient(err) {
+			return err
+		}
+
+		g.breaker.Failure()
+		if stopped || attempt >= g.maxRetries {
+			return err
+		}
+
+		delay := retryBaseDelay << attempt
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+		delay += rand.N(delay / 2)
+		g.logger.Debug("Retrying dependency call",
+			zap.String("dependency", g.breaker.Name()),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Registry holds the guard of every dependency so their breakers can be
+// reported in health checks.
+type Registry struct {
+	logger *zap.Logger
+	mu     sync.Mutex
+	guards map[string]*Guard
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry(logger *zap.Logger) *Registry {
+	return &Registry{
+		logger: logger,
+		guards: make(map[string]*Guard),
+	}
+}
+
+// Guard returns the guard of the named dependency, creating it with opts
+// and the transient error classifier on first use.
+func (r *Registry) Guard(name string, opts Options, transient func(error) bool) *Guard {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if guard, ok := r.guards[name]; ok {
+		return guard
+	}
+
+	guard := &Guard{
+		breaker:    NewBreaker(name, opts.BreakerThreshold, opts.BreakerCooldown, r.logger),
+		maxRetries: opts.MaxRetries,
+		transient:  transient,
+		logger:     r.logger,
+	}
+	r.guards[name] = guard
+	return guard
+}
+
+// Status returns the state of every breaker, sorted by dependency name.
+func (r *Registry) Status() []BreakerStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]BreakerStatus, 0, len(r.guards))
+	for _, guard := range r.guards {
+		statuses = append(statuses, guard.breaker.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// IsNetworkError reports whether err is a connection failure or timeout,
+// the usual sign of a dependency being down.
+func IsNetworkError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	var opErr *net.OpError
+	var urlErr *url.Error
+	return errors.As(err, &netErr) || errors.As(err, &opErr) || errors.As(err, &urlErr)
+}
@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/oklog/ulid/v2"
@@ -8,19 +10,33 @@ import (
 
 // APIKey represents an API key for authentication
 type APIKey struct {
-	ID         ulid.ULID  `db:"id" json:"id"`
-	UserID     ulid.ULID  `db:"user_id" json:"user_id"`
-	KeyHash    string     `db:"key_hash" json:"-"` // Never send key hash to client
-	KeyPrefix  string     `db:"key_prefix" json:"key_prefix"`
-	Name       string     `db:"name" json:"name"`
-	Scopes     []string   `db:"scopes" json:"scopes"`
-	IsActive   bool       `db:"is_active" json:"is_active"`
-	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
-	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at,omitempty"`
-	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
-	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+	ID        ulid.ULID `db:"id" json:"id"`
+	UserID    ulid.ULID `db:"user_id" json:"user_id"`
+	KeyHash   string    `db:"key_hash" json:"-"` // Never send key hash to client
+	KeyPrefix string    `db:"key_prefix" json:"key_prefix"`
+	Name      string    `db:"name" json:"name"`
+	Scopes    []string  `db:"scopes" json:"scopes"`
+	IsActive  bool      `db:"is_active" json:"is_active"`
+	Session   bool      `db:"session" json:"session"` // Created by a web login
+	IP        string    `db:"ip" json:"ip,omitempty"`
+	UserAgent string    `db:"user_agent" json:"user_agent,omitempty"`
+	// Public keys are embedded in the chat widget, see PublicKeyPrefix
+	Public          bool       `db:"public" json:"public"`
+	WebsiteID       *uint      `db:"website_id" json:"website_id,omitempty"`
+	AllowedOrigins  []string   `db:"allowed_origins" json:"allowed_origins,omitempty"`
+	RateLimitPerMin int        `db:"rate_limit_per_min" json:"rate_limit_per_min,omitempty"`
+	LastUsedAt      *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+	ExpiresAt       *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
 }
 
+// PublicKeyPrefix starts the public keys of the chat widget. They aren't
+// secret since they are embedded in web pages, so they can only query their
+// website, from their allowed origins and at a limited rate, and can't be
+// used with the rest of the API.
+const PublicKeyPrefix = "hmt_pub_"
+
 // CreateAPIKeyRequest represents the request to create a new API key
 type CreateAPIKeyRequest struct {
 	Name      string     `json:"name" validate:"required,min=3,max=255"`
@@ -45,31 +61,105 @@ type UpdateAPIKeyRequest struct {
 
 // APIKeyResponse represents API key data returned to client (without sensitive fields)
 type APIKeyResponse struct {
+	ID        ulid.ULID `json:"id"`
+	UserID    ulid.ULID `json:"user_id"`
+	KeyPrefix string    `json:"key_prefix"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	IsActive  bool      `json:"is_active"`
+	// Public, WebsiteID, AllowedOrigins and RateLimitPerMin describe the
+	// public keys of the chat widget
+	Public          bool       `json:"public,omitempty"`
+	WebsiteID       *uint      `json:"website_id,omitempty"`
+	AllowedOrigins  []string   `json:"allowed_origins,omitempty"`
+	RateLimitPerMin int        `json:"rate_limit_per_min,omitempty"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// ToResponse converts APIKey to APIKeyResponse
+func (k *APIKey) ToResponse() *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:              k.ID,
+		UserID:          k.UserID,
+		KeyPrefix:       k.KeyPrefix,
+		Name:            k.Name,
+		Scopes:          k.Scopes,
+		IsActive:        k.IsActive,
+		Public:          k.Public,
+		WebsiteID:       k.WebsiteID,
+		AllowedOrigins:  k.AllowedOrigins,
+		RateLimitPerMin: k.RateLimitPerMin,
+		LastUsedAt:      k.LastUsedAt,
+		ExpiresAt:       k.ExpiresAt,
+		CreatedAt:       k.CreatedAt,
+		UpdatedAt:       k.UpdatedAt,
+	}
+}
+
+// SessionResponse represents a web session returned to client
+type SessionResponse struct {
 	ID         ulid.ULID  `json:"id"`
-	UserID     ulid.ULID  `json:"user_id"`
-	KeyPrefix  string     `json:"key_prefix"`
-	Name       string     `json:"name"`
-	Scopes     []string   `json:"scopes"`
-	IsActive   bool       `json:"is_active"`
+	Device     string     `json:"device"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	Current    bool       `json:"current"` // The session the request was made with
 	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
 	CreatedAt  time.Time  `json:"created_at"`
-	UpdatedAt  time.Time  `json:"updated_at"`
 }
 
-// ToResponse converts APIKey to APIKeyResponse
-func (k *APIKey) ToResponse() *APIKeyResponse {
-	return &APIKeyResponse{
+// ToSessionResponse converts a session APIKey to SessionResponse
+func (k *APIKey) ToSessionResponse(current bool) *SessionResponse {
+	return &SessionResponse{
 		ID:         k.ID,
-		UserID:     k.UserID,
-		KeyPrefix:  k.KeyPrefix,
-		Name:       k.Name,
-		Scopes:     k.Scopes,
-		IsActive:   k.IsActive,
+		Device:     DescribeUserAgent(k.UserAgent),
+		IP:         k.IP,
+		UserAgent:  k.UserAgent,
+		Current:    current,
 		LastUsedAt: k.LastUsedAt,
 		ExpiresAt:  k.ExpiresAt,
 		CreatedAt:  k.CreatedAt,
-		UpdatedAt:  k.UpdatedAt,
+	}
+}
+
+// userAgentBrowsers and userAgentSystems map user agent tokens to names, in
+// the order they are checked since most browsers claim to be others too.
+var (
+	userAgentBrowsers = [][2]string{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"},
+		{"Safari/", "Safari"}, {"curl/", "curl"},
+	}
+	userAgentSystems = [][2]string{
+		{"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Android", "Android"}, {"Windows", "Windows"},
+		{"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	}
+)
+
+// DescribeUserAgent returns a short description of the browser and operating
+// system of a user agent, e.g. "Firefox on Linux".
+func DescribeUserAgent(userAgent string) string {
+	match := func(names [][2]string) string {
+		for _, name := range names {
+			if strings.Contains(userAgent, name[0]) {
+				return name[1]
+			}
+		}
+		return ""
+	}
+
+	browser, system := match(userAgentBrowsers), match(userAgentSystems)
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	default:
+		return "Unknown device"
 	}
 }
 
@@ -86,7 +176,53 @@ func (k *APIKey) IsValid() bool {
 	return k.IsActive && !k.IsExpired()
 }
 
-// HasScope checks if the API key has a specific scope
+// AllowsOrigin reports whether a public key may be used from a page of
+// origin, an Origin header such as https://docs.example.com. Allowed origins
+// are hosts, *.example.com allows the subdomains of example.com.
+func (k *APIKey) AllowsOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	for _, allowed := range k.AllowedOrigins {
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeOrigin returns the host an allowed origin of a public key is
+// stored as, accepting a URL such as https://docs.example.com/ or a host
+// such as docs.example.com or *.example.com.
+func NormalizeOrigin(origin string) (string, bool) {
+	origin = strings.ToLower(strings.TrimSpace(origin))
+	if strings.Contains(origin, "://") {
+		u, err := url.Parse(origin)
+		if err != nil {
+			return "", false
+		}
+		origin = u.Hostname()
+	}
+
+	host, wildcard := strings.CutPrefix(origin, "*.")
+	if host == "" || strings.ContainsAny(host, "/:*?#@ ") || (!strings.Contains(host, ".") && host != "localhost") {
+		return "", false
+	}
+	if wildcard {
+		return "*." + host, true
+	}
+	return host, true
+}
+
+// HasScope checks if the API key has a specific scope. Granted scopes may use
+// wildcards, see ScopeMatches.
 func (k *APIKey) HasScope(scope string) bool {
 	// Empty scopes means full access
 	if len(k.Scopes) == 0 {
@@ -94,9 +230,19 @@ func (k *APIKey) HasScope(scope string) bool {
 	}
 
 	for _, s := range k.Scopes {
-		if s == scope || s == "*" {
+		if s == scope || ScopeMatches(s, scope) {
 			return true
 		}
 	}
 	return false
 }
+
+// ValidateScopes checks every scope against the scope grammar.
+func ValidateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if err := ValidateScope(scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Resource types of audit log entries
+const (
+	AuditResourceUser    = "user"
+	AuditResourceWebsite = "website"
+	AuditResourceAPIKey  = "api_key"
+	AuditResourceSession = "session"
+	AuditResourceJob     = "job"
+	AuditResourceQueue   = "queue"
+	AuditResourceSystem  = "system"
+)
+
+// AuditLog records a mutating API request: who made it, with which API key
+// and from where, the resource it changed and its state before and after.
+// Before and After are null when the resource didn't exist, or has no
+// snapshot; secrets are redacted from them.
+type AuditLog struct {
+	ID           int64      `db:"id" json:"id"`
+	ActorUserID  *ulid.ULID `db:"actor_user_id" json:"actor_user_id,omitempty"`
+	ActorEmail   string     `db:"actor_email" json:"actor_email,omitempty"`
+	APIKeyID     *ulid.ULID `db:"api_key_id" json:"api_key_id,omitempty"`
+	Action       string     `db:"action" json:"action" example:"website.recrawl"`
+	ResourceType string     `db:"resource_type" json:"resource_type" example:"website"`
+	ResourceID   string     `db:"resource_id" json:"resource_id,omitempty" example:"42"`
+	Before       JSONRaw    `db:"before" json:"before,omitempty" swaggertype:"object"`
+	After        JSONRaw    `db:"after" json:"after,omitempty" swaggertype:"object"`
+	// Details holds the path parameters and query of the request
+	Details   JSONRaw   `db:"details" json:"details,omitempty" swaggertype:"object"`
+	Status    int       `db:"status" json:"status" example:"202"`
+	IP        string    `db:"ip" json:"ip"`
+	UserAgent string    `db:"user_agent" json:"user_agent"`
+	RequestID string    `db:"request_id" json:"request_id,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// AuditLogFilter selects audit log entries, empty fields match any entry.
+type AuditLogFilter struct {
+	ActorUserID  *ulid.ULID
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Since        *time.Time
+	Until        *time.Time
+}
@@ -0,0 +1,21 @@
+package schema
+
+// CrawlCheckpoint is the progress of a crawl interrupted by a worker
+// shutdown, stored as JSON with the website so the next crawl resumes it.
+type CrawlCheckpoint struct {
+	// Visited are the normalized URLs the crawl fetched or skipped
+	Visited []string `json:"visited"`
+	// Pending are the URLs found but not fetched yet
+	Pending []PendingURL `json:"pending"`
+	// Pages requested, and pages saved and failed so far
+	Pages     int `json:"pages"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// PendingURL is a URL an interrupted crawl still has to fetch, at its link
+// depth from the start URL.
+type PendingURL struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+}
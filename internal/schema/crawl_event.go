@@ -0,0 +1,36 @@
+package schema
+
+import "time"
+
+// Crawl event levels
+const (
+	CrawlEventLevelInfo  = "info"
+	CrawlEventLevelWarn  = "warn"
+	CrawlEventLevelError = "error"
+)
+
+// Crawl event types
+const (
+	CrawlEventRobotsBlocked = "robots_blocked"
+	CrawlEventRobotsIgnored = "robots_ignored" // fetched although disallowed, robots.txt is bypassed
+	CrawlEventLowQuality    = "low_quality"
+	CrawlEventRedirected    = "redirected"
+	CrawlEventError         = "error"
+	CrawlEventTrapped       = "trapped"
+	CrawlEventBudget        = "budget_exhausted"
+	CrawlEventStalled       = "crawl_stalled"     // no page activity, e.g. after the worker crashed
+	CrawlEventInterrupted   = "crawl_interrupted" // stopped by a worker shutdown, progress saved
+	CrawlEventResumed       = "crawl_resumed"
+	CrawlEventUnchanged     = "sitemap_unchanged" // pages not indexed again, unchanged since their last crawl
+)
+
+// CrawlEvent represents a notable event that happened while crawling a page.
+type CrawlEvent struct {
+	ID        uint64    `db:"id" json:"id"`
+	WebsiteID uint      `db:"website_id" json:"website_id"`
+	URL       string    `db:"url" json:"url"`
+	Level     string    `db:"level" json:"level"`
+	Event     string    `db:"event" json:"event"`
+	Reason    string    `db:"reason" json:"reason"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
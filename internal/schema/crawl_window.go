@@ -0,0 +1,64 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+)
+
+// CrawlWindow is the daily time range, in UTC, in which crawls of a website
+// may start. Windows ending before they start span midnight, e.g.
+// 22:00-04:00.
+type CrawlWindow struct {
+	Start int // minutes after midnight
+	End   int // minutes after midnight, exclusive
+}
+
+// ParseCrawlWindow parses the HH:MM start and end of a crawl window.
+func ParseCrawlWindow(start, end string) (CrawlWindow, error) {
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return CrawlWindow{}, fmt.Errorf("invalid crawl window start %q, expected HH:MM", start)
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return CrawlWindow{}, fmt.Errorf("invalid crawl window end %q, expected HH:MM", end)
+	}
+
+	window := CrawlWindow{
+		Start: startTime.Hour()*60 + startTime.Minute(),
+		End:   endTime.Hour()*60 + endTime.Minute(),
+	}
+	if window.Start == window.End {
+		return CrawlWindow{}, fmt.Errorf("crawl window can't start and end at the same time")
+	}
+	return window, nil
+}
+
+// Contains reports whether crawls may start at t.
+func (w CrawlWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	minute := t.Hour()*60 + t.Minute()
+	if w.Start < w.End {
+		return minute >= w.Start && minute < w.End
+	}
+	return minute >= w.Start || minute < w.End
+}
+
+// Delay returns how long after t the window opens, 0 when it is open.
+func (w CrawlWindow) Delay(t time.Time) time.Duration {
+	if w.Contains(t) {
+		return 0
+	}
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	opens := midnight.Add(time.Duration(w.Start) * time.Minute)
+	if !opens.After(t) {
+		opens = opens.Add(24 * time.Hour)
+	}
+	return opens.Sub(t)
+}
+
+// String formats the window as HH:MM-HH:MM UTC.
+func (w CrawlWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d UTC", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
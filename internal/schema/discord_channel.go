@@ -0,0 +1,16 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// DiscordChannel links a Discord channel to the website questions asked in
+// it, and in its threads, are answered from.
+type DiscordChannel struct {
+	ChannelID string     `db:"channel_id" json:"channel_id" example:"1234567890123456789"`
+	WebsiteID uint       `db:"website_id" json:"website_id"`
+	CreatedBy *ulid.ULID `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,64 @@
+package schema
+
+import (
+	"time"
+)
+
+// Evaluation scorer constants
+const (
+	EvalScorerOverlap = "overlap"
+	EvalScorerLLM     = "llm"
+)
+
+// Evaluation run status constants
+const (
+	EvalRunPending   = "pending"
+	EvalRunRunning   = "running"
+	EvalRunCompleted = "completed"
+	EvalRunFailed    = "failed"
+)
+
+// EvalCase is a question with the answer a website's RAG pipeline is
+// expected to give.
+type EvalCase struct {
+	ID             uint      `db:"id" json:"id"`
+	WebsiteID      uint      `db:"website_id" json:"website_id"`
+	Question       string    `db:"question" json:"question"`
+	ExpectedAnswer string    `db:"expected_answer" json:"expected_answer"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+// EvalRun is a run of all evaluation cases of a website. Settings holds the
+// retrieval settings the run used, and the metrics are set once it completed.
+type EvalRun struct {
+	ID            uint       `db:"id" json:"id"`
+	WebsiteID     uint       `db:"website_id" json:"website_id"`
+	Status        string     `db:"status" json:"status"`
+	Scorer        string     `db:"scorer" json:"scorer"`
+	Settings      JSONRaw    `db:"settings" json:"settings" swaggertype:"object"`
+	Cases         int        `db:"cases" json:"cases"`
+	Answered      int        `db:"answered" json:"answered"`
+	MeanScore     *float64   `db:"mean_score" json:"mean_score,omitempty"`
+	PassRate      *float64   `db:"pass_rate" json:"pass_rate,omitempty"`
+	MeanLatencyMS *float64   `db:"mean_latency_ms" json:"mean_latency_ms,omitempty"`
+	Error         *string    `db:"error" json:"error,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	StartedAt     *time.Time `db:"started_at" json:"started_at,omitempty"`
+	CompletedAt   *time.Time `db:"completed_at" json:"completed_at,omitempty"`
+}
+
+// EvalResult is the answer and score of a single case in a run.
+type EvalResult struct {
+	ID              uint      `db:"id" json:"id"`
+	RunID           uint      `db:"run_id" json:"run_id"`
+	CaseID          *uint     `db:"case_id" json:"case_id,omitempty"`
+	Question        string    `db:"question" json:"question"`
+	ExpectedAnswer  string    `db:"expected_answer" json:"expected_answer"`
+	Answer          string    `db:"answer" json:"answer"`
+	Score           float64   `db:"score" json:"score"`
+	RetrievedChunks int       `db:"retrieved_chunks" json:"retrieved_chunks"`
+	Degraded        bool      `db:"degraded" json:"degraded"`
+	LatencyMS       int64     `db:"latency_ms" json:"latency_ms"`
+	Error           *string   `db:"error" json:"error,omitempty"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,52 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Limits of the labels of a website.
+const (
+	MaxWebsiteLabels      = 20
+	MaxWebsiteLabelLength = 50
+)
+
+// LabelCount is a website label with the number of websites labelled with it.
+type LabelCount struct {
+	Label    string `json:"label"`
+	Websites int    `json:"websites"`
+}
+
+// NormalizeLabel returns label in the form labels are stored in, lower case
+// with words joined by hyphens, so "Internal Docs" matches "internal-docs".
+func NormalizeLabel(label string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(label, "-", " "))), "-")
+}
+
+// NormalizeLabels normalizes the labels of a website, dropping empty and
+// duplicate ones, and checks their number and length.
+func NormalizeLabels(labels []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, label := range labels {
+		label = NormalizeLabel(label)
+		if label == "" || seen[label] {
+			continue
+		}
+		if len(label) > MaxWebsiteLabelLength {
+			return nil, fmt.Errorf("labels must be at most %d characters", MaxWebsiteLabelLength)
+		}
+		seen[label] = true
+		normalized = append(normalized, label)
+	}
+	if len(normalized) > MaxWebsiteLabels {
+		return nil, fmt.Errorf("at most %d labels are allowed", MaxWebsiteLabels)
+	}
+	return normalized, nil
+}
+
+// HasLabel reports whether the website is labelled with the normalized label.
+func (w *Website) HasLabel(label string) bool {
+	return slices.Contains(w.Labels, label)
+}
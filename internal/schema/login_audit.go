@@ -0,0 +1,27 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Login failure reasons recorded in the login audit
+const (
+	LoginReasonInvalidCredentials = "invalid_credentials"
+	LoginReasonInactive           = "inactive"
+	LoginReasonLockedOut          = "locked_out"
+)
+
+// LoginAttempt is a successful or failed login recorded for auditing.
+// UserID is nil when the email doesn't belong to an account.
+type LoginAttempt struct {
+	ID        int64      `db:"id" json:"id"`
+	UserID    *ulid.ULID `db:"user_id" json:"-"`
+	Email     string     `db:"email" json:"email"`
+	IP        string     `db:"ip" json:"ip"`
+	UserAgent string     `db:"user_agent" json:"user_agent"`
+	Success   bool       `db:"success" json:"success"`
+	Reason    string     `db:"reason" json:"reason,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,35 @@
+package schema
+
+import "fmt"
+
+// How the noise patterns of a website combine with CONTENT_NOISE_PATTERNS.
+const (
+	NoiseRemovalExtend  = "extend"  // server patterns and the website's
+	NoiseRemovalReplace = "replace" // only the website's patterns
+	NoiseRemovalOff     = "off"     // no noise removal
+)
+
+// Limits of the noise patterns of a website.
+const (
+	MaxNoisePatterns      = 50
+	MaxNoisePatternLength = 200
+)
+
+// ValidateNoiseRemoval checks the noise removal mode and the number and
+// length of the noise patterns of a website.
+func ValidateNoiseRemoval(mode string, patterns []string) error {
+	switch mode {
+	case NoiseRemovalExtend, NoiseRemovalReplace, NoiseRemovalOff:
+	default:
+		return fmt.Errorf("noise_removal must be one of %s, %s or %s", NoiseRemovalExtend, NoiseRemovalReplace, NoiseRemovalOff)
+	}
+	if len(patterns) > MaxNoisePatterns {
+		return fmt.Errorf("at most %d noise patterns are allowed", MaxNoisePatterns)
+	}
+	for _, pattern := range patterns {
+		if len(pattern) > MaxNoisePatternLength {
+			return fmt.Errorf("noise patterns must be at most %d characters", MaxNoisePatternLength)
+		}
+	}
+	return nil
+}
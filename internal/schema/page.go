@@ -2,9 +2,39 @@ package schema
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
+// PageSnippetLength is the maximum number of characters of cleaned text
+// stored with a page for previews.
+const PageSnippetLength = 500
+
+// PageStatusSkipped marks a discovered page that was deliberately not indexed.
+const PageStatusSkipped = "skipped"
+
+// Reasons a discovered page was skipped.
+const (
+	SkipReasonRobots    = "robots"    // disallowed by robots.txt
+	SkipReasonPattern   = "pattern"   // URL looks like a crawler trap
+	SkipReasonDepth     = "depth"     // deeper than the maximum crawl depth
+	SkipReasonBudget    = "budget"    // a crawl budget was exhausted
+	SkipReasonDuplicate = "duplicate" // redirects to a page that was already crawled
+	SkipReasonQuality   = "quality"   // extracted content too short or low quality
+	SkipReasonSize      = "size"      // response larger than the maximum body size
+	SkipReasonMIME      = "mime"      // response is not an HTML document
+	SkipReasonScope     = "scope"     // outside the crawl scope of the website
+)
+
+// Vectorization statuses of a page's content.
+const (
+	VectorStatusPending    = "pending"    // queued for vectorization
+	VectorStatusVectorized = "vectorized" // chunks stored in ChromaDB
+	VectorStatusFailed     = "failed"     // last vectorization attempt failed
+)
+
 // Page represents a crawled page in the database.
 type Page struct {
 	ID             uint           `db:"id"`
@@ -14,7 +44,109 @@ type Page struct {
 	ContentHash    sql.NullString `db:"content_hash"`
 	Status         string         `db:"status"`
 	ErrorMessage   sql.NullString `db:"error_message"`
+	Title          sql.NullString `db:"title"`
+	Snippet        sql.NullString `db:"snippet"`
+	HTTPStatus     sql.NullInt32  `db:"http_status"`
+	RetryCount     int            `db:"retry_count"`
+	SkipReason     sql.NullString `db:"skip_reason"`
 	CrawledAt      sql.NullTime   `db:"crawled_at"`
 	CreatedAt      time.Time      `db:"created_at"`
 	UpdatedAt      time.Time      `db:"updated_at"`
+
+	// LLM summary of the content with the content hash it was generated from
+	Summary            sql.NullString `db:"summary"`
+	SummaryContentHash sql.NullString `db:"summary_content_hash"`
+	SummarizedAt       sql.NullTime   `db:"summarized_at"`
+
+	// Images of the main content with their alt text and captions
+	Images PageImages `db:"images"`
+
+	// Screenshot of the rendered page from its last crawl
+	ScreenshotKey sql.NullString `db:"screenshot_key"`
+	ScreenshotAt  sql.NullTime   `db:"screenshot_at"`
+
+	// Raw HTML of the last fetch, archived for reprocessing
+	HTMLObjectKey  sql.NullString `db:"html_object_key"`
+	HTMLArchivedAt sql.NullTime   `db:"html_archived_at"`
+
+	// Whether the chunks of the content were stored in ChromaDB, with the
+	// error of the last failed attempt
+	VectorStatus sql.NullString `db:"vector_status"`
+	VectorError  sql.NullString `db:"vector_error"`
+	ChunkCount   sql.NullInt32  `db:"chunk_count"`
+	VectorizedAt sql.NullTime   `db:"vectorized_at"`
+
+	// Topics extracted from the content, stored in page_tags
+	Tags []string `db:"-"`
+}
+
+// PageImage is an image of a page's main content with the text describing
+// it.
+type PageImage struct {
+	Src     string `json:"src"`
+	Alt     string `json:"alt,omitempty"`
+	Caption string `json:"caption,omitempty"`
+	// Context is the text of the paragraph around the image
+	Context string `json:"context,omitempty"`
+}
+
+// PageImages are the images of a page stored as a JSON array in the database.
+type PageImages []PageImage
+
+// Value implements driver.Valuer.
+func (p PageImages) Value() (driver.Value, error) {
+	if p == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]PageImage(p))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (p *PageImages) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]PageImage)(p))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]PageImage)(p))
+	default:
+		return fmt.Errorf("cannot scan %T into PageImages", src)
+	}
+}
+
+// SearchHit is a page matching a full-text search.
+type SearchHit struct {
+	PageID uint    `db:"id" json:"page_id"`
+	URL    string  `db:"url" json:"url"`
+	Title  string  `db:"title" json:"title,omitempty"`
+	Rank   float64 `db:"rank" json:"rank"`
+	// Highlights are the passages of the page matching the search, with
+	// the matches wrapped in <mark> tags and the rest HTML escaped
+	Highlights []string `db:"-" json:"highlights"`
+
+	Snippet   string `db:"snippet" json:"-"`
+	ObjectKey string `db:"minio_object_key" json:"-"`
+}
+
+// PIIReport counts the personal data redacted from a website's pages.
+type PIIReport struct {
+	Enabled bool `json:"enabled"`
+	// PagesRedacted is the number of pages personal data was redacted from
+	PagesRedacted int `json:"pages_redacted"`
+	Redactions    int `json:"redactions"`
+	// ByKind counts the redactions of each kind: email, phone, ssn and the
+	// kinds of PII_PATTERNS
+	ByKind map[string]int `json:"by_kind"`
+}
+
+// TagCount is a tag with the number of pages of a website tagged with it.
+type TagCount struct {
+	Tag   string `db:"tag" json:"tag"`
+	Pages int    `db:"pages" json:"pages"`
 }
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// QueryCacheEntry represents a cached RAG answer for a query.
+type QueryCacheEntry struct {
+	ID              uint         `db:"id"`
+	WebsiteID       uint         `db:"website_id"`
+	Query           string       `db:"query"`
+	Embedding       Float32Slice `db:"embedding"`
+	Answer          string       `db:"answer"`
+	Sources         JSONRaw      `db:"sources"`
+	RetrievedChunks int          `db:"retrieved_chunks"`
+	HitCount        int          `db:"hit_count"`
+	CreatedAt       time.Time    `db:"created_at"`
+	ExpiresAt       time.Time    `db:"expires_at"`
+}
+
+// Float32Slice is a []float32 stored as a JSON array in the database.
+type Float32Slice []float32
+
+// Value implements driver.Valuer.
+func (f Float32Slice) Value() (driver.Value, error) {
+	if f == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]float32(f))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (f *Float32Slice) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*f = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]float32)(f))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]float32)(f))
+	default:
+		return fmt.Errorf("cannot scan %T into Float32Slice", src)
+	}
+}
+
+// JSONRaw is raw JSON stored in a JSONB column.
+type JSONRaw []byte
+
+// Value implements driver.Valuer.
+func (j JSONRaw) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return "null", nil
+	}
+	return string(j), nil
+}
+
+// Scan implements sql.Scanner.
+func (j *JSONRaw) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*j = nil
+		return nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+		return nil
+	case string:
+		*j = JSONRaw(v)
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into JSONRaw", src)
+	}
+}
+
+// MarshalJSON returns the raw JSON.
+func (j JSONRaw) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
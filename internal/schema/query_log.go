@@ -0,0 +1,81 @@
+package schema
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// QueryLog represents a recorded RAG query and its answer.
+type QueryLog struct {
+	ID              uint       `db:"id" json:"id"`
+	WebsiteID       uint       `db:"website_id" json:"website_id"`
+	UserID          *ulid.ULID `db:"user_id" json:"user_id,omitempty"`
+	Query           string     `db:"query" json:"query"`
+	Answer          string     `db:"answer" json:"answer"`
+	Sources         JSONRaw    `db:"sources" json:"sources"`
+	RetrievedChunks int        `db:"retrieved_chunks" json:"retrieved_chunks"`
+	LatencyMS       int64      `db:"latency_ms" json:"latency_ms"`
+	Cached          bool       `db:"cached" json:"cached"`
+	Streamed        bool       `db:"streamed" json:"streamed"`
+	// Tokens the LLM processed for the answer
+	PromptTokens     int       `db:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int       `db:"completion_tokens" json:"completion_tokens"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+}
+
+// QueryFeedback represents a user's rating of a query answer.
+type QueryFeedback struct {
+	ID         uint           `db:"id" json:"id"`
+	QueryLogID uint           `db:"query_log_id" json:"query_log_id"`
+	UserID     *ulid.ULID     `db:"user_id" json:"user_id,omitempty"`
+	Rating     int            `db:"rating" json:"rating"`
+	Comment    sql.NullString `db:"comment" json:"-"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// Feedback rating constants
+const (
+	FeedbackThumbsUp   = 1
+	FeedbackThumbsDown = -1
+)
+
+// QueryFeedbackRequest represents the request to rate a query answer
+type QueryFeedbackRequest struct {
+	Rating  string `json:"rating" validate:"required,oneof=up down" example:"up"`
+	Comment string `json:"comment,omitempty" example:"The answer cited the wrong page"`
+}
+
+// FeedbackStats aggregates query and feedback counts for a website.
+type FeedbackStats struct {
+	WebsiteID        uint    `db:"website_id" json:"website_id"`
+	TotalQueries     int     `db:"total_queries" json:"total_queries"`
+	CachedQueries    int     `db:"cached_queries" json:"cached_queries"`
+	AvgLatencyMS     float64 `db:"avg_latency_ms" json:"avg_latency_ms"`
+	TotalFeedback    int     `db:"total_feedback" json:"total_feedback"`
+	ThumbsUp         int     `db:"thumbs_up" json:"thumbs_up"`
+	ThumbsDown       int     `db:"thumbs_down" json:"thumbs_down"`
+	SatisfactionRate float64 `db:"-" json:"satisfaction_rate"`
+}
+
+// UsageStats aggregates the LLM token usage of queries, per website or per
+// user. EstimatedCost prices the tokens with the configured per 1K token
+// costs.
+type UsageStats struct {
+	WebsiteID        *uint   `db:"website_id" json:"website_id,omitempty"`
+	UserID           *string `db:"user_id" json:"user_id,omitempty"`
+	Queries          int     `db:"queries" json:"queries"`
+	CachedQueries    int     `db:"cached_queries" json:"cached_queries"`
+	PromptTokens     int64   `db:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int64   `db:"completion_tokens" json:"completion_tokens"`
+	TotalTokens      int64   `db:"-" json:"total_tokens"`
+	EstimatedCost    float64 `db:"-" json:"estimated_cost"`
+}
+
+// Price fills in the total tokens and the estimated cost of the usage.
+func (u *UsageStats) Price(costPer1KPrompt, costPer1KCompletion float64) {
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	u.EstimatedCost = float64(u.PromptTokens)/1000*costPer1KPrompt + float64(u.CompletionTokens)/1000*costPer1KCompletion
+}
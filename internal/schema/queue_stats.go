@@ -0,0 +1,23 @@
+package schema
+
+import "time"
+
+// QueueSample is a snapshot of the stats of a job queue.
+type QueueSample struct {
+	ID        int64  `db:"id"`
+	Queue     string `db:"queue"`
+	Pending   int    `db:"pending"`
+	Active    int    `db:"active"`
+	Scheduled int    `db:"scheduled"`
+	Retry     int    `db:"retry"`
+	Archived  int    `db:"archived"`
+	Completed int    `db:"completed"`
+	// Tasks processed and failed since the queue was created, processed
+	// including the failed ones
+	ProcessedTotal int64 `db:"processed_total"`
+	FailedTotal    int64 `db:"failed_total"`
+	// LatencyMS is the age of the oldest pending task
+	LatencyMS int64     `db:"latency_ms"`
+	Paused    bool      `db:"paused"`
+	SampledAt time.Time `db:"sampled_at"`
+}
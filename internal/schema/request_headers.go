@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// MaxUserAgentRotation limits the number of user agents rotated between the
+// requests of a website.
+const MaxUserAgentRotation = 20
+
+// RequestHeaders are extra headers sent with every request for a website,
+// stored as a JSON object in the database. Unlike credentials they are not
+// secret and are shown with the website.
+type RequestHeaders map[string]string
+
+// Validate checks that the headers can be sent. The user agent is set with
+// its own setting.
+func (h RequestHeaders) Validate() error {
+	if err := validateHeaders(h); err != nil {
+		return err
+	}
+	for name := range h {
+		if textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name)) == "User-Agent" {
+			return fmt.Errorf("set the user agent with user_agent instead of a header")
+		}
+	}
+	return nil
+}
+
+// Apply sets the headers on a request header.
+func (h RequestHeaders) Apply(header http.Header) {
+	for name, value := range h {
+		header.Set(strings.TrimSpace(name), value)
+	}
+}
+
+// Value implements driver.Valuer.
+func (h RequestHeaders) Value() (driver.Value, error) {
+	if h == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(map[string]string(h))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (h *RequestHeaders) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*map[string]string)(h))
+	case string:
+		return json.Unmarshal([]byte(v), (*map[string]string)(h))
+	default:
+		return fmt.Errorf("cannot scan %T into RequestHeaders", src)
+	}
+}
+
+// ValidateUserAgents checks a website's user agent and rotation list.
+func ValidateUserAgents(userAgent string, rotation []string) error {
+	if len(rotation) > MaxUserAgentRotation {
+		return fmt.Errorf("at most %d user agents can be rotated", MaxUserAgentRotation)
+	}
+	for _, agent := range append([]string{userAgent}, rotation...) {
+		if len(agent) > 512 || strings.ContainsAny(agent, "\r\n") {
+			return fmt.Errorf("invalid user agent %q", agent)
+		}
+	}
+	for _, agent := range rotation {
+		if strings.TrimSpace(agent) == "" {
+			return fmt.Errorf("rotated user agents can't be empty")
+		}
+	}
+	return nil
+}
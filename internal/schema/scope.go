@@ -0,0 +1,153 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ScopeWildcard matches any resource, instance or action.
+const ScopeWildcard = "*"
+
+// scopeSegmentPattern matches a single non-wildcard scope segment.
+var scopeSegmentPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ScopeResource describes a resource API key scopes can grant access to.
+type ScopeResource struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Actions     []string `json:"actions"`
+}
+
+// ScopeResources lists the resources and actions that scopes can refer to.
+var ScopeResources = []ScopeResource{
+	{
+		Name:        "websites",
+		Description: "Websites and their pages, crawls and queries. Instances are website IDs.",
+		Actions:     []string{"read", "write", "delete", "crawl", "query"},
+	},
+	{
+		Name:        "api_keys",
+		Description: "API keys and sessions of the user.",
+		Actions:     []string{"read", "write", "delete"},
+	},
+	{
+		Name:        "jobs",
+		Description: "Background jobs and queues.",
+		Actions:     []string{"read", "write"},
+	},
+}
+
+// ScopeGrammar documents the scope syntax accepted when creating API keys.
+type ScopeGrammar struct {
+	Syntax    []string          `json:"syntax"`
+	Rules     []string          `json:"rules"`
+	Resources []ScopeResource   `json:"resources"`
+	Examples  map[string]string `json:"examples"`
+}
+
+// ScopeDocumentation describes the scope grammar.
+var ScopeDocumentation = ScopeGrammar{
+	Syntax: []string{
+		"*",
+		"<resource>:*",
+		"<resource>:<action>",
+		"<resource>:<instance>:<action>",
+	},
+	Rules: []string{
+		"Segments are separated by ':' and contain lowercase letters, digits, '-' or '_'.",
+		"'*' in a segment matches any value of that segment.",
+		"<resource>:<action> is short for <resource>:*:<action>.",
+		"<resource>:* is short for <resource>:*:*.",
+		"A key without scopes has full access.",
+	},
+	Resources: ScopeResources,
+	Examples: map[string]string{
+		"*":                 "Full access",
+		"websites:read":     "Read all websites",
+		"websites:*:read":   "Read all websites",
+		"websites:42:query": "Query website 42 only",
+		"websites:42:*":     "Any action on website 42",
+		"websites:*":        "Any action on any website",
+		"jobs:read":         "View background jobs",
+		"api_keys:read":     "List the API keys",
+	},
+}
+
+// expandScope normalizes a scope to its resource, instance and action segments.
+func expandScope(scope string) ([3]string, bool) {
+	parts := strings.Split(scope, ":")
+	switch len(parts) {
+	case 1:
+		if parts[0] == ScopeWildcard {
+			return [3]string{ScopeWildcard, ScopeWildcard, ScopeWildcard}, true
+		}
+	case 2:
+		return [3]string{parts[0], ScopeWildcard, parts[1]}, true
+	case 3:
+		return [3]string{parts[0], parts[1], parts[2]}, true
+	}
+	return [3]string{}, false
+}
+
+// ValidateScope checks that a scope follows the scope grammar and refers to a
+// known resource and action.
+func ValidateScope(scope string) error {
+	segments, ok := expandScope(scope)
+	if !ok {
+		return fmt.Errorf("invalid scope %q: expected *, <resource>:<action> or <resource>:<instance>:<action>", scope)
+	}
+
+	for _, segment := range segments {
+		if segment != ScopeWildcard && !scopeSegmentPattern.MatchString(segment) {
+			return fmt.Errorf("invalid scope %q: segment %q contains invalid characters", scope, segment)
+		}
+	}
+
+	resource, action := segments[0], segments[2]
+	if resource == ScopeWildcard {
+		if scope != ScopeWildcard {
+			return fmt.Errorf("invalid scope %q: resource wildcard is only allowed as *", scope)
+		}
+		return nil
+	}
+
+	for _, r := range ScopeResources {
+		if r.Name != resource {
+			continue
+		}
+		if action == ScopeWildcard {
+			return nil
+		}
+		for _, a := range r.Actions {
+			if a == action {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid scope %q: unknown action %q for %s", scope, action, resource)
+	}
+
+	return fmt.Errorf("invalid scope %q: unknown resource %q", scope, resource)
+}
+
+// ScopeMatches reports whether a granted scope covers a required scope. Each
+// segment of the granted scope must be a wildcard or equal to the required one,
+// so websites:*:read covers websites:42:read but websites:42:read does not
+// cover websites:*:read.
+func ScopeMatches(granted, required string) bool {
+	g, ok := expandScope(granted)
+	if !ok {
+		return false
+	}
+	r, ok := expandScope(required)
+	if !ok {
+		return false
+	}
+
+	for i := range g {
+		if g[i] != ScopeWildcard && g[i] != r[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,16 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// SlackChannel links a Slack channel to the website questions asked in it
+// are answered from.
+type SlackChannel struct {
+	ChannelID string     `db:"channel_id" json:"channel_id" example:"C0123456789"`
+	WebsiteID uint       `db:"website_id" json:"website_id"`
+	CreatedBy *ulid.ULID `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
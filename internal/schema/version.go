@@ -0,0 +1,10 @@
+package schema
+
+import "time"
+
+// Version identifies the state of a set of rows, e.g. to derive HTTP ETags.
+// It changes whenever a row is added, updated or deleted.
+type Version struct {
+	Count     int       `db:"count" json:"count"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
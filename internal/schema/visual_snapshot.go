@@ -0,0 +1,19 @@
+package schema
+
+import (
+	"database/sql"
+	"time"
+)
+
+// VisualSnapshot represents a perceptual hash of a page screenshot taken during a crawl.
+type VisualSnapshot struct {
+	ID          uint           `db:"id" json:"id"`
+	WebsiteID   uint           `db:"website_id" json:"website_id"`
+	PageID      uint           `db:"page_id" json:"page_id"`
+	PageURL     string         `db:"page_url" json:"page_url"`
+	PHash       int64          `db:"phash" json:"phash"`
+	ObjectKey   sql.NullString `db:"object_key" json:"-"`
+	ChangeScore float64        `db:"change_score" json:"change_score"`
+	TextChanged bool           `db:"text_changed" json:"text_changed"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+}
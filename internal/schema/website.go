@@ -19,6 +19,98 @@ type Website struct {
 	TotalPagesCrawled int            `db:"total_pages_crawled"`
 	TotalPagesFailed  int            `db:"total_pages_failed"`
 	LastError         sql.NullString `db:"last_error"`
+	TLSSkipVerify     bool           `db:"tls_skip_verify"`
+	VisualMonitoring  bool           `db:"visual_monitoring"`
 	CreatedAt         time.Time      `db:"created_at"`
 	UpdatedAt         time.Time      `db:"updated_at"`
+
+	// Crawl budgets, 0 uses the global default
+	MaxCrawlBytes           int64          `db:"max_crawl_bytes"`
+	MaxCrawlDurationSeconds int            `db:"max_crawl_duration_seconds"`
+	MaxPagesPerPrefix       int            `db:"max_pages_per_prefix"`
+	BudgetExhausted         sql.NullString `db:"budget_exhausted"`
+
+	// Minimum content quality of indexed pages, 0 uses the global default
+	MinContentQuality float64 `db:"min_content_quality"`
+
+	// PIIRedaction redacts personal data from pages before they are stored
+	// and vectorized
+	PIIRedaction bool `db:"pii_redaction"`
+
+	// How NoisePatterns combine with CONTENT_NOISE_PATTERNS, one of the
+	// NoiseRemoval constants
+	NoiseRemoval  string   `db:"noise_removal"`
+	NoisePatterns []string `db:"noise_patterns"`
+
+	// Daily window in UTC crawls may start in, in minutes after midnight;
+	// null when crawls may start at any time
+	CrawlWindowStart sql.NullInt32 `db:"crawl_window_start"`
+	CrawlWindowEnd   sql.NullInt32 `db:"crawl_window_end"`
+
+	// RespectRobots overrides CRAWLER_RESPECT_ROBOTS_TXT, null uses it
+	RespectRobots sql.NullBool `db:"respect_robots"`
+
+	// User agent the website is crawled with instead of the global one,
+	// user agents rotated between its requests and extra headers sent with
+	// every request, including for robots.txt and sitemaps
+	UserAgent         string         `db:"user_agent"`
+	UserAgentRotation []string       `db:"user_agent_rotation"`
+	RequestHeaders    RequestHeaders `db:"request_headers"`
+
+	// Start URLs crawled besides URL, and the URL prefixes crawls are
+	// restricted to, empty for the whole host
+	SeedURLs      []string `db:"seed_urls"`
+	ScopePrefixes []string `db:"scope_prefixes"`
+
+	// Labels group websites, e.g. docs or competitor, in their normalized
+	// form
+	Labels []string `db:"labels"`
+
+	// Sitemaps discovered from robots.txt or common locations
+	SitemapURLs []string `db:"sitemap_urls"`
+
+	// LLM rollup of the page summaries
+	Summary      sql.NullString `db:"summary"`
+	SummarizedAt sql.NullTime   `db:"summarized_at"`
+
+	// Vectors and stored objects of the website, refreshed after crawls and
+	// vectorization batches
+	VectorCount    sql.NullInt64 `db:"vector_count"`
+	StorageObjects sql.NullInt64 `db:"storage_objects"`
+	StorageBytes   sql.NullInt64 `db:"storage_bytes"`
+	IndexStatsAt   sql.NullTime  `db:"index_stats_at"`
+}
+
+// CrawlWindow returns the window crawls of the website may start in, false
+// when they may start at any time.
+func (w *Website) CrawlWindow() (CrawlWindow, bool) {
+	if !w.CrawlWindowStart.Valid || !w.CrawlWindowEnd.Valid {
+		return CrawlWindow{}, false
+	}
+	return CrawlWindow{Start: int(w.CrawlWindowStart.Int32), End: int(w.CrawlWindowEnd.Int32)}, true
+}
+
+// CrawlDelay returns how long a crawl starting at t has to wait for the
+// website's crawl window to open.
+func (w *Website) CrawlDelay(t time.Time) time.Duration {
+	window, ok := w.CrawlWindow()
+	if !ok {
+		return 0
+	}
+	return window.Delay(t)
+}
+
+// SuggestedQuestions are questions generated from a website's content that
+// users could ask about it.
+type SuggestedQuestions struct {
+	Questions   []string  `db:"suggested_questions" json:"questions"`
+	GeneratedAt time.Time `db:"suggested_questions_at" json:"generated_at"`
+}
+
+// StalledCrawl is a website marked as crawling without page activity since
+// LastActivityAt.
+type StalledCrawl struct {
+	WebsiteID      uint      `db:"id"`
+	URL            string    `db:"url"`
+	LastActivityAt time.Time `db:"last_activity_at"`
 }
@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Kinds of connectors ingesting a website's pages through an API instead of
+// crawling them.
+const (
+	ConnectorKindNotion     = "notion"
+	ConnectorKindConfluence = "confluence"
+)
+
+// ConnectorConfig configures the connector a website's pages are ingested
+// with, including its credentials.
+type ConnectorConfig struct {
+	Kind string `json:"kind" example:"confluence" enums:"notion,confluence"`
+	// Token is the Notion integration token, or the personal access token of
+	// Confluence Server and Data Center
+	Token string `json:"token,omitempty"`
+	// BaseURL of the Confluence site, e.g. "https://acme.atlassian.net/wiki"
+	BaseURL string `json:"base_url,omitempty" example:"https://acme.atlassian.net/wiki"`
+	// Email and APIToken authenticate to Confluence Cloud
+	Email    string `json:"email,omitempty" example:"docs-bot@acme.com"`
+	APIToken string `json:"api_token,omitempty"`
+	// SpaceKey of the Confluence space to ingest
+	SpaceKey string `json:"space_key,omitempty" example:"ENG"`
+}
+
+// Validate checks that the connector config is complete.
+func (cc *ConnectorConfig) Validate() error {
+	switch cc.Kind {
+	case ConnectorKindNotion:
+		if cc.Token == "" {
+			return fmt.Errorf("notion connector requires an integration token")
+		}
+	case ConnectorKindConfluence:
+		u, err := url.Parse(strings.TrimSpace(cc.BaseURL))
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("confluence connector requires a valid base URL")
+		}
+		if cc.SpaceKey == "" {
+			return fmt.Errorf("confluence connector requires a space key")
+		}
+		if cc.Token == "" && (cc.Email == "" || cc.APIToken == "") {
+			return fmt.Errorf("confluence connector requires an email and API token or a personal access token")
+		}
+	default:
+		return fmt.Errorf("unsupported connector kind %q", cc.Kind)
+	}
+	return nil
+}
+
+// ConnectorSummary describes a connector without revealing its tokens.
+type ConnectorSummary struct {
+	Kind     string `json:"kind" example:"confluence"`
+	BaseURL  string `json:"base_url,omitempty" example:"https://acme.atlassian.net/wiki"`
+	Email    string `json:"email,omitempty" example:"docs-bot@acme.com"`
+	SpaceKey string `json:"space_key,omitempty" example:"ENG"`
+}
+
+// Summary returns a summary of the connector that is safe to return to clients.
+func (cc *ConnectorConfig) Summary() *ConnectorSummary {
+	return &ConnectorSummary{
+		Kind:     cc.Kind,
+		BaseURL:  cc.BaseURL,
+		Email:    cc.Email,
+		SpaceKey: cc.SpaceKey,
+	}
+}
@@ -0,0 +1,36 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Website permission constants. Owners and admins have all of them; manage
+// also grants read and query.
+const (
+	WebsitePermissionRead   = "read"   // Website status, pages, tags and crawl history
+	WebsitePermissionQuery  = "query"  // Queries, suggested questions and query feedback
+	WebsitePermissionManage = "manage" // Crawls, crawl settings, credentials and evaluations
+)
+
+// WebsitePermissions lists the permissions that can be granted on a website.
+var WebsitePermissions = []string{WebsitePermissionRead, WebsitePermissionQuery, WebsitePermissionManage}
+
+// WebsitePermission grants a user, or a single API key, access to a website
+// it doesn't own. Exactly one of UserID and APIKeyID is set.
+type WebsitePermission struct {
+	ID         uint       `db:"id" json:"id"`
+	WebsiteID  uint       `db:"website_id" json:"website_id"`
+	UserID     *ulid.ULID `db:"user_id" json:"user_id,omitempty"`
+	UserEmail  *string    `db:"user_email" json:"user_email,omitempty"`
+	APIKeyID   *ulid.ULID `db:"api_key_id" json:"api_key_id,omitempty"`
+	Permission string     `db:"permission" json:"permission"`
+	CreatedBy  *ulid.ULID `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Implies reports whether holding permission p also grants permission other.
+func (p *WebsitePermission) Implies(other string) bool {
+	return p.Permission == other || p.Permission == WebsitePermissionManage
+}
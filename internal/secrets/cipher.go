@@ -0,0 +1,231 @@
+// Package secrets encrypts sensitive values before they are stored.
+//
+// Values are envelope encrypted: each value is encrypted with its own random
+// data key, which is stored next to it wrapped by a key encryption key. Keys
+// are rotated by making a new key current and keeping the previous ones to
+// decrypt existing values until they are rewrapped.
+package secrets
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrDisabled is returned when no encryption key is configured.
+var ErrDisabled = errors.New("no encryption key configured")
+
+// envelopeMagic starts envelope encrypted values. Values without it were
+// encrypted directly with a local key before envelope encryption.
+var envelopeMagic = []byte("HMT1")
+
+// dataKeySize is the size of the AES-256 data keys.
+const dataKeySize = 32
+
+// Cipher envelope encrypts values with AES-256-GCM.
+type Cipher struct {
+	current KeyEncryptionKey
+	keys    map[string]KeyEncryptionKey
+	// legacy decrypts values encrypted directly with a local key
+	legacy []cipher.AEAD
+}
+
+// NewCipher creates a Cipher from base64 encoded 32 byte keys: the current
+// key encrypting new values and previous keys that still decrypt values. It
+// returns nil without an error when key is empty, which disables encryption.
+func NewCipher(key string, previous ...string) (*Cipher, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	current, err := NewLocalKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	old := make([]KeyEncryptionKey, 0, len(previous))
+	for _, p := range previous {
+		k, err := NewLocalKey(p)
+		if err != nil {
+			return nil, fmt.Errorf("previous key: %w", err)
+		}
+		old = append(old, k)
+	}
+
+	return NewKeyringCipher(current, old...), nil
+}
+
+// NewKeyringCipher creates a Cipher encrypting with the current key
+// encryption key and decrypting with any of the keys, e.g. keys held in a KMS.
+func NewKeyringCipher(current KeyEncryptionKey, previous ...KeyEncryptionKey) *Cipher {
+	c := &Cipher{current: current, keys: make(map[string]KeyEncryptionKey)}
+	for _, k := range append([]KeyEncryptionKey{current}, previous...) {
+		c.keys[k.ID()] = k
+		if local, ok := k.(*LocalKey); ok {
+			c.legacy = append(c.legacy, local.aead)
+		}
+	}
+	return c
+}
+
+// KeyID returns the ID of the key new values are encrypted with.
+func (c *Cipher) KeyID() string {
+	if c == nil {
+		return ""
+	}
+	return c.current.ID()
+}
+
+// Encrypt encrypts plaintext with a new data key wrapped by the current key.
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	if c == nil {
+		return nil, ErrDisabled
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := seal(aead, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := c.current.Wrap(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return encodeEnvelope(c.current.ID(), wrapped, sealed)
+}
+
+// Decrypt decrypts a value produced by Encrypt with any of the keys.
+func (c *Cipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	if c == nil {
+		return nil, ErrDisabled
+	}
+
+	keyID, wrapped, sealed, ok := decodeEnvelope(ciphertext)
+	if !ok {
+		return c.decryptLegacy(ciphertext)
+	}
+
+	dataKey, err := c.unwrap(keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, sealed)
+}
+
+// Rotate rewraps the data key of a value with the current key. Values
+// encrypted before envelope encryption are encrypted again. It reports
+// whether the value changed.
+func (c *Cipher) Rotate(ciphertext []byte) ([]byte, bool, error) {
+	if c == nil {
+		return nil, false, ErrDisabled
+	}
+
+	keyID, wrapped, sealed, ok := decodeEnvelope(ciphertext)
+	if !ok {
+		plaintext, err := c.decryptLegacy(ciphertext)
+		if err != nil {
+			return nil, false, err
+		}
+		rotated, err := c.Encrypt(plaintext)
+		return rotated, err == nil, err
+	}
+	if keyID == c.current.ID() {
+		return ciphertext, false, nil
+	}
+
+	dataKey, err := c.unwrap(keyID, wrapped)
+	if err != nil {
+		return nil, false, err
+	}
+	rewrapped, err := c.current.Wrap(dataKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	rotated, err := encodeEnvelope(c.current.ID(), rewrapped, sealed)
+	return rotated, err == nil, err
+}
+
+// unwrap unwraps a data key with the key it was wrapped with.
+func (c *Cipher) unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := c.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key %q", keyID)
+	}
+	dataKey, err := key.Unwrap(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+// decryptLegacy decrypts a value encrypted directly with one of the local keys.
+func (c *Cipher) decryptLegacy(ciphertext []byte) ([]byte, error) {
+	err := errors.New("no local key to decrypt value")
+	for _, aead := range c.legacy {
+		var plaintext []byte
+		if plaintext, err = open(aead, ciphertext); err == nil {
+			return plaintext, nil
+		}
+	}
+	return nil, err
+}
+
+// encodeEnvelope lays out an envelope as the magic, the key ID and the
+// wrapped data key, each prefixed with their length, and the sealed value.
+func encodeEnvelope(keyID string, wrapped, sealed []byte) ([]byte, error) {
+	if len(keyID) > 255 || len(wrapped) > 65535 {
+		return nil, errors.New("key ID or wrapped data key too long")
+	}
+
+	var buf bytes.Buffer
+	buf.Write(envelopeMagic)
+	buf.WriteByte(byte(len(keyID)))
+	buf.WriteString(keyID)
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(wrapped)))
+	buf.Write(wrapped)
+	buf.Write(sealed)
+	return buf.Bytes(), nil
+}
+
+// decodeEnvelope splits an envelope into its parts. It reports false for
+// values that aren't envelopes.
+func decodeEnvelope(ciphertext []byte) (string, []byte, []byte, bool) {
+	rest, ok := bytes.CutPrefix(ciphertext, envelopeMagic)
+	if !ok || len(rest) < 1 {
+		return "", nil, nil, false
+	}
+
+	idLen := int(rest[0])
+	rest = rest[1:]
+	if len(rest) < idLen+2 {
+		return "", nil, nil, false
+	}
+	keyID := string(rest[:idLen])
+	rest = rest[idLen:]
+
+	wrappedLen := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) < wrappedLen {
+		return "", nil, nil, false
+	}
+
+	return keyID, rest[:wrappedLen], rest[wrappedLen:], true
+}
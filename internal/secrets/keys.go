@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// KeyEncryptionKey wraps the data keys values are encrypted with. Keys held
+// in a KMS implement it by calling the KMS' encrypt and decrypt operations.
+type KeyEncryptionKey interface {
+	// ID identifies the key in encrypted values, so they can be decrypted
+	// after the current key was rotated.
+	ID() string
+	Wrap(dataKey []byte) ([]byte, error)
+	Unwrap(wrapped []byte) ([]byte, error)
+}
+
+// LocalKey is a key encryption key configured in the environment.
+type LocalKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewLocalKey creates a LocalKey from a base64 encoded 32 byte key. Its ID is
+// derived from the key.
+func NewLocalKey(key string) (*LocalKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encryption key: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(raw))
+	}
+
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(raw)
+	return &LocalKey{id: "local:" + hex.EncodeToString(sum[:4]), aead: aead}, nil
+}
+
+// ID implements KeyEncryptionKey.
+func (k *LocalKey) ID() string {
+	return k.id
+}
+
+// Wrap implements KeyEncryptionKey.
+func (k *LocalKey) Wrap(dataKey []byte) ([]byte, error) {
+	return seal(k.aead, dataKey)
+}
+
+// Unwrap implements KeyEncryptionKey.
+func (k *LocalKey) Unwrap(wrapped []byte) ([]byte, error) {
+	return open(k.aead, wrapped)
+}
+
+// newAEAD creates an AES-256-GCM AEAD from a 32 byte key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return aead, nil
+}
+
+// seal encrypts plaintext, prefixing the result with a random nonce.
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts a value produced by seal.
+func open(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	size := aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	plaintext, err := aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
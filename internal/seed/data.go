@@ -0,0 +1,100 @@
+package seed
+
+// demoPage is a page of a demo website, stored as if it had been crawled.
+type demoPage struct {
+	URL     string
+	Title   string
+	Tags    []string
+	Content string
+}
+
+// demoWebsite is a small website seeded with its pages already crawled and
+// vectorized.
+type demoWebsite struct {
+	URL     string
+	Summary string
+	Pages   []demoPage
+}
+
+// demoWebsites are the websites created for the demo user. Their URLs are
+// under example.com so recrawling them fetches nothing of value.
+var demoWebsites = []demoWebsite{
+	{
+		URL:     "https://docs.example.com",
+		Summary: "Documentation of Acme Widgets: installing the CLI, configuring projects and deploying widgets.",
+		Pages: []demoPage{
+			{
+				URL:   "https://docs.example.com/",
+				Title: "Acme Widgets Documentation",
+				Tags:  []string{"docs"},
+				Content: `Acme Widgets Documentation
+
+Acme Widgets lets you build, test and deploy small interactive widgets for your website. This documentation covers installing the command line interface, configuring a project and deploying widgets to production.
+
+Start with the installation guide, then follow the configuration reference to set up your first project. The deployment guide explains how widgets are published and rolled back.`,
+			},
+			{
+				URL:   "https://docs.example.com/install",
+				Title: "Installing the CLI",
+				Tags:  []string{"docs", "install"},
+				Content: `Installing the CLI
+
+The acme command line interface runs on Linux, macOS and Windows. Install it with your package manager or download a release binary.
+
+On macOS run brew install acme. On Linux download the archive for your architecture, extract it and move the acme binary to a directory on your PATH. On Windows use winget install acme.
+
+Check the installation with acme version. The CLI requires Node.js 20 or later to build widgets locally.`,
+			},
+			{
+				URL:   "https://docs.example.com/configuration",
+				Title: "Configuration Reference",
+				Tags:  []string{"docs", "configuration"},
+				Content: `Configuration Reference
+
+Projects are configured in an acme.yaml file at the root of the repository. The name field sets the project name shown in the dashboard. The widgets field lists the widgets of the project with their entry points.
+
+The region field selects where widgets are served from, either us, eu or ap, and defaults to us. Set cache_ttl to the number of seconds widget assets are cached by browsers, 3600 by default.
+
+Secrets are never stored in acme.yaml. Set them with acme secrets set NAME VALUE and read them from the environment at runtime.`,
+			},
+			{
+				URL:   "https://docs.example.com/deploy",
+				Title: "Deploying Widgets",
+				Tags:  []string{"docs", "deploy"},
+				Content: `Deploying Widgets
+
+Run acme deploy to build the widgets of the project and publish them. Each deploy creates a new immutable release with a version number.
+
+Deploys are rolled out gradually over ten minutes. Run acme rollback to return to the previous release immediately; rolling back doesn't rebuild the widgets.
+
+Deploys from CI authenticate with a deploy token created in the dashboard under Settings, Tokens. Set it in the ACME_TOKEN environment variable.`,
+			},
+		},
+	},
+	{
+		URL:     "https://blog.example.com",
+		Summary: "Blog of Acme Widgets with release announcements and the pricing of the hosted plans.",
+		Pages: []demoPage{
+			{
+				URL:   "https://blog.example.com/releases/2-0",
+				Title: "Announcing Acme Widgets 2.0",
+				Tags:  []string{"blog", "release"},
+				Content: `Announcing Acme Widgets 2.0
+
+Acme Widgets 2.0 is available today. Widgets now load twice as fast thanks to a new bundler, and the dashboard shows live usage per widget.
+
+The release adds the eu and ap regions, gradual rollouts for every deploy and one-command rollbacks. Projects created with version 1 keep working; run acme migrate to update acme.yaml to the new format.`,
+			},
+			{
+				URL:   "https://blog.example.com/pricing",
+				Title: "Pricing",
+				Tags:  []string{"blog", "pricing"},
+				Content: `Pricing
+
+The Free plan includes three widgets and 10,000 widget loads per month. The Team plan costs 20 dollars per month and includes unlimited widgets, 500,000 loads and five team members.
+
+The Enterprise plan adds single sign-on, audit logs and a 99.99 percent uptime agreement. Contact sales for a quote. All plans can be cancelled at any time and are billed monthly.`,
+			},
+		},
+	},
+}
@@ -0,0 +1,336 @@
+// Package seed creates deterministic demo data: a demo user with an API key
+// and small websites whose pages are stored as if they had been crawled and
+// vectorized, so the API can be tried out and tested end to end without
+// crawling or running the embedding model.
+package seed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/auth"
+	"hermit/internal/contentprocessor"
+	"hermit/internal/repositories"
+	"hermit/internal/schema"
+	"hermit/internal/storage"
+	"hermit/internal/vectorizer"
+
+	"go.uber.org/zap"
+)
+
+// Defaults of the demo user and its API key.
+const (
+	DefaultEmail    = "demo@hermit.local"
+	DefaultPassword = "hermit-demo"
+	APIKeyName      = "Demo key"
+)
+
+// defaultDimensions is the size of the canned embeddings when the embedding
+// dimensions aren't configured, the size of mxbai-embed-large's.
+const defaultDimensions = 1024
+
+// Options configures the seeded demo data.
+type Options struct {
+	Email    string
+	Password string
+	// APIKey is the demo user's API key, a random key is created when empty
+	APIKey string
+	// Dimensions is the size of the canned embeddings, 0 for the default
+	Dimensions int
+}
+
+// Report describes the seeded demo data.
+type Report struct {
+	User        *schema.User
+	UserCreated bool
+	// APIKey is the plain demo API key, replacing the previous demo keys
+	APIKey   string
+	Websites []WebsiteReport
+}
+
+// WebsiteReport describes a seeded demo website.
+type WebsiteReport struct {
+	Website *schema.Website
+	Pages   int
+	Chunks  int
+	// Created is false when the website had already been seeded
+	Created bool
+}
+
+// Seeder creates the demo data.
+type Seeder struct {
+	authService   *auth.Service
+	userRepo      *repositories.UserRepository
+	apiKeyRepo    *repositories.APIKeyRepository
+	websiteRepo   *repositories.WebsiteRepository
+	pageRepo      *repositories.PageRepository
+	storage       *storage.GarageStorage
+	vectorizerSvc *vectorizer.Service
+	logger        *zap.Logger
+}
+
+// NewSeeder creates a new Seeder.
+func NewSeeder(
+	authService *auth.Service,
+	userRepo *repositories.UserRepository,
+	apiKeyRepo *repositories.APIKeyRepository,
+	websiteRepo *repositories.WebsiteRepository,
+	pageRepo *repositories.PageRepository,
+	storage *storage.GarageStorage,
+	vectorizerSvc *vectorizer.Service,
+	logger *zap.Logger,
+) *Seeder {
+	return &Seeder{
+		authService:   authService,
+		userRepo:      userRepo,
+		apiKeyRepo:    apiKeyRepo,
+		websiteRepo:   websiteRepo,
+		pageRepo:      pageRepo,
+		storage:       storage,
+		vectorizerSvc: vectorizerSvc,
+		logger:        logger,
+	}
+}
+
+// Seed creates the demo user, its API key and the demo websites. It can be
+// run again: the user and the websites already seeded are kept, and the demo
+// API key is replaced.
+func (s *Seeder) Seed(ctx context.Context, opts Options) (*Report, error) {
+	if opts.Email == "" {
+		opts.Email = DefaultEmail
+	}
+	if opts.Password == "" {
+		opts.Password = DefaultPassword
+	}
+	if opts.Dimensions <= 0 {
+		opts.Dimensions = defaultDimensions
+	}
+
+	report := &Report{}
+
+	user, created, err := s.ensureUser(ctx, opts.Email, opts.Password)
+	if err != nil {
+		return nil, err
+	}
+	report.User, report.UserCreated = user, created
+
+	report.APIKey, err = s.replaceAPIKey(ctx, user, opts.APIKey)
+	if err != nil {
+		return nil, err
+	}
+
+	websites, err := s.websiteRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list websites: %w", err)
+	}
+
+	for _, demo := range demoWebsites {
+		if existing := findWebsite(websites, user, demo.URL); existing != nil {
+			report.Websites = append(report.Websites, WebsiteReport{Website: existing, Pages: existing.TotalPagesCrawled})
+			continue
+		}
+
+		website, chunks, err := s.seedWebsite(ctx, user, demo, opts.Dimensions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to seed %s: %w", demo.URL, err)
+		}
+		report.Websites = append(report.Websites, WebsiteReport{Website: website, Pages: len(demo.Pages), Chunks: chunks, Created: true})
+	}
+
+	s.logger.Info("Seeded demo data",
+		zap.String("email", user.Email),
+		zap.Int("websites", len(report.Websites)),
+	)
+
+	return report, nil
+}
+
+// ensureUser returns the user with the email, creating it with the password
+// when there is none. It reports whether the user was created.
+func (s *Seeder) ensureUser(ctx context.Context, email, password string) (*schema.User, bool, error) {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		return user, false, nil
+	}
+	if !apperrors.Is(err, apperrors.CodeNotFound) {
+		return nil, false, err
+	}
+
+	user, err = s.authService.Register(ctx, email, password)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create demo user: %w", err)
+	}
+	return user, true, nil
+}
+
+// replaceAPIKey deletes the user's previous demo API keys and creates a new
+// one, with the plain key when set. It returns the plain key.
+func (s *Seeder) replaceAPIKey(ctx context.Context, user *schema.User, plainKey string) (string, error) {
+	keys, err := s.apiKeyRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to list API keys: %w", err)
+	}
+	for _, key := range keys {
+		if key.Name != APIKeyName || key.Session || key.Public {
+			continue
+		}
+		if err := s.apiKeyRepo.Delete(ctx, key.ID); err != nil {
+			return "", fmt.Errorf("failed to delete previous demo API key: %w", err)
+		}
+	}
+
+	if plainKey != "" {
+		if _, err := s.authService.ImportAPIKey(ctx, user.ID, APIKeyName, plainKey, nil); err != nil {
+			return "", err
+		}
+		return plainKey, nil
+	}
+
+	_, plainKey, err = s.authService.CreateAPIKey(ctx, user.ID, APIKeyName, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return plainKey, nil
+}
+
+// findWebsite returns the user's website with the URL, or nil.
+func findWebsite(websites []schema.Website, user *schema.User, url string) *schema.Website {
+	for i, website := range websites {
+		if website.URL == url && website.UserID != nil && *website.UserID == user.ID {
+			return &websites[i]
+		}
+	}
+	return nil
+}
+
+// seedWebsite creates a demo website owned by the user with its pages
+// crawled and vectorized. It returns the website and the number of chunks
+// stored.
+func (s *Seeder) seedWebsite(ctx context.Context, user *schema.User, demo demoWebsite, dimensions int) (*schema.Website, int, error) {
+	website, err := s.websiteRepo.Create(ctx, demo.URL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create website: %w", err)
+	}
+
+	// Not monitored, recrawling the example URLs would replace the pages
+	website.UserID = &user.ID
+	website.IsMonitored = false
+	if err := s.websiteRepo.Update(ctx, website); err != nil {
+		return nil, 0, fmt.Errorf("failed to associate website with user: %w", err)
+	}
+
+	if err := s.websiteRepo.StartCrawl(ctx, website.ID); err != nil {
+		return nil, 0, fmt.Errorf("failed to start crawl: %w", err)
+	}
+
+	total := 0
+	for _, page := range demo.Pages {
+		chunks, err := s.seedPage(ctx, website.ID, page, dimensions)
+		if err != nil {
+			_ = s.websiteRepo.FailCrawl(ctx, website.ID, err.Error())
+			return nil, 0, fmt.Errorf("failed to seed page %s: %w", page.URL, err)
+		}
+		total += chunks
+	}
+
+	if err := s.websiteRepo.CompleteCrawl(ctx, website.ID, len(demo.Pages), 0); err != nil {
+		return nil, 0, fmt.Errorf("failed to complete crawl: %w", err)
+	}
+	if err := s.websiteRepo.SetSummary(ctx, website.ID, demo.Summary); err != nil {
+		return nil, 0, fmt.Errorf("failed to set summary: %w", err)
+	}
+
+	seeded, err := s.websiteRepo.GetByID(ctx, website.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return seeded, total, nil
+}
+
+// seedPage stores a demo page like a crawl would, with canned embeddings of
+// its chunks. It returns the number of chunks stored.
+func (s *Seeder) seedPage(ctx context.Context, websiteID uint, demo demoPage, dimensions int) (int, error) {
+	page, err := s.pageRepo.Upsert(ctx, websiteID, demo.URL)
+	if err != nil {
+		return 0, err
+	}
+
+	hash := sha256.Sum256([]byte(demo.Content))
+	objectKey, err := s.storage.SavePageContent(ctx, int(websiteID), demo.URL, demo.Content, storage.PageMetadata{
+		Title:       demo.Title,
+		ContentHash: hex.EncodeToString(hash[:]),
+		ExtractedAt: time.Now(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to save content: %w", err)
+	}
+
+	snippet := contentprocessor.Snippet(demo.Content, schema.PageSnippetLength)
+	if err := s.pageRepo.UpdateSuccess(ctx, page.ID, objectKey, hex.EncodeToString(hash[:]), demo.Title, snippet); err != nil {
+		return 0, err
+	}
+	if err := s.pageRepo.SetSearchText(ctx, page.ID, demo.Title, demo.Content); err != nil {
+		return 0, err
+	}
+	if err := s.pageRepo.SetTags(ctx, websiteID, page.ID, demo.Tags); err != nil {
+		return 0, err
+	}
+
+	chunks := vectorizer.ChunkText(demo.Content)
+	embeddings := make([][]float32, len(chunks))
+	for i, chunk := range chunks {
+		embeddings[i] = CannedEmbedding(chunk, dimensions)
+	}
+	if err := s.vectorizerSvc.StoreEmbeddings(ctx, websiteID, page.ID, demo.URL, chunks, embeddings, demo.Tags); err != nil {
+		return 0, err
+	}
+	if err := s.pageRepo.SetVectorized(ctx, page.ID, len(chunks)); err != nil {
+		return 0, err
+	}
+
+	return len(chunks), nil
+}
+
+// CannedEmbedding returns a deterministic embedding of a text by hashing its
+// words into the dimensions, so texts sharing words are close to each other.
+// It stands in for the embedding model where none runs.
+func CannedEmbedding(text string, dimensions int) []float32 {
+	embedding := make([]float32, dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+		// The top bit picks the sign so unrelated words cancel out
+		if sum>>63 == 0 {
+			embedding[sum%uint64(dimensions)]++
+		} else {
+			embedding[sum%uint64(dimensions)]--
+		}
+	}
+
+	var norm float64
+	for _, v := range embedding {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		embedding[0] = 1
+		return embedding
+	}
+	norm = math.Sqrt(norm)
+	for i := range embedding {
+		embedding[i] = float32(float64(embedding[i]) / norm)
+	}
+	return embedding
+}
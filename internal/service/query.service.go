@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/contentprocessor"
+	"hermit/internal/llm"
+	"hermit/internal/schema"
+	"hermit/internal/vectorizer"
+
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+)
+
+// embeddingMismatchMessage is returned when a website's vectors were built
+// with another embedding model than the configured one.
+const embeddingMismatchMessage = "Website was indexed with a different embedding model, reindex it before querying"
+
+// QueryService answers questions about websites and records the queries.
+// Callers authorize access to the website before, e.g. with WebsiteService.Get.
+type QueryService struct {
+	ragService   *llm.RAGService
+	suggester    *llm.QuestionSuggester
+	queryLogRepo QueryLogStore
+	pageRepo     PageStore
+	logger       *zap.Logger
+}
+
+// NewQueryService creates a new QueryService.
+func NewQueryService(
+	ragService *llm.RAGService,
+	suggester *llm.QuestionSuggester,
+	queryLogRepo QueryLogStore,
+	pageRepo PageStore,
+	logger *zap.Logger,
+) *QueryService {
+	return &QueryService{
+		ragService:   ragService,
+		suggester:    suggester,
+		queryLogRepo: queryLogRepo,
+		pageRepo:     pageRepo,
+		logger:       logger,
+	}
+}
+
+// Question is a question about a website.
+type Question struct {
+	Query string
+	// Tags limits retrieval to pages tagged with any of them
+	Tags []string
+	// PreviewID answers from the sources of a previous preview
+	PreviewID string
+}
+
+// normalizedTags returns the tags of the question in their stored form.
+func (q Question) normalizedTags() []string {
+	var tags []string
+	for _, tag := range q.Tags {
+		if tag = contentprocessor.NormalizeTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// queryError returns the error to answer a failed query with. Typed errors
+// such as an expired preview or an unavailable embedding service are kept,
+// anything else is reported with message.
+func queryError(err error, message string) *apperrors.Error {
+	if errors.Is(err, vectorizer.ErrEmbeddingModelMismatch) {
+		return apperrors.Wrap(apperrors.CodeConflict, embeddingMismatchMessage, err)
+	}
+	if appErr, ok := apperrors.As(err); ok {
+		return appErr
+	}
+	return apperrors.Internal(message, err)
+}
+
+// Query answers a question and records it in the query log.
+func (s *QueryService) Query(ctx context.Context, actor Actor, website *schema.Website, q Question) (*llm.QueryResponse, error) {
+	start := time.Now()
+	ctx, usage := llm.WithUsage(ctx)
+	response, err := s.ragService.Query(ctx, website.ID, q.Query, q.normalizedTags(), q.PreviewID)
+	if err != nil {
+		return nil, queryError(err, "Failed to process query")
+	}
+	response.Usage = usage.Usage()
+
+	response.QueryID = s.recordQuery(ctx, response.Usage, &schema.QueryLog{
+		WebsiteID:       website.ID,
+		UserID:          actorUserID(actor),
+		Query:           response.Query,
+		Answer:          response.Answer,
+		RetrievedChunks: response.RetrievedChunks,
+		LatencyMS:       time.Since(start).Milliseconds(),
+		Cached:          response.Cached,
+	}, response.Sources)
+	response.Sources = s.withPageTitles(ctx, response.Sources)
+
+	return response, nil
+}
+
+// QueryWebsites answers a question from the content of several websites,
+// e.g. those of WebsiteService.ListQueryable. Its sources name their website.
+// The query log is per website, so these answers aren't recorded.
+func (s *QueryService) QueryWebsites(ctx context.Context, websites []schema.Website, q Question) (*llm.QueryResponse, error) {
+	if q.PreviewID != "" {
+		return nil, apperrors.Validation("preview_id can't be used across websites")
+	}
+
+	ids := make([]uint, len(websites))
+	for i, website := range websites {
+		ids[i] = website.ID
+	}
+
+	ctx, usage := llm.WithUsage(ctx)
+	response, err := s.ragService.QueryWebsites(ctx, ids, q.Query, q.normalizedTags())
+	if err != nil {
+		return nil, queryError(err, "Failed to process query")
+	}
+	response.Usage = usage.Usage()
+	response.Sources = s.withPageTitles(ctx, response.Sources)
+
+	return response, nil
+}
+
+// PreviewSources returns the sources a question would be answered from.
+func (s *QueryService) PreviewSources(ctx context.Context, website *schema.Website, q Question) (*llm.SourcesPreview, error) {
+	if q.Query == "" {
+		return nil, apperrors.Validation("query is required")
+	}
+
+	preview, err := s.ragService.PreviewSources(ctx, website.ID, q.Query, q.normalizedTags())
+	if err != nil {
+		return nil, queryError(err, "Failed to retrieve sources")
+	}
+	preview.Sources = s.withPageTitles(ctx, preview.Sources)
+
+	return preview, nil
+}
+
+// QueryStream answers a question, passing the sources and the chunks of the
+// answer to the callbacks as they are available, and records it in the query
+// log. It returns the ID of the recorded query.
+func (s *QueryService) QueryStream(
+	ctx context.Context,
+	actor Actor,
+	website *schema.Website,
+	q Question,
+	onSources func(meta *llm.QueryStreamMeta) error,
+	onChunk func(chunk string) error,
+) (*llm.QueryStreamMeta, uint, error) {
+	start := time.Now()
+	ctx, usage := llm.WithUsage(ctx)
+	var answer strings.Builder
+	meta, err := s.ragService.QueryStream(ctx, website.ID, q.Query, q.normalizedTags(), q.PreviewID,
+		onSources,
+		func(chunk string) error {
+			answer.WriteString(chunk)
+			return onChunk(chunk)
+		},
+	)
+	if err != nil {
+		return nil, 0, queryError(err, "Failed to process query")
+	}
+	meta.Usage = usage.Usage()
+
+	queryID := s.recordQuery(ctx, meta.Usage, &schema.QueryLog{
+		WebsiteID:       website.ID,
+		UserID:          actorUserID(actor),
+		Query:           meta.Query,
+		Answer:          answer.String(),
+		RetrievedChunks: meta.RetrievedChunks,
+		LatencyMS:       time.Since(start).Milliseconds(),
+		Cached:          meta.Cached,
+		Streamed:        true,
+	}, meta.Sources)
+
+	return meta, queryID, nil
+}
+
+// Chat answers a message of a conversation about a website, streaming the
+// answer like QueryStream. Chat messages aren't recorded.
+func (s *QueryService) Chat(
+	ctx context.Context,
+	website *schema.Website,
+	message string,
+	history []llm.ChatMessage,
+	onSources func(meta *llm.QueryStreamMeta) error,
+	onChunk func(chunk string) error,
+) (*llm.QueryStreamMeta, error) {
+	ctx, usage := llm.WithUsage(ctx)
+	meta, err := s.ragService.ChatStream(ctx, website.ID, message, history, onSources, onChunk)
+	if err != nil {
+		return nil, queryError(err, "Failed to process message")
+	}
+	meta.Usage = usage.Usage()
+	return meta, nil
+}
+
+// Suggest returns questions users could ask about a website.
+func (s *QueryService) Suggest(ctx context.Context, website *schema.Website) (*schema.SuggestedQuestions, error) {
+	suggested, err := s.suggester.Suggest(ctx, website)
+	if err != nil {
+		return nil, queryError(err, "Failed to suggest questions")
+	}
+	return suggested, nil
+}
+
+func actorUserID(actor Actor) *ulid.ULID {
+	if actor.User == nil {
+		return nil
+	}
+	return &actor.User.ID
+}
+
+// recordQuery persists a query log for later evaluation and usage reporting
+// and returns its ID. Failures are logged but never surfaced to the caller.
+func (s *QueryService) recordQuery(ctx context.Context, usage *llm.TokenUsage, log *schema.QueryLog, sources []llm.QuerySource) uint {
+	if usage != nil {
+		log.PromptTokens = usage.PromptTokens
+		log.CompletionTokens = usage.CompletionTokens
+	}
+
+	encoded, err := json.Marshal(sources)
+	if err != nil {
+		s.logger.Warn("Failed to encode query sources", zap.Error(err))
+		encoded = []byte("[]")
+	}
+	log.Sources = encoded
+
+	if err := s.queryLogRepo.Create(ctx, log); err != nil {
+		s.logger.Warn("Failed to record query log", zap.Uint("websiteID", log.WebsiteID), zap.Error(err))
+		return 0
+	}
+
+	return log.ID
+}
+
+// withPageTitles returns a copy of sources with the stored page titles filled
+// in. Sources are returned unchanged when the titles can't be loaded.
+func (s *QueryService) withPageTitles(ctx context.Context, sources []llm.QuerySource) []llm.QuerySource {
+	ids := make([]int64, 0, len(sources))
+	for _, source := range sources {
+		if source.PageID != 0 {
+			ids = append(ids, int64(source.PageID))
+		}
+	}
+	if len(ids) == 0 {
+		return sources
+	}
+
+	titles, err := s.pageRepo.GetTitles(ctx, ids)
+	if err != nil {
+		s.logger.Warn("Failed to load page titles for sources", zap.Error(err))
+		return sources
+	}
+
+	withTitles := make([]llm.QuerySource, len(sources))
+	copy(withTitles, sources)
+	for i := range withTitles {
+		withTitles[i].PageTitle = titles[withTitles[i].PageID]
+	}
+
+	return withTitles
+}
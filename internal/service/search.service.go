@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/schema"
+
+	"go.uber.org/zap"
+)
+
+// MaxSearchQueryLength limits the length of full-text search queries.
+const MaxSearchQueryLength = 200
+
+// Matches are delimited with control characters in ts_headline so the text
+// can be escaped before they become <mark> tags.
+const (
+	matchStart        = "\x02"
+	matchStop         = "\x03"
+	fragmentDelimiter = "\x1f"
+	headlineOptions   = "StartSel=" + matchStart + ", StopSel=" + matchStop +
+		", FragmentDelimiter=" + fragmentDelimiter + ", MaxFragments=3, MaxWords=30, MinWords=12"
+)
+
+// controlChars removes the delimiters of headlines from page text.
+var controlChars = strings.NewReplacer(matchStart, "", matchStop, "", fragmentDelimiter, "", "\x00", "")
+
+// SearchService runs keyword searches over the stored text of pages.
+// Callers authorize access to the website before, e.g. with WebsiteService.Get.
+type SearchService struct {
+	pageRepo PageStore
+	storage  ContentStore
+	logger   *zap.Logger
+}
+
+// NewSearchService creates a new SearchService.
+func NewSearchService(pageRepo PageStore, storage ContentStore, logger *zap.Logger) *SearchService {
+	return &SearchService{pageRepo: pageRepo, storage: storage, logger: logger}
+}
+
+// Search returns the pages of a website matching q with highlighted passages,
+// best match first, and the total number of matching pages.
+func (s *SearchService) Search(ctx context.Context, website *schema.Website, q string, limit, offset int) ([]schema.SearchHit, int, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, 0, apperrors.Validation("Search query is required")
+	}
+	if len(q) > MaxSearchQueryLength {
+		return nil, 0, apperrors.Validation("Search query is too long")
+	}
+
+	hits, total, err := s.pageRepo.Search(ctx, website.ID, q, limit, offset)
+	if err != nil {
+		return nil, 0, apperrors.Internal("Failed to search pages", err)
+	}
+	if len(hits) == 0 {
+		return []schema.SearchHit{}, total, nil
+	}
+
+	// Highlight the stored text, or the snippet of pages it can't be read for
+	texts := make([]string, len(hits))
+	for i, hit := range hits {
+		texts[i] = hit.Snippet
+		if hit.ObjectKey == "" {
+			continue
+		}
+		content, err := s.storage.GetPageContent(ctx, hit.ObjectKey)
+		if err != nil {
+			s.logger.Warn("Failed to read page content for search highlights",
+				zap.Uint("pageID", hit.PageID),
+				zap.Error(err),
+			)
+			continue
+		}
+		texts[i] = content
+	}
+	for i := range texts {
+		texts[i] = controlChars.Replace(texts[i])
+	}
+
+	headlines, err := s.pageRepo.Headlines(ctx, q, headlineOptions, texts)
+	if err != nil {
+		return nil, 0, apperrors.Internal("Failed to search pages", err)
+	}
+	for i := range hits {
+		hits[i].Highlights = highlights(headlines[i])
+	}
+
+	return hits, total, nil
+}
+
+// highlights splits a headline into its passages, escaping them and marking
+// the matches with <mark> tags.
+func highlights(headline string) []string {
+	passages := []string{}
+	for _, passage := range strings.Split(headline, fragmentDelimiter) {
+		passage = strings.Join(strings.Fields(passage), " ")
+		if passage == "" {
+			continue
+		}
+		passage = html.EscapeString(passage)
+		passage = strings.NewReplacer(matchStart, "<mark>", matchStop, "</mark>").Replace(passage)
+		passages = append(passages, passage)
+	}
+	return passages
+}
@@ -0,0 +1,28 @@
+// Package service holds the business rules shared by the REST API and the web
+// interface: who may access a website, website limits, crawl scope rules and
+// how queries are answered and recorded. Services return apperrors, which both
+// the API error handler and the web handlers map to responses.
+package service
+
+import (
+	"hermit/internal/schema"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Actor is the user a service call is made for.
+type Actor struct {
+	User *schema.User
+	// APIKeyID is the API key the request was authenticated with, if any.
+	// Permissions granted to the key apply to its requests only.
+	APIKeyID *ulid.ULID
+}
+
+// Owns reports whether the actor owns the website or is an admin, who may
+// act on any website.
+func (a Actor) Owns(website *schema.Website) bool {
+	if a.User == nil {
+		return false
+	}
+	return a.User.IsAdmin() || (website.UserID != nil && *website.UserID == a.User.ID)
+}
@@ -0,0 +1,628 @@
+// Package servicefake provides in-memory implementations of the stores and
+// queues the services depend on, so the rules of the services and of the
+// handlers built on them can be exercised without Postgres, Redis or object
+// storage. The fakes are safe for concurrent use; set Err on any of them to
+// make every call fail.
+package servicefake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hermit/internal/jobs"
+	"hermit/internal/schema"
+	"hermit/internal/service"
+	"hermit/internal/storage"
+
+	"github.com/oklog/ulid/v2"
+)
+
+var (
+	_ service.WebsiteStore    = (*Websites)(nil)
+	_ service.PageStore       = (*Pages)(nil)
+	_ service.UserStore       = (*Users)(nil)
+	_ service.PermissionStore = (*Permissions)(nil)
+	_ service.QueryLogStore   = (*QueryLogs)(nil)
+	_ service.ContentStore    = (*Content)(nil)
+	_ service.AnswerCache     = (*Answers)(nil)
+	_ service.JobQueue        = (*Jobs)(nil)
+)
+
+// Websites is an in-memory service.WebsiteStore.
+type Websites struct {
+	mu       sync.Mutex
+	websites map[uint]schema.Website
+	nextID   uint
+	Err      error
+}
+
+// NewWebsites creates a Websites holding copies of the websites.
+func NewWebsites(websites ...schema.Website) *Websites {
+	w := &Websites{websites: map[uint]schema.Website{}}
+	for _, website := range websites {
+		w.websites[website.ID] = website
+		if website.ID > w.nextID {
+			w.nextID = website.ID
+		}
+	}
+	return w
+}
+
+// GetByID returns a copy of the website, nil when it doesn't exist.
+func (w *Websites) GetByID(ctx context.Context, id uint) (*schema.Website, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.Err != nil {
+		return nil, w.Err
+	}
+	website, ok := w.websites[id]
+	if !ok {
+		return nil, nil
+	}
+	return &website, nil
+}
+
+// List returns copies of the websites by ID.
+func (w *Websites) List(ctx context.Context) ([]schema.Website, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.Err != nil {
+		return nil, w.Err
+	}
+	websites := make([]schema.Website, 0, len(w.websites))
+	for _, website := range w.websites {
+		websites = append(websites, website)
+	}
+	sort.Slice(websites, func(i, j int) bool { return websites[i].ID < websites[j].ID })
+	return websites, nil
+}
+
+// ListAccessible returns a page of the websites of the user and of the
+// shared websites, all websites for a nil user, with label when it is set.
+func (w *Websites) ListAccessible(ctx context.Context, userID *ulid.ULID, sharedIDs []int64, label string, limit, offset int) ([]schema.Website, int, error) {
+	websites, err := w.List(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	accessible := []schema.Website{}
+	for _, website := range websites {
+		owned := userID == nil || (website.UserID != nil && *website.UserID == *userID)
+		if !owned && !slices.Contains(sharedIDs, int64(website.ID)) {
+			continue
+		}
+		if label != "" && !website.HasLabel(label) {
+			continue
+		}
+		accessible = append(accessible, website)
+	}
+
+	total := len(accessible)
+	accessible = accessible[min(offset, total):]
+	if limit > 0 {
+		accessible = accessible[:min(limit, len(accessible))]
+	}
+	return accessible, total, nil
+}
+
+// ListVersion counts the websites of the user, all websites for a nil user.
+func (w *Websites) ListVersion(ctx context.Context, userID *ulid.ULID) (schema.Version, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var version schema.Version
+	if w.Err != nil {
+		return version, w.Err
+	}
+	for _, website := range w.websites {
+		if userID != nil && (website.UserID == nil || *website.UserID != *userID) {
+			continue
+		}
+		version.Count++
+		if website.UpdatedAt.After(version.UpdatedAt) {
+			version.UpdatedAt = website.UpdatedAt
+		}
+	}
+	return version, nil
+}
+
+// Create adds a monitored, idle website like the repository does.
+func (w *Websites) Create(ctx context.Context, url string) (*schema.Website, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.Err != nil {
+		return nil, w.Err
+	}
+	w.nextID++
+	now := time.Now()
+	website := schema.Website{
+		ID:          w.nextID,
+		URL:         url,
+		IsMonitored: true,
+		CrawlStatus: "idle",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	w.websites[website.ID] = website
+	return &website, nil
+}
+
+// Update replaces a stored website.
+func (w *Websites) Update(ctx context.Context, website *schema.Website) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.Err != nil {
+		return w.Err
+	}
+	if _, ok := w.websites[website.ID]; !ok {
+		return fmt.Errorf("website %d not found", website.ID)
+	}
+	website.UpdatedAt = time.Now()
+	w.websites[website.ID] = *website
+	return nil
+}
+
+// Pages is an in-memory service.PageStore. Its search matches pages whose
+// title or text contains the query, case insensitively.
+type Pages struct {
+	mu    sync.Mutex
+	pages map[uint]schema.Page
+	// Text is the searchable text of the pages by ID
+	Text map[uint]string
+	// PII is the redaction report of the websites by ID
+	PII map[uint]*schema.PIIReport
+	Err error
+}
+
+// NewPages creates a Pages holding copies of the pages.
+func NewPages(pages ...schema.Page) *Pages {
+	p := &Pages{pages: map[uint]schema.Page{}, Text: map[uint]string{}, PII: map[uint]*schema.PIIReport{}}
+	for _, page := range pages {
+		p.pages[page.ID] = page
+	}
+	return p
+}
+
+// GetByID returns a copy of the page, nil when it doesn't exist.
+func (p *Pages) GetByID(ctx context.Context, id uint) (*schema.Page, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	page, ok := p.pages[id]
+	if !ok {
+		return nil, nil
+	}
+	return &page, nil
+}
+
+// GetTitles returns the titles of the pages that have one.
+func (p *Pages) GetTitles(ctx context.Context, ids []int64) (map[uint]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	titles := make(map[uint]string, len(ids))
+	for _, id := range ids {
+		if page, ok := p.pages[uint(id)]; ok && page.Title.Valid {
+			titles[page.ID] = page.Title.String
+		}
+	}
+	return titles, nil
+}
+
+// Search returns the successfully crawled pages of the website whose title or
+// text contains q, by ID.
+func (p *Pages) Search(ctx context.Context, websiteID uint, q string, limit, offset int) ([]schema.SearchHit, int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return nil, 0, p.Err
+	}
+	q = strings.ToLower(q)
+	var hits []schema.SearchHit
+	for _, page := range p.pages {
+		if page.WebsiteID != websiteID || page.Status != "success" {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(page.Title.String+" "+p.Text[page.ID]), q) {
+			continue
+		}
+		hits = append(hits, schema.SearchHit{
+			PageID:    page.ID,
+			URL:       page.URL,
+			Title:     page.Title.String,
+			Rank:      1,
+			Snippet:   page.Snippet.String,
+			ObjectKey: page.MinioObjectKey.String,
+		})
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].PageID < hits[j].PageID })
+
+	total := len(hits)
+	if offset >= total {
+		return []schema.SearchHit{}, total, nil
+	}
+	hits = hits[offset:]
+	if limit > 0 && limit < len(hits) {
+		hits = hits[:limit]
+	}
+	return hits, total, nil
+}
+
+// Headlines returns the texts unchanged.
+func (p *Pages) Headlines(ctx context.Context, q, options string, texts []string) ([]string, error) {
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	return append([]string(nil), texts...), nil
+}
+
+// GetPIIReport returns the website's report, an empty one when unset.
+func (p *Pages) GetPIIReport(ctx context.Context, websiteID uint) (*schema.PIIReport, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	if report, ok := p.PII[websiteID]; ok {
+		copied := *report
+		return &copied, nil
+	}
+	return &schema.PIIReport{ByKind: map[string]int{}}, nil
+}
+
+// ClearPIIRedactions removes the website's report.
+func (p *Pages) ClearPIIRedactions(ctx context.Context, websiteID uint) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return p.Err
+	}
+	delete(p.PII, websiteID)
+	return nil
+}
+
+// Users is an in-memory service.UserStore.
+type Users struct {
+	mu sync.Mutex
+	// WebsiteCounts is the number of websites of the users by ID
+	WebsiteCounts map[ulid.ULID]int
+	Err           error
+}
+
+// NewUsers creates an empty Users.
+func NewUsers() *Users {
+	return &Users{WebsiteCounts: map[ulid.ULID]int{}}
+}
+
+// GetWebsiteCount returns the user's website count, 0 when unset.
+func (u *Users) GetWebsiteCount(ctx context.Context, userID ulid.ULID) (int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.Err != nil {
+		return 0, u.Err
+	}
+	return u.WebsiteCounts[userID], nil
+}
+
+// Permissions is an in-memory service.PermissionStore.
+type Permissions struct {
+	mu          sync.Mutex
+	permissions []schema.WebsitePermission
+	Err         error
+}
+
+// NewPermissions creates a Permissions holding the granted permissions.
+func NewPermissions(permissions ...schema.WebsitePermission) *Permissions {
+	return &Permissions{permissions: append([]schema.WebsitePermission(nil), permissions...)}
+}
+
+// Grant adds a permission.
+func (p *Permissions) Grant(permission schema.WebsitePermission) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if permission.CreatedAt.IsZero() {
+		permission.CreatedAt = time.Now()
+	}
+	p.permissions = append(p.permissions, permission)
+}
+
+// granted returns the permissions held by the user or the API key.
+func (p *Permissions) granted(userID ulid.ULID, apiKeyID *ulid.ULID) []schema.WebsitePermission {
+	var granted []schema.WebsitePermission
+	for _, permission := range p.permissions {
+		if (permission.UserID != nil && *permission.UserID == userID) ||
+			(apiKeyID != nil && permission.APIKeyID != nil && *permission.APIKeyID == *apiKeyID) {
+			granted = append(granted, permission)
+		}
+	}
+	return granted
+}
+
+// ListGranted returns the permissions on the website held by the user or
+// the API key.
+func (p *Permissions) ListGranted(ctx context.Context, websiteID uint, userID ulid.ULID, apiKeyID *ulid.ULID) ([]schema.WebsitePermission, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	permissions := []schema.WebsitePermission{}
+	for _, permission := range p.granted(userID, apiKeyID) {
+		if permission.WebsiteID == websiteID {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
+
+// SharedWebsiteIDs returns the websites the user or the API key holds any
+// permission on.
+func (p *Permissions) SharedWebsiteIDs(ctx context.Context, userID ulid.ULID, apiKeyID *ulid.ULID) (map[uint]bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	shared := map[uint]bool{}
+	for _, permission := range p.granted(userID, apiKeyID) {
+		shared[permission.WebsiteID] = true
+	}
+	return shared, nil
+}
+
+// SharedVersion counts the permissions held by the user or the API key.
+func (p *Permissions) SharedVersion(ctx context.Context, userID ulid.ULID, apiKeyID *ulid.ULID) (schema.Version, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var version schema.Version
+	if p.Err != nil {
+		return version, p.Err
+	}
+	for _, permission := range p.granted(userID, apiKeyID) {
+		version.Count++
+		if permission.CreatedAt.After(version.UpdatedAt) {
+			version.UpdatedAt = permission.CreatedAt
+		}
+	}
+	return version, nil
+}
+
+// QueryLogs is an in-memory service.QueryLogStore.
+type QueryLogs struct {
+	mu   sync.Mutex
+	logs []schema.QueryLog
+	Err  error
+}
+
+// Create records a copy of the log.
+func (q *QueryLogs) Create(ctx context.Context, log *schema.QueryLog) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.Err != nil {
+		return q.Err
+	}
+	q.logs = append(q.logs, *log)
+	return nil
+}
+
+// Logs returns the recorded logs in order.
+func (q *QueryLogs) Logs() []schema.QueryLog {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]schema.QueryLog(nil), q.logs...)
+}
+
+// Content is an in-memory service.ContentStore keyed by object key.
+type Content struct {
+	mu          sync.Mutex
+	Pages       map[string]string
+	Metadata    map[string]*storage.PageInfo
+	Screenshots map[string][]byte
+	Err         error
+}
+
+// NewContent creates an empty Content.
+func NewContent() *Content {
+	return &Content{Pages: map[string]string{}, Metadata: map[string]*storage.PageInfo{}, Screenshots: map[string][]byte{}}
+}
+
+// GetPageContent returns the stored content, storage.ErrObjectNotFound when
+// there is none.
+func (c *Content) GetPageContent(ctx context.Context, objectKey string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Err != nil {
+		return "", c.Err
+	}
+	content, ok := c.Pages[objectKey]
+	if !ok {
+		return "", storage.ErrObjectNotFound
+	}
+	return content, nil
+}
+
+// GetPageMetadata returns the metadata set for a stored page, or only its
+// size when none was set, and storage.ErrObjectNotFound when there is no page.
+func (c *Content) GetPageMetadata(ctx context.Context, objectKey string) (*storage.PageInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	content, ok := c.Pages[objectKey]
+	if !ok {
+		return nil, storage.ErrObjectNotFound
+	}
+	if info, ok := c.Metadata[objectKey]; ok {
+		return info, nil
+	}
+	return &storage.PageInfo{
+		ObjectKey:   objectKey,
+		Encoding:    storage.EncodingNone,
+		StoredBytes: int64(len(content)),
+	}, nil
+}
+
+// GetScreenshot returns the stored screenshot, storage.ErrObjectNotFound when
+// there is none.
+func (c *Content) GetScreenshot(ctx context.Context, objectKey string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	image, ok := c.Screenshots[objectKey]
+	if !ok {
+		return nil, storage.ErrObjectNotFound
+	}
+	return image, nil
+}
+
+// Job is a job queued on Jobs.
+type Job struct {
+	Type      string
+	WebsiteID uint
+	PageID    uint
+	URLs      []string
+	Delay     time.Duration
+}
+
+// Jobs is an in-memory service.JobQueue recording the jobs queued. Like the
+// job client, it refuses a second crawl of a website while one is live.
+type Jobs struct {
+	mu   sync.Mutex
+	jobs []Job
+	// Live are the websites whose crawl is queued or running
+	Live map[uint]bool
+	Err  error
+}
+
+// NewJobs creates an empty Jobs.
+func NewJobs() *Jobs {
+	return &Jobs{Live: map[uint]bool{}}
+}
+
+// Queued returns the jobs queued in order.
+func (j *Jobs) Queued() []Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Job(nil), j.jobs...)
+}
+
+// queue records a job, or returns Err.
+func (j *Jobs) queue(job Job) (string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.Err != nil {
+		return "", j.Err
+	}
+	j.jobs = append(j.jobs, job)
+	return fmt.Sprintf("%s:%d:%d", job.Type, job.WebsiteID, len(j.jobs)), nil
+}
+
+// queueCrawl records a crawl of the website unless one is live.
+func (j *Jobs) queueCrawl(job Job) (string, error) {
+	j.mu.Lock()
+	live := j.Live[job.WebsiteID]
+	if !live && j.Err == nil {
+		j.Live[job.WebsiteID] = true
+	}
+	j.mu.Unlock()
+	if live {
+		return "", jobs.ErrAlreadyQueued
+	}
+	return j.queue(job)
+}
+
+// EnqueueCrawlWebsite records a crawl of the website.
+func (j *Jobs) EnqueueCrawlWebsite(ctx context.Context, websiteID uint, startURL string) error {
+	_, err := j.queueCrawl(Job{Type: jobs.TypeCrawlWebsite, WebsiteID: websiteID, URLs: []string{startURL}})
+	return err
+}
+
+// EnqueueBatch records the tasks of the items.
+func (j *Jobs) EnqueueBatch(ctx context.Context, items []jobs.BatchItem) *jobs.BatchSummary {
+	summary := &jobs.BatchSummary{Results: make([]jobs.BatchResult, len(items))}
+	for i, item := range items {
+		summary.Results[i].Index = i
+		taskID, err := j.queue(Job{Type: item.Task.Type()})
+		if err != nil {
+			summary.Results[i].Err = err
+			summary.Failed++
+			continue
+		}
+		summary.Results[i].TaskID = taskID
+		summary.Succeeded++
+	}
+	return summary
+}
+
+// CrawlTaskLive reports whether the website is in Live.
+func (j *Jobs) CrawlTaskLive(websiteID uint) (bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.Err != nil {
+		return false, j.Err
+	}
+	return j.Live[websiteID], nil
+}
+
+// EnqueueRecrawlWebsite records a recrawl of the website.
+func (j *Jobs) EnqueueRecrawlWebsite(ctx context.Context, websiteID uint, delay time.Duration) error {
+	_, err := j.queueCrawl(Job{Type: jobs.TypeRecrawlWebsite, WebsiteID: websiteID, Delay: delay})
+	return err
+}
+
+// EnqueueIndexPages records the indexing of the URLs.
+func (j *Jobs) EnqueueIndexPages(ctx context.Context, websiteID uint, urls []string) (string, error) {
+	return j.queue(Job{Type: jobs.TypeIndexPages, WebsiteID: websiteID, URLs: append([]string(nil), urls...)})
+}
+
+// EnqueueRebuildWebsite records a rebuild of the website.
+func (j *Jobs) EnqueueRebuildWebsite(ctx context.Context, websiteID uint) (string, error) {
+	return j.queue(Job{Type: jobs.TypeRebuildWebsite, WebsiteID: websiteID})
+}
+
+// EnqueueRevectorizePages records the revectorization of the website.
+func (j *Jobs) EnqueueRevectorizePages(ctx context.Context, websiteID uint) (string, error) {
+	return j.queue(Job{Type: jobs.TypeRevectorizePages, WebsiteID: websiteID})
+}
+
+// EnqueueReprocessPage records the reprocessing of the page.
+func (j *Jobs) EnqueueReprocessPage(ctx context.Context, websiteID, pageID uint) (string, error) {
+	return j.queue(Job{Type: jobs.TypeReprocessPage, WebsiteID: websiteID, PageID: pageID})
+}
+
+// Answers is an in-memory service.AnswerCache recording the websites whose
+// answers were invalidated.
+type Answers struct {
+	mu          sync.Mutex
+	invalidated []uint
+	Err         error
+}
+
+// Invalidate records the website, or returns Err.
+func (a *Answers) Invalidate(ctx context.Context, websiteID uint) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.Err != nil {
+		return a.Err
+	}
+	a.invalidated = append(a.invalidated, websiteID)
+	return nil
+}
+
+// Invalidated returns the websites whose answers were invalidated in order.
+func (a *Answers) Invalidated() []uint {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]uint(nil), a.invalidated...)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"hermit/internal/jobs"
+	"hermit/internal/llm"
+	"hermit/internal/repositories"
+	"hermit/internal/schema"
+	"hermit/internal/storage"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// The services depend on the stores and queues below rather than on the
+// repositories, so their rules can be exercised with the in-memory fakes of
+// the servicefake package. Each interface lists only the methods the services
+// call.
+
+// WebsiteStore persists websites.
+type WebsiteStore interface {
+	// GetByID returns nil when the website doesn't exist.
+	GetByID(ctx context.Context, id uint) (*schema.Website, error)
+	List(ctx context.Context) ([]schema.Website, error)
+	// ListAccessible returns a page of the websites of a user and of the
+	// shared websites, all websites for a nil user, and their total. Only
+	// websites with label are listed when it is set, every website for a
+	// limit that is not positive.
+	ListAccessible(ctx context.Context, userID *ulid.ULID, sharedIDs []int64, label string, limit, offset int) ([]schema.Website, int, error)
+	// ListVersion identifies the state of the websites of a user, of all
+	// websites for a nil user.
+	ListVersion(ctx context.Context, userID *ulid.ULID) (schema.Version, error)
+	Create(ctx context.Context, url string) (*schema.Website, error)
+	Update(ctx context.Context, website *schema.Website) error
+}
+
+// PageStore persists the crawled pages of websites.
+type PageStore interface {
+	GetByID(ctx context.Context, id uint) (*schema.Page, error)
+	// GetTitles returns the titles of the pages by ID, skipping unknown ones.
+	GetTitles(ctx context.Context, ids []int64) (map[uint]string, error)
+	// Search runs a full-text search over the pages of a website.
+	Search(ctx context.Context, websiteID uint, q string, limit, offset int) ([]schema.SearchHit, int, error)
+	// Headlines highlights the matches of q in each of the texts.
+	Headlines(ctx context.Context, q, options string, texts []string) ([]string, error)
+	GetPIIReport(ctx context.Context, websiteID uint) (*schema.PIIReport, error)
+	ClearPIIRedactions(ctx context.Context, websiteID uint) error
+}
+
+// UserStore persists users.
+type UserStore interface {
+	GetWebsiteCount(ctx context.Context, userID ulid.ULID) (int, error)
+}
+
+// PermissionStore persists the website permissions granted to users and
+// API keys.
+type PermissionStore interface {
+	// ListGranted returns the permissions on a website granted to a user, or
+	// to the API key when set.
+	ListGranted(ctx context.Context, websiteID uint, userID ulid.ULID, apiKeyID *ulid.ULID) ([]schema.WebsitePermission, error)
+	// SharedWebsiteIDs returns the websites on which a user, or the API key
+	// when set, was granted a permission.
+	SharedWebsiteIDs(ctx context.Context, userID ulid.ULID, apiKeyID *ulid.ULID) (map[uint]bool, error)
+	// SharedVersion identifies the state of the permissions a user, or the
+	// API key when set, holds and of the websites they share.
+	SharedVersion(ctx context.Context, userID ulid.ULID, apiKeyID *ulid.ULID) (schema.Version, error)
+}
+
+// QueryLogStore records the queries answered.
+type QueryLogStore interface {
+	Create(ctx context.Context, log *schema.QueryLog) error
+}
+
+// ContentStore holds the stored content of pages.
+type ContentStore interface {
+	GetPageContent(ctx context.Context, objectKey string) (string, error)
+	GetPageMetadata(ctx context.Context, objectKey string) (*storage.PageInfo, error)
+	GetScreenshot(ctx context.Context, objectKey string) ([]byte, error)
+}
+
+// AnswerCache holds the answers cached per website.
+type AnswerCache interface {
+	// Invalidate drops the cached answers of a website.
+	Invalidate(ctx context.Context, websiteID uint) error
+}
+
+// JobQueue queues the background jobs of websites.
+type JobQueue interface {
+	EnqueueCrawlWebsite(ctx context.Context, websiteID uint, startURL string) error
+	EnqueueBatch(ctx context.Context, items []jobs.BatchItem) *jobs.BatchSummary
+	// CrawlTaskLive reports whether a crawl task of the website is queued or
+	// running.
+	CrawlTaskLive(websiteID uint) (bool, error)
+	EnqueueRecrawlWebsite(ctx context.Context, websiteID uint, delay time.Duration) error
+	EnqueueIndexPages(ctx context.Context, websiteID uint, urls []string) (string, error)
+	EnqueueRebuildWebsite(ctx context.Context, websiteID uint) (string, error)
+	EnqueueRevectorizePages(ctx context.Context, websiteID uint) (string, error)
+	EnqueueReprocessPage(ctx context.Context, websiteID, pageID uint) (string, error)
+}
+
+var (
+	_ WebsiteStore    = (*repositories.WebsiteRepository)(nil)
+	_ PageStore       = (*repositories.PageRepository)(nil)
+	_ UserStore       = (*repositories.UserRepository)(nil)
+	_ PermissionStore = (*repositories.WebsitePermissionRepository)(nil)
+	_ QueryLogStore   = (*repositories.QueryLogRepository)(nil)
+	_ ContentStore    = (*storage.GarageStorage)(nil)
+	_ AnswerCache     = (*llm.AnswerCache)(nil)
+	_ JobQueue        = (*jobs.Client)(nil)
+)
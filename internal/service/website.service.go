@@ -0,0 +1,782 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/contentprocessor"
+	"hermit/internal/crawler"
+	"hermit/internal/jobs"
+	"hermit/internal/requestid"
+	"hermit/internal/schema"
+	"hermit/internal/storage"
+	"hermit/internal/tenant"
+
+	"go.uber.org/zap"
+)
+
+// MaxIndexPages limits the number of pages indexed at once.
+const MaxIndexPages = 50
+
+// WebsiteService manages websites and decides who may access them.
+type WebsiteService struct {
+	websiteRepo WebsiteStore
+	pageRepo    PageStore
+	userRepo    UserStore
+	permRepo    PermissionStore
+	jobClient   JobQueue
+	storage     ContentStore
+	answerCache AnswerCache
+	logger      *zap.Logger
+}
+
+// NewWebsiteService creates a new WebsiteService.
+func NewWebsiteService(
+	websiteRepo WebsiteStore,
+	pageRepo PageStore,
+	userRepo UserStore,
+	permRepo PermissionStore,
+	jobClient JobQueue,
+	storage ContentStore,
+	answerCache AnswerCache,
+	logger *zap.Logger,
+) *WebsiteService {
+	return &WebsiteService{
+		websiteRepo: websiteRepo,
+		pageRepo:    pageRepo,
+		userRepo:    userRepo,
+		permRepo:    permRepo,
+		jobClient:   jobClient,
+		storage:     storage,
+		answerCache: answerCache,
+		logger:      logger,
+	}
+}
+
+// Get returns a website the actor has the given permission on.
+func (s *WebsiteService) Get(ctx context.Context, actor Actor, id uint, permission string) (*schema.Website, error) {
+	website, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Authorize(ctx, actor, website, permission); err != nil {
+		return nil, err
+	}
+	return website, nil
+}
+
+// GetOwned returns a website the actor owns. Granted permissions don't count,
+// e.g. they don't allow sharing the website further.
+func (s *WebsiteService) GetOwned(ctx context.Context, actor Actor, id uint) (*schema.Website, error) {
+	website, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.Owns(website) {
+		return nil, apperrors.Forbidden("Access denied")
+	}
+	return website, nil
+}
+
+func (s *WebsiteService) find(ctx context.Context, id uint) (*schema.Website, error) {
+	website, err := s.websiteRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.Internal("Failed to retrieve website", err)
+	}
+	if website == nil {
+		return nil, apperrors.NotFound("Website not found")
+	}
+	return website, nil
+}
+
+// Authorize checks that the actor may act on a website with the given
+// permission. Admins and the owner may do anything, other users need the
+// permission granted to them or to the API key of the request.
+func (s *WebsiteService) Authorize(ctx context.Context, actor Actor, website *schema.Website, permission string) error {
+	if actor.User == nil {
+		return apperrors.Unauthorized("authentication required")
+	}
+	if actor.Owns(website) {
+		return nil
+	}
+
+	granted, err := s.permRepo.ListGranted(ctx, website.ID, actor.User.ID, actor.APIKeyID)
+	if err != nil {
+		return apperrors.Internal("Failed to check website permissions", err)
+	}
+	for _, p := range granted {
+		if p.Implies(permission) {
+			return nil
+		}
+	}
+
+	return apperrors.Forbidden("Access denied")
+}
+
+// ListAccessible returns a page of the websites the actor owns or that were
+// shared with the actor, all websites for admins, and the total number of
+// them. When label is set only websites with it are listed. A limit that is
+// not positive lists every website.
+func (s *WebsiteService) ListAccessible(ctx context.Context, actor Actor, label string, limit, offset int) ([]schema.Website, int, error) {
+	if actor.User == nil {
+		return nil, 0, apperrors.Unauthorized("authentication required")
+	}
+
+	owner := &actor.User.ID
+	var sharedIDs []int64
+	if actor.User.IsAdmin() {
+		owner = nil
+	} else {
+		shared, err := s.permRepo.SharedWebsiteIDs(ctx, actor.User.ID, actor.APIKeyID)
+		if err != nil {
+			return nil, 0, apperrors.Internal("Failed to list websites", err)
+		}
+		for id := range shared {
+			sharedIDs = append(sharedIDs, int64(id))
+		}
+	}
+
+	websites, total, err := s.websiteRepo.ListAccessible(ctx, owner, sharedIDs, label, limit, offset)
+	if err != nil {
+		return nil, 0, apperrors.Internal("Failed to list websites", err)
+	}
+	return websites, total, nil
+}
+
+// MaxLabelQueryWebsites bounds the number of websites a query across the
+// websites with a label searches.
+const MaxLabelQueryWebsites = 20
+
+// ListQueryable returns the websites with label the actor may query, for a
+// query across them. At most MaxLabelQueryWebsites websites may be queried.
+func (s *WebsiteService) ListQueryable(ctx context.Context, actor Actor, label string) ([]schema.Website, error) {
+	if label == "" {
+		return nil, apperrors.Validation("tag is required")
+	}
+
+	websites, _, err := s.ListAccessible(ctx, actor, label, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	queryable := []schema.Website{}
+	for i := range websites {
+		err := s.Authorize(ctx, actor, &websites[i], schema.WebsitePermissionQuery)
+		if apperrors.Is(err, apperrors.CodeForbidden) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		queryable = append(queryable, websites[i])
+	}
+
+	if len(queryable) == 0 {
+		return nil, apperrors.NotFound("No website labelled " + label + " can be queried")
+	}
+	if len(queryable) > MaxLabelQueryWebsites {
+		return nil, apperrors.Validation(fmt.Sprintf("%d websites are labelled %s, a query searches at most %d", len(queryable), label, MaxLabelQueryWebsites))
+	}
+	return queryable, nil
+}
+
+// ListVersion returns values that change whenever the list of websites
+// accessible to the actor changes.
+func (s *WebsiteService) ListVersion(ctx context.Context, actor Actor) ([]any, error) {
+	if actor.User == nil {
+		return nil, apperrors.Unauthorized("authentication required")
+	}
+
+	owner := &actor.User.ID
+	if actor.User.IsAdmin() {
+		owner = nil
+	}
+	version, err := s.websiteRepo.ListVersion(ctx, owner)
+	if err != nil {
+		return nil, apperrors.Internal("Failed to list websites", err)
+	}
+
+	sharedVersion, err := s.permRepo.SharedVersion(ctx, actor.User.ID, actor.APIKeyID)
+	if err != nil {
+		return nil, apperrors.Internal("Failed to list websites", err)
+	}
+
+	return []any{actor.User.ID.String(), actor.User.IsAdmin(), version, sharedVersion}, nil
+}
+
+// NewWebsite holds the URL and crawl settings of a website to create.
+type NewWebsite struct {
+	URL                     string
+	TLSSkipVerify           bool
+	VisualMonitoring        bool
+	MaxCrawlBytes           int64
+	MaxCrawlDurationSeconds int
+	MaxPagesPerPrefix       int
+	MinContentQuality       float64
+	PIIRedaction            bool
+	UserAgent               string
+	UserAgentRotation       []string
+	RequestHeaders          schema.RequestHeaders
+	SeedURLs                []string
+	ScopePrefixes           []string
+}
+
+// ValidateCrawlScope checks that the scope prefixes and seed URLs of a
+// website are on its host and that its start URLs are within the scope.
+func ValidateCrawlScope(websiteURL string, seedURLs, scopePrefixes []string) error {
+	scope, err := crawler.NewScope(websiteURL, scopePrefixes)
+	if err != nil {
+		return apperrors.Validation("Invalid crawl scope: " + err.Error())
+	}
+
+	for _, startURL := range append([]string{websiteURL}, seedURLs...) {
+		if !scope.Contains(startURL) {
+			return apperrors.Validation(fmt.Sprintf("Start URL %s is outside the crawl scope", startURL))
+		}
+	}
+
+	return nil
+}
+
+// ValidateRequestSettings checks the user agent, the rotated user agents and
+// the extra request headers of a website.
+func ValidateRequestSettings(userAgent string, rotation []string, headers schema.RequestHeaders) error {
+	if err := schema.ValidateUserAgents(userAgent, rotation); err != nil {
+		return apperrors.Validation(err.Error())
+	}
+	if err := headers.Validate(); err != nil {
+		return apperrors.Validation(err.Error())
+	}
+	return nil
+}
+
+// checkLimit checks that the actor can create count more websites.
+func (s *WebsiteService) checkLimit(ctx context.Context, actor Actor, count int) error {
+	websiteCount, err := s.userRepo.GetWebsiteCount(ctx, actor.User.ID)
+	if err != nil {
+		return apperrors.Internal("Failed to check website limit", err)
+	}
+
+	if !actor.User.CanCreateWebsite(websiteCount + count - 1) {
+		if count == 1 {
+			return apperrors.QuotaExceeded(fmt.Sprintf("Website limit reached (%d/%d)", websiteCount, actor.User.WebsiteLimit))
+		}
+		return apperrors.QuotaExceeded(fmt.Sprintf("Website limit would be exceeded (%d+%d/%d)", websiteCount, count, actor.User.WebsiteLimit))
+	}
+	return nil
+}
+
+// Create creates a website owned by the actor and enqueues its first crawl.
+// The website is returned even when the crawl can't be enqueued.
+func (s *WebsiteService) Create(ctx context.Context, actor Actor, in NewWebsite) (*schema.Website, error) {
+	if actor.User == nil {
+		return nil, apperrors.Unauthorized("authentication required")
+	}
+
+	if err := ValidateCrawlScope(in.URL, in.SeedURLs, in.ScopePrefixes); err != nil {
+		return nil, err
+	}
+	if err := ValidateRequestSettings(in.UserAgent, in.UserAgentRotation, in.RequestHeaders); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkLimit(ctx, actor, 1); err != nil {
+		return nil, err
+	}
+
+	website, err := s.websiteRepo.Create(ctx, in.URL)
+	if err != nil {
+		return nil, apperrors.Internal("Failed to create website", err)
+	}
+
+	// Associate website with user and apply crawl settings
+	website.UserID = &actor.User.ID
+	website.TLSSkipVerify = in.TLSSkipVerify
+	website.VisualMonitoring = in.VisualMonitoring
+	website.MaxCrawlBytes = in.MaxCrawlBytes
+	website.MaxCrawlDurationSeconds = in.MaxCrawlDurationSeconds
+	website.MaxPagesPerPrefix = in.MaxPagesPerPrefix
+	website.MinContentQuality = in.MinContentQuality
+	website.PIIRedaction = in.PIIRedaction
+	website.UserAgent = in.UserAgent
+	website.UserAgentRotation = in.UserAgentRotation
+	website.RequestHeaders = in.RequestHeaders
+	website.SeedURLs = in.SeedURLs
+	website.ScopePrefixes = in.ScopePrefixes
+	if err := s.websiteRepo.Update(ctx, website); err != nil {
+		s.logger.Error("Failed to associate website with user", zap.Error(err))
+	}
+
+	if err := s.jobClient.EnqueueCrawlWebsite(ctx, website.ID, website.URL); err != nil {
+		s.logger.Error("Failed to enqueue crawl job", zap.Error(err))
+		// Don't fail the request, website is created
+	}
+
+	return website, nil
+}
+
+// BulkCreateResult reports the outcome for a single URL of CreateBulk.
+type BulkCreateResult struct {
+	URL     string
+	Website *schema.Website
+	Queued  bool
+	Error   string
+}
+
+// CreateBulk creates a website owned by the actor for each URL and enqueues
+// their crawls in a single batch. Failures are reported per URL.
+func (s *WebsiteService) CreateBulk(ctx context.Context, actor Actor, urls []string) ([]BulkCreateResult, error) {
+	if actor.User == nil {
+		return nil, apperrors.Unauthorized("authentication required")
+	}
+
+	if err := s.checkLimit(ctx, actor, len(urls)); err != nil {
+		return nil, err
+	}
+
+	owner, _ := tenant.FromContext(ctx)
+	results := make([]BulkCreateResult, len(urls))
+	var items []jobs.BatchItem
+	var itemResults []int
+
+	for i, rawURL := range urls {
+		results[i].URL = rawURL
+
+		website, err := s.websiteRepo.Create(ctx, rawURL)
+		if err != nil {
+			results[i].Error = "Failed to create website"
+			continue
+		}
+
+		website.UserID = &actor.User.ID
+		if err := s.websiteRepo.Update(ctx, website); err != nil {
+			s.logger.Error("Failed to associate website with user", zap.Error(err))
+		}
+		results[i].Website = website
+
+		item, err := jobs.NewCrawlWebsiteItem(website.ID, website.URL, owner, requestid.MetaFromContext(ctx))
+		if err != nil {
+			results[i].Error = "Failed to build crawl job"
+			continue
+		}
+		items = append(items, item)
+		itemResults = append(itemResults, i)
+	}
+
+	summary := s.jobClient.EnqueueBatch(ctx, items)
+	for _, res := range summary.Results {
+		i := itemResults[res.Index]
+		if errors.Is(res.Err, jobs.ErrAlreadyQueued) {
+			results[i].Queued = true
+			continue
+		}
+		if res.Err != nil {
+			s.logger.Error("Failed to enqueue crawl job", zap.String("url", results[i].URL), zap.Error(res.Err))
+			results[i].Error = "Website created but crawl could not be queued"
+			continue
+		}
+		results[i].Queued = true
+	}
+
+	return results, nil
+}
+
+// Recrawl enqueues a crawl of a website that isn't being crawled. Outside
+// the website's crawl window the crawl is scheduled for when it opens, which
+// is returned; the zero time means the crawl starts as soon as possible.
+func (s *WebsiteService) Recrawl(ctx context.Context, website *schema.Website) (time.Time, error) {
+	// A website left crawling without a crawl task, e.g. after its worker
+	// crashed and the task ran out of retries, can be crawled again
+	if website.CrawlStatus == "crawling" {
+		live, err := s.jobClient.CrawlTaskLive(website.ID)
+		if err != nil {
+			s.logger.Warn("Failed to inspect crawl task", zap.Uint("websiteID", website.ID), zap.Error(err))
+		}
+		if err != nil || live {
+			return time.Time{}, apperrors.Conflict("Website is already being crawled")
+		}
+		s.logger.Warn("Website is crawling without a crawl task, crawling it again", zap.Uint("websiteID", website.ID))
+	}
+
+	now := time.Now()
+	delay := website.CrawlDelay(now)
+	err := s.jobClient.EnqueueRecrawlWebsite(ctx, website.ID, delay)
+	if errors.Is(err, jobs.ErrAlreadyQueued) {
+		return time.Time{}, apperrors.Conflict("A crawl is already queued for this website")
+	}
+	if err != nil {
+		s.logger.Error("Failed to enqueue recrawl job", zap.Error(err))
+		return time.Time{}, apperrors.Internal("Failed to enqueue recrawl job", err)
+	}
+
+	if delay == 0 {
+		return time.Time{}, nil
+	}
+	return now.Add(delay).Truncate(time.Second), nil
+}
+
+// SetCrawlWindow restricts the crawls of a website to a daily window in UTC,
+// given as HH:MM start and end times. Empty times remove the window.
+func (s *WebsiteService) SetCrawlWindow(ctx context.Context, website *schema.Website, start, end string) error {
+	website.CrawlWindowStart = sql.NullInt32{}
+	website.CrawlWindowEnd = sql.NullInt32{}
+	if start != "" || end != "" {
+		window, err := schema.ParseCrawlWindow(start, end)
+		if err != nil {
+			return apperrors.Validation(err.Error())
+		}
+		website.CrawlWindowStart = sql.NullInt32{Int32: int32(window.Start), Valid: true}
+		website.CrawlWindowEnd = sql.NullInt32{Int32: int32(window.End), Valid: true}
+	}
+
+	if err := s.websiteRepo.Update(ctx, website); err != nil {
+		return apperrors.Internal("Failed to update crawl window", err)
+	}
+
+	return nil
+}
+
+// Reindex enqueues embedding the stored content of a website again and
+// returns the ID of the job.
+func (s *WebsiteService) Reindex(ctx context.Context, website *schema.Website) (string, error) {
+	taskID, err := s.jobClient.EnqueueRebuildWebsite(ctx, website.ID)
+	if errors.Is(err, jobs.ErrAlreadyQueued) {
+		return "", apperrors.Conflict("A reindex is already queued for this website")
+	}
+	if err != nil {
+		s.logger.Error("Failed to enqueue rebuild job", zap.Error(err))
+		return "", apperrors.Internal("Failed to enqueue reindex job", err)
+	}
+
+	return taskID, nil
+}
+
+// RevectorizeFailedPages enqueues vectorizing the pages of a website whose
+// vectorization failed again and returns the ID of the job.
+func (s *WebsiteService) RevectorizeFailedPages(ctx context.Context, website *schema.Website) (string, error) {
+	taskID, err := s.jobClient.EnqueueRevectorizePages(ctx, website.ID)
+	if errors.Is(err, jobs.ErrAlreadyQueued) {
+		return "", apperrors.Conflict("Failed pages are already queued for vectorization")
+	}
+	if err != nil {
+		s.logger.Error("Failed to enqueue revectorize job", zap.Error(err))
+		return "", apperrors.Internal("Failed to enqueue revectorize job", err)
+	}
+
+	return taskID, nil
+}
+
+// SetCrawlScope replaces the additional start URLs of a website and the URL
+// prefixes its crawls are restricted to.
+func (s *WebsiteService) SetCrawlScope(ctx context.Context, website *schema.Website, seedURLs, scopePrefixes []string) error {
+	if err := ValidateCrawlScope(website.URL, seedURLs, scopePrefixes); err != nil {
+		return err
+	}
+
+	website.SeedURLs = seedURLs
+	website.ScopePrefixes = scopePrefixes
+	if err := s.websiteRepo.Update(ctx, website); err != nil {
+		return apperrors.Internal("Failed to update crawl scope", err)
+	}
+	s.invalidateAnswers(ctx, website.ID)
+
+	return nil
+}
+
+// SetContentQuality sets the minimum content quality of a website's pages,
+// 0 for the global default.
+func (s *WebsiteService) SetContentQuality(ctx context.Context, website *schema.Website, minQuality float64) error {
+	if minQuality < 0 || minQuality > 1 {
+		return apperrors.Validation("Minimum content quality must be between 0 and 1")
+	}
+
+	website.MinContentQuality = minQuality
+	if err := s.websiteRepo.Update(ctx, website); err != nil {
+		return apperrors.Internal("Failed to update content quality", err)
+	}
+	s.invalidateAnswers(ctx, website.ID)
+
+	return nil
+}
+
+// SetRequestSettings replaces the user agent a website is crawled with, the
+// user agents rotated between its requests and the extra headers sent with
+// them. Empty values use the server's user agent without extra headers.
+func (s *WebsiteService) SetRequestSettings(ctx context.Context, website *schema.Website, userAgent string, rotation []string, headers schema.RequestHeaders) error {
+	if err := ValidateRequestSettings(userAgent, rotation, headers); err != nil {
+		return err
+	}
+
+	website.UserAgent = userAgent
+	website.UserAgentRotation = rotation
+	website.RequestHeaders = headers
+	if err := s.websiteRepo.Update(ctx, website); err != nil {
+		return apperrors.Internal("Failed to update request settings", err)
+	}
+
+	return nil
+}
+
+// SetRespectRobots overrides whether robots.txt is respected when crawling a
+// website, nil for the server setting. Changes are logged for auditing.
+func (s *WebsiteService) SetRespectRobots(ctx context.Context, actor Actor, website *schema.Website, respect *bool) error {
+	previous := website.RespectRobots
+	website.RespectRobots = sql.NullBool{}
+	if respect != nil {
+		website.RespectRobots = sql.NullBool{Bool: *respect, Valid: true}
+	}
+	if err := s.websiteRepo.Update(ctx, website); err != nil {
+		return apperrors.Internal("Failed to update robots.txt compliance", err)
+	}
+
+	fields := []zap.Field{
+		zap.Uint("websiteID", website.ID),
+		zap.Any("respectRobots", respect),
+		zap.Any("previous", nullBool(previous)),
+		zap.String("requestID", requestid.FromContext(ctx)),
+	}
+	if actor.User != nil {
+		fields = append(fields, zap.String("userID", actor.User.ID.String()))
+	}
+	if actor.APIKeyID != nil {
+		fields = append(fields, zap.String("apiKeyID", actor.APIKeyID.String()))
+	}
+	if respect != nil && !*respect {
+		s.logger.Warn("robots.txt compliance disabled for website", fields...)
+	} else {
+		s.logger.Info("robots.txt compliance changed for website", fields...)
+	}
+
+	return nil
+}
+
+// nullBool returns the value of b, nil when null.
+func nullBool(b sql.NullBool) *bool {
+	if !b.Valid {
+		return nil
+	}
+	return &b.Bool
+}
+
+// SetPIIRedaction turns redacting personal data from a website's pages on or
+// off. Pages are redacted from the next crawl on; when turned off, the
+// redaction counts of the pages are reset.
+func (s *WebsiteService) SetPIIRedaction(ctx context.Context, website *schema.Website, enabled bool) error {
+	website.PIIRedaction = enabled
+	if err := s.websiteRepo.Update(ctx, website); err != nil {
+		return apperrors.Internal("Failed to update PII redaction", err)
+	}
+
+	if !enabled {
+		if err := s.pageRepo.ClearPIIRedactions(ctx, website.ID); err != nil {
+			return apperrors.Internal("Failed to reset PII redaction counts", err)
+		}
+	}
+	s.invalidateAnswers(ctx, website.ID)
+
+	return nil
+}
+
+// SetNoisePatterns replaces the noise patterns of a website and how they
+// combine with the server's. Patterns are phrases, or regular expressions
+// wrapped in slashes.
+func (s *WebsiteService) SetNoisePatterns(ctx context.Context, website *schema.Website, mode string, patterns []string) error {
+	if err := schema.ValidateNoiseRemoval(mode, patterns); err != nil {
+		return apperrors.Validation(err.Error())
+	}
+	if _, err := contentprocessor.ParseNoisePatterns(patterns); err != nil {
+		return apperrors.Validation(err.Error())
+	}
+
+	website.NoiseRemoval = mode
+	website.NoisePatterns = patterns
+	if err := s.websiteRepo.Update(ctx, website); err != nil {
+		return apperrors.Internal("Failed to update noise patterns", err)
+	}
+	s.invalidateAnswers(ctx, website.ID)
+
+	return nil
+}
+
+// invalidateAnswers drops the cached answers of a website whose indexing
+// settings changed, as they may quote content the settings now exclude or
+// redact. Failures are only logged, the answers still expire.
+func (s *WebsiteService) invalidateAnswers(ctx context.Context, websiteID uint) {
+	if err := s.answerCache.Invalidate(ctx, websiteID); err != nil {
+		s.logger.Warn("Failed to invalidate cached answers", zap.Uint("websiteID", websiteID), zap.Error(err))
+	}
+}
+
+// SetLabels replaces the labels of a website, normalized so they match
+// regardless of case and spacing.
+func (s *WebsiteService) SetLabels(ctx context.Context, website *schema.Website, labels []string) error {
+	normalized, err := schema.NormalizeLabels(labels)
+	if err != nil {
+		return apperrors.Validation(err.Error())
+	}
+
+	website.Labels = normalized
+	if err := s.websiteRepo.Update(ctx, website); err != nil {
+		return apperrors.Internal("Failed to update labels", err)
+	}
+
+	return nil
+}
+
+// ListLabels returns the labels of the websites accessible to the actor,
+// with the number of websites labelled with each, most used first.
+func (s *WebsiteService) ListLabels(ctx context.Context, actor Actor) ([]schema.LabelCount, error) {
+	websites, _, err := s.ListAccessible(ctx, actor, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, website := range websites {
+		for _, label := range website.Labels {
+			counts[label]++
+		}
+	}
+
+	labels := make([]schema.LabelCount, 0, len(counts))
+	for label, count := range counts {
+		labels = append(labels, schema.LabelCount{Label: label, Websites: count})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Websites != labels[j].Websites {
+			return labels[i].Websites > labels[j].Websites
+		}
+		return labels[i].Label < labels[j].Label
+	})
+	return labels, nil
+}
+
+// PIIReport returns the number of redactions of personal data in a
+// website's pages.
+func (s *WebsiteService) PIIReport(ctx context.Context, website *schema.Website) (*schema.PIIReport, error) {
+	report, err := s.pageRepo.GetPIIReport(ctx, website.ID)
+	if err != nil {
+		return nil, apperrors.Internal("Failed to get PII report", err)
+	}
+	report.Enabled = website.PIIRedaction
+	return report, nil
+}
+
+// PageScreenshot returns the PNG screenshot of a website's page from its last
+// crawl.
+func (s *WebsiteService) PageScreenshot(ctx context.Context, website *schema.Website, pageID uint) ([]byte, error) {
+	page, err := s.pageRepo.GetByID(ctx, pageID)
+	if err != nil {
+		return nil, apperrors.Internal("Failed to get page", err)
+	}
+	if page == nil || page.WebsiteID != website.ID {
+		return nil, apperrors.NotFound("Page not found")
+	}
+	if !page.ScreenshotKey.Valid {
+		return nil, apperrors.NotFound("No screenshot was captured for this page")
+	}
+
+	image, err := s.storage.GetScreenshot(ctx, page.ScreenshotKey.String)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, apperrors.NotFound("No screenshot was captured for this page")
+		}
+		return nil, apperrors.Internal("Failed to get page screenshot", err)
+	}
+	return image, nil
+}
+
+// PageContentMetadata returns the metadata of the stored content of a
+// website's page without downloading it.
+func (s *WebsiteService) PageContentMetadata(ctx context.Context, website *schema.Website, pageID uint) (*storage.PageInfo, error) {
+	page, err := s.pageRepo.GetByID(ctx, pageID)
+	if err != nil {
+		return nil, apperrors.Internal("Failed to get page", err)
+	}
+	if page == nil || page.WebsiteID != website.ID {
+		return nil, apperrors.NotFound("Page not found")
+	}
+	if !page.MinioObjectKey.Valid {
+		return nil, apperrors.NotFound("No content was stored for this page")
+	}
+
+	info, err := s.storage.GetPageMetadata(ctx, page.MinioObjectKey.String)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, apperrors.NotFound("No content was stored for this page")
+		}
+		return nil, apperrors.Internal("Failed to get page content metadata", err)
+	}
+	return info, nil
+}
+
+// ReprocessPage enqueues extracting a website's page again from its archived
+// HTML without fetching it. It returns the ID of the job.
+func (s *WebsiteService) ReprocessPage(ctx context.Context, website *schema.Website, pageID uint) (string, error) {
+	page, err := s.pageRepo.GetByID(ctx, pageID)
+	if err != nil {
+		return "", apperrors.Internal("Failed to get page", err)
+	}
+	if page == nil || page.WebsiteID != website.ID {
+		return "", apperrors.NotFound("Page not found")
+	}
+	if !page.HTMLObjectKey.Valid {
+		return "", apperrors.Conflict("No HTML was archived for this page, enable ARCHIVE_RAW_HTML and recrawl it first")
+	}
+
+	taskID, err := s.jobClient.EnqueueReprocessPage(ctx, website.ID, page.ID)
+	if err != nil {
+		s.logger.Error("Failed to enqueue reprocess job", zap.Error(err))
+		return "", apperrors.Internal("Failed to enqueue reprocess job", err)
+	}
+	return taskID, nil
+}
+
+// IndexPages enqueues indexing pages of a website without a full crawl. It
+// returns the deduplicated URLs and the ID of the job.
+func (s *WebsiteService) IndexPages(ctx context.Context, website *schema.Website, rawURLs []string) ([]string, string, error) {
+	if len(rawURLs) == 0 {
+		return nil, "", apperrors.Validation("At least one URL is required")
+	}
+	if len(rawURLs) > MaxIndexPages {
+		return nil, "", apperrors.Validation(fmt.Sprintf("At most %d URLs can be indexed at once", MaxIndexPages))
+	}
+
+	websiteURL, err := url.Parse(website.URL)
+	if err != nil {
+		return nil, "", apperrors.Internal("Invalid website URL", err)
+	}
+
+	// Only pages of the website itself can be indexed
+	seen := make(map[string]bool, len(rawURLs))
+	urls := make([]string, 0, len(rawURLs))
+	for _, rawURL := range rawURLs {
+		pageURL, err := url.Parse(strings.TrimSpace(rawURL))
+		if err != nil || (pageURL.Scheme != "http" && pageURL.Scheme != "https") {
+			return nil, "", apperrors.Validation(fmt.Sprintf("Invalid URL: %s", rawURL))
+		}
+		if !strings.EqualFold(pageURL.Host, websiteURL.Host) {
+			return nil, "", apperrors.Validation(fmt.Sprintf("URL %s does not belong to %s", rawURL, websiteURL.Host))
+		}
+
+		if !seen[pageURL.String()] {
+			seen[pageURL.String()] = true
+			urls = append(urls, pageURL.String())
+		}
+	}
+
+	taskID, err := s.jobClient.EnqueueIndexPages(ctx, website.ID, urls)
+	if err != nil {
+		s.logger.Error("Failed to enqueue index job", zap.Error(err))
+		return nil, "", apperrors.Internal("Failed to enqueue index job", err)
+	}
+
+	return urls, taskID, nil
+}
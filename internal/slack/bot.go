@@ -0,0 +1,197 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/config"
+	"hermit/internal/llm"
+	"hermit/internal/repositories"
+	"hermit/internal/schema"
+	"hermit/internal/service"
+	"hermit/internal/tenant"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// updateInterval throttles the edits streaming an answer, Slack rate
+	// limits chat.update to about one call per second per channel
+	updateInterval = 1500 * time.Millisecond
+	// answerTimeout bounds answering a question
+	answerTimeout = 2 * time.Minute
+	// maxSources is the number of source links listed under an answer
+	maxSources = 5
+)
+
+// Bot answers questions asked in linked channels from their website.
+type Bot struct {
+	client        *Client
+	signingSecret string
+	channelRepo   *repositories.SlackChannelRepository
+	websiteRepo   *repositories.WebsiteRepository
+	queries       *service.QueryService
+	logger        *zap.Logger
+}
+
+// NewBot creates a new Bot. It returns nil when no bot token or signing
+// secret is configured, which disables the Slack app.
+func NewBot(
+	cfg *config.Config,
+	channelRepo *repositories.SlackChannelRepository,
+	websiteRepo *repositories.WebsiteRepository,
+	queries *service.QueryService,
+	logger *zap.Logger,
+) *Bot {
+	if cfg.SlackBotToken == "" || cfg.SlackSigningSecret == "" {
+		return nil
+	}
+	return &Bot{
+		client:        NewClient(cfg.SlackBotToken, time.Duration(cfg.HTTPTimeout)*time.Second),
+		signingSecret: cfg.SlackSigningSecret,
+		channelRepo:   channelRepo,
+		websiteRepo:   websiteRepo,
+		queries:       queries,
+		logger:        logger.Named("slack"),
+	}
+}
+
+// Verify checks that a request was signed by Slack.
+func (b *Bot) Verify(header http.Header, body []byte) error {
+	return VerifyRequest(b.signingSecret, header, body, time.Now())
+}
+
+// Question is a question asked in a channel.
+type Question struct {
+	ChannelID string
+	// ThreadTS answers in the thread of a message when set
+	ThreadTS string
+	UserID   string
+	Text     string
+}
+
+// Resolve returns the channel link and website a question asked in a
+// channel is answered from. It returns apperrors.NotFound when the channel
+// isn't linked.
+func (b *Bot) Resolve(ctx context.Context, channelID string) (*schema.SlackChannel, *schema.Website, error) {
+	channel, err := b.channelRepo.Get(ctx, channelID)
+	if err != nil {
+		return nil, nil, apperrors.Internal("Failed to look up channel", err)
+	}
+	if channel == nil {
+		return nil, nil, apperrors.NotFound("This channel isn't linked to a website")
+	}
+
+	website, err := b.websiteRepo.GetByID(ctx, channel.WebsiteID)
+	if err != nil {
+		return nil, nil, apperrors.Internal("Failed to look up website", err)
+	}
+	if website == nil {
+		return nil, nil, apperrors.NotFound("The website linked to this channel no longer exists")
+	}
+	return channel, website, nil
+}
+
+// Answer posts the answer to a question in its channel, editing the message
+// as the answer streams in. Failures are reported in the channel.
+func (b *Bot) Answer(ctx context.Context, q Question) {
+	ctx, cancel := context.WithTimeout(ctx, answerTimeout)
+	defer cancel()
+	logger := b.logger.With(zap.String("channel", q.ChannelID), zap.String("slackUser", q.UserID))
+
+	channel, website, err := b.Resolve(ctx, q.ChannelID)
+	if err != nil {
+		b.reply(ctx, logger, q, "", err)
+		return
+	}
+
+	// Attribute the query to the user who linked the channel
+	if channel.CreatedBy != nil {
+		ctx = tenant.WithTenant(ctx, tenant.Tenant{UserID: channel.CreatedBy.String()})
+	}
+	logger = tenant.Logger(ctx, logger).With(zap.Uint("websiteID", website.ID))
+
+	header := fmt.Sprintf("<@%s> asked: %s", q.UserID, escape(q.Text))
+	ts, err := b.client.PostMessage(ctx, q.ChannelID, q.ThreadTS, header+"\n_Searching "+escape(website.URL)+"..._")
+	if err != nil {
+		logger.Warn("Failed to post answer message", zap.Error(err))
+		return
+	}
+
+	var answer strings.Builder
+	lastUpdate := time.Now()
+	meta, _, err := b.queries.QueryStream(ctx, service.Actor{}, website, service.Question{Query: q.Text},
+		func(*llm.QueryStreamMeta) error { return nil },
+		func(chunk string) error {
+			answer.WriteString(chunk)
+			if time.Since(lastUpdate) < updateInterval {
+				return nil
+			}
+			lastUpdate = time.Now()
+			if err := b.client.UpdateMessage(ctx, q.ChannelID, ts, header+"\n"+formatAnswer(answer.String())+" ..."); err != nil {
+				logger.Debug("Failed to update streaming answer", zap.Error(err))
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		logger.Warn("Failed to answer question", zap.Error(err))
+		b.reply(ctx, logger, q, ts, err)
+		return
+	}
+
+	text := header + "\n" + formatAnswer(answer.String()) + formatSources(meta.Sources)
+	if err := b.client.UpdateMessage(ctx, q.ChannelID, ts, text); err != nil {
+		logger.Warn("Failed to post answer", zap.Error(err))
+	}
+}
+
+// reply reports an error in the channel, editing the answer message ts when
+// it was posted.
+func (b *Bot) reply(ctx context.Context, logger *zap.Logger, q Question, ts string, err error) {
+	message := "Sorry, something went wrong answering your question."
+	if appErr, ok := apperrors.As(err); ok && appErr.Code != apperrors.CodeInternal {
+		message = appErr.Message
+	}
+
+	if ts != "" {
+		err = b.client.UpdateMessage(ctx, q.ChannelID, ts, ":warning: "+escape(message))
+	} else {
+		_, err = b.client.PostMessage(ctx, q.ChannelID, q.ThreadTS, ":warning: "+escape(message))
+	}
+	if err != nil {
+		logger.Warn("Failed to report error in channel", zap.Error(err))
+	}
+}
+
+// formatAnswer converts the Markdown of an answer to Slack's mrkdwn.
+func formatAnswer(answer string) string {
+	answer = escape(strings.TrimSpace(answer))
+	return strings.ReplaceAll(answer, "**", "*")
+}
+
+// formatSources lists the distinct pages an answer was generated from.
+func formatSources(sources []llm.QuerySource) string {
+	var b strings.Builder
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		if seen[source.PageURL] || len(seen) == maxSources {
+			continue
+		}
+		seen[source.PageURL] = true
+
+		title := source.PageTitle
+		if title == "" {
+			title = source.PageURL
+		}
+		if b.Len() == 0 {
+			b.WriteString("\n\n*Sources*")
+		}
+		fmt.Fprintf(&b, "\n• <%s|%s>", source.PageURL, strings.ReplaceAll(escape(title), "|", "-"))
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+// Package slack answers questions asked in Slack channels linked to a
+// website, through a slash command or by mentioning the app.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const apiURL = "https://slack.com/api"
+
+// maxRequestAge is how old a signed request may be before it is rejected as
+// a possible replay.
+const maxRequestAge = 5 * time.Minute
+
+// ErrInvalidSignature is returned for requests not signed by Slack.
+var ErrInvalidSignature = errors.New("invalid slack signature")
+
+// VerifyRequest checks that a request was signed by Slack with the signing
+// secret of the app.
+func VerifyRequest(signingSecret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Client calls the Slack Web API with a bot token.
+type Client struct {
+	token  string
+	client *http.Client
+}
+
+// NewClient creates a new Client.
+func NewClient(token string, timeout time.Duration) *Client {
+	return &Client{token: token, client: &http.Client{Timeout: timeout}}
+}
+
+// PostMessage posts a message to a channel, in the thread of threadTS when
+// set, and returns its timestamp.
+func (c *Client) PostMessage(ctx context.Context, channel, threadTS, text string) (string, error) {
+	payload := map[string]any{
+		"channel":      channel,
+		"text":         text,
+		"unfurl_links": false,
+	}
+	if threadTS != "" {
+		payload["thread_ts"] = threadTS
+	}
+
+	var resp struct {
+		TS string `json:"ts"`
+	}
+	if err := c.call(ctx, "chat.postMessage", payload, &resp); err != nil {
+		return "", err
+	}
+	return resp.TS, nil
+}
+
+// UpdateMessage replaces the text of a message posted by the bot.
+func (c *Client) UpdateMessage(ctx context.Context, channel, ts, text string) error {
+	payload := map[string]any{
+		"channel": channel,
+		"ts":      ts,
+		"text":    text,
+	}
+	return c.call(ctx, "chat.update", payload, nil)
+}
+
+func (c *Client) call(ctx context.Context, method string, payload, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+
+	// Slack reports errors in the body of 200 responses
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("slack %s: HTTP %d: %w", method, resp.StatusCode, err)
+	}
+	if !result.OK {
+		return fmt.Errorf("slack %s: %s", method, result.Error)
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+// escape escapes the characters Slack treats as control sequences in text.
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
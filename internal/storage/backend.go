@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"hermit/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// Supported storage drivers.
+const (
+	DriverS3    = "s3"
+	DriverLocal = "local"
+)
+
+// ErrObjectNotFound is returned when an object does not exist.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectOptions describes an object being stored.
+type ObjectOptions struct {
+	ContentType string
+	Metadata    map[string]string
+}
+
+// Usage is the number and total size of stored objects.
+type Usage struct {
+	Objects int   `json:"objects"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// Backend stores raw objects. Keys are slash-separated paths such as
+// "websites/1/example.com/index_ab12cd34.txt".
+type Backend interface {
+	// EnsureBucket prepares the backend for use, creating the bucket or
+	// directory if needed. It doubles as a health check.
+	EnsureBucket(ctx context.Context) error
+	// PutObject stores data under key, replacing any existing object.
+	PutObject(ctx context.Context, key string, data []byte, opts ObjectOptions) error
+	// GetObject returns the data and metadata of an object, or ErrObjectNotFound.
+	GetObject(ctx context.Context, key string) ([]byte, map[string]string, error)
+	// StatObject returns the size, content type and metadata of an object
+	// without its data, or ErrObjectNotFound.
+	StatObject(ctx context.Context, key string) (ObjectInfo, error)
+	// ListObjectKeys returns the keys of all objects under a prefix.
+	ListObjectKeys(ctx context.Context, prefix string) ([]string, error)
+	// Usage returns the number and total size of objects under a prefix.
+	Usage(ctx context.Context, prefix string) (Usage, error)
+	// DeleteObject removes an object. Deleting a missing object is not an error.
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// NewBackend creates the storage backend selected by STORAGE_DRIVER.
+func NewBackend(cfg *config.Config, logger *zap.Logger) (Backend, error) {
+	switch cfg.StorageDriver {
+	case DriverS3, "":
+		return NewS3Backend(cfg, logger)
+	case DriverLocal:
+		return NewLocalBackend(cfg.StorageLocalPath, logger)
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.StorageDriver)
+	}
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"container/list"
+	"sync"
+)
+
+// contentCache is a size-bounded LRU cache of decompressed page content keyed
+// by object key. It keeps hot pages from being downloaded from storage again.
+type contentCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+}
+
+// contentCacheEntry is a cached object.
+type contentCacheEntry struct {
+	key     string
+	content string
+}
+
+// newContentCache creates a cache holding at most maxBytes of content. It
+// returns nil when maxBytes is not positive, which disables caching.
+func newContentCache(maxBytes int64) *contentCache {
+	if maxBytes <= 0 {
+		return nil
+	}
+	return &contentCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached content of an object.
+func (c *contentCache) get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*contentCacheEntry).content, true
+}
+
+// put caches the content of an object, evicting the least recently used
+// objects to stay within the size limit. Objects larger than a quarter of the
+// cache are not cached so a single page can't flush it.
+func (c *contentCache) put(key, content string) {
+	if c == nil || int64(len(content)) > c.maxBytes/4 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+
+	elem := c.order.PushFront(&contentCacheEntry{key: key, content: content})
+	c.entries[key] = elem
+	c.size += int64(len(content))
+
+	for c.size > c.maxBytes {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// remove drops an object from the cache.
+func (c *contentCache) remove(key string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// removeElement drops an element. The caller must hold c.mu.
+func (c *contentCache) removeElement(elem *list.Element) {
+	entry := c.order.Remove(elem).(*contentCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= int64(len(entry.content))
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported page content encodings, stored in the object's metadata.
+const (
+	EncodingNone = "identity"
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+// encodingMetadataKey is the user metadata key recording how an object was
+// compressed. A custom key is used because a plain Content-Encoding header
+// could be decoded by HTTP clients. Objects without it were written before
+// compression was added and are plain text.
+const encodingMetadataKey = "Hermit-Encoding"
+
+// compress encodes data with the given encoding.
+func compress(data []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch encoding {
+	case EncodingNone, "":
+		return data, nil
+	case EncodingGzip:
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to gzip content: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("failed to gzip content: %w", err)
+		}
+	case EncodingZstd:
+		w, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to zstd content: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("failed to zstd content: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decompress returns a reader that decodes r according to encoding.
+func decompress(r io.Reader, encoding string) (io.ReadCloser, error) {
+	switch encoding {
+	case EncodingNone, "":
+		return io.NopCloser(r), nil
+	case EncodingGzip:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip content: %w", err)
+		}
+		return gr, nil
+	case EncodingZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zstd content: %w", err)
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+}
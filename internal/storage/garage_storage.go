@@ -5,88 +5,222 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"hermit/internal/config"
+	"hermit/internal/resilience"
+	"io"
 	"net/url"
 	"path"
+	"time"
 
-	"github.com/minio/minio-go/v7"
 	"go.uber.org/zap"
 )
 
-// GarageStorage handles storing crawled content in Garage S3 storage.
+// GarageStorage handles storing crawled content and screenshots. Objects are
+// kept in Garage S3 storage by default, or in any other configured Backend.
 type GarageStorage struct {
-	client     *minio.Client
-	bucketName string
-	logger     *zap.Logger
+	backend     Backend
+	compression string
+	cache       *contentCache
+	guard       *resilience.Guard
+	logger      *zap.Logger
 }
 
-// NewGarageStorage creates a new GarageStorage service.
-func NewGarageStorage(client *minio.Client, cfg *config.Config, logger *zap.Logger) *GarageStorage {
+// NewGarageStorage creates a new GarageStorage service. Calls to the backend
+// are guarded by the "storage" breaker registered in breakers.
+func NewGarageStorage(backend Backend, cfg *config.Config, breakers *resilience.Registry, logger *zap.Logger) *GarageStorage {
+	compression := cfg.StorageCompression
+	switch compression {
+	case EncodingGzip, EncodingZstd, EncodingNone:
+	case "", "none":
+		compression = EncodingNone
+	default:
+		logger.Warn("Unknown storage compression, storing content uncompressed", zap.String("compression", compression))
+		compression = EncodingNone
+	}
+
 	return &GarageStorage{
-		client:     client,
-		bucketName: cfg.GarageBucketName,
-		logger:     logger,
+		backend:     backend,
+		compression: compression,
+		cache:       newContentCache(int64(cfg.StorageCacheMaxMB) << 20),
+		guard:       breakers.Guard("storage", resilience.OptionsFromConfig(cfg), isTransient),
+		logger:      logger,
 	}
 }
 
+// isTransient reports whether a backend error may go away on retry. Missing
+// objects are an answer, not a failure of the backend.
+func isTransient(err error) bool {
+	return !errors.Is(err, ErrObjectNotFound) && !errors.Is(err, context.Canceled)
+}
+
 // EnsureBucket creates the bucket if it doesn't exist.
 func (s *GarageStorage) EnsureBucket(ctx context.Context) error {
-	exists, err := s.client.BucketExists(ctx, s.bucketName)
+	return s.backend.EnsureBucket(ctx)
+}
+
+// SavePageContent saves the content of a crawled page to storage, compressed
+// with the configured encoding, along with its metadata. Returns the object
+// key where the content was stored.
+func (s *GarageStorage) SavePageContent(ctx context.Context, websiteID int, pageURL string, content string, meta PageMetadata) (string, error) {
+	// Generate a unique key for this page
+	objectKey := s.generateObjectKey(websiteID, pageURL)
+
+	// Compress content
+	contentBytes, err := compress([]byte(content), s.compression)
 	if err != nil {
-		return fmt.Errorf("failed to check if bucket exists: %w", err)
+		return "", err
 	}
 
-	if !exists {
-		err = s.client.MakeBucket(ctx, s.bucketName, minio.MakeBucketOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to create bucket: %w", err)
-		}
-		s.logger.Info("Created Garage bucket", zap.String("bucket", s.bucketName))
+	// Upload to storage
+	err = s.guard.Do(ctx, func(ctx context.Context) error {
+		return s.backend.PutObject(ctx, objectKey, contentBytes, ObjectOptions{
+			ContentType: "text/plain; charset=utf-8",
+			Metadata:    pageMetadata(websiteID, pageURL, s.compression, meta),
+		})
+	})
+
+	if err != nil {
+		return "", err
 	}
+	s.cache.remove(objectKey)
 
-	return nil
+	s.logger.Info("Saved page content",
+		zap.String("objectKey", objectKey),
+		zap.String("url", pageURL),
+		zap.Int("size", len(content)),
+		zap.Int("storedSize", len(contentBytes)),
+		zap.String("encoding", s.compression),
+	)
+
+	return objectKey, nil
 }
 
-// SavePageContent saves the content of a crawled page to Garage.
-// Returns the object key where the content was stored.
-func (s *GarageStorage) SavePageContent(ctx context.Context, websiteID int, pageURL string, content string) (string, error) {
-	// Generate a unique key for this page
-	objectKey := s.generateObjectKey(websiteID, pageURL)
+// SaveScreenshot saves a PNG screenshot of a page to storage.
+// Returns the object key where the screenshot was stored.
+func (s *GarageStorage) SaveScreenshot(ctx context.Context, websiteID int, pageURL string, image []byte) (string, error) {
+	// Format: websites/<website_id>/screenshots/<url_hash>/<unix_ts>.png
+	objectKey := fmt.Sprintf("websites/%d/screenshots/%s/%d.png", websiteID, hashString(pageURL)[:16], time.Now().Unix())
 
-	// Convert content to bytes
-	contentBytes := []byte(content)
-	reader := bytes.NewReader(contentBytes)
-
-	// Upload to Garage
-	_, err := s.client.PutObject(
-		ctx,
-		s.bucketName,
-		objectKey,
-		reader,
-		int64(len(contentBytes)),
-		minio.PutObjectOptions{
-			ContentType: "text/plain",
-			UserMetadata: map[string]string{
+	err := s.guard.Do(ctx, func(ctx context.Context) error {
+		return s.backend.PutObject(ctx, objectKey, image, ObjectOptions{
+			ContentType: "image/png",
+			Metadata: map[string]string{
 				"website-id": fmt.Sprintf("%d", websiteID),
 				"page-url":   pageURL,
 			},
-		},
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.logger.Debug("Saved page screenshot",
+		zap.String("objectKey", objectKey),
+		zap.String("url", pageURL),
+		zap.Int("size", len(image)),
 	)
 
+	return objectKey, nil
+}
+
+// SavePageScreenshot saves the latest PNG screenshot of a page, replacing the
+// one of its previous crawl. Returns the object key where it was stored.
+func (s *GarageStorage) SavePageScreenshot(ctx context.Context, websiteID int, pageURL string, image []byte) (string, error) {
+	// Format: websites/<website_id>/pages/<url_hash>.png
+	objectKey := fmt.Sprintf("websites/%d/pages/%s.png", websiteID, hashString(pageURL))
+
+	err := s.guard.Do(ctx, func(ctx context.Context) error {
+		return s.backend.PutObject(ctx, objectKey, image, ObjectOptions{
+			ContentType: "image/png",
+			Metadata: map[string]string{
+				"website-id": fmt.Sprintf("%d", websiteID),
+				"page-url":   pageURL,
+			},
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return objectKey, nil
+}
+
+// SavePageHTML archives the raw HTML of a fetched page, replacing the one of
+// its previous fetch. It is compressed even when page content is stored
+// uncompressed. Returns the object key where it was stored.
+func (s *GarageStorage) SavePageHTML(ctx context.Context, websiteID int, pageURL string, html []byte, contentType string) (string, error) {
+	// Format: websites/<website_id>/html/<url_hash>.html
+	objectKey := fmt.Sprintf("websites/%d/html/%s.html", websiteID, hashString(pageURL))
+
+	encoding := s.compression
+	if encoding == EncodingNone {
+		encoding = EncodingGzip
+	}
+	data, err := compress(html, encoding)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload content to Garage: %w", err)
+		return "", err
 	}
 
-	s.logger.Info("Saved page content to Garage",
+	err = s.guard.Do(ctx, func(ctx context.Context) error {
+		return s.backend.PutObject(ctx, objectKey, data, ObjectOptions{
+			ContentType: "text/html",
+			Metadata:    pageMetadata(websiteID, pageURL, encoding, PageMetadata{SourceContentType: contentType}),
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.logger.Debug("Archived page HTML",
 		zap.String("objectKey", objectKey),
 		zap.String("url", pageURL),
-		zap.Int("size", len(contentBytes)),
+		zap.Int("size", len(html)),
+		zap.Int("storedSize", len(data)),
 	)
 
 	return objectKey, nil
 }
 
+// GetPageHTML retrieves archived raw HTML by object key along with the
+// content type the page was served with.
+func (s *GarageStorage) GetPageHTML(ctx context.Context, objectKey string) ([]byte, string, error) {
+	var data []byte
+	var metadata map[string]string
+	err := s.guard.Do(ctx, func(ctx context.Context) error {
+		var err error
+		data, metadata, err = s.backend.GetObject(ctx, objectKey)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	reader, err := decompress(bytes.NewReader(data), metadataValue(metadata, encodingMetadataKey))
+	if err != nil {
+		return nil, "", err
+	}
+	defer reader.Close()
+
+	html, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read archived HTML: %w", err)
+	}
+	return html, metadataValue(metadata, sourceContentTypeMetadataKey), nil
+}
+
+// GetScreenshot retrieves a PNG screenshot by object key.
+func (s *GarageStorage) GetScreenshot(ctx context.Context, objectKey string) ([]byte, error) {
+	var data []byte
+	err := s.guard.Do(ctx, func(ctx context.Context) error {
+		var err error
+		data, _, err = s.backend.GetObject(ctx, objectKey)
+		return err
+	})
+	return data, err
+}
+
 // generateObjectKey creates a unique key for storing page content.
 // Format: websites/<website_id>/<url_hash>.txt
 func (s *GarageStorage) generateObjectKey(websiteID int, pageURL string) string {
@@ -124,19 +258,84 @@ func hashString(s string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// GetPageContent retrieves content from Garage by object key.
+// GetPageContent retrieves content from storage by object key, decompressing
+// it if needed. Objects stored before compression was added are read as is.
+// Recently read content is served from an in-memory cache.
 func (s *GarageStorage) GetPageContent(ctx context.Context, objectKey string) (string, error) {
-	object, err := s.client.GetObject(ctx, s.bucketName, objectKey, minio.GetObjectOptions{})
+	if content, ok := s.cache.get(objectKey); ok {
+		return content, nil
+	}
+
+	var data []byte
+	var metadata map[string]string
+	err := s.guard.Do(ctx, func(ctx context.Context) error {
+		var err error
+		data, metadata, err = s.backend.GetObject(ctx, objectKey)
+		return err
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to get object from Garage: %w", err)
+		return "", err
 	}
-	defer object.Close()
+
+	reader, err := decompress(bytes.NewReader(data), metadata[encodingMetadataKey])
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
 
 	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(object)
+	_, err = buf.ReadFrom(reader)
 	if err != nil {
 		return "", fmt.Errorf("failed to read object content: %w", err)
 	}
 
-	return buf.String(), nil
+	content := buf.String()
+	s.cache.put(objectKey, content)
+
+	return content, nil
+}
+
+// GetPageMetadata returns the metadata of the content stored for a page
+// without downloading it, or ErrObjectNotFound.
+func (s *GarageStorage) GetPageMetadata(ctx context.Context, objectKey string) (*PageInfo, error) {
+	var info ObjectInfo
+	err := s.guard.Do(ctx, func(ctx context.Context) error {
+		var err error
+		info, err = s.backend.StatObject(ctx, objectKey)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newPageInfo(objectKey, info), nil
+}
+
+// ListObjectKeys returns the keys of all objects under a prefix.
+func (s *GarageStorage) ListObjectKeys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := s.guard.Do(ctx, func(ctx context.Context) error {
+		var err error
+		keys, err = s.backend.ListObjectKeys(ctx, prefix)
+		return err
+	})
+	return keys, err
+}
+
+// Usage returns the number and total size of objects under a prefix.
+func (s *GarageStorage) Usage(ctx context.Context, prefix string) (Usage, error) {
+	var usage Usage
+	err := s.guard.Do(ctx, func(ctx context.Context) error {
+		var err error
+		usage, err = s.backend.Usage(ctx, prefix)
+		return err
+	})
+	return usage, err
+}
+
+// DeleteObject removes an object from storage.
+func (s *GarageStorage) DeleteObject(ctx context.Context, objectKey string) error {
+	s.cache.remove(objectKey)
+	return s.guard.Do(ctx, func(ctx context.Context) error {
+		return s.backend.DeleteObject(ctx, objectKey)
+	})
 }
@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// metadataDir holds object metadata next to the objects of a LocalBackend.
+const metadataDir = ".meta"
+
+// contentTypeMetadataKey records the content type of an object with its
+// metadata, which S3 services keep apart.
+const contentTypeMetadataKey = "Content-Type"
+
+// LocalBackend stores objects as files under a directory, so Hermit can run
+// without an S3 service during development.
+type LocalBackend struct {
+	root   string
+	logger *zap.Logger
+}
+
+// NewLocalBackend creates a new LocalBackend rooted at dir.
+func NewLocalBackend(dir string, logger *zap.Logger) (*LocalBackend, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve storage path: %w", err)
+	}
+
+	logger.Info("Using local filesystem storage", zap.String("path", root))
+
+	return &LocalBackend{
+		root:   root,
+		logger: logger,
+	}, nil
+}
+
+// EnsureBucket creates the storage directory if it doesn't exist.
+func (b *LocalBackend) EnsureBucket(ctx context.Context) error {
+	if err := os.MkdirAll(b.root, 0o755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return nil
+}
+
+// PutObject writes an object and its metadata to disk.
+func (b *LocalBackend) PutObject(ctx context.Context, key string, data []byte, opts ObjectOptions) error {
+	objectPath, metaPath, err := b.paths(key)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFile(objectPath, data); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+
+	metadata := make(map[string]string, len(opts.Metadata)+1)
+	for key, value := range opts.Metadata {
+		metadata[key] = value
+	}
+	if opts.ContentType != "" {
+		metadata[contentTypeMetadataKey] = opts.ContentType
+	}
+	meta, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode object metadata: %w", err)
+	}
+	if err := writeFile(metaPath, meta); err != nil {
+		return fmt.Errorf("failed to write object metadata: %w", err)
+	}
+
+	return nil
+}
+
+// GetObject reads an object and its metadata from disk.
+func (b *LocalBackend) GetObject(ctx context.Context, key string) ([]byte, map[string]string, error) {
+	objectPath, metaPath, err := b.paths(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(objectPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, ErrObjectNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to read object: %w", err)
+	}
+
+	metadata, err := readMetadata(metaPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	delete(metadata, contentTypeMetadataKey)
+
+	return data, metadata, nil
+}
+
+// StatObject reads the size of an object and its metadata from disk.
+func (b *LocalBackend) StatObject(ctx context.Context, key string) (ObjectInfo, error) {
+	objectPath, metaPath, err := b.paths(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	stat, err := os.Stat(objectPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ObjectInfo{}, ErrObjectNotFound
+		}
+		return ObjectInfo{}, fmt.Errorf("failed to stat object: %w", err)
+	}
+
+	metadata, err := readMetadata(metaPath)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	contentType := metadata[contentTypeMetadataKey]
+	delete(metadata, contentTypeMetadataKey)
+
+	return ObjectInfo{
+		Size:         stat.Size(),
+		ContentType:  contentType,
+		LastModified: stat.ModTime(),
+		Metadata:     metadata,
+	}, nil
+}
+
+// readMetadata reads the metadata file of an object, empty for objects
+// stored without one.
+func readMetadata(metaPath string) (map[string]string, error) {
+	metadata := map[string]string{}
+	meta, err := os.ReadFile(metaPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return metadata, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object metadata: %w", err)
+	}
+	if err := json.Unmarshal(meta, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode object metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// ListObjectKeys returns the keys of all objects under a prefix.
+func (b *LocalBackend) ListObjectKeys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(b.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == metadataDir && filepath.Dir(path) == b.root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(b.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Usage returns the number and total size of objects under a prefix.
+// Metadata files are not counted.
+func (b *LocalBackend) Usage(ctx context.Context, prefix string) (Usage, error) {
+	var usage Usage
+	err := filepath.WalkDir(b.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == metadataDir && filepath.Dir(path) == b.root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(b.root, path)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(filepath.ToSlash(rel), prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		usage.Objects++
+		usage.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to compute storage usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// DeleteObject removes an object and its metadata.
+func (b *LocalBackend) DeleteObject(ctx context.Context, key string) error {
+	objectPath, metaPath, err := b.paths(key)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range []string{objectPath, metaPath} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete object: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// paths returns the file paths of an object and its metadata, rejecting keys
+// that would escape the storage directory.
+func (b *LocalBackend) paths(key string) (string, string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) ||
+		clean == metadataDir || strings.HasPrefix(clean, metadataDir+string(filepath.Separator)) {
+		return "", "", fmt.Errorf("invalid object key: %s", key)
+	}
+
+	return filepath.Join(b.root, clean), filepath.Join(b.root, metadataDir, clean+".json"), nil
+}
+
+// writeFile writes data to path, creating parent directories as needed.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"mime"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// User metadata keys describing stored page content. S3 services return them
+// in canonical form, so they are read with metadataValue.
+const (
+	websiteIDMetadataKey         = "website-id"
+	pageURLMetadataKey           = "page-url"
+	titleMetadataKey             = "Hermit-Title"
+	languageMetadataKey          = "Hermit-Language"
+	contentHashMetadataKey       = "Hermit-Content-Hash"
+	extractedAtMetadataKey       = "Hermit-Extracted-At"
+	sourceContentTypeMetadataKey = "Hermit-Source-Content-Type"
+)
+
+// PageMetadata describes the content of a page stored with it.
+type PageMetadata struct {
+	Title    string
+	Language string
+	// ContentHash is the SHA-256 of the stored text, as recorded with the page
+	ContentHash string
+	ExtractedAt time.Time
+	// SourceContentType is the content type the page was served with
+	SourceContentType string
+}
+
+// ObjectInfo describes a stored object without its data.
+type ObjectInfo struct {
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+	Metadata     map[string]string
+}
+
+// PageInfo describes the stored content of a page, read without downloading
+// it. Content stored before the metadata was recorded only has the object
+// fields and the page URL.
+type PageInfo struct {
+	ObjectKey    string    `json:"object_key"`
+	ContentType  string    `json:"content_type"`
+	Encoding     string    `json:"encoding"`
+	StoredBytes  int64     `json:"stored_bytes"`
+	LastModified time.Time `json:"last_modified"`
+
+	PageURL           string     `json:"page_url,omitempty"`
+	Title             string     `json:"title,omitempty"`
+	Language          string     `json:"language,omitempty"`
+	ContentHash       string     `json:"content_hash,omitempty"`
+	ExtractedAt       *time.Time `json:"extracted_at,omitempty"`
+	SourceContentType string     `json:"source_content_type,omitempty"`
+}
+
+// pageMetadata returns the user metadata of the content of a page. The title
+// is Q-encoded since S3 metadata must be ASCII.
+func pageMetadata(websiteID int, pageURL, encoding string, meta PageMetadata) map[string]string {
+	metadata := map[string]string{
+		websiteIDMetadataKey: strconv.Itoa(websiteID),
+		pageURLMetadataKey:   pageURL,
+		encodingMetadataKey:  encoding,
+	}
+	if meta.Title != "" {
+		metadata[titleMetadataKey] = mime.QEncoding.Encode("utf-8", meta.Title)
+	}
+	if meta.Language != "" {
+		metadata[languageMetadataKey] = meta.Language
+	}
+	if meta.ContentHash != "" {
+		metadata[contentHashMetadataKey] = meta.ContentHash
+	}
+	if !meta.ExtractedAt.IsZero() {
+		metadata[extractedAtMetadataKey] = meta.ExtractedAt.UTC().Format(time.RFC3339)
+	}
+	if meta.SourceContentType != "" {
+		metadata[sourceContentTypeMetadataKey] = meta.SourceContentType
+	}
+	return metadata
+}
+
+// newPageInfo decodes the metadata of the content of a page.
+func newPageInfo(objectKey string, info ObjectInfo) *PageInfo {
+	page := &PageInfo{
+		ObjectKey:         objectKey,
+		ContentType:       info.ContentType,
+		Encoding:          metadataValue(info.Metadata, encodingMetadataKey),
+		StoredBytes:       info.Size,
+		LastModified:      info.LastModified,
+		PageURL:           metadataValue(info.Metadata, pageURLMetadataKey),
+		Language:          metadataValue(info.Metadata, languageMetadataKey),
+		ContentHash:       metadataValue(info.Metadata, contentHashMetadataKey),
+		SourceContentType: metadataValue(info.Metadata, sourceContentTypeMetadataKey),
+	}
+	if page.Encoding == "" {
+		page.Encoding = EncodingNone
+	}
+	if title := metadataValue(info.Metadata, titleMetadataKey); title != "" {
+		decoded, err := new(mime.WordDecoder).DecodeHeader(title)
+		if err != nil {
+			decoded = title
+		}
+		page.Title = decoded
+	}
+	if extractedAt, err := time.Parse(time.RFC3339, metadataValue(info.Metadata, extractedAtMetadataKey)); err == nil {
+		page.ExtractedAt = &extractedAt
+	}
+	return page
+}
+
+// metadataValue looks a metadata key up as written or in canonical form, as
+// backends differ in which they return.
+func metadataValue(metadata map[string]string, key string) string {
+	if value, ok := metadata[key]; ok {
+		return value
+	}
+	if value, ok := metadata[textproto.CanonicalMIMEHeaderKey(key)]; ok {
+		return value
+	}
+	for k, value := range metadata {
+		if textproto.CanonicalMIMEHeaderKey(k) == key {
+			return value
+		}
+	}
+	return ""
+}
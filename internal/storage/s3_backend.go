@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"hermit/internal/config"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.uber.org/zap"
+)
+
+// S3Backend stores objects in an S3-compatible service such as Garage or MinIO.
+type S3Backend struct {
+	client     *minio.Client
+	bucketName string
+	logger     *zap.Logger
+}
+
+// NewS3Backend creates a new S3Backend.
+func NewS3Backend(cfg *config.Config, logger *zap.Logger) (*S3Backend, error) {
+	client, err := minio.New(cfg.GarageEndpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.GarageAccessKey, cfg.GarageSecretKey, ""),
+		Secure: false, // Set to true if using TLS
+		Region: cfg.GarageRegion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	logger.Info("Using S3 storage", zap.String("endpoint", cfg.GarageEndpoint), zap.String("bucket", cfg.GarageBucketName))
+
+	return &S3Backend{
+		client:     client,
+		bucketName: cfg.GarageBucketName,
+		logger:     logger,
+	}, nil
+}
+
+// EnsureBucket creates the bucket if it doesn't exist.
+func (b *S3Backend) EnsureBucket(ctx context.Context) error {
+	exists, err := b.client.BucketExists(ctx, b.bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to check if bucket exists: %w", err)
+	}
+
+	if !exists {
+		err = b.client.MakeBucket(ctx, b.bucketName, minio.MakeBucketOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %w", err)
+		}
+		b.logger.Info("Created S3 bucket", zap.String("bucket", b.bucketName))
+	}
+
+	return nil
+}
+
+// PutObject uploads an object.
+func (b *S3Backend) PutObject(ctx context.Context, key string, data []byte, opts ObjectOptions) error {
+	_, err := b.client.PutObject(
+		ctx,
+		b.bucketName,
+		key,
+		bytes.NewReader(data),
+		int64(len(data)),
+		minio.PutObjectOptions{
+			ContentType:  opts.ContentType,
+			UserMetadata: opts.Metadata,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upload object to S3: %w", err)
+	}
+
+	return nil
+}
+
+// GetObject downloads an object and its user metadata.
+func (b *S3Backend) GetObject(ctx context.Context, key string) ([]byte, map[string]string, error) {
+	object, err := b.client.GetObject(ctx, b.bucketName, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get object from S3: %w", err)
+	}
+	defer object.Close()
+
+	info, err := object.Stat()
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, nil, ErrObjectNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get object info from S3: %w", err)
+	}
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read object content: %w", err)
+	}
+
+	return data, info.UserMetadata, nil
+}
+
+// StatObject returns the info and user metadata of an object.
+func (b *S3Backend) StatObject(ctx context.Context, key string) (ObjectInfo, error) {
+	info, err := b.client.StatObject(ctx, b.bucketName, key, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return ObjectInfo{}, ErrObjectNotFound
+		}
+		return ObjectInfo{}, fmt.Errorf("failed to get object info from S3: %w", err)
+	}
+
+	return ObjectInfo{
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified,
+		Metadata:     info.UserMetadata,
+	}, nil
+}
+
+// ListObjectKeys returns the keys of all objects under a prefix.
+func (b *S3Backend) ListObjectKeys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for object := range b.client.ListObjects(ctx, b.bucketName, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", object.Err)
+		}
+		keys = append(keys, object.Key)
+	}
+
+	return keys, nil
+}
+
+// Usage returns the number and total size of objects under a prefix.
+func (b *S3Backend) Usage(ctx context.Context, prefix string) (Usage, error) {
+	var usage Usage
+	for object := range b.client.ListObjects(ctx, b.bucketName, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			return Usage{}, fmt.Errorf("failed to list objects: %w", object.Err)
+		}
+		usage.Objects++
+		usage.Bytes += object.Size
+	}
+
+	return usage, nil
+}
+
+// DeleteObject removes an object.
+func (b *S3Backend) DeleteObject(ctx context.Context, key string) error {
+	err := b.client.RemoveObject(ctx, b.bucketName, key, minio.RemoveObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete object from S3: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+// Package tenant carries the identity of the user a request or background job
+// acts on behalf of, so logs and metrics can be attributed to it.
+package tenant
+
+import (
+	"context"
+
+	"hermit/internal/requestid"
+	"hermit/internal/schema"
+
+	"go.uber.org/zap"
+)
+
+type contextKey struct{}
+
+// LabelSystem labels work that is not attributed to a user, such as
+// scheduled maintenance.
+const LabelSystem = "system"
+
+// Tenant identifies the user a request or job belongs to.
+type Tenant struct {
+	UserID   string `json:"user_id,omitempty"`
+	UserRole string `json:"user_role,omitempty"`
+	APIKeyID string `json:"-"`
+}
+
+// FromUser builds a Tenant for an authenticated user and API key.
+func FromUser(user *schema.User, key *schema.APIKey) Tenant {
+	if user == nil {
+		return Tenant{}
+	}
+
+	t := Tenant{
+		UserID:   user.ID.String(),
+		UserRole: user.Role,
+	}
+	if key != nil {
+		t.APIKeyID = key.ID.String()
+	}
+	return t
+}
+
+// WithTenant returns a copy of ctx carrying t.
+func WithTenant(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the tenant stored in ctx, if any.
+func FromContext(ctx context.Context) (Tenant, bool) {
+	t, ok := ctx.Value(contextKey{}).(Tenant)
+	return t, ok && t.UserID != ""
+}
+
+// Fields returns log fields identifying the tenant in ctx.
+func Fields(ctx context.Context) []zap.Field {
+	t, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	fields := []zap.Field{
+		zap.String("userID", t.UserID),
+		zap.String("userRole", t.UserRole),
+	}
+	if t.APIKeyID != "" {
+		fields = append(fields, zap.String("apiKeyID", t.APIKeyID))
+	}
+	return fields
+}
+
+// Logger returns logger annotated with the tenant and the request ID in ctx.
+func Logger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	fields := append(Fields(ctx), requestid.Fields(ctx)...)
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.With(fields...)
+}
+
+// Label returns a low-cardinality metrics label for the tenant in ctx. User
+// IDs are never used as labels; work is grouped by role instead.
+func Label(ctx context.Context) string {
+	t, ok := FromContext(ctx)
+	if !ok || t.UserRole == "" {
+		return LabelSystem
+	}
+	return t.UserRole
+}
@@ -2,21 +2,88 @@ package vectorizer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/resilience"
 
 	chroma "github.com/amikos-tech/chroma-go"
+	chhttp "github.com/amikos-tech/chroma-go/pkg/commons/http"
 	"github.com/amikos-tech/chroma-go/types"
 	"go.uber.org/zap"
 )
 
+// WebsiteOwners resolves the user owning a website. Collections are namespaced
+// by owner so that a wrong website ID can't reach another user's vectors.
+type WebsiteOwners interface {
+	// GetOwnerID returns the owner's user ID, or an empty string for
+	// websites without an owner.
+	GetOwnerID(ctx context.Context, websiteID uint) (string, error)
+}
+
+// errNoEmbeddingFunction is returned when ChromaDB's client is asked to embed
+// texts, which the embedder does before they reach it.
+var errNoEmbeddingFunction = errors.New("texts must be embedded before they are sent to ChromaDB")
+
+// precomputedEmbeddings is the embedding function of collections. Chunks and
+// queries are always embedded by Ollama, and without it the client would load
+// its default ONNX model, downloading it on first use.
+type precomputedEmbeddings struct{}
+
+// EmbedDocuments accepts no texts, the client calls it even when a query
+// carries its embedding.
+func (precomputedEmbeddings) EmbedDocuments(ctx context.Context, texts []string) ([]*types.Embedding, error) {
+	if len(texts) > 0 {
+		return nil, errNoEmbeddingFunction
+	}
+	return nil, nil
+}
+
+func (precomputedEmbeddings) EmbedQuery(ctx context.Context, text string) (*types.Embedding, error) {
+	return nil, errNoEmbeddingFunction
+}
+
+func (precomputedEmbeddings) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
+	if len(records) > 0 {
+		return errNoEmbeddingFunction
+	}
+	return nil
+}
+
+// noOwner is the namespace of websites without an owner.
+const noOwner = "none"
+
+// legacyCollectionPrefix is the prefix of collections created before they
+// were namespaced by owner.
+const legacyCollectionPrefix = "website_"
+
+// ErrEmbeddingModelMismatch is returned when a website's vectors were built
+// with a different embedding model than the one configured. The website has
+// to be reindexed before it can be queried again.
+var ErrEmbeddingModelMismatch = apperrors.Conflict("embedding model mismatch")
+
+// embeddingSpace identifies the embedding model that built a collection's
+// vectors. Vectors of different spaces can't be compared.
+type embeddingSpace struct {
+	model      string
+	dimensions int
+}
+
 // ChromaRepository handles storing and querying vector embeddings in ChromaDB.
 type ChromaRepository struct {
 	client *chroma.Client
+	owners WebsiteOwners
+	guard  *resilience.Guard
 	logger *zap.Logger
 }
 
-// NewChromaRepository creates a new ChromaRepository.
-func NewChromaRepository(chromaURL string, logger *zap.Logger) (*ChromaRepository, error) {
+// NewChromaRepository creates a new ChromaRepository. Calls to ChromaDB are
+// guarded by the "chromadb" breaker registered in breakers.
+func NewChromaRepository(chromaURL string, owners WebsiteOwners, breakers *resilience.Registry, opts resilience.Options, logger *zap.Logger) (*ChromaRepository, error) {
 	client, err := chroma.NewClient(chroma.WithBasePath(chromaURL))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create ChromaDB client: %w", err)
@@ -26,54 +93,314 @@ func NewChromaRepository(chromaURL string, logger *zap.Logger) (*ChromaRepositor
 
 	return &ChromaRepository{
 		client: client,
+		owners: owners,
+		guard:  breakers.Guard("chromadb", opts, isTransientChromaError),
 		logger: logger,
 	}, nil
 }
 
-// getCollectionName generates a collection name for a website.
-func (r *ChromaRepository) getCollectionName(websiteID uint) string {
-	return fmt.Sprintf("website_%d", websiteID)
+// isTransientChromaError reports whether a ChromaDB error means the server is
+// down or overloaded. Missing collections and invalid requests are not.
+func isTransientChromaError(err error) bool {
+	var chromaErr *chhttp.ChromaError
+	if errors.As(err, &chromaErr) {
+		// No status code means the request never got a response
+		return chromaErr.ErrorCode == 0 ||
+			chromaErr.ErrorCode >= http.StatusInternalServerError ||
+			chromaErr.ErrorCode == http.StatusTooManyRequests
+	}
+	return resilience.IsNetworkError(err)
+}
+
+// chromaUnreachable reports whether err means ChromaDB couldn't answer, as
+// opposed to the collection not existing.
+func chromaUnreachable(err error) bool {
+	var unavailable *resilience.UnavailableError
+	return errors.As(err, &unavailable) || isTransientChromaError(err)
+}
+
+// guarded runs fn with retries while ChromaDB's breaker is closed.
+func guarded[T any](ctx context.Context, guard *resilience.Guard, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := guard.Do(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
+
+// getNamedCollection fetches a collection by name.
+func (r *ChromaRepository) getNamedCollection(ctx context.Context, name string) (*chroma.Collection, error) {
+	return guarded(ctx, r.guard, func(ctx context.Context) (*chroma.Collection, error) {
+		return r.client.GetCollection(ctx, name, precomputedEmbeddings{})
+	})
+}
+
+// listCollections lists all collections.
+func (r *ChromaRepository) listCollections(ctx context.Context) ([]*chroma.Collection, error) {
+	return guarded(ctx, r.guard, r.client.ListCollections)
+}
+
+// deleteCollection deletes a collection by name.
+func (r *ChromaRepository) deleteCollection(ctx context.Context, name string) error {
+	_, err := guarded(ctx, r.guard, func(ctx context.Context) (*chroma.Collection, error) {
+		return r.client.DeleteCollection(ctx, name)
+	})
+	return err
+}
+
+// collectionName generates the collection name for a website, e.g.
+// "user_01hx..._website_42" or "user_none_website_42" for orphaned websites.
+func collectionName(owner string, websiteID uint) string {
+	return fmt.Sprintf("user_%s_website_%d", owner, websiteID)
+}
+
+// parseCollectionName extracts the owner and website ID from a collection
+// name. Legacy collections are reported with an empty owner.
+func parseCollectionName(name string) (owner string, websiteID uint, ok bool) {
+	if rest, found := strings.CutPrefix(name, "user_"); found {
+		i := strings.LastIndex(rest, "_"+legacyCollectionPrefix)
+		if i <= 0 {
+			return "", 0, false
+		}
+		owner, name = rest[:i], rest[i+1:]
+	}
+
+	idStr, found := strings.CutPrefix(name, legacyCollectionPrefix)
+	if !found {
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return owner, uint(id), true
+}
+
+// ownerOf returns the namespace of a website's vectors.
+func (r *ChromaRepository) ownerOf(ctx context.Context, websiteID uint) (string, error) {
+	owner, err := r.owners.GetOwnerID(ctx, websiteID)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve website owner: %w", err)
+	}
+	if owner == "" {
+		return noOwner, nil
+	}
+	return strings.ToLower(owner), nil
+}
+
+// getCollection returns a website's collection in its owner's namespace,
+// migrating a legacy or previously orphaned collection into it. When create
+// is set a missing collection is created for that embedding space.
+func (r *ChromaRepository) getCollection(ctx context.Context, websiteID uint, create *embeddingSpace) (*chroma.Collection, string, error) {
+	owner, err := r.ownerOf(ctx, websiteID)
+	if err != nil {
+		return nil, "", err
+	}
+	name := collectionName(owner, websiteID)
+
+	collection, err := r.getNamedCollection(ctx, name)
+	if err == nil {
+		if stamped, ok := collection.Metadata["user_id"].(string); ok && stamped != owner {
+			return nil, "", fmt.Errorf("collection %s belongs to user %s", name, stamped)
+		}
+		return collection, owner, nil
+	}
+	if chromaUnreachable(err) {
+		return nil, "", fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	sources := []string{legacyCollectionPrefix + strconv.FormatUint(uint64(websiteID), 10)}
+	if owner != noOwner {
+		sources = append(sources, collectionName(noOwner, websiteID))
+	}
+	for _, source := range sources {
+		old, err := r.getNamedCollection(ctx, source)
+		if err != nil {
+			if chromaUnreachable(err) {
+				return nil, "", fmt.Errorf("failed to get collection: %w", err)
+			}
+			continue
+		}
+		collection, err := r.migrateCollection(ctx, old, owner, websiteID)
+		if err != nil {
+			return nil, "", err
+		}
+		return collection, owner, nil
+	}
+
+	if create == nil {
+		return nil, "", fmt.Errorf("failed to get collection: collection %s does not exist", name)
+	}
+
+	r.logger.Info("Creating new ChromaDB collection", zap.String("collection", name))
+
+	collection, err = r.createCollection(ctx, owner, websiteID, map[string]interface{}{
+		"embedding_model":      create.model,
+		"embedding_dimensions": create.dimensions,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	r.logger.Info("Created ChromaDB collection", zap.String("collection", name))
+
+	return collection, owner, nil
+}
+
+// createCollection creates a website's collection in an owner's namespace
+// with the given extra metadata.
+func (r *ChromaRepository) createCollection(ctx context.Context, owner string, websiteID uint, extra map[string]interface{}) (*chroma.Collection, error) {
+	metadata := map[string]interface{}{
+		"hnsw:space": "cosine",
+		"user_id":    owner,
+		"website_id": websiteID,
+	}
+	for key, value := range extra {
+		metadata[key] = value
+	}
+
+	collection, err := guarded(ctx, r.guard, func(ctx context.Context) (*chroma.Collection, error) {
+		return r.client.CreateCollection(ctx, collectionName(owner, websiteID), metadata, true, precomputedEmbeddings{}, types.L2)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collection: %w", err)
+	}
+	return collection, nil
 }
 
-// EnsureCollection creates or retrieves a collection for a website.
-func (r *ChromaRepository) EnsureCollection(ctx context.Context, websiteID uint) (*chroma.Collection, error) {
-	collectionName := r.getCollectionName(websiteID)
+// migrateCollection copies the chunks of a collection into the owner's
+// namespace, stamping them with the owner, and deletes the old collection.
+// Chunks are copied rather than the collection renamed because ChromaDB
+// doesn't allow keeping the distance function when updating metadata.
+func (r *ChromaRepository) migrateCollection(ctx context.Context, old *chroma.Collection, owner string, websiteID uint) (*chroma.Collection, error) {
+	// Keep the embedding space the vectors were built with
+	extra := make(map[string]interface{})
+	for _, key := range []string{"embedding_model", "embedding_dimensions"} {
+		if value, ok := old.Metadata[key]; ok {
+			extra[key] = value
+		}
+	}
 
-	collection, err := r.client.GetCollection(ctx, collectionName, nil)
+	collection, err := r.createCollection(ctx, owner, websiteID, extra)
 	if err != nil {
-		// Collection doesn't exist, create it
-		r.logger.Info("Creating new ChromaDB collection", zap.String("collection", collectionName))
+		return nil, err
+	}
 
-		collection, err = r.client.CreateCollection(ctx, collectionName, map[string]interface{}{
-			"hnsw:space": "cosine",
-		}, true, nil, types.L2)
+	copied := 0
+	for offset := int32(0); ; offset += chunkPageSize {
+		results, err := guarded(ctx, r.guard, func(ctx context.Context) (*chroma.GetResults, error) {
+			return old.GetWithOptions(ctx,
+				types.WithInclude(types.IDocuments, types.IMetadatas, types.IEmbeddings),
+				types.WithLimit(chunkPageSize),
+				types.WithOffset(offset),
+			)
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to create collection: %w", err)
+			return nil, fmt.Errorf("failed to read chunks of %s: %w", old.Name, err)
+		}
+
+		if len(results.Ids) > 0 {
+			for _, metadata := range results.Metadatas {
+				metadata["user_id"] = owner
+			}
+			_, err := guarded(ctx, r.guard, func(ctx context.Context) (*chroma.Collection, error) {
+				return collection.Upsert(ctx, results.Embeddings, results.Metadatas, results.Documents, results.Ids)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to copy chunks of %s: %w", old.Name, err)
+			}
+			copied += len(results.Ids)
+		}
+
+		if len(results.Ids) < chunkPageSize {
+			break
 		}
+	}
 
-		r.logger.Info("Created ChromaDB collection", zap.String("collection", collectionName))
+	if err := r.deleteCollection(ctx, old.Name); err != nil {
+		return nil, fmt.Errorf("failed to delete collection %s: %w", old.Name, err)
 	}
 
+	r.logger.Info("Migrated ChromaDB collection",
+		zap.String("from", old.Name),
+		zap.String("to", collection.Name),
+		zap.Int("chunks", copied),
+	)
+
 	return collection, nil
 }
 
+// checkSpace returns ErrEmbeddingModelMismatch when a collection was built in
+// a different embedding space. Collections created before the space was
+// recorded are accepted.
+func checkSpace(collection *chroma.Collection, space embeddingSpace) error {
+	if model, ok := collection.Metadata["embedding_model"].(string); ok && model != space.model {
+		return fmt.Errorf("%w: collection %s was built with %s, configured model is %s",
+			ErrEmbeddingModelMismatch, collection.Name, model, space.model)
+	}
+	if dims, ok := metadataUint(collection.Metadata["embedding_dimensions"]); ok && int(dims) != space.dimensions {
+		return fmt.Errorf("%w: collection %s has %d dimensions, embeddings have %d",
+			ErrEmbeddingModelMismatch, collection.Name, dims, space.dimensions)
+	}
+	return nil
+}
+
+// MigrateCollections moves every legacy collection into its owner's namespace
+// and returns the number of collections migrated. Collections of deleted
+// websites are left for garbage collection.
+func (r *ChromaRepository) MigrateCollections(ctx context.Context) (int, error) {
+	collections, err := r.listCollections(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	migrated := 0
+	for _, collection := range collections {
+		owner, websiteID, ok := parseCollectionName(collection.Name)
+		if !ok || owner != "" {
+			continue
+		}
+		if _, _, err := r.getCollection(ctx, websiteID, nil); err != nil {
+			r.logger.Warn("Failed to migrate ChromaDB collection",
+				zap.String("collection", collection.Name),
+				zap.Error(err),
+			)
+			continue
+		}
+		migrated++
+	}
+
+	return migrated, nil
+}
+
 // StoreChunks saves text chunks with their embeddings to ChromaDB.
 func (r *ChromaRepository) StoreChunks(
 	ctx context.Context,
 	websiteID uint,
 	pageID uint,
 	pageURL string,
+	model string,
 	chunks []string,
 	embeddings [][]float32,
+	tags []string,
 ) error {
 	if len(chunks) != len(embeddings) {
 		return fmt.Errorf("chunks and embeddings length mismatch: %d vs %d", len(chunks), len(embeddings))
 	}
+	if len(embeddings) == 0 {
+		return nil
+	}
 
-	collection, err := r.EnsureCollection(ctx, websiteID)
+	space := embeddingSpace{model: model, dimensions: len(embeddings[0])}
+	collection, owner, err := r.getCollection(ctx, websiteID, &space)
 	if err != nil {
 		return err
 	}
+	if err := checkSpace(collection, space); err != nil {
+		return err
+	}
 
 	// Prepare data for ChromaDB
 	ids := make([]string, len(chunks))
@@ -95,22 +422,34 @@ func (r *ChromaRepository) StoreChunks(
 
 		// Create metadata
 		metadatas[i] = map[string]interface{}{
+			"user_id":     owner,
 			"website_id":  websiteID,
 			"page_id":     pageID,
 			"page_url":    pageURL,
 			"chunk_index": i,
 			"chunk_size":  len(chunk),
 		}
+		// Chroma metadata holds no lists, so each tag is a flag of its own
+		// and the list is kept for display
+		if len(tags) > 0 {
+			metadatas[i]["tags"] = strings.Join(tags, ",")
+			for _, tag := range tags {
+				metadatas[i][tagKey(tag)] = true
+			}
+		}
 	}
 
-	// Add documents to collection: Add(ctx, embeddings, metadatas, documents, ids)
-	_, err = collection.Add(ctx, embeddingTypes, metadatas, documents, ids)
+	// Upsert rather than add so a retried request that already went through
+	// doesn't fail on duplicate IDs
+	_, err = guarded(ctx, r.guard, func(ctx context.Context) (*chroma.Collection, error) {
+		return collection.Upsert(ctx, embeddingTypes, metadatas, documents, ids)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to add documents to ChromaDB: %w", err)
 	}
 
 	r.logger.Info("Stored chunks in ChromaDB",
-		zap.String("collection", r.getCollectionName(websiteID)),
+		zap.String("collection", collection.Name),
 		zap.Uint("websiteID", websiteID),
 		zap.Uint("pageID", pageID),
 		zap.Int("numChunks", len(chunks)),
@@ -119,6 +458,11 @@ func (r *ChromaRepository) StoreChunks(
 	return nil
 }
 
+// tagKey is the chunk metadata key flagging a tag.
+func tagKey(tag string) string {
+	return "tag:" + tag
+}
+
 // QueryResult represents a result from a similarity search.
 type QueryResult struct {
 	ID       string
@@ -127,28 +471,54 @@ type QueryResult struct {
 	Distance float32
 }
 
-// Query performs a similarity search using a query embedding.
+// Query performs a similarity search using a query embedding generated by
+// model, limited to chunks of pages with any of tags when set. It returns
+// ErrEmbeddingModelMismatch when the website's vectors were built with
+// another model.
 func (r *ChromaRepository) Query(
 	ctx context.Context,
 	websiteID uint,
+	model string,
 	queryEmbedding []float32,
 	topK int,
+	tags []string,
 ) ([]QueryResult, error) {
-	collection, err := r.client.GetCollection(ctx, r.getCollectionName(websiteID), nil)
+	collection, owner, err := r.getCollection(ctx, websiteID, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get collection: %w", err)
+		return nil, err
+	}
+	if err := checkSpace(collection, embeddingSpace{model: model, dimensions: len(queryEmbedding)}); err != nil {
+		return nil, err
 	}
 
 	// Create Embedding type for query
 	queryEmbeddingType := types.NewEmbeddingFromFloat32(queryEmbedding)
 
+	where := map[string]interface{}{"user_id": owner}
+	if len(tags) > 0 {
+		tagFilters := make([]map[string]interface{}, len(tags))
+		for i, tag := range tags {
+			tagFilters[i] = map[string]interface{}{tagKey(tag): true}
+		}
+		tagFilter := tagFilters[0]
+		if len(tagFilters) > 1 {
+			tagFilter = map[string]interface{}{"$or": tagFilters}
+		}
+		where = map[string]interface{}{
+			"$and": []map[string]interface{}{where, tagFilter},
+		}
+	}
+
 	// Query using QueryWithOptions for embedding-based search
-	queryResults, err := collection.QueryWithOptions(
-		ctx,
-		types.WithQueryEmbedding(queryEmbeddingType),
-		types.WithNResults(int32(topK)),
-		types.WithInclude(types.IDocuments, types.IMetadatas, types.IDistances),
-	)
+	queryResults, err := guarded(ctx, r.guard, func(ctx context.Context) (*chroma.QueryResults, error) {
+		return collection.QueryWithOptions(
+			ctx,
+			types.WithQueryEmbedding(queryEmbeddingType),
+			types.WithNResults(int32(topK)),
+			types.WithWhereMap(where),
+			types.WithInclude(types.IDocuments, types.IMetadatas, types.IDistances),
+		)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to query ChromaDB: %w", err)
 	}
@@ -181,7 +551,7 @@ func (r *ChromaRepository) Query(
 	}
 
 	r.logger.Info("Query completed",
-		zap.String("collection", r.getCollectionName(websiteID)),
+		zap.String("collection", collection.Name),
 		zap.Int("resultsCount", len(results)),
 	)
 
@@ -190,54 +560,208 @@ func (r *ChromaRepository) Query(
 
 // DeletePageChunks removes all chunks for a specific page.
 func (r *ChromaRepository) DeletePageChunks(ctx context.Context, websiteID uint, pageID uint) error {
-	collection, err := r.client.GetCollection(ctx, r.getCollectionName(websiteID), nil)
+	collection, owner, err := r.getCollection(ctx, websiteID, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get collection: %w", err)
+		return err
 	}
 
 	// Query for all chunks belonging to this page
 	where := map[string]interface{}{
-		"page_id": pageID,
+		"$and": []map[string]interface{}{
+			{"page_id": pageID},
+			{"user_id": owner},
+		},
 	}
 
-	_, err = collection.Delete(ctx, nil, where, nil)
+	_, err = guarded(ctx, r.guard, func(ctx context.Context) ([]string, error) {
+		return collection.Delete(ctx, nil, where, nil)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete page chunks: %w", err)
 	}
 
 	r.logger.Info("Deleted page chunks",
-		zap.String("collection", r.getCollectionName(websiteID)),
+		zap.String("collection", collection.Name),
 		zap.Uint("pageID", pageID),
 	)
 
 	return nil
 }
 
-// DeleteCollection removes an entire collection for a website.
+// DeleteCollection removes every collection of a website, whichever namespace
+// it is in. It doesn't need the website to exist so deleted websites can be
+// cleaned up.
 func (r *ChromaRepository) DeleteCollection(ctx context.Context, websiteID uint) error {
-	collectionName := r.getCollectionName(websiteID)
-
-	_, err := r.client.DeleteCollection(ctx, collectionName)
+	collections, err := r.listCollections(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to delete collection: %w", err)
+		return fmt.Errorf("failed to list collections: %w", err)
 	}
 
-	r.logger.Info("Deleted collection", zap.String("collection", collectionName))
+	for _, collection := range collections {
+		if _, id, ok := parseCollectionName(collection.Name); !ok || id != websiteID {
+			continue
+		}
+
+		if err := r.deleteCollection(ctx, collection.Name); err != nil {
+			return fmt.Errorf("failed to delete collection: %w", err)
+		}
+
+		r.logger.Info("Deleted collection", zap.String("collection", collection.Name))
+	}
 
 	return nil
 }
 
 // GetCollectionCount returns the number of documents in a collection.
 func (r *ChromaRepository) GetCollectionCount(ctx context.Context, websiteID uint) (int, error) {
-	collection, err := r.client.GetCollection(ctx, r.getCollectionName(websiteID), nil)
+	collection, _, err := r.getCollection(ctx, websiteID, nil)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get collection: %w", err)
+		return 0, err
 	}
 
-	count, err := collection.Count(ctx)
+	count, err := guarded(ctx, r.guard, collection.Count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get count: %w", err)
 	}
 
 	return int(count), nil
 }
+
+// CountAllVectors returns the number of documents stored in all website
+// collections.
+func (r *ChromaRepository) CountAllVectors(ctx context.Context) (int, error) {
+	collections, err := r.listCollections(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	total := 0
+	for _, collection := range collections {
+		if _, _, ok := parseCollectionName(collection.Name); !ok {
+			continue
+		}
+		count, err := guarded(ctx, r.guard, collection.Count)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get count of %s: %w", collection.Name, err)
+		}
+		total += int(count)
+	}
+
+	return total, nil
+}
+
+// chunkPageSize is the number of chunks fetched per request when scanning a collection.
+const chunkPageSize = 1000
+
+// ListWebsiteCollections returns the website IDs that have a collection in ChromaDB.
+func (r *ChromaRepository) ListWebsiteCollections(ctx context.Context) ([]uint, error) {
+	collections, err := r.listCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	var websiteIDs []uint
+	seen := make(map[uint]bool)
+	for _, collection := range collections {
+		_, id, ok := parseCollectionName(collection.Name)
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		websiteIDs = append(websiteIDs, id)
+	}
+
+	return websiteIDs, nil
+}
+
+// CountChunksByPage returns the number of chunks stored for each page ID in a
+// website's collection.
+func (r *ChromaRepository) CountChunksByPage(ctx context.Context, websiteID uint) (map[uint]int, error) {
+	collection, owner, err := r.getCollection(ctx, websiteID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int)
+	for offset := int32(0); ; offset += chunkPageSize {
+		results, err := guarded(ctx, r.guard, func(ctx context.Context) (*chroma.GetResults, error) {
+			return collection.GetWithOptions(ctx,
+				types.WithInclude(types.IMetadatas),
+				types.WithWhereMap(map[string]interface{}{"user_id": owner}),
+				types.WithLimit(chunkPageSize),
+				types.WithOffset(offset),
+			)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chunks: %w", err)
+		}
+
+		for _, metadata := range results.Metadatas {
+			if pageID, ok := metadataUint(metadata["page_id"]); ok {
+				counts[pageID]++
+			}
+		}
+
+		if len(results.Ids) < chunkPageSize {
+			break
+		}
+	}
+
+	return counts, nil
+}
+
+// ListFirstChunks returns the first chunk of up to limit pages of a website,
+// which usually introduces what the page is about.
+func (r *ChromaRepository) ListFirstChunks(ctx context.Context, websiteID uint, limit int) ([]QueryResult, error) {
+	collection, owner, err := r.getCollection(ctx, websiteID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := guarded(ctx, r.guard, func(ctx context.Context) (*chroma.GetResults, error) {
+		return collection.GetWithOptions(ctx,
+			types.WithInclude(types.IDocuments, types.IMetadatas),
+			types.WithWhereMap(map[string]interface{}{
+				"$and": []map[string]interface{}{
+					{"user_id": owner},
+					{"chunk_index": 0},
+				},
+			}),
+			types.WithLimit(int32(limit)),
+		)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chunks: %w", err)
+	}
+
+	chunks := make([]QueryResult, len(results.Ids))
+	for i, id := range results.Ids {
+		chunks[i].ID = id
+		if i < len(results.Documents) {
+			chunks[i].Document = results.Documents[i]
+		}
+		if i < len(results.Metadatas) {
+			chunks[i].Metadata = results.Metadatas[i]
+		}
+	}
+
+	return chunks, nil
+}
+
+// metadataUint converts a numeric metadata value returned by ChromaDB to uint.
+func metadataUint(value interface{}) (uint, bool) {
+	switch v := value.(type) {
+	case float64:
+		return uint(v), true
+	case float32:
+		return uint(v), true
+	case int32:
+		return uint(v), true
+	case int64:
+		return uint(v), true
+	case int:
+		return uint(v), true
+	default:
+		return 0, false
+	}
+}
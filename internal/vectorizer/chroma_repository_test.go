@@ -0,0 +1,250 @@
+package vectorizer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hermit/internal/resilience"
+
+	"go.uber.org/zap"
+)
+
+func TestParseCollectionName(t *testing.T) {
+	tests := []struct {
+		name      string
+		owner     string
+		websiteID uint
+		ok        bool
+	}{
+		{"website_42", "", 42, true},
+		{"user_01hx5n3k_website_42", "01hx5n3k", 42, true},
+		{"user_none_website_7", "none", 7, true},
+		// Owners may contain underscores, the last website segment wins
+		{"user_a_website_b_website_3", "a_website_b", 3, true},
+		{"user_website_42", "", 0, false},
+		{"user__website_42", "", 0, false},
+		{"website_", "", 0, false},
+		{"website_abc", "", 0, false},
+		{"website_-1", "", 0, false},
+		{"website_99999999999", "", 0, false},
+		{"user_01hx5n3k_website_x", "", 0, false},
+		{"pages_42", "", 0, false},
+		{"", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, websiteID, ok := parseCollectionName(tt.name)
+			if ok != tt.ok || owner != tt.owner || websiteID != tt.websiteID {
+				t.Errorf("parseCollectionName(%q) = %q, %d, %v, want %q, %d, %v",
+					tt.name, owner, websiteID, ok, tt.owner, tt.websiteID, tt.ok)
+			}
+			if ok && owner != "" && collectionName(owner, websiteID) != tt.name {
+				t.Errorf("collectionName(%q, %d) = %q, want %q", owner, websiteID, collectionName(owner, websiteID), tt.name)
+			}
+		})
+	}
+}
+
+// fakeOwners resolves website owners from a map.
+type fakeOwners map[uint]string
+
+func (o fakeOwners) GetOwnerID(ctx context.Context, websiteID uint) (string, error) {
+	return o[websiteID], nil
+}
+
+// fakeChunk is a chunk stored in a fakeChroma collection.
+type fakeChunk struct {
+	id       string
+	document string
+	metadata map[string]interface{}
+}
+
+// fakeCollection is a collection of a fakeChroma server.
+type fakeCollection struct {
+	id       string
+	metadata map[string]interface{}
+	chunks   []fakeChunk
+}
+
+// fakeChroma serves the parts of ChromaDB's API used to look up and query
+// collections, applying the where filter of queries.
+type fakeChroma struct {
+	collections map[string]*fakeCollection
+}
+
+func (f *fakeChroma) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case path == "/version":
+		// Older than the multi-tenant API, so no tenant checks are made
+		w.Write([]byte(`"0.4.14"`))
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/collections/"):
+		name := strings.TrimPrefix(path, "/collections/")
+		collection, ok := f.collections[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "collection " + name + " does not exist"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":       collection.id,
+			"name":     name,
+			"metadata": collection.metadata,
+		})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/query"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/collections/"), "/query")
+		var body struct {
+			Where map[string]interface{} `json:"where"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ids, documents, metadatas, distances := []string{}, []string{}, []map[string]interface{}{}, []float32{}
+		for _, collection := range f.collections {
+			if collection.id != id {
+				continue
+			}
+			for _, chunk := range collection.chunks {
+				if !matchesWhere(chunk.metadata, body.Where) {
+					continue
+				}
+				ids = append(ids, chunk.id)
+				documents = append(documents, chunk.document)
+				metadatas = append(metadatas, chunk.metadata)
+				distances = append(distances, 0.1)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ids":       [][]string{ids},
+			"documents": [][]string{documents},
+			"metadatas": [][]map[string]interface{}{metadatas},
+			"distances": [][]float32{distances},
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// matchesWhere reports whether metadata matches a where filter of equality
+// conditions combined with $and and $or.
+func matchesWhere(metadata, where map[string]interface{}) bool {
+	for key, value := range where {
+		switch key {
+		case "$and", "$or":
+			conditions, _ := value.([]interface{})
+			matchedAny := false
+			for _, condition := range conditions {
+				matched := matchesWhere(metadata, condition.(map[string]interface{}))
+				if key == "$and" && !matched {
+					return false
+				}
+				matchedAny = matchedAny || matched
+			}
+			if key == "$or" && !matchedAny {
+				return false
+			}
+		default:
+			if metadata[key] != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func newTestChromaRepository(t *testing.T, chroma *fakeChroma, owners fakeOwners) *ChromaRepository {
+	t.Helper()
+	server := httptest.NewServer(chroma)
+	t.Cleanup(server.Close)
+
+	opts := resilience.Options{BreakerThreshold: 100, BreakerCooldown: time.Second}
+	repo, err := NewChromaRepository(server.URL, owners, resilience.NewRegistry(zap.NewNop()), opts, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewChromaRepository() error = %v", err)
+	}
+	return repo
+}
+
+func TestQueryIsolatesOwners(t *testing.T) {
+	space := map[string]interface{}{"embedding_model": "test", "embedding_dimensions": 2}
+	withOwner := func(owner string) map[string]interface{} {
+		metadata := map[string]interface{}{"user_id": owner}
+		for key, value := range space {
+			metadata[key] = value
+		}
+		return metadata
+	}
+	chroma := &fakeChroma{collections: map[string]*fakeCollection{
+		"user_alice_website_1": {
+			id:       "c1",
+			metadata: withOwner("alice"),
+			chunks: []fakeChunk{
+				{id: "page_1_chunk_0", document: "alice's page", metadata: map[string]interface{}{"user_id": "alice"}},
+				// A chunk of another user that ended up in the collection
+				{id: "page_9_chunk_0", document: "bob's page", metadata: map[string]interface{}{"user_id": "bob"}},
+			},
+		},
+		"user_bob_website_2": {
+			id:       "c2",
+			metadata: withOwner("bob"),
+			chunks: []fakeChunk{
+				{id: "page_2_chunk_0", document: "bob's page", metadata: map[string]interface{}{"user_id": "bob"}},
+			},
+		},
+		// Named for bob but stamped as alice's
+		"user_bob_website_3": {
+			id:       "c3",
+			metadata: withOwner("alice"),
+			chunks: []fakeChunk{
+				{id: "page_3_chunk_0", document: "alice's page", metadata: map[string]interface{}{"user_id": "alice"}},
+			},
+		},
+	}}
+	owners := fakeOwners{1: "alice", 2: "bob", 3: "bob", 4: "bob"}
+	repo := newTestChromaRepository(t, chroma, owners)
+	ctx := context.Background()
+	query := []float32{0.1, 0.2}
+
+	results, err := repo.Query(ctx, 1, "test", query, 10, nil)
+	if err != nil {
+		t.Fatalf("Query() of alice's website error = %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "page_1_chunk_0" {
+		t.Errorf("Query() of alice's website = %+v, want only alice's chunk", results)
+	}
+
+	results, err = repo.Query(ctx, 2, "test", query, 10, nil)
+	if err != nil {
+		t.Fatalf("Query() of bob's website error = %v", err)
+	}
+	for _, result := range results {
+		if result.Metadata["user_id"] != "bob" {
+			t.Errorf("Query() of bob's website returned chunk %s of %v", result.ID, result.Metadata["user_id"])
+		}
+	}
+
+	// A collection stamped with another owner is refused
+	if results, err := repo.Query(ctx, 3, "test", query, 10, nil); err == nil {
+		t.Errorf("Query() of a collection stamped as alice's = %+v, want an error", results)
+	}
+
+	// Website 1 moving to bob doesn't give him alice's collection
+	owners[1] = "bob"
+	if results, err := repo.Query(ctx, 1, "test", query, 10, nil); err == nil {
+		t.Errorf("Query() of alice's collection as bob = %+v, want an error", results)
+	}
+
+	// Without a collection in bob's namespace there is nothing to read
+	if results, err := repo.Query(ctx, 4, "test", query, 10, nil); err == nil {
+		t.Errorf("Query() of a website without a collection = %+v, want an error", results)
+	}
+}
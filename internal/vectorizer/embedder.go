@@ -4,27 +4,22 @@ import (
 	"context"
 	"fmt"
 
+	"hermit/internal/ollama"
+
 	"github.com/ollama/ollama/api"
 	"go.uber.org/zap"
 )
 
 // Embedder handles generating embeddings using Ollama.
 type Embedder struct {
-	client *api.Client
+	client *ollama.Client
 	model  string
 	logger *zap.Logger
 }
 
 // NewEmbedder creates a new Embedder service.
 // model should be the Ollama model name (e.g., "mxbai-embed-large", "nomic-embed-text")
-func NewEmbedder(ollamaURL string, model string, logger *zap.Logger) *Embedder {
-	client, err := api.ClientFromEnvironment()
-	if err != nil {
-		logger.Warn("Failed to create Ollama client from environment, using default", zap.Error(err))
-		// Fallback to default URL
-		client = &api.Client{}
-	}
-
+func NewEmbedder(client *ollama.Client, model string, logger *zap.Logger) *Embedder {
 	return &Embedder{
 		client: client,
 		model:  model,
@@ -32,6 +27,11 @@ func NewEmbedder(ollamaURL string, model string, logger *zap.Logger) *Embedder {
 	}
 }
 
+// Model returns the name of the embedding model.
+func (e *Embedder) Model() string {
+	return e.model
+}
+
 // EmbedText generates an embedding for a single text string.
 // Returns the embedding vector and any error.
 func (e *Embedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
@@ -103,6 +103,60 @@ func (e *Embedder) EmbedChunks(ctx context.Context, chunks []string) ([][]float3
 	return embeddings, nil
 }
 
+// EmbedBatch generates embeddings for multiple texts in a single request.
+// Returns the embedding vectors in the order of the texts and any error.
+func (e *Embedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts provided")
+	}
+
+	req := &api.EmbedRequest{
+		Model: e.model,
+		Input: texts,
+	}
+
+	resp, err := e.client.Embed(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings from Ollama, got %d", len(texts), len(resp.Embeddings))
+	}
+
+	// Convert []float64 to []float32 for ChromaDB compatibility
+	embeddings := make([][]float32, len(resp.Embeddings))
+	for i, values := range resp.Embeddings {
+		embeddings[i] = make([]float32, len(values))
+		for j, v := range values {
+			embeddings[i][j] = float32(v)
+		}
+	}
+
+	e.logger.Info("Successfully embedded batch",
+		zap.Int("totalTexts", len(texts)),
+		zap.Int("dimensions", len(embeddings[0])),
+	)
+
+	return embeddings, nil
+}
+
+// Warmup loads the embedding model into memory with a small request and
+// returns the embedding dimensions it produces. When expectedDims is greater
+// than zero, a different dimension count is reported as an error.
+func (e *Embedder) Warmup(ctx context.Context, expectedDims int) (int, error) {
+	embedding, err := e.EmbedText(ctx, "warm up")
+	if err != nil {
+		return 0, fmt.Errorf("failed to warm up embedding model %s: %w", e.model, err)
+	}
+
+	if expectedDims > 0 && len(embedding) != expectedDims {
+		return len(embedding), fmt.Errorf("embedding model %s returned %d dimensions, expected %d", e.model, len(embedding), expectedDims)
+	}
+
+	return len(embedding), nil
+}
+
 // GetModelInfo retrieves information about the current embedding model.
 func (e *Embedder) GetModelInfo(ctx context.Context) (*api.ShowResponse, error) {
 	req := &api.ShowRequest{
@@ -3,6 +3,9 @@ package vectorizer
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
+
+	"hermit/internal/apperrors"
 
 	"go.uber.org/zap"
 )
@@ -28,15 +31,24 @@ func NewService(
 	}
 }
 
+// EmbeddingModel returns the name of the model chunks and queries are
+// embedded with.
+func (s *Service) EmbeddingModel() string {
+	return s.embedder.Model()
+}
+
 // ProcessPageContent processes page content through the full vectorization pipeline.
-// It chunks the text, generates embeddings, and stores them in ChromaDB.
+// It chunks the text, generates embeddings, and stores them in ChromaDB with
+// the page's tags so retrieval can be filtered by tag. It returns the number
+// of chunks stored.
 func (s *Service) ProcessPageContent(
 	ctx context.Context,
 	websiteID uint,
 	pageID uint,
 	pageURL string,
 	content string,
-) error {
+	tags []string,
+) (int, error) {
 	s.logger.Info("Starting vectorization process",
 		zap.Uint("websiteID", websiteID),
 		zap.Uint("pageID", pageID),
@@ -50,7 +62,7 @@ func (s *Service) ProcessPageContent(
 		s.logger.Warn("No chunks generated from content",
 			zap.Uint("pageID", pageID),
 		)
-		return fmt.Errorf("no chunks generated from content")
+		return 0, fmt.Errorf("no chunks generated from content")
 	}
 
 	s.logger.Info("Text chunked",
@@ -65,7 +77,7 @@ func (s *Service) ProcessPageContent(
 			zap.Uint("pageID", pageID),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to generate embeddings: %w", err)
+		return 0, apperrors.UpstreamUnavailable("Embedding service is unavailable", err)
 	}
 
 	s.logger.Info("Embeddings generated",
@@ -74,13 +86,13 @@ func (s *Service) ProcessPageContent(
 	)
 
 	// Step 3: Store chunks and embeddings in ChromaDB
-	err = s.chromaRepo.StoreChunks(ctx, websiteID, pageID, pageURL, chunks, embeddings)
+	err = s.chromaRepo.StoreChunks(ctx, websiteID, pageID, pageURL, s.embedder.Model(), chunks, embeddings, tags)
 	if err != nil {
 		s.logger.Error("Failed to store chunks in ChromaDB",
 			zap.Uint("pageID", pageID),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to store chunks: %w", err)
+		return 0, fmt.Errorf("failed to store chunks: %w", err)
 	}
 
 	s.logger.Info("Vectorization completed successfully",
@@ -89,9 +101,98 @@ func (s *Service) ProcessPageContent(
 		zap.Int("totalChunks", len(chunks)),
 	)
 
+	return len(chunks), nil
+}
+
+// StoreEmbeddings stores chunks of a page with embeddings computed by the
+// caller, e.g. the canned embeddings of seeded demo data, as if they were
+// embedded with the embedding model.
+func (s *Service) StoreEmbeddings(ctx context.Context, websiteID, pageID uint, pageURL string, chunks []string, embeddings [][]float32, tags []string) error {
+	if err := s.chromaRepo.StoreChunks(ctx, websiteID, pageID, pageURL, s.embedder.Model(), chunks, embeddings, tags); err != nil {
+		return fmt.Errorf("failed to store chunks: %w", err)
+	}
 	return nil
 }
 
+// PageContent is the content of a page vectorized in a batch.
+type PageContent struct {
+	PageID  uint
+	PageURL string
+	Content string
+	Tags    []string
+}
+
+// ProcessPages vectorizes the pages of a website in a batch, embedding the
+// chunks of all pages in a single request. It returns the number of chunks
+// stored per page and the error of each page that failed. An error is only
+// returned when no page could be vectorized because embedding failed.
+func (s *Service) ProcessPages(ctx context.Context, websiteID uint, pages []PageContent) (map[uint]int, map[uint]error, error) {
+	stored := make(map[uint]int, len(pages))
+	failed := make(map[uint]error)
+
+	// Step 1: Chunk the text of every page
+	chunksByPage := make([][]string, len(pages))
+	var allChunks []string
+	for i, page := range pages {
+		chunks := ChunkText(page.Content)
+		if len(chunks) == 0 {
+			failed[page.PageID] = fmt.Errorf("no chunks generated from content")
+			continue
+		}
+		chunksByPage[i] = chunks
+		allChunks = append(allChunks, chunks...)
+	}
+	if len(allChunks) == 0 {
+		return stored, failed, nil
+	}
+
+	s.logger.Info("Starting batch vectorization",
+		zap.Uint("websiteID", websiteID),
+		zap.Int("numPages", len(pages)),
+		zap.Int("numChunks", len(allChunks)),
+	)
+
+	// Step 2: Generate embeddings for the chunks of all pages at once
+	embeddings, err := s.embedder.EmbedBatch(ctx, allChunks)
+	if err != nil {
+		s.logger.Error("Failed to generate batch embeddings",
+			zap.Uint("websiteID", websiteID),
+			zap.Error(err),
+		)
+		return nil, nil, apperrors.UpstreamUnavailable("Embedding service is unavailable", err)
+	}
+
+	// Step 3: Store the chunks and embeddings of each page in ChromaDB
+	offset := 0
+	for i, page := range pages {
+		chunks := chunksByPage[i]
+		if len(chunks) == 0 {
+			continue
+		}
+		pageEmbeddings := embeddings[offset : offset+len(chunks)]
+		offset += len(chunks)
+
+		err := s.chromaRepo.StoreChunks(ctx, websiteID, page.PageID, page.PageURL, s.embedder.Model(), chunks, pageEmbeddings, page.Tags)
+		if err != nil {
+			s.logger.Error("Failed to store chunks in ChromaDB",
+				zap.Uint("pageID", page.PageID),
+				zap.Error(err),
+			)
+			failed[page.PageID] = fmt.Errorf("failed to store chunks: %w", err)
+			continue
+		}
+		stored[page.PageID] = len(chunks)
+	}
+
+	s.logger.Info("Batch vectorization completed",
+		zap.Uint("websiteID", websiteID),
+		zap.Int("vectorized", len(stored)),
+		zap.Int("failed", len(failed)),
+	)
+
+	return stored, failed, nil
+}
+
 // QuerySimilarContent performs semantic search to find similar content.
 func (s *Service) QuerySimilarContent(
 	ctx context.Context,
@@ -105,18 +206,39 @@ func (s *Service) QuerySimilarContent(
 		zap.Int("topK", topK),
 	)
 
-	// Generate embedding for the query
+	queryEmbedding, err := s.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.QuerySimilarByEmbedding(ctx, websiteID, queryEmbedding, topK, nil)
+}
+
+// EmbedQuery generates an embedding for a search query.
+func (s *Service) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
 	queryEmbedding, err := s.embedder.EmbedText(ctx, query)
 	if err != nil {
 		s.logger.Error("Failed to embed query",
 			zap.String("query", query),
 			zap.Error(err),
 		)
-		return nil, fmt.Errorf("failed to embed query: %w", err)
+		return nil, apperrors.UpstreamUnavailable("Embedding service is unavailable", err)
 	}
 
+	return queryEmbedding, nil
+}
+
+// QuerySimilarByEmbedding performs semantic search using a precomputed query
+// embedding. When tags are set only chunks of pages with any of them match.
+func (s *Service) QuerySimilarByEmbedding(
+	ctx context.Context,
+	websiteID uint,
+	queryEmbedding []float32,
+	topK int,
+	tags []string,
+) ([]QueryResult, error) {
 	// Query ChromaDB for similar chunks
-	results, err := s.chromaRepo.Query(ctx, websiteID, queryEmbedding, topK)
+	results, err := s.chromaRepo.Query(ctx, websiteID, s.embedder.Model(), queryEmbedding, topK, tags)
 	if err != nil {
 		s.logger.Error("Failed to query ChromaDB",
 			zap.Uint("websiteID", websiteID),
@@ -187,3 +309,44 @@ func (s *Service) GetWebsiteVectorCount(ctx context.Context, websiteID uint) (in
 
 	return count, nil
 }
+
+// GetTotalVectorCount returns the number of vectors stored for all websites.
+func (s *Service) GetTotalVectorCount(ctx context.Context) (int, error) {
+	return s.chromaRepo.CountAllVectors(ctx)
+}
+
+// ListWebsiteCollections returns the website IDs that have vectors stored.
+func (s *Service) ListWebsiteCollections(ctx context.Context) ([]uint, error) {
+	return s.chromaRepo.ListWebsiteCollections(ctx)
+}
+
+// CountChunksByPage returns the number of vectors stored for each page of a website.
+func (s *Service) CountChunksByPage(ctx context.Context, websiteID uint) (map[uint]int, error) {
+	return s.chromaRepo.CountChunksByPage(ctx, websiteID)
+}
+
+// sampleWindow is the number of pages chunks are sampled from.
+const sampleWindow = 200
+
+// SampleChunks returns the first chunks of up to n random pages of a website.
+func (s *Service) SampleChunks(ctx context.Context, websiteID uint, n int) ([]QueryResult, error) {
+	chunks, err := s.chromaRepo.ListFirstChunks(ctx, websiteID, sampleWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	rand.Shuffle(len(chunks), func(i, j int) {
+		chunks[i], chunks[j] = chunks[j], chunks[i]
+	})
+	if len(chunks) > n {
+		chunks = chunks[:n]
+	}
+
+	return chunks, nil
+}
+
+// MigrateCollections moves vectors stored before collections were namespaced
+// by owner into their owner's namespace.
+func (s *Service) MigrateCollections(ctx context.Context) (int, error) {
+	return s.chromaRepo.MigrateCollections(ctx)
+}
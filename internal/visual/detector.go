@@ -0,0 +1,115 @@
+package visual
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"hermit/internal/config"
+	"hermit/internal/notifications"
+	"hermit/internal/repositories"
+	"hermit/internal/schema"
+	"hermit/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+// Detector compares page screenshots between crawls and notifies when a page
+// changes visually, even if its extracted text did not.
+type Detector struct {
+	screenshots  *ScreenshotClient
+	snapshotRepo *repositories.VisualSnapshotRepository
+	storage      *storage.GarageStorage
+	notifier     *notifications.Notifier
+	threshold    float64
+	logger       *zap.Logger
+}
+
+// NewDetector creates a new Detector. It returns nil when no screenshot service
+// is configured, which disables visual change detection.
+func NewDetector(
+	cfg *config.Config,
+	snapshotRepo *repositories.VisualSnapshotRepository,
+	storage *storage.GarageStorage,
+	notifier *notifications.Notifier,
+	logger *zap.Logger,
+) *Detector {
+	if cfg.ScreenshotServiceURL == "" {
+		return nil
+	}
+
+	return &Detector{
+		screenshots:  NewScreenshotClient(cfg.ScreenshotServiceURL, cfg.ScreenshotWidth, cfg.ScreenshotHeight, time.Duration(cfg.CrawlerTimeout)*time.Second),
+		snapshotRepo: snapshotRepo,
+		storage:      storage,
+		notifier:     notifier,
+		threshold:    cfg.VisualChangeThreshold,
+		logger:       logger,
+	}
+}
+
+// Threshold returns the change score at or above which a page counts as changed.
+func (d *Detector) Threshold() float64 {
+	return d.threshold
+}
+
+// Capture returns a PNG screenshot of a page.
+func (d *Detector) Capture(ctx context.Context, pageURL string) ([]byte, error) {
+	return d.screenshots.Capture(ctx, pageURL)
+}
+
+// Check records the perceptual hash of a page screenshot taken with Capture
+// and compares it with the previous snapshot. A notification is sent when the
+// change score reaches the threshold.
+func (d *Detector) Check(ctx context.Context, websiteID, pageID uint, pageURL string, image []byte, textChanged bool) (*schema.VisualSnapshot, error) {
+	hash, err := PerceptualHash(image)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := d.snapshotRepo.GetLatestByPageID(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &schema.VisualSnapshot{
+		WebsiteID:   websiteID,
+		PageID:      pageID,
+		PageURL:     pageURL,
+		PHash:       int64(hash),
+		TextChanged: textChanged,
+	}
+	if previous != nil {
+		snapshot.ChangeScore = ChangeScore(uint64(previous.PHash), hash)
+	}
+
+	objectKey, err := d.storage.SaveScreenshot(ctx, int(websiteID), pageURL, image)
+	if err != nil {
+		d.logger.Warn("Failed to store screenshot", zap.String("url", pageURL), zap.Error(err))
+	} else {
+		snapshot.ObjectKey = sql.NullString{String: objectKey, Valid: true}
+	}
+
+	if err := d.snapshotRepo.Create(ctx, snapshot); err != nil {
+		return nil, err
+	}
+
+	if previous != nil && snapshot.ChangeScore >= d.threshold {
+		err := d.notifier.Notify(ctx, notifications.Event{
+			Type:      notifications.EventVisualChange,
+			WebsiteID: websiteID,
+			PageURL:   pageURL,
+			Data: map[string]any{
+				"change_score": snapshot.ChangeScore,
+				"text_changed": textChanged,
+				"snapshot_id":  snapshot.ID,
+			},
+		})
+		if err != nil {
+			return snapshot, fmt.Errorf("failed to send visual change notification: %w", err)
+		}
+	}
+
+	return snapshot, nil
+}
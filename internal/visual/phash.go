@@ -0,0 +1,80 @@
+package visual
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math/bits"
+
+	// Register decoders for the formats screenshot services return
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// hashWidth and hashHeight define the grid used for the difference hash.
+// Comparing each cell with its right neighbour yields 8x8 = 64 bits.
+const (
+	hashWidth  = 9
+	hashHeight = 8
+)
+
+// PerceptualHash computes a 64-bit difference hash (dHash) of an encoded image.
+// Visually similar images produce hashes with a small Hamming distance.
+func PerceptualHash(data []byte) (uint64, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	grid := downscaleGray(img, hashWidth, hashHeight)
+
+	var hash uint64
+	for y := 0; y < hashHeight; y++ {
+		for x := 0; x < hashWidth-1; x++ {
+			hash <<= 1
+			if grid[y][x] > grid[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash, nil
+}
+
+// ChangeScore returns the fraction of differing bits between two hashes,
+// from 0 (identical) to 1 (completely different).
+func ChangeScore(a, b uint64) float64 {
+	return float64(bits.OnesCount64(a^b)) / 64
+}
+
+// downscaleGray averages the luminance of img over a width x height grid.
+func downscaleGray(img image.Image, width, height int) [][]float64 {
+	bounds := img.Bounds()
+	grid := make([][]float64, height)
+
+	for gy := 0; gy < height; gy++ {
+		grid[gy] = make([]float64, width)
+		y0 := bounds.Min.Y + gy*bounds.Dy()/height
+		y1 := bounds.Min.Y + (gy+1)*bounds.Dy()/height
+
+		for gx := 0; gx < width; gx++ {
+			x0 := bounds.Min.X + gx*bounds.Dx()/width
+			x1 := bounds.Min.X + (gx+1)*bounds.Dx()/width
+
+			var sum float64
+			var count int
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					r, g, b, _ := img.At(x, y).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+					count++
+				}
+			}
+			if count > 0 {
+				grid[gy][gx] = sum / float64(count)
+			}
+		}
+	}
+
+	return grid
+}
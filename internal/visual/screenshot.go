@@ -0,0 +1,86 @@
+package visual
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxScreenshotSize caps the size of a screenshot read from the service.
+const maxScreenshotSize = 20 << 20 // 20 MiB
+
+// ScreenshotClient captures page screenshots through a headless browser service
+// exposing a browserless-compatible POST /screenshot endpoint.
+type ScreenshotClient struct {
+	baseURL string
+	width   int
+	height  int
+	client  *http.Client
+}
+
+// NewScreenshotClient creates a new ScreenshotClient.
+func NewScreenshotClient(baseURL string, width, height int, timeout time.Duration) *ScreenshotClient {
+	return &ScreenshotClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		width:   width,
+		height:  height,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// screenshotRequest is the request body sent to the screenshot service.
+type screenshotRequest struct {
+	URL      string             `json:"url"`
+	Options  screenshotOptions  `json:"options"`
+	Viewport screenshotViewport `json:"viewport"`
+}
+
+type screenshotOptions struct {
+	Type     string `json:"type"`
+	FullPage bool   `json:"fullPage"`
+}
+
+type screenshotViewport struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Capture returns a PNG screenshot of the viewport for pageURL.
+func (c *ScreenshotClient) Capture(ctx context.Context, pageURL string) ([]byte, error) {
+	body, err := json.Marshal(screenshotRequest{
+		URL:      pageURL,
+		Options:  screenshotOptions{Type: "png"},
+		Viewport: screenshotViewport{Width: c.width, Height: c.height},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode screenshot request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/screenshot", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create screenshot request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("screenshot request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("screenshot service returned status %d", resp.StatusCode)
+	}
+
+	image, err := io.ReadAll(io.LimitReader(resp.Body, maxScreenshotSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read screenshot: %w", err)
+	}
+
+	return image, nil
+}
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Create query_cache table for semantic RAG answer caching
+CREATE TABLE IF NOT EXISTS query_cache (
+    id SERIAL PRIMARY KEY,
+    website_id INTEGER NOT NULL REFERENCES websites(id) ON DELETE CASCADE,
+    query TEXT NOT NULL,
+    embedding JSONB NOT NULL,
+    answer TEXT NOT NULL,
+    sources JSONB NOT NULL DEFAULT '[]',
+    retrieved_chunks INTEGER NOT NULL DEFAULT 0,
+    hit_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- Create index for per-website lookups of unexpired entries
+CREATE INDEX idx_query_cache_website_expires ON query_cache(website_id, expires_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_query_cache_website_expires;
+DROP TABLE IF EXISTS query_cache;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Allow crawling internal sites that use self-signed certificates
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS tls_skip_verify BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS tls_skip_verify;
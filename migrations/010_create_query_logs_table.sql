@@ -0,0 +1,39 @@
+-- +goose Up
+-- Create query_logs table to record every RAG query for later evaluation
+CREATE TABLE IF NOT EXISTS query_logs (
+    id SERIAL PRIMARY KEY,
+    website_id INTEGER NOT NULL REFERENCES websites(id) ON DELETE CASCADE,
+    user_id VARCHAR(26) REFERENCES users(id) ON DELETE SET NULL,
+    query TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    sources JSONB NOT NULL DEFAULT '[]',
+    retrieved_chunks INTEGER NOT NULL DEFAULT 0,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    cached BOOLEAN NOT NULL DEFAULT FALSE,
+    streamed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_query_logs_website_id ON query_logs(website_id);
+CREATE INDEX idx_query_logs_created_at ON query_logs(created_at);
+
+-- Create query_feedback table for thumbs up/down ratings on answers
+CREATE TABLE IF NOT EXISTS query_feedback (
+    id SERIAL PRIMARY KEY,
+    query_log_id INTEGER NOT NULL REFERENCES query_logs(id) ON DELETE CASCADE,
+    user_id VARCHAR(26) REFERENCES users(id) ON DELETE SET NULL,
+    rating SMALLINT NOT NULL CHECK (rating IN (-1, 1)),
+    comment TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(query_log_id, user_id)
+);
+
+CREATE INDEX idx_query_feedback_query_log_id ON query_feedback(query_log_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_query_feedback_query_log_id;
+DROP TABLE IF EXISTS query_feedback;
+DROP INDEX IF EXISTS idx_query_logs_created_at;
+DROP INDEX IF EXISTS idx_query_logs_website_id;
+DROP TABLE IF EXISTS query_logs;
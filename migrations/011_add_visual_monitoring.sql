@@ -0,0 +1,23 @@
+-- +goose Up
+-- Screenshot-based visual change detection
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS visual_monitoring BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS page_visual_snapshots (
+    id SERIAL PRIMARY KEY,
+    website_id INTEGER NOT NULL REFERENCES websites(id) ON DELETE CASCADE,
+    page_id INTEGER NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    phash BIGINT NOT NULL,
+    object_key TEXT,
+    change_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    text_changed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_page_visual_snapshots_page_id ON page_visual_snapshots(page_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_page_visual_snapshots_website_id ON page_visual_snapshots(website_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_page_visual_snapshots_website_id;
+DROP INDEX IF EXISTS idx_page_visual_snapshots_page_id;
+DROP TABLE IF EXISTS page_visual_snapshots;
+ALTER TABLE websites DROP COLUMN IF EXISTS visual_monitoring;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Notable per-page crawl events so users can diagnose crawls without server logs
+CREATE TABLE IF NOT EXISTS crawl_events (
+    id BIGSERIAL PRIMARY KEY,
+    website_id INTEGER NOT NULL REFERENCES websites(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    level VARCHAR(10) NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_crawl_events_website_created ON crawl_events(website_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_crawl_events_website_created;
+DROP TABLE IF EXISTS crawl_events;
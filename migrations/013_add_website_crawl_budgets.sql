@@ -0,0 +1,13 @@
+-- +goose Up
+-- Per-website crawl budgets, 0 falls back to the global defaults
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS max_crawl_bytes BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS max_crawl_duration_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS max_pages_per_prefix INTEGER NOT NULL DEFAULT 0;
+-- Budget that stopped the last crawl early, if any
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS budget_exhausted VARCHAR(32);
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS budget_exhausted;
+ALTER TABLE websites DROP COLUMN IF EXISTS max_pages_per_prefix;
+ALTER TABLE websites DROP COLUMN IF EXISTS max_crawl_duration_seconds;
+ALTER TABLE websites DROP COLUMN IF EXISTS max_crawl_bytes;
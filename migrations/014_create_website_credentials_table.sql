@@ -0,0 +1,11 @@
+-- +goose Up
+-- Encrypted credentials used when crawling protected websites
+CREATE TABLE IF NOT EXISTS website_credentials (
+    website_id INTEGER PRIMARY KEY REFERENCES websites(id) ON DELETE CASCADE,
+    encrypted BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS website_credentials;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Title and leading text of the cleaned content, stored at crawl time so
+-- listings and previews don't have to fetch the page from storage
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS title TEXT;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS snippet TEXT;
+
+-- +goose Down
+ALTER TABLE pages DROP COLUMN IF EXISTS snippet;
+ALTER TABLE pages DROP COLUMN IF EXISTS title;
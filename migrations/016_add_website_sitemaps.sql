@@ -0,0 +1,6 @@
+-- +goose Up
+-- Sitemaps found in robots.txt or at common locations, used to seed crawls
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS sitemap_urls TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS sitemap_urls;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- HTTP status of the last failed fetch (0 for network errors) and how often
+-- the page was retried since its last successful fetch
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS http_status INTEGER;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS retry_count INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE pages DROP COLUMN IF EXISTS retry_count;
+ALTER TABLE pages DROP COLUMN IF EXISTS http_status;
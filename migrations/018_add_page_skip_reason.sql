@@ -0,0 +1,6 @@
+-- +goose Up
+-- Why a discovered page was not indexed, for pages with status 'skipped'
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS skip_reason VARCHAR(32);
+
+-- +goose Down
+ALTER TABLE pages DROP COLUMN IF EXISTS skip_reason;
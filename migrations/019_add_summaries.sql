@@ -0,0 +1,16 @@
+-- +goose Up
+-- LLM summaries of each page and a rollup of the whole website. A page's
+-- summary_content_hash is the content hash it was generated from, so
+-- unchanged pages aren't summarized again on recrawl
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS summary TEXT;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS summary_content_hash TEXT;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS summarized_at TIMESTAMPTZ;
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS summary TEXT;
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS summarized_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS summarized_at;
+ALTER TABLE websites DROP COLUMN IF EXISTS summary;
+ALTER TABLE pages DROP COLUMN IF EXISTS summarized_at;
+ALTER TABLE pages DROP COLUMN IF EXISTS summary_content_hash;
+ALTER TABLE pages DROP COLUMN IF EXISTS summary;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Topics extracted from the content of each page, used to filter pages and
+-- retrieval. website_id is denormalized so a website's tags can be listed
+-- without joining pages
+CREATE TABLE IF NOT EXISTS page_tags (
+    page_id INTEGER NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    website_id INTEGER NOT NULL REFERENCES websites(id) ON DELETE CASCADE,
+    tag VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (page_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_page_tags_website_tag ON page_tags(website_id, tag);
+
+-- +goose Down
+DROP TABLE IF EXISTS page_tags;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Questions generated from a sample of a website's content for the chat's
+-- empty state. They are generated again once a newer crawl completed
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS suggested_questions TEXT[];
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS suggested_questions_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS suggested_questions_at;
+ALTER TABLE websites DROP COLUMN IF EXISTS suggested_questions;
@@ -0,0 +1,59 @@
+-- +goose Up
+-- Question/expected answer pairs a website's RAG answers are evaluated against
+CREATE TABLE IF NOT EXISTS eval_cases (
+    id SERIAL PRIMARY KEY,
+    website_id INTEGER NOT NULL REFERENCES websites(id) ON DELETE CASCADE,
+    question TEXT NOT NULL,
+    expected_answer TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_eval_cases_website_id ON eval_cases(website_id);
+
+-- Evaluation runs with the retrieval settings they used and their metrics, so
+-- runs can be compared over time
+CREATE TABLE IF NOT EXISTS eval_runs (
+    id SERIAL PRIMARY KEY,
+    website_id INTEGER NOT NULL REFERENCES websites(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    scorer VARCHAR(20) NOT NULL,
+    settings JSONB NOT NULL DEFAULT '{}',
+    cases INTEGER NOT NULL DEFAULT 0,
+    answered INTEGER NOT NULL DEFAULT 0,
+    mean_score DOUBLE PRECISION,
+    pass_rate DOUBLE PRECISION,
+    mean_latency_ms DOUBLE PRECISION,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_eval_runs_website_id_created_at ON eval_runs(website_id, created_at DESC);
+
+-- The answer and score of every case of a run. Results keep a copy of the case
+-- so they stay readable after the cases were replaced
+CREATE TABLE IF NOT EXISTS eval_results (
+    id SERIAL PRIMARY KEY,
+    run_id INTEGER NOT NULL REFERENCES eval_runs(id) ON DELETE CASCADE,
+    case_id INTEGER REFERENCES eval_cases(id) ON DELETE SET NULL,
+    question TEXT NOT NULL,
+    expected_answer TEXT NOT NULL,
+    answer TEXT NOT NULL DEFAULT '',
+    score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    retrieved_chunks INTEGER NOT NULL DEFAULT 0,
+    degraded BOOLEAN NOT NULL DEFAULT FALSE,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_eval_results_run_id ON eval_results(run_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_eval_results_run_id;
+DROP TABLE IF EXISTS eval_results;
+DROP INDEX IF EXISTS idx_eval_runs_website_id_created_at;
+DROP TABLE IF EXISTS eval_runs;
+DROP INDEX IF EXISTS idx_eval_cases_website_id;
+DROP TABLE IF EXISTS eval_cases;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Start URLs crawled in addition to the website URL, and the URL prefixes
+-- crawls are restricted to. No prefixes means the whole host is crawled
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS seed_urls TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS scope_prefixes TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS scope_prefixes;
+ALTER TABLE websites DROP COLUMN IF EXISTS seed_urls;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Access to a website granted by its owner to another user or to a single
+-- API key. Exactly one of user_id and api_key_id is set
+CREATE TABLE IF NOT EXISTS website_permissions (
+    id SERIAL PRIMARY KEY,
+    website_id INTEGER NOT NULL REFERENCES websites(id) ON DELETE CASCADE,
+    user_id VARCHAR(26) REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id VARCHAR(26) REFERENCES api_keys(id) ON DELETE CASCADE,
+    permission VARCHAR(20) NOT NULL,
+    created_by VARCHAR(26) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((user_id IS NULL) <> (api_key_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_website_permissions_user
+    ON website_permissions (website_id, user_id, permission) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_website_permissions_api_key
+    ON website_permissions (website_id, api_key_id, permission) WHERE api_key_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_website_permissions_user_id ON website_permissions (user_id);
+CREATE INDEX IF NOT EXISTS idx_website_permissions_api_key_id ON website_permissions (api_key_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS website_permissions;
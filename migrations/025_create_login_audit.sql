@@ -0,0 +1,19 @@
+-- +goose Up
+-- Successful and failed login attempts. user_id is set when the email
+-- belongs to an account
+CREATE TABLE IF NOT EXISTS login_audit (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(26) REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL,
+    reason VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_audit_user_created ON login_audit (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_login_audit_created_at ON login_audit (created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS login_audit;
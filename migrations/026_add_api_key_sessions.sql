@@ -0,0 +1,18 @@
+-- +goose Up
+-- Web sessions are API keys created at login with the client's IP and user
+-- agent. They expire with the session cookie
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS session BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS ip VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '';
+
+UPDATE api_keys
+SET session = true, expires_at = COALESCE(expires_at, created_at + INTERVAL '7 days')
+WHERE name LIKE 'Web Session - %';
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_session ON api_keys (user_id) WHERE session;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_api_keys_user_session;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS user_agent;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS ip;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS session;
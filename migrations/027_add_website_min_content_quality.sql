@@ -0,0 +1,7 @@
+-- +goose Up
+-- Per-website minimum content quality of indexed pages, 0 falls back to the
+-- global default
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS min_content_quality DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS min_content_quality;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Hashes of the text blocks the last crawl found on most pages of a website,
+-- stripped from pages before the next crawl has seen enough pages itself
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS boilerplate_blocks TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS boilerplate_blocks;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Images of a page's main content with their alt text, captions and the
+-- paragraph around them
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS images JSONB NOT NULL DEFAULT '[]';
+
+-- +goose Down
+ALTER TABLE pages DROP COLUMN IF EXISTS images;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Encrypted configs of the connectors ingesting websites from Notion or
+-- Confluence instead of crawling them
+CREATE TABLE IF NOT EXISTS website_connectors (
+    website_id INTEGER PRIMARY KEY REFERENCES websites(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    encrypted BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS website_connectors;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Discord channels whose questions are answered from a website
+CREATE TABLE IF NOT EXISTS discord_channels (
+    channel_id VARCHAR(32) PRIMARY KEY,
+    website_id INTEGER NOT NULL REFERENCES websites(id) ON DELETE CASCADE,
+    created_by VARCHAR(26) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_discord_channels_website_id ON discord_channels(website_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS discord_channels;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Public keys are embedded in the chat widget on a customer's site. They can
+-- only query one website, from the listed origins and at a limited rate
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS public BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS website_id INTEGER REFERENCES websites(id) ON DELETE CASCADE;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS allowed_origins TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS rate_limit_per_min INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE api_keys DROP COLUMN IF EXISTS rate_limit_per_min;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS allowed_origins;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS website_id;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS public;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Tokens the LLM processed for each answer, for usage and cost reporting
+ALTER TABLE query_logs ADD COLUMN IF NOT EXISTS prompt_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE query_logs ADD COLUMN IF NOT EXISTS completion_tokens INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_query_logs_user_id ON query_logs(user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_query_logs_user_id;
+ALTER TABLE query_logs DROP COLUMN IF EXISTS completion_tokens;
+ALTER TABLE query_logs DROP COLUMN IF EXISTS prompt_tokens;
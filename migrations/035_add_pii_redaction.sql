@@ -0,0 +1,9 @@
+-- +goose Up
+-- Websites can have personal data redacted from their pages before they are
+-- stored and vectorized. Pages keep the number of redactions of each kind
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS pii_redaction BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS pii_redactions JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE pages DROP COLUMN IF EXISTS pii_redactions;
+ALTER TABLE websites DROP COLUMN IF EXISTS pii_redaction;
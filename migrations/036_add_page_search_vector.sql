@@ -0,0 +1,9 @@
+-- +goose Up
+-- Full-text search over the stored text of pages. Pages crawled before are
+-- searchable once their website is recrawled or reindexed
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+CREATE INDEX IF NOT EXISTS idx_pages_search_vector ON pages USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_pages_search_vector;
+ALTER TABLE pages DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Pages keep the storage key of a screenshot of their last crawl when
+-- SCREENSHOT_PAGES is enabled
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS screenshot_key TEXT;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS screenshot_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE pages DROP COLUMN IF EXISTS screenshot_at;
+ALTER TABLE pages DROP COLUMN IF EXISTS screenshot_key;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Websites blocking the default user agent can be crawled with their own user
+-- agent, a list of user agents rotated between requests and extra headers
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS user_agent_rotation TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS request_headers JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS request_headers;
+ALTER TABLE websites DROP COLUMN IF EXISTS user_agent_rotation;
+ALTER TABLE websites DROP COLUMN IF EXISTS user_agent;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Websites can override CRAWLER_RESPECT_ROBOTS_TXT; NULL uses the server setting
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS respect_robots BOOLEAN;
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS respect_robots;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Daily UTC window crawls of a website may start in, in minutes after
+-- midnight. Crawls queued outside of it are deferred until it opens
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS crawl_window_start INTEGER;
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS crawl_window_end INTEGER;
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS crawl_window_end;
+ALTER TABLE websites DROP COLUMN IF EXISTS crawl_window_start;
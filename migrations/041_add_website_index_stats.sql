@@ -0,0 +1,13 @@
+-- +goose Up
+-- Vector count and storage usage of websites, refreshed after crawls and
+-- vectorization; NULL until first computed
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS vector_count INTEGER;
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS storage_objects INTEGER;
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS storage_bytes BIGINT;
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS index_stats_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS index_stats_at;
+ALTER TABLE websites DROP COLUMN IF EXISTS storage_bytes;
+ALTER TABLE websites DROP COLUMN IF EXISTS storage_objects;
+ALTER TABLE websites DROP COLUMN IF EXISTS vector_count;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Whether the chunks of a page's content were stored in ChromaDB; NULL for
+-- pages without content
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS vector_status TEXT;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS vector_error TEXT;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS chunk_count INTEGER;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS vectorized_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_pages_website_vector_status ON pages (website_id, vector_status);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_pages_website_vector_status;
+ALTER TABLE pages DROP COLUMN IF EXISTS vectorized_at;
+ALTER TABLE pages DROP COLUMN IF EXISTS chunk_count;
+ALTER TABLE pages DROP COLUMN IF EXISTS vector_error;
+ALTER TABLE pages DROP COLUMN IF EXISTS vector_status;
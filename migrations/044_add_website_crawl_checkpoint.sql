@@ -0,0 +1,7 @@
+-- +goose Up
+-- Progress of a crawl interrupted by a worker shutdown: the URLs it already
+-- handled and those it still had to fetch, so the next crawl resumes it
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS crawl_checkpoint JSONB;
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS crawl_checkpoint;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Pages keep the storage key of the raw HTML of their last fetch when
+-- ARCHIVE_RAW_HTML is enabled, so they can be reprocessed without a refetch
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS html_object_key TEXT;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS html_archived_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE pages DROP COLUMN IF EXISTS html_archived_at;
+ALTER TABLE pages DROP COLUMN IF EXISTS html_object_key;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Websites add noise patterns to CONTENT_NOISE_PATTERNS, replace them or
+-- turn noise removal off
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS noise_removal TEXT NOT NULL DEFAULT 'extend';
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS noise_patterns TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS noise_patterns;
+ALTER TABLE websites DROP COLUMN IF EXISTS noise_removal;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- Periodic snapshots of the stats of every job queue, charted by the queue
+-- history endpoint. The processed and failed counters are cumulative as kept
+-- by asynq, throughput is derived from the difference between samples
+CREATE TABLE IF NOT EXISTS queue_stats (
+    id BIGSERIAL PRIMARY KEY,
+    queue VARCHAR(100) NOT NULL,
+    pending INTEGER NOT NULL DEFAULT 0,
+    active INTEGER NOT NULL DEFAULT 0,
+    scheduled INTEGER NOT NULL DEFAULT 0,
+    retry INTEGER NOT NULL DEFAULT 0,
+    archived INTEGER NOT NULL DEFAULT 0,
+    completed INTEGER NOT NULL DEFAULT 0,
+    processed_total BIGINT NOT NULL DEFAULT 0,
+    failed_total BIGINT NOT NULL DEFAULT 0,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    sampled_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_queue_stats_queue_sampled_at ON queue_stats (queue, sampled_at);
+CREATE INDEX IF NOT EXISTS idx_queue_stats_sampled_at ON queue_stats (sampled_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS queue_stats;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- User-defined labels grouping websites, e.g. docs or competitor
+ALTER TABLE websites ADD COLUMN IF NOT EXISTS labels TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE websites DROP COLUMN IF EXISTS labels;
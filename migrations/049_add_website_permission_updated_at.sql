@@ -0,0 +1,8 @@
+-- +goose Up
+-- Versions the websites shared with a user, so the ETag of their website list
+-- changes when a permission changes
+ALTER TABLE website_permissions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+UPDATE website_permissions SET updated_at = created_at;
+
+-- +goose Down
+ALTER TABLE website_permissions DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Event types of a streamed query, in the order they are sent. Error replaces
+// the remaining events when the query fails after the stream started.
+const (
+	EventStart    = "start"
+	EventSources  = "sources"
+	EventChunk    = "chunk"
+	EventMetadata = "metadata"
+	EventDone     = "done"
+	EventError    = "error"
+)
+
+// StreamEvent is an event of a streamed query. Depending on Type, Text,
+// Sources, Metadata or Err is set.
+type StreamEvent struct {
+	Type string
+	// Text is the next part of the answer of chunk events
+	Text     string
+	Sources  *StreamSources
+	Metadata *StreamMetadata
+	Err      *Error
+	// Data is the raw JSON payload of the event
+	Data json.RawMessage
+}
+
+// StreamSources holds the sources an answer is generated from.
+type StreamSources struct {
+	Sources         []QuerySource `json:"sources"`
+	RetrievedChunks int           `json:"retrieved_chunks"`
+	Query           string        `json:"query"`
+	Cached          bool          `json:"cached,omitempty"`
+	Degraded        bool          `json:"degraded,omitempty"`
+	Guardrail       string        `json:"guardrail,omitempty"`
+}
+
+// StreamMetadata is sent once the answer is complete. QueryID identifies the
+// query for SubmitFeedback.
+type StreamMetadata struct {
+	QueryID         uint        `json:"query_id"`
+	RetrievedChunks int         `json:"retrieved_chunks"`
+	SourcesCount    int         `json:"sources_count"`
+	Cached          bool        `json:"cached"`
+	Degraded        bool        `json:"degraded"`
+	Guardrail       string      `json:"guardrail,omitempty"`
+	Usage           *TokenUsage `json:"usage,omitempty"`
+}
+
+// QueryStream reads the events of a streamed query.
+//
+//	for stream.Next() {
+//		fmt.Print(stream.Event().Text)
+//	}
+//	if err := stream.Err(); err != nil { ... }
+type QueryStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	event   StreamEvent
+	err     error
+}
+
+// QueryStream answers a question about a website, streaming the answer as it
+// is generated. The stream must be closed.
+func (c *Client) QueryStream(ctx context.Context, id uint, req QueryRequest) (*QueryStream, error) {
+	httpReq, err := c.newRequest(ctx, http.MethodPost, websitePath(id, "/query/stream"), nil, req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	return &QueryStream{body: resp.Body, scanner: scanner}, nil
+}
+
+// Next reads the next event. It returns false at the end of the stream, after
+// a done or error event, or when reading fails.
+func (s *QueryStream) Next() bool {
+	if s.err != nil || s.event.Type == EventDone || s.event.Type == EventError {
+		return false
+	}
+
+	var event StreamEvent
+	var data strings.Builder
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		switch {
+		case line == "":
+			if event.Type == "" {
+				continue
+			}
+			event.Data = json.RawMessage(data.String())
+			if s.err = decodeEvent(&event); s.err != nil {
+				return false
+			}
+			s.event = event
+			return true
+		case strings.HasPrefix(line, "event:"):
+			event.Type = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	s.err = s.scanner.Err()
+	return false
+}
+
+// decodeEvent decodes the payload of an event into its typed field.
+func decodeEvent(event *StreamEvent) error {
+	var err error
+	switch event.Type {
+	case EventChunk:
+		var chunk struct {
+			Text string `json:"text"`
+		}
+		err = json.Unmarshal(event.Data, &chunk)
+		event.Text = chunk.Text
+	case EventSources:
+		event.Sources = &StreamSources{}
+		err = json.Unmarshal(event.Data, event.Sources)
+	case EventMetadata:
+		event.Metadata = &StreamMetadata{}
+		err = json.Unmarshal(event.Data, event.Metadata)
+	case EventError:
+		event.Err = &Error{StatusCode: http.StatusOK}
+		err = json.Unmarshal(event.Data, event.Err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+	}
+	return nil
+}
+
+// Event returns the event read by Next.
+func (s *QueryStream) Event() StreamEvent {
+	return s.event
+}
+
+// Err returns the error that ended the stream: a read error or the error
+// event of a failed query.
+func (s *QueryStream) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.event.Err != nil {
+		return s.event.Err
+	}
+	return nil
+}
+
+// Close closes the stream.
+func (s *QueryStream) Close() error {
+	return s.body.Close()
+}
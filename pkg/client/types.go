@@ -0,0 +1,363 @@
+package client
+
+import (
+	"database/sql"
+	"time"
+)
+
+// User is a Hermit account.
+type User struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	IsActive     bool      `json:"is_active"`
+	WebsiteLimit int       `json:"website_limit"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// LoginResponse holds the user and the API key created on registration or
+// login. The key is only returned once.
+type LoginResponse struct {
+	User    *User  `json:"user"`
+	APIKey  string `json:"api_key"`
+	Message string `json:"message"`
+}
+
+// APIKey describes an API key without its secret.
+type APIKey struct {
+	ID        string   `json:"id"`
+	UserID    string   `json:"user_id"`
+	KeyPrefix string   `json:"key_prefix"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	IsActive  bool     `json:"is_active"`
+	// Public keys are the chat widget's, restricted to querying WebsiteID
+	// from AllowedOrigins
+	Public          bool       `json:"public,omitempty"`
+	WebsiteID       *uint      `json:"website_id,omitempty"`
+	AllowedOrigins  []string   `json:"allowed_origins,omitempty"`
+	RateLimitPerMin int        `json:"rate_limit_per_min,omitempty"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// CreateAPIKeyRequest creates an API key. Scopes default to full access.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateAPIKeyResponse holds a created API key with its secret, which is
+// only returned once.
+type CreateAPIKeyResponse struct {
+	APIKey   *APIKey `json:"api_key"`
+	PlainKey string  `json:"plain_key"`
+	Message  string  `json:"message"`
+}
+
+// Website is a crawled website. Nullable fields are encoded like their
+// database/sql counterparts.
+type Website struct {
+	ID                uint
+	URL               string
+	UserID            *string
+	IsMonitored       bool
+	CrawlStatus       string
+	CrawlStartedAt    sql.NullTime
+	CrawlCompletedAt  sql.NullTime
+	TotalPagesCrawled int
+	TotalPagesFailed  int
+	LastError         sql.NullString
+	TLSSkipVerify     bool
+	VisualMonitoring  bool
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+
+	MaxCrawlBytes           int64
+	MaxCrawlDurationSeconds int
+	MaxPagesPerPrefix       int
+	BudgetExhausted         sql.NullString
+
+	MinContentQuality float64
+	PIIRedaction      bool
+	RespectRobots     sql.NullBool
+
+	// NoiseRemoval is extend, replace or off, for how NoisePatterns combine
+	// with the server's noise patterns.
+	NoiseRemoval  string
+	NoisePatterns []string
+
+	// CrawlWindowStart and CrawlWindowEnd bound the daily crawl window in
+	// minutes after midnight UTC.
+	CrawlWindowStart sql.NullInt32
+	CrawlWindowEnd   sql.NullInt32
+
+	UserAgent         string
+	UserAgentRotation []string
+	RequestHeaders    map[string]string
+
+	SeedURLs      []string
+	ScopePrefixes []string
+	SitemapURLs   []string
+
+	Summary      sql.NullString
+	SummarizedAt sql.NullTime
+
+	// Vectors and stored objects of the website, refreshed shortly after
+	// crawls and vectorization
+	VectorCount    sql.NullInt64
+	StorageObjects sql.NullInt64
+	StorageBytes   sql.NullInt64
+	IndexStatsAt   sql.NullTime
+}
+
+// CreateWebsiteRequest adds a website and starts crawling it.
+type CreateWebsiteRequest struct {
+	URL              string `json:"url"`
+	TLSSkipVerify    bool   `json:"tls_skip_verify"`
+	VisualMonitoring bool   `json:"visual_monitoring"`
+	// Crawl budgets, 0 uses the server defaults
+	MaxCrawlBytes           int64 `json:"max_crawl_bytes,omitempty"`
+	MaxCrawlDurationSeconds int   `json:"max_crawl_duration_seconds,omitempty"`
+	MaxPagesPerPrefix       int   `json:"max_pages_per_prefix,omitempty"`
+	// Minimum content quality of indexed pages between 0 and 1, 0 uses the
+	// server default
+	MinContentQuality float64 `json:"min_content_quality,omitempty"`
+	// PIIRedaction redacts personal data from pages before they are stored
+	PIIRedaction bool `json:"pii_redaction,omitempty"`
+	// User agent the website is crawled with instead of the server's, user
+	// agents rotated between requests and extra headers sent with them
+	UserAgent         string            `json:"user_agent,omitempty"`
+	UserAgentRotation []string          `json:"user_agent_rotation,omitempty"`
+	RequestHeaders    map[string]string `json:"request_headers,omitempty"`
+	// Start URLs crawled besides URL and the URL prefixes the crawl is
+	// restricted to
+	SeedURLs []string `json:"seed_urls,omitempty"`
+	Scope    []string `json:"scope,omitempty"`
+}
+
+// WebsiteStatus is a website with its crawl progress.
+type WebsiteStatus struct {
+	Website
+	ErrorStatusCodes map[string]int `json:"error_status_codes"`
+	Progress         CrawlProgress  `json:"progress"`
+}
+
+// CrawlProgress aggregates live crawl data of a website.
+type CrawlProgress struct {
+	PagesByStatus         map[string]int `json:"pages_by_status"`
+	PagesProcessed        int            `json:"pages_processed"`
+	PagesLimit            int            `json:"pages_limit,omitempty"`
+	VectorCount           *int           `json:"vector_count,omitempty"`
+	ElapsedSeconds        int            `json:"elapsed_seconds,omitempty"`
+	ETASeconds            *int           `json:"eta_seconds,omitempty"`
+	EstimatedCompletionAt *time.Time     `json:"estimated_completion_at,omitempty"`
+	RecentErrors          []CrawlEvent   `json:"recent_errors"`
+	Unavailable           []string       `json:"unavailable,omitempty"`
+}
+
+// CrawlEvent is an event logged while crawling a website.
+type CrawlEvent struct {
+	ID        uint64    `json:"id"`
+	WebsiteID uint      `json:"website_id"`
+	URL       string    `json:"url"`
+	Level     string    `json:"level"`
+	Event     string    `json:"event"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Page is a crawled page of a website.
+type Page struct {
+	ID             uint
+	WebsiteID      uint
+	URL            string
+	Status         string
+	ErrorMessage   sql.NullString
+	Title          sql.NullString
+	Snippet        sql.NullString
+	HTTPStatus     sql.NullInt32
+	RetryCount     int
+	SkipReason     sql.NullString
+	CrawledAt      sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Summary        sql.NullString
+	SummarizedAt   sql.NullTime
+	Tags           []string
+	Images         []PageImage
+	ScreenshotAt   sql.NullTime
+	HTMLArchivedAt sql.NullTime
+	ContentHash    sql.NullString
+	MinioObjectKey sql.NullString
+	VectorStatus   sql.NullString
+	VectorError    sql.NullString
+	ChunkCount     sql.NullInt32
+	VectorizedAt   sql.NullTime
+}
+
+// PageImage is an image of a page's main content with the text describing
+// it.
+type PageImage struct {
+	Src     string `json:"src"`
+	Alt     string `json:"alt,omitempty"`
+	Caption string `json:"caption,omitempty"`
+	Context string `json:"context,omitempty"`
+}
+
+// PageContentMetadata describes the stored content of a page. The content
+// hash changes whenever the extracted text does.
+type PageContentMetadata struct {
+	ObjectKey         string     `json:"object_key"`
+	ContentType       string     `json:"content_type"`
+	Encoding          string     `json:"encoding"`
+	StoredBytes       int64      `json:"stored_bytes"`
+	LastModified      time.Time  `json:"last_modified"`
+	PageURL           string     `json:"page_url,omitempty"`
+	Title             string     `json:"title,omitempty"`
+	Language          string     `json:"language,omitempty"`
+	ContentHash       string     `json:"content_hash,omitempty"`
+	ExtractedAt       *time.Time `json:"extracted_at,omitempty"`
+	SourceContentType string     `json:"source_content_type,omitempty"`
+}
+
+// SearchHit is a page matching a keyword search. Highlights are HTML escaped
+// passages of the page with the matches wrapped in <mark> tags.
+type SearchHit struct {
+	PageID     uint     `json:"page_id"`
+	URL        string   `json:"url"`
+	Title      string   `json:"title,omitempty"`
+	Rank       float64  `json:"rank"`
+	Highlights []string `json:"highlights"`
+}
+
+// PageListOptions filters and paginates the pages of a website.
+type PageListOptions struct {
+	ListOptions
+	Status       string
+	Reason       string
+	Tag          string
+	VectorStatus string
+}
+
+// Paginated is a page of a list endpoint.
+type Paginated[T any] struct {
+	Data       []T `json:"data"`
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// Enqueued confirms a background job was enqueued.
+type Enqueued struct {
+	Message string `json:"message"`
+	TaskID  string `json:"task_id,omitempty"`
+	Status  string `json:"status,omitempty"`
+	// ScheduledAt is when a deferred job runs, e.g. when a crawl window opens.
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+}
+
+// QueryRequest asks a question about a website.
+type QueryRequest struct {
+	Query string `json:"query,omitempty"`
+	// PreviewID reuses the sources of PreviewSources instead of searching
+	// again; Query may be omitted when it is set
+	PreviewID string `json:"preview_id,omitempty"`
+	// Tags limits retrieval to pages tagged with any of them
+	Tags []string `json:"tags,omitempty"`
+}
+
+// QueryResponse is the answer to a query with the sources it is based on.
+type QueryResponse struct {
+	Answer          string        `json:"answer"`
+	Sources         []QuerySource `json:"sources"`
+	RetrievedChunks int           `json:"retrieved_chunks"`
+	Query           string        `json:"query"`
+	Cached          bool          `json:"cached,omitempty"`
+	// Degraded is set when the answer is made of the retrieved chunks
+	// because the LLM was unavailable
+	Degraded bool `json:"degraded,omitempty"`
+	// Guardrail is set when a guardrail replaced the answer with a refusal:
+	// GuardrailPromptInjection, GuardrailOffTopic or GuardrailModeration
+	Guardrail string `json:"guardrail,omitempty"`
+	QueryID   uint   `json:"query_id,omitempty"`
+	// Usage counts the LLM tokens of the answer, nil for cached answers
+	Usage *TokenUsage `json:"usage,omitempty"`
+}
+
+// TokenUsage counts the tokens the LLM processed for an answer.
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Guardrails refusing questions.
+const (
+	GuardrailPromptInjection = "prompt_injection"
+	GuardrailOffTopic        = "off_topic"
+	GuardrailModeration      = "moderation"
+)
+
+// QuerySource is a chunk of a page an answer is based on.
+type QuerySource struct {
+	PageURL    string  `json:"page_url"`
+	ChunkText  string  `json:"chunk_text"`
+	ChunkIndex int     `json:"chunk_index"`
+	Similarity float32 `json:"similarity"`
+	PageID     uint    `json:"page_id"`
+	PageTitle  string  `json:"page_title,omitempty"`
+	// WebsiteID is set for answers from several websites
+	WebsiteID uint `json:"website_id,omitempty"`
+}
+
+// SourcesPreview holds the sources retrieved for a query before an answer is
+// generated.
+type SourcesPreview struct {
+	PreviewID       string        `json:"preview_id"`
+	Query           string        `json:"query"`
+	Sources         []QuerySource `json:"sources"`
+	RetrievedChunks int           `json:"retrieved_chunks"`
+	ExpiresAt       time.Time     `json:"expires_at"`
+}
+
+// SuggestedQuestions are questions users could ask about a website.
+type SuggestedQuestions struct {
+	Questions   []string  `json:"questions"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// Feedback ratings.
+const (
+	RatingUp   = "up"
+	RatingDown = "down"
+)
+
+// QueryFeedback is the rating of an answer.
+type QueryFeedback struct {
+	ID         uint      `json:"id"`
+	QueryLogID uint      `json:"query_log_id"`
+	UserID     *string   `json:"user_id,omitempty"`
+	Rating     int       `json:"rating"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Health is the health of the server and its dependencies.
+type Health struct {
+	Status    string                   `json:"status"`
+	Timestamp string                   `json:"timestamp"`
+	Services  map[string]ServiceHealth `json:"services"`
+}
+
+// ServiceHealth is the health of a dependency.
+type ServiceHealth struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Latency string `json:"latency,omitempty"`
+}
@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Health returns the health of the server and its dependencies. It does not
+// need an API key.
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var health Health
+	if err := c.do(ctx, http.MethodGet, "/health", nil, nil, &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// CreateWebsite adds a website and starts crawling it.
+func (c *Client) CreateWebsite(ctx context.Context, req CreateWebsiteRequest) (*Website, error) {
+	var website Website
+	if err := c.do(ctx, http.MethodPost, "/websites", nil, req, &website); err != nil {
+		return nil, err
+	}
+	return &website, nil
+}
+
+// ListWebsites returns the websites the current user owns or was granted
+// access to.
+func (c *Client) ListWebsites(ctx context.Context, opts ListOptions) (*Paginated[Website], error) {
+	var resp Paginated[Website]
+	if err := c.do(ctx, http.MethodGet, "/websites", opts.values(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetWebsiteStatus returns a website with its crawl progress.
+func (c *Client) GetWebsiteStatus(ctx context.Context, id uint) (*WebsiteStatus, error) {
+	var status WebsiteStatus
+	if err := c.do(ctx, http.MethodGet, websitePath(id, "/status"), nil, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// ListPages returns the crawled pages of a website.
+func (c *Client) ListPages(ctx context.Context, id uint, opts PageListOptions) (*Paginated[Page], error) {
+	query := opts.values()
+	if opts.Status != "" {
+		query.Set("status", opts.Status)
+	}
+	if opts.Reason != "" {
+		query.Set("reason", opts.Reason)
+	}
+	if opts.Tag != "" {
+		query.Set("tag", opts.Tag)
+	}
+	if opts.VectorStatus != "" {
+		query.Set("vector_status", opts.VectorStatus)
+	}
+
+	var resp Paginated[Page]
+	if err := c.do(ctx, http.MethodGet, websitePath(id, "/pages"), query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Search returns the pages of a website matching a keyword query, best match
+// first, with highlighted passages.
+func (c *Client) Search(ctx context.Context, id uint, q string, opts ListOptions) (*Paginated[SearchHit], error) {
+	query := opts.values()
+	query.Set("q", q)
+
+	var resp Paginated[SearchHit]
+	if err := c.do(ctx, http.MethodGet, websitePath(id, "/search"), query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PageScreenshot returns the PNG screenshot of a page from its last crawl.
+func (c *Client) PageScreenshot(ctx context.Context, id, pageID uint) ([]byte, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, websitePath(id, "/pages/", uintString(pageID), "/screenshot"), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/png")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, decodeError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// PageContentMetadata returns the metadata of the stored content of a page
+// without downloading it.
+func (c *Client) PageContentMetadata(ctx context.Context, id, pageID uint) (*PageContentMetadata, error) {
+	var resp PageContentMetadata
+	if err := c.do(ctx, http.MethodGet, websitePath(id, "/pages/", uintString(pageID), "/metadata"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListCrawlEvents returns the crawl events of a website, newest first.
+func (c *Client) ListCrawlEvents(ctx context.Context, id uint, opts ListOptions) (*Paginated[CrawlEvent], error) {
+	var resp Paginated[CrawlEvent]
+	if err := c.do(ctx, http.MethodGet, websitePath(id, "/crawl-events"), opts.values(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Recrawl enqueues a new crawl of a website.
+func (c *Client) Recrawl(ctx context.Context, id uint) (*Enqueued, error) {
+	var resp Enqueued
+	if err := c.do(ctx, http.MethodPost, websitePath(id, "/recrawl"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Reindex enqueues re-embedding the stored content of a website.
+func (c *Client) Reindex(ctx context.Context, id uint) (*Enqueued, error) {
+	var resp Enqueued
+	if err := c.do(ctx, http.MethodPost, websitePath(id, "/reindex"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RevectorizeFailedPages enqueues vectorizing the pages of a website whose
+// vectorization failed again.
+func (c *Client) RevectorizeFailedPages(ctx context.Context, id uint) (*Enqueued, error) {
+	var resp Enqueued
+	if err := c.do(ctx, http.MethodPost, websitePath(id, "/pages/revectorize"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ReprocessPage enqueues extracting a page again from its archived HTML
+// without fetching it.
+func (c *Client) ReprocessPage(ctx context.Context, id, pageID uint) (*Enqueued, error) {
+	var resp Enqueued
+	if err := c.do(ctx, http.MethodPost, websitePath(id, "/pages/", uintString(pageID), "/reprocess"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Query answers a question about a website.
+func (c *Client) Query(ctx context.Context, id uint, req QueryRequest) (*QueryResponse, error) {
+	var resp QueryResponse
+	if err := c.do(ctx, http.MethodPost, websitePath(id, "/query"), nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// QueryLabel asks a question about the websites with a label, answered from
+// the content of all of them. Sources name their website.
+func (c *Client) QueryLabel(ctx context.Context, label string, req QueryRequest) (*QueryResponse, error) {
+	var resp QueryResponse
+	if err := c.do(ctx, http.MethodPost, "/websites/query", url.Values{"tag": {label}}, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PreviewSources retrieves the sources a query would be answered from. Pass
+// the preview ID in a QueryRequest to answer from them.
+func (c *Client) PreviewSources(ctx context.Context, id uint, req QueryRequest) (*SourcesPreview, error) {
+	var resp SourcesPreview
+	if err := c.do(ctx, http.MethodPost, websitePath(id, "/query/sources"), nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SuggestedQuestions returns questions users could ask about a website.
+func (c *Client) SuggestedQuestions(ctx context.Context, id uint) (*SuggestedQuestions, error) {
+	var resp SuggestedQuestions
+	if err := c.do(ctx, http.MethodGet, websitePath(id, "/suggested-questions"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SubmitFeedback rates the answer to a query, RatingUp or RatingDown.
+func (c *Client) SubmitFeedback(ctx context.Context, id, queryID uint, rating, comment string) (*QueryFeedback, error) {
+	body := map[string]string{"rating": rating, "comment": comment}
+
+	var resp QueryFeedback
+	path := websitePath(id, "/queries/", uintString(queryID), "/feedback")
+	if err := c.do(ctx, http.MethodPost, path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
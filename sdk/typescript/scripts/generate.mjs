@@ -0,0 +1,159 @@
+// Generates src/generated.ts from the OpenAPI (Swagger 2.0) spec of the API:
+// an interface per definition and a method per operation.
+//
+//   node scripts/generate.mjs [path/to/swagger.json]
+//
+// Operations producing event streams are skipped; the hand-written client in
+// src/index.ts streams queries.
+import { readFileSync, writeFileSync } from "node:fs";
+import { dirname, resolve } from "node:path";
+import { fileURLToPath } from "node:url";
+
+const root = resolve(dirname(fileURLToPath(import.meta.url)), "..");
+const specPath = process.argv[2] ?? resolve(root, "../../internal/docs/swagger.json");
+const spec = JSON.parse(readFileSync(specPath, "utf8"));
+
+// typeName strips the Go package of a definition name: schema.Website -> Website.
+const typeName = (ref) => {
+  const name = ref.replace("#/definitions/", "");
+  const short = name.split(".").pop();
+  return short.charAt(0).toUpperCase() + short.slice(1);
+};
+
+// comment flattens a description into a single line doc comment.
+const comment = (text) => text.replace(/\s+/g, " ").replace(/\*\//g, "*\\/");
+
+const isIdentifier = (name) => /^[A-Za-z_$][\w$]*$/.test(name);
+const propName = (name) => (isIdentifier(name) ? name : JSON.stringify(name));
+
+// tsType converts a schema to a TypeScript type.
+function tsType(schema, indent = "") {
+  if (!schema) return "unknown";
+  if (schema.$ref) return typeName(schema.$ref);
+  if (schema.allOf) return allOfType(schema.allOf, indent);
+  if (schema.enum) return schema.enum.map((v) => JSON.stringify(v)).join(" | ");
+
+  switch (schema.type) {
+    case "string":
+      return "string";
+    case "integer":
+    case "number":
+      return "number";
+    case "boolean":
+      return "boolean";
+    case "array": {
+      const item = tsType(schema.items, indent);
+      return /^[\w.]+$/.test(item) ? `${item}[]` : `Array<${item}>`;
+    }
+    case "object":
+    case undefined:
+      if (schema.properties) return objectType(schema, indent);
+      if (schema.additionalProperties && schema.additionalProperties !== true) {
+        return `Record<string, ${tsType(schema.additionalProperties, indent)}>`;
+      }
+      return "Record<string, unknown>";
+    default:
+      return "unknown";
+  }
+}
+
+// allOfType merges schemas, the properties of later ones replacing those of
+// earlier ones, as swag emits for responses like PaginatedResponse{data=[]X}.
+function allOfType(schemas, indent) {
+  return schemas
+    .map((schema, i) => {
+      const type = tsType(schema, indent);
+      const replaced = schemas.slice(i + 1).flatMap((s) => Object.keys(s.properties ?? {}));
+      return replaced.length ? `Omit<${type}, ${replaced.map((k) => JSON.stringify(k)).join(" | ")}>` : type;
+    })
+    .join(" & ");
+}
+
+function objectType(schema, indent) {
+  const required = new Set(schema.required ?? []);
+  const lines = Object.entries(schema.properties).map(([name, prop]) => {
+    const doc = prop.description ? `${indent}  /** ${comment(prop.description)} */\n` : "";
+    const optional = required.has(name) ? "" : "?";
+    return `${doc}${indent}  ${propName(name)}${optional}: ${tsType(prop, indent + "  ")};`;
+  });
+  return `{\n${lines.join("\n")}\n${indent}}`;
+}
+
+function definition(name, schema) {
+  const doc = schema.description ? `/** ${comment(schema.description)} */\n` : "";
+  if (schema.properties) {
+    return `${doc}export interface ${typeName(name)} ${objectType(schema, "")}\n`;
+  }
+  return `${doc}export type ${typeName(name)} = ${tsType(schema)};\n`;
+}
+
+// successSchema returns the schema of the first 2xx response of an operation.
+function successSchema(op) {
+  const code = Object.keys(op.responses ?? {}).find((c) => c.startsWith("2"));
+  return code ? op.responses[code].schema : undefined;
+}
+
+function method(path, verb, op) {
+  const params = op.parameters ?? [];
+  const pathParams = params.filter((p) => p.in === "path");
+  const queryParams = params.filter((p) => p.in === "query");
+  const body = params.find((p) => p.in === "body");
+
+  const args = pathParams.map((p) => `${p.name}: ${tsType(p)}`);
+  if (body) args.push(`body: ${tsType(body.schema)}`);
+  if (queryParams.length) {
+    const fields = queryParams.map((p) => `${propName(p.name)}?: ${tsType(p)}`).join("; ");
+    args.push(`query: { ${fields} } = {}`);
+  }
+
+  const url = spec.basePath + path.replace(/\{(\w+)\}/g, (_, name) => `\${encodeURIComponent(String(${name}))}`);
+  // Files such as screenshots are returned as Blobs instead of parsed JSON
+  const file = successSchema(op)?.type === "file";
+  const result = file ? "Blob" : successSchema(op) ? tsType(successSchema(op), "  ") : "void";
+  const options = [];
+  if (queryParams.length) options.push("query");
+  if (body) options.push("body");
+
+  const docs = [op.summary, op.description].filter(Boolean).map((line) => `   * ${comment(line)}`);
+  return [
+    "  /**",
+    ...docs,
+    `   * ${verb.toUpperCase()} ${spec.basePath}${path}`,
+    "   */",
+    `  ${op.operationId}(${args.join(", ")}): Promise<${result}> {`,
+    file
+      ? `    return this.requestFile("${verb.toUpperCase()}", \`${url}\`, { ${options.join(", ")} }, "${(op.produces ?? ["*/*"])[0]}");`
+      : `    return this.request("${verb.toUpperCase()}", \`${url}\`, { ${options.join(", ")} });`,
+    "  }",
+  ].join("\n");
+}
+
+const out = [
+  "// Code generated by scripts/generate.mjs from the OpenAPI spec. DO NOT EDIT.",
+  "",
+  'import { BaseClient } from "./runtime.js";',
+  "",
+];
+
+for (const [name, schema] of Object.entries(spec.definitions ?? {}).sort(([a], [b]) => typeName(a).localeCompare(typeName(b)))) {
+  out.push(definition(name, schema));
+}
+
+const methods = [];
+for (const [path, verbs] of Object.entries(spec.paths)) {
+  for (const [verb, op] of Object.entries(verbs)) {
+    if ((op.produces ?? []).includes("text/event-stream")) continue;
+    methods.push(method(path, verb, op));
+  }
+}
+
+out.push(
+  "/** Methods for every operation of the Hermit API. */",
+  "export class GeneratedClient extends BaseClient {",
+  methods.join("\n\n"),
+  "}",
+  "",
+);
+
+writeFileSync(resolve(root, "src/generated.ts"), out.join("\n"));
+console.log(`Generated ${methods.length} operations and ${Object.keys(spec.definitions ?? {}).length} types`);
@@ -0,0 +1,71 @@
+import { GeneratedClient } from "./generated.js";
+import type { QueryRequest, QuerySource } from "./generated.js";
+import { HermitError } from "./runtime.js";
+
+export * from "./generated.js";
+export { HermitError } from "./runtime.js";
+export type { ClientOptions, RequestOptions } from "./runtime.js";
+
+/** Events of a streamed query, in the order they are sent. */
+export type StreamEvent =
+  | { type: "start"; query: string }
+  | { type: "sources"; sources: QuerySource[]; retrieved_chunks: number; query: string; cached?: boolean; degraded?: boolean }
+  | { type: "chunk"; text: string }
+  | { type: "metadata"; query_id: number; retrieved_chunks: number; sources_count: number; cached: boolean; degraded: boolean }
+  | { type: "done"; status: string };
+
+/**
+ * Client for the Hermit API.
+ *
+ * ```ts
+ * const hermit = new HermitClient({ baseUrl: "http://localhost:8080", apiKey: "hmt_..." });
+ * for await (const event of hermit.queryStream(1, { query: "What is this website about?" })) {
+ *   if (event.type === "chunk") process.stdout.write(event.text);
+ * }
+ * ```
+ */
+export class HermitClient extends GeneratedClient {
+  /**
+   * Answers a question about a website, streaming the answer as it is
+   * generated. A failed query throws a HermitError.
+   */
+  async *queryStream(id: number, body: QueryRequest, signal?: AbortSignal): AsyncGenerator<StreamEvent> {
+    const response = await this.send("POST", `/api/v1/websites/${encodeURIComponent(String(id))}/query/stream`, { body, signal }, "text/event-stream");
+    if (!response.body) return;
+
+    const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+    let buffer = "";
+    try {
+      for (;;) {
+        const { value, done } = await reader.read();
+        if (done) return;
+        buffer += value;
+
+        let end: number;
+        while ((end = buffer.indexOf("\n\n")) >= 0) {
+          const event = parseEvent(buffer.slice(0, end));
+          buffer = buffer.slice(end + 2);
+          if (!event) continue;
+          if (event.type === "error") {
+            throw new HermitError(200, event.data);
+          }
+          yield { type: event.type, ...event.data } as StreamEvent;
+          if (event.type === "done") return;
+        }
+      }
+    } finally {
+      reader.releaseLock();
+    }
+  }
+}
+
+function parseEvent(block: string): { type: string; data: Record<string, unknown> } | undefined {
+  let type = "";
+  const data: string[] = [];
+  for (const line of block.split("\n")) {
+    if (line.startsWith("event:")) type = line.slice(6).trim();
+    else if (line.startsWith("data:")) data.push(line.slice(5).replace(/^ /, ""));
+  }
+  if (!type) return undefined;
+  return { type, data: data.length ? JSON.parse(data.join("\n")) : {} };
+}
@@ -0,0 +1,89 @@
+export interface ClientOptions {
+  /** URL of the server, e.g. http://localhost:8080 */
+  baseUrl: string;
+  /** API key sent as a bearer token */
+  apiKey?: string;
+  /** fetch implementation, defaults to the global fetch */
+  fetch?: typeof fetch;
+  /** Headers sent with every request */
+  headers?: Record<string, string>;
+}
+
+export interface RequestOptions {
+  query?: Record<string, string | number | boolean | undefined>;
+  body?: unknown;
+  signal?: AbortSignal;
+}
+
+/** An error response of the API. */
+export class HermitError extends Error {
+  readonly status: number;
+  readonly code: string;
+  readonly requestId?: string;
+  readonly details?: Record<string, unknown>;
+  /** Seconds to wait before retrying rate limited requests */
+  readonly retryAfter?: number;
+
+  constructor(status: number, body: { error?: string; code?: string; request_id?: string; details?: Record<string, unknown> }, retryAfter?: number) {
+    super(body.error ?? `Request failed with status ${status}`);
+    this.name = "HermitError";
+    this.status = status;
+    this.code = body.code ?? "";
+    this.requestId = body.request_id;
+    this.details = body.details;
+    this.retryAfter = retryAfter;
+  }
+}
+
+/** Sends requests to the API and decodes responses and errors. */
+export class BaseClient {
+  protected readonly options: ClientOptions;
+
+  constructor(options: ClientOptions) {
+    this.options = { ...options, baseUrl: options.baseUrl.replace(/\/+$/, "") };
+  }
+
+  /** Sends a request and returns the raw response, throwing HermitError on errors. */
+  protected async send(method: string, path: string, options: RequestOptions = {}, accept = "application/json"): Promise<Response> {
+    const url = new URL(this.options.baseUrl + path);
+    for (const [key, value] of Object.entries(options.query ?? {})) {
+      if (value !== undefined) url.searchParams.set(key, String(value));
+    }
+
+    const headers: Record<string, string> = { Accept: accept, ...this.options.headers };
+    if (this.options.apiKey) headers.Authorization = `Bearer ${this.options.apiKey}`;
+    if (options.body !== undefined) headers["Content-Type"] = "application/json";
+
+    const doFetch = this.options.fetch ?? fetch;
+    const response = await doFetch(url, {
+      method,
+      headers,
+      body: options.body === undefined ? undefined : JSON.stringify(options.body),
+      signal: options.signal,
+    });
+
+    if (!response.ok) {
+      const text = await response.text();
+      let body: { error?: string; code?: string } = {};
+      try {
+        body = JSON.parse(text);
+      } catch {
+        body = { error: text || response.statusText };
+      }
+      const retryAfter = Number(response.headers.get("Retry-After")) || undefined;
+      throw new HermitError(response.status, body, retryAfter);
+    }
+    return response;
+  }
+
+  protected async request<T>(method: string, path: string, options: RequestOptions = {}): Promise<T> {
+    const response = await this.send(method, path, options);
+    const text = await response.text();
+    return (text ? JSON.parse(text) : undefined) as T;
+  }
+
+  protected async requestFile(method: string, path: string, options: RequestOptions = {}, accept = "*/*"): Promise<Blob> {
+    const response = await this.send(method, path, options, accept);
+    return response.blob();
+  }
+}
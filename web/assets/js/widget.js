@@ -0,0 +1,176 @@
+/*
+ * Hermit chat widget. Add it to a page with the snippet returned when
+ * creating a widget key:
+ *
+ *   <script src="https://hermit.example.com/widget.js" data-key="hmt_pub_..." async></script>
+ *
+ * Optional attributes: data-title (header text), data-placeholder (input
+ * placeholder) and data-color (accent color).
+ */
+(function () {
+  "use strict";
+
+  var script = document.currentScript;
+  if (!script || !script.dataset.key) {
+    console.error("Hermit widget: missing data-key attribute");
+    return;
+  }
+
+  var apiBase = new URL(script.src).origin + "/api/v1/widget";
+  var key = script.dataset.key;
+  var title = script.dataset.title || "Ask our docs";
+  var placeholder = script.dataset.placeholder || "Ask a question...";
+  var color = script.dataset.color || "#4f46e5";
+
+  function request(method, path, body) {
+    return fetch(apiBase + path, {
+      method: method,
+      headers: { "Authorization": "Bearer " + key, "Content-Type": "application/json" },
+      body: body ? JSON.stringify(body) : undefined,
+    }).then(function (resp) {
+      return resp.json().then(function (data) {
+        if (!resp.ok) {
+          throw new Error((data && data.error) || "Request failed");
+        }
+        return data;
+      });
+    });
+  }
+
+  function el(tag, className, text) {
+    var node = document.createElement(tag);
+    if (className) node.className = className;
+    if (text) node.textContent = text;
+    return node;
+  }
+
+  var host = el("div");
+  host.id = "hermit-widget";
+  var root = host.attachShadow({ mode: "open" });
+
+  var style = el("style");
+  style.textContent = [
+    ":host { all: initial; font-family: system-ui, sans-serif; font-size: 14px; }",
+    ".bubble { position: fixed; right: 20px; bottom: 20px; width: 56px; height: 56px; border-radius: 50%; border: none; background: " + color + "; color: #fff; font-size: 24px; cursor: pointer; box-shadow: 0 4px 12px rgba(0,0,0,.25); z-index: 2147483646; }",
+    ".panel { position: fixed; right: 20px; bottom: 88px; width: 360px; max-width: calc(100vw - 40px); height: 480px; max-height: calc(100vh - 120px); display: none; flex-direction: column; background: #fff; color: #111; border-radius: 12px; box-shadow: 0 8px 24px rgba(0,0,0,.2); overflow: hidden; z-index: 2147483647; }",
+    ".panel.open { display: flex; }",
+    ".header { padding: 12px 16px; background: " + color + "; color: #fff; font-weight: 600; }",
+    ".messages { flex: 1; overflow-y: auto; padding: 12px; display: flex; flex-direction: column; gap: 8px; }",
+    ".message { padding: 8px 12px; border-radius: 8px; line-height: 1.4; white-space: pre-wrap; word-wrap: break-word; max-width: 85%; }",
+    ".user { align-self: flex-end; background: " + color + "; color: #fff; }",
+    ".assistant { align-self: flex-start; background: #f3f4f6; }",
+    ".error { align-self: flex-start; background: #fef2f2; color: #b91c1c; }",
+    ".sources { margin-top: 6px; font-size: 12px; }",
+    ".sources a { display: block; color: " + color + "; }",
+    ".suggestion { align-self: flex-start; border: 1px solid #e5e7eb; background: #fff; border-radius: 16px; padding: 6px 10px; cursor: pointer; font: inherit; }",
+    "form { display: flex; border-top: 1px solid #e5e7eb; }",
+    "input { flex: 1; border: none; padding: 12px; font: inherit; outline: none; }",
+    "button.send { border: none; background: none; color: " + color + "; padding: 0 16px; font: inherit; font-weight: 600; cursor: pointer; }",
+  ].join("\n");
+
+  var bubble = el("button", "bubble", "?");
+  bubble.setAttribute("aria-label", title);
+  var panel = el("div", "panel");
+  var messages = el("div", "messages");
+  var form = el("form");
+  var input = el("input");
+  input.placeholder = placeholder;
+  input.maxLength = 1000;
+  var send = el("button", "send", "Send");
+  send.type = "submit";
+
+  form.appendChild(input);
+  form.appendChild(send);
+  panel.appendChild(el("div", "header", title));
+  panel.appendChild(messages);
+  panel.appendChild(form);
+  root.appendChild(style);
+  root.appendChild(panel);
+  root.appendChild(bubble);
+
+  function addMessage(className, text) {
+    var message = el("div", "message " + className, text);
+    messages.appendChild(message);
+    messages.scrollTop = messages.scrollHeight;
+    return message;
+  }
+
+  function addSources(message, sources) {
+    var seen = {};
+    var list = el("div", "sources");
+    (sources || []).forEach(function (source) {
+      if (seen[source.page_url] || Object.keys(seen).length === 5) return;
+      seen[source.page_url] = true;
+      var link = el("a", "", source.page_title || source.page_url);
+      link.href = source.page_url;
+      link.target = "_blank";
+      link.rel = "noopener";
+      list.appendChild(link);
+    });
+    if (list.childNodes.length) message.appendChild(list);
+  }
+
+  function ask(question) {
+    question = question.trim();
+    if (!question || input.disabled) return;
+    root.querySelectorAll(".suggestion").forEach(function (node) { node.remove(); });
+    addMessage("user", question);
+    var pending = addMessage("assistant", "...");
+    input.value = "";
+    input.disabled = send.disabled = true;
+
+    request("POST", "/query", { query: question })
+      .then(function (data) {
+        pending.textContent = data.answer;
+        addSources(pending, data.sources);
+      })
+      .catch(function (err) {
+        pending.className = "message error";
+        pending.textContent = err.message;
+      })
+      .then(function () {
+        input.disabled = send.disabled = false;
+        input.focus();
+        messages.scrollTop = messages.scrollHeight;
+      });
+  }
+
+  var suggested = false;
+  function loadSuggestions() {
+    if (suggested) return;
+    suggested = true;
+    request("GET", "/suggested-questions")
+      .then(function (data) {
+        (data.questions || []).slice(0, 3).forEach(function (question) {
+          var suggestion = el("button", "suggestion", question);
+          suggestion.type = "button";
+          suggestion.addEventListener("click", function () { ask(question); });
+          messages.appendChild(suggestion);
+        });
+      })
+      .catch(function () {});
+  }
+
+  bubble.addEventListener("click", function () {
+    var open = panel.classList.toggle("open");
+    bubble.textContent = open ? "×" : "?";
+    if (open) {
+      loadSuggestions();
+      input.focus();
+    }
+  });
+
+  form.addEventListener("submit", function (event) {
+    event.preventDefault();
+    ask(input.value);
+  });
+
+  function mount() {
+    document.body.appendChild(host);
+  }
+  if (document.body) {
+    mount();
+  } else {
+    document.addEventListener("DOMContentLoaded", mount);
+  }
+})();
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"hermit/internal/apperrors"
+	"hermit/internal/llm"
+	"hermit/internal/schema"
+	"hermit/internal/service"
+
+	"github.com/coder/websocket"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// maxChatHistory is the number of previous messages per website sent to the LLM.
+const maxChatHistory = 10
+
+// chatClientMessage is a message sent by the browser over the chat socket.
+type chatClientMessage struct {
+	Type      string `json:"type"` // "message" or "reset"
+	WebsiteID uint   `json:"website_id"`
+	Content   string `json:"content"`
+}
+
+// chatServerMessage is a message sent to the browser over the chat socket.
+type chatServerMessage struct {
+	Type            string            `json:"type"` // "sources", "chunk", "done", "reset" or "error"
+	Content         string            `json:"content,omitempty"`
+	Sources         []llm.QuerySource `json:"sources,omitempty"`
+	RetrievedChunks int               `json:"retrieved_chunks,omitempty"`
+	Degraded        bool              `json:"degraded,omitempty"`
+	Message         string            `json:"message,omitempty"`
+}
+
+// chatSession holds the conversation state of a single socket connection,
+// with one conversation per website.
+type chatSession struct {
+	user    *schema.User
+	history *llm.ChatHistories
+}
+
+// HandleChatSocket upgrades the connection to a WebSocket and answers chat
+// messages about the user's websites with streaming RAG responses.
+func (h *Handlers) HandleChatSocket(c echo.Context) error {
+	user, err := h.getUserFromSession(c)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+
+	socket, err := websocket.Accept(c.Response().Writer, c.Request(), nil)
+	if err != nil {
+		h.logger.Error("Could not open chat websocket", zap.Error(err))
+		return nil
+	}
+	defer socket.CloseNow()
+
+	ctx := c.Request().Context()
+	session := &chatSession{
+		user:    user,
+		history: llm.NewChatHistories(maxChatHistory, 0),
+	}
+
+	for {
+		_, data, err := socket.Read(ctx)
+		if err != nil {
+			if websocket.CloseStatus(err) != websocket.StatusNormalClosure && !errors.Is(err, context.Canceled) {
+				h.logger.Debug("Chat websocket closed", zap.Error(err))
+			}
+			return nil
+		}
+
+		var msg chatClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			h.writeChatMessage(ctx, socket, chatServerMessage{Type: "error", Message: "Invalid message"})
+			continue
+		}
+
+		switch msg.Type {
+		case "reset":
+			session.history.Reset(strconv.FormatUint(uint64(msg.WebsiteID), 10))
+			h.writeChatMessage(ctx, socket, chatServerMessage{Type: "reset"})
+		case "message":
+			if err := h.answerChatMessage(ctx, socket, session, msg); err != nil {
+				return nil
+			}
+		default:
+			h.writeChatMessage(ctx, socket, chatServerMessage{Type: "error", Message: "Unknown message type"})
+		}
+	}
+}
+
+// answerChatMessage streams the answer to a single chat message. It only
+// returns an error when the socket can no longer be written to.
+func (h *Handlers) answerChatMessage(ctx context.Context, socket *websocket.Conn, session *chatSession, msg chatClientMessage) error {
+	question := strings.TrimSpace(msg.Content)
+	if question == "" {
+		return h.writeChatMessage(ctx, socket, chatServerMessage{Type: "error", Message: "Message cannot be empty"})
+	}
+
+	website, err := h.websites.Get(ctx, service.Actor{User: session.user}, msg.WebsiteID, schema.WebsitePermissionQuery)
+	if err != nil {
+		message := "Website not found"
+		if appErr, ok := apperrors.As(err); ok && appErr.Code != apperrors.CodeInternal {
+			message = appErr.Message
+		}
+		return h.writeChatMessage(ctx, socket, chatServerMessage{Type: "error", Message: message})
+	}
+
+	conversation := strconv.FormatUint(uint64(website.ID), 10)
+	var answer strings.Builder
+	meta, err := h.queries.Chat(ctx, website, question, session.history.Get(conversation),
+		func(meta *llm.QueryStreamMeta) error {
+			return h.writeChatMessage(ctx, socket, chatServerMessage{
+				Type:            "sources",
+				Sources:         meta.Sources,
+				RetrievedChunks: meta.RetrievedChunks,
+			})
+		},
+		func(chunk string) error {
+			answer.WriteString(chunk)
+			return h.writeChatMessage(ctx, socket, chatServerMessage{Type: "chunk", Content: chunk})
+		},
+	)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		h.logger.Error("Failed to answer chat message", zap.Uint("websiteID", website.ID), zap.Error(err))
+		return h.writeChatMessage(ctx, socket, chatServerMessage{Type: "error", Message: "Failed to process message"})
+	}
+
+	session.history.Record(conversation, question, answer.String())
+
+	return h.writeChatMessage(ctx, socket, chatServerMessage{Type: "done", Degraded: meta.Degraded})
+}
+
+// writeChatMessage sends a JSON-encoded message over the socket.
+func (h *Handlers) writeChatMessage(ctx context.Context, socket *websocket.Conn, msg chatServerMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return socket.Write(ctx, websocket.MessageText, data)
+}
@@ -1,58 +1,69 @@
 package web
 
 import (
+	"context"
 	"net/http"
-	"time"
+	"strconv"
 
+	"hermit/internal/apperrors"
 	"hermit/internal/auth"
-	"hermit/internal/repositories"
 	"hermit/internal/schema"
+	"hermit/internal/service"
 
 	"github.com/labstack/echo/v4"
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
 )
 
-const (
-	sessionCookieName = "hermit_session"
-	sessionMaxAge     = 7 * 24 * 60 * 60 // 7 days
-)
+const sessionCookieName = "hermit_session"
+
+// APIKeyStore lists the API keys of users, it is implemented by
+// repositories.APIKeyRepository.
+type APIKeyStore interface {
+	GetByUserID(ctx context.Context, userID ulid.ULID) ([]*schema.APIKey, error)
+}
 
 // Handlers holds all dependencies for web handlers
 type Handlers struct {
 	authService *auth.Service
-	websiteRepo *repositories.WebsiteRepository
-	apiKeyRepo  *repositories.APIKeyRepository
-	userRepo    *repositories.UserRepository
+	apiKeyRepo  APIKeyStore
+	websites    *service.WebsiteService
+	queries     *service.QueryService
+	logger      *zap.Logger
 }
 
 // NewHandlers creates a new web handlers instance
 func NewHandlers(
 	authService *auth.Service,
-	websiteRepo *repositories.WebsiteRepository,
-	apiKeyRepo *repositories.APIKeyRepository,
-	userRepo *repositories.UserRepository,
+	apiKeyRepo APIKeyStore,
+	websites *service.WebsiteService,
+	queries *service.QueryService,
+	logger *zap.Logger,
 ) *Handlers {
 	return &Handlers{
 		authService: authService,
-		websiteRepo: websiteRepo,
 		apiKeyRepo:  apiKeyRepo,
-		userRepo:    userRepo,
+		websites:    websites,
+		queries:     queries,
+		logger:      logger,
 	}
 }
 
 // getUserFromSession extracts user from session cookie
 func (h *Handlers) getUserFromSession(c echo.Context) (*schema.User, error) {
+	user, _, err := h.getSession(c)
+	return user, err
+}
+
+// getSession extracts user and the session's API key from session cookie
+func (h *Handlers) getSession(c echo.Context) (*schema.User, *schema.APIKey, error) {
 	cookie, err := c.Cookie(sessionCookieName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Validate API key from cookie
-	user, _, err := h.authService.ValidateAPIKey(cookie.Value)
-	if err != nil {
-		return nil, err
-	}
-
-	return user, nil
+	return h.authService.ValidateAPIKey(c.Request().Context(), cookie.Value)
 }
 
 // setSessionCookie sets a session cookie with the API key
@@ -61,7 +72,7 @@ func (h *Handlers) setSessionCookie(c echo.Context, apiKey string) {
 		Name:     sessionCookieName,
 		Value:    apiKey,
 		Path:     "/",
-		MaxAge:   sessionMaxAge,
+		MaxAge:   int(auth.SessionTTL.Seconds()),
 		HttpOnly: true,
 		Secure:   false, // Set to true in production with HTTPS
 		SameSite: http.SameSiteLaxMode,
@@ -109,18 +120,16 @@ func (h *Handlers) HandleLogin(c echo.Context) error {
 	}
 
 	// Login user
-	user, err := h.authService.Login(email, password)
+	user, err := h.authService.Login(c.Request().Context(), email, password, c.RealIP(), c.Request().UserAgent())
+	if apperrors.Is(err, apperrors.CodeQuotaExceeded) {
+		return c.HTML(http.StatusTooManyRequests, `<div class="bg-red-900/50 border border-red-800 rounded-lg p-4 text-red-200 text-sm">Too many failed login attempts, please try again later</div>`)
+	}
 	if err != nil {
 		return c.HTML(http.StatusUnauthorized, `<div class="bg-red-900/50 border border-red-800 rounded-lg p-4 text-red-200 text-sm">Invalid email or password</div>`)
 	}
 
 	// Create session API key
-	_, plainKey, err := h.authService.CreateAPIKey(
-		user.ID,
-		"Web Session - "+time.Now().Format("2006-01-02 15:04:05"),
-		[]string{"*"},
-		nil,
-	)
+	_, plainKey, err := h.authService.CreateSession(c.Request().Context(), user.ID, c.RealIP(), c.Request().UserAgent())
 	if err != nil {
 		return c.HTML(http.StatusInternalServerError, `<div class="bg-red-900/50 border border-red-800 rounded-lg p-4 text-red-200 text-sm">Login successful but failed to create session</div>`)
 	}
@@ -152,18 +161,13 @@ func (h *Handlers) HandleRegister(c echo.Context) error {
 	}
 
 	// Register user
-	user, err := h.authService.Register(email, password)
+	user, err := h.authService.Register(c.Request().Context(), email, password)
 	if err != nil {
 		return c.HTML(http.StatusBadRequest, `<div class="bg-red-900/50 border border-red-800 rounded-lg p-4 text-red-200 text-sm">Registration failed: `+err.Error()+`</div>`)
 	}
 
 	// Create session API key
-	_, plainKey, err := h.authService.CreateAPIKey(
-		user.ID,
-		"Web Session - "+time.Now().Format("2006-01-02 15:04:05"),
-		[]string{"*"},
-		nil,
-	)
+	_, plainKey, err := h.authService.CreateSession(c.Request().Context(), user.ID, c.RealIP(), c.Request().UserAgent())
 	if err != nil {
 		return c.HTML(http.StatusInternalServerError, `<div class="bg-red-900/50 border border-red-800 rounded-lg p-4 text-red-200 text-sm">Registration successful but failed to create session</div>`)
 	}
@@ -178,6 +182,11 @@ func (h *Handlers) HandleRegister(c echo.Context) error {
 
 // HandleLogout logs out the user
 func (h *Handlers) HandleLogout(c echo.Context) error {
+	if user, session, err := h.getSession(c); err == nil {
+		if err := h.authService.RevokeSession(c.Request().Context(), session.ID, user.ID); err != nil {
+			h.logger.Warn("Failed to revoke session on logout", zap.Error(err))
+		}
+	}
 	h.clearSessionCookie(c)
 	c.Response().Header().Set("HX-Redirect", "/login")
 	return c.NoContent(http.StatusOK)
@@ -190,8 +199,44 @@ func (h *Handlers) ShowChat(c echo.Context) error {
 		return c.Redirect(http.StatusFound, "/login")
 	}
 
-	_ = user // Use user if needed
-	return Chat().Render(c.Request().Context(), c.Response().Writer)
+	// Offer the user's own websites and those shared with the user
+	websites, _, err := h.websites.ListAccessible(c.Request().Context(), service.Actor{User: user}, "", 0, 0)
+	if err != nil {
+		websites = []schema.Website{}
+	}
+
+	return Chat(websites).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// ShowChatSuggestions returns the suggested questions of a website as JSON
+// for the chat's empty state
+func (h *Handlers) ShowChatSuggestions(c echo.Context) error {
+	user, err := h.getUserFromSession(c)
+	if err != nil {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	websiteID, err := strconv.ParseUint(c.QueryParam("website_id"), 10, 32)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	website, err := h.websites.Get(c.Request().Context(), service.Actor{User: user}, uint(websiteID), schema.WebsitePermissionQuery)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if appErr, ok := apperrors.As(err); ok {
+			status = appErr.Code.Status()
+		}
+		return c.NoContent(status)
+	}
+
+	suggested, err := h.queries.Suggest(c.Request().Context(), website)
+	if err != nil {
+		h.logger.Warn("Failed to suggest questions", zap.Uint("websiteID", website.ID), zap.Error(err))
+		return c.NoContent(http.StatusServiceUnavailable)
+	}
+
+	return c.JSON(http.StatusOK, suggested)
 }
 
 // ShowWebsites displays the website management page
@@ -201,21 +246,12 @@ func (h *Handlers) ShowWebsites(c echo.Context) error {
 		return c.Redirect(http.StatusFound, "/login")
 	}
 
-	// Fetch all websites (TODO: filter by user_id when implemented in repo)
-	websites, err := h.websiteRepo.List(c.Request().Context())
+	websites, _, err := h.websites.ListAccessible(c.Request().Context(), service.Actor{User: user}, "", 0, 0)
 	if err != nil {
 		websites = []schema.Website{}
 	}
 
-	// Filter by user ID
-	userWebsites := []schema.Website{}
-	for _, w := range websites {
-		if w.UserID != nil && *w.UserID == user.ID {
-			userWebsites = append(userWebsites, w)
-		}
-	}
-
-	return Websites(userWebsites).Render(c.Request().Context(), c.Response().Writer)
+	return Websites(websites).Render(c.Request().Context(), c.Response().Writer)
 }
 
 // ShowAPIKeys displays the API key management page
@@ -231,15 +267,82 @@ func (h *Handlers) ShowAPIKeys(c echo.Context) error {
 		return APIKeys([]schema.APIKey{}).Render(c.Request().Context(), c.Response().Writer)
 	}
 
-	// Convert []*schema.APIKey to []schema.APIKey
-	keys := make([]schema.APIKey, len(keysPtr))
-	for i, k := range keysPtr {
-		keys[i] = *k
+	// Convert []*schema.APIKey to []schema.APIKey, web sessions are listed
+	// on the sessions page
+	keys := make([]schema.APIKey, 0, len(keysPtr))
+	for _, k := range keysPtr {
+		if !k.Session {
+			keys = append(keys, *k)
+		}
 	}
 
 	return APIKeys(keys).Render(c.Request().Context(), c.Response().Writer)
 }
 
+// ShowSessions displays the user's active web sessions
+func (h *Handlers) ShowSessions(c echo.Context) error {
+	user, current, err := h.getSession(c)
+	if err != nil {
+		return c.Redirect(http.StatusFound, "/login")
+	}
+
+	sessions, err := h.authService.GetUserSessions(c.Request().Context(), user.ID)
+	if err != nil {
+		h.logger.Warn("Failed to list sessions", zap.Error(err))
+		sessions = []*schema.APIKey{}
+	}
+
+	responses := make([]*schema.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, session.ToSessionResponse(session.ID == current.ID))
+	}
+
+	return Sessions(responses).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// HandleRevokeSession signs out one of the user's web sessions
+func (h *Handlers) HandleRevokeSession(c echo.Context) error {
+	user, current, err := h.getSession(c)
+	if err != nil {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	sessionID, err := ulid.Parse(c.Param("id"))
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if err := h.authService.RevokeSession(c.Request().Context(), sessionID, user.ID); err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if sessionID == current.ID {
+		h.clearSessionCookie(c)
+		c.Response().Header().Set("HX-Redirect", "/login")
+		return c.NoContent(http.StatusOK)
+	}
+
+	c.Response().Header().Set("HX-Redirect", "/sessions")
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleRevokeOtherSessions signs out all of the user's web sessions but the
+// current one
+func (h *Handlers) HandleRevokeOtherSessions(c echo.Context) error {
+	user, current, err := h.getSession(c)
+	if err != nil {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	if _, err := h.authService.RevokeSessions(c.Request().Context(), user.ID, &current.ID); err != nil {
+		h.logger.Warn("Failed to revoke sessions", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	c.Response().Header().Set("HX-Redirect", "/sessions")
+	return c.NoContent(http.StatusOK)
+}
+
 // ShowJobs displays the job monitoring page (admin only)
 func (h *Handlers) ShowJobs(c echo.Context) error {
 	user, err := h.getUserFromSession(c)
@@ -4,27 +4,39 @@ import (
 	"net/http"
 
 	"hermit/internal/auth"
-	"hermit/internal/repositories"
+	"hermit/internal/service"
 
 	"github.com/a-h/templ"
 	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
 )
 
 // SetupRoutes configures the routes for the web interface.
 func SetupRoutes(
 	e *echo.Echo,
 	authService *auth.Service,
-	websiteRepo *repositories.WebsiteRepository,
-	apiKeyRepo *repositories.APIKeyRepository,
-	userRepo *repositories.UserRepository,
+	apiKeyRepo APIKeyStore,
+	websites *service.WebsiteService,
+	queries *service.QueryService,
+	logger *zap.Logger,
 ) {
 	// Create handlers
-	h := NewHandlers(authService, websiteRepo, apiKeyRepo, userRepo)
+	h := NewHandlers(authService, apiKeyRepo, websites, queries, logger)
 
 	// Use the embedded file system for static assets
 	assetHandler := http.FileServer(http.FS(Files))
 	e.GET("/assets/*", echo.WrapHandler(assetHandler))
 
+	// Embeddable chat widget, see the widget API routes
+	e.GET("/widget.js", func(c echo.Context) error {
+		script, err := Files.ReadFile("assets/js/widget.js")
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+		return c.Blob(http.StatusOK, "text/javascript; charset=utf-8", script)
+	})
+
 	// Public routes
 	e.GET("/", func(c echo.Context) error {
 		return c.Redirect(http.StatusFound, "/login")
@@ -39,8 +51,13 @@ func SetupRoutes(
 	protected := e.Group("")
 	protected.Use(h.AuthMiddleware)
 	protected.GET("/chat", h.ShowChat)
+	protected.GET("/chat/ws", h.HandleChatSocket)
+	protected.GET("/chat/suggestions", h.ShowChatSuggestions)
 	protected.GET("/websites", h.ShowWebsites)
 	protected.GET("/api-keys", h.ShowAPIKeys)
+	protected.GET("/sessions", h.ShowSessions)
+	protected.POST("/sessions/revoke-others", h.HandleRevokeOtherSessions)
+	protected.POST("/sessions/:id/revoke", h.HandleRevokeSession)
 
 	// Admin routes (require admin role)
 	admin := e.Group("")